@@ -18,18 +18,21 @@ sed -i 's!^import.*api/packrd"$!import _ "github.com/upmio/dbscale-kube/cluster_
 cd ${CWD}
 cd cluster_manager/apiserver && go get . && go build -ldflags "-X github.com/upmio/dbscale-kube/pkg/vars.GITCOMMIT=$VERSION -X \"github.com/upmio/dbscale-kube/pkg/vars.BUILDTIME=${BUILDTIME}\"" || die "!!! build cluster_manager/apiserver failed"
 cd ${CWD}
+cd cluster_manager/dbscalectl && go build -ldflags "-X github.com/upmio/dbscale-kube/pkg/vars.GITCOMMIT=$VERSION -X \"github.com/upmio/dbscale-kube/pkg/vars.BUILDTIME=${BUILDTIME}\"" || die "!!! build cluster_manager/dbscalectl failed"
+cd ${CWD}
 cd cluster_engine/controller-manager && go get . && go build -ldflags "-X github.com/upmio/dbscale-kube/pkg/vars.GITCOMMIT=$VERSION -X \"github.com/upmio/dbscale-kube/pkg/vars.BUILDTIME=${BUILDTIME}\"" || die "!!! build cluster_engine/controller-manager failed"
 cd ${CWD}
 cd cluster_engine/agent-manager && go get . && go build -ldflags "-X github.com/upmio/dbscale-kube/pkg/vars.GITCOMMIT=$VERSION -X \"github.com/upmio/dbscale-kube/pkg/vars.BUILDTIME=${BUILDTIME}\"" || die "!!! build cluster_engine/agent-manager failed"
 cd ${CWD}
 
-\rm -f CM-apiserver CE-controller-manager CE-controller-agent
+\rm -f CM-apiserver CM-dbscalectl CE-controller-manager CE-controller-agent
 
 mv cluster_manager/apiserver/apiserver CM-apiserver
+mv cluster_manager/dbscalectl/dbscalectl CM-dbscalectl
 # mv cluster_engine/controller-manager/controller-manager CE-controller-manager
 # mv cluster_engine/agent-manager/agent-manager CE-controller-agent
 
 strip C[EM]*
 rm -rf a.zip
 # zip -9 a.zip CM-apiserver CE-controller-manager CE-controller-agent
-zip -9 a.zip CM-apiserver
+zip -9 a.zip CM-apiserver CM-dbscalectl
@@ -8,10 +8,15 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	netclientset "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/clientset/versioned"
+	netScheme "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/clientset/versioned/scheme"
+	netInformers "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/informers/externalversions"
+	netlisters "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/listers/networking/v1alpha1"
 	clientset "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/clientset/versioned"
 	vpScheme "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/clientset/versioned/scheme"
 	vpInformers "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/informers/externalversions"
 	listers "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/listers/volumepath/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"golang.org/x/time/rate"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -44,18 +49,26 @@ type Controller struct {
 	nodeSynced cache.InformerSynced
 	nodeQueue  workqueue.RateLimitingInterface
 
+	netClientSet       netclientset.Interface
+	networkClaimLister netlisters.NetworkClaimLister
+	networkClaimSynced cache.InformerSynced
+	claimQueue         workqueue.RateLimitingInterface
+
 	recorder record.EventRecorder
 }
 
 func NewController(kubeclientset kubernetes.Interface,
 	vpClient clientset.Interface,
+	netClient netclientset.Interface,
 	vpInformerFactory vpInformers.SharedInformerFactory,
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
+	netInformerFactory netInformers.SharedInformerFactory,
 	shellDir, hostname string) *Controller {
 	// Create event broadcaster
 	// Add sample-controller types to the default Kubernetes Scheme so Events can be
 	// logged for sample-controller types.
 	vpScheme.AddToScheme(scheme.Scheme)
+	netScheme.AddToScheme(scheme.Scheme)
 	klog.V(4).Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
@@ -65,6 +78,7 @@ func NewController(kubeclientset kubernetes.Interface,
 
 	vpInformer := vpInformerFactory.Lvm().V1alpha1().VolumePaths()
 	nodesInformer := kubeInformerFactory.Core().V1().Nodes()
+	claimInformer := netInformerFactory.Networking().V1alpha1().NetworkClaims()
 
 	controller := &Controller{
 		kubeclientset: kubeclientset,
@@ -85,6 +99,11 @@ func NewController(kubeclientset kubernetes.Interface,
 		nodeLister: nodesInformer.Lister(),
 		nodeSynced: nodesInformer.Informer().HasSynced,
 		nodeQueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "nodes"),
+
+		netClientSet:       netClient,
+		networkClaimLister: claimInformer.Lister(),
+		networkClaimSynced: claimInformer.Informer().HasSynced,
+		claimQueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "NetworkClaims"),
 	}
 	klog.Info("Setting up event handlers")
 
@@ -111,6 +130,11 @@ func NewController(kubeclientset kubernetes.Interface,
 		UpdateFunc: func(oldObj, newObj interface{}) { controller.enqueueWork(controller.nodeQueue, newObj) },
 	})
 
+	claimInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { controller.enqueueWork(controller.claimQueue, obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) { controller.enqueueWork(controller.claimQueue, newObj) },
+	})
+
 	return controller
 }
 
@@ -118,13 +142,14 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
 	defer c.VpQueue.ShutDown()
 	defer c.nodeQueue.ShutDown()
+	defer c.claimQueue.ShutDown()
 
 	// Start the informer factories to begin populating the informer caches
 	klog.Info("Starting seed controller")
 	klog.Infof("Waiting for caches to sync for volumepaths")
 
-	if !cache.WaitForCacheSync(stopCh, c.nodeSynced, c.VolumePathSynced) {
-		return fmt.Errorf("Unable to sync caches for volumepaths,nodes")
+	if !cache.WaitForCacheSync(stopCh, c.nodeSynced, c.VolumePathSynced, c.networkClaimSynced) {
+		return fmt.Errorf("Unable to sync caches for volumepaths,nodes,networkclaims")
 	}
 
 	klog.Info("Starting workers")
@@ -135,6 +160,8 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 		go wait.Until(c.nodesRunWorker, 5*time.Second, stopCh)
 	}
 
+	go wait.Until(c.claimsRunWorker, 5*time.Second, stopCh)
+
 	//go wait.Until(c.checkMount, 5*time.Second, stopCh)
 
 	klog.Info("Started workers")
@@ -196,6 +223,7 @@ func (c *Controller) vpsRunWorker() {
 			}
 
 			if err := c.volumePathHandler(key); err != nil {
+				metrics.ReconcileError("volumepath", err)
 
 				if c.VpQueue.NumRequeues(key) < maxRetries {
 					c.VpQueue.AddRateLimited(key)
@@ -251,6 +279,7 @@ func (c *Controller) nodesRunWorker() {
 			}
 
 			if err := c.SyncNodeHandle(key); err != nil {
+				metrics.ReconcileError("node", err)
 
 				if c.nodeQueue.NumRequeues(key) < maxRetries {
 					c.nodeQueue.AddRateLimited(key)
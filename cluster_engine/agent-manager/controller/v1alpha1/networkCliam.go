@@ -1 +1,160 @@
 package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	stderr "errors"
+	"fmt"
+	"path/filepath"
+
+	netv1 "github.com/upmio/dbscale-kube/pkg/apis/networking/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
+	"github.com/upmio/dbscale-kube/pkg/utils/exec"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	//与cni插件配置的shellDir一致
+	netdevShellFile = "netdevMGR"
+)
+
+var errUnknownNetns = stderr.New("unknown netns of the network claim")
+
+type bandwidthCfg struct {
+	IfName    string `json:"kube_dev_name"`
+	Netns     string `json:"network_namespace"`
+	Device    string `json:"native_dev"`
+	Bandwidth int32  `json:"bandwidth_Mb"`
+}
+
+// 在线调整本节点上pod网卡的带宽，使status.curBandwidth与spec.bandwidth一致
+func (c *Controller) networkClaimHandler(key string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	claim, err := c.networkClaimLister.Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	cmd, ok, err := bandwidthCmd(c.ShellDir, c.HostName, claim)
+	if err == errUnknownNetns {
+		klog.V(2).Infof("%s: unknown netns,skip set bandwidth %d", key, claim.Spec.Bandwidth)
+		return nil
+	}
+	if err != nil || !ok {
+		return err
+	}
+
+	err = exec.CommonShellExec(cmd, defaultTimeout, nil, nil)
+	if err != nil {
+		c.recorder.Eventf(claim, corev1.EventTypeWarning, "SetBandwidthFail", "set bandwidth %d->%d fail:%s", claim.Status.CurBandwidth, claim.Spec.Bandwidth, err.Error())
+		return err
+	}
+
+	toUpdate := claim.DeepCopy()
+	toUpdate.Status.CurBandwidth = claim.Spec.Bandwidth
+
+	_, err = c.netClientSet.NetworkingV1alpha1().NetworkClaims().UpdateStatus(context.TODO(), toUpdate, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("%s:UpdateStatus curBandwidth fail:%s", key, err.Error())
+	}
+
+	c.recorder.Eventf(claim, corev1.EventTypeNormal, "SetBandwidth", "set bandwidth %d->%d on %s", claim.Status.CurBandwidth, claim.Spec.Bandwidth, c.HostName)
+
+	return nil
+}
+
+// bandwidthCmd 返回调整本节点claim带宽的netdevMGR命令，带宽为0表示不限速，
+// 不属于本节点或带宽不变时返回false
+func bandwidthCmd(shellDir, host string, claim *netv1.NetworkClaim) ([]string, bool, error) {
+	if claim.Status.Host != host ||
+		claim.Status.Status != netv1.Using ||
+		claim.Spec.Mode == netv1.CalicoNetworkMode ||
+		claim.Spec.Bandwidth == claim.Status.CurBandwidth {
+		return nil, false, nil
+	}
+
+	//由旧版本插件创建或插件记录失败，等待pod重建
+	if claim.Status.Netns == "" || claim.Status.IfName == "" {
+		return nil, false, errUnknownNetns
+	}
+
+	cfg, err := json.Marshal(bandwidthCfg{
+		IfName:    claim.Status.IfName,
+		Netns:     claim.Status.Netns,
+		Device:    claim.Status.HostDevice,
+		Bandwidth: claim.Spec.Bandwidth,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return []string{filepath.Join(shellDir, netdevShellFile), "network", "bandwidth", string(cfg)}, true, nil
+}
+
+func (c *Controller) claimsRunWorker() {
+
+	klog.V(4).Infoln("claimsRunWorker start..")
+
+	workFunc := func() bool {
+
+		obj, shutdown := c.claimQueue.Get()
+
+		if shutdown {
+			return true
+		}
+
+		err := func(obj interface{}) error {
+
+			defer c.claimQueue.Done(obj)
+			var key string
+			var ok bool
+
+			if key, ok = obj.(string); !ok {
+				c.claimQueue.Forget(obj)
+				return fmt.Errorf("expected string in claimQueue but got %#v", obj)
+			}
+
+			if err := c.networkClaimHandler(key); err != nil {
+				metrics.ReconcileError("agent-networkclaim", err)
+
+				if c.claimQueue.NumRequeues(key) < maxRetries {
+					c.claimQueue.AddRateLimited(key)
+					return fmt.Errorf("error networkClaimHandler %s:%s  ", key, err.Error())
+				}
+
+				c.claimQueue.Forget(obj)
+				return fmt.Errorf("error networkClaimHandler '%s': %s and queue forget", key, err.Error())
+			}
+
+			c.claimQueue.Forget(obj)
+			return nil
+
+		}(obj)
+
+		if err != nil {
+			runtime.HandleError(err)
+			return false
+		}
+
+		return false
+	}
+
+	for !workFunc() {
+	}
+
+	klog.Infoln("claimsRunWorker worker shutting down")
+	return
+}
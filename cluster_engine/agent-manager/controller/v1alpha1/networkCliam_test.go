@@ -0,0 +1,83 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	netv1 "github.com/upmio/dbscale-kube/pkg/apis/networking/v1alpha1"
+)
+
+func TestBandwidthCmd(t *testing.T) {
+	claim := func(mutate func(c *netv1.NetworkClaim)) *netv1.NetworkClaim {
+		c := &netv1.NetworkClaim{}
+		c.Spec.Mode = netv1.SriovNetworkMode
+		c.Spec.Bandwidth = 200
+		c.Status.Host = "node1"
+		c.Status.Status = netv1.Using
+		c.Status.HostDevice = "eth0v1"
+		c.Status.CurBandwidth = 100
+		c.Status.Netns = "/proc/1234/ns/net"
+		c.Status.IfName = "eth1"
+
+		if mutate != nil {
+			mutate(c)
+		}
+
+		return c
+	}
+
+	cases := []struct {
+		name  string
+		claim *netv1.NetworkClaim
+		apply bool
+		err   error
+		// 期望的 bandwidth_Mb
+		bandwidth int32
+	}{
+		{name: "changed", claim: claim(nil), apply: true, bandwidth: 200},
+		{name: "unchanged", claim: claim(func(c *netv1.NetworkClaim) { c.Spec.Bandwidth = 100 })},
+		{name: "other host", claim: claim(func(c *netv1.NetworkClaim) { c.Status.Host = "node2" })},
+		{name: "not using", claim: claim(func(c *netv1.NetworkClaim) { c.Status.Status = netv1.Passing })},
+		{name: "calico", claim: claim(func(c *netv1.NetworkClaim) { c.Spec.Mode = netv1.CalicoNetworkMode })},
+		{name: "unknown netns", claim: claim(func(c *netv1.NetworkClaim) { c.Status.Netns = "" }), err: errUnknownNetns},
+		{name: "unknown ifname", claim: claim(func(c *netv1.NetworkClaim) { c.Status.IfName = "" }), err: errUnknownNetns},
+		{name: "unlimited", claim: claim(func(c *netv1.NetworkClaim) { c.Spec.Bandwidth = 0 }), apply: true, bandwidth: 0},
+		{name: "limit unlimited", claim: claim(func(c *netv1.NetworkClaim) { c.Status.CurBandwidth = 0 }), apply: true, bandwidth: 200},
+	}
+
+	for _, c := range cases {
+		cmd, ok, err := bandwidthCmd("/opt/scripts", "node1", c.claim)
+		if err != c.err || ok != c.apply {
+			t.Errorf("%s: expect apply %t err %v,got %t %v", c.name, c.apply, c.err, ok, err)
+			continue
+		}
+
+		if !ok {
+			if cmd != nil {
+				t.Errorf("%s: expect no command,got %v", c.name, cmd)
+			}
+			continue
+		}
+
+		if want := []string{"/opt/scripts/netdevMGR", "network", "bandwidth"}; len(cmd) != 4 || !reflect.DeepEqual(cmd[:3], want) {
+			t.Errorf("%s: unexpected command %v", c.name, cmd)
+			continue
+		}
+
+		cfg := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(cmd[3]), &cfg); err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+
+		want := map[string]interface{}{
+			"kube_dev_name":     "eth1",
+			"network_namespace": "/proc/1234/ns/net",
+			"native_dev":        "eth0v1",
+			"bandwidth_Mb":      float64(c.bandwidth),
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%s: expect %v,got %v", c.name, want, cfg)
+		}
+	}
+}
@@ -112,8 +112,18 @@ func (c *Controller) SyncNodeHandle(key string) error {
 
 	setComponentInfo(execfile, maps)
 	setMaxUnitInfo(execfile, networkmode, maps)
+
+	var sriovErr error
+	if networkmode == sriovNetworkMode {
+		sriovErr = c.syncSriov(node, maps)
+	}
+
 	err = c.updateNodeAnnotationInfo(node, maps)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return sriovErr
 }
 
 func (c *Controller) updateNodeAnnotationInfo(node *corev1.Node, maps map[string]string) error {
@@ -0,0 +1,206 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/utils/exec"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	log "k8s.io/klog/v2"
+)
+
+const sriovNetworkMode = "sriov"
+
+type netDevice struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Link string `json:"link"`
+}
+
+type vfInventory struct {
+	PFs []hostv1.SriovPF `json:"pfs"`
+	//宿主机网络命名空间中的网卡，已分配给pod的不在其中
+	Devices []netDevice `json:"devices"`
+}
+
+type vfSetCfg struct {
+	PF       string `json:"pf"`
+	Index    int    `json:"index"`
+	Vlan     *int   `json:"vlan,omitempty"`
+	SpoofChk *bool  `json:"spoofchk,omitempty"`
+	Rate     *int   `json:"rate,omitempty"`
+}
+
+// 同步VF配置并发布sriov资源清单，MaxUnit取已使用及可用(link up)网卡数量之和
+func (c *Controller) syncSriov(node *corev1.Node, maps map[string]string) error {
+	execfile := filepath.Join(c.ShellDir, netdevShellFile)
+
+	inv, err := getVFInventory(execfile)
+	if err != nil {
+		return err
+	}
+
+	changed, reconcileErr := reconcileVFConfig(execfile, node, inv.PFs)
+	if changed {
+		inv, err = getVFInventory(execfile)
+		if err != nil {
+			return err
+		}
+	}
+
+	used, err := c.usedNetDevices()
+	if err != nil {
+		return err
+	}
+
+	out := summarizeVFInventory(inv, used)
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	maps[hostv1.SriovInventoryAnnotation] = string(data)
+	maps[hostv1.HostMaxUnitAnnotation] = fmt.Sprint(out.UsedDevices + out.FreeDevices)
+
+	return reconcileErr
+}
+
+func getVFInventory(execfile string) (vfInventory, error) {
+	inv := vfInventory{}
+	err := exec.CommonShellExec([]string{execfile, "vf", "inventory"}, defaultTimeout, nil, &inv)
+
+	return inv, err
+}
+
+// 本节点上networkclaim使用的网卡
+func (c *Controller) usedNetDevices() (map[string]struct{}, error) {
+	claims, err := c.networkClaimLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]struct{})
+	for _, claim := range claims {
+		if claim.Status.Host == c.HostName && claim.Status.HostDevice != "" {
+			used[claim.Status.HostDevice] = struct{}{}
+		}
+	}
+
+	return used, nil
+}
+
+func summarizeVFInventory(inv vfInventory, used map[string]struct{}) hostv1.SriovInventory {
+	out := hostv1.SriovInventory{
+		PFs:         inv.PFs,
+		UsedDevices: len(used),
+	}
+
+	for i := range out.PFs {
+		for j := range out.PFs[i].VFs {
+			vf := &out.PFs[i].VFs[j]
+			vf.Used = vf.Netdev == ""
+
+			out.TotalVFs++
+			if vf.Used {
+				out.UsedVFs++
+			}
+		}
+	}
+	out.FreeVFs = out.TotalVFs - out.UsedVFs
+
+	for _, dev := range inv.Devices {
+		if _, ok := used[dev.Name]; ok {
+			continue
+		}
+
+		if dev.Link == "up" {
+			out.FreeDevices++
+		} else {
+			out.DownDevices++
+		}
+	}
+	out.Devices = out.UsedDevices + out.FreeDevices + out.DownDevices
+
+	return out
+}
+
+// 按node annotation中期望的配置调整VF，返回是否有变更
+func reconcileVFConfig(execfile string, node *corev1.Node, pfs []hostv1.SriovPF) (bool, error) {
+	value, ok := node.Annotations[hostv1.SriovVFConfigAnnotation]
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	configs := []hostv1.SriovVFConfig{}
+	if err := json.Unmarshal([]byte(value), &configs); err != nil {
+		return false, fmt.Errorf("%s:%s annotation Unmarshal fail:%s (data:%s)", node.GetName(), hostv1.SriovVFConfigAnnotation, err, value)
+	}
+
+	changed := false
+	errs := []error{}
+
+	for _, cfg := range configs {
+		var pf *hostv1.SriovPF
+		for i := range pfs {
+			if pfs[i].Name == cfg.PF {
+				pf = &pfs[i]
+				break
+			}
+		}
+
+		if pf == nil {
+			errs = append(errs, fmt.Errorf("not find sriov pf %s", cfg.PF))
+			continue
+		}
+
+		for _, vf := range pf.VFs {
+			if cfg.VF != nil && *cfg.VF != vf.Index {
+				continue
+			}
+
+			set, ok := diffVFConfig(cfg, vf)
+			if !ok {
+				continue
+			}
+
+			set.PF = pf.Name
+			if err := exec.CommonShellExec([]string{execfile, "vf", "set"}, defaultTimeout, set, nil); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			log.Infof("%s: vf %d of %s is reconciled", node.GetName(), vf.Index, pf.Name)
+			changed = true
+		}
+	}
+
+	return changed, utilerrors.NewAggregate(errs)
+}
+
+func diffVFConfig(cfg hostv1.SriovVFConfig, vf hostv1.SriovVF) (vfSetCfg, bool) {
+	set := vfSetCfg{Index: vf.Index}
+	diff := false
+
+	if cfg.Vlan != nil && *cfg.Vlan != vf.Vlan {
+		set.Vlan = cfg.Vlan
+		diff = true
+	}
+
+	if cfg.SpoofChk != nil && *cfg.SpoofChk != vf.SpoofChk {
+		set.SpoofChk = cfg.SpoofChk
+		diff = true
+	}
+
+	//已使用(不在宿主机网络命名空间)VF的速率由networkclaim带宽控制
+	if cfg.Rate != nil && vf.Netdev != "" && *cfg.Rate != vf.Rate {
+		set.Rate = cfg.Rate
+		diff = true
+	}
+
+	return set, diff
+}
@@ -0,0 +1,145 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+)
+
+func TestSummarizeVFInventory(t *testing.T) {
+	cases := []struct {
+		name string
+		inv  vfInventory
+		used map[string]struct{}
+		want hostv1.SriovInventory
+	}{
+		{
+			name: "empty",
+			inv:  vfInventory{},
+			used: map[string]struct{}{},
+			want: hostv1.SriovInventory{},
+		},
+		{
+			name: "vfs in pod are used",
+			inv: vfInventory{
+				PFs: []hostv1.SriovPF{
+					{Name: "eth0", VFs: []hostv1.SriovVF{{Index: 0, Netdev: "eth0v0"}, {Index: 1}}},
+					{Name: "eth1", VFs: []hostv1.SriovVF{{Index: 0}, {Index: 1}, {Index: 2, Netdev: "eth1v2"}}},
+				},
+			},
+			used: map[string]struct{}{},
+			want: hostv1.SriovInventory{
+				TotalVFs: 5,
+				UsedVFs:  3,
+				FreeVFs:  2,
+			},
+		},
+		{
+			name: "devices",
+			inv: vfInventory{
+				Devices: []netDevice{
+					{Name: "bond0", Link: "up"},
+					{Name: "bond1", Link: "down"},
+					{Name: "bond2", Link: "up"},
+					{Name: "bond3", Link: "down"},
+				},
+			},
+			used: map[string]struct{}{"bond0": {}, "bond1": {}, "bond9": {}},
+			want: hostv1.SriovInventory{
+				Devices:     5,
+				UsedDevices: 3,
+				FreeDevices: 1,
+				DownDevices: 1,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		got := summarizeVFInventory(c.inv, c.used)
+		got.PFs = nil
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expect %+v,got %+v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestSummarizeVFInventoryMarkUsed(t *testing.T) {
+	inv := vfInventory{
+		PFs: []hostv1.SriovPF{
+			{Name: "eth0", VFs: []hostv1.SriovVF{{Index: 0, Netdev: "eth0v0"}, {Index: 1}}},
+		},
+	}
+
+	out := summarizeVFInventory(inv, nil)
+
+	if vfs := out.PFs[0].VFs; vfs[0].Used || !vfs[1].Used {
+		t.Fatalf("expect only vf 1 used,got %+v", vfs)
+	}
+}
+
+func TestDiffVFConfig(t *testing.T) {
+	vlan, vlan0 := 100, 0
+	rate, rate0 := 1000, 0
+	on, off := true, false
+
+	free := hostv1.SriovVF{Index: 1, Vlan: 0, SpoofChk: true, Rate: 0, Netdev: "eth0v1"}
+	inPod := hostv1.SriovVF{Index: 2, Vlan: 0, SpoofChk: true, Rate: 0}
+
+	cases := []struct {
+		name string
+		cfg  hostv1.SriovVFConfig
+		vf   hostv1.SriovVF
+		want vfSetCfg
+		diff bool
+	}{
+		{
+			name: "nil fields",
+			cfg:  hostv1.SriovVFConfig{},
+			vf:   free,
+			want: vfSetCfg{Index: 1},
+		},
+		{
+			name: "same",
+			cfg:  hostv1.SriovVFConfig{Vlan: &vlan0, SpoofChk: &on, Rate: &rate0},
+			vf:   free,
+			want: vfSetCfg{Index: 1},
+		},
+		{
+			name: "vlan and spoofchk",
+			cfg:  hostv1.SriovVFConfig{Vlan: &vlan, SpoofChk: &off},
+			vf:   free,
+			want: vfSetCfg{Index: 1, Vlan: &vlan, SpoofChk: &off},
+			diff: true,
+		},
+		{
+			name: "rate of free vf",
+			cfg:  hostv1.SriovVFConfig{Rate: &rate},
+			vf:   free,
+			want: vfSetCfg{Index: 1, Rate: &rate},
+			diff: true,
+		},
+		{
+			name: "rate of vf in pod",
+			cfg:  hostv1.SriovVFConfig{Rate: &rate},
+			vf:   inPod,
+			want: vfSetCfg{Index: 2},
+		},
+		{
+			name: "vlan of vf in pod",
+			cfg:  hostv1.SriovVFConfig{Vlan: &vlan, Rate: &rate},
+			vf:   inPod,
+			want: vfSetCfg{Index: 2, Vlan: &vlan},
+			diff: true,
+		},
+	}
+
+	for _, c := range cases {
+		got, diff := diffVFConfig(c.cfg, c.vf)
+
+		if diff != c.diff || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expect %+v,%t,got %+v,%t", c.name, c.want, c.diff, got, diff)
+		}
+	}
+}
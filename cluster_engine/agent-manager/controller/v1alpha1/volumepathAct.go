@@ -8,6 +8,7 @@ import (
 	"time"
 
 	vpv1 "github.com/upmio/dbscale-kube/pkg/apis/volumepath/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"k8s.io/klog/v2"
 )
 
@@ -151,7 +152,9 @@ func vpCommonAct(shellfile, model, act string, cfg *VpActCfg, timeout time.Durat
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	start := time.Now()
 	out, err := exec.CommandContext(ctx, shellfile, args...).CombinedOutput()
+	metrics.ObserveScript("VPMGR", model+"_"+act, start, err)
 	if err != nil {
 		return nil, fmt.Errorf("%s fail:%s,%s(cmd: %s %v)", act, err.Error(), string(out), shellfile, args)
 	}
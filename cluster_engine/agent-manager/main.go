@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	netclientset "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/clientset/versioned"
+	netinformers "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/informers/externalversions"
 	clientset "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/clientset/versioned"
 	informers "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/informers/externalversions"
 	"github.com/upmio/dbscale-kube/pkg/vars"
@@ -15,6 +17,7 @@ import (
 	"k8s.io/klog/v2"
 
 	agent "github.com/upmio/dbscale-kube/cluster_engine/agent-manager/controller/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"github.com/upmio/dbscale-kube/pkg/signals"
 )
 
@@ -24,6 +27,7 @@ var (
 	hostname    string
 	shellDir    string
 	versionFlag bool
+	metricsAddr string
 )
 
 func init() {
@@ -32,6 +36,7 @@ func init() {
 	flag.StringVar(&hostname, "hostname", "", "the host name.(can't be empty)")
 	flag.StringVar(&shellDir, "shelldir", "/tmp/scripts/", "the shellDir.")
 	flag.BoolVar(&versionFlag, "version", false, "show the version ")
+	flag.StringVar(&metricsAddr, "metrics-addr", metricsAddr, "the address /metrics serves on, empty means disabled.")
 }
 
 func main() {
@@ -48,6 +53,8 @@ func main() {
 		klog.Fatal("the hostname must be set.(which is same as the kubelet hostName)")
 	}
 
+	metrics.Serve(metricsAddr)
+
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
 
@@ -66,12 +73,19 @@ func main() {
 		klog.Fatalf("Error building vp clientset: %s", err.Error())
 	}
 
+	netClient, err := netclientset.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("Error building network clientset: %s", err.Error())
+	}
+
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
 	vpInformerFactory := informers.NewSharedInformerFactory(vpClient, time.Second*30)
-	controller := agent.NewController(kubeClient, vpClient, vpInformerFactory, kubeInformerFactory, shellDir, hostname)
+	netInformerFactory := netinformers.NewSharedInformerFactory(netClient, time.Second*30)
+	controller := agent.NewController(kubeClient, vpClient, netClient, vpInformerFactory, kubeInformerFactory, netInformerFactory, shellDir, hostname)
 
 	go kubeInformerFactory.Start(stopCh)
 	go vpInformerFactory.Start(stopCh)
+	go netInformerFactory.Start(stopCh)
 
 	if err = controller.Run(5, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
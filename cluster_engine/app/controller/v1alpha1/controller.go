@@ -0,0 +1,215 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	appv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/app/v1alpha1"
+	appclientset "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/clientset/versioned"
+	appscheme "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/clientset/versioned/scheme"
+	appinformers "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/informers/externalversions/app/v1alpha1"
+	applisters "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/listers/app/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
+)
+
+const (
+	maxRetries = 5
+
+	// 任务执行中时重新检查的间隔
+	defaultTaskInterval = 30 * time.Second
+	requestTimeout      = time.Minute
+)
+
+// Manager 由 cluster_manager 的 api/client/v1.Client 实现，
+// App 的编排仍由 cluster_manager 完成，operator 只提交 manifest 并跟踪任务
+type Manager interface {
+	PlanAppManifest(ctx context.Context, query api.SubscriptionQuery, body api.AppManifest) (api.AppManifestPlan, error)
+	ApplyAppManifest(ctx context.Context, query api.SubscriptionQuery, body api.AppManifest) (api.AppManifestPlan, error)
+
+	ListApps(ctx context.Context, query api.AppListQuery) (api.AppsResponse, error)
+	DeleteApp(ctx context.Context, app string, query api.SubscriptionQuery) error
+
+	ListTasks(ctx context.Context, query api.TaskListQuery) (api.TasksResponse, error)
+}
+
+// Controller is the controller implementation for App and BackupStrategy resources
+type Controller struct {
+	appClientset appclientset.Interface
+	manager      Manager
+	recorder     record.EventRecorder
+
+	appLister applisters.AppLister
+	appSynced cache.InformerSynced
+
+	strategyLister applisters.BackupStrategyLister
+	strategySynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+
+	interval time.Duration
+}
+
+// NewController returns a app controller
+func NewController(
+	kubeclientset kubernetes.Interface,
+	appClientset appclientset.Interface,
+	manager Manager,
+	appInformerFactory appinformers.Interface) *Controller {
+
+	appscheme.AddToScheme(scheme.Scheme)
+	klog.V(4).Info("Creating event broadcaster")
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.Infof)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "appcontroller"})
+
+	appInformer := appInformerFactory.Apps()
+	strategyInformer := appInformerFactory.BackupStrategies()
+
+	controller := &Controller{
+		appClientset: appClientset,
+		manager:      manager,
+		recorder:     recorder,
+
+		appLister:      appInformer.Lister(),
+		appSynced:      appInformer.Informer().HasSynced,
+		strategyLister: strategyInformer.Lister(),
+		strategySynced: strategyInformer.Informer().HasSynced,
+
+		queue: workqueue.NewNamedRateLimitingQueue(workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(5*time.Second, 60*5*time.Second),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+		), "apps"),
+
+		interval: defaultTaskInterval,
+	}
+
+	klog.Info("Setting up event handlers")
+
+	appInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    controller.enqueueApp,
+		UpdateFunc: func(oldObj, newObj interface{}) { controller.enqueueApp(newObj) },
+		DeleteFunc: controller.enqueueApp,
+	})
+
+	strategyInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleStrategy,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.handleStrategy(oldObj)
+			controller.handleStrategy(newObj)
+		},
+		DeleteFunc: controller.handleStrategy,
+	})
+
+	return controller
+}
+
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting app controller")
+	klog.Infof("Waiting for caches to sync for app controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.appSynced, c.strategySynced) {
+		return fmt.Errorf("Unable to sync caches for app controller")
+	}
+
+	klog.Info("Starting workers")
+
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(c.runWorker, time.Second, stopCh)
+	}
+
+	klog.Info("Started workers")
+
+	<-stopCh
+	klog.Info("Shutting down workers")
+	return nil
+}
+
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+
+	err := func(obj interface{}) error {
+		defer c.queue.Done(obj)
+
+		key, ok := obj.(string)
+		if !ok {
+			c.queue.Forget(obj)
+			return fmt.Errorf("expected string in app queue but got %#v", obj)
+		}
+
+		if err := c.syncHandler(key); err != nil {
+			metrics.ReconcileError("app", err)
+
+			if c.queue.NumRequeues(key) < maxRetries {
+				c.queue.AddRateLimited(key)
+				return fmt.Errorf("error syncing app '%s': %s, requeuing", key, err.Error())
+			}
+
+			c.queue.Forget(obj)
+			return fmt.Errorf("error syncing app '%s': %s and queue forget", key, err.Error())
+		}
+
+		c.queue.Forget(obj)
+		klog.V(4).Infof("Successfully synced app '%s'", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		runtime.HandleError(err)
+	}
+
+	return true
+}
+
+func (c *Controller) enqueueApp(obj interface{}) {
+	if unknown, ok := obj.(cache.DeletedFinalStateUnknown); ok && unknown.Obj != nil {
+		obj = unknown.Obj
+	}
+
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("failed to get key from object: %v", err)
+		return
+	}
+
+	c.queue.Add(key)
+}
+
+// BackupStrategy 变化时同步其引用的App
+func (c *Controller) handleStrategy(obj interface{}) {
+	if unknown, ok := obj.(cache.DeletedFinalStateUnknown); ok && unknown.Obj != nil {
+		obj = unknown.Obj
+	}
+
+	strategy, ok := obj.(*appv1alpha1.BackupStrategy)
+	if !ok || strategy.Spec.AppRef == "" {
+		return
+	}
+
+	c.queue.Add(strategy.Namespace + "/" + strategy.Spec.AppRef)
+}
@@ -0,0 +1,330 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	client "github.com/upmio/dbscale-kube/cluster_manager/apiserver/api/client/v1"
+	appv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/app/v1alpha1"
+)
+
+const (
+	taskRunning = "running"
+	taskSuccess = "success"
+)
+
+func (c *Controller) syncHandler(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil
+	}
+
+	app, err := c.appLister.Apps(namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	if app.DeletionTimestamp != nil {
+		return c.deleteApp(ctx, app)
+	}
+
+	want := app.Spec.DeletionPolicy == appv1alpha1.DeletionDelete
+	if want != hasFinalizer(app) {
+		return c.setFinalizer(ctx, app, want)
+	}
+
+	if app.Spec.Suspend {
+		return nil
+	}
+
+	status := app.Status.DeepCopy()
+	status.ObservedGeneration = app.Generation
+
+	if status.Phase == appv1alpha1.PhaseApplying && status.TaskID != "" {
+		task, err := c.getTask(ctx, status.TaskID)
+		if err != nil {
+			return err
+		}
+
+		switch task.Status {
+		case taskRunning:
+			c.queue.AddAfter(key, c.interval)
+			return nil
+
+		case taskSuccess:
+			status.Phase = appv1alpha1.PhasePending
+
+		default:
+			status.Phase = appv1alpha1.PhaseFailed
+			status.Message = fmt.Sprintf("task %s %s:%s", task.ID, task.Status, task.Error)
+			c.recorder.Event(app, corev1.EventTypeWarning, "ApplyFailed", status.Message)
+
+			return c.updateStatus(ctx, app, status)
+		}
+	}
+
+	manifest, err := c.manifest(app)
+	if err != nil {
+		return c.failed(ctx, app, status, err)
+	}
+
+	query := api.SubscriptionQuery{SubscriptionID: app.Spec.SubscriptionID}
+
+	plan, err := c.manager.PlanAppManifest(ctx, query, manifest)
+	if client.StatusCode(err) == http.StatusBadRequest {
+		//manifest 无效，等待对象更新
+		return c.failed(ctx, app, status, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if plan.App.ID != "" {
+		status.AppID = plan.App.ID
+	}
+
+	if len(plan.Steps) == 0 {
+		status.Phase = appv1alpha1.PhaseReady
+		status.Message = ""
+
+		return c.updateStatus(ctx, app, status)
+	}
+
+	plan, err = c.manager.ApplyAppManifest(ctx, query, manifest)
+	if err != nil {
+		return err
+	}
+
+	status.Phase = appv1alpha1.PhaseApplying
+	status.TaskID = plan.Task.ID
+	status.Steps = planSteps(plan)
+	status.Message = ""
+	status.LastApplyTime = metav1.Now()
+
+	c.recorder.Eventf(app, corev1.EventTypeNormal, "Applying", "apply %d steps in task %s", len(plan.Steps), plan.Task.ID)
+	c.queue.AddAfter(key, c.interval)
+
+	return c.updateStatus(ctx, app, status)
+}
+
+// manifest 解析 spec.manifest 并合并引用该 App 的 BackupStrategy
+func (c *Controller) manifest(app *appv1alpha1.App) (api.AppManifest, error) {
+	manifest := api.AppManifest{}
+
+	if len(app.Spec.Manifest.Raw) > 0 {
+		err := json.Unmarshal(app.Spec.Manifest.Raw, &manifest)
+		if err != nil {
+			return manifest, fmt.Errorf("decode spec.manifest:%s", err)
+		}
+	}
+
+	if manifest.Name == "" {
+		manifest.Name = app.Name
+	}
+
+	strategies, err := c.strategies(app)
+	if err != nil {
+		return manifest, err
+	}
+
+	for _, s := range strategies {
+		config := api.BackupStrategyConfig{}
+
+		if len(s.Spec.Strategy.Raw) > 0 {
+			err := json.Unmarshal(s.Spec.Strategy.Raw, &config)
+			if err != nil {
+				return manifest, fmt.Errorf("decode spec.strategy of BackupStrategy %s:%s", s.Name, err)
+			}
+		}
+
+		if config.Name == "" {
+			config.Name = s.Name
+		}
+
+		manifest.BackupStrategies = append(manifest.BackupStrategies, config)
+	}
+
+	return manifest, nil
+}
+
+func (c *Controller) strategies(app *appv1alpha1.App) ([]*appv1alpha1.BackupStrategy, error) {
+	list, err := c.strategyLister.BackupStrategies(app.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*appv1alpha1.BackupStrategy, 0, len(list))
+	for _, s := range list {
+		if s.Spec.AppRef == app.Name && s.DeletionTimestamp == nil {
+			out = append(out, s)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
+
+func (c *Controller) getTask(ctx context.Context, id string) (api.Task, error) {
+	tasks, err := c.manager.ListTasks(ctx, api.TaskListQuery{ID: id})
+	if err != nil {
+		return api.Task{}, err
+	}
+
+	if len(tasks) == 0 {
+		return api.Task{}, fmt.Errorf("not found task %s", id)
+	}
+
+	return tasks[0], nil
+}
+
+func (c *Controller) failed(ctx context.Context, app *appv1alpha1.App, status *appv1alpha1.AppStatus, err error) error {
+	status.Phase = appv1alpha1.PhaseFailed
+	status.Message = err.Error()
+
+	c.recorder.Event(app, corev1.EventTypeWarning, "InvalidManifest", status.Message)
+
+	return c.updateStatus(ctx, app, status)
+}
+
+// updateStatus 更新 App 及其 BackupStrategy 的状态，没有变化时不更新
+func (c *Controller) updateStatus(ctx context.Context, app *appv1alpha1.App, status *appv1alpha1.AppStatus) error {
+	if !reflect.DeepEqual(app.Status, *status) {
+		clone := app.DeepCopy()
+		clone.Status = *status
+
+		_, err := c.appClientset.AppV1alpha1().Apps(app.Namespace).UpdateStatus(ctx, clone, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	strategies, err := c.strategies(app)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range strategies {
+		want := appv1alpha1.BackupStrategyStatus{
+			ObservedGeneration: s.Generation,
+			Phase:              status.Phase,
+			Message:            status.Message,
+		}
+
+		if s.Status == want {
+			continue
+		}
+
+		clone := s.DeepCopy()
+		clone.Status = want
+
+		_, err := c.appClientset.AppV1alpha1().BackupStrategies(s.Namespace).UpdateStatus(ctx, clone, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasFinalizer(app *appv1alpha1.App) bool {
+	for _, f := range app.Finalizers {
+		if f == appv1alpha1.AppFinalizer {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Controller) setFinalizer(ctx context.Context, app *appv1alpha1.App, add bool) error {
+	clone := app.DeepCopy()
+	clone.Finalizers = make([]string, 0, len(app.Finalizers)+1)
+
+	for _, f := range app.Finalizers {
+		if f != appv1alpha1.AppFinalizer {
+			clone.Finalizers = append(clone.Finalizers, f)
+		}
+	}
+
+	if add {
+		clone.Finalizers = append(clone.Finalizers, appv1alpha1.AppFinalizer)
+	}
+
+	_, err := c.appClientset.AppV1alpha1().Apps(app.Namespace).Update(ctx, clone, metav1.UpdateOptions{})
+
+	return err
+}
+
+// deleteApp 删除策略为 Delete 时删除 cluster_manager 中同名的服务，再移除 finalizer
+func (c *Controller) deleteApp(ctx context.Context, app *appv1alpha1.App) error {
+	if !hasFinalizer(app) {
+		return nil
+	}
+
+	if app.Spec.DeletionPolicy == appv1alpha1.DeletionDelete {
+		manifest, err := c.manifest(app)
+		if err != nil {
+			klog.Warningf("App %s/%s:%s", app.Namespace, app.Name, err)
+		}
+
+		name := manifest.Name
+		if name == "" {
+			name = app.Name
+		}
+
+		query := api.SubscriptionQuery{SubscriptionID: app.Spec.SubscriptionID}
+
+		apps, err := c.manager.ListApps(ctx, api.AppListQuery{
+			Name:           name,
+			SubscriptionID: app.Spec.SubscriptionID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range apps {
+			if apps[i].Name != name {
+				continue
+			}
+
+			err := c.manager.DeleteApp(ctx, apps[i].ID, query)
+			if err != nil && !client.IsNotFound(err) {
+				return err
+			}
+
+			c.recorder.Eventf(app, corev1.EventTypeNormal, "Deleted", "delete app %s", apps[i].ID)
+		}
+	}
+
+	return c.setFinalizer(ctx, app, false)
+}
+
+func planSteps(plan api.AppManifestPlan) []string {
+	out := make([]string, len(plan.Steps))
+	for i, step := range plan.Steps {
+		out[i] = step.Action + " " + step.Target
+	}
+
+	return out
+}
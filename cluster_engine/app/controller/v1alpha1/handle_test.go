@@ -0,0 +1,185 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	appv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/app/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/clientset/versioned/fake"
+	applisters "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/listers/app/v1alpha1"
+)
+
+type fakeManager struct {
+	steps   []api.ManifestStep
+	status  string
+	applied []api.AppManifest
+	deleted []string
+}
+
+func (m *fakeManager) PlanAppManifest(ctx context.Context, query api.SubscriptionQuery, body api.AppManifest) (api.AppManifestPlan, error) {
+	return api.AppManifestPlan{App: api.NewIDName("app001", body.Name), Steps: m.steps}, nil
+}
+
+func (m *fakeManager) ApplyAppManifest(ctx context.Context, query api.SubscriptionQuery, body api.AppManifest) (api.AppManifestPlan, error) {
+	m.applied = append(m.applied, body)
+
+	return api.AppManifestPlan{
+		App:   api.NewIDName("app001", body.Name),
+		Steps: m.steps,
+		Task:  api.TaskBrief{ID: "task001"},
+	}, nil
+}
+
+func (m *fakeManager) ListApps(ctx context.Context, query api.AppListQuery) (api.AppsResponse, error) {
+	return api.AppsResponse{{ID: "app001", Name: query.Name}}, nil
+}
+
+func (m *fakeManager) DeleteApp(ctx context.Context, app string, query api.SubscriptionQuery) error {
+	m.deleted = append(m.deleted, app)
+	return nil
+}
+
+func (m *fakeManager) ListTasks(ctx context.Context, query api.TaskListQuery) (api.TasksResponse, error) {
+	return api.TasksResponse{{ID: query.ID, Status: m.status}}, nil
+}
+
+func newTestController(manager Manager, objects ...runtime.Object) (*Controller, *fake.Clientset, cache.Indexer) {
+	client := fake.NewSimpleClientset(objects...)
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	apps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)
+	strategies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)
+
+	for _, obj := range objects {
+		switch obj.(type) {
+		case *appv1alpha1.App:
+			apps.Add(obj)
+		case *appv1alpha1.BackupStrategy:
+			strategies.Add(obj)
+		}
+	}
+
+	c := &Controller{
+		appClientset:   client,
+		manager:        manager,
+		recorder:       record.NewFakeRecorder(10),
+		appLister:      applisters.NewAppLister(apps),
+		strategyLister: applisters.NewBackupStrategyLister(strategies),
+		queue:          workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		interval:       defaultTaskInterval,
+	}
+
+	return c, client, apps
+}
+
+func TestSyncApp(t *testing.T) {
+	app := &appv1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "db01", Namespace: "default", Generation: 2},
+		Spec: appv1alpha1.AppSpec{
+			Manifest: runtime.RawExtension{Raw: []byte(`{"desc":"gitops","prune":true}`)},
+		},
+	}
+	strategy := &appv1alpha1.BackupStrategy{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "default", Generation: 1},
+		Spec: appv1alpha1.BackupStrategySpec{
+			AppRef:   "db01",
+			Strategy: runtime.RawExtension{Raw: []byte(`{"schedule":"0 1 * * *","type":"full"}`)},
+		},
+	}
+
+	manager := &fakeManager{
+		steps:  []api.ManifestStep{{Action: api.ManifestAppCreate, Target: "db01"}},
+		status: taskRunning,
+	}
+
+	c, client, indexer := newTestController(manager, app, strategy)
+	ctx := context.Background()
+
+	err := c.syncHandler("default/db01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manager.applied) != 1 {
+		t.Fatalf("expected 1 apply,got %d", len(manager.applied))
+	}
+
+	manifest := manager.applied[0]
+	if manifest.Name != "db01" || manifest.Desc != "gitops" || !manifest.Prune ||
+		len(manifest.BackupStrategies) != 1 || manifest.BackupStrategies[0].Name != "daily" {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	got, err := client.AppV1alpha1().Apps("default").Get(ctx, "db01", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status.Phase != appv1alpha1.PhaseApplying || got.Status.TaskID != "task001" ||
+		got.Status.ObservedGeneration != 2 || len(got.Status.Steps) != 1 {
+		t.Errorf("unexpected status %+v", got.Status)
+	}
+
+	bs, err := client.AppV1alpha1().BackupStrategies("default").Get(ctx, "daily", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bs.Status.Phase != appv1alpha1.PhaseApplying || bs.Status.ObservedGeneration != 1 {
+		t.Errorf("unexpected strategy status %+v", bs.Status)
+	}
+
+	// 任务执行中，不重复提交
+	indexer.Update(got)
+
+	err = c.syncHandler("default/db01")
+	if err != nil || len(manager.applied) != 1 {
+		t.Fatalf("apply again while task running,%v", err)
+	}
+
+	// 任务完成且没有差异
+	manager.status = taskSuccess
+	manager.steps = nil
+
+	err = c.syncHandler("default/db01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ = client.AppV1alpha1().Apps("default").Get(ctx, "db01", metav1.GetOptions{})
+	if got.Status.Phase != appv1alpha1.PhaseReady || got.Status.AppID != "app001" || len(manager.applied) != 1 {
+		t.Errorf("unexpected status %+v", got.Status)
+	}
+
+	// 删除策略为 Delete 时先添加 finalizer，删除对象时删除服务
+	got.Spec.DeletionPolicy = appv1alpha1.DeletionDelete
+	indexer.Update(got)
+
+	if err := c.syncHandler("default/db01"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ = client.AppV1alpha1().Apps("default").Get(ctx, "db01", metav1.GetOptions{})
+	if !hasFinalizer(got) {
+		t.Fatal("finalizer is not added")
+	}
+
+	now := metav1.Now()
+	got.DeletionTimestamp = &now
+	indexer.Update(got)
+
+	if err := c.syncHandler("default/db01"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ = client.AppV1alpha1().Apps("default").Get(ctx, "db01", metav1.GetOptions{})
+	if hasFinalizer(got) || len(manager.deleted) != 1 || manager.deleted[0] != "app001" {
+		t.Errorf("unexpected deletion %v %v", got.Finalizers, manager.deleted)
+	}
+}
@@ -1,19 +1,23 @@
 package main
 
 import (
+	appctrl "github.com/upmio/dbscale-kube/cluster_engine/app/controller/v1alpha1"
 	hostctrl "github.com/upmio/dbscale-kube/cluster_engine/host/controller/v1alpha1"
 	// imagectrl "github.com/upmio/dbscale-kube/cluster_engine/image/controller/v1alpha1"
 	networkctrl "github.com/upmio/dbscale-kube/cluster_engine/network/controller/v1alpha1"
 	sanctrl "github.com/upmio/dbscale-kube/cluster_engine/storage/controller/v1alpha1"
 	unitctrl "github.com/upmio/dbscale-kube/cluster_engine/unit/v1alpha4"
+
+	managerclient "github.com/upmio/dbscale-kube/cluster_manager/apiserver/api/client/v1"
+	"github.com/upmio/dbscale-kube/pkg/tenant"
 )
 
 type controller interface {
 	Run(threadiness int, stopCh <-chan struct{}) error
 }
 
-func knownControllers(ctx *connects, unit, san, network bool) []controller {
-	controllers := make([]controller, 0, 3)
+func knownControllers(ctx *connects, unit, san, network, app bool) []controller {
+	controllers := make([]controller, 0, 5)
 
 	if san {
 		ctrl := sanctrl.NewController(
@@ -49,6 +53,22 @@ func knownControllers(ctx *connects, unit, san, network bool) []controller {
 			ctx.networkClient,
 			ctx.kubeInformerFactory,
 			ctx.networkInformerFactory)
+		ctrl.SetIPProber(ctx.prober)
+
+		controllers = append(controllers, ctrl)
+	}
+
+	if app {
+		manager := managerclient.NewClient(managerServer, nil)
+		if managerToken != "" {
+			manager.SetHeader(tenant.AdminHeader, managerToken)
+		}
+
+		ctrl := appctrl.NewController(
+			ctx.kubeClient,
+			ctx.appClient,
+			manager,
+			ctx.appInformerFactory.App().V1alpha1())
 
 		controllers = append(controllers, ctrl)
 	}
@@ -10,6 +10,7 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	rest "k8s.io/client-go/rest"
 
+	appv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/app/v1alpha1"
 	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
 	networkv1 "github.com/upmio/dbscale-kube/pkg/apis/networking"
 	sanv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/san/v1alpha1"
@@ -387,6 +388,16 @@ func networkClaimPrintColumnDefinition() []v1apiextensions.CustomResourceColumnD
 			Type:     "string",
 			JSONPath: ".status.hostDevice",
 		},
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "bandwidth",
+			Type:     "integer",
+			JSONPath: ".spec.bandwidth",
+		},
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "curBandwidth",
+			Type:     "integer",
+			JSONPath: ".status.curBandwidth",
+		},
 		v1apiextensions.CustomResourceColumnDefinition{
 			Name:     "Age",
 			Type:     "date",
@@ -425,6 +436,140 @@ func networkPrintColumnDefinition() []v1apiextensions.CustomResourceColumnDefini
 	}
 }
 
+// initAppCRDs 只在启用 app operator 时创建
+func initAppCRDs(config *rest.Config) error {
+	client, err := apiextensions.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0, 2)
+	_, err = client.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), &v1apiextensions.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apiextensions.k8s.io/v1",
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "apps." + appv1alpha1.SchemeGroupVersion.Group,
+		},
+		Spec: v1apiextensions.CustomResourceDefinitionSpec{
+			Group: appv1alpha1.SchemeGroupVersion.Group,
+			Names: v1apiextensions.CustomResourceDefinitionNames{
+				Kind:       "App",
+				ListKind:   "AppList",
+				Plural:     "apps",
+				ShortNames: []string{"dbapp"},
+			},
+			Scope: v1apiextensions.NamespaceScoped,
+			Versions: []v1apiextensions.CustomResourceDefinitionVersion{
+				v1apiextensions.CustomResourceDefinitionVersion{
+					AdditionalPrinterColumns: appPrintColumnDefinition(),
+					Schema: &v1apiextensions.CustomResourceValidation{
+						OpenAPIV3Schema: &v1apiextensions.JSONSchemaProps{
+							XPreserveUnknownFields: &defaultPreserveUnknownFields,
+						},
+					},
+					Name:    appv1alpha1.SchemeGroupVersion.Version,
+					Served:  true,
+					Storage: true,
+					Subresources: &v1apiextensions.CustomResourceSubresources{
+						Status: &v1apiextensions.CustomResourceSubresourceStatus{},
+					},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		errs = append(errs, err)
+	}
+
+	_, err = client.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), &v1apiextensions.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apiextensions.k8s.io/v1",
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "backupstrategies." + appv1alpha1.SchemeGroupVersion.Group,
+		},
+		Spec: v1apiextensions.CustomResourceDefinitionSpec{
+			Group: appv1alpha1.SchemeGroupVersion.Group,
+			Names: v1apiextensions.CustomResourceDefinitionNames{
+				Kind:       "BackupStrategy",
+				ListKind:   "BackupStrategyList",
+				Plural:     "backupstrategies",
+				ShortNames: []string{"dbbs"},
+			},
+			Scope: v1apiextensions.NamespaceScoped,
+			Versions: []v1apiextensions.CustomResourceDefinitionVersion{
+				v1apiextensions.CustomResourceDefinitionVersion{
+					AdditionalPrinterColumns: backupStrategyPrintColumnDefinition(),
+					Schema: &v1apiextensions.CustomResourceValidation{
+						OpenAPIV3Schema: &v1apiextensions.JSONSchemaProps{
+							XPreserveUnknownFields: &defaultPreserveUnknownFields,
+						},
+					},
+					Name:    appv1alpha1.SchemeGroupVersion.Version,
+					Served:  true,
+					Storage: true,
+					Subresources: &v1apiextensions.CustomResourceSubresources{
+						Status: &v1apiextensions.CustomResourceSubresourceStatus{},
+					},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func appPrintColumnDefinition() []v1apiextensions.CustomResourceColumnDefinition {
+	return []v1apiextensions.CustomResourceColumnDefinition{
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "Phase",
+			Type:     "string",
+			JSONPath: ".status.phase",
+		},
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "AppID",
+			Type:     "string",
+			JSONPath: ".status.appID",
+		},
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "Task",
+			Type:     "string",
+			JSONPath: ".status.taskID",
+		},
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
+		},
+	}
+}
+
+func backupStrategyPrintColumnDefinition() []v1apiextensions.CustomResourceColumnDefinition {
+	return []v1apiextensions.CustomResourceColumnDefinition{
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "App",
+			Type:     "string",
+			JSONPath: ".spec.appRef",
+		},
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "Phase",
+			Type:     "string",
+			JSONPath: ".status.phase",
+		},
+		v1apiextensions.CustomResourceColumnDefinition{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
+		},
+	}
+}
+
 func initCSIDriver(kubeClient kubernetes.Interface) error {
 	_, err := kubeClient.StorageV1().CSIDrivers().Get(context.TODO(), lvmv1alpha1.VPCSIDriverName, metav1.GetOptions{})
 	if err != nil && !errors.IsNotFound(err) {
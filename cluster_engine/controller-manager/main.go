@@ -9,6 +9,8 @@ import (
 	"os"
 	"time"
 
+	appclientset "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/clientset/versioned"
+	appinformers "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/informers/externalversions"
 	nwclientset "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/clientset/versioned"
 	nwinformers "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/informers/externalversions"
 	sanclientset "github.com/upmio/dbscale-kube/pkg/client/san/v1alpha1/clientset/versioned"
@@ -17,6 +19,7 @@ import (
 	unitinformers "github.com/upmio/dbscale-kube/pkg/client/unit/v1alpha4/informers/externalversions"
 	lvmclientset "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/clientset/versioned"
 	lvminformers "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/informers/externalversions"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"github.com/upmio/dbscale-kube/pkg/signals"
 	"github.com/upmio/dbscale-kube/pkg/vars"
 	"k8s.io/api/core/v1"
@@ -35,6 +38,7 @@ import (
 	"k8s.io/component-base/logs"
 	"k8s.io/klog/v2"
 
+	networkctrl "github.com/upmio/dbscale-kube/cluster_engine/network/controller/v1alpha1"
 	hostclientset "github.com/upmio/dbscale-kube/pkg/client/host/v1alpha1/clientset/versioned"
 	hostInformers "github.com/upmio/dbscale-kube/pkg/client/host/v1alpha1/informers/externalversions"
 )
@@ -44,10 +48,17 @@ const controllerManagerUserAgent = "dbscale-kube-controller-manager"
 var (
 	versionFlag bool
 	execServer  string
+	metricsAddr string
 	masterURL   string
 	kubeconfig  string
 	script      = "/opt/kube/scripts/StorMGR/StorMGR"
 
+	managerServer string
+	managerToken  string
+
+	networkProbe      = networkctrl.ProbeARP
+	networkProbeIface string
+
 	LeaderElection = &componentbaseconfig.LeaderElectionConfiguration{
 		LeaseDuration: metav1.Duration{Duration: 15 * time.Second},
 		RenewDeadline: metav1.Duration{Duration: 10 * time.Second},
@@ -63,6 +74,11 @@ func init() {
 	flag.BoolVar(&versionFlag, "version", false, "show the version ")
 	flag.StringVar(&script, "scripts", script, "path to storage script dir.")
 	flag.StringVar(&execServer, "exec-server", execServer, "addr of exec service")
+	flag.StringVar(&metricsAddr, "metrics-addr", metricsAddr, "the address /metrics serves on, empty means disabled(exec-server also serves /metrics).")
+	flag.StringVar(&managerServer, "manager-server", managerServer, "the address of cluster_manager apiserver such as http://127.0.0.1:8080, enables the App and BackupStrategy operator.")
+	flag.StringVar(&managerToken, "manager-admin-token", managerToken, "the admin token of cluster_manager apiserver(--tenant-admin-token), the operator manages apps of all subscriptions.")
+	flag.StringVar(&networkProbe, "network-probe", networkProbe, "probe the candidate ip before binding a networkclaim, one of none,icmp,arp.")
+	flag.StringVar(&networkProbeIface, "network-probe-iface", networkProbeIface, "the interface arping sends from, empty means chosen by the route of the ip.")
 
 	flag.BoolVar(&LeaderElection.LeaderElect, "leader-elect", LeaderElection.LeaderElect, ""+
 		"Start a leader election client and gain leadership before "+
@@ -100,6 +116,13 @@ func main() {
 
 	klog.Info("VERSION: ", vars.GITCOMMIT, " ", vars.BUILDTIME, fmt.Sprintf("  '%s'", vars.SeCretAESKey))
 
+	prober, err := networkctrl.NewIPProber(networkProbe, networkProbeIface)
+	if err != nil {
+		klog.Fatalf("Error network probe: %s", err)
+	}
+
+	metrics.Serve(metricsAddr)
+
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
 
@@ -120,6 +143,13 @@ func main() {
 		klog.Fatalf("Error init CRDs: %s", err)
 	}
 
+	if managerServer != "" {
+		err = initAppCRDs(config)
+		if err != nil {
+			klog.Fatalf("Error init app CRDs: %s", err)
+		}
+	}
+
 	err = initCSIDriver(kubeClient)
 	if err != nil {
 		klog.Fatalf("Error init vp csidriver: %s", err)
@@ -148,6 +178,7 @@ func main() {
 		clients := &connects{
 			key:    vars.SeCretAESKey,
 			script: script,
+			prober: prober,
 		}
 
 		err = clients.init(config, 30*time.Second)
@@ -155,7 +186,7 @@ func main() {
 			klog.Fatalf("Error init connects: %s", err)
 		}
 
-		controllers := knownControllers(clients, true, true, true)
+		controllers := knownControllers(clients, true, true, true, managerServer != "")
 
 		clients.Start(controllers, ctx.Done())
 
@@ -220,6 +251,8 @@ type connects struct {
 	key    string
 	script string
 
+	prober networkctrl.IPProber
+
 	config *restclient.Config
 
 	kubeClient          kubernetes.Interface
@@ -239,6 +272,9 @@ type connects struct {
 
 	hostClient          hostclientset.Interface
 	hostInformerFactory hostInformers.SharedInformerFactory
+
+	appClient          appclientset.Interface
+	appInformerFactory appinformers.SharedInformerFactory
 }
 
 func (ctx *connects) init(config *restclient.Config, defaultResync time.Duration) (err error) {
@@ -273,12 +309,18 @@ func (ctx *connects) init(config *restclient.Config, defaultResync time.Duration
 		return fmt.Errorf("Error building host clientset: %s", err)
 	}
 
+	ctx.appClient, err = appclientset.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("Error building app clientset: %s", err)
+	}
+
 	ctx.kubeInformerFactory = kubeinformers.NewSharedInformerFactory(ctx.kubeClient, defaultResync)
 	ctx.networkInformerFactory = nwinformers.NewSharedInformerFactory(ctx.networkClient, defaultResync)
 	ctx.sanInformerFactory = saninformers.NewSharedInformerFactory(ctx.sanClient, defaultResync)
 	ctx.lvminformer = lvminformers.NewSharedInformerFactory(ctx.lvmClient, defaultResync)
 	ctx.unitInformerFactory = unitinformers.NewSharedInformerFactory(ctx.unitClient, defaultResync)
 	ctx.hostInformerFactory = hostInformers.NewSharedInformerFactory(ctx.hostClient, defaultResync)
+	ctx.appInformerFactory = appinformers.NewSharedInformerFactory(ctx.appClient, defaultResync)
 
 	return nil
 }
@@ -291,6 +333,7 @@ func (ctx connects) Start(controllers []controller, stopCh <-chan struct{}) {
 	ctx.unitInformerFactory.Start(stopCh)
 	ctx.lvminformer.Start(stopCh)
 	ctx.hostInformerFactory.Start(stopCh)
+	ctx.appInformerFactory.Start(stopCh)
 
 	for i := range controllers {
 		ctr := controllers[i]
@@ -33,6 +33,7 @@ import (
 	hostScheme "github.com/upmio/dbscale-kube/pkg/client/host/v1alpha1/clientset/versioned/scheme"
 	hostInformers "github.com/upmio/dbscale-kube/pkg/client/host/v1alpha1/informers/externalversions"
 	hostlisters "github.com/upmio/dbscale-kube/pkg/client/host/v1alpha1/listers/host/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 )
 
 const maxRetries = 5
@@ -179,6 +180,7 @@ func (c *Controller) hostManagerWorker() {
 			}
 
 			if err := c.hostManagerHandler(key); err != nil {
+				metrics.ReconcileError("host", err)
 
 				if c.hostQueue.NumRequeues(key) < maxRetries {
 					c.hostQueue.AddRateLimited(key)
@@ -1,16 +1,21 @@
 package v1alpha1
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
 	"github.com/upmio/dbscale-kube/pkg/utils"
 	"github.com/upmio/dbscale-kube/pkg/utils/crypto"
+	"github.com/upmio/dbscale-kube/pkg/utils/sshauth"
 	"github.com/upmio/dbscale-kube/pkg/vars"
+	"k8s.io/client-go/kubernetes"
 	log "k8s.io/klog/v2"
 )
 
@@ -27,6 +32,8 @@ type HostCfg struct {
 	NetworkMode string `json:"network_mode"`
 
 	LocalVGs []LocalVGCfg `json:"vgs"`
+
+	sshauth.AnsibleVars `json:",inline"`
 }
 
 type LocalVGCfg struct {
@@ -34,19 +41,34 @@ type LocalVGCfg struct {
 	Name    string `json:"vg_name"`
 }
 
-func generateHostCfg(host *hostv1.Host) (*HostCfg, error) {
+// generateHostCfg 密钥认证时私钥及known_hosts写入dir，由调用者删除
+func generateHostCfg(client kubernetes.Interface, host *hostv1.Host, dir string) (*HostCfg, error) {
 	hostcfg := HostCfg{}
 	user, err := crypto.AesDecrypto(host.Spec.OsUser, vars.SeCretAESKey)
 	if err != nil {
 		return nil, err
 	}
 
-	password, err := crypto.AesDecrypto(host.Spec.OsPassword, vars.SeCretAESKey)
-	if err != nil {
-		return nil, err
+	if host.Spec.SSH != nil {
+		cred, err := sshauth.Load(context.TODO(), client, user, *host.Spec.SSH)
+		if err != nil {
+			return nil, err
+		}
+
+		addr := net.JoinHostPort(host.Spec.HostIP, strconv.Itoa(int(host.Spec.HostPort)))
+		hostcfg.AnsibleVars, err = cred.WriteAnsibleFiles(dir, addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		password, err := crypto.AesDecrypto(host.Spec.OsPassword, vars.SeCretAESKey)
+		if err != nil {
+			return nil, err
+		}
+
+		hostcfg.SSHPasswod = password
 	}
 
-	hostcfg.SSHPasswod = password
 	hostcfg.SSHUser = user
 	hostcfg.SSHIP = host.Spec.HostIP
 	hostcfg.SSHPort = host.Spec.HostPort
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 
 	"k8s.io/klog/v2"
 	"strconv"
@@ -248,7 +250,13 @@ func (c *Controller) installHandle(host *hostv1.Host) error {
 			return err
 		}
 
-		cfg, err := generateHostCfg(host)
+		dir, err := ioutil.TempDir("", "host-init-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		cfg, err := generateHostCfg(c.kubeclientset, host, dir)
 		if err != nil {
 			c.recorder.Event(host, corev1.EventTypeWarning, "generateHostCfg fail", err.Error())
 
@@ -366,7 +374,13 @@ func (c *Controller) unInstallHandle(host *hostv1.Host) error {
 
 	log.V(2).Infof("%s: uninstall node: unSyncNodeLabel ok", host.GetName())
 
-	cfg, err := generateHostCfg(host)
+	dir, err := ioutil.TempDir("", "host-init-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	cfg, err := generateHostCfg(c.kubeclientset, host, dir)
 	if err != nil {
 		c.recorder.Event(host, corev1.EventTypeWarning, "generateHostCfg fail", err.Error())
 		return fmt.Errorf("generateHostCfg  fail %s", err.Error())
@@ -609,7 +623,8 @@ func (c *Controller) clearNodeLabel(host *hostv1.Host) error {
 	for nannotain, _ := range node.Annotations {
 		switch nannotain {
 		case hostv1.HostMaxUnitAnnotation, hostv1.HostcomponentAnnotation, hostv1.SanInitiatorAnnotation,
-			hostv1.NodeUsageLimtAnnotation, hostv1.VGsAnnotation, hostv1.SanInitiatorTypeAnnotation, hostv1.HostNetworkMode:
+			hostv1.NodeUsageLimtAnnotation, hostv1.VGsAnnotation, hostv1.SanInitiatorTypeAnnotation, hostv1.HostNetworkMode,
+			hostv1.SriovInventoryAnnotation, hostv1.SriovVFConfigAnnotation:
 			find = true
 			delete(node.Annotations, nannotain)
 		default:
@@ -19,6 +19,7 @@ import (
 
 	controller "github.com/upmio/dbscale-kube/cluster_engine/network/controller/v1alpha1"
 	networkv1 "github.com/upmio/dbscale-kube/pkg/apis/networking"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"github.com/upmio/dbscale-kube/pkg/signals"
 	"github.com/upmio/dbscale-kube/pkg/vars"
 	v1beta1apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -30,12 +31,20 @@ var (
 
 	masterURL  string
 	kubeconfig string
+
+	metricsAddr string
+
+	probe      = controller.ProbeARP
+	probeIface string
 )
 
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 	flag.BoolVar(&versionFlag, "version", false, "show the version ")
+	flag.StringVar(&probe, "probe", probe, "probe the candidate ip before binding a networkclaim, one of none,icmp,arp.")
+	flag.StringVar(&probeIface, "probe-iface", probeIface, "the interface arping sends from, empty means chosen by the route of the ip.")
+	flag.StringVar(&metricsAddr, "metrics-addr", metricsAddr, "the address /metrics serves on, empty means disabled.")
 }
 
 func main() {
@@ -46,6 +55,8 @@ func main() {
 		return
 	}
 
+	metrics.Serve(metricsAddr)
+
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
 
@@ -72,7 +83,13 @@ func main() {
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
 	networkingInformerFactory := informers.NewSharedInformerFactory(networkingClient, time.Second*30)
 
+	prober, err := controller.NewIPProber(probe, probeIface)
+	if err != nil {
+		klog.Fatalf("Error building ip prober: %s", err.Error())
+	}
+
 	controller := controller.NewController(kubeClient, networkingClient, kubeInformerFactory, networkingInformerFactory)
+	controller.SetIPProber(prober)
 
 	go kubeInformerFactory.Start(stopCh)
 	go networkingInformerFactory.Start(stopCh)
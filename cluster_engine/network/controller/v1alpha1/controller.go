@@ -10,6 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -18,6 +19,7 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
@@ -27,12 +29,16 @@ import (
 	networkingScheme "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/clientset/versioned/scheme"
 	networkingInformers "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/informers/externalversions"
 	listers "github.com/upmio/dbscale-kube/pkg/client/networking/v1alpha1/listers/networking/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
+	"github.com/upmio/dbscale-kube/pkg/utils"
 	// utilcore "github.com/upmio/dbscale-kube/pkg/utils/core"
 )
 
 var (
 	controllerAgentName = "networking-controller"
 	maxRetries          = 15
+	//隔离记录写入status后，informer缓存同步前不做解除隔离判断
+	quarantineGracePeriod = time.Minute
 )
 
 // Controller is the controller implementation for Networking resources
@@ -60,6 +66,9 @@ type Controller struct {
 	recorder record.EventRecorder
 
 	networkingMgr NetworkingMgrInterface
+
+	//为nil时分配IP不做探测
+	prober IPProber
 }
 
 // NewController returns a networking controller
@@ -109,7 +118,7 @@ func NewController(
 	networkClaimInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		DeleteFunc: controller.deleteNetworkClaimHandle,
 		AddFunc:    func(obj interface{}) { controller.enqueueWork(controller.cliamWorkqueue, obj) },
-		UpdateFunc: func(oldObj, newObj interface{}) { controller.enqueueWork(controller.cliamWorkqueue, newObj) },
+		UpdateFunc: controller.updateNetworkClaimHandle,
 	})
 
 	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -129,6 +138,11 @@ func NewController(
 
 }
 
+// SetIPProber sets the prober used to check a candidate IP before binding.
+func (c *Controller) SetIPProber(prober IPProber) {
+	c.prober = prober
+}
+
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
 	defer c.netWorkqueue.ShutDown()
@@ -184,6 +198,8 @@ func (c *Controller) syncNetworkStatusWorker() {
 			continue
 		}
 
+		c.syncQuarantined(network, networkMgr)
+
 		all, used := networkMgr.getIPCounts()
 		if network.Status.AllIPCounts != all || network.Status.UsedIPCount != used {
 			c.updateNetworkStatus(network, all, used, network.Status.Conflicts)
@@ -221,7 +237,12 @@ func (c *Controller) syncNetworkStatusWorker() {
 			conflictsMap[bindIP] = networkclaim.GetName()
 		}
 
-		if len(conflicts) != 0 || (len(conflicts) == 0 && len(network.Status.Conflicts) != 0) {
+		//被隔离的IP同样记录为冲突IP
+		for _, conflict := range network.Status.Quarantined {
+			conflicts = append(conflicts, conflict.IP)
+		}
+
+		if !sets.NewString(conflicts...).Equal(sets.NewString(network.Status.Conflicts...)) {
 			c.updateNetworkStatus(network, network.Status.AllIPCounts, network.Status.UsedIPCount, conflicts)
 		}
 	}
@@ -250,6 +271,116 @@ func (c *Controller) syncNetworkStatusWorker() {
 
 }
 
+// 以status.quarantined为准同步隔离的IP，
+// 记录被清除(人工确认后)则解除隔离并释放IP
+func (c *Controller) syncQuarantined(network *networkv1.Network, networkMgr *NetworkMgr) {
+	recorded := make(map[string]networkv1.IPConflict, len(network.Status.Quarantined))
+	for _, conflict := range network.Status.Quarantined {
+		recorded[conflict.IP] = conflict
+	}
+
+	quarantined := networkMgr.quarantinedIPs()
+
+	for ip, at := range quarantined {
+		if _, ok := recorded[ip]; ok || time.Since(at) < quarantineGracePeriod {
+			continue
+		}
+
+		if err := networkMgr.unquarantine(ip); err != nil {
+			klog.Errorf("%s network unquarantine %s fail:%s", network.GetName(), ip, err)
+			continue
+		}
+
+		klog.Infof("%s network unquarantine %s", network.GetName(), ip)
+		c.recorder.Eventf(network, corev1.EventTypeNormal, "IPUnquarantined", "%s is released to the pool", ip)
+	}
+
+	for ip, conflict := range recorded {
+		if _, ok := quarantined[ip]; ok {
+			continue
+		}
+
+		if err := networkMgr.quarantine(ip, conflict.Time.Time); err != nil {
+			klog.Errorf("%s network quarantine %s fail:%s", network.GetName(), ip, err)
+		}
+	}
+}
+
+// 分配IP并探测是否已被占用，有应答的IP隔离后重新分配
+func (c *Controller) allocAndProbe(networkClaim *networkv1.NetworkClaim, network *networkv1.Network) (string, error) {
+	if c.prober == nil {
+		return c.networkingMgr.AllocRequest(network.GetName(), network.Spec.DisabledIP)
+	}
+
+	for i := 0; i < maxProbeTimes; i++ {
+		ip, err := c.networkingMgr.AllocRequest(network.GetName(), network.Spec.DisabledIP)
+		if err != nil {
+			return "", err
+		}
+
+		inUse, err := c.prober.InUse(ip)
+		if err != nil {
+			//探测工具异常不阻塞分配
+			klog.Warningf("probe %s fail:%s", ip, err)
+			return ip, nil
+		}
+
+		if !inUse {
+			return ip, nil
+		}
+
+		if err := c.quarantineIP(network.GetName(), ip, networkClaim.GetName()); err != nil {
+			c.networkingMgr.ReleaseRequest(network.GetName(), ip)
+			return "", err
+		}
+
+		c.recorder.Eventf(networkClaim, corev1.EventTypeWarning, "IPConflict", "%s responds on the wire and is quarantined", ip)
+	}
+
+	return "", fmt.Errorf("%d candidate ips of %s network are all in use on the wire", maxProbeTimes, network.GetName())
+}
+
+// 记录隔离IP到network status
+func (c *Controller) quarantineIP(network, ip, claim string) error {
+	now := metav1.Now()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := c.networkingClientset.NetworkingV1alpha1().Networks().Get(context.TODO(), network, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		for _, conflict := range obj.Status.Quarantined {
+			if conflict.IP == ip {
+				return nil
+			}
+		}
+
+		toUpdate := obj.DeepCopy()
+		toUpdate.Status.Quarantined = append(toUpdate.Status.Quarantined, networkv1.IPConflict{
+			IP:     ip,
+			Claim:  claim,
+			Reason: fmt.Sprintf("responds to %s probe", c.prober),
+			Time:   now,
+		})
+
+		if !utils.ContainsString(toUpdate.Status.Conflicts, ip) {
+			toUpdate.Status.Conflicts = append(toUpdate.Status.Conflicts, ip)
+		}
+
+		_, err = c.networkingClientset.NetworkingV1alpha1().Networks().UpdateStatus(context.TODO(), toUpdate, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("%s network quarantine %s fail:%s", network, ip, err)
+		return err
+	}
+
+	klog.Warningf("%s network quarantine %s, which responds to probe", network, ip)
+
+	return c.networkingMgr.Quarantine(network, ip, now.Time)
+}
+
 //判断全局是否已分配了该IP地址
 func (c *Controller) checkIPFromClaims(IP string) error {
 
@@ -304,7 +435,7 @@ func (c *Controller) networkClaimHandler(key string) error {
 
 	//是否分配IP地址
 	if networkClaim.Status.BindIP == "" {
-		allocIP, err = c.networkingMgr.AllocRequest(networkClaim.Spec.Network, network.Spec.DisabledIP)
+		allocIP, err = c.allocAndProbe(networkClaim, network)
 		if err != nil {
 			c.recorder.Event(networkClaim, corev1.EventTypeWarning, "Alloc IP fail", err.Error())
 			return err
@@ -349,10 +480,40 @@ func (c *Controller) networkClaimHandler(key string) error {
 		return c.updateNetworkClaimStatus(networkClaim, allocIP, uesdPod, status)
 	}
 
+	c.checkClaimBandwidth(networkClaim)
+
 	klog.V(5).Infof("%s: claim nothing to do.", key)
 	return nil
 }
 
+// 带宽由claim所在节点的agent-manager调整并更新status.curBandwidth，
+// 网卡由旧版本插件创建时没有记录netns，只能重启pod生效
+func (c *Controller) checkClaimBandwidth(networkClaim *networkv1.NetworkClaim) {
+	if networkClaim.Status.Status != networkv1.Using ||
+		networkClaim.Spec.Mode == networkv1.CalicoNetworkMode ||
+		networkClaim.Spec.Bandwidth == networkClaim.Status.CurBandwidth {
+		return
+	}
+
+	if networkClaim.Status.Netns == "" {
+		c.recorder.Eventf(networkClaim, corev1.EventTypeWarning, "BandwidthPending",
+			"bandwidth %d->%d can't apply online(unknown netns),restart pod %s to take effect",
+			networkClaim.Status.CurBandwidth, networkClaim.Spec.Bandwidth, networkClaim.Status.Used)
+	}
+}
+
+func (c *Controller) updateNetworkClaimHandle(oldObj, newObj interface{}) {
+	oldClaim, ok := oldObj.(*networkv1.NetworkClaim)
+	newClaim, _ok := newObj.(*networkv1.NetworkClaim)
+
+	if ok && _ok && oldClaim.Spec.Bandwidth != newClaim.Spec.Bandwidth {
+		c.recorder.Eventf(newClaim, corev1.EventTypeNormal, "BandwidthChanged",
+			"bandwidth %d->%d,wait for host %s to apply", oldClaim.Spec.Bandwidth, newClaim.Spec.Bandwidth, newClaim.Status.Host)
+	}
+
+	c.enqueueWork(c.cliamWorkqueue, newObj)
+}
+
 func (c *Controller) findUsedPod(networkClaim *networkv1.NetworkClaim) (string, error) {
 	//pods, err := c.podLister.List(labels.SelectorFromSet(labels.Set{vars.LableDBScaleKey: vars.LableDBScaleValue}))
 	pods, err := c.podLister.List(labels.Everything())
@@ -435,6 +596,8 @@ func (c *Controller) updateNetworkClaimStatus(networkClaim *networkv1.NetworkCla
 		toUpdate.Status.Host = ""
 		toUpdate.Status.HostDevice = ""
 		toUpdate.Status.CurBandwidth = 0
+		toUpdate.Status.Netns = ""
+		toUpdate.Status.IfName = ""
 	}
 
 	_, err := c.networkingClientset.NetworkingV1alpha1().NetworkClaims().UpdateStatus(context.TODO(), toUpdate, metav1.UpdateOptions{})
@@ -518,6 +681,7 @@ func (c *Controller) networkClaimRunWorker() {
 			}
 
 			if err := c.networkClaimHandler(key); err != nil {
+				metrics.ReconcileError("networkclaim", err)
 
 				if c.cliamWorkqueue.NumRequeues(key) < maxRetries {
 					c.cliamWorkqueue.AddRateLimited(key)
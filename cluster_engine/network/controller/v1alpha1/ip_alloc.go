@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"sync"
+	"time"
 
 	networkv1 "github.com/upmio/dbscale-kube/pkg/apis/networking/v1alpha1"
 	"github.com/upmio/dbscale-kube/pkg/utils"
@@ -23,6 +24,9 @@ type NetworkingMgrInterface interface {
 	AllocRequest(network string, ignores []string) (string, error)
 	ReleaseRequest(network, ip string) error
 
+	Quarantine(network, ip string, at time.Time) error
+	Unquarantine(network, ip string) error
+
 	AddNetwork(network *networkv1.Network) error
 	ReleaseNetwork(network *networkv1.Network) error
 }
@@ -66,6 +70,8 @@ func (networking *NetworkingMgr) Init() error {
 			klog.Errorf("%s netowrk init fail:%s", key, err.Error())
 		}
 
+		networkMgr.initQuarantined(network.Status.Quarantined)
+
 		networking.networks[network.GetName()] = networkMgr
 	}
 
@@ -109,6 +115,27 @@ func (networking *NetworkingMgr) ReleaseRequest(network string, ip string) error
 
 	return networkMgr.releaseRequest(ip)
 }
+
+// 隔离IP地址，隔离期间不参与分配
+func (networking *NetworkingMgr) Quarantine(network, ip string, at time.Time) error {
+	networkMgr, ok := networking.networks[network]
+	if !ok {
+		return xerrors.Errorf("don't find the network '%s'", network)
+	}
+
+	return networkMgr.quarantine(ip, at)
+}
+
+// 解除隔离并释放IP地址
+func (networking *NetworkingMgr) Unquarantine(network, ip string) error {
+	networkMgr, ok := networking.networks[network]
+	if !ok {
+		return xerrors.Errorf("don't find the network '%s'", network)
+	}
+
+	return networkMgr.unquarantine(ip)
+}
+
 func (networking *NetworkingMgr) ReleaseNetwork(network *networkv1.Network) error {
 	networking.lock.Lock()
 	defer networking.lock.Unlock()
@@ -130,24 +157,29 @@ func (networking *NetworkingMgr) AddNetwork(network *networkv1.Network) error {
 		return xerrors.Errorf("%s netowrk init fail:%s", network.GetName(), err.Error())
 	}
 
+	networkMgr.initQuarantined(network.Status.Quarantined)
+
 	networking.networks[key] = networkMgr
 
 	return nil
 }
 
 type NetworkMgr struct {
-	name      string
-	ipPool    map[uint32]bool
-	lock      *sync.Mutex
-	allocLock *sync.Mutex
+	name   string
+	ipPool map[uint32]bool
+	//隔离的IP及隔离时间，隔离的IP在ipPool中保持已使用
+	quarantined map[uint32]time.Time
+	lock        *sync.Mutex
+	allocLock   *sync.Mutex
 }
 
 func NewNetworkMgr(name string) *NetworkMgr {
 	return &NetworkMgr{
-		name:      name,
-		ipPool:    make(map[uint32]bool),
-		lock:      new(sync.Mutex),
-		allocLock: new(sync.Mutex),
+		name:        name,
+		ipPool:      make(map[uint32]bool),
+		quarantined: make(map[uint32]time.Time),
+		lock:        new(sync.Mutex),
+		allocLock:   new(sync.Mutex),
 	}
 }
 
@@ -199,24 +231,60 @@ func (n *NetworkMgr) releaseRequest(ip string) error {
 	return nil
 }
 
-/*
-func (n *NetworkMgr) pingCheckAndRelease(ip string) (string, error) {
-	//ping ip 1s
-	return n.releaseRequest(ip)
+func (n *NetworkMgr) initQuarantined(conflicts []networkv1.IPConflict) {
+	for _, conflict := range conflicts {
+		if err := n.quarantine(conflict.IP, conflict.Time.Time); err != nil {
+			klog.Warningf("%s network quarantine %s fail:%s", n.name, conflict.IP, err)
+		}
+	}
 }
 
-//直到分配成功或者返回资源不足，可并发
-func (n *NetworkMgr) allocAndPingCheck() (string, error) {
-	for i := 0; i < 200; i++ {
-		ip, err := n.allocRequest()
-		if err == LackResourceErr {
-			return err
-		}
-		//ping check 1s
+func (n *NetworkMgr) quarantine(ip string, at time.Time) error {
+	n.allocLock.Lock()
+	defer n.allocLock.Unlock()
+
+	if err := n.used(ip); err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.quarantined[utils.IPToUint32(ip)] = at
+
+	return nil
+}
+
+func (n *NetworkMgr) unquarantine(ip string) error {
+	n.allocLock.Lock()
+	defer n.allocLock.Unlock()
+
+	IPU32 := utils.IPToUint32(ip)
+
+	n.lock.Lock()
+	_, ok := n.quarantined[IPU32]
+	delete(n.quarantined, IPU32)
+	n.lock.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return n.unUsed(ip)
+}
+
+// 返回隔离的IP及隔离时间
+func (n *NetworkMgr) quarantinedIPs() map[string]time.Time {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	out := make(map[string]time.Time, len(n.quarantined))
+	for key, at := range n.quarantined {
+		out[utils.Uint32ToIP(key)] = at
 	}
 
+	return out
 }
-*/
 
 func (n *NetworkMgr) allocRequest(ignores []string) (string, error) {
 	n.allocLock.Lock()
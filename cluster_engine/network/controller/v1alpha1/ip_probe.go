@@ -0,0 +1,115 @@
+package v1alpha1
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	ProbeNone = "none"
+	ProbeICMP = "icmp"
+	ProbeARP  = "arp"
+
+	//单次分配最多探测的IP数量
+	maxProbeTimes = 5
+	probeTimeout  = 5 * time.Second
+)
+
+// 分配IP前在线探测该地址是否已被占用(如遗留的虚拟机)
+type IPProber interface {
+	// InUse returns true if the ip answers on the wire
+	InUse(ip string) (bool, error)
+}
+
+// NewIPProber returns an IPProber by mode,
+// iface is the interface arping sends from, only used by arp mode,
+// empty means arping chooses it by the route of the ip.
+// icmp 和 arp 都使用原始套接字，容器需要 CAP_NET_RAW
+func NewIPProber(mode, iface string) (IPProber, error) {
+	switch mode {
+	case "", ProbeNone:
+		return nil, nil
+	case ProbeICMP:
+		return icmpProber{}, nil
+	case ProbeARP:
+		return arpProber{iface: iface}, nil
+	}
+
+	return nil, xerrors.Errorf("unsupported probe mode '%s'", mode)
+}
+
+type icmpProber struct{}
+
+func (icmpProber) String() string {
+	return ProbeICMP
+}
+
+// ping: 0 有应答，1 无应答，其他为执行错误
+func (icmpProber) InUse(ip string) (bool, error) {
+	args := []string{"-c", "2", "-W", "1", ip}
+
+	switch code, out, err := runProbe("ping", args...); {
+	case err != nil:
+		return false, err
+	case code == 0:
+		return true, nil
+	case code == 1:
+		return false, nil
+	default:
+		return false, xerrors.Errorf("ping %s exit %d:%s", strings.Join(args, " "), code, out)
+	}
+}
+
+type arpProber struct {
+	iface string
+}
+
+func (p arpProber) String() string {
+	if p.iface == "" {
+		return ProbeARP
+	}
+
+	return ProbeARP + "(" + p.iface + ")"
+}
+
+// arping -D(重复地址检测): 0 无应答，1 有应答，其他为执行错误
+func (p arpProber) InUse(ip string) (bool, error) {
+	args := []string{"-D", "-q", "-c", "2", "-w", "2"}
+	if p.iface != "" {
+		args = append(args, "-I", p.iface)
+	}
+	args = append(args, ip)
+
+	switch code, out, err := runProbe("arping", args...); {
+	case err != nil:
+		return false, err
+	case code == 0:
+		return false, nil
+	case code == 1:
+		return true, nil
+	default:
+		return false, xerrors.Errorf("arping %s exit %d:%s", strings.Join(args, " "), code, out)
+	}
+}
+
+// runProbe returns the exit code of the command,
+// err is not nil when the command can't run to the end.
+func runProbe(name string, args ...string) (int, []byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err == nil {
+		return 0, out, nil
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok && ctx.Err() == nil {
+		return exitErr.ExitCode(), out, nil
+	}
+
+	return -1, out, xerrors.Errorf("%s %s:%s,%s", name, strings.Join(args, " "), err, out)
+}
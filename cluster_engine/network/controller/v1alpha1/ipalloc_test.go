@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 )
 
 func TestAllocIP(t *testing.T) {
@@ -17,3 +18,33 @@ func TestAllocIP(t *testing.T) {
 	all, used := mgr.getIPCounts()
 	t.Logf("all:%d,used:%d", all, used)
 }
+
+func TestQuarantineIP(t *testing.T) {
+	mgr := NewNetworkMgr("test")
+	mgr.init("192.168.1.17", "192.168.1.18", 24)
+
+	if err := mgr.quarantine("192.168.1.17", time.Now()); err != nil {
+		t.Fatalf("quarantine fail:%s", err)
+	}
+
+	ip, err := mgr.allocRequest(nil)
+	if err != nil || ip != "192.168.1.18" {
+		t.Fatalf("expect alloc 192.168.1.18,got %s,%v", ip, err)
+	}
+
+	if _, err := mgr.allocRequest(nil); err != LackResourceErr {
+		t.Fatalf("expect %s,got %v", LackResourceErr, err)
+	}
+
+	if err := mgr.unquarantine("192.168.1.17"); err != nil {
+		t.Fatalf("unquarantine fail:%s", err)
+	}
+
+	if ip, err := mgr.allocRequest(nil); err != nil || ip != "192.168.1.17" {
+		t.Fatalf("expect alloc 192.168.1.17,got %s,%v", ip, err)
+	}
+
+	if len(mgr.quarantinedIPs()) != 0 {
+		t.Fatalf("expect no quarantined ip,got %v", mgr.quarantinedIPs())
+	}
+}
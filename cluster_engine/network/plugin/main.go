@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
@@ -22,6 +23,7 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
@@ -67,6 +69,7 @@ type networkRuntimeCfg struct {
 
 	podIP   string
 	podMask string
+	claim   string
 }
 
 type runtimeConfig struct {
@@ -220,6 +223,30 @@ func updateCliamStatus(kubeclient *Kubeclient, networkClaim *networkv1.NetworkCl
 	return nil
 }
 
+// 记录网卡所在的网络命名空间，agent-manager据此在线调整带宽
+func updateCliamNetns(kubeclient *Kubeclient, name, netns, ifName string, bandwidth int32) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		claim, err := kubeclient.networkingClient.NetworkingV1alpha1().NetworkClaims().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		toUpdate := claim.DeepCopy()
+		toUpdate.Status.Netns = netns
+		toUpdate.Status.IfName = ifName
+		toUpdate.Status.CurBandwidth = bandwidth
+
+		_, err = kubeclient.networkingClient.NetworkingV1alpha1().NetworkClaims().UpdateStatus(context.TODO(), toUpdate, metav1.UpdateOptions{})
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Networkclaims UpdateStatus Netns fail:%s", err.Error())
+	}
+
+	return nil
+}
+
 func prepareNetworkeConfig(kubeclient *Kubeclient, podName, podNameSpace string) (*networkRuntimeCfg, error) {
 	networkcfg := &networkRuntimeCfg{}
 	pod, err := kubeclient.kubeClient.CoreV1().Pods(podNameSpace).Get(context.TODO(), podName, metav1.GetOptions{})
@@ -237,6 +264,7 @@ func prepareNetworkeConfig(kubeclient *Kubeclient, podName, podNameSpace string)
 	//pod默认IP地址
 	networkcfg.podIP = cfg.Ip
 	networkcfg.podMask = cfg.Mask
+	networkcfg.claim = pod.Annotations[networkv1.NetworkClaimLabelInternal]
 
 	//外网可选
 	// excfg, err := prepareRunTimeConfig(kubeclient, pod, networkv1.NetworkClaimLabelExternal)
@@ -405,9 +433,10 @@ func cmdAdd(args *skel.CmdArgs) error {
 	}
 
 	//update Bandwidth status
-	// if err := updateCliamBandwidthStatus(kubeclient, podName, podNameSpace, runtimeCfg.Bandwidth); err != nil {
-	// 	return err
-	// }
+	//网卡已配置完成，记录失败不影响pod网络，只是agent-manager无法在线调整带宽，直到pod重建
+	if err := updateCliamNetns(kubeclient, networkRuntimeCfg.claim, args.Netns, args.IfName, networkRuntimeCfg.Devices[0].Bandwidth); err != nil {
+		fmt.Fprintf(os.Stderr, "networkclaim %s: record netns %s fail,bandwidth can't be adjusted online:%s\n", networkRuntimeCfg.claim, args.Netns, err)
+	}
 
 	//返回结果
 	_, ipnet, err := net.ParseCIDR(networkRuntimeCfg.podIP + "/" + networkRuntimeCfg.podMask)
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"time"
 
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/registry"
 	"github.com/upmio/dbscale-kube/pkg/server/client"
+	"github.com/upmio/dbscale-kube/pkg/utils/sshauth"
 	"k8s.io/klog/v2"
 )
 
@@ -46,6 +49,13 @@ type DeployImageOptionResponse struct {
 	Outputs string `json:"outputs"`
 }
 
+// InspectImageResponse 站点镜像仓库中镜像 tag 对应的 digest 及其 cosign 签名
+type InspectImageResponse struct {
+	Errors     string               `json:"error"`
+	Digest     string               `json:"digest"`
+	Signatures []registry.Signature `json:"signatures"`
+}
+
 type LegalizeHostOption struct {
 	SSHUser    string       `json:"ssh_user"`
 	SSHPasswod string       `json:"ssh_password"`
@@ -55,6 +65,8 @@ type LegalizeHostOption struct {
 	MaxUnit    int          `json:"max_unit"`
 	NtpServer  string       `json:"ntp_server"`
 	LocalVGs   []LocalVGCfg `json:"vgs"`
+	//密钥认证，设置后不使用SSHPasswod
+	SSH *hostv1.SSHSpec `json:"ssh,omitempty"`
 
 	CheckType string `json:"check_type"`
 }
@@ -68,6 +80,8 @@ type LegalizeHostExecOption struct {
 	MaxUnit    int          `json:"max_unit"`
 	NtpServer  string       `json:"ntp_server"`
 	LocalVGs   []LocalVGCfg `json:"vgs"`
+
+	sshauth.AnsibleVars `json:",inline"`
 }
 
 type LocalVGCfg struct {
@@ -88,6 +102,7 @@ func (pe PodExecResponse) Error() string {
 type ExecClient interface {
 	Exec(ctx context.Context, opts PodExecOption) (PodExecResponse, error)
 	DeployImage(ctx context.Context, opts DeployImageOption) (DeployImageOptionResponse, error)
+	InspectImage(ctx context.Context, opts DeployImageOption) (InspectImageResponse, error)
 	LegalizeHost(ctx context.Context, opts LegalizeHostOption) (LegalizeHostResponse, error)
 }
 
@@ -137,6 +152,22 @@ func (c *execClient) DeployImage(ctx context.Context, opts DeployImageOption) (D
 	return out, err
 }
 
+func (c *execClient) InspectImage(ctx context.Context, opts DeployImageOption) (InspectImageResponse, error) {
+	resp, err := c.client.Post(ctx, "/v1.0/image/inspect", opts)
+	if err != nil {
+		client.EnsureBodyClose(resp)
+
+		return InspectImageResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	out := InspectImageResponse{}
+
+	err = json.NewDecoder(resp.Body).Decode(&out)
+
+	return out, err
+}
+
 func (c *execClient) LegalizeHost(ctx context.Context, opts LegalizeHostOption) (LegalizeHostResponse, error) {
 
 	resp, err := c.client.Post(ctx, "/v1.0/host/legalization", opts)
@@ -5,22 +5,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	//"sync/atomic"
 	"time"
 
-	"golang.org/x/crypto/ssh"
-
+	"github.com/upmio/dbscale-kube/pkg/registry"
 	"github.com/upmio/dbscale-kube/pkg/utils"
+	"github.com/upmio/dbscale-kube/pkg/utils/sshauth"
 	"k8s.io/klog/v2"
 
 	"github.com/upmio/dbscale-kube/cluster_engine/plugin/execservice/api"
 	"github.com/upmio/dbscale-kube/pkg/server/router"
 	executil "github.com/upmio/dbscale-kube/pkg/utils/exec"
+	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 )
 
@@ -45,14 +48,29 @@ func (a *atomicBool) get() bool {
 	return atomic.LoadInt32(&a.v) == 1
 }*/
 
+// loadRegistryCredentials reads the registry credentials from docker config.json,
+// the registries without credential are accessed anonymously.
+func loadRegistryCredentials() map[string]registry.Credential {
+	file := registry.DockerConfigFile()
+
+	creds, err := registry.LoadDockerConfig(file)
+	if err != nil {
+		klog.Warningf("load registry credentials from %s:%s", file, err)
+	}
+
+	return creds
+}
+
 func RegisterRouter(config *restclient.Config, routers router.Adder) {
 	er := &execRouter{
-		config: config,
+		config:   config,
+		registry: registry.NewClient(nil, loadRegistryCredentials()),
 	}
 
 	er.routes = []router.Route{
 		router.NewPostRoute("/engine/exec", er.Exec),
 		router.NewPostRoute("/image/deploy", er.DeployImage),
+		router.NewPostRoute("/image/inspect", er.InspectImage),
 		router.NewPostRoute("/host/legalization", er.LegalizeHost),
 		router.NewGetRoute("/healthz", er.healthzHandler),
 	}
@@ -61,7 +79,8 @@ func RegisterRouter(config *restclient.Config, routers router.Adder) {
 }
 
 type execRouter struct {
-	config *restclient.Config
+	config   *restclient.Config
+	registry *registry.Client
 
 	routes []router.Route
 }
@@ -100,6 +119,32 @@ func (er execRouter) DeployImage(ctx context.Context, w http.ResponseWriter, r *
 	return http.StatusOK, resp, nil
 }
 
+// InspectImage 查询站点镜像仓库中镜像 tag 当前对应的 digest 及其签名，签名由 cluster_manager 验证
+func (er execRouter) InspectImage(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	req := api.DeployImageOption{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	repository := req.ProjectName + "/" + req.Type
+	tag := req.Version + "-" + req.Arch
+
+	resp := api.InspectImageResponse{}
+
+	resp.Digest, err = er.registry.Digest(ctx, req.ImageRegistry, repository, tag)
+	if err == nil {
+		resp.Signatures, err = er.registry.Signatures(ctx, req.ImageRegistry, repository, resp.Digest)
+	}
+
+	if err != nil {
+		klog.Errorf("InspectImage %s/%s:%s err: %s", req.ImageRegistry, repository, tag, err)
+		resp.Errors = err.Error()
+	}
+
+	return http.StatusOK, resp, nil
+}
+
 func (er execRouter) Exec(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
 	req := api.PodExecOption{}
 
@@ -155,6 +200,40 @@ func (er execRouter) LegalizeHost(ctx context.Context, w http.ResponseWriter, r
 		LocalVGs:   req.LocalVGs,
 	}
 
+	addr := net.JoinHostPort(execOpts.SSHIP, strconv.Itoa(int(execOpts.SSHPort)))
+	cred := sshauth.Credential{
+		User:     execOpts.SSHUser,
+		Password: execOpts.SSHPasswod,
+	}
+
+	dir, err := ioutil.TempDir("", "host-legalize-")
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if req.SSH != nil {
+		client, err := kubernetes.NewForConfig(er.config)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		cred, err = sshauth.Load(ctx, client, req.SSHUser, *req.SSH)
+		if err != nil {
+			resp.Errors = err.Error()
+			resp.Outputs = "Load ssh credential failed: "
+			return http.StatusOK, resp, nil
+		}
+
+		execOpts.SSHPasswod = ""
+		execOpts.AnsibleVars, err = cred.WriteAnsibleFiles(dir, addr)
+		if err != nil {
+			resp.Errors = err.Error()
+			resp.Outputs = "Write ssh credential failed: "
+			return http.StatusOK, resp, nil
+		}
+	}
+
 	argsjson, err := json.Marshal(execOpts)
 	if err != nil {
 		resp.Errors = err.Error()
@@ -163,35 +242,23 @@ func (er execRouter) LegalizeHost(ctx context.Context, w http.ResponseWriter, r
 	}
 	execfile := filepath.Join(utils.GetNodeInitDir(), "host-init")
 
+	execOpts.SSHPasswod = "****"
+
 	switch req.CheckType {
 	case "user":
 		klog.Info("start check user,name,password...")
-		hostKeyCallbk := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			return nil
-		}
-
-		config := ssh.ClientConfig{
-			User:            execOpts.SSHUser,
-			Auth:            []ssh.AuthMethod{ssh.Password(execOpts.SSHPasswod)},
-			HostKeyCallback: hostKeyCallbk,
-			Timeout:         time.Second * 2,
-		}
-
-		addr := net.JoinHostPort(execOpts.SSHIP, strconv.Itoa(int(execOpts.SSHPort)))
 
-		client, err := ssh.Dial("tcp", addr, &config)
+		err := cred.Verify(addr, time.Second*2)
 		if err != nil {
 			resp.Errors = err.Error()
 			resp.Outputs = "Username or password or port authentication failed: "
 			return http.StatusOK, resp, nil
 		}
-		defer client.Close()
 
 		return http.StatusOK, resp, nil
 
 	case "port":
 		klog.Info("LegalizeHost start check port...")
-		execOpts.SSHPasswod = "****"
 		printjson, _ := json.Marshal(execOpts)
 		klog.Infof("LegalizeHost execfile:%s input:%s", execfile, printjson)
 
@@ -207,7 +274,6 @@ func (er execRouter) LegalizeHost(ctx context.Context, w http.ResponseWriter, r
 
 	case "network":
 		klog.Info("LegalizeHost start check network...")
-		execOpts.SSHPasswod = "****"
 		printjson, _ := json.Marshal(execOpts)
 		klog.Infof("LegalizeHost execfile:%s input:%s", execfile, printjson)
 
@@ -223,7 +289,6 @@ func (er execRouter) LegalizeHost(ctx context.Context, w http.ResponseWriter, r
 
 	case "storage":
 		klog.Info("LegalizeHost start check storage...")
-		execOpts.SSHPasswod = "****"
 		printjson, _ := json.Marshal(execOpts)
 		klog.Infof("LegalizeHost execfile:%s input:%s", execfile, printjson)
 
@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"github.com/upmio/dbscale-kube/pkg/signals"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,6 +38,7 @@ var (
 	versionFlag bool
 	masterURL   string
 	kubeconfig  string
+	metricsAddr string
 
 	script = "/opt/kube/scripts/StorMGR/StorMGR"
 )
@@ -55,6 +57,8 @@ func main() {
 
 	klog.Info("VERSION: ", vars.GITCOMMIT, " ", vars.BUILDTIME)
 
+	metrics.Serve(metricsAddr)
+
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
 
@@ -118,6 +122,7 @@ func init() {
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 	flag.BoolVar(&versionFlag, "version", false, "show the version ")
 	flag.StringVar(&script, "scripts", script, "path to storage script dir.")
+	flag.StringVar(&metricsAddr, "metrics-addr", metricsAddr, "the address /metrics serves on, empty means disabled.")
 }
 
 func initCRDs(config *rest.Config) error {
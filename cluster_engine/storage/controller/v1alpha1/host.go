@@ -14,6 +14,7 @@ import (
 	"k8s.io/klog/v2"
 
 	hostctrl "github.com/upmio/dbscale-kube/cluster_engine/host/controller/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 )
 
 // hostWorker processes items from hostQueue. It must run only once,
@@ -97,6 +98,7 @@ func (ctrl *Controller) hostWorker() {
 			klog.Infof("hostgroupWorker successfully synced '%s'", keyObj)
 		} else {
 			klog.Error("hostgroupWorker  %s fail:%s", keyObj, err.Error())
+			metrics.ReconcileError("san-host", err)
 		}
 
 		ctrl.hostQueue.Forget(keyObj)
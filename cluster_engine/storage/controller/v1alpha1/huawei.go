@@ -13,6 +13,7 @@ import (
 
 	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
 	"github.com/upmio/dbscale-kube/pkg/apis/san/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"github.com/upmio/dbscale-kube/pkg/utils"
 	crypto "github.com/upmio/dbscale-kube/pkg/utils/crypto"
 	corev1 "k8s.io/api/core/v1"
@@ -325,7 +326,9 @@ func (h huawei) execWithJsonParams(cmd cmdType, v interface{}) ([]byte, error) {
 
 	args[len(args)-1] = fmt.Sprintf("'%s'", in)
 
+	start := time.Now()
 	dat, err := h.execContext(context.Background(), args...)
+	metrics.ObserveScript("StorMGR", string(cmd), start, err)
 
 	out, _ := utils.MaskJsonSecret(in)
 	args[len(args)-1] = fmt.Sprintf("'%s'", out)
@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"github.com/upmio/dbscale-kube/pkg/utils"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -59,7 +60,7 @@ func (ctrl *Controller) lungroupWorker() {
 				if err != nil {
 					ctrl.recorder.Eventf(lg, corev1.EventTypeWarning, failedSync, messageResourceSyncFailed, key, err)
 
-					return fmt.Errorf("error getting name of lungroup %q to sync lungroup: %v", key, err)
+					return fmt.Errorf("error getting name of lungroup %q to sync lungroup: %w", key, err)
 				}
 
 				return err
@@ -76,6 +77,7 @@ func (ctrl *Controller) lungroupWorker() {
 			klog.Infof("lungroupWorker successfully synced '%s'", keyObj)
 		} else {
 			klog.Error("lungroupWorker  %s fail:%s", keyObj, err.Error())
+			metrics.ReconcileError("lungroup", err)
 		}
 
 		ctrl.lunQueue.Forget(keyObj)
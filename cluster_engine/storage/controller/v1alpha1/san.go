@@ -9,6 +9,7 @@ import (
 	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
 	"github.com/upmio/dbscale-kube/pkg/apis/san/v1alpha1"
 	clientset "github.com/upmio/dbscale-kube/pkg/client/san/v1alpha1/clientset/versioned"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -54,7 +55,7 @@ func (ctrl *Controller) sanWorker() {
 				err = ctrl.syncSan(san)
 				if err != nil {
 					ctrl.recorder.Eventf(san, corev1.EventTypeWarning, failedSync, messageResourceSyncFailed, key, err)
-					return fmt.Errorf("error getting name of san %q to sync san: %v", key, err)
+					return fmt.Errorf("error getting name of san %q to sync san: %w", key, err)
 				}
 
 				ctrl.recorder.Event(san, corev1.EventTypeNormal, successSynced, messageResourceSynced)
@@ -72,6 +73,7 @@ func (ctrl *Controller) sanWorker() {
 			klog.Infof("sanWorker successfully synced '%s'", keyObj)
 		} else {
 			klog.Error("sanWorker  %s fail:%s", keyObj, err.Error())
+			metrics.ReconcileError("san", err)
 		}
 
 		ctrl.sanQueue.Forget(keyObj)
@@ -36,6 +36,7 @@ import (
 	informers "github.com/upmio/dbscale-kube/pkg/client/unit/v1alpha4/informers/externalversions/unit/v1alpha4"
 	listers "github.com/upmio/dbscale-kube/pkg/client/unit/v1alpha4/listers/unit/v1alpha4"
 	lvmclientset "github.com/upmio/dbscale-kube/pkg/client/volumepath/v1alpha1/clientset/versioned"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 )
 
 const (
@@ -277,6 +278,7 @@ func (ctrl *Controller) processNextWorkItem() bool {
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Unit resource to be synced.
 		if err := ctrl.syncHandler(key); err != nil {
+			metrics.ReconcileError("unit", err)
 			if ctrl.workqueue.NumRequeues(key) < ctrl.maxRetries {
 				// Put the item back on the workqueue to handle any transient errors.
 				ctrl.workqueue.AddRateLimited(key)
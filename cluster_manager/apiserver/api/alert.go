@@ -0,0 +1,227 @@
+package api
+
+import (
+	"net/mail"
+	"net/url"
+
+	"golang.org/x/xerrors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	AlertSeverityCritical = "critical"
+	AlertSeverityWarning  = "warning"
+
+	AlertStateFiring   = "firing"
+	AlertStatePending  = "pending"
+	AlertStateResolved = "resolved"
+
+	// 告警来源，prometheus规则或apiserver根据备份记录计算
+	AlertSourcePrometheus = "prometheus"
+	AlertSourceManager    = "manager"
+
+	AlertReplicationBroken  = "ReplicationBroken"
+	AlertReplicationLagging = "ReplicationLagging"
+	AlertDiskUsageHigh      = "DiskUsageHigh"
+	AlertConnectionsHigh    = "ConnectionsNearMax"
+	AlertUnitDown           = "UnitDown"
+	AlertBackupFailed       = "BackupFailed"
+	AlertBackupMissed       = "BackupMissed"
+
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// AlertThresholds 服务告警阈值
+type AlertThresholds struct {
+	// 复制延迟(秒)
+	ReplicationLagSeconds int `json:"replication_lag_seconds"`
+	// 数据/日志卷使用率(%)
+	DiskUsagePercent int `json:"disk_usage_percent"`
+	// 连接数占max_connections的比例(%)
+	ConnectionsPercent int `json:"connections_percent"`
+	// 单元不可用持续时间(分钟)
+	UnitDownMinutes int `json:"unit_down_minutes"`
+	// 超过计划备份时间多久未成功视为漏备(小时)
+	BackupMissedHours int `json:"backup_missed_hours"`
+}
+
+func DefaultAlertThresholds() AlertThresholds {
+	return AlertThresholds{
+		ReplicationLagSeconds: 300,
+		DiskUsagePercent:      85,
+		ConnectionsPercent:    80,
+		UnitDownMinutes:       2,
+		BackupMissedHours:     2,
+	}
+}
+
+func (t AlertThresholds) Valid() error {
+	var errs []error
+
+	if t.ReplicationLagSeconds <= 0 {
+		errs = append(errs, xerrors.New("replication_lag_seconds should be greater than 0"))
+	}
+
+	if t.DiskUsagePercent <= 0 || t.DiskUsagePercent > 100 {
+		errs = append(errs, xerrors.New("disk_usage_percent should be in (0,100]"))
+	}
+
+	if t.ConnectionsPercent <= 0 || t.ConnectionsPercent > 100 {
+		errs = append(errs, xerrors.New("connections_percent should be in (0,100]"))
+	}
+
+	if t.UnitDownMinutes <= 0 {
+		errs = append(errs, xerrors.New("unit_down_minutes should be greater than 0"))
+	}
+
+	if t.BackupMissedHours <= 0 {
+		errs = append(errs, xerrors.New("backup_missed_hours should be greater than 0"))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// AppAlertConfig 服务的告警配置
+type AppAlertConfig struct {
+	App        IDName          `json:"app"`
+	Enabled    bool            `json:"enabled"`
+	Thresholds AlertThresholds `json:"thresholds"`
+	Modified   Editor          `json:"modified"`
+}
+
+type AppAlertConfigOptions struct {
+	Enabled               *bool `json:"enabled,omitempty"`
+	ReplicationLagSeconds *int  `json:"replication_lag_seconds,omitempty"`
+	DiskUsagePercent      *int  `json:"disk_usage_percent,omitempty"`
+	ConnectionsPercent    *int  `json:"connections_percent,omitempty"`
+	UnitDownMinutes       *int  `json:"unit_down_minutes,omitempty"`
+	BackupMissedHours     *int  `json:"backup_missed_hours,omitempty"`
+
+	User string `json:"modified_user"`
+}
+
+// Apply merges the options into thresholds.
+func (opts AppAlertConfigOptions) Apply(t AlertThresholds) AlertThresholds {
+	if opts.ReplicationLagSeconds != nil {
+		t.ReplicationLagSeconds = *opts.ReplicationLagSeconds
+	}
+	if opts.DiskUsagePercent != nil {
+		t.DiskUsagePercent = *opts.DiskUsagePercent
+	}
+	if opts.ConnectionsPercent != nil {
+		t.ConnectionsPercent = *opts.ConnectionsPercent
+	}
+	if opts.UnitDownMinutes != nil {
+		t.UnitDownMinutes = *opts.UnitDownMinutes
+	}
+	if opts.BackupMissedHours != nil {
+		t.BackupMissedHours = *opts.BackupMissedHours
+	}
+
+	return t
+}
+
+type AlertsResponse []AppAlerts
+
+// AppAlerts 服务当前的告警
+type AppAlerts struct {
+	App    IDName  `json:"app"`
+	Alerts []Alert `json:"alerts"`
+}
+
+type Alert struct {
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+	State    string `json:"state"`
+	Source   string `json:"source"`
+	Site     string `json:"site,omitempty"`
+	Unit     string `json:"unit,omitempty"`
+	Summary  string `json:"summary"`
+	Value    string `json:"value,omitempty"`
+	ActiveAt Time   `json:"active_at"`
+}
+
+// NotificationChannel 告警通知渠道
+type NotificationChannel struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+	ChannelConfig
+
+	Created  Editor `json:"created"`
+	Modified Editor `json:"modified"`
+}
+
+type ChannelConfig struct {
+	// webhook地址，type=webhook时必填
+	URL string `json:"url,omitempty"`
+	// 收件人，type=email时必填
+	To []string `json:"to,omitempty"`
+	// 只通知这些服务的告警，为空表示全部
+	Apps []string `json:"apps,omitempty"`
+	// 只通知这些级别的告警，为空表示全部
+	Severities []string `json:"severities,omitempty"`
+}
+
+func (c ChannelConfig) Valid(typ string) error {
+	var errs []error
+
+	switch typ {
+	case ChannelWebhook:
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, xerrors.Errorf("invalid webhook url '%s'", c.URL))
+		}
+
+	case ChannelEmail:
+		if len(c.To) == 0 {
+			errs = append(errs, xerrors.New("email recipients are required"))
+		}
+
+		for _, to := range c.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				errs = append(errs, xerrors.Errorf("invalid email address '%s':%w", to, err))
+			}
+		}
+
+	default:
+		errs = append(errs, xerrors.Errorf("unsupported channel type '%s'", typ))
+	}
+
+	for _, s := range c.Severities {
+		if s != AlertSeverityCritical && s != AlertSeverityWarning {
+			errs = append(errs, xerrors.Errorf("unsupported severity '%s'", s))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+type NotificationChannelConfig struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+	ChannelConfig
+
+	User string `json:"created_user"`
+}
+
+func (c NotificationChannelConfig) Valid() error {
+	if c.Name == "" {
+		return xerrors.New("name is required")
+	}
+
+	return c.ChannelConfig.Valid(c.Type)
+}
+
+type NotificationChannelOptions struct {
+	Name       *string   `json:"name,omitempty"`
+	Enabled    *bool     `json:"enabled,omitempty"`
+	URL        *string   `json:"url,omitempty"`
+	To         *[]string `json:"to,omitempty"`
+	Apps       *[]string `json:"apps,omitempty"`
+	Severities *[]string `json:"severities,omitempty"`
+
+	User string `json:"modified_user"`
+}
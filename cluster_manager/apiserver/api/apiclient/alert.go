@@ -0,0 +1,202 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/client"
+)
+
+var _ AlertAPI = &clientConfig{}
+
+type AlertAPI interface {
+	ListAlerts(ctx context.Context, app, severity string) (api.AlertsResponse, error)
+
+	GetAppAlertConfig(ctx context.Context, app string) (api.AppAlertConfig, error)
+	SetAppAlertConfig(ctx context.Context, app string, opts api.AppAlertConfigOptions) (api.AppAlertConfig, error)
+
+	PostNotificationChannel(ctx context.Context, config api.NotificationChannelConfig) (api.ObjectResponse, error)
+	UpdateNotificationChannel(ctx context.Context, id string, opts api.NotificationChannelOptions) (api.NotificationChannel, error)
+	GetNotificationChannel(ctx context.Context, id string) (api.NotificationChannel, error)
+	ListNotificationChannels(ctx context.Context, typ string) ([]api.NotificationChannel, error)
+	DeleteNotificationChannel(ctx context.Context, id string) error
+}
+
+// NewAlertAPI returns a AlertAPI
+func NewAlertAPI(host string, cli client.Client) AlertAPI {
+	return &clientConfig{
+		host:   host,
+		client: cli,
+	}
+}
+
+func (c *clientConfig) ListAlerts(ctx context.Context, app, severity string) (api.AlertsResponse, error) {
+	params := make(url.Values)
+
+	if app != "" {
+		params.Set("app_id", app)
+	}
+
+	if severity != "" {
+		params.Set("severity", severity)
+	}
+
+	url := url.URL{
+		Path:     "/v1.0/manager/alerts",
+		RawQuery: params.Encode(),
+	}
+
+	resp, err := requireOK(c.client.Get(ctx, url.RequestURI()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list api.AlertsResponse
+
+	err = decodeBody(resp, &list)
+	if err != nil {
+		return nil, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return list, err
+}
+
+func (c *clientConfig) GetAppAlertConfig(ctx context.Context, app string) (api.AppAlertConfig, error) {
+	uri := "/v1.0/manager/apps/" + app + "/alerts/config"
+
+	resp, err := requireOK(c.client.Get(ctx, uri))
+	if err != nil {
+		return api.AppAlertConfig{}, err
+	}
+	defer resp.Body.Close()
+
+	config := api.AppAlertConfig{}
+
+	err = decodeBody(resp, &config)
+	if err != nil {
+		return api.AppAlertConfig{}, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return config, err
+}
+
+func (c *clientConfig) SetAppAlertConfig(ctx context.Context, app string, opts api.AppAlertConfigOptions) (api.AppAlertConfig, error) {
+	uri := "/v1.0/manager/apps/" + app + "/alerts/config"
+
+	resp, err := requireOK(c.client.Put(ctx, uri, opts))
+	if err != nil {
+		return api.AppAlertConfig{}, err
+	}
+	defer resp.Body.Close()
+
+	config := api.AppAlertConfig{}
+
+	err = decodeBody(resp, &config)
+	if err != nil {
+		return api.AppAlertConfig{}, errors.Errorf("%s %s%s,%v", http.MethodPut, c.host, resp.Request.URL.String(), err)
+	}
+
+	return config, err
+}
+
+func (c *clientConfig) PostNotificationChannel(ctx context.Context, config api.NotificationChannelConfig) (api.ObjectResponse, error) {
+	const uri = "/v1.0/manager/alerts/channels"
+
+	resp, err := requireOK(c.client.Post(ctx, uri, config))
+	if err != nil {
+		return api.ObjectResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	obj := api.ObjectResponse{}
+
+	err = decodeBody(resp, &obj)
+	if err != nil {
+		return api.ObjectResponse{}, errors.Errorf("%s %s%s,%v", http.MethodPost, c.host, resp.Request.URL.String(), err)
+	}
+
+	return obj, err
+}
+
+func (c *clientConfig) UpdateNotificationChannel(ctx context.Context, id string, opts api.NotificationChannelOptions) (api.NotificationChannel, error) {
+	uri := "/v1.0/manager/alerts/channels/" + id
+
+	resp, err := requireOK(c.client.Put(ctx, uri, opts))
+	if err != nil {
+		return api.NotificationChannel{}, err
+	}
+	defer resp.Body.Close()
+
+	ch := api.NotificationChannel{}
+
+	err = decodeBody(resp, &ch)
+	if err != nil {
+		return api.NotificationChannel{}, errors.Errorf("%s %s%s,%v", http.MethodPut, c.host, resp.Request.URL.String(), err)
+	}
+
+	return ch, err
+}
+
+func (c *clientConfig) GetNotificationChannel(ctx context.Context, id string) (api.NotificationChannel, error) {
+	uri := "/v1.0/manager/alerts/channels/" + id
+
+	resp, err := requireOK(c.client.Get(ctx, uri))
+	if err != nil {
+		return api.NotificationChannel{}, err
+	}
+	defer resp.Body.Close()
+
+	ch := api.NotificationChannel{}
+
+	err = decodeBody(resp, &ch)
+	if err != nil {
+		return api.NotificationChannel{}, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return ch, err
+}
+
+func (c *clientConfig) ListNotificationChannels(ctx context.Context, typ string) ([]api.NotificationChannel, error) {
+	params := make(url.Values)
+
+	if typ != "" {
+		params.Set("type", typ)
+	}
+
+	url := url.URL{
+		Path:     "/v1.0/manager/alerts/channels",
+		RawQuery: params.Encode(),
+	}
+
+	resp, err := requireOK(c.client.Get(ctx, url.RequestURI()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list []api.NotificationChannel
+
+	err = decodeBody(resp, &list)
+	if err != nil {
+		return nil, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return list, err
+}
+
+func (c *clientConfig) DeleteNotificationChannel(ctx context.Context, id string) error {
+	uri := "/v1.0/manager/alerts/channels/" + id
+
+	resp, err := requireOK(c.client.Delete(ctx, uri))
+	if err != nil {
+		return err
+	}
+
+	client.EnsureBodyClose(resp)
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/client"
+)
+
+var _ AuditAPI = &clientConfig{}
+
+type AuditAPI interface {
+	ListAudits(ctx context.Context, query api.AuditQuery) (api.AuditLogsResponse, error)
+}
+
+// NewAuditAPI returns a AuditAPI
+func NewAuditAPI(host string, cli client.Client) AuditAPI {
+	return &clientConfig{
+		host:   host,
+		client: cli,
+	}
+}
+
+func (c *clientConfig) ListAudits(ctx context.Context, query api.AuditQuery) (api.AuditLogsResponse, error) {
+	params := make(url.Values)
+
+	if query.User != "" {
+		params.Set("user", query.User)
+	}
+
+	if query.App != "" {
+		params.Set("app_id", query.App)
+	}
+
+	if query.Action != "" {
+		params.Set("action", query.Action)
+	}
+
+	if !query.Since.IsZero() {
+		params.Set("since", query.Since.Format(api.TimeFormat))
+	}
+
+	if !query.Until.IsZero() {
+		params.Set("until", query.Until.Format(api.TimeFormat))
+	}
+
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	url := url.URL{
+		Path:     "/v1.0/manager/audit",
+		RawQuery: params.Encode(),
+	}
+
+	resp, err := requireOK(c.client.Get(ctx, url.RequestURI()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list api.AuditLogsResponse
+
+	err = decodeBody(resp, &list)
+	if err != nil {
+		return nil, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return list, err
+}
@@ -17,6 +17,9 @@ type HostAPI interface {
 	DeleteHost(ctx context.Context, id string) error
 	UpdateHost(ctx context.Context, id string, opts api.HostOptions) (api.TaskObjectResponse, error)
 	ListHosts(ctx context.Context, id, name, siteId, clusterId string) ([]api.Host, error)
+
+	SetHostMaintenance(ctx context.Context, id string, opts api.HostMaintenanceOptions) (api.HostMaintenance, error)
+	GetHostMaintenance(ctx context.Context, id string) (api.HostMaintenance, error)
 }
 
 func NewHostAPI(host string, cli client.Client) HostAPI {
@@ -115,3 +118,41 @@ func (c *clientConfig) ListHosts(ctx context.Context, id, name, siteId, clusterI
 
 	return list, err
 }
+
+func (c *clientConfig) SetHostMaintenance(ctx context.Context, id string, opts api.HostMaintenanceOptions) (api.HostMaintenance, error) {
+	uri := "/v1.0/manager/hosts/" + id + "/maintenance"
+
+	resp, err := requireOK(c.client.Put(ctx, uri, opts))
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+	defer resp.Body.Close()
+
+	s := api.HostMaintenance{}
+
+	err = decodeBody(resp, &s)
+	if err != nil {
+		return api.HostMaintenance{}, errors.Errorf("%s %s%s,%v", http.MethodPut, c.host, resp.Request.URL.String(), err)
+	}
+
+	return s, err
+}
+
+func (c *clientConfig) GetHostMaintenance(ctx context.Context, id string) (api.HostMaintenance, error) {
+	uri := "/v1.0/manager/hosts/" + id + "/maintenance"
+
+	resp, err := requireOK(c.client.Get(ctx, uri))
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+	defer resp.Body.Close()
+
+	s := api.HostMaintenance{}
+
+	err = decodeBody(resp, &s)
+	if err != nil {
+		return api.HostMaintenance{}, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return s, err
+}
@@ -0,0 +1,38 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+// listPage gets a page of the list endpoint,items is a pointer of slice.
+func (c *clientConfig) listPage(ctx context.Context, uri string, items interface{}) (api.ListMeta, error) {
+	resp, err := requireOK(c.client.Get(ctx, uri))
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+	defer resp.Body.Close()
+
+	var page struct {
+		Items      json.RawMessage `json:"items"`
+		Total      uint64          `json:"total"`
+		NextCursor string          `json:"next_cursor"`
+	}
+
+	err = decodeBody(resp, &page)
+	if err == nil {
+		err = json.Unmarshal(page.Items, items)
+	}
+	if err != nil {
+		return api.ListMeta{}, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return api.ListMeta{
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}, nil
+}
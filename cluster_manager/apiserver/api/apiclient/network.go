@@ -17,6 +17,7 @@ type NetworkAPI interface {
 	DeleteNetwork(ctx context.Context, id string) error
 	UpdateNetwork(ctx context.Context, id string, opts api.NetworkOptions) (api.TaskObjectResponse, error)
 	ListNetworks(ctx context.Context, name, id, siteId, clusterId, topology string) ([]api.Network, error)
+	ReleaseNetworkConflict(ctx context.Context, id, ip string) error
 }
 
 // NewNetworkAPI returns a NetworkAPI
@@ -59,6 +60,19 @@ func (c *clientConfig) DeleteNetwork(ctx context.Context, id string) error {
 	return nil
 }
 
+func (c *clientConfig) ReleaseNetworkConflict(ctx context.Context, id, ip string) error {
+	uri := "/v1.0/manager/networks/" + id + "/conflicts/" + ip
+
+	resp, err := requireOK(c.client.Delete(ctx, uri))
+	if err != nil {
+		return err
+	}
+
+	client.EnsureBodyClose(resp)
+
+	return nil
+}
+
 func (c *clientConfig) UpdateNetwork(ctx context.Context, id string, opts api.NetworkOptions) (api.TaskObjectResponse, error) {
 	uri := "/v1.0/manager/networks/" + id
 
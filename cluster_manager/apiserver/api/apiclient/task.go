@@ -14,6 +14,7 @@ var _ TaskAPI = &clientConfig{}
 
 type TaskAPI interface {
 	ListTasks(ctx context.Context, id, relatedId, action, status string) ([]api.Task, error)
+	ListTasksPage(ctx context.Context, opts api.ListOptions) ([]api.Task, api.ListMeta, error)
 }
 
 func NewTaskAPI(host string, cli client.Client) TaskAPI {
@@ -31,7 +32,7 @@ func (c *clientConfig) ListTasks(ctx context.Context, id, relatedId, action, sta
 	}
 
 	if relatedId != "" {
-		params.Set("related_id", relatedId)
+		params.Set("relate_id", relatedId)
 	}
 
 	if action != "" {
@@ -62,3 +63,16 @@ func (c *clientConfig) ListTasks(ctx context.Context, id, relatedId, action, sta
 
 	return list, err
 }
+
+func (c *clientConfig) ListTasksPage(ctx context.Context, opts api.ListOptions) ([]api.Task, api.ListMeta, error) {
+	url := url.URL{
+		Path:     "/v1.0/manager/tasks",
+		RawQuery: opts.Values().Encode(),
+	}
+
+	var list []api.Task
+
+	meta, err := c.listPage(ctx, url.RequestURI(), &list)
+
+	return list, meta, err
+}
@@ -0,0 +1,158 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/client"
+)
+
+var _ WebhookAPI = &clientConfig{}
+
+type WebhookAPI interface {
+	PostWebhook(ctx context.Context, config api.WebhookConfig) (api.ObjectResponse, error)
+	UpdateWebhook(ctx context.Context, id string, opts api.WebhookOptions) (api.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (api.Webhook, error)
+	ListWebhooks(ctx context.Context, app string) (api.WebhooksResponse, error)
+	DeleteWebhook(ctx context.Context, id string) error
+
+	ListWebhookDeliveries(ctx context.Context, id string, limit int) (api.WebhookDeliveriesResponse, error)
+}
+
+// NewWebhookAPI returns a WebhookAPI
+func NewWebhookAPI(host string, cli client.Client) WebhookAPI {
+	return &clientConfig{
+		host:   host,
+		client: cli,
+	}
+}
+
+func (c *clientConfig) PostWebhook(ctx context.Context, config api.WebhookConfig) (api.ObjectResponse, error) {
+	const uri = "/v1.0/manager/webhooks"
+
+	resp, err := requireOK(c.client.Post(ctx, uri, config))
+	if err != nil {
+		return api.ObjectResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	obj := api.ObjectResponse{}
+
+	err = decodeBody(resp, &obj)
+	if err != nil {
+		return api.ObjectResponse{}, errors.Errorf("%s %s%s,%v", http.MethodPost, c.host, resp.Request.URL.String(), err)
+	}
+
+	return obj, err
+}
+
+func (c *clientConfig) UpdateWebhook(ctx context.Context, id string, opts api.WebhookOptions) (api.Webhook, error) {
+	uri := "/v1.0/manager/webhooks/" + id
+
+	resp, err := requireOK(c.client.Put(ctx, uri, opts))
+	if err != nil {
+		return api.Webhook{}, err
+	}
+	defer resp.Body.Close()
+
+	wh := api.Webhook{}
+
+	err = decodeBody(resp, &wh)
+	if err != nil {
+		return api.Webhook{}, errors.Errorf("%s %s%s,%v", http.MethodPut, c.host, resp.Request.URL.String(), err)
+	}
+
+	return wh, err
+}
+
+func (c *clientConfig) GetWebhook(ctx context.Context, id string) (api.Webhook, error) {
+	uri := "/v1.0/manager/webhooks/" + id
+
+	resp, err := requireOK(c.client.Get(ctx, uri))
+	if err != nil {
+		return api.Webhook{}, err
+	}
+	defer resp.Body.Close()
+
+	wh := api.Webhook{}
+
+	err = decodeBody(resp, &wh)
+	if err != nil {
+		return api.Webhook{}, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return wh, err
+}
+
+func (c *clientConfig) ListWebhooks(ctx context.Context, app string) (api.WebhooksResponse, error) {
+	params := make(url.Values)
+
+	if app != "" {
+		params.Set("app_id", app)
+	}
+
+	url := url.URL{
+		Path:     "/v1.0/manager/webhooks",
+		RawQuery: params.Encode(),
+	}
+
+	resp, err := requireOK(c.client.Get(ctx, url.RequestURI()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list api.WebhooksResponse
+
+	err = decodeBody(resp, &list)
+	if err != nil {
+		return nil, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return list, err
+}
+
+func (c *clientConfig) DeleteWebhook(ctx context.Context, id string) error {
+	uri := "/v1.0/manager/webhooks/" + id
+
+	resp, err := requireOK(c.client.Delete(ctx, uri))
+	if err != nil {
+		return err
+	}
+
+	client.EnsureBodyClose(resp)
+
+	return nil
+}
+
+func (c *clientConfig) ListWebhookDeliveries(ctx context.Context, id string, limit int) (api.WebhookDeliveriesResponse, error) {
+	params := make(url.Values)
+
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	url := url.URL{
+		Path:     "/v1.0/manager/webhooks/" + id + "/deliveries",
+		RawQuery: params.Encode(),
+	}
+
+	resp, err := requireOK(c.client.Get(ctx, url.RequestURI()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list api.WebhookDeliveriesResponse
+
+	err = decodeBody(resp, &list)
+	if err != nil {
+		return nil, errors.Errorf("%s %s%s,%v", http.MethodGet, c.host, resp.Request.URL.String(), err)
+	}
+
+	return list, err
+}
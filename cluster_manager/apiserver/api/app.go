@@ -87,6 +87,8 @@ type DatabaseServices struct {
 type GroupSpec struct {
 	Image    ImageVersion `json:"image"`
 	Services ServiceSpec  `json:"services"`
+	// TemplateRevision 固定使用的镜像模板版本，0表示跟随镜像当前版本
+	TemplateRevision int `json:"template_revision,omitempty"`
 }
 
 type ServiceSpec struct {
@@ -520,6 +522,7 @@ type ExecAction struct {
 	Command   []string `json:"command,omitempty"`
 }
 
+// AppUserConfig pwd 为空时由服务端生成密码并保存到 Secret
 type AppUserConfig struct {
 	// require: false
 	Login *Login `json:"login,omitempty"`
@@ -612,11 +615,14 @@ type DBTable struct {
 
 type DBSchemaDetailResponse DBSchemaDetail
 
+// AppUserResetConfig pwd 为空时由服务端生成密码并保存到 Secret
 type AppUserResetConfig struct {
 	Name     string `json:"name"`
 	IP       string `json:"ip"`
 	AuthType string `json:"auth_type"`
 	Password string `json:"pwd"`
+	// 双密码轮换，旧密码在丢弃前仍然有效，需要 MySQL 8.0.14 及以上
+	RetainCurrent bool `json:"retain_current_password,omitempty"`
 }
 
 type UnitRoleSwitchConfig struct {
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// AuditLog 审计记录
+type AuditLog struct {
+	ID         string          `json:"id"`
+	User       string          `json:"user"`
+	Action     string          `json:"action"`
+	Route      string          `json:"route"`
+	Path       string          `json:"path"`
+	App        string          `json:"app_id,omitempty"`
+	RemoteAddr string          `json:"remote_addr"`
+	Code       int             `json:"code"`
+	Error      string          `json:"error,omitempty"`
+	Request    json.RawMessage `json:"request,omitempty"`
+	Changes    []AuditChange   `json:"changes,omitempty"`
+	Time       Time            `json:"time"`
+}
+
+// AuditChange 一次调用中变更的对象，before/after中的敏感字段已屏蔽
+type AuditChange struct {
+	Action string          `json:"action"`
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+type AuditLogsResponse []AuditLog
+
+// 审计操作，记录在AuditChange.Action
+const (
+	AuditDBUserCreate        = "db_user.create"
+	AuditDBUserDelete        = "db_user.delete"
+	AuditDBUserResetPassword = "db_user.reset_password"
+	AuditDBUserPrivileges    = "db_user.update_privileges"
+	AuditDBUserSpecUpdate    = "db_user.update_spec"
+	AuditDBUserSpecDelete    = "db_user.delete_spec"
+	AuditAppConfigUpdate     = "app.update_config"
+	AuditAppAlertConfig      = "app.update_alert_config"
+	AuditSiteDelete          = "site.delete"
+	AuditHostDelete          = "host.delete"
+	AuditSubscriptionQuota   = "subscription.update_quota"
+)
+
+// AuditQuery 审计记录查询条件
+type AuditQuery struct {
+	User   string
+	App    string
+	Action string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// DefaultAuditLimit 未指定limit时最多返回的记录数
+const DefaultAuditLimit = 500
+
+// ParseAuditTime parses the time of audit query,format is TimeFormat or RFC3339.
+func ParseAuditTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation(TimeFormat, s, time.Local); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, xerrors.Errorf("invalid time '%s',should be '%s' or RFC3339", s, TimeFormat)
+	}
+
+	return t, nil
+}
+
+func (q AuditQuery) Valid() error {
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return xerrors.New("since should be before until")
+	}
+
+	if q.Limit <= 0 {
+		return xerrors.New("limit should be greater than 0")
+	}
+
+	return nil
+}
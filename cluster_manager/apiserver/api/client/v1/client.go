@@ -0,0 +1,287 @@
+// Package client is the typed client of the cluster_manager apiserver api v1.
+//
+// Methods of Client are generated from the routes by ./gen,
+// run go generate after changing routes,incompatible changes should be
+// released as a new major version such as api/client/v2.
+package client
+
+//go:generate go run ./gen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+type Client struct {
+	host   string
+	client *http.Client
+	header http.Header
+}
+
+// NewClient returns the client of apiserver,host such as http://127.0.0.1:8080,
+// http.DefaultClient is used if cli is nil.
+func NewClient(host string, cli *http.Client) *Client {
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+
+	return &Client{
+		host:   strings.TrimSuffix(host, "/"),
+		client: cli,
+		header: http.Header{},
+	}
+}
+
+// SetHeader sets the header carried by all requests of the client,
+// such as X-DBScale-Subscription or X-DBScale-Admin-Token,
+// it should be called before the client is used.
+// The header set by WithHeader takes precedence.
+func (c *Client) SetHeader(key, value string) {
+	c.header.Set(key, value)
+}
+
+// Error is returned when the response status code isn't 2xx.
+type Error struct {
+	Method     string
+	URL        string
+	StatusCode int
+
+	// Code and Message are decoded from the error response
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s,%d:%s", e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// StatusCode returns the status code of the *Error,returns 0 if err isn't an *Error.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+
+	return 0
+}
+
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+type requestHeaderKey struct{}
+type responseHeaderKey struct{}
+
+// WithHeader returns a copy of ctx,requests with it carry the header,
+// such as Idempotency-Key or If-Match.
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	h := http.Header{}
+
+	if old, ok := ctx.Value(requestHeaderKey{}).(http.Header); ok {
+		h = old.Clone()
+	}
+
+	h.Set(key, value)
+
+	return context.WithValue(ctx, requestHeaderKey{}, h)
+}
+
+// WithResponseHeader returns a copy of ctx,the response header of requests with it
+// is copied into h,such as ETag.
+func WithResponseHeader(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, responseHeaderKey{}, h)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	uri := c.host + BasePath + path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+
+	var body io.Reader
+
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return errors.Errorf("%s %s,%v", method, uri, err)
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for key := range c.header {
+		req.Header.Set(key, c.header.Get(key))
+	}
+
+	if h, ok := ctx.Value(requestHeaderKey{}).(http.Header); ok {
+		for key := range h {
+			req.Header.Set(key, h.Get(key))
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if h, ok := ctx.Value(responseHeaderKey{}).(http.Header); ok && h != nil {
+		for key, values := range resp.Header {
+			h[key] = values
+		}
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		e := &Error{
+			Method:     method,
+			URL:        uri,
+			StatusCode: resp.StatusCode,
+		}
+
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		var er struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+
+		if json.Unmarshal(data, &er) == nil && er.Msg != "" {
+			e.Code, e.Message = er.Code, er.Msg
+		} else {
+			e.Message = strings.TrimSpace(string(data))
+		}
+
+		return e
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil && err != io.EOF {
+		return errors.Errorf("%s %s,decode response,%v", method, uri, err)
+	}
+
+	return nil
+}
+
+// listPage gets a page of the list endpoint,items is a pointer of slice.
+func (c *Client) listPage(ctx context.Context, path string, query url.Values, items interface{}) (api.ListMeta, error) {
+	// 未指定任何分页参数时接口返回全部列表
+	if !api.IsListRequest(query) {
+		query.Set("limit", strconv.Itoa(api.DefaultListLimit))
+	}
+
+	var page struct {
+		Items      json.RawMessage `json:"items"`
+		Total      uint64          `json:"total"`
+		NextCursor string          `json:"next_cursor"`
+	}
+
+	err := c.do(ctx, http.MethodGet, path, query, nil, &page)
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	if len(page.Items) > 0 {
+		err = json.Unmarshal(page.Items, items)
+		if err != nil {
+			return api.ListMeta{}, errors.Errorf("GET %s,decode items,%v", path, err)
+		}
+	}
+
+	return api.ListMeta{
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+// queryValues encodes the query struct by json tags,zero values are omitted.
+func queryValues(v interface{}) url.Values {
+	values := make(url.Values)
+
+	encodeQuery(reflect.ValueOf(v), values)
+
+	return values
+}
+
+func encodeQuery(v reflect.Value, values url.Values) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			encodeQuery(fv, values)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if fv.IsZero() {
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.Slice:
+			for j := 0; j < fv.Len(); j++ {
+				values.Add(name, fmt.Sprint(fv.Index(j).Interface()))
+			}
+		default:
+			values.Set(name, fmt.Sprint(fv.Interface()))
+		}
+	}
+}
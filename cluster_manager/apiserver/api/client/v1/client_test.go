@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+func TestClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/v1/manager/tasks":
+			q := r.URL.Query()
+
+			if q.Get("limit") != "" {
+				json.NewEncoder(w).Encode(api.ListResponse{
+					Items:      []api.Task{{ID: "t1"}},
+					Total:      2,
+					NextCursor: "next",
+				})
+				return
+			}
+
+			if q.Get("status") != "running" || q.Get("id") != "" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+
+			json.NewEncoder(w).Encode(api.TasksResponse{{ID: "t1"}, {ID: "t2"}})
+
+		case "/v1/manager/sites/s 1":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 404, "msg": "site not found"})
+
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer s.Close()
+
+	c := NewClient(s.URL, nil)
+	ctx := context.Background()
+
+	tasks, err := c.ListTasks(ctx, api.TaskListQuery{Status: "running"})
+	if err != nil || len(tasks) != 2 {
+		t.Errorf("list tasks,%v %v", tasks, err)
+	}
+
+	items, meta, err := c.ListTasksPage(ctx, api.TaskListQuery{})
+	if err != nil || len(items) != 1 || meta.Total != 2 || meta.NextCursor != "next" {
+		t.Errorf("list tasks page,%v %v %v", items, meta, err)
+	}
+
+	err = c.DeleteSite(ctx, "s 1")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found but got %v", err)
+	}
+
+	if e := err.(*Error); e.Code != 404 || e.Message != "site not found" {
+		t.Errorf("unexpected error %#v", e)
+	}
+}
+
+func TestClientHeader(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-DBScale-Admin-Token"); got != "secret" {
+			t.Errorf("unexpected admin token %q", got)
+		}
+		if got := r.Header.Get("X-DBScale-Subscription"); got != "t2" {
+			t.Errorf("unexpected subscription %q", got)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.TasksResponse{})
+	}))
+	defer s.Close()
+
+	c := NewClient(s.URL, nil)
+	c.SetHeader("X-DBScale-Admin-Token", "secret")
+	c.SetHeader("X-DBScale-Subscription", "t1")
+
+	ctx := WithHeader(context.Background(), "X-DBScale-Subscription", "t2")
+
+	if _, err := c.ListTasks(ctx, api.TaskListQuery{}); err != nil {
+		t.Error(err)
+	}
+}
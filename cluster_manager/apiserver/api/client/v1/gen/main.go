@@ -0,0 +1,225 @@
+// gen generates the OpenAPI document and the methods of client v1 from the routes of apiserver.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"go/format"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/openapi"
+	oas "github.com/upmio/dbscale-kube/pkg/server/openapi"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+	"k8s.io/klog/v2"
+)
+
+var (
+	clientFile  = "zz_generated.client.go"
+	openapiFile = "../../../openapi.json"
+
+	apiPkgPath = reflect.TypeOf(api.Task{}).PkgPath()
+
+	pathVarRegexp = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+)
+
+type method struct {
+	Name   string
+	Method string
+	// MethodName such as Get,used as http.MethodGet
+	MethodName string
+	Path       string
+	Summary    string
+
+	// PathExpr is the go expression of the path
+	PathExpr string
+	Vars     []string
+	Query    string
+	Body     string
+	Response string
+	// Item is the element type of Response if the route is paged
+	Item string
+}
+
+func main() {
+	flag.StringVar(&clientFile, "client", clientFile, "output file of client methods")
+	flag.StringVar(&openapiFile, "openapi", openapiFile, "output file of OpenAPI document")
+	flag.Parse()
+
+	routes := openapi.Routes()
+
+	doc, err := openapi.NewDocument(routes)
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(openapiFile, append(data, '\n'), 0644)
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	methods := make([]method, 0, len(routes))
+
+	for _, r := range routes {
+		dr, ok := r.(router.DocRoute)
+		if !ok || dr.Doc().ID == "" {
+			klog.Fatalf("%s %s has no doc", r.Method(), r.Path())
+		}
+
+		methods = append(methods, newMethod(r.Method(), r.Path(), dr.Doc()))
+	}
+
+	var buf bytes.Buffer
+
+	err = clientTemplate.Execute(&buf, struct {
+		BasePath string
+		Methods  []method
+	}{
+		BasePath: openapi.BasePath,
+		Methods:  methods,
+	})
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		klog.Fatalf("%s\n%s", err, buf.Bytes())
+	}
+
+	err = ioutil.WriteFile(clientFile, src, 0644)
+	if err != nil {
+		klog.Fatal(err)
+	}
+}
+
+func newMethod(httpMethod, path string, doc router.Doc) method {
+	p, vars := oas.PathTemplate(path)
+
+	m := method{
+		Name:       strings.ToUpper(doc.ID[:1]) + doc.ID[1:],
+		Method:     httpMethod,
+		MethodName: httpMethod[:1] + strings.ToLower(httpMethod[1:]),
+		Path:       p,
+		Summary:    doc.Summary,
+		Vars:       vars,
+	}
+
+	// "/manager/apps/{app}/units" => "/manager/apps/" + url.PathEscape(app) + "/units"
+	parts := []string{}
+	last := 0
+
+	for _, loc := range pathVarRegexp.FindAllStringSubmatchIndex(path, -1) {
+		if loc[0] > last {
+			parts = append(parts, `"`+path[last:loc[0]]+`"`)
+		}
+
+		parts = append(parts, "url.PathEscape("+path[loc[2]:loc[3]]+")")
+		last = loc[1]
+	}
+
+	if last < len(path) {
+		parts = append(parts, `"`+path[last:]+`"`)
+	}
+
+	m.PathExpr = strings.Join(parts, " + ")
+
+	if doc.Query != nil {
+		m.Query = typeName(reflect.TypeOf(doc.Query))
+	}
+
+	if doc.Body != nil {
+		m.Body = typeName(reflect.TypeOf(doc.Body))
+	}
+
+	if doc.Response != nil && doc.Code != http.StatusNoContent {
+		t := reflect.TypeOf(doc.Response)
+		m.Response = typeName(t)
+
+		if doc.Paged {
+			if t.Kind() != reflect.Slice || doc.Query == nil {
+				klog.Fatalf("%s is paged but response isn't a slice or no query", doc.ID)
+			}
+
+			m.Item = typeName(t.Elem())
+		}
+	}
+
+	return m
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + typeName(t.Elem())
+	case reflect.Slice:
+		if t.Name() == "" {
+			return "[]" + typeName(t.Elem())
+		}
+	case reflect.Map:
+		if t.Name() == "" {
+			return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+		}
+	case reflect.Interface:
+		if t.Name() == "" {
+			return "interface{}"
+		}
+	}
+
+	if t.PkgPath() != "" && t.PkgPath() != apiPkgPath {
+		klog.Fatalf("type %s isn't in package api", t)
+	}
+
+	return t.String()
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by gen. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+// BasePath is the version prefix of the api paths
+const BasePath = "{{.BasePath}}"
+{{range .Methods}}
+// {{.Name}} {{.Summary}}
+//
+// {{.Method}} {{.Path}}
+func (c *Client) {{.Name}}(ctx context.Context{{range .Vars}}, {{.}} string{{end}}{{if .Query}}, query {{.Query}}{{end}}{{if .Body}}, body {{.Body}}{{end}}) {{if .Response}}({{.Response}}, error){{else}}error{{end}} {
+	{{- if .Response}}
+	var out {{.Response}}
+
+	err := c.do(ctx, http.Method{{.MethodName}}, {{.PathExpr}}, {{if .Query}}queryValues(query){{else}}nil{{end}}, {{if .Body}}body{{else}}nil{{end}}, &out)
+
+	return out, err
+	{{- else}}
+	return c.do(ctx, http.Method{{.MethodName}}, {{.PathExpr}}, {{if .Query}}queryValues(query){{else}}nil{{end}}, {{if .Body}}body{{else}}nil{{end}}, nil)
+	{{- end}}
+}
+{{if .Item}}
+// {{.Name}}Page returns a page of {{.Name}},the page is decided by query.ListRequest.
+func (c *Client) {{.Name}}Page(ctx context.Context{{range .Vars}}, {{.}} string{{end}}, query {{.Query}}) ([]{{.Item}}, api.ListMeta, error) {
+	var items []{{.Item}}
+
+	meta, err := c.listPage(ctx, {{.PathExpr}}, queryValues(query), &items)
+
+	return items, meta, err
+}
+{{end}}{{end}}`))
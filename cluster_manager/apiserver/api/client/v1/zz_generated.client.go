@@ -0,0 +1,1435 @@
+// Code generated by gen. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+// BasePath is the version prefix of the api paths
+const BasePath = "/v1"
+
+// ListAudits 查询审计记录
+//
+// GET /manager/audit
+func (c *Client) ListAudits(ctx context.Context, query api.AuditListQuery) (api.AuditLogsResponse, error) {
+	var out api.AuditLogsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/audit", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostSite 增加站点
+//
+// POST /manager/sites
+func (c *Client) PostSite(ctx context.Context, body api.SiteConfig) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/sites", nil, body, &out)
+
+	return out, err
+}
+
+// PreflightSite 站点预检，检查API连通性、版本、CRD、exec服务、prometheus operator及存储类，不保存站点
+//
+// POST /manager/sites/preflight
+func (c *Client) PreflightSite(ctx context.Context, body api.SiteConfig) (api.SitePreflight, error) {
+	var out api.SitePreflight
+
+	err := c.do(ctx, http.MethodPost, "/manager/sites/preflight", nil, body, &out)
+
+	return out, err
+}
+
+// ListSites 查询站点
+//
+// GET /manager/sites
+func (c *Client) ListSites(ctx context.Context, query api.SiteListQuery) (api.SitesResponse, error) {
+	var out api.SitesResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/sites", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListSitesPage returns a page of ListSites,the page is decided by query.ListRequest.
+func (c *Client) ListSitesPage(ctx context.Context, query api.SiteListQuery) ([]api.Site, api.ListMeta, error) {
+	var items []api.Site
+
+	meta, err := c.listPage(ctx, "/manager/sites", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// UpdateSite 更新站点
+//
+// PUT /manager/sites/{id}
+func (c *Client) UpdateSite(ctx context.Context, id string, body api.SiteOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/sites/"+url.PathEscape(id), nil, body, nil)
+}
+
+// DeleteSite 删除站点
+//
+// DELETE /manager/sites/{id}
+func (c *Client) DeleteSite(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/sites/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ListTasks 查询任务列表
+//
+// GET /manager/tasks
+func (c *Client) ListTasks(ctx context.Context, query api.TaskListQuery) (api.TasksResponse, error) {
+	var out api.TasksResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/tasks", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListTasksPage returns a page of ListTasks,the page is decided by query.ListRequest.
+func (c *Client) ListTasksPage(ctx context.Context, query api.TaskListQuery) ([]api.Task, api.ListMeta, error) {
+	var items []api.Task
+
+	meta, err := c.listPage(ctx, "/manager/tasks", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// PostNetwork 增加网络
+//
+// POST /manager/networks
+func (c *Client) PostNetwork(ctx context.Context, body api.NetworkConfig) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/networks", nil, body, &out)
+
+	return out, err
+}
+
+// ListNetworks 查询网络
+//
+// GET /manager/networks
+func (c *Client) ListNetworks(ctx context.Context, query api.NetworkListQuery) (api.NetworksResponse, error) {
+	var out api.NetworksResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/networks", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListNetworksPage returns a page of ListNetworks,the page is decided by query.ListRequest.
+func (c *Client) ListNetworksPage(ctx context.Context, query api.NetworkListQuery) ([]api.Network, api.ListMeta, error) {
+	var items []api.Network
+
+	meta, err := c.listPage(ctx, "/manager/networks", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// UpdateNetwork 更新网络
+//
+// PUT /manager/networks/{id}
+func (c *Client) UpdateNetwork(ctx context.Context, id string, body api.NetworkOptions) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/networks/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteNetwork 删除网络
+//
+// DELETE /manager/networks/{id}
+func (c *Client) DeleteNetwork(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/networks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ReleaseConflict 释放冲突IP
+//
+// DELETE /manager/networks/{id}/conflicts/{ip}
+func (c *Client) ReleaseConflict(ctx context.Context, id string, ip string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/networks/"+url.PathEscape(id)+"/conflicts/"+url.PathEscape(ip), nil, nil, nil)
+}
+
+// ListImages 查询镜像
+//
+// GET /manager/images
+func (c *Client) ListImages(ctx context.Context, query api.ImageListOptions) (api.ImagesResponse, error) {
+	var out api.ImagesResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/images", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListImageSets 查询多架构镜像集合，同一类型和版本的各架构镜像
+//
+// GET /manager/images/sets
+func (c *Client) ListImageSets(ctx context.Context, query api.ImageListOptions) (api.ImageSetsResponse, error) {
+	var out api.ImageSetsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/sets", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostImage 增加镜像
+//
+// POST /manager/images
+func (c *Client) PostImage(ctx context.Context, body api.ImageConfig) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/images", nil, body, &out)
+
+	return out, err
+}
+
+// UpdateImage 更新镜像
+//
+// PUT /manager/images/{id}
+func (c *Client) UpdateImage(ctx context.Context, id string, body api.ImageOptions) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/images/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// UpdateImageLifecycle 设置镜像的弃用及停止支持时间
+//
+// PUT /manager/images/{id}/lifecycle
+func (c *Client) UpdateImageLifecycle(ctx context.Context, id string, body api.ImageLifecycleOptions) (api.Image, error) {
+	var out api.Image
+
+	err := c.do(ctx, http.MethodPut, "/manager/images/"+url.PathEscape(id)+"/lifecycle", nil, body, &out)
+
+	return out, err
+}
+
+// DeleteImage 删除镜像
+//
+// DELETE /manager/images/{id}
+func (c *Client) DeleteImage(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/images/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ListImageTemplates 查询镜像模板
+//
+// GET /manager/images/{id}/templates
+func (c *Client) ListImageTemplates(ctx context.Context, id string) (api.ImageTemplate, error) {
+	var out api.ImageTemplate
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/"+url.PathEscape(id)+"/templates", nil, nil, &out)
+
+	return out, err
+}
+
+// UpdateImageTemplate 更新镜像模板
+//
+// PUT /manager/images/{id}/templates
+func (c *Client) UpdateImageTemplate(ctx context.Context, id string, body api.ConfigTemplateOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/images/"+url.PathEscape(id)+"/templates", nil, body, &out)
+
+	return out, err
+}
+
+// ListImageScripts 查询镜像脚本
+//
+// GET /manager/images/{id}/scripts
+func (c *Client) ListImageScripts(ctx context.Context, id string) (api.ImageScripts, error) {
+	var out api.ImageScripts
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/"+url.PathEscape(id)+"/scripts", nil, nil, &out)
+
+	return out, err
+}
+
+// SyncImageScripts 同步镜像脚本
+//
+// PUT /manager/images/{id}/scripts
+func (c *Client) SyncImageScripts(ctx context.Context, id string, query api.ImageScriptsQuery) error {
+	return c.do(ctx, http.MethodPut, "/manager/images/"+url.PathEscape(id)+"/scripts", queryValues(query), nil, nil)
+}
+
+// SyncMaintenanceImageScripts 同步镜像脚本(维护)
+//
+// PUT /maintenance/images/{id}/scripts
+func (c *Client) SyncMaintenanceImageScripts(ctx context.Context, id string, query api.ImageScriptsQuery) error {
+	return c.do(ctx, http.MethodPut, "/maintenance/images/"+url.PathEscape(id)+"/scripts", queryValues(query), nil, nil)
+}
+
+// ImageLifecycleReport 查询仍在使用弃用或停止支持镜像的服务
+//
+// GET /manager/images/lifecycle/report
+func (c *Client) ImageLifecycleReport(ctx context.Context, query api.ImageLifecycleQuery) (api.ImageLifecycleReportResponse, error) {
+	var out api.ImageLifecycleReportResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/lifecycle/report", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostImageCampaign 创建镜像升级活动，在维护窗口内按并发数升级服务镜像
+//
+// POST /manager/images/campaigns
+func (c *Client) PostImageCampaign(ctx context.Context, body api.ImageCampaignConfig) (api.ImageCampaign, error) {
+	var out api.ImageCampaign
+
+	err := c.do(ctx, http.MethodPost, "/manager/images/campaigns", nil, body, &out)
+
+	return out, err
+}
+
+// ListImageCampaigns 查询镜像升级活动
+//
+// GET /manager/images/campaigns
+func (c *Client) ListImageCampaigns(ctx context.Context, query api.ImageCampaignListQuery) (api.ImageCampaignsResponse, error) {
+	var out api.ImageCampaignsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/campaigns", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// GetImageCampaign 查询镜像升级活动的进度
+//
+// GET /manager/images/campaigns/{id}
+func (c *Client) GetImageCampaign(ctx context.Context, id string) (api.ImageCampaign, error) {
+	var out api.ImageCampaign
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/campaigns/"+url.PathEscape(id), nil, nil, &out)
+
+	return out, err
+}
+
+// CancelImageCampaign 取消镜像升级活动，已开始的升级继续执行
+//
+// POST /manager/images/campaigns/{id}/cancel
+func (c *Client) CancelImageCampaign(ctx context.Context, id string) (api.ImageCampaign, error) {
+	var out api.ImageCampaign
+
+	err := c.do(ctx, http.MethodPost, "/manager/images/campaigns/"+url.PathEscape(id)+"/cancel", nil, nil, &out)
+
+	return out, err
+}
+
+// ListImageTemplateRevisions 查询镜像模板及脚本的历史版本
+//
+// GET /manager/images/{id}/templates/revisions
+func (c *Client) ListImageTemplateRevisions(ctx context.Context, id string) (api.ImageTemplateRevisionsResponse, error) {
+	var out api.ImageTemplateRevisionsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/"+url.PathEscape(id)+"/templates/revisions", nil, nil, &out)
+
+	return out, err
+}
+
+// GetImageTemplateRevision 查询镜像模板版本的内容
+//
+// GET /manager/images/{id}/templates/revisions/{revision}
+func (c *Client) GetImageTemplateRevision(ctx context.Context, id string, revision string) (api.ImageTemplateRevision, error) {
+	var out api.ImageTemplateRevision
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/"+url.PathEscape(id)+"/templates/revisions/"+url.PathEscape(revision), nil, nil, &out)
+
+	return out, err
+}
+
+// DiffImageTemplateRevisions 并排对比镜像模板的两个版本，版本号为空表示最新版本
+//
+// GET /manager/images/{id}/templates/diff
+func (c *Client) DiffImageTemplateRevisions(ctx context.Context, id string, query api.ImageTemplateDiffQuery) (api.ImageTemplateDiff, error) {
+	var out api.ImageTemplateDiff
+
+	err := c.do(ctx, http.MethodGet, "/manager/images/"+url.PathEscape(id)+"/templates/diff", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// RollbackImageTemplate 回滚镜像模板到指定版本，回滚记录为新的版本
+//
+// POST /manager/images/{id}/templates/rollback
+func (c *Client) RollbackImageTemplate(ctx context.Context, id string, body api.ImageTemplateRollbackOptions) (api.ImageTemplateRevision, error) {
+	var out api.ImageTemplateRevision
+
+	err := c.do(ctx, http.MethodPost, "/manager/images/"+url.PathEscape(id)+"/templates/rollback", nil, body, &out)
+
+	return out, err
+}
+
+// PinAppImageTemplate 固定服务使用的镜像模板版本，版本号为0表示跟随镜像当前版本
+//
+// PUT /manager/apps/{id}/templates/pin
+func (c *Client) PinAppImageTemplate(ctx context.Context, id string, body api.AppTemplatePinOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(id)+"/templates/pin", nil, body, &out)
+
+	return out, err
+}
+
+// ListHosts 查询主机
+//
+// GET /manager/hosts
+func (c *Client) ListHosts(ctx context.Context, query api.HostListQuery) (api.HostsResponse, error) {
+	var out api.HostsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/hosts", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListHostsPage returns a page of ListHosts,the page is decided by query.ListRequest.
+func (c *Client) ListHostsPage(ctx context.Context, query api.HostListQuery) ([]api.Host, api.ListMeta, error) {
+	var items []api.Host
+
+	meta, err := c.listPage(ctx, "/manager/hosts", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// PostHost 增加主机
+//
+// POST /manager/hosts
+func (c *Client) PostHost(ctx context.Context, body api.HostConfig) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/hosts", nil, body, &out)
+
+	return out, err
+}
+
+// UpdateHost 更新主机
+//
+// PUT /manager/hosts/{id}
+func (c *Client) UpdateHost(ctx context.Context, id string, body api.HostOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/hosts/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteHost 删除主机
+//
+// DELETE /manager/hosts/{id}
+func (c *Client) DeleteHost(ctx context.Context, id string, query api.HostDeleteQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/hosts/"+url.PathEscape(id), queryValues(query), nil, nil)
+}
+
+// GetHostDetail 查询主机详情
+//
+// GET /manager/hosts/{id}/detail
+func (c *Client) GetHostDetail(ctx context.Context, id string) (api.HostDetail, error) {
+	var out api.HostDetail
+
+	err := c.do(ctx, http.MethodGet, "/manager/hosts/"+url.PathEscape(id)+"/detail", nil, nil, &out)
+
+	return out, err
+}
+
+// SetHostMaintenance 设置主机维护模式
+//
+// PUT /manager/hosts/{id}/maintenance
+func (c *Client) SetHostMaintenance(ctx context.Context, id string, body api.HostMaintenanceOptions) (api.HostMaintenance, error) {
+	var out api.HostMaintenance
+
+	err := c.do(ctx, http.MethodPut, "/manager/hosts/"+url.PathEscape(id)+"/maintenance", nil, body, &out)
+
+	return out, err
+}
+
+// GetHostMaintenance 查询主机维护模式
+//
+// GET /manager/hosts/{id}/maintenance
+func (c *Client) GetHostMaintenance(ctx context.Context, id string) (api.HostMaintenance, error) {
+	var out api.HostMaintenance
+
+	err := c.do(ctx, http.MethodGet, "/manager/hosts/"+url.PathEscape(id)+"/maintenance", nil, nil, &out)
+
+	return out, err
+}
+
+// ValidateHost 主机合规检查
+//
+// POST /manager/hosts/validation
+func (c *Client) ValidateHost(ctx context.Context, query api.TypeQuery, body api.HostConfig) error {
+	return c.do(ctx, http.MethodPost, "/manager/hosts/validation", queryValues(query), body, nil)
+}
+
+// ListClusters 查询集群
+//
+// GET /manager/clusters
+func (c *Client) ListClusters(ctx context.Context, query api.ClusterListQuery) (api.ClustersResponse, error) {
+	var out api.ClustersResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/clusters", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListClustersPage returns a page of ListClusters,the page is decided by query.ListRequest.
+func (c *Client) ListClustersPage(ctx context.Context, query api.ClusterListQuery) ([]api.Cluster, api.ListMeta, error) {
+	var items []api.Cluster
+
+	meta, err := c.listPage(ctx, "/manager/clusters", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// PostCluster 增加集群
+//
+// POST /manager/clusters
+func (c *Client) PostCluster(ctx context.Context, body api.ClusterConfig) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/clusters", nil, body, &out)
+
+	return out, err
+}
+
+// UpdateCluster 更新集群
+//
+// PUT /manager/clusters/{id}
+func (c *Client) UpdateCluster(ctx context.Context, id string, body api.ClusterOptions) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/clusters/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteCluster 删除集群
+//
+// DELETE /manager/clusters/{id}
+func (c *Client) DeleteCluster(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/clusters/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// PostRemoteStorage 增加外置存储
+//
+// POST /manager/storages/remote
+func (c *Client) PostRemoteStorage(ctx context.Context, body api.RemoteStorageConfig) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/storages/remote", nil, body, &out)
+
+	return out, err
+}
+
+// ListRemoteStorages 查询外置存储
+//
+// GET /manager/storages/remote
+func (c *Client) ListRemoteStorages(ctx context.Context, query api.RemoteStorageListQuery) (api.RemoteStoragesResponse, error) {
+	var out api.RemoteStoragesResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/storages/remote", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// UpdateRemoteStorage 更新外置存储
+//
+// PUT /manager/storages/remote/{id}
+func (c *Client) UpdateRemoteStorage(ctx context.Context, id string, body api.RemoteStorageOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/storages/remote/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteRemoteStorage 删除外置存储
+//
+// DELETE /manager/storages/remote/{id}
+func (c *Client) DeleteRemoteStorage(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/storages/remote/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ListRemoteStoragePools 查询外置存储池
+//
+// GET /manager/storages/remote/{storage}/pools
+func (c *Client) ListRemoteStoragePools(ctx context.Context, storage string, query api.IDNameQuery) (api.RemoteStoragePoolsResponse, error) {
+	var out api.RemoteStoragePoolsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/storages/remote/"+url.PathEscape(storage)+"/pools", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostRemoteStoragePool 增加外置存储池
+//
+// POST /manager/storages/remote/{storage}/pools
+func (c *Client) PostRemoteStoragePool(ctx context.Context, storage string, body api.RemoteStoragePoolConfig) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/storages/remote/"+url.PathEscape(storage)+"/pools", nil, body, &out)
+
+	return out, err
+}
+
+// UpdateRemoteStoragePool 更新外置存储池
+//
+// PUT /manager/storages/remote/{storage}/pools/{pool}
+func (c *Client) UpdateRemoteStoragePool(ctx context.Context, storage string, pool string, body api.RemoteStoragePoolOptions) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/storages/remote/"+url.PathEscape(storage)+"/pools/"+url.PathEscape(pool), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteRemoteStoragePool 删除外置存储池
+//
+// DELETE /manager/storages/remote/{storage}/pools/{pool}
+func (c *Client) DeleteRemoteStoragePool(ctx context.Context, storage string, pool string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/storages/remote/"+url.PathEscape(storage)+"/pools/"+url.PathEscape(pool), nil, nil, nil)
+}
+
+// ListSubscriptions 查询订阅，限定订阅的请求只返回自身订阅
+//
+// GET /manager/subscriptions
+func (c *Client) ListSubscriptions(ctx context.Context, query api.SubscriptionListQuery) (api.SubscriptionsResponse, error) {
+	var out api.SubscriptionsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/subscriptions", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostSubscription 增加订阅及配额
+//
+// POST /manager/subscriptions
+func (c *Client) PostSubscription(ctx context.Context, body api.SubscriptionConfig) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/subscriptions", nil, body, &out)
+
+	return out, err
+}
+
+// GetSubscription 查询订阅详情
+//
+// GET /manager/subscriptions/{id}
+func (c *Client) GetSubscription(ctx context.Context, id string) (api.Subscription, error) {
+	var out api.Subscription
+
+	err := c.do(ctx, http.MethodGet, "/manager/subscriptions/"+url.PathEscape(id), nil, nil, &out)
+
+	return out, err
+}
+
+// SetSubscription 更新订阅及配额
+//
+// PUT /manager/subscriptions/{id}
+func (c *Client) SetSubscription(ctx context.Context, id string, body api.SubscriptionOptions) (api.Subscription, error) {
+	var out api.Subscription
+
+	err := c.do(ctx, http.MethodPut, "/manager/subscriptions/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteSubscription 删除订阅，订阅下存在服务时不能删除
+//
+// DELETE /manager/subscriptions/{id}
+func (c *Client) DeleteSubscription(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/subscriptions/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetSubscriptionUsage 查询订阅的配额及各服务的资源用量
+//
+// GET /manager/subscriptions/{id}/usage
+func (c *Client) GetSubscriptionUsage(ctx context.Context, id string) (api.SubscriptionUsage, error) {
+	var out api.SubscriptionUsage
+
+	err := c.do(ctx, http.MethodGet, "/manager/subscriptions/"+url.PathEscape(id)+"/usage", nil, nil, &out)
+
+	return out, err
+}
+
+// ListUsage 按日或按月汇总服务或订阅的资源用量，限定订阅的请求只返回自身订阅
+//
+// GET /manager/usage
+func (c *Client) ListUsage(ctx context.Context, query api.UsageListQuery) (api.UsageResponse, error) {
+	var out api.UsageResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/usage", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ExportUsage 导出资源用量，返回text/csv，查询参数同listUsage
+//
+// GET /manager/usage/export
+func (c *Client) ExportUsage(ctx context.Context, query api.UsageListQuery) error {
+	return c.do(ctx, http.MethodGet, "/manager/usage/export", queryValues(query), nil, nil)
+}
+
+// PostApp 增加新服务
+//
+// POST /manager/apps
+func (c *Client) PostApp(ctx context.Context, query api.SubscriptionQuery, body api.AppConfig) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/apps", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// ListApps 查询服务
+//
+// GET /manager/apps
+func (c *Client) ListApps(ctx context.Context, query api.AppListQuery) (api.AppsResponse, error) {
+	var out api.AppsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListAppsPage returns a page of ListApps,the page is decided by query.ListRequest.
+func (c *Client) ListAppsPage(ctx context.Context, query api.AppListQuery) ([]api.Application, api.ListMeta, error) {
+	var items []api.Application
+
+	meta, err := c.listPage(ctx, "/manager/apps", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// ListAppsWithPagination 分页查询服务
+//
+// GET /pagination/apps
+func (c *Client) ListAppsWithPagination(ctx context.Context, query api.AppPaginationQuery) (api.PaginationResp, error) {
+	var out api.PaginationResp
+
+	err := c.do(ctx, http.MethodGet, "/pagination/apps", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListAppsDetail 查询服务详情
+//
+// GET /manager/apps/detail
+func (c *Client) ListAppsDetail(ctx context.Context, query api.AppListQuery) (api.AppsResponse, error) {
+	var out api.AppsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/detail", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListAppsDetailPage returns a page of ListAppsDetail,the page is decided by query.ListRequest.
+func (c *Client) ListAppsDetailPage(ctx context.Context, query api.AppListQuery) ([]api.Application, api.ListMeta, error) {
+	var items []api.Application
+
+	meta, err := c.listPage(ctx, "/manager/apps/detail", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// DeleteApp 删除服务
+//
+// DELETE /manager/apps/{app}
+func (c *Client) DeleteApp(ctx context.Context, app string, query api.SubscriptionQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/apps/"+url.PathEscape(app), queryValues(query), nil, nil)
+}
+
+// UpdateAppArch 更新服务架构
+//
+// PUT /manager/apps/{app}/arch
+func (c *Client) UpdateAppArch(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppArchOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/arch", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// UpdateAppImage 更新服务镜像
+//
+// PUT /manager/apps/{app}/image
+func (c *Client) UpdateAppImage(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppImageOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/image", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// UpdateAppState 更新服务状态
+//
+// PUT /manager/apps/{app}/state
+func (c *Client) UpdateAppState(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppStateOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/state", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// UpdateAppResources 更新服务资源
+//
+// PUT /manager/apps/{app}/resource/requests
+func (c *Client) UpdateAppResources(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppResourcesOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/resource/requests", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// SetUnitRole 设置单元角色
+//
+// PUT /manager/apps/{app}/units/{unit}/role
+func (c *Client) SetUnitRole(ctx context.Context, app string, unit string, query api.SubscriptionQuery, body api.RoleSetOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/units/"+url.PathEscape(unit)+"/role", queryValues(query), body, nil)
+}
+
+// UpdateUnitState 更新单元状态
+//
+// PUT /manager/apps/{app}/units/{unit}/state
+func (c *Client) UpdateUnitState(ctx context.Context, app string, unit string, query api.SubscriptionQuery, body api.AppStateOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/units/"+url.PathEscape(unit)+"/state", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// RebuildUnit 重建单元
+//
+// PUT /manager/apps/{app}/units/{unit}/rebuild
+func (c *Client) RebuildUnit(ctx context.Context, app string, unit string, query api.SubscriptionQuery, body api.UnitRebuildOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/units/"+url.PathEscape(unit)+"/rebuild", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// MigrateUnit 迁移单元
+//
+// PUT /manager/apps/{app}/units/{unit}/migrate
+func (c *Client) MigrateUnit(ctx context.Context, app string, unit string, query api.SubscriptionQuery, body api.UnitMigrateOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/units/"+url.PathEscape(unit)+"/migrate", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// RestoreUnit 单元备份恢复
+//
+// PUT /manager/apps/{app}/units/{unit}/restore
+func (c *Client) RestoreUnit(ctx context.Context, app string, unit string, query api.SubscriptionQuery, body api.UnitRestoreOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/units/"+url.PathEscape(unit)+"/restore", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// UpdateUnitResources 更新单元资源
+//
+// PUT /manager/apps/{app}/units/{unit}/resource/requests
+func (c *Client) UpdateUnitResources(ctx context.Context, app string, unit string, query api.SubscriptionQuery, body api.AppResourcesOptions) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/units/"+url.PathEscape(unit)+"/resource/requests", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// RoleSwitch 单元角色切换
+//
+// PUT /manager/apps/{app}/role
+func (c *Client) RoleSwitch(ctx context.Context, app string, query api.SubscriptionQuery, body api.UnitRoleSwitchConfig) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/role", queryValues(query), body, nil)
+}
+
+// ListAppConfig 查询服务配置
+//
+// GET /manager/apps/{app}/config
+func (c *Client) ListAppConfig(ctx context.Context, app string, query api.SubscriptionQuery) (api.ConfigMapResponse, error) {
+	var out api.ConfigMapResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/config", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// UpdateAppConfig 更新服务配置
+//
+// PUT /manager/apps/{app}/config
+func (c *Client) UpdateAppConfig(ctx context.Context, app string, query api.SubscriptionQuery, body api.ConfigMapOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/config", queryValues(query), body, nil)
+}
+
+// ListAppDBUsers 查询数据库用户
+//
+// GET /manager/apps/{app}/database/users
+func (c *Client) ListAppDBUsers(ctx context.Context, app string, query api.SubscriptionQuery) (api.AppUsersResponse, error) {
+	var out api.AppUsersResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/users", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// GetAppDBUser 查询单个数据库用户
+//
+// GET /manager/apps/{app}/database/users/{user}
+func (c *Client) GetAppDBUser(ctx context.Context, app string, user string, query api.DBUserQuery) (api.DatabaseUser, error) {
+	var out api.DatabaseUser
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/users/"+url.PathEscape(user), queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostAppDBUser 增加数据库用户，未指定密码时由服务端生成并保存到服务namespace的Secret
+//
+// POST /manager/apps/{app}/database/users
+func (c *Client) PostAppDBUser(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppUserConfig) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/apps/"+url.PathEscape(app)+"/database/users", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// ResetAppDBUserPassword 重置数据库用户密码，未指定密码时由服务端生成并保存到Secret
+//
+// PUT /manager/apps/{app}/database/users/pwd
+func (c *Client) ResetAppDBUserPassword(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppUserResetConfig) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/database/users/pwd", queryValues(query), body, nil)
+}
+
+// DiscardAppDBUserOldPassword 丢弃双密码轮换保留的旧密码
+//
+// PUT /manager/apps/{app}/database/users/pwd/discard
+func (c *Client) DiscardAppDBUserOldPassword(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppUserDiscardPasswordConfig) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/database/users/pwd/discard", queryValues(query), body, nil)
+}
+
+// ListAppDBUserCredentials 查询数据库用户的密码状态，包括Secret及过期时间，不包含密码
+//
+// GET /manager/apps/{app}/database/credentials
+func (c *Client) ListAppDBUserCredentials(ctx context.Context, app string, query api.SubscriptionQuery) (api.DBUserCredentialsResponse, error) {
+	var out api.DBUserCredentialsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/credentials", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// GetPasswordPolicy 查询数据库用户的密码策略
+//
+// GET /manager/password_policy
+func (c *Client) GetPasswordPolicy(ctx context.Context) (api.PasswordPolicy, error) {
+	var out api.PasswordPolicy
+
+	err := c.do(ctx, http.MethodGet, "/manager/password_policy", nil, nil, &out)
+
+	return out, err
+}
+
+// DeleteAppDBUser 删除数据库用户
+//
+// DELETE /manager/apps/{app}/database/users/{user}
+func (c *Client) DeleteAppDBUser(ctx context.Context, app string, user string, query api.DBUserQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/apps/"+url.PathEscape(app)+"/database/users/"+url.PathEscape(user), queryValues(query), nil, nil)
+}
+
+// UpdateAppDBUserPrivileges 更新数据库用户权限
+//
+// PUT /manager/apps/{app}/database/users/privileges
+func (c *Client) UpdateAppDBUserPrivileges(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppUserPrivilegesOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/database/users/privileges", queryValues(query), body, nil)
+}
+
+// ListAppDBSchemas 查询数据库
+//
+// GET /manager/apps/{app}/database/schemas
+func (c *Client) ListAppDBSchemas(ctx context.Context, app string, query api.SubscriptionQuery) (api.DBSchemaResponse, error) {
+	var out api.DBSchemaResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/schemas", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// GetAppDBSchema 查询数据库详情
+//
+// GET /manager/apps/{app}/database/schemas/{schema}
+func (c *Client) GetAppDBSchema(ctx context.Context, app string, schema string, query api.SubscriptionQuery) (api.DBSchemaDetailResponse, error) {
+	var out api.DBSchemaDetailResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/schemas/"+url.PathEscape(schema), queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostAppDBSchema 增加数据库
+//
+// POST /manager/apps/{app}/database/schemas
+func (c *Client) PostAppDBSchema(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppSchemaConfig) (api.TaskObjectResponse, error) {
+	var out api.TaskObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/apps/"+url.PathEscape(app)+"/database/schemas", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// DeleteAppDBSchema 删除数据库
+//
+// DELETE /manager/apps/{app}/database/schemas/{schema}
+func (c *Client) DeleteAppDBSchema(ctx context.Context, app string, schema string, query api.SubscriptionQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/apps/"+url.PathEscape(app)+"/database/schemas/"+url.PathEscape(schema), queryValues(query), nil, nil)
+}
+
+// GetCmhaTopology 查询cmha拓扑
+//
+// GET /manager/apps/{app}/topology
+func (c *Client) GetCmhaTopology(ctx context.Context, app string, query api.SubscriptionQuery) (api.CmhaTopology, error) {
+	var out api.CmhaTopology
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/topology", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// SetCmhaReplMode 设置复制模式
+//
+// PUT /manager/apps/{app}/replication/semi_sync
+func (c *Client) SetCmhaReplMode(ctx context.Context, app string, query api.SubscriptionQuery, body api.CmhaReplModeOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/replication/semi_sync", queryValues(query), body, nil)
+}
+
+// SetCmhaReplSource 设置复制源
+//
+// PUT /manager/apps/{app}/replication/set_source
+func (c *Client) SetCmhaReplSource(ctx context.Context, app string, query api.SubscriptionQuery, body api.CmhaReplModeOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/replication/set_source", queryValues(query), body, nil)
+}
+
+// SetCmhaMaintenance 设置cmha维护模式
+//
+// PUT /manager/apps/{app}/maintenance
+func (c *Client) SetCmhaMaintenance(ctx context.Context, app string, query api.SubscriptionQuery, body api.CmhaMaintenanceOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/maintenance", queryValues(query), body, nil)
+}
+
+// PlanAppManifest 生成服务manifest的执行计划
+//
+// POST /manager/apps/manifests/plan
+func (c *Client) PlanAppManifest(ctx context.Context, query api.SubscriptionQuery, body api.AppManifest) (api.AppManifestPlan, error) {
+	var out api.AppManifestPlan
+
+	err := c.do(ctx, http.MethodPost, "/manager/apps/manifests/plan", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// ApplyAppManifest 在一个任务中执行服务manifest
+//
+// POST /manager/apps/manifests/apply
+func (c *Client) ApplyAppManifest(ctx context.Context, query api.SubscriptionQuery, body api.AppManifest) (api.AppManifestPlan, error) {
+	var out api.AppManifestPlan
+
+	err := c.do(ctx, http.MethodPost, "/manager/apps/manifests/apply", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// GetAppDBUserSpec 查询服务期望的数据库用户及最近一次比较的结果
+//
+// GET /manager/apps/{app}/database/user_spec
+func (c *Client) GetAppDBUserSpec(ctx context.Context, app string, query api.SubscriptionQuery) (api.AppDBUserSpecResponse, error) {
+	var out api.AppDBUserSpecResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/user_spec", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// SetAppDBUserSpec 设置服务期望的数据库用户及权限，report 只报告差异，enforce 创建缺少的用户并修正权限
+//
+// PUT /manager/apps/{app}/database/user_spec
+func (c *Client) SetAppDBUserSpec(ctx context.Context, app string, query api.SubscriptionQuery, body api.AppDBUserSpec) (api.AppDBUserSpecResponse, error) {
+	var out api.AppDBUserSpecResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/database/user_spec", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// DeleteAppDBUserSpec 删除服务期望的数据库用户，不影响实例中的用户
+//
+// DELETE /manager/apps/{app}/database/user_spec
+func (c *Client) DeleteAppDBUserSpec(ctx context.Context, app string, query api.SubscriptionQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/apps/"+url.PathEscape(app)+"/database/user_spec", queryValues(query), nil, nil)
+}
+
+// ReconcileAppDBUser 立即比较服务的数据库用户与期望
+//
+// POST /manager/apps/{app}/database/user_spec/reconcile
+func (c *Client) ReconcileAppDBUser(ctx context.Context, app string, query api.SubscriptionQuery) (api.DBUserReconcileStatus, error) {
+	var out api.DBUserReconcileStatus
+
+	err := c.do(ctx, http.MethodPost, "/manager/apps/"+url.PathEscape(app)+"/database/user_spec/reconcile", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListAppUnmanagedDBUsers 查询实例中不在期望中的数据库用户
+//
+// GET /manager/apps/{app}/database/unmanaged_users
+func (c *Client) ListAppUnmanagedDBUsers(ctx context.Context, app string, query api.SubscriptionQuery) (api.AppUsersResponse, error) {
+	var out api.AppUsersResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/unmanaged_users", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListSchemaMigrations 查询数据库的迁移版本及执行记录
+//
+// GET /manager/apps/{app}/database/schemas/{schema}/migrations
+func (c *Client) ListSchemaMigrations(ctx context.Context, app string, schema string, query api.SubscriptionQuery) (api.SchemaMigrationsResponse, error) {
+	var out api.SchemaMigrationsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/database/schemas/"+url.PathEscape(schema)+"/migrations", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// SetSchemaMigrations 上传数据库的迁移集合，已执行的版本不能修改，未执行的版本被替换
+//
+// PUT /manager/apps/{app}/database/schemas/{schema}/migrations
+func (c *Client) SetSchemaMigrations(ctx context.Context, app string, schema string, query api.SubscriptionQuery, body api.SchemaMigrationSet) (api.SchemaMigrationsResponse, error) {
+	var out api.SchemaMigrationsResponse
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/database/schemas/"+url.PathEscape(schema)+"/migrations", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// ApplySchemaMigrations 在一个任务中按版本执行未执行的迁移，dry_run 时只在草稿库中验证
+//
+// POST /manager/apps/{app}/database/schemas/{schema}/migrations/apply
+func (c *Client) ApplySchemaMigrations(ctx context.Context, app string, schema string, query api.SubscriptionQuery, body api.SchemaMigrationApplyOptions) (api.SchemaMigrationApplyResponse, error) {
+	var out api.SchemaMigrationApplyResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/apps/"+url.PathEscape(app)+"/database/schemas/"+url.PathEscape(schema)+"/migrations/apply", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// ApplyAppResource 创建或更新站点中的App对象
+//
+// PUT /manager/apps/resources
+func (c *Client) ApplyAppResource(ctx context.Context, query api.AppResourceQuery, body api.AppResourceOptions) (api.AppResource, error) {
+	var out api.AppResource
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/resources", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// GetAppResource 查询站点中App对象的同步状态
+//
+// GET /manager/apps/resources/{name}
+func (c *Client) GetAppResource(ctx context.Context, name string, query api.AppResourceQuery) (api.AppResource, error) {
+	var out api.AppResource
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/resources/"+url.PathEscape(name), queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// DeleteAppResource 删除站点中的App对象
+//
+// DELETE /manager/apps/resources/{name}
+func (c *Client) DeleteAppResource(ctx context.Context, name string, query api.AppResourceQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/apps/resources/"+url.PathEscape(name), queryValues(query), nil, nil)
+}
+
+// ListBackupFiles 查询备份文件
+//
+// GET /manager/backup/files
+func (c *Client) ListBackupFiles(ctx context.Context, query api.BackupFileListQuery) (api.BackupFilesResponse, error) {
+	var out api.BackupFilesResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/backup/files", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// ListBackupFilesPage returns a page of ListBackupFiles,the page is decided by query.ListRequest.
+func (c *Client) ListBackupFilesPage(ctx context.Context, query api.BackupFileListQuery) ([]api.BackupFile, api.ListMeta, error) {
+	var items []api.BackupFile
+
+	meta, err := c.listPage(ctx, "/manager/backup/files", queryValues(query), &items)
+
+	return items, meta, err
+}
+
+// DeleteBackupFile 删除备份文件
+//
+// DELETE /manager/backup/files
+func (c *Client) DeleteBackupFile(ctx context.Context, query api.IDAppQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/backup/files", queryValues(query), nil, nil)
+}
+
+// PostBackupStrategy 增加备份策略
+//
+// POST /manager/backup/strategy
+func (c *Client) PostBackupStrategy(ctx context.Context, query api.SubscriptionQuery, body api.BackupStrategyConfig) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/backup/strategy", queryValues(query), body, &out)
+
+	return out, err
+}
+
+// UpdateBackupStrategy 更新备份策略
+//
+// PUT /manager/backup/strategy/{id}
+func (c *Client) UpdateBackupStrategy(ctx context.Context, id string, query api.SubscriptionQuery, body api.BackupStrategyOptions) error {
+	return c.do(ctx, http.MethodPut, "/manager/backup/strategy/"+url.PathEscape(id), queryValues(query), body, nil)
+}
+
+// ListBackupStrategies 查询备份策略
+//
+// GET /manager/backup/strategy
+func (c *Client) ListBackupStrategies(ctx context.Context, query api.BackupStrategyListQuery) (api.BackupStrategyResponse, error) {
+	var out api.BackupStrategyResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/backup/strategy", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// DeleteBackupStrategy 删除备份策略
+//
+// DELETE /manager/backup/strategy
+func (c *Client) DeleteBackupStrategy(ctx context.Context, query api.IDAppQuery) error {
+	return c.do(ctx, http.MethodDelete, "/manager/backup/strategy", queryValues(query), nil, nil)
+}
+
+// ListBackupEndpoints 查询备份存储
+//
+// GET /manager/backup/endpoint
+func (c *Client) ListBackupEndpoints(ctx context.Context, query api.BackupEndpointListQuery) ([]api.BackupEndpoint, error) {
+	var out []api.BackupEndpoint
+
+	err := c.do(ctx, http.MethodGet, "/manager/backup/endpoint", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostBackupEndpoint 增加备份存储
+//
+// POST /manager/backup/endpoint
+func (c *Client) PostBackupEndpoint(ctx context.Context, body api.BackupEndpoint) (api.BackupEndpoint, error) {
+	var out api.BackupEndpoint
+
+	err := c.do(ctx, http.MethodPost, "/manager/backup/endpoint", nil, body, &out)
+
+	return out, err
+}
+
+// UpdateBackupEndpoint 更新备份存储
+//
+// PUT /manager/backup/endpoint/{id}
+func (c *Client) UpdateBackupEndpoint(ctx context.Context, id string, body api.BackupEndpoint) (api.BackupEndpoint, error) {
+	var out api.BackupEndpoint
+
+	err := c.do(ctx, http.MethodPut, "/manager/backup/endpoint/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// GetBackupEndpoint 查询备份存储详情
+//
+// GET /manager/backup/endpoint/{id}
+func (c *Client) GetBackupEndpoint(ctx context.Context, id string) (api.BackupEndpoint, error) {
+	var out api.BackupEndpoint
+
+	err := c.do(ctx, http.MethodGet, "/manager/backup/endpoint/"+url.PathEscape(id), nil, nil, &out)
+
+	return out, err
+}
+
+// DeleteBackupEndpoint 删除备份存储
+//
+// DELETE /manager/backup/endpoint/{id}
+func (c *Client) DeleteBackupEndpoint(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/backup/endpoint/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ListAlerts 查询告警
+//
+// GET /manager/alerts
+func (c *Client) ListAlerts(ctx context.Context, query api.AlertListQuery) (api.AlertsResponse, error) {
+	var out api.AlertsResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/alerts", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// GetAppAlertConfig 查询服务告警规则
+//
+// GET /manager/apps/{app}/alerts/config
+func (c *Client) GetAppAlertConfig(ctx context.Context, app string) (api.AppAlertConfig, error) {
+	var out api.AppAlertConfig
+
+	err := c.do(ctx, http.MethodGet, "/manager/apps/"+url.PathEscape(app)+"/alerts/config", nil, nil, &out)
+
+	return out, err
+}
+
+// SetAppAlertConfig 设置服务告警规则
+//
+// PUT /manager/apps/{app}/alerts/config
+func (c *Client) SetAppAlertConfig(ctx context.Context, app string, body api.AppAlertConfigOptions) (api.AppAlertConfig, error) {
+	var out api.AppAlertConfig
+
+	err := c.do(ctx, http.MethodPut, "/manager/apps/"+url.PathEscape(app)+"/alerts/config", nil, body, &out)
+
+	return out, err
+}
+
+// ListNotificationChannels 查询通知渠道
+//
+// GET /manager/alerts/channels
+func (c *Client) ListNotificationChannels(ctx context.Context, query api.TypeQuery) ([]api.NotificationChannel, error) {
+	var out []api.NotificationChannel
+
+	err := c.do(ctx, http.MethodGet, "/manager/alerts/channels", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostNotificationChannel 增加通知渠道
+//
+// POST /manager/alerts/channels
+func (c *Client) PostNotificationChannel(ctx context.Context, body api.NotificationChannelConfig) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/alerts/channels", nil, body, &out)
+
+	return out, err
+}
+
+// GetNotificationChannel 查询通知渠道详情
+//
+// GET /manager/alerts/channels/{id}
+func (c *Client) GetNotificationChannel(ctx context.Context, id string) (api.NotificationChannel, error) {
+	var out api.NotificationChannel
+
+	err := c.do(ctx, http.MethodGet, "/manager/alerts/channels/"+url.PathEscape(id), nil, nil, &out)
+
+	return out, err
+}
+
+// SetNotificationChannel 更新通知渠道
+//
+// PUT /manager/alerts/channels/{id}
+func (c *Client) SetNotificationChannel(ctx context.Context, id string, body api.NotificationChannelOptions) (api.NotificationChannel, error) {
+	var out api.NotificationChannel
+
+	err := c.do(ctx, http.MethodPut, "/manager/alerts/channels/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteNotificationChannel 删除通知渠道
+//
+// DELETE /manager/alerts/channels/{id}
+func (c *Client) DeleteNotificationChannel(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/alerts/channels/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ListWebhooks 查询webhook
+//
+// GET /manager/webhooks
+func (c *Client) ListWebhooks(ctx context.Context, query api.AppIDQuery) (api.WebhooksResponse, error) {
+	var out api.WebhooksResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/webhooks", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// PostWebhook 增加webhook
+//
+// POST /manager/webhooks
+func (c *Client) PostWebhook(ctx context.Context, body api.WebhookConfig) (api.ObjectResponse, error) {
+	var out api.ObjectResponse
+
+	err := c.do(ctx, http.MethodPost, "/manager/webhooks", nil, body, &out)
+
+	return out, err
+}
+
+// GetWebhook 查询webhook详情
+//
+// GET /manager/webhooks/{id}
+func (c *Client) GetWebhook(ctx context.Context, id string) (api.Webhook, error) {
+	var out api.Webhook
+
+	err := c.do(ctx, http.MethodGet, "/manager/webhooks/"+url.PathEscape(id), nil, nil, &out)
+
+	return out, err
+}
+
+// SetWebhook 更新webhook
+//
+// PUT /manager/webhooks/{id}
+func (c *Client) SetWebhook(ctx context.Context, id string, body api.WebhookOptions) (api.Webhook, error) {
+	var out api.Webhook
+
+	err := c.do(ctx, http.MethodPut, "/manager/webhooks/"+url.PathEscape(id), nil, body, &out)
+
+	return out, err
+}
+
+// DeleteWebhook 删除webhook
+//
+// DELETE /manager/webhooks/{id}
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/manager/webhooks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ListWebhookDeliveries 查询webhook投递记录
+//
+// GET /manager/webhooks/{id}/deliveries
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, query api.LimitQuery) (api.WebhookDeliveriesResponse, error) {
+	var out api.WebhookDeliveriesResponse
+
+	err := c.do(ctx, http.MethodGet, "/manager/webhooks/"+url.PathEscape(id)+"/deliveries", queryValues(query), nil, &out)
+
+	return out, err
+}
+
+// GetOpenAPIDocument 查询OpenAPI文档
+//
+// GET /openapi.json
+func (c *Client) GetOpenAPIDocument(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+
+	err := c.do(ctx, http.MethodGet, "/openapi.json", nil, nil, &out)
+
+	return out, err
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/xerrors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// PasswordPolicy 数据库用户的密码策略，由apiserver启动参数配置
+type PasswordPolicy struct {
+	MinLength      int  `json:"min_length"`
+	RequireUpper   bool `json:"require_upper"`
+	RequireLower   bool `json:"require_lower"`
+	RequireDigit   bool `json:"require_digit"`
+	RequireSpecial bool `json:"require_special"`
+	// 不能与最近几次使用过的密码相同，0表示不检查
+	History int `json:"history"`
+	// 密码有效天数，0表示不过期
+	MaxAgeDays int `json:"max_age_days"`
+	// 过期前几天发布 db_user.password_expiring 事件
+	WarnDays int `json:"warn_days"`
+	// 服务端生成的密码过期时自动轮换
+	AutoRotate bool `json:"auto_rotate"`
+	// 双密码轮换后旧密码保留的小时数，之后自动丢弃
+	RetainOldHours int `json:"retain_old_hours"`
+}
+
+func (p PasswordPolicy) Valid() error {
+	if p.MinLength < 0 || p.History < 0 || p.MaxAgeDays < 0 || p.WarnDays < 0 || p.RetainOldHours < 0 {
+		return xerrors.New("password policy must not be negative")
+	}
+
+	return nil
+}
+
+// Check returns the rules of policy the password breaks.
+func (p PasswordPolicy) Check(password string) error {
+	var (
+		errs                         []error
+		upper, lower, digit, special bool
+	)
+
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			special = true
+		}
+	}
+
+	if len(password) < p.MinLength {
+		errs = append(errs, xerrors.Errorf("password should be at least %d characters", p.MinLength))
+	}
+	if p.RequireUpper && !upper {
+		errs = append(errs, xerrors.New("password should contain upper case letters"))
+	}
+	if p.RequireLower && !lower {
+		errs = append(errs, xerrors.New("password should contain lower case letters"))
+	}
+	if p.RequireDigit && !digit {
+		errs = append(errs, xerrors.New("password should contain digits"))
+	}
+	if p.RequireSpecial && !special {
+		errs = append(errs, xerrors.New("password should contain special characters"))
+	}
+	if strings.ContainsAny(password, `"'\`) {
+		errs = append(errs, xerrors.New(`password should not contain quotes or \`))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// DBUserSecret 服务端生成的密码所在的 Secret，位于服务单元所在的 namespace，
+// 包含 username、host、password，双密码轮换期间包含 previous-password
+type DBUserSecret struct {
+	Site      string `json:"site_id"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// DBUserCredential 数据库用户的密码状态，不包含密码
+type DBUserCredential struct {
+	Name string `json:"name"`
+	IP   IP     `json:"ip"`
+	// 密码由服务端生成，保存在Secret中
+	Generated bool          `json:"generated"`
+	Secret    *DBUserSecret `json:"secret,omitempty"`
+	// 轮换后旧密码仍然有效
+	OldPasswordRetained bool  `json:"old_password_retained"`
+	ChangedAt           Time  `json:"changed_at"`
+	ExpiresAt           *Time `json:"expires_at,omitempty"`
+}
+
+type DBUserCredentialsResponse []DBUserCredential
+
+type AppUserDiscardPasswordConfig struct {
+	Name string `json:"name"`
+	IP   string `json:"ip"`
+}
+
+func (c AppUserDiscardPasswordConfig) Valid() error {
+	if c.Name == "" || c.IP == "" {
+		return xerrors.New("name and ip are required")
+	}
+
+	return nil
+}
+
+const (
+	// DBUserReconcileReport 只报告与期望不一致的用户
+	DBUserReconcileReport = "report"
+	// DBUserReconcileEnforce 创建缺少的用户并修正权限
+	DBUserReconcileEnforce = "enforce"
+
+	DBUserDriftMissing    = "missing"
+	DBUserDriftPrivileges = "privileges"
+
+	DBUserStateDrifted = "drifted"
+	DBUserStateInSync  = "in_sync"
+)
+
+// DBUserSpec 期望的数据库用户，db_privileges 为 null 时不检查权限
+type DBUserSpec struct {
+	Name string `json:"name"`
+	IP   IP     `json:"ip"`
+	// 创建缺少的用户时使用，默认 mysql_native_password
+	AuthType   string              `json:"auth_type,omitempty"`
+	Privileges []DatabasePrivilege `json:"db_privileges"`
+}
+
+// AppDBUserSpec 服务期望的数据库用户及权限，由后台定期与实例中的用户比较，
+// 不在期望中的用户只报告，不会删除
+type AppDBUserSpec struct {
+	// report 或 enforce，默认 report
+	Mode  string       `json:"mode"`
+	Users []DBUserSpec `json:"users"`
+}
+
+func (s *AppDBUserSpec) Valid() error {
+	if s.Mode == "" {
+		s.Mode = DBUserReconcileReport
+	}
+
+	if s.Mode != DBUserReconcileReport && s.Mode != DBUserReconcileEnforce {
+		return xerrors.Errorf("unsupported mode '%s',should be %s or %s", s.Mode, DBUserReconcileReport, DBUserReconcileEnforce)
+	}
+
+	var errs []error
+	users := make(map[string]bool, len(s.Users))
+
+	for _, u := range s.Users {
+		if u.Name == "" || u.IP == "" {
+			errs = append(errs, xerrors.New("user name and ip are required"))
+			continue
+		}
+
+		key := u.Name + "@" + string(u.IP)
+		if users[key] {
+			errs = append(errs, xerrors.Errorf("duplicate user %s", key))
+		}
+		users[key] = true
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// DBUserDrift 实例中的用户与期望不一致，missing 为用户不存在，privileges 为权限不同
+type DBUserDrift struct {
+	Name     string              `json:"name"`
+	IP       IP                  `json:"ip"`
+	Type     string              `json:"type"`
+	Expected []DatabasePrivilege `json:"expected_privileges,omitempty"`
+	Actual   []DatabasePrivilege `json:"actual_privileges,omitempty"`
+	// enforce 模式下已修正
+	Corrected bool   `json:"corrected"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DBUserReconcileStatus 最近一次比较的结果
+type DBUserReconcileStatus struct {
+	State     string         `json:"state"`
+	Drifts    []DBUserDrift  `json:"drifts"`
+	Unmanaged []DatabaseUser `json:"unmanaged_users"`
+	Error     string         `json:"error,omitempty"`
+	CheckedAt Time           `json:"checked_at"`
+}
+
+type AppDBUserSpecResponse struct {
+	AppDBUserSpec
+
+	Status   *DBUserReconcileStatus `json:"status,omitempty"`
+	Modified Time                   `json:"modified_at"`
+}
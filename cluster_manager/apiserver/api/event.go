@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"golang.org/x/xerrors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	EventTaskCreated     = "task.created"
+	EventTaskFinished    = "task.finished"
+	EventAppState        = "app.state"
+	EventUnitState       = "unit.state"
+	EventUnitDeleted     = "unit.deleted"
+	EventBackupCompleted = "backup.completed"
+	EventBackupFailed    = "backup.failed"
+	EventSiteState       = "site.state"
+
+	EventDBUserPasswordExpiring = "db_user.password_expiring"
+	EventDBUserPasswordRotated  = "db_user.password_rotated"
+	// State 为 drifted 或 in_sync，Object 为服务ID
+	EventDBUserDrift = "db_user.drift"
+
+	// webhook请求头
+	WebhookHeaderEvent    = "X-DBScale-Event"
+	WebhookHeaderDelivery = "X-DBScale-Delivery"
+	// 发送时间，unix秒
+	WebhookHeaderTimestamp = "X-DBScale-Timestamp"
+	// 签名为 "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))，
+	// timestamp 为 X-DBScale-Timestamp 的值，接收方应同时校验时间以拒绝重放的请求
+	WebhookHeaderSignature = "X-DBScale-Signature"
+)
+
+var eventTypes = []string{
+	EventTaskCreated,
+	EventTaskFinished,
+	EventAppState,
+	EventUnitState,
+	EventUnitDeleted,
+	EventBackupCompleted,
+	EventBackupFailed,
+	EventSiteState,
+	EventDBUserPasswordExpiring,
+	EventDBUserPasswordRotated,
+	EventDBUserDrift,
+}
+
+// Event 任务、服务、单元、站点状态变化、备份完成、数据库用户密码及权限漂移事件，ID单调递增
+type Event struct {
+	ID   uint64 `json:"id"`
+	Type string `json:"type"`
+	// 任务ID、服务ID、单元名称、站点ID、备份文件ID或数据库用户(name@ip)
+	Object    string `json:"object"`
+	Name      string `json:"name,omitempty"`
+	App       string `json:"app_id,omitempty"`
+	Site      string `json:"site_id,omitempty"`
+	State     string `json:"state,omitempty"`
+	PrevState string `json:"prev_state,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Time      Time   `json:"time"`
+}
+
+// MatchEventType returns true if typ matches one of the patterns,
+// a pattern without '.' matches all the types of the kind,e.g. "task" matches "task.created".
+// Empty patterns match everything.
+func MatchEventType(patterns []string, typ string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, p := range patterns {
+		if p == typ || strings.HasPrefix(typ, p+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidEventTypes checks the patterns used by webhooks and event stream.
+func ValidEventTypes(patterns []string) error {
+	var errs []error
+
+loop:
+	for _, p := range patterns {
+		for _, typ := range eventTypes {
+			if p == typ || strings.HasPrefix(typ, p+".") {
+				continue loop
+			}
+		}
+
+		errs = append(errs, xerrors.Errorf("unsupported event type '%s'", p))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Webhook 事件订阅，签名密钥不返回
+type Webhook struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	App        string   `json:"app_id,omitempty"`
+	Enabled    bool     `json:"enabled"`
+
+	Created  Editor `json:"created"`
+	Modified Editor `json:"modified"`
+}
+
+type WebhooksResponse []Webhook
+
+type WebhookConfig struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// 用于签名请求体
+	Secret string `json:"secret"`
+	// 订阅的事件类型，为空表示全部
+	EventTypes []string `json:"event_types,omitempty"`
+	// 只订阅该服务的事件，为空表示全部
+	App     string `json:"app_id,omitempty"`
+	Enabled bool   `json:"enabled"`
+
+	User string `json:"created_user"`
+}
+
+func (c WebhookConfig) Valid() error {
+	var errs []error
+
+	if c.Name == "" {
+		errs = append(errs, xerrors.New("name is required"))
+	}
+
+	if err := validWebhookURL(c.URL); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Secret == "" {
+		errs = append(errs, xerrors.New("secret is required"))
+	}
+
+	if err := ValidEventTypes(c.EventTypes); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+type WebhookOptions struct {
+	Name       *string   `json:"name,omitempty"`
+	URL        *string   `json:"url,omitempty"`
+	Secret     *string   `json:"secret,omitempty"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+	App        *string   `json:"app_id,omitempty"`
+	Enabled    *bool     `json:"enabled,omitempty"`
+
+	User string `json:"modified_user"`
+}
+
+func (opts WebhookOptions) Valid() error {
+	var errs []error
+
+	if opts.Name != nil && *opts.Name == "" {
+		errs = append(errs, xerrors.New("name is required"))
+	}
+
+	if opts.URL != nil {
+		if err := validWebhookURL(*opts.URL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if opts.Secret != nil && *opts.Secret == "" {
+		errs = append(errs, xerrors.New("secret is required"))
+	}
+
+	if opts.EventTypes != nil {
+		if err := ValidEventTypes(*opts.EventTypes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func validWebhookURL(addr string) error {
+	if u, err := url.Parse(addr); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return xerrors.Errorf("invalid webhook url '%s'", addr)
+	}
+
+	return nil
+}
+
+// WebhookDelivery 事件投递记录
+type WebhookDelivery struct {
+	ID           string          `json:"id"`
+	Webhook      string          `json:"webhook_id"`
+	EventID      uint64          `json:"event_id"`
+	EventType    string          `json:"event_type"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode int             `json:"response_code"`
+	Error        string          `json:"error,omitempty"`
+	CreatedAt    Time            `json:"created_at"`
+	UpdatedAt    Time            `json:"updated_at"`
+}
+
+type WebhookDeliveriesResponse []WebhookDelivery
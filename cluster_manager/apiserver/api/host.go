@@ -101,6 +101,8 @@ type HostConfig struct {
 
 	// 系统帐号
 	SSHConfig Auth `json:"ssh"`
+	// 密钥认证，设置后不需要密码
+	SSHAuth *SSHAuth `json:"ssh_auth,omitempty"`
 
 	// 位置
 	Location
@@ -116,6 +118,29 @@ type HostConfig struct {
 	User    string `json:"created_user"`
 }
 
+// SSHAuth 私钥及sudo密码需预先保存在站点的Secret中
+type SSHAuth struct {
+	// Secret需包含ssh-privatekey，sudo_mode为password时还需包含sudo-password
+	Secret string `json:"secret"`
+	// 默认kube-system
+	SecretNamespace string `json:"secret_namespace,omitempty"`
+	// nopasswd(默认) or password
+	SudoMode string `json:"sudo_mode,omitempty"`
+	// 主机公钥(authorized_keys格式)，设置后严格校验
+	HostKey string   `json:"host_key,omitempty"`
+	Bastion *Bastion `json:"bastion,omitempty"`
+}
+
+// Bastion 跳板机，只支持密钥认证
+type Bastion struct {
+	IP   IP     `json:"ip"`
+	Port int    `json:"port"`
+	User string `json:"username"`
+	// 为空时使用主机的Secret
+	Secret  string `json:"secret,omitempty"`
+	HostKey string `json:"host_key,omitempty"`
+}
+
 type ResourceLimit struct {
 	MaxUnit int `json:"max_unit"`
 
@@ -175,8 +200,24 @@ func (n HostConfig) Valid() error {
 		errs = append(errs, xerrors.New("ssh user is required"))
 	}
 
-	if n.SSHConfig.Password == "" {
-		errs = append(errs, xerrors.New("ssh password is required"))
+	if n.SSHAuth == nil && n.SSHConfig.Password == "" {
+		errs = append(errs, xerrors.New("ssh password or ssh_auth is required"))
+	}
+
+	if n.SSHAuth != nil {
+		if n.SSHAuth.Secret == "" {
+			errs = append(errs, xerrors.New("ssh_auth secret is required"))
+		}
+
+		if b := n.SSHAuth.Bastion; b != nil {
+			if b.IP.Parse() == nil {
+				errs = append(errs, xerrors.Errorf("bastion ip: %s parse error", b.IP))
+			}
+
+			if b.User == "" {
+				errs = append(errs, xerrors.New("bastion user is required"))
+			}
+		}
 	}
 
 	if n.Room == "" {
@@ -239,3 +280,71 @@ type HostOptions struct {
 	Role    string `json:"role,omitempty"`
 	User    string `json:"modified_user"`
 }
+
+// HostMaintenanceOptions 维护模式，进入时禁止调度并迁出主机上的所有单元
+type HostMaintenanceOptions struct {
+	// true: 进入维护模式; false: 恢复服务(不迁回单元)
+	Maintenance bool `json:"maintenance"`
+	// 只返回迁移计划，不执行
+	DryRun bool `json:"dry_run,omitempty"`
+	// 同时迁移的应用数量，同一应用的单元依次迁移，默认1
+	Concurrency int    `json:"concurrency,omitempty"`
+	User        string `json:"modified_user"`
+}
+
+func (opts HostMaintenanceOptions) Valid() error {
+	if opts.Concurrency < 0 || opts.Concurrency > MaxMaintenanceConcurrency {
+		return xerrors.Errorf("concurrency should be in [0,%d]", MaxMaintenanceConcurrency)
+	}
+
+	if opts.DryRun && !opts.Maintenance {
+		return xerrors.New("dry_run is only supported when entering maintenance")
+	}
+
+	return nil
+}
+
+const (
+	MaxMaintenanceConcurrency = 10
+
+	MaintenancePlanned  = "planned"
+	MaintenanceDraining = "draining"
+	MaintenanceDrained  = "drained"
+	MaintenanceFailed   = "failed"
+	MaintenanceCanceled = "canceled"
+
+	EvacuationMigrate = "migrate"
+	EvacuationRebuild = "rebuild"
+
+	EvacuationPending = "pending"
+	EvacuationRunning = "running"
+	EvacuationDone    = "done"
+	EvacuationFailed  = "failed"
+)
+
+type HostMaintenance struct {
+	Host        IDName           `json:"host"`
+	Maintenance bool             `json:"maintenance"`
+	DryRun      bool             `json:"dry_run"`
+	State       string           `json:"state"`
+	Concurrency int              `json:"concurrency"`
+	Units       []UnitEvacuation `json:"units"`
+	Task        TaskBrief        `json:"task"`
+	Created     Editor           `json:"created"`
+}
+
+// UnitEvacuation 单元迁移计划及进度
+type UnitEvacuation struct {
+	App       string `json:"app_id"`
+	Unit      string `json:"unit"`
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	// 主库先切换到NewMaster
+	Switchover bool   `json:"switchover"`
+	NewMaster  string `json:"new_master,omitempty"`
+	// migrate: 共享存储; rebuild: 本地存储
+	Action string `json:"action"`
+	Target IDName `json:"target"`
+	State  string `json:"state"`
+	Error  string `json:"error,omitempty"`
+}
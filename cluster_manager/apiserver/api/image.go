@@ -5,12 +5,25 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/xerrors"
 	corev1 "k8s.io/api/core/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
+const (
+	// 镜像签名验证结果，未配置公钥时为空
+	ImageSignatureVerified = "verified"
+	ImageSignatureUnsigned = "unsigned"
+	ImageSignatureInvalid  = "invalid"
+
+	// 镜像生命周期，deprecated 和 eol 的镜像不能用于新建服务和升级
+	ImageLifecycleActive     = "active"
+	ImageLifecycleDeprecated = "deprecated"
+	ImageLifecycleEOL        = "eol"
+)
+
 type Image struct {
 	ImageVersion
 	Unschedulable bool      `json:"unschedulable"`
@@ -20,8 +33,31 @@ type Image struct {
 	Task          TaskBrief `json:"task"`
 	Created       Editor    `json:"created"`
 	Modified      Editor    `json:"modified"`
+
+	// 导入时tag对应的digest，单元使用digest固定镜像
+	Digest          string `json:"digest,omitempty"`
+	SignatureStatus string `json:"signature_status,omitempty"`
+	// 站点仓库中tag当前对应的digest，与导入时不同时非空
+	DriftDigest string `json:"drift_digest,omitempty"`
+
+	// enum: active,deprecated,eol
+	Lifecycle    string `json:"lifecycle"`
+	DeprecatedAt *Time  `json:"deprecated_at,omitempty"`
+	EOLAt        *Time  `json:"eol_at,omitempty"`
 }
 
+// ArchMulti 多架构服务，每个单元按调度到的主机架构使用镜像集合中对应架构的镜像
+const ArchMulti = "multi"
+
+// ImageSet 同一类型和版本的各架构镜像，ID 不含架构，例如 mysql:5.7.25.1
+type ImageSet struct {
+	ImageVersion
+	Archs    []string `json:"archs"`
+	Variants []Image  `json:"variants"`
+}
+
+type ImageSetsResponse []ImageSet
+
 type ImageConfig struct {
 	// 镜像版本
 	ImageVersion
@@ -59,6 +95,12 @@ func (iv ImageVersion) String() string {
 func ParseImageVersion(image string) (ImageVersion, error) {
 	ierr := errors.New("parse image:" + image)
 	iv := ImageVersion{}
+
+	// registry1.service.consul:20160/project_name/mysql:5.7.25.12-amd64@sha256:...
+	if i := strings.Index(image, "@"); i > 0 {
+		image = image[:i]
+	}
+
 	ss := strings.Split(image, "/")
 
 	// registry1.service.consul:20160/project_name/mysql:5.7.25.12-amd64
@@ -145,6 +187,33 @@ type ImageOptions struct {
 	Desc          *string `json:"desc,omitempty"`
 }
 
+// ImageLifecycleOptions 设置镜像的弃用及停止支持时间，为空表示取消
+type ImageLifecycleOptions struct {
+	DeprecatedAt *Time  `json:"deprecated_at,omitempty"`
+	EOLAt        *Time  `json:"eol_at,omitempty"`
+	User         string `json:"modified_user"`
+}
+
+func (opts ImageLifecycleOptions) Valid() error {
+	if opts.DeprecatedAt != nil && opts.EOLAt != nil &&
+		time.Time(*opts.EOLAt).Before(time.Time(*opts.DeprecatedAt)) {
+		return xerrors.New("eol_at cannot be earlier than deprecated_at")
+	}
+
+	return nil
+}
+
+// ImageLifecycleReport 弃用或停止支持的镜像及仍在使用的服务
+type ImageLifecycleReport struct {
+	Image        ImageVersion `json:"image"`
+	Lifecycle    string       `json:"lifecycle"`
+	DeprecatedAt *Time        `json:"deprecated_at,omitempty"`
+	EOLAt        *Time        `json:"eol_at,omitempty"`
+	Apps         []IDName     `json:"apps"`
+}
+
+type ImageLifecycleReportResponse []ImageLifecycleReport
+
 type ImageListOptions struct {
 	Unschedulable *string `json:"unschedulable,omitempty"`
 
@@ -194,6 +263,9 @@ type ImageTemplatesResponse []ImageTemplate
 
 type ConfigTemplateOptions struct {
 	Keysets []KeySet `json:"keysets"`
+	// Reason 修改原因，记录在模板版本中
+	Reason string `json:"reason"`
+	User   string `json:"user"`
 	//	Content　*string`json:"content"`
 }
 
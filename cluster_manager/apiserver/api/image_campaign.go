@@ -0,0 +1,178 @@
+package api
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	MaxCampaignConcurrency = 20
+
+	CampaignRunning   = "running"
+	CampaignCompleted = "completed"
+	CampaignCanceled  = "canceled"
+
+	CampaignAppPending  = "pending"
+	CampaignAppRunning  = "running"
+	CampaignAppDone     = "done"
+	CampaignAppFailed   = "failed"
+	CampaignAppSkipped  = "skipped"
+	CampaignAppCanceled = "canceled"
+
+	windowClock = "15:04"
+)
+
+// MaintenanceWindow 每天允许执行的时间段，End早于Start表示跨天，都为空表示不限制
+type MaintenanceWindow struct {
+	// example: 01:00
+	Start string `json:"start,omitempty"`
+	// example: 05:00
+	End string `json:"end,omitempty"`
+	// 允许执行的星期，0为周日，为空表示每天
+	Weekdays []int `json:"weekdays,omitempty"`
+}
+
+func (w MaintenanceWindow) Valid() error {
+	var errs []error
+
+	if (w.Start == "") != (w.End == "") {
+		errs = append(errs, xerrors.New("window start and end should be set together"))
+	}
+
+	for _, v := range []string{w.Start, w.End} {
+		if v == "" {
+			continue
+		}
+
+		if _, err := time.Parse(windowClock, v); err != nil {
+			errs = append(errs, xerrors.Errorf("invalid window time %q,HH:MM is required", v))
+		}
+	}
+
+	for _, d := range w.Weekdays {
+		if d < 0 || d > 6 {
+			errs = append(errs, xerrors.Errorf("invalid weekday %d,should be in [0,6]", d))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Contains returns true if t is in the window,
+// the weekday of a window crossing midnight is the day it starts.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	if w.Start == "" || w.End == "" {
+		return w.weekday(t.Weekday())
+	}
+
+	start, err := time.Parse(windowClock, w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(windowClock, w.End)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	switch {
+	case from < to:
+		return minute >= from && minute < to && w.weekday(t.Weekday())
+	case minute >= from:
+		return w.weekday(t.Weekday())
+	case minute < to:
+		return w.weekday((t.Weekday() + 6) % 7)
+	}
+
+	return false
+}
+
+func (w MaintenanceWindow) weekday(d time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+
+	for _, v := range w.Weekdays {
+		if time.Weekday(v) == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ImageCampaignConfig 镜像升级活动，在维护窗口内将服务中同类型的镜像升级到目标镜像
+type ImageCampaignConfig struct {
+	Name string `json:"name"`
+	// 目标镜像id
+	Image string `json:"image_id"`
+	// 升级的服务，为空时选择所有使用弃用或停止支持的同类型镜像的服务
+	Apps   []string          `json:"apps,omitempty"`
+	Window MaintenanceWindow `json:"window"`
+	// 同时升级的服务数量，默认1
+	Concurrency int    `json:"concurrency,omitempty"`
+	User        string `json:"created_user"`
+}
+
+func (c ImageCampaignConfig) Valid() error {
+	var errs []error
+
+	if c.Name == "" {
+		errs = append(errs, xerrors.New("name is required"))
+	}
+
+	if c.Image == "" {
+		errs = append(errs, xerrors.New("image_id is required"))
+	}
+
+	if c.Concurrency < 0 || c.Concurrency > MaxCampaignConcurrency {
+		errs = append(errs, xerrors.Errorf("concurrency should be in [0,%d]", MaxCampaignConcurrency))
+	}
+
+	if err := c.Window.Valid(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// ImageCampaignSpec 保存的升级活动配置
+type ImageCampaignSpec struct {
+	Window      MaintenanceWindow `json:"window"`
+	Concurrency int               `json:"concurrency"`
+}
+
+type ImageCampaign struct {
+	ID    string       `json:"id"`
+	Name  string       `json:"name"`
+	Image ImageVersion `json:"image"`
+	// enum: running,completed,canceled
+	State string `json:"state"`
+	ImageCampaignSpec
+	// 各状态的服务数量
+	Summary map[string]int `json:"summary"`
+	Apps    []CampaignApp  `json:"apps"`
+
+	Created  Editor `json:"created"`
+	Modified Editor `json:"modified"`
+}
+
+type ImageCampaignsResponse []ImageCampaign
+
+// CampaignApp 服务的升级进度
+type CampaignApp struct {
+	App IDName `json:"app"`
+	// 升级前的镜像
+	From ImageVersion `json:"from"`
+	// enum: pending,running,done,failed,skipped,canceled
+	State      string `json:"state"`
+	Task       string `json:"task_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  *Time  `json:"started_at,omitempty"`
+	FinishedAt *Time  `json:"finished_at,omitempty"`
+}
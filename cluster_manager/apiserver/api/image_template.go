@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+)
+
+const (
+	DiffEqual  = "equal"
+	DiffAdd    = "add"
+	DiffDelete = "delete"
+	DiffChange = "change"
+)
+
+// ImageTemplateRevision 镜像模板及脚本的历史版本，列表中不包含内容
+type ImageTemplateRevision struct {
+	Image    ImageVersion `json:"image"`
+	Revision int          `json:"revision"`
+	Reason   string       `json:"reason"`
+	// Current 镜像当前使用的版本，未固定版本的服务使用该版本
+	Current bool `json:"current"`
+
+	KeySets        string            `json:"key_sets,omitempty"`
+	ConfigTemplate string            `json:"config_template,omitempty"`
+	Scripts        map[string]string `json:"scripts,omitempty"`
+
+	Created Editor `json:"created"`
+}
+
+type ImageTemplateRevisionsResponse []ImageTemplateRevision
+
+type ImageTemplateRollbackOptions struct {
+	Revision int    `json:"revision"`
+	Reason   string `json:"reason"`
+	User     string `json:"user"`
+}
+
+func (opts ImageTemplateRollbackOptions) Valid() error {
+	if opts.Revision <= 0 {
+		return errors.New("revision is required")
+	}
+
+	return nil
+}
+
+// DiffLine 并排对比的一行，Op 为 equal/add/delete/change，行号从1开始，0表示该侧没有对应行
+type DiffLine struct {
+	Op        string `json:"op"`
+	Left      string `json:"left"`
+	LeftLine  int    `json:"left_line"`
+	Right     string `json:"right"`
+	RightLine int    `json:"right_line"`
+}
+
+// KeySetChange 两个版本中值不同的参数
+type KeySetChange struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type ImageTemplateDiff struct {
+	Image ImageVersion `json:"image"`
+	From  int          `json:"from"`
+	To    int          `json:"to"`
+
+	KeySets []KeySetChange        `json:"keysets"`
+	Config  []DiffLine            `json:"config"`
+	Scripts map[string][]DiffLine `json:"scripts"`
+}
+
+// AppTemplatePinOptions 固定服务使用的镜像模板版本，Revision 为0表示跟随镜像当前版本
+type AppTemplatePinOptions struct {
+	// enum: mysql,proxysql,cmha
+	Type     string `json:"type"`
+	Revision int    `json:"revision"`
+	User     string `json:"user"`
+}
+
+func (opts AppTemplatePinOptions) Valid() error {
+	if opts.Type == "" {
+		return errors.New("type is required")
+	}
+
+	if opts.Revision < 0 {
+		return errors.New("revision must not be negative")
+	}
+
+	return nil
+}
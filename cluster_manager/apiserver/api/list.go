@@ -0,0 +1,400 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+
+	FilterEquals    = "="
+	FilterNotEquals = "!="
+	FilterIn        = "in"
+	FilterNotIn     = "notin"
+	FilterGreater   = "gt"
+	FilterLess      = "lt"
+)
+
+// 各列表接口可排序、过滤的字段
+var (
+	TaskListFields        = []string{"id", "action", "relate_id", "status", "created_user", "created_at", "finished_at"}
+	BackupFileListFields  = []string{"id", "type", "status", "app_id", "unit_id", "site_id", "strategy_id", "created_user", "size", "created_at", "finished_at", "expired_at"}
+	HostListFields        = []string{"id", "name", "ip", "cluster_id", "room", "seat", "enabled", "created_at"}
+	AppListFields         = []string{"id", "name", "subscription_id", "created_user", "created_at", "modified_at"}
+	SiteListFields        = []string{"id", "name", "type", "region", "created_at"}
+	ClusterListFields     = []string{"id", "name", "site_id", "zone", "ha_tag", "enabled", "created_at"}
+	NetworkListFields     = []string{"id", "name", "cluster_id", "enabled", "created_at"}
+	listRequestParameters = []string{"limit", "cursor", "sort", "filter", "fields"}
+)
+
+// ListRequest 列表接口的通用查询参数，任一参数存在时返回ListResponse
+type ListRequest struct {
+	// 返回条数，默认100，最大1000
+	// in: query
+	// required: false
+	Limit uint64 `json:"limit"`
+
+	// 上一页返回的next_cursor
+	// in: query
+	// required: false
+	Cursor string `json:"cursor"`
+
+	// 排序字段，逗号分隔，'-'前缀表示降序，如 -created_at,name
+	// in: query
+	// required: false
+	Sort string `json:"sort"`
+
+	// 过滤条件，label selector语法，如 status=running,app_id in (a,b),size>100
+	// in: query
+	// required: false
+	Filter string `json:"filter"`
+
+	// 返回字段，逗号分隔
+	// in: query
+	// required: false
+	Fields string `json:"fields"`
+}
+
+// ListFilter 一个过滤条件，语法与label selector一致，如 status=running,app_id in (a,b),size>100
+type ListFilter struct {
+	Field    string
+	Operator string
+	Values   []string
+}
+
+type ListSort struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions 列表接口的通用参数：分页游标、排序、过滤和返回字段
+type ListOptions struct {
+	Limit uint64
+	// 上一页最后一条记录的排序字段及主键的值，由cursor解码
+	After   []string
+	Sort    []ListSort
+	Filters []ListFilter
+	Fields  []string
+}
+
+// ListMeta 分页结果，NextCursor为空表示没有下一页
+type ListMeta struct {
+	Total      uint64
+	NextCursor string
+}
+
+// ListResponse 列表接口的分页返回
+type ListResponse struct {
+	Items      interface{} `json:"items"`
+	Total      uint64      `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+func NewListResponse(items interface{}, meta ListMeta, fields []string) (ListResponse, error) {
+	items, err := SelectFields(items, fields)
+
+	return ListResponse{
+		Items:      items,
+		Total:      meta.Total,
+		NextCursor: meta.NextCursor,
+	}, err
+}
+
+// IsListRequest returns true if any of limit,cursor,sort,filter and fields is requested,
+// the list endpoints return ListResponse rather than the plain array in that case.
+func IsListRequest(values url.Values) bool {
+	for _, p := range listRequestParameters {
+		if _, ok := values[p]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listCursor 分页游标，记录排序方式及上一页最后一条记录的排序字段和主键的值，
+// 下一页从该记录之后开始(keyset)，不受翻页期间插入或删除记录的影响
+type listCursor struct {
+	Sort   string   `json:"s,omitempty"`
+	Values []string `json:"v"`
+}
+
+func (opts ListOptions) sortString() string {
+	sorts := make([]string, len(opts.Sort))
+	for i, s := range opts.Sort {
+		sorts[i] = s.Field
+		if s.Desc {
+			sorts[i] = "-" + s.Field
+		}
+	}
+
+	return strings.Join(sorts, ",")
+}
+
+// EncodeCursor returns the cursor of the page after the record has the values of sort fields and key.
+func (opts ListOptions) EncodeCursor(after []string) string {
+	b, _ := json.Marshal(listCursor{Sort: opts.sortString(), Values: after})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor returns the values of the record the page after,
+// the cursor should be created with the same sort of opts.
+func (opts ListOptions) DecodeCursor(cursor string) ([]string, error) {
+	c := listCursor{}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil || len(c.Values) == 0 {
+		return nil, xerrors.Errorf("invalid cursor '%s'", cursor)
+	}
+
+	if c.Sort != opts.sortString() {
+		return nil, xerrors.Errorf("cursor '%s' doesn't match the sort '%s'", cursor, opts.sortString())
+	}
+
+	return c.Values, nil
+}
+
+// NewListMeta returns the meta of the page,after is the values of the last record if there is a next page.
+func (opts ListOptions) NewListMeta(total uint64, after []string) ListMeta {
+	meta := ListMeta{Total: total}
+
+	if len(after) > 0 {
+		meta.NextCursor = opts.EncodeCursor(after)
+	}
+
+	return meta
+}
+
+// Values encodes opts as the query parameters parsed by ParseListOptions.
+func (opts ListOptions) Values() url.Values {
+	values := make(url.Values)
+
+	if opts.Limit > 0 {
+		values.Set("limit", strconv.FormatUint(opts.Limit, 10))
+	}
+	if len(opts.After) > 0 {
+		values.Set("cursor", opts.EncodeCursor(opts.After))
+	}
+
+	if len(opts.Sort) > 0 {
+		values.Set("sort", opts.sortString())
+	}
+
+	if len(opts.Filters) > 0 {
+		filters := make([]string, len(opts.Filters))
+		for i, f := range opts.Filters {
+			switch f.Operator {
+			case FilterIn, FilterNotIn:
+				filters[i] = f.Field + " " + f.Operator + " (" + strings.Join(f.Values, ",") + ")"
+			case FilterGreater:
+				filters[i] = f.Field + ">" + strings.Join(f.Values, "")
+			case FilterLess:
+				filters[i] = f.Field + "<" + strings.Join(f.Values, "")
+			default:
+				filters[i] = f.Field + f.Operator + strings.Join(f.Values, "")
+			}
+		}
+
+		values.Set("filter", strings.Join(filters, ","))
+	}
+
+	if len(opts.Fields) > 0 {
+		values.Set("fields", strings.Join(opts.Fields, ","))
+	}
+
+	return values
+}
+
+// AddFilter appends an equality filter if value isn't empty,
+// it's used to merge the legacy query parameters of list endpoints.
+func (opts *ListOptions) AddFilter(field, value string) {
+	if value == "" {
+		return
+	}
+
+	opts.Filters = append(opts.Filters, ListFilter{
+		Field:    field,
+		Operator: FilterEquals,
+		Values:   []string{value},
+	})
+}
+
+func containsField(fields []string, f string) bool {
+	for i := range fields {
+		if fields[i] == f {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseListFilters(filter string, fields []string) ([]ListFilter, error) {
+	selector, err := labels.Parse(filter)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid filter '%s':%w", filter, err)
+	}
+
+	reqs, _ := selector.Requirements()
+	filters := make([]ListFilter, 0, len(reqs))
+
+	var errs []error
+
+	for _, req := range reqs {
+		f := ListFilter{
+			Field:  req.Key(),
+			Values: req.Values().List(),
+		}
+
+		switch req.Operator() {
+		case selection.Equals, selection.DoubleEquals:
+			f.Operator = FilterEquals
+		case selection.NotEquals:
+			f.Operator = FilterNotEquals
+		case selection.In:
+			f.Operator = FilterIn
+		case selection.NotIn:
+			f.Operator = FilterNotIn
+		case selection.GreaterThan:
+			f.Operator = FilterGreater
+		case selection.LessThan:
+			f.Operator = FilterLess
+		default:
+			errs = append(errs, xerrors.Errorf("unsupported filter operator '%s' of '%s'", req.Operator(), req.Key()))
+			continue
+		}
+
+		if !containsField(fields, f.Field) {
+			errs = append(errs, xerrors.Errorf("unsupported filter field '%s'", f.Field))
+			continue
+		}
+
+		filters = append(filters, f)
+	}
+
+	return filters, utilerrors.NewAggregate(errs)
+}
+
+// ParseListOptions parses the list parameters,fields are the sortable and filterable fields of the endpoint.
+//
+//	limit:  返回条数，默认100，最大1000
+//	cursor: 上一页返回的next_cursor
+//	sort:   排序字段，逗号分隔，'-'前缀表示降序，如 -created_at,name
+//	filter: 过滤条件，label selector语法，如 status=running,app_id in (a,b)
+//	fields: 返回字段，逗号分隔，为空表示全部
+func ParseListOptions(values url.Values, fields []string) (ListOptions, error) {
+	opts := ListOptions{
+		Limit: DefaultListLimit,
+	}
+
+	var errs []error
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n == 0 || n > MaxListLimit {
+			errs = append(errs, xerrors.Errorf("limit should be in [1,%d]", MaxListLimit))
+		} else {
+			opts.Limit = n
+		}
+	}
+
+	if v := values.Get("sort"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			sort := ListSort{Field: strings.TrimSpace(s)}
+
+			if strings.HasPrefix(sort.Field, "-") {
+				sort.Field = sort.Field[1:]
+				sort.Desc = true
+			}
+
+			if !containsField(fields, sort.Field) {
+				errs = append(errs, xerrors.Errorf("unsupported sort field '%s'", sort.Field))
+				continue
+			}
+
+			opts.Sort = append(opts.Sort, sort)
+		}
+	}
+
+	// 游标与排序方式一致
+	if v := values.Get("cursor"); v != "" {
+		after, err := opts.DecodeCursor(v)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		opts.After = after
+	}
+
+	if v := values.Get("filter"); v != "" {
+		filters, err := parseListFilters(v, fields)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		opts.Filters = filters
+	}
+
+	if v := values.Get("fields"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				opts.Fields = append(opts.Fields, f)
+			}
+		}
+	}
+
+	return opts, utilerrors.NewAggregate(errs)
+}
+
+// SelectFields keeps the json fields of each item of the slice,
+// items are returned unchanged if fields is empty.
+func SelectFields(items interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return items, nil
+	}
+
+	if v := reflect.ValueOf(items); v.Kind() != reflect.Slice {
+		return nil, xerrors.Errorf("expected slice but got %T", items)
+	}
+
+	b, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []map[string]json.RawMessage
+
+	err = json.Unmarshal(b, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]map[string]json.RawMessage, len(list))
+
+	for i := range list {
+		out[i] = make(map[string]json.RawMessage, len(fields))
+
+		for _, f := range fields {
+			if v, ok := list[i][f]; ok {
+				out[i][f] = v
+			}
+		}
+	}
+
+	return out, nil
+}
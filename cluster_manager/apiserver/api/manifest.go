@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// manifest 步骤的操作，按执行顺序排列
+const (
+	ManifestAppCreate = "app-create"
+	// 启动服务在其他操作之前，停止服务在最后
+	ManifestAppState       = "app-state"
+	ManifestAppArch        = "app-arch"
+	ManifestAppImage       = "app-image"
+	ManifestAppResources   = "app-resources"
+	ManifestAppConfig      = "app-config"
+	ManifestSchemaCreate   = "schema-create"
+	ManifestUserCreate     = "user-create"
+	ManifestUserPrivileges = "user-privileges"
+	ManifestStrategyCreate = "backup-strategy-create"
+	ManifestStrategyUpdate = "backup-strategy-update"
+	ManifestStrategyDelete = "backup-strategy-delete"
+	ManifestUserDelete     = "user-delete"
+	ManifestSchemaDelete   = "schema-delete"
+)
+
+// AppManifest 服务的期望状态，与当前状态比较后生成执行计划。
+// 以 name 与 subscription_id 查找服务，不存在则创建；
+// spec.database.services.units.readiness_state 为空时不改变服务状态。
+type AppManifest struct {
+	Name string  `json:"name"`
+	Desc string  `json:"desc"`
+	Arch string  `json:"arch"`
+	Spec AppSpec `json:"spec"`
+
+	// 服务配置，只更新列出的参数
+	Config []ConfigMapOptions `json:"config,omitempty"`
+	// 用户密码只在创建用户时使用
+	Users            []AppUserConfig        `json:"users,omitempty"`
+	Schemas          []AppSchemaConfig      `json:"schemas,omitempty"`
+	BackupStrategies []BackupStrategyConfig `json:"backup_strategies,omitempty"`
+
+	// 删除 manifest 中未列出的用户、库和备份策略
+	Prune bool `json:"prune"`
+
+	User string `json:"created_user"`
+}
+
+func (m AppManifest) Valid() error {
+	if m.Name == "" {
+		return errors.New("manifest name is required")
+	}
+
+	err := AppConfig{
+		Name: m.Name,
+		Desc: m.Desc,
+		User: m.User,
+		Arch: m.Arch,
+		Spec: m.Spec,
+	}.Valid()
+	if err != nil {
+		return err
+	}
+
+	users := make(map[string]bool, len(m.Users))
+	for _, u := range m.Users {
+		key := u.Name + "@" + string(u.IP)
+		if users[key] {
+			return fmt.Errorf("user %s is duplicated", key)
+		}
+		users[key] = true
+	}
+
+	schemas := make(map[string]bool, len(m.Schemas))
+	for _, s := range m.Schemas {
+		if schemas[s.Name] {
+			return fmt.Errorf("schema %s is duplicated", s.Name)
+		}
+		schemas[s.Name] = true
+	}
+
+	strategies := make(map[string]bool, len(m.BackupStrategies))
+	for _, s := range m.BackupStrategies {
+		if s.Name == "" {
+			return errors.New("backup strategy name is required")
+		}
+		if strategies[s.Name] {
+			return fmt.Errorf("backup strategy %s is duplicated", s.Name)
+		}
+		strategies[s.Name] = true
+	}
+
+	return nil
+}
+
+// AppManifestPlan manifest 的执行计划，apply 时包含执行计划的任务
+type AppManifestPlan struct {
+	// 创建服务时 id 为空
+	App   IDName         `json:"app"`
+	Steps []ManifestStep `json:"steps"`
+	Task  TaskBrief      `json:"task"`
+}
+
+type ManifestStep struct {
+	Action string `json:"action"`
+	// 操作对象，如 user@ip、库名、备份策略名称
+	Target string `json:"target"`
+	Reason string `json:"reason"`
+	// 调用已有接口的请求体，密码已隐藏
+	Body interface{} `json:"body,omitempty"`
+}
+
+// AppResourceQuery manifest 写入站点的位置，站点需要以 --manager-server 启动 operator
+type AppResourceQuery struct {
+	SiteID    string `json:"site_id"`
+	Namespace string `json:"namespace"`
+
+	SubscriptionQuery
+}
+
+type AppResourceOptions struct {
+	// enum: Retain,Delete
+	// 删除对象时是否删除服务，默认 Retain
+	DeletionPolicy string `json:"deletion_policy"`
+	Suspend        bool   `json:"suspend"`
+
+	Manifest AppManifest `json:"manifest"`
+}
+
+// AppResource 站点中 App 对象的状态，由站点的 operator 调用 manifest 接口同步
+type AppResource struct {
+	SiteID    string `json:"site_id"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	Generation         int64 `json:"generation"`
+	ObservedGeneration int64 `json:"observed_generation"`
+
+	// enum: Pending,Applying,Ready,Failed
+	Phase   string   `json:"phase"`
+	AppID   string   `json:"app_id"`
+	TaskID  string   `json:"task_id"`
+	Steps   []string `json:"steps"`
+	Message string   `json:"message"`
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/xerrors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	MigrationPending = "pending"
+	MigrationRunning = "running"
+	MigrationApplied = "applied"
+	MigrationFailed  = "failed"
+	// MigrationInterrupted apiserver 重启时正在执行的迁移，可能已部分执行，需人工确认
+	MigrationInterrupted = "interrupted"
+
+	OnlineSchemaToolGhost = "gh-ost"
+	OnlineSchemaToolPT    = "pt-online-schema-change"
+)
+
+// SchemaMigration 一个版本的DDL迁移，按 version 从小到大在主库执行
+type SchemaMigration struct {
+	Version     int64  `json:"version"`
+	Description string `json:"description"`
+	SQL         string `json:"sql"`
+	// 使用在线DDL工具执行以避免锁表，sql 只能是一条 ALTER TABLE 语句
+	Online bool `json:"online,omitempty"`
+}
+
+// SchemaMigrationSet 上传的迁移集合，已执行的版本不能修改，未执行的版本被替换
+type SchemaMigrationSet struct {
+	Migrations []SchemaMigration `json:"migrations"`
+}
+
+func (s SchemaMigrationSet) Valid() error {
+	var errs []error
+	versions := make(map[int64]bool, len(s.Migrations))
+
+	for _, m := range s.Migrations {
+		if m.Version <= 0 {
+			errs = append(errs, xerrors.Errorf("version %d should be greater than 0", m.Version))
+			continue
+		}
+
+		if versions[m.Version] {
+			errs = append(errs, xerrors.Errorf("duplicate version %d", m.Version))
+		}
+		versions[m.Version] = true
+
+		if strings.TrimSpace(m.SQL) == "" {
+			errs = append(errs, xerrors.Errorf("sql of version %d is required", m.Version))
+			continue
+		}
+
+		if m.Online {
+			if _, _, err := ParseOnlineAlter(m.SQL); err != nil {
+				errs = append(errs, xerrors.Errorf("version %d:%s", m.Version, err))
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+var onlineAlterRegexp = regexp.MustCompile("(?is)^\\s*ALTER\\s+TABLE\\s+`?([A-Za-z0-9_$]+)`?\\s+(.+?)\\s*;?\\s*$")
+
+// ParseOnlineAlter returns the table and the alter clause of "ALTER TABLE table ..."
+func ParseOnlineAlter(sql string) (string, string, error) {
+	match := onlineAlterRegexp.FindStringSubmatch(sql)
+	if match == nil {
+		return "", "", xerrors.New("online migration should be a single ALTER TABLE statement")
+	}
+
+	if strings.Contains(match[2], ";") {
+		return "", "", xerrors.New("online migration should be a single ALTER TABLE statement")
+	}
+
+	return match[1], match[2], nil
+}
+
+// SchemaMigrationStatus 迁移及执行记录，checksum 为 sql 的 sha256
+type SchemaMigrationStatus struct {
+	SchemaMigration
+
+	Checksum string `json:"checksum"`
+	State    string `json:"state"`
+	Error    string `json:"error,omitempty"`
+	// 最近一次验证通过的时间
+	ValidatedAt *Time `json:"validated_at,omitempty"`
+	AppliedAt   *Time `json:"applied_at,omitempty"`
+	// 执行耗时，秒
+	Duration int64 `json:"duration,omitempty"`
+}
+
+type SchemaMigrationsResponse []SchemaMigrationStatus
+
+// SchemaMigrationApplyOptions dry_run 时在草稿库中验证：在 unit 上按 schema 的表结构(不含数据)
+// 创建草稿库并执行迁移，不写 binlog，结束后删除草稿库
+type SchemaMigrationApplyOptions struct {
+	// 执行到该版本为止，0表示所有未执行的版本
+	TargetVersion int64 `json:"target_version,omitempty"`
+	DryRun        bool  `json:"dry_run,omitempty"`
+	// 验证使用的单元，不能是主库单元，默认一个从库单元，只用于 dry_run
+	Unit string `json:"unit,omitempty"`
+	// 确认中断的迁移可以重新执行
+	ResolveInterrupted bool   `json:"resolve_interrupted,omitempty"`
+	User               string `json:"user,omitempty"`
+}
+
+func (opts SchemaMigrationApplyOptions) Valid() error {
+	if opts.TargetVersion < 0 {
+		return xerrors.New("target_version should not be negative")
+	}
+
+	if opts.Unit != "" && !opts.DryRun {
+		return xerrors.New("unit is only supported by dry_run")
+	}
+
+	return nil
+}
+
+type SchemaMigrationApplyResponse struct {
+	// 将要执行的迁移
+	Migrations SchemaMigrationsResponse `json:"migrations"`
+	Task       TaskBrief                `json:"task"`
+}
@@ -19,8 +19,17 @@ type Network struct {
 	// 拓扑
 	Topology []string  `json:"topology"`
 	IP       IPSummary `json:"ip_summary"`
-	Created  Editor    `json:"created"`
-	Modified Editor    `json:"modified"`
+	// 探测到已被占用而隔离的 IP
+	Conflicts []IPConflict `json:"conflicts"`
+	Created   Editor       `json:"created"`
+	Modified  Editor       `json:"modified"`
+}
+
+type IPConflict struct {
+	IP     IP     `json:"ip"`
+	Claim  string `json:"claim"`
+	Reason string `json:"reason"`
+	Time   Time   `json:"time"`
 }
 
 type IPSummary struct {
@@ -20,8 +20,8 @@ type PaginationReq struct {
 }
 
 func NewPaginationReq(size, page string) PaginationReq {
-	size_, _ := strconv.ParseUint(size, 10, 8)
-	page_, _ := strconv.ParseUint(page, 10, 8)
+	size_, _ := strconv.ParseUint(size, 10, 32)
+	page_, _ := strconv.ParseUint(page, 10, 32)
 	ret := PaginationReq{
 		Size: uint(size_),
 		Page: uint(page_),
@@ -32,6 +32,9 @@ func NewPaginationReq(size, page string) PaginationReq {
 	if ret.Size <= 0 {
 		ret.Size = 10
 	}
+	if ret.Size > MaxListLimit {
+		ret.Size = MaxListLimit
+	}
 	return ret
 }
 
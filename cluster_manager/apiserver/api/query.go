@@ -0,0 +1,186 @@
+package api
+
+// 以下为各接口的查询参数，用于生成OpenAPI文档与客户端，字段名为json tag，空值不传。
+
+type SubscriptionQuery struct {
+	SubscriptionID string `json:"subscription_id"`
+}
+
+type TaskListQuery struct {
+	ID       string `json:"id"`
+	RelateID string `json:"relate_id"`
+	Action   string `json:"action"`
+	Status   string `json:"status"`
+
+	ListRequest
+}
+
+type AppListQuery struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	SubscriptionID string `json:"subscription_id"`
+
+	ListRequest
+}
+
+type AppPaginationQuery struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	SubscriptionID string `json:"subscription_id"`
+	Page           uint   `json:"page"`
+	Size           uint   `json:"size"`
+}
+
+type DBUserQuery struct {
+	IP             string `json:"ip"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
+type AlertListQuery struct {
+	App      string `json:"app_id"`
+	Severity string `json:"severity"`
+}
+
+type TypeQuery struct {
+	Type string `json:"type"`
+}
+
+type ImageScriptsQuery struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	User   string `json:"user"`
+}
+
+type ImageTemplateDiffQuery struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+type ImageLifecycleQuery struct {
+	// enum: deprecated,eol
+	Lifecycle string `json:"lifecycle"`
+}
+
+type ImageCampaignListQuery struct {
+	// enum: running,completed,canceled
+	State string `json:"state"`
+}
+
+type AuditListQuery struct {
+	User   string `json:"user"`
+	App    string `json:"app_id"`
+	Action string `json:"action"`
+	// TimeFormat 或 RFC3339
+	Since string `json:"since"`
+	Until string `json:"until"`
+	Limit int    `json:"limit"`
+}
+
+type NetworkListQuery struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Cluster  string `json:"cluster_id"`
+	Site     string `json:"site_id"`
+	Topology string `json:"topology"`
+	Enabled  string `json:"enabled"`
+
+	ListRequest
+}
+
+type AppIDQuery struct {
+	App string `json:"app_id"`
+}
+
+type LimitQuery struct {
+	Limit int `json:"limit"`
+}
+
+type RemoteStorageListQuery struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Site    string `json:"site_id"`
+	Enabled string `json:"enabled"`
+}
+
+type IDNameQuery struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type SiteListQuery struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	ListRequest
+}
+
+type ClusterListQuery struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Site    string `json:"site_id"`
+	Enabled string `json:"enabled"`
+
+	ListRequest
+}
+
+type HostListQuery struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Cluster string `json:"cluster_id"`
+	Site    string `json:"site_id"`
+	Enabled string `json:"enabled"`
+
+	ListRequest
+}
+
+// HostDeleteQuery 登录主机清理环境的ssh信息
+type HostDeleteQuery struct {
+	Username string `json:"username"`
+	Password string `json:"pwd"`
+	Port     int    `json:"ssh_port"`
+}
+
+type BackupFileListQuery struct {
+	ID             string `json:"id"`
+	Unit           string `json:"unit_id"`
+	App            string `json:"app_id"`
+	Site           string `json:"site_id"`
+	CreatedUser    string `json:"created_user"`
+	SubscriptionID string `json:"subscription_id"`
+
+	ListRequest
+}
+
+type BackupStrategyListQuery struct {
+	ID             string `json:"id"`
+	Unit           string `json:"unit_id"`
+	App            string `json:"app_id"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
+type IDAppQuery struct {
+	ID             string `json:"id"`
+	App            string `json:"app_id"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
+type BackupEndpointListQuery struct {
+	Site string `json:"site_id"`
+	Type string `json:"type"`
+}
+
+type SubscriptionListQuery struct {
+	Name string `json:"name"`
+}
+
+type UsageListQuery struct {
+	SubscriptionID string `json:"subscription_id"`
+	App            string `json:"app_id"`
+	// enum: day,month
+	Period string `json:"period"`
+	// enum: app,subscription
+	GroupBy string `json:"group_by"`
+	// TimeFormat 或 RFC3339
+	Since string `json:"since"`
+	Until string `json:"until"`
+}
@@ -23,6 +23,8 @@ type Site struct {
 	State         string `json:"state"`
 	Created       Editor `json:"created"`
 	Modified      Editor `json:"modified"`
+	// Status 后台周期检查的站点组件状态，尚未检查时为空
+	Status *SiteStatus `json:"status,omitempty"`
 
 	Labels map[string]string `json:"-"`
 }
@@ -56,6 +58,9 @@ type SiteConfig struct {
 	ProjectName   string `json:"project_name"`
 	NetworkMode   string `json:"network_mode"`
 	User          string `json:"created_user"`
+
+	// 跳过预检，预检失败时仍然增加站点
+	SkipPreflight bool `json:"skip_preflight"`
 }
 
 func (c SiteConfig) Valid() error {
@@ -135,3 +140,35 @@ type DeleteSiteRequest struct {
 	// in: path
 	ID string `json:"id"`
 }
+
+const (
+	SiteCheckPassing  = "passing"
+	SiteCheckWarning  = "warning"
+	SiteCheckCritical = "critical"
+)
+
+// SiteCheck 站点单项检查的结果
+type SiteCheck struct {
+	// example: apiserver
+	Name string `json:"name"`
+	// enum: passing,warning,critical
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// SitePreflight 增加站点前的预检结果，存在 critical 的检查项时不通过
+type SitePreflight struct {
+	Passed bool        `json:"passed"`
+	Checks []SiteCheck `json:"checks"`
+}
+
+// SiteStatus 站点组件状态，State 为各组件中最差的状态
+type SiteStatus struct {
+	// enum: passing,warning,critical
+	State       string      `json:"state"`
+	Components  []SiteCheck `json:"components"`
+	CacheSynced bool        `json:"cache_synced"`
+	LastError   string      `json:"last_error,omitempty"`
+	LastErrorAt *Time       `json:"last_error_at,omitempty"`
+	CheckedAt   Time        `json:"checked_at"`
+}
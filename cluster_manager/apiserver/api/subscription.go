@@ -0,0 +1,112 @@
+package api
+
+import (
+	"golang.org/x/xerrors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// SubscriptionResources 订阅的配额或用量，配额为0表示不限制，
+// CPU单位为millicore，内存及存储单位为MiB
+type SubscriptionResources struct {
+	CPU    int64 `json:"cpu"`
+	Memory int64 `json:"memory"`
+	// 各性能等级的存储，key为性能等级
+	// example: {"medium":102400,"high":51200}
+	Storage       map[Performance]int64 `json:"storage,omitempty"`
+	Apps          int                   `json:"apps"`
+	BackupStorage int64                 `json:"backup_storage"`
+}
+
+func (r SubscriptionResources) Valid() error {
+	var errs []error
+
+	if r.CPU < 0 || r.Memory < 0 || r.Apps < 0 || r.BackupStorage < 0 {
+		errs = append(errs, xerrors.New("quota must not be negative"))
+	}
+
+	for level, v := range r.Storage {
+		if level == PerformanceNone || v < 0 {
+			errs = append(errs, xerrors.Errorf("invalid storage quota %s:%d", level, v))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Subscription 租户订阅，服务通过 subscription_id 属于订阅
+type Subscription struct {
+	ID      string                `json:"id"`
+	Name    string                `json:"name"`
+	Desc    string                `json:"desc"`
+	Enabled bool                  `json:"enabled"`
+	Quota   SubscriptionResources `json:"quota"`
+
+	Created  Editor `json:"created"`
+	Modified Editor `json:"modified"`
+}
+
+type SubscriptionsResponse []Subscription
+
+type SubscriptionConfig struct {
+	// 订阅号，与服务的subscription_id对应，为空时自动生成
+	ID      string                `json:"id"`
+	Name    string                `json:"name"`
+	Desc    string                `json:"desc"`
+	Enabled bool                  `json:"enabled"`
+	Quota   SubscriptionResources `json:"quota"`
+
+	User string `json:"created_user"`
+}
+
+func (c SubscriptionConfig) Valid() error {
+	var errs []error
+
+	if c.Name == "" {
+		errs = append(errs, xerrors.New("name is required"))
+	}
+
+	if err := c.Quota.Valid(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+type SubscriptionOptions struct {
+	Name    *string                `json:"name,omitempty"`
+	Desc    *string                `json:"desc,omitempty"`
+	Enabled *bool                  `json:"enabled,omitempty"`
+	Quota   *SubscriptionResources `json:"quota,omitempty"`
+
+	User string `json:"modified_user"`
+}
+
+func (opts SubscriptionOptions) Valid() error {
+	var errs []error
+
+	if opts.Name != nil && *opts.Name == "" {
+		errs = append(errs, xerrors.New("name is required"))
+	}
+
+	if opts.Quota != nil {
+		if err := opts.Quota.Valid(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// AppUsage 服务占用的资源，Apps 为1
+type AppUsage struct {
+	App   IDName                `json:"app"`
+	Usage SubscriptionResources `json:"usage"`
+}
+
+// SubscriptionUsage 订阅的配额及用量，资源按服务规格统计，备份存储按备份文件大小统计
+type SubscriptionUsage struct {
+	Subscription IDName                `json:"subscription"`
+	Quota        SubscriptionResources `json:"quota"`
+	Usage        SubscriptionResources `json:"usage"`
+	Apps         []AppUsage            `json:"apps"`
+}
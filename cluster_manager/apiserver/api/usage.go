@@ -0,0 +1,65 @@
+package api
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	UsagePeriodDay   = "day"
+	UsagePeriodMonth = "month"
+
+	UsageGroupByApp          = "app"
+	UsageGroupBySubscription = "subscription"
+)
+
+// UsageQuery 用量统计查询条件
+type UsageQuery struct {
+	Subscription string
+	App          string
+	// day 或 month
+	Period string
+	// app 或 subscription
+	GroupBy string
+	Since   time.Time
+	Until   time.Time
+}
+
+func (q UsageQuery) Valid() error {
+	if q.Period != UsagePeriodDay && q.Period != UsagePeriodMonth {
+		return xerrors.Errorf("unsupported period '%s',should be %s or %s", q.Period, UsagePeriodDay, UsagePeriodMonth)
+	}
+
+	if q.GroupBy != UsageGroupByApp && q.GroupBy != UsageGroupBySubscription {
+		return xerrors.Errorf("unsupported group_by '%s',should be %s or %s", q.GroupBy, UsageGroupByApp, UsageGroupBySubscription)
+	}
+
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return xerrors.New("since should be before until")
+	}
+
+	return nil
+}
+
+// UsageRecord 一个周期内服务或订阅的用量，每小时采样一次，
+// 各项为采样值之和，即 millicore·小时、MiB·小时、Mbps·小时，除以 Hours 为平均值
+type UsageRecord struct {
+	// 2006-01-02 或 2006-01
+	Period       string `json:"period"`
+	Subscription string `json:"subscription_id"`
+	// 按订阅统计时为空
+	App       IDName `json:"app"`
+	Hours     int    `json:"hours"`
+	CPU       int64  `json:"cpu"`
+	Memory    int64  `json:"memory"`
+	Bandwidth int64  `json:"net_bandwidth"`
+	// 分配的存储，key为性能等级
+	Storage map[Performance]int64 `json:"storage,omitempty"`
+	// 已使用的存储，key为性能等级
+	StorageUsed map[Performance]int64 `json:"storage_used,omitempty"`
+	// 备份文件大小，key为备份端点
+	BackupStorage map[string]int64 `json:"backup_storage,omitempty"`
+}
+
+type UsageResponse []UsageRecord
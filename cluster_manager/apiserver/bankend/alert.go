@@ -0,0 +1,714 @@
+package bankend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/audit"
+	"github.com/upmio/dbscale-kube/pkg/zone"
+)
+
+// AlertConfig prometheus与通知相关的配置
+type AlertConfig struct {
+	// prometheus服务所在的namespace
+	PrometheusNamespace string
+	// prometheus服务，格式为 name:port
+	PrometheusService string
+
+	// 发送邮件的smtp服务器，无认证
+	SMTPAddr string
+	SMTPFrom string
+
+	// 检查告警并发送通知的间隔
+	NotifyInterval time.Duration
+}
+
+func NewAlertBankend(zone zone.ZoneInterface, m modelAlert, apps appGetter,
+	strategies strategyGetter, files backupFileGetter, config AlertConfig) *bankendAlert {
+	return &bankendAlert{
+		m:          m,
+		apps:       apps,
+		strategies: strategies,
+		files:      files,
+		zone:       zoneIface{zone: zone},
+		config:     config,
+		notified:   make(map[string]alertNotification),
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type bankendAlert struct {
+	m          modelAlert
+	apps       appGetter
+	strategies strategyGetter
+	files      backupFileGetter
+
+	zone   zoneIface
+	config AlertConfig
+
+	client *http.Client
+
+	lock sync.Mutex
+	// 已通知的firing告警，用于去重和发送resolved通知
+	notified map[string]alertNotification
+}
+
+type modelAlert interface {
+	GetAppConfig(app string) (model.AppAlertConfig, error)
+	SetAppConfig(c model.AppAlertConfig) error
+	DeleteAppConfig(app string) error
+
+	InsertChannel(ch model.NotificationChannel) (string, error)
+	UpdateChannel(ch model.NotificationChannel) error
+	DeleteChannel(id string) error
+	GetChannel(id string) (model.NotificationChannel, error)
+	ListChannels(selector map[string]string) ([]model.NotificationChannel, error)
+}
+
+func (b *bankendAlert) appAlertConfig(app model.Application) (api.AppAlertConfig, error) {
+	config := api.AppAlertConfig{
+		App: api.IDName{
+			ID:   app.ID,
+			Name: app.Name,
+		},
+		Enabled:    true,
+		Thresholds: api.DefaultAlertThresholds(),
+	}
+
+	c, err := b.m.GetAppConfig(app.ID)
+	if model.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if c.Thresholds != "" {
+		err = json.Unmarshal([]byte(c.Thresholds), &config.Thresholds)
+		if err != nil {
+			return config, err
+		}
+	}
+
+	config.Enabled = c.Enabled
+	config.Modified = api.NewEditor(c.ModifiedUser, c.ModifiedAt)
+
+	return config, nil
+}
+
+func (b *bankendAlert) GetAppAlertConfig(ctx context.Context, app string) (api.AppAlertConfig, error) {
+	ma, err := b.apps.Get(app)
+	if err != nil {
+		return api.AppAlertConfig{}, err
+	}
+
+	return b.appAlertConfig(ma)
+}
+
+func (b *bankendAlert) SetAppAlertConfig(ctx context.Context, app string, opts api.AppAlertConfigOptions) (api.AppAlertConfig, error) {
+	ma, err := b.apps.Get(app)
+	if err != nil {
+		return api.AppAlertConfig{}, err
+	}
+
+	config, err := b.appAlertConfig(ma)
+	if err != nil {
+		return config, err
+	}
+
+	before := config
+
+	config.Thresholds = opts.Apply(config.Thresholds)
+	if opts.Enabled != nil {
+		config.Enabled = *opts.Enabled
+	}
+
+	if err := config.Thresholds.Valid(); err != nil {
+		return config, err
+	}
+
+	for _, site := range appSites(ma) {
+		iface, err := b.zone.siteInterface(site)
+		if err != nil {
+			return config, err
+		}
+
+		if config.Enabled {
+			err = applyAlertRules(iface, ma.ID, ma.Name, config.Thresholds, true)
+		} else {
+			err = deleteAlertRules(iface, ma.Name)
+		}
+		if err != nil {
+			return config, fmt.Errorf("apply prometheus rules of service %s in site %s:%w", ma.Name, site, err)
+		}
+	}
+
+	thresholds, err := json.Marshal(config.Thresholds)
+	if err != nil {
+		return config, err
+	}
+
+	now := time.Now()
+	err = b.m.SetAppConfig(model.AppAlertConfig{
+		App:        ma.ID,
+		Enabled:    config.Enabled,
+		Thresholds: string(thresholds),
+		Editor: model.Editor{
+			CreatedUser:  opts.User,
+			CreatedAt:    now,
+			ModifiedUser: opts.User,
+			ModifiedAt:   now,
+		},
+	})
+
+	config.Modified = api.NewEditor(opts.User, now)
+
+	if err == nil {
+		audit.Record(ctx, api.AuditAppAlertConfig, "app_alert_config", ma.Name, before, config)
+	}
+
+	return config, err
+}
+
+// appSites returns the sites of the units of app,the prometheus rules are applied in these sites.
+func appSites(app model.Application) []string {
+	sites := make([]string, 0, 1)
+	exist := make(map[string]bool)
+
+	for _, unit := range app.Units {
+		if unit.Site != "" && !exist[unit.Site] {
+			exist[unit.Site] = true
+			sites = append(sites, unit.Site)
+		}
+	}
+
+	return sites
+}
+
+// ListAlerts returns the active alerts of apps,
+// include the firing/pending alerts of prometheus and the backup alerts.
+func (b *bankendAlert) ListAlerts(ctx context.Context, app, severity string) (api.AlertsResponse, error) {
+	var (
+		apps []model.Application
+		err  error
+	)
+
+	if app != "" {
+		ma, err := b.apps.Get(app)
+		if err != nil {
+			return nil, err
+		}
+
+		apps = []model.Application{ma}
+	} else {
+		apps, err = b.apps.List(map[string]string{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(apps) == 0 {
+		return api.AlertsResponse{}, nil
+	}
+
+	promAlerts := b.listPrometheusAlerts()
+
+	var errs []error
+	out := make(api.AlertsResponse, 0, len(apps))
+
+	for i := range apps {
+		config, err := b.appAlertConfig(apps[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if !config.Enabled {
+			continue
+		}
+
+		alerts := promAlerts[apps[i].ID]
+
+		backups, err := b.backupAlerts(apps[i].ID, config.Thresholds)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		alerts = append(alerts, backups...)
+		alerts = filterAlerts(alerts, severity)
+
+		if len(alerts) == 0 {
+			continue
+		}
+
+		sort.Slice(alerts, func(i, j int) bool {
+			return time.Time(alerts[i].ActiveAt).Before(time.Time(alerts[j].ActiveAt))
+		})
+
+		out = append(out, api.AppAlerts{
+			App:    config.App,
+			Alerts: alerts,
+		})
+	}
+
+	return out, utilerrors.NewAggregate(errs)
+}
+
+func filterAlerts(alerts []api.Alert, severity string) []api.Alert {
+	if severity == "" {
+		return alerts
+	}
+
+	out := make([]api.Alert, 0, len(alerts))
+
+	for i := range alerts {
+		if alerts[i].Severity == severity {
+			out = append(out, alerts[i])
+		}
+	}
+
+	return out
+}
+
+// listPrometheusAlerts returns the alerts generated by the rules of apps from all sites, key is app id.
+func (b *bankendAlert) listPrometheusAlerts() map[string][]api.Alert {
+	out := make(map[string][]api.Alert)
+
+	for _, s := range b.zone.listSites() {
+		iface, err := b.zone.siteInterface(s.Name())
+		if err != nil {
+			klog.Errorf("list prometheus alerts of site %s:%s", s.Name(), err)
+			continue
+		}
+
+		list, err := iface.PrometheusAlerts().List(b.config.PrometheusNamespace, b.config.PrometheusService)
+		if err != nil {
+			klog.Errorf("list prometheus alerts of site %s:%s", s.Name(), err)
+			continue
+		}
+
+		for _, pa := range list {
+			id := pa.Labels[alertLabelAppID]
+			if id == "" {
+				continue
+			}
+
+			out[id] = append(out[id], api.Alert{
+				Name:     pa.Labels["alertname"],
+				Severity: pa.Labels[alertLabelSeverity],
+				State:    pa.State,
+				Source:   api.AlertSourcePrometheus,
+				Site:     s.Name(),
+				Unit:     pa.Labels["pod"],
+				Summary:  pa.Annotations[alertAnnotationSummary],
+				Value:    pa.Value,
+				ActiveAt: api.Time(pa.ActiveAt),
+			})
+		}
+	}
+
+	return out
+}
+
+// backupAlerts checks the latest backup of each enabled strategy of app,
+// backup jobs are cleaned up soon after finished,so the alerts are computed from the backup files.
+func (b *bankendAlert) backupAlerts(app string, t api.AlertThresholds) ([]api.Alert, error) {
+	strategies, err := b.strategies.ListStrategy(map[string]string{"app_id": app})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var alerts []api.Alert
+
+	for _, bs := range strategies {
+		if !bs.Enabled || bs.Schedule == "" {
+			continue
+		}
+
+		files, err := b.files.ListFiles(map[string]string{"strategy_id": bs.ID})
+		if err != nil {
+			return alerts, err
+		}
+
+		var latest, lastComplete *model.BackupFile
+
+		for i := range files {
+			if latest == nil || files[i].CreatedAt.After(latest.CreatedAt) {
+				latest = &files[i]
+			}
+
+			if files[i].Status == model.BackupFileComplete &&
+				(lastComplete == nil || files[i].FinishedAt.After(lastComplete.FinishedAt)) {
+				lastComplete = &files[i]
+			}
+		}
+
+		if latest != nil && latest.Status == model.BackupFileFailed {
+			alerts = append(alerts, api.Alert{
+				Name:     api.AlertBackupFailed,
+				Severity: api.AlertSeverityCritical,
+				State:    api.AlertStateFiring,
+				Source:   api.AlertSourceManager,
+				Site:     latest.Site,
+				Unit:     latest.Unit,
+				Summary:  fmt.Sprintf("backup %s of strategy %s failed", latest.Job, bs.Name),
+				ActiveAt: api.Time(latest.FinishedAt),
+			})
+		}
+
+		sched, err := cron.ParseStandard(bs.Schedule)
+		if err != nil {
+			klog.Errorf("parse schedule '%s' of backup strategy %s:%s", bs.Schedule, bs.ID, err)
+			continue
+		}
+
+		since := bs.CreatedAt
+		if lastComplete != nil {
+			since = lastComplete.FinishedAt
+		}
+
+		due := sched.Next(since).Add(time.Duration(t.BackupMissedHours) * time.Hour)
+		if now.After(due) {
+			alerts = append(alerts, api.Alert{
+				Name:     api.AlertBackupMissed,
+				Severity: api.AlertSeverityWarning,
+				State:    api.AlertStateFiring,
+				Source:   api.AlertSourceManager,
+				Unit:     bs.Unit,
+				Summary:  fmt.Sprintf("no successful backup of strategy %s since %s", bs.Name, since.Format(api.TimeFormat)),
+				ActiveAt: api.Time(due),
+			})
+		}
+	}
+
+	return alerts, nil
+}
+
+func convertNotificationChannel(ch model.NotificationChannel) (api.NotificationChannel, error) {
+	out := api.NotificationChannel{
+		ID:       ch.ID,
+		Name:     ch.Name,
+		Type:     ch.Type,
+		Enabled:  ch.Enabled,
+		Created:  api.NewEditor(ch.CreatedUser, ch.CreatedAt),
+		Modified: api.NewEditor(ch.ModifiedUser, ch.ModifiedAt),
+	}
+
+	if ch.Config == "" {
+		return out, nil
+	}
+
+	err := json.Unmarshal([]byte(ch.Config), &out.ChannelConfig)
+
+	return out, err
+}
+
+func (b *bankendAlert) AddChannel(ctx context.Context, config api.NotificationChannelConfig) (api.ObjectResponse, error) {
+	cc, err := json.Marshal(config.ChannelConfig)
+	if err != nil {
+		return api.ObjectResponse{}, err
+	}
+
+	now := time.Now()
+	id, err := b.m.InsertChannel(model.NotificationChannel{
+		Name:    strings.TrimSpace(config.Name),
+		Type:    config.Type,
+		Config:  string(cc),
+		Enabled: config.Enabled,
+		Editor: model.Editor{
+			CreatedUser:  config.User,
+			CreatedAt:    now,
+			ModifiedUser: config.User,
+			ModifiedAt:   now,
+		},
+	})
+	if err != nil {
+		return api.ObjectResponse{}, err
+	}
+
+	return api.ObjectResponse{
+		ID:   id,
+		Name: config.Name,
+	}, nil
+}
+
+func (b *bankendAlert) SetChannel(ctx context.Context, id string, opts api.NotificationChannelOptions) (api.NotificationChannel, error) {
+	ch, err := b.m.GetChannel(id)
+	if err != nil {
+		return api.NotificationChannel{}, err
+	}
+
+	out, err := convertNotificationChannel(ch)
+	if err != nil {
+		return out, err
+	}
+
+	if opts.Name != nil {
+		out.Name = strings.TrimSpace(*opts.Name)
+	}
+	if opts.Enabled != nil {
+		out.Enabled = *opts.Enabled
+	}
+	if opts.URL != nil {
+		out.URL = *opts.URL
+	}
+	if opts.To != nil {
+		out.To = *opts.To
+	}
+	if opts.Apps != nil {
+		out.Apps = *opts.Apps
+	}
+	if opts.Severities != nil {
+		out.Severities = *opts.Severities
+	}
+
+	if err := out.ChannelConfig.Valid(out.Type); err != nil {
+		return out, err
+	}
+
+	cc, err := json.Marshal(out.ChannelConfig)
+	if err != nil {
+		return out, err
+	}
+
+	ch.Name = out.Name
+	ch.Enabled = out.Enabled
+	ch.Config = string(cc)
+	ch.ModifiedUser = opts.User
+	ch.ModifiedAt = time.Now()
+
+	err = b.m.UpdateChannel(ch)
+	if err != nil {
+		return out, err
+	}
+
+	out.Modified = api.NewEditor(ch.ModifiedUser, ch.ModifiedAt)
+
+	return out, nil
+}
+
+func (b *bankendAlert) GetChannel(ctx context.Context, id string) (api.NotificationChannel, error) {
+	ch, err := b.m.GetChannel(id)
+	if err != nil {
+		return api.NotificationChannel{}, err
+	}
+
+	return convertNotificationChannel(ch)
+}
+
+func (b *bankendAlert) ListChannels(ctx context.Context, typ string) ([]api.NotificationChannel, error) {
+	selector := make(map[string]string)
+	if typ != "" {
+		selector["type"] = typ
+	}
+
+	list, err := b.m.ListChannels(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.NotificationChannel, 0, len(list))
+
+	for i := range list {
+		ch, err := convertNotificationChannel(list[i])
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, ch)
+	}
+
+	return out, nil
+}
+
+func (b *bankendAlert) DeleteChannel(ctx context.Context, id string) error {
+	ch, err := b.m.GetChannel(id)
+	if model.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return b.m.DeleteChannel(ch.ID)
+}
+
+// RunNotifier checks the alerts every NotifyInterval
+// and notifies the channels when alerts are firing or resolved, until stopCh is closed.
+func (b *bankendAlert) RunNotifier(stopCh <-chan struct{}) {
+	if b.config.NotifyInterval <= 0 {
+		return
+	}
+
+	go wait.Until(b.notify, b.config.NotifyInterval, stopCh)
+}
+
+// alertNotification 发送到通知渠道的内容
+type alertNotification struct {
+	Status string     `json:"status"`
+	App    api.IDName `json:"app"`
+	Alert  api.Alert  `json:"alert"`
+}
+
+func alertKey(app string, a api.Alert) string {
+	return strings.Join([]string{app, a.Name, a.Site, a.Unit}, "/")
+}
+
+func (b *bankendAlert) notify() {
+	resp, listErr := b.ListAlerts(context.Background(), "", "")
+	if listErr != nil {
+		klog.Errorf("notifier list alerts:%s", listErr)
+		// 部分服务出错时不发送resolved，避免误报
+		if len(resp) == 0 {
+			return
+		}
+	}
+
+	channels, err := b.m.ListChannels(map[string]string{labelEnabled: "1"})
+	if err != nil {
+		klog.Errorf("notifier list notification channels:%s", err)
+		return
+	}
+
+	firing := make(map[string]alertNotification)
+
+	for _, app := range resp {
+		for _, a := range app.Alerts {
+			if a.State != api.AlertStateFiring {
+				continue
+			}
+
+			firing[alertKey(app.App.ID, a)] = alertNotification{
+				Status: api.AlertStateFiring,
+				App:    app.App,
+				Alert:  a,
+			}
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var notifications []alertNotification
+
+	for key, n := range firing {
+		if _, ok := b.notified[key]; !ok {
+			notifications = append(notifications, n)
+		}
+	}
+
+	if listErr == nil {
+		for key, n := range b.notified {
+			if _, ok := firing[key]; ok {
+				continue
+			}
+
+			n.Status = api.AlertStateResolved
+			n.Alert.State = api.AlertStateResolved
+			notifications = append(notifications, n)
+
+			delete(b.notified, key)
+		}
+	}
+
+	for key, n := range firing {
+		b.notified[key] = n
+	}
+
+	for _, n := range notifications {
+		for i := range channels {
+			ch, err := convertNotificationChannel(channels[i])
+			if err != nil {
+				klog.Errorf("notification channel %s:%s", channels[i].Name, err)
+				continue
+			}
+
+			if !channelMatch(ch, n) {
+				continue
+			}
+
+			if err := b.send(ch, n); err != nil {
+				klog.Errorf("send %s alert %s of service %s to channel %s:%s", n.Status, n.Alert.Name, n.App.ID, ch.Name, err)
+			}
+		}
+	}
+}
+
+func channelMatch(ch api.NotificationChannel, n alertNotification) bool {
+	if !ch.Enabled {
+		return false
+	}
+
+	if len(ch.Apps) > 0 && !containsStr(ch.Apps, n.App.ID) && !containsStr(ch.Apps, n.App.Name) {
+		return false
+	}
+
+	if len(ch.Severities) > 0 && !containsStr(ch.Severities, n.Alert.Severity) {
+		return false
+	}
+
+	return true
+}
+
+func containsStr(list []string, s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *bankendAlert) send(ch api.NotificationChannel, n alertNotification) error {
+	switch ch.Type {
+	case api.ChannelWebhook:
+		body, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+
+		resp, err := b.client.Post(ch.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("webhook %s response %s", ch.URL, resp.Status)
+		}
+
+		return nil
+
+	case api.ChannelEmail:
+		subject := fmt.Sprintf("[%s] %s %s of service %s", strings.ToUpper(n.Status), n.Alert.Severity, n.Alert.Name, n.App.Name)
+
+		msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\nsite: %s\r\nunit: %s\r\nactive at: %s\r\n",
+			b.config.SMTPFrom, strings.Join(ch.To, ","), subject,
+			n.Alert.Summary, n.Alert.Site, n.Alert.Unit, time.Time(n.Alert.ActiveAt).Format(api.TimeFormat))
+
+		return smtp.SendMail(b.config.SMTPAddr, nil, b.config.SMTPFrom, ch.To, []byte(msg))
+	}
+
+	return fmt.Errorf("unsupported channel type '%s'", ch.Type)
+}
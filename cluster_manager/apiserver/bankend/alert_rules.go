@@ -0,0 +1,137 @@
+package bankend
+
+import (
+	"fmt"
+	"regexp"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
+	"github.com/upmio/dbscale-kube/pkg/structs"
+	"github.com/upmio/dbscale-kube/pkg/vars"
+	"github.com/upmio/dbscale-kube/pkg/zone/site"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// 告警规则上的标签，用于按服务汇总告警
+	alertLabelAppID    = "dbscale_app_id"
+	alertLabelAppName  = "dbscale_app_name"
+	alertLabelSeverity = "severity"
+
+	alertAnnotationSummary = "summary"
+)
+
+func alertRuleName(appName string) string {
+	return fmt.Sprintf("%s-alert-rules", appName)
+}
+
+// generatePrometheusRule generates the alerting rules of the app's mysql units,
+// the series are selected by the exporter service created by RegisterMonitor.
+func generatePrometheusRule(appID, appName string, t api.AlertThresholds) monitoringv1.PrometheusRule {
+	svc := fmt.Sprintf(`service="%s-%s-exporter-svc"`, appName, structs.MysqlServiceType)
+	pvc := fmt.Sprintf(`persistentvolumeclaim=~"%s-.+"`, regexp.QuoteMeta(appName))
+
+	rule := func(name, severity, expr, for_, summary string) monitoringv1.Rule {
+		return monitoringv1.Rule{
+			Alert: name,
+			Expr:  intstr.FromString(expr),
+			For:   for_,
+			Labels: map[string]string{
+				alertLabelSeverity: severity,
+				alertLabelAppID:    appID,
+				alertLabelAppName:  appName,
+			},
+			Annotations: map[string]string{
+				alertAnnotationSummary: summary,
+			},
+		}
+	}
+
+	pr := monitoringv1.PrometheusRule{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       monitoringv1.PrometheusRuleKind,
+			APIVersion: "monitoring.coreos.com/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      alertRuleName(appName),
+			Namespace: metav1.NamespaceSystem,
+			Labels: map[string]string{
+				labelRelease:          PrometheusNameSpace,
+				vars.LabelDBScaleKey:  vars.LabelDBScaleValue,
+				v1alpha4.LabelGroup:   appName,
+				labelAppID:            appID,
+				labelServiceName:      appID,
+				labelServiceImageType: structs.MysqlServiceType,
+			},
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{
+				{
+					Name: appName,
+					Rules: []monitoringv1.Rule{
+						rule(api.AlertUnitDown, api.AlertSeverityCritical,
+							fmt.Sprintf(`up{%s} == 0`, svc),
+							fmt.Sprintf("%dm", t.UnitDownMinutes),
+							"unit {{ $labels.pod }} is down"),
+
+						rule(api.AlertReplicationBroken, api.AlertSeverityCritical,
+							fmt.Sprintf(`mysql_slave_status_slave_io_running{%[1]s} == 0 or mysql_slave_status_slave_sql_running{%[1]s} == 0`, svc),
+							"1m",
+							"replication of unit {{ $labels.pod }} is broken"),
+
+						rule(api.AlertReplicationLagging, api.AlertSeverityWarning,
+							fmt.Sprintf(`mysql_slave_status_seconds_behind_master{%s} > %d`, svc, t.ReplicationLagSeconds),
+							"2m",
+							"unit {{ $labels.pod }} is {{ $value }}s behind master"),
+
+						rule(api.AlertConnectionsHigh, api.AlertSeverityWarning,
+							fmt.Sprintf(`mysql_global_status_threads_connected{%[1]s} / mysql_global_variables_max_connections{%[1]s} * 100 > %[2]d`, svc, t.ConnectionsPercent),
+							"5m",
+							"connections of unit {{ $labels.pod }} reach {{ $value | humanize }}% of max_connections"),
+
+						rule(api.AlertDiskUsageHigh, api.AlertSeverityWarning,
+							fmt.Sprintf(`kubelet_volume_stats_used_bytes{%[1]s} / kubelet_volume_stats_capacity_bytes{%[1]s} * 100 > %[2]d`, pvc, t.DiskUsagePercent),
+							"5m",
+							"volume {{ $labels.persistentvolumeclaim }} is {{ $value | humanize }}% used"),
+					},
+				},
+			},
+		},
+	}
+
+	return pr
+}
+
+// applyAlertRules creates the rules of app,or updates them if update is true.
+func applyAlertRules(iface site.Interface, appID, appName string, t api.AlertThresholds, update bool) error {
+	pr := generatePrometheusRule(appID, appName, t)
+
+	existing, err := iface.PrometheusRules().Get(pr.Namespace, pr.Name)
+	if errors.IsNotFound(err) {
+		_, err = iface.PrometheusRules().Create(pr.Namespace, &pr)
+		return err
+	}
+	if err != nil || !update {
+		return err
+	}
+
+	existing = existing.DeepCopy()
+	existing.Labels = pr.Labels
+	existing.Spec = pr.Spec
+
+	_, err = iface.PrometheusRules().Update(existing.Namespace, existing)
+
+	return err
+}
+
+func deleteAlertRules(iface site.Interface, appName string) error {
+	err := iface.PrometheusRules().Delete(metav1.NamespaceSystem, alertRuleName(appName))
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,123 @@
+package bankend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+type alertStrategies []model.BackupStrategy
+
+func (s alertStrategies) Lock(id string) (model.BackupStrategy, error) {
+	return s.GetStrategy(id)
+}
+
+func (s alertStrategies) GetStrategy(id string) (model.BackupStrategy, error) {
+	for i := range s {
+		if s[i].ID == id {
+			return s[i], nil
+		}
+	}
+
+	return model.BackupStrategy{}, model.NewNotFound("backup strategy", id)
+}
+
+func (s alertStrategies) ListStrategy(map[string]string) ([]model.BackupStrategy, error) {
+	return s, nil
+}
+
+type alertFiles map[string][]model.BackupFile
+
+func (f alertFiles) GetFile(id string) (model.BackupFile, error) {
+	return model.BackupFile{}, model.NewNotFound("backup file", id)
+}
+
+func (f alertFiles) ListFiles(selector map[string]string) ([]model.BackupFile, error) {
+	return f[selector["strategy_id"]], nil
+}
+
+func TestBackupAlerts(t *testing.T) {
+	now := time.Now()
+	thresholds := api.DefaultAlertThresholds()
+
+	b := &bankendAlert{
+		strategies: alertStrategies{
+			{ID: "failed", Enabled: true, Schedule: "@every 1h", Editor: model.Editor{CreatedAt: now.Add(-time.Hour)}},
+			{ID: "missed", Enabled: true, Schedule: "@every 1h", Editor: model.Editor{CreatedAt: now.Add(-24 * time.Hour)}},
+			{ID: "ok", Enabled: true, Schedule: "@every 1h", Editor: model.Editor{CreatedAt: now.Add(-24 * time.Hour)}},
+			{ID: "disabled", Enabled: false, Schedule: "@every 1h", Editor: model.Editor{CreatedAt: now.Add(-24 * time.Hour)}},
+		},
+		files: alertFiles{
+			"failed": {
+				{Status: model.BackupFileComplete, CreatedAt: now.Add(-70 * time.Minute), FinishedAt: now.Add(-65 * time.Minute)},
+				{Status: model.BackupFileFailed, CreatedAt: now.Add(-10 * time.Minute), FinishedAt: now.Add(-5 * time.Minute)},
+			},
+			"missed": {
+				{Status: model.BackupFileComplete, CreatedAt: now.Add(-5 * time.Hour), FinishedAt: now.Add(-5 * time.Hour)},
+			},
+			"ok": {
+				{Status: model.BackupFileComplete, CreatedAt: now.Add(-30 * time.Minute), FinishedAt: now.Add(-20 * time.Minute)},
+			},
+		},
+	}
+
+	alerts, err := b.backupAlerts("app", thresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int)
+	for _, a := range alerts {
+		got[a.Name]++
+	}
+
+	if len(alerts) != 2 || got[api.AlertBackupFailed] != 1 || got[api.AlertBackupMissed] != 1 {
+		t.Errorf("unexpected backup alerts:%+v", alerts)
+	}
+}
+
+func TestChannelMatch(t *testing.T) {
+	n := alertNotification{
+		Status: api.AlertStateFiring,
+		App:    api.IDName{ID: "id", Name: "name"},
+		Alert:  api.Alert{Severity: api.AlertSeverityWarning},
+	}
+
+	cases := []struct {
+		ch   api.NotificationChannel
+		want bool
+	}{
+		{api.NotificationChannel{Enabled: true}, true},
+		{api.NotificationChannel{Enabled: false}, false},
+		{api.NotificationChannel{Enabled: true, ChannelConfig: api.ChannelConfig{Apps: []string{"name"}}}, true},
+		{api.NotificationChannel{Enabled: true, ChannelConfig: api.ChannelConfig{Apps: []string{"other"}}}, false},
+		{api.NotificationChannel{Enabled: true, ChannelConfig: api.ChannelConfig{Severities: []string{api.AlertSeverityCritical}}}, false},
+	}
+
+	for i, c := range cases {
+		if got := channelMatch(c.ch, n); got != c.want {
+			t.Errorf("case %d:expected %t but got %t", i, c.want, got)
+		}
+	}
+}
+
+func TestAppSites(t *testing.T) {
+	app := model.Application{
+		Units: []model.Unit{
+			{ID: "u1", Site: "site2"},
+			{ID: "u2", Site: "site2"},
+			{ID: "u3", Site: "site3"},
+			{ID: "u4"},
+		},
+	}
+
+	if sites := appSites(app); len(sites) != 2 || sites[0] != "site2" || sites[1] != "site3" {
+		t.Errorf("expect the sites of units,got %v", sites)
+	}
+
+	if sites := appSites(model.Application{}); len(sites) != 0 {
+		t.Errorf("expect no site,got %v", sites)
+	}
+}
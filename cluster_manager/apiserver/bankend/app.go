@@ -15,6 +15,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	"github.com/upmio/dbscale-kube/pkg/audit"
 	"github.com/upmio/dbscale-kube/pkg/structs"
 	"github.com/upmio/dbscale-kube/pkg/vars"
 
@@ -54,7 +55,10 @@ func NewAppBankend(zone zone.ZoneInterface,
 	files backupFileGetter,
 	endpoints endpointGetter,
 	storages storageGetter,
-	pools poolGetter) *bankendApp {
+	pools poolGetter,
+	subscriptions subscriptionGetter,
+	credentials modelDBUserCredential,
+	passwordPolicy api.PasswordPolicy) *bankendApp {
 	return &bankendApp{
 		m:      m,
 		images: images,
@@ -69,6 +73,11 @@ func NewAppBankend(zone zone.ZoneInterface,
 		pools:     pools,
 		files:     files,
 		endpoints: endpoints,
+
+		subscriptions: subscriptions,
+
+		credentials:    credentials,
+		passwordPolicy: passwordPolicy,
 	}
 }
 
@@ -84,6 +93,13 @@ type bankendApp struct {
 	files     backupFileGetter
 	endpoints endpointGetter
 
+	subscriptions subscriptionGetter
+
+	credentials    modelDBUserCredential
+	passwordPolicy api.PasswordPolicy
+	// 发布密码过期及轮换事件，RunPasswordRotation 设置
+	events eventPublisher
+
 	zone zoneIface
 
 	waits *waitTasks
@@ -101,6 +117,7 @@ type modelApp interface {
 	UpdateStatus(app, newStatus, targetService, user string) error
 	UpdateAppTask(app *model.Application, tk model.Task) error
 	Delete(name string) error
+	ListPage(opts api.ListOptions) ([]model.Application, api.ListMeta, error)
 }
 
 type appGetter interface {
@@ -156,7 +173,7 @@ func (beApp *bankendApp) AddApp(ctx context.Context, config api.AppConfig, subsc
 		return api.Application{}, err
 	}
 
-	err = beApp.preResourceCheck(ctx, config)
+	err = beApp.preResourceCheck(ctx, config, subscriptionId)
 	if err != nil {
 		return api.Application{}, err
 	}
@@ -336,6 +353,21 @@ func (beApp *bankendApp) prepareAppConfig(ctx context.Context, config *api.AppCo
 	validateGroupSpec := func(name string, spec *api.GroupSpec) error {
 		var im model.Image
 		var err error
+		// 多架构服务使用镜像集合，按单元调度的主机架构选择镜像
+		if config.Arch == api.ArchMulti {
+			im, err = resolveImageSet(beApp.images, name, spec.Image.ID)
+			if err != nil {
+				return stderror.Errorf("%s image set cannot be found, casused by %s", name, err)
+			}
+
+			if err := checkImageLifecycle(im); err != nil {
+				return err
+			}
+
+			spec.Image = api.ImageVersion(im.ImageVersion)
+			return nil
+		}
+
 		// check arch is same with image.ID's arch
 		if strings.Contains(spec.Image.ID, "-") {
 			parts := strings.Split(spec.Image.ID, "-")
@@ -352,6 +384,10 @@ func (beApp *bankendApp) prepareAppConfig(ctx context.Context, config *api.AppCo
 			return stderror.Errorf("%s image cannot be found, casused by %s", name, err)
 		}
 
+		if err := checkImageLifecycle(im); err != nil {
+			return err
+		}
+
 		//image = im
 		spec.Image = api.ImageVersion(im.ImageVersion)
 		return nil
@@ -510,7 +546,7 @@ func mergeStorageValue(storage *api.StorageRequirement, requestLevel string) int
 }
 
 //roughly check if resource is enough to build the app
-func (beApp *bankendApp) preResourceCheck(ctx context.Context, config api.AppConfig) error {
+func (beApp *bankendApp) preResourceCheck(ctx context.Context, config api.AppConfig, subscriptionId string) error {
 	var (
 		resRecordHost []resRecord
 		cpuTotal      int64
@@ -519,6 +555,11 @@ func (beApp *bankendApp) preResourceCheck(ctx context.Context, config api.AppCon
 		vgHighTotal   int64
 	)
 
+	err := checkSubscriptionQuota(beApp.subscriptions, beApp.m, beApp.files, subscriptionId, "", config.Spec)
+	if err != nil {
+		return err
+	}
+
 	hostIface, err := beApp.zone.zone.HostInterface(beApp.GetSiteStr())
 	if err != nil {
 		return err
@@ -576,18 +617,24 @@ func (beApp *bankendApp) preResourceCheck(ctx context.Context, config api.AppCon
 			}
 		}
 
+		// sriov 等网络模式下 unit 数量受网卡(upm.host.maxunit)限制
+		podAllocatable := host.Status.Allocatable.Pods.Value()
+		if !host.Status.Capacity.Units.IsZero() && host.Status.Allocatable.Units.Value() < podAllocatable {
+			podAllocatable = host.Status.Allocatable.Units.Value()
+		}
+
 		resRecordHost = append(resRecordHost, resRecord{
 			host.Name,
 			host.Status.Allocatable.Cpu.MilliValue(),
 			host.Status.Allocatable.Memery.Value() >> 20,
 			vgMediumAllocatable,
 			vgHighAllocatable,
-			host.Status.Allocatable.Pods.Value(),
+			podAllocatable,
 			host.Status.Allocatable.Cpu.MilliValue(),
 			host.Status.Allocatable.Memery.Value() >> 20,
 			vgMediumFree,
 			vgHighFree,
-			host.Status.Allocatable.Pods.Value(),
+			podAllocatable,
 			[]string{},
 		})
 		cpuTotal += host.Status.Allocatable.Cpu.MilliValue()
@@ -693,11 +740,23 @@ func (beApp *bankendApp) deploySpec(ctx context.Context, resChan chan mapBool,
 			return err
 		}
 
+		// 固定模板版本的服务，新建单元使用相同版本的模板
+		if spec.TemplateRevision > 0 {
+			tmpl.Spec.MainImageVerison = templateRevisionVersion(image, spec.TemplateRevision)
+		}
+
 		err = beApp.injectSchedulerInfo(&tmpl, arch, replicas, spec.Services.Conditions, image, spec.Services.Units.Resources.Requests.Storage)
 		if err != nil {
 			return err
 		}
 
+		if arch == api.ArchMulti {
+			ctrl.variants, err = beApp.planUnitVariants(tmpl, image, replicas, spec.Services.Units.Resources.Requests, spec.Services.Conditions.Host.HighAvailability, site)
+			if err != nil {
+				return err
+			}
+		}
+
 		err = ctrl.DeployService(appName, groupName, groupType, replicas, tmpl)
 		if err != nil {
 			klog.Errorf("deploy %s error:%s", groupName, err)
@@ -1183,6 +1242,36 @@ func (beApp *bankendApp) ListAppsWithPagination(ctx context.Context, id, name, s
 	return paginationRes, nil
 }
 
+// ListAppsPage lists a page of apps,the order of the page is kept.
+func (beApp *bankendApp) ListAppsPage(ctx context.Context, detail bool, opts api.ListOptions) (api.ListResponse, error) {
+	list, meta, err := beApp.m.ListPage(opts)
+	if err != nil {
+		return api.ListResponse{}, err
+	}
+
+	out := make([]api.Application, len(list))
+	wg := sync.WaitGroup{}
+
+	for i := range list {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			units, err := beApp.listAppUnits(list[i].ID, list[i].Units, detail)
+			if err != nil {
+				klog.Errorf("App %s listAppUnits,%s", list[i].Name, err)
+			}
+
+			out[i] = convertToAppAPI(list[i], units)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return api.NewListResponse(out, meta, opts.Fields)
+}
+
 func convertToAppAPI(ma model.Application, units []api.UnitInfo) api.Application {
 	spec := api.AppSpec{}
 	json.Unmarshal([]byte(ma.Spec), &spec)
@@ -1878,6 +1967,8 @@ func (beApp *bankendApp) UpdateConfig(ctx context.Context, appID string, opts ap
 			return err
 		}
 
+		before := api.ConfigMapOptions{Key: opts.Key, Value: configer.String(opts.Key)}
+
 		err = configer.Set(opts.Key, opts.Value)
 		if err != nil {
 			return fmt.Errorf("update key: %s err: %s", opts.Key, err)
@@ -1910,6 +2001,8 @@ func (beApp *bankendApp) UpdateConfig(ctx context.Context, appID string, opts ap
 		if err != nil {
 			return fmt.Errorf("Run script in unit: %s err: %s", unit.Name, err)
 		}
+
+		audit.Record(ctx, api.AuditAppConfigUpdate, "unit_config", unit.Name, before, opts)
 	}
 
 	return nil
@@ -0,0 +1,791 @@
+package bankend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"golang.org/x/xerrors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	ActionAppManifestApply = "app-manifest-apply"
+
+	manifestTaskInterval = 5 * time.Second
+	manifestTaskTimeout  = 30 * time.Minute
+
+	redactedPassword = "******"
+)
+
+// manifestApps 由bankendApp实现，manifest 的每一步调用其中一个已有操作
+type manifestApps interface {
+	AddApp(ctx context.Context, config api.AppConfig, subscriptionId string) (api.Application, error)
+	ListApps(ctx context.Context, id, name, subscriptionId string, detail bool) (api.AppsResponse, error)
+
+	UpdateArch(ctx context.Context, app string, opts api.AppArchOptions) (api.TaskObjectResponse, error)
+	UpdateState(ctx context.Context, app string, opts api.AppStateOptions) (api.TaskObjectResponse, error)
+	UpdateImage(ctx context.Context, app string, opts api.AppImageOptions) (api.TaskObjectResponse, error)
+	UpdateAppResourceRequests(ctx context.Context, app string, opts api.AppResourcesOptions) (api.TaskObjectResponse, error)
+
+	ListConfig(ctx context.Context, app string) (api.ConfigMapResponse, error)
+	UpdateConfig(ctx context.Context, app string, config api.ConfigMapOptions) error
+
+	AddAppDBUser(ctx context.Context, app string, config api.AppUserConfig) (api.TaskObjectResponse, error)
+	ListAppDBUsers(ctx context.Context, app string) (api.AppUsersResponse, error)
+	DeleteAppDBUser(ctx context.Context, app, user, ip string) error
+	UpdateUserPrivileges(ctx context.Context, appID string, opts api.AppUserPrivilegesOptions) error
+
+	ListAppDBSchema(ctx context.Context, app string) (api.DBSchemaResponse, error)
+	AddAppDBSchema(ctx context.Context, app string, config api.AppSchemaConfig) (api.TaskObjectResponse, error)
+	DeleteAppDBSchema(ctx context.Context, app, schema string) error
+}
+
+// manifestStrategies 由bankendBackup实现
+type manifestStrategies interface {
+	AddBackupStrategy(ctx context.Context, config api.BackupStrategyConfig) (api.ObjectResponse, error)
+	SetBackupStrategy(ctx context.Context, id string, opts api.BackupStrategyOptions) error
+	ListBackupStrategy(ctx context.Context, id, unit, app string) (api.BackupStrategyResponse, error)
+	DeleteBackupStrategy(ctx context.Context, id, app string) error
+}
+
+type manifestTasks interface {
+	Insert(model.Task) (string, error)
+	Update(model.Task) error
+	Get(id string) (model.Task, error)
+}
+
+func NewManifestBankend(apps manifestApps, strategies manifestStrategies, tasks manifestTasks, revisions revisionBumper) *bankendManifest {
+	return &bankendManifest{
+		apps:       apps,
+		strategies: strategies,
+		tasks:      tasks,
+		revisions:  revisions,
+		interval:   manifestTaskInterval,
+	}
+}
+
+type bankendManifest struct {
+	apps       manifestApps
+	strategies manifestStrategies
+	tasks      manifestTasks
+	revisions  revisionBumper
+
+	interval time.Duration
+}
+
+// manifestStep 计划中的一步，app 为执行时的服务id，创建服务后才能确定，
+// 返回已有操作的任务id，为空表示操作已同步完成
+type manifestStep struct {
+	api.ManifestStep
+
+	run func(ctx context.Context, app *string) (string, error)
+}
+
+type manifestPlan struct {
+	app   api.IDName
+	steps []manifestStep
+}
+
+func (p *manifestPlan) add(action, target, reason string, body interface{},
+	run func(ctx context.Context, app *string) (string, error)) {
+
+	p.steps = append(p.steps, manifestStep{
+		ManifestStep: api.ManifestStep{
+			Action: action,
+			Target: target,
+			Reason: reason,
+			Body:   body,
+		},
+		run: run,
+	})
+}
+
+func (p manifestPlan) convert() api.AppManifestPlan {
+	out := api.AppManifestPlan{
+		App:   p.app,
+		Steps: make([]api.ManifestStep, len(p.steps)),
+	}
+
+	for i := range p.steps {
+		out.Steps[i] = p.steps[i].ManifestStep
+	}
+
+	return out
+}
+
+func (b *bankendManifest) Plan(ctx context.Context, manifest api.AppManifest, subscriptionId string) (api.AppManifestPlan, error) {
+	plan, err := b.plan(ctx, manifest, subscriptionId)
+	if err != nil {
+		return api.AppManifestPlan{}, err
+	}
+
+	return plan.convert(), nil
+}
+
+// Apply 生成执行计划并在一个任务中依次执行，前一步的任务结束后才执行下一步，
+// 任一步失败则任务失败，后续步骤不再执行。
+func (b *bankendManifest) Apply(ctx context.Context, manifest api.AppManifest, subscriptionId string) (api.AppManifestPlan, error) {
+	plan, err := b.plan(ctx, manifest, subscriptionId)
+	if err != nil {
+		return api.AppManifestPlan{}, err
+	}
+
+	out := plan.convert()
+
+	if len(plan.steps) == 0 {
+		return out, nil
+	}
+
+	relate := plan.app.ID
+	if relate == "" {
+		relate = plan.app.Name
+	}
+
+	tk := model.NewTask(ActionAppManifestApply, relate, model.Application{}.Table(), manifest.User)
+	tk.ID, err = b.tasks.Insert(tk)
+	if err != nil {
+		return api.AppManifestPlan{}, err
+	}
+
+	out.Task = api.TaskBrief{
+		ID:     tk.ID,
+		Status: model.TaskRunning.State(),
+		Action: ActionAppManifestApply,
+		User:   manifest.User,
+	}
+
+	go func() {
+		err := b.apply(context.Background(), plan)
+		if err != nil {
+			klog.Errorf("apply manifest of app %s:%s", plan.app.Name, err)
+		}
+
+		if _err := b.tasks.Update(taskUpdate(tk.ID, err)); _err != nil {
+			klog.Errorf("update manifest task %s:%s", tk.ID, _err)
+		}
+	}()
+
+	return out, nil
+}
+
+func (b *bankendManifest) apply(ctx context.Context, plan manifestPlan) error {
+	app := plan.app.ID
+
+	for i, step := range plan.steps {
+		task, err := step.run(ctx, &app)
+		if err == nil && task != "" {
+			err = b.waitTask(ctx, task)
+		}
+
+		// 站点 operator 同步的 manifest 也由此执行，不经过服务的修改路由
+		if task != "" || err == nil {
+			b.bumpRevision(app)
+		}
+
+		if err != nil {
+			return fmt.Errorf("step %d %s %s:%s", i+1, step.Action, step.Target, err)
+		}
+	}
+
+	return nil
+}
+
+func (b *bankendManifest) bumpRevision(app string) {
+	if b.revisions != nil {
+		b.revisions.bump(revisionKindApp, app)
+	}
+}
+
+func (b *bankendManifest) waitTask(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, manifestTaskTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		tk, err := b.tasks.Get(id)
+		if err != nil {
+			return err
+		}
+
+		switch tk.Status {
+		case model.TaskRunning:
+		case model.TaskSuccess:
+			return nil
+		default:
+			return fmt.Errorf("task %s %s:%s", id, tk.Status.State(), tk.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait task %s:%s", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *bankendManifest) plan(ctx context.Context, manifest api.AppManifest, subscriptionId string) (manifestPlan, error) {
+	apps, err := b.apps.ListApps(ctx, "", manifest.Name, subscriptionId, false)
+	if err != nil {
+		return manifestPlan{}, err
+	}
+
+	switch len(apps) {
+	case 0:
+		return b.planCreate(manifest, subscriptionId), nil
+	case 1:
+	default:
+		return manifestPlan{}, xerrors.Errorf("%d apps named %s", len(apps), manifest.Name)
+	}
+
+	app := apps[0]
+	plan := manifestPlan{app: api.NewIDName(app.ID, app.Name)}
+
+	b.planState(&plan, app.Spec, manifest, false)
+
+	err = b.planSpec(&plan, app.Spec, manifest)
+	if err != nil {
+		return plan, err
+	}
+
+	current, err := b.apps.ListConfig(ctx, app.ID)
+	if err != nil {
+		return plan, err
+	}
+
+	b.planConfig(&plan, current, manifest.Config)
+
+	schemas, err := b.apps.ListAppDBSchema(ctx, app.ID)
+	if err != nil {
+		return plan, err
+	}
+
+	users, err := b.apps.ListAppDBUsers(ctx, app.ID)
+	if err != nil {
+		return plan, err
+	}
+
+	strategies, err := b.strategies.ListBackupStrategy(ctx, "", "", app.ID)
+	if err != nil {
+		return plan, err
+	}
+
+	b.planSchemas(&plan, schemas, manifest.Schemas, false)
+	b.planUsers(&plan, users, manifest.Users, false)
+	b.planStrategies(&plan, strategies, manifest, false)
+
+	if manifest.Prune {
+		b.planStrategies(&plan, strategies, manifest, true)
+		b.planUsers(&plan, users, manifest.Users, true)
+		b.planSchemas(&plan, schemas, manifest.Schemas, true)
+	}
+
+	b.planState(&plan, app.Spec, manifest, true)
+
+	return plan, nil
+}
+
+func (b *bankendManifest) planCreate(manifest api.AppManifest, subscriptionId string) manifestPlan {
+	plan := manifestPlan{app: api.NewIDName("", manifest.Name)}
+
+	config := api.AppConfig{
+		Name: manifest.Name,
+		Desc: manifest.Desc,
+		User: manifest.User,
+		Arch: manifest.Arch,
+		Spec: manifest.Spec,
+	}
+
+	plan.add(api.ManifestAppCreate, manifest.Name, "app not found", config,
+		func(ctx context.Context, app *string) (string, error) {
+			created, err := b.apps.AddApp(ctx, config, subscriptionId)
+			if err != nil {
+				return "", err
+			}
+
+			*app = created.ID
+
+			return created.Task.ID, nil
+		})
+
+	b.planConfig(&plan, nil, manifest.Config)
+	b.planSchemas(&plan, nil, manifest.Schemas, false)
+	b.planUsers(&plan, nil, manifest.Users, false)
+	b.planStrategies(&plan, nil, manifest, false)
+
+	return plan
+}
+
+type manifestGroup struct {
+	name          string
+	current, want *api.GroupSpec
+}
+
+// planSpec 比较服务规格，只支持已有操作能修改的部分：架构、镜像和资源
+func (b *bankendManifest) planSpec(plan *manifestPlan, spec api.AppSpec, manifest api.AppManifest) error {
+	groups := []manifestGroup{
+		{"database", spec.Database, manifest.Spec.Database},
+		{"cmha", spec.Cmha, manifest.Spec.Cmha},
+		{"proxy", spec.Proxy, manifest.Spec.Proxy},
+	}
+
+	for _, g := range groups {
+		if (g.current == nil) != (g.want == nil) {
+			return xerrors.Errorf("cannot add or remove %s by manifest", g.name)
+		}
+	}
+
+	if spec.Database != nil && spec.Database.Services.Arch != manifest.Spec.Database.Services.Arch {
+		arch := manifest.Spec.Database.Services.Arch
+
+		opts := api.AppArchOptions{}
+		opts.Spec.Database = &struct {
+			Arch *api.Arch `json:"arch,omitempty"`
+		}{Arch: &arch}
+
+		plan.add(api.ManifestAppArch, "database",
+			fmt.Sprintf("%s/%d -> %s/%d", spec.Database.Services.Arch.Mode, spec.Database.Services.Arch.Replicas, arch.Mode, arch.Replicas),
+			opts,
+			func(ctx context.Context, app *string) (string, error) {
+				resp, err := b.apps.UpdateArch(ctx, *app, opts)
+				return resp.TaskID, err
+			})
+	}
+
+	images := api.AppImageOptions{}
+	changed := []string{}
+
+	for _, g := range groups {
+		if g.want == nil || sameImage(g.current.Image, g.want.Image) {
+			continue
+		}
+
+		im := g.want.Image
+		if im.Arch == "" {
+			im.Arch = g.current.Image.Arch
+		}
+		if im.ID == "" {
+			im.ID = fmt.Sprintf("%s-%s", im.String(), im.Arch)
+		}
+
+		group := &struct {
+			Image *api.ImageVersion `json:"image,omitempty"`
+		}{Image: &im}
+
+		switch g.name {
+		case "database":
+			images.Spec.Database = group
+		case "cmha":
+			images.Spec.Cmha = group
+		case "proxy":
+			images.Spec.Proxy = group
+		}
+
+		changed = append(changed, fmt.Sprintf("%s %s -> %s", g.name, g.current.Image.String(), im.String()))
+	}
+
+	if len(changed) > 0 {
+		plan.add(api.ManifestAppImage, strings.Join(groupNames(changed), ","), strings.Join(changed, ","), images,
+			func(ctx context.Context, app *string) (string, error) {
+				resp, err := b.apps.UpdateImage(ctx, *app, images)
+				return resp.TaskID, err
+			})
+	}
+
+	requests := make(map[string]interface{})
+	changed = changed[:0]
+
+	for _, g := range groups {
+		if g.want == nil {
+			continue
+		}
+
+		req, fields := resourceOptions(g.current.Services.Units.Resources.Requests, g.want.Services.Units.Resources.Requests)
+		if len(fields) == 0 {
+			continue
+		}
+
+		requests[g.name] = map[string]interface{}{
+			"services": map[string]interface{}{
+				"units": map[string]interface{}{
+					"resources": map[string]interface{}{
+						"requests": req,
+					},
+				},
+			},
+		}
+
+		changed = append(changed, g.name+" "+strings.Join(fields, ","))
+	}
+
+	if len(changed) > 0 {
+		opts := api.AppResourcesOptions{}
+
+		err := convertByJSON(map[string]interface{}{"spec": requests}, &opts)
+		if err != nil {
+			return err
+		}
+
+		plan.add(api.ManifestAppResources, strings.Join(groupNames(changed), ","), strings.Join(changed, ";")+" changed", opts,
+			func(ctx context.Context, app *string) (string, error) {
+				resp, err := b.apps.UpdateAppResourceRequests(ctx, *app, opts)
+				return resp.TaskID, err
+			})
+	}
+
+	return nil
+}
+
+// planState 服务启动在其他操作之前，停止在最后
+func (b *bankendManifest) planState(plan *manifestPlan, spec api.AppSpec, manifest api.AppManifest, stop bool) {
+	if manifest.Spec.Database == nil || spec.Database == nil {
+		return
+	}
+
+	want := manifest.Spec.Database.Services.Units.ReadinessState
+	current := spec.Database.Services.Units.ReadinessState
+
+	if want == "" || want == current || stop == (want == api.StatePassing) {
+		return
+	}
+
+	opts := api.AppStateOptions{
+		State: want,
+		User:  manifest.User,
+	}
+
+	plan.add(api.ManifestAppState, plan.app.Name, fmt.Sprintf("%s -> %s", current, want), opts,
+		func(ctx context.Context, app *string) (string, error) {
+			resp, err := b.apps.UpdateState(ctx, *app, opts)
+			return resp.TaskID, err
+		})
+}
+
+func sameImage(current, want api.ImageVersion) bool {
+	if want.ID != "" && current.ID != "" {
+		return want.ID == current.ID
+	}
+
+	return current.String() == want.String() &&
+		(want.Arch == "" || want.Arch == current.Arch)
+}
+
+// resourceOptions 返回资源需求中变化的部分
+func resourceOptions(current, want api.ResourceRequirements) (api.ResourceRequirementsOptions, []string) {
+	opts := api.ResourceRequirementsOptions{}
+	changed := []string{}
+
+	if current.CPU != want.CPU {
+		opts.CPU = &want.CPU
+		changed = append(changed, "cpu")
+	}
+
+	if current.Memory != want.Memory {
+		opts.Memory = &want.Memory
+		changed = append(changed, "memory")
+	}
+
+	if want.Bandwidth != nil && (current.Bandwidth == nil || *current.Bandwidth != *want.Bandwidth) {
+		opts.Bandwidth = want.Bandwidth
+		changed = append(changed, "net_bandwidth")
+	}
+
+	if want.Storage != nil && !reflect.DeepEqual(current.Storage, want.Storage) {
+		opts.Storage = want.Storage
+		changed = append(changed, "storage")
+	}
+
+	return opts, changed
+}
+
+func groupNames(changed []string) []string {
+	out := make([]string, len(changed))
+	for i := range changed {
+		out[i] = strings.SplitN(changed[i], " ", 2)[0]
+	}
+
+	return out
+}
+
+func convertByJSON(in, out interface{}) error {
+	data, err := encodeJson(in)
+	if err != nil {
+		return err
+	}
+
+	return decodeJson(bytes.NewReader(data), out)
+}
+
+func (b *bankendManifest) planConfig(plan *manifestPlan, current api.ConfigMapResponse, want []api.ConfigMapOptions) {
+	values := make(map[string]string, len(current))
+	for _, kv := range current {
+		values[kv.Key] = kv.Value
+	}
+
+	for i := range want {
+		opts := want[i]
+
+		value, ok := values[opts.Key]
+		if ok && value == opts.Value {
+			continue
+		}
+
+		reason := fmt.Sprintf("%s -> %s", value, opts.Value)
+		if current == nil {
+			reason = "set " + opts.Value
+		}
+
+		plan.add(api.ManifestAppConfig, opts.Key, reason, opts,
+			func(ctx context.Context, app *string) (string, error) {
+				return "", b.apps.UpdateConfig(ctx, *app, opts)
+			})
+	}
+}
+
+func (b *bankendManifest) planSchemas(plan *manifestPlan, current api.DBSchemaResponse, want []api.AppSchemaConfig, prune bool) {
+	exist := make(map[string]bool, len(current))
+	for _, s := range current {
+		exist[s.Name] = true
+	}
+
+	if prune {
+		wanted := make(map[string]bool, len(want))
+		for _, s := range want {
+			wanted[s.Name] = true
+		}
+
+		for _, s := range current {
+			if wanted[s.Name] {
+				continue
+			}
+
+			name := s.Name
+			plan.add(api.ManifestSchemaDelete, name, "not in manifest", nil,
+				func(ctx context.Context, app *string) (string, error) {
+					return "", b.apps.DeleteAppDBSchema(ctx, *app, name)
+				})
+		}
+
+		return
+	}
+
+	for i := range want {
+		config := want[i]
+		if exist[config.Name] {
+			continue
+		}
+
+		plan.add(api.ManifestSchemaCreate, config.Name, "schema not found", config,
+			func(ctx context.Context, app *string) (string, error) {
+				resp, err := b.apps.AddAppDBSchema(ctx, *app, config)
+				return resp.TaskID, err
+			})
+	}
+}
+
+func userKey(name string, ip api.IP) string {
+	return name + "@" + string(ip)
+}
+
+func (b *bankendManifest) planUsers(plan *manifestPlan, current api.AppUsersResponse, want []api.AppUserConfig, prune bool) {
+	exist := make(map[string]api.DatabaseUser, len(current))
+	for _, u := range current {
+		exist[userKey(u.Name, u.IP)] = u
+	}
+
+	if prune {
+		wanted := make(map[string]bool, len(want))
+		for _, u := range want {
+			wanted[userKey(u.Name, u.IP)] = true
+		}
+
+		for _, u := range current {
+			if wanted[userKey(u.Name, u.IP)] {
+				continue
+			}
+
+			name, ip := u.Name, string(u.IP)
+			plan.add(api.ManifestUserDelete, userKey(u.Name, u.IP), "not in manifest", nil,
+				func(ctx context.Context, app *string) (string, error) {
+					return "", b.apps.DeleteAppDBUser(ctx, *app, name, ip)
+				})
+		}
+
+		return
+	}
+
+	for i := range want {
+		config := want[i]
+		key := userKey(config.Name, config.IP)
+
+		u, ok := exist[key]
+		if !ok {
+			plan.add(api.ManifestUserCreate, key, "user not found", redactUser(config),
+				func(ctx context.Context, app *string) (string, error) {
+					resp, err := b.apps.AddAppDBUser(ctx, *app, config)
+					return resp.TaskID, err
+				})
+
+			continue
+		}
+
+		if config.Privileges == nil || samePrivileges(u.Privileges, config.Privileges) {
+			continue
+		}
+
+		opts := api.AppUserPrivilegesOptions{
+			Name:       config.Name,
+			IP:         config.IP,
+			Privileges: config.Privileges,
+		}
+
+		plan.add(api.ManifestUserPrivileges, key, "privileges changed", opts,
+			func(ctx context.Context, app *string) (string, error) {
+				return "", b.apps.UpdateUserPrivileges(ctx, *app, opts)
+			})
+	}
+}
+
+func redactUser(config api.AppUserConfig) api.AppUserConfig {
+	if config.Password != "" {
+		config.Password = redactedPassword
+	}
+
+	if config.Login != nil {
+		login := *config.Login
+		if login.Password != "" {
+			login.Password = redactedPassword
+		}
+		config.Login = &login
+	}
+
+	return config
+}
+
+func privilegesMap(list []api.DatabasePrivilege) map[string]string {
+	out := make(map[string]string, len(list))
+
+	for _, p := range list {
+		privileges := make([]string, len(p.Privileges))
+		for i := range p.Privileges {
+			privileges[i] = strings.ToUpper(strings.TrimSpace(p.Privileges[i]))
+		}
+
+		sort.Strings(privileges)
+		out[p.DBName] = strings.Join(privileges, ",")
+	}
+
+	return out
+}
+
+func samePrivileges(a, b []api.DatabasePrivilege) bool {
+	return reflect.DeepEqual(privilegesMap(a), privilegesMap(b))
+}
+
+func (b *bankendManifest) planStrategies(plan *manifestPlan, current api.BackupStrategyResponse, manifest api.AppManifest, prune bool) {
+	exist := make(map[string]api.BackupStrategy, len(current))
+	for _, s := range current {
+		exist[s.Name] = s
+	}
+
+	if prune {
+		wanted := make(map[string]bool, len(manifest.BackupStrategies))
+		for _, s := range manifest.BackupStrategies {
+			wanted[s.Name] = true
+		}
+
+		for _, s := range current {
+			if wanted[s.Name] {
+				continue
+			}
+
+			id := s.ID
+			plan.add(api.ManifestStrategyDelete, s.Name, "not in manifest", nil,
+				func(ctx context.Context, app *string) (string, error) {
+					return "", b.strategies.DeleteBackupStrategy(ctx, id, "")
+				})
+		}
+
+		return
+	}
+
+	for i := range manifest.BackupStrategies {
+		config := manifest.BackupStrategies[i]
+		if config.User == "" {
+			config.User = manifest.User
+		}
+		config.App = plan.app.ID
+
+		s, ok := exist[config.Name]
+		if !ok {
+			plan.add(api.ManifestStrategyCreate, config.Name, "backup strategy not found", config,
+				func(ctx context.Context, app *string) (string, error) {
+					config.App = *app
+					_, err := b.strategies.AddBackupStrategy(ctx, config)
+					return "", err
+				})
+
+			continue
+		}
+
+		opts, changed := strategyOptions(s, config)
+		if len(changed) == 0 {
+			continue
+		}
+
+		opts.User = manifest.User
+		id := s.ID
+
+		plan.add(api.ManifestStrategyUpdate, config.Name, strings.Join(changed, ",")+" changed", opts,
+			func(ctx context.Context, app *string) (string, error) {
+				return "", b.strategies.SetBackupStrategy(ctx, id, opts)
+			})
+	}
+}
+
+// strategyOptions 返回更新备份策略的参数与变化的字段
+func strategyOptions(s api.BackupStrategy, config api.BackupStrategyConfig) (api.BackupStrategyOptions, []string) {
+	opts := api.BackupStrategyOptions{}
+	changed := []string{}
+
+	if s.Enabled != config.Enabled {
+		opts.Enabled = &config.Enabled
+		changed = append(changed, "enabled")
+	}
+
+	if s.Retention != config.Retention {
+		opts.Retention = &config.Retention
+		changed = append(changed, "retention")
+	}
+
+	if s.Schedule != config.Schedule {
+		opts.Schedule = &config.Schedule
+		changed = append(changed, "schedule")
+	}
+
+	if config.Unit != "" && s.Unit != config.Unit {
+		opts.Unit = &config.Unit
+		changed = append(changed, "unit_id")
+	}
+
+	if s.Type != config.BackupType {
+		typ := string(config.BackupType)
+		opts.BackupType = &typ
+		changed = append(changed, "type")
+	}
+
+	if s.Desc != config.Desc {
+		opts.Desc = &config.Desc
+		changed = append(changed, "desc")
+	}
+
+	return opts, changed
+}
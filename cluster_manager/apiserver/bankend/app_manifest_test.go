@@ -0,0 +1,293 @@
+package bankend
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+type fakeManifestApps struct {
+	lock  sync.Mutex
+	calls []string
+
+	apps    api.AppsResponse
+	config  api.ConfigMapResponse
+	users   api.AppUsersResponse
+	schemas api.DBSchemaResponse
+}
+
+func (f *fakeManifestApps) call(name string) {
+	f.lock.Lock()
+	f.calls = append(f.calls, name)
+	f.lock.Unlock()
+}
+
+func (f *fakeManifestApps) AddApp(ctx context.Context, config api.AppConfig, subscriptionId string) (api.Application, error) {
+	f.call("AddApp")
+	return api.Application{ID: "app001", Name: config.Name, Task: api.TaskBrief{ID: "task-add"}}, nil
+}
+
+func (f *fakeManifestApps) ListApps(ctx context.Context, id, name, subscriptionId string, detail bool) (api.AppsResponse, error) {
+	return f.apps, nil
+}
+
+func (f *fakeManifestApps) UpdateArch(ctx context.Context, app string, opts api.AppArchOptions) (api.TaskObjectResponse, error) {
+	f.call("UpdateArch")
+	return api.TaskObjectResponse{}, nil
+}
+
+func (f *fakeManifestApps) UpdateState(ctx context.Context, app string, opts api.AppStateOptions) (api.TaskObjectResponse, error) {
+	f.call("UpdateState")
+	return api.TaskObjectResponse{TaskID: "task-state"}, nil
+}
+
+func (f *fakeManifestApps) UpdateImage(ctx context.Context, app string, opts api.AppImageOptions) (api.TaskObjectResponse, error) {
+	f.call("UpdateImage")
+	return api.TaskObjectResponse{TaskID: "task-image"}, nil
+}
+
+func (f *fakeManifestApps) UpdateAppResourceRequests(ctx context.Context, app string, opts api.AppResourcesOptions) (api.TaskObjectResponse, error) {
+	f.call("UpdateAppResourceRequests")
+	return api.TaskObjectResponse{}, nil
+}
+
+func (f *fakeManifestApps) ListConfig(ctx context.Context, app string) (api.ConfigMapResponse, error) {
+	return f.config, nil
+}
+
+func (f *fakeManifestApps) UpdateConfig(ctx context.Context, app string, config api.ConfigMapOptions) error {
+	f.call("UpdateConfig")
+	return nil
+}
+
+func (f *fakeManifestApps) AddAppDBUser(ctx context.Context, app string, config api.AppUserConfig) (api.TaskObjectResponse, error) {
+	f.call("AddAppDBUser " + app)
+	return api.TaskObjectResponse{}, nil
+}
+
+func (f *fakeManifestApps) ListAppDBUsers(ctx context.Context, app string) (api.AppUsersResponse, error) {
+	return f.users, nil
+}
+
+func (f *fakeManifestApps) DeleteAppDBUser(ctx context.Context, app, user, ip string) error {
+	f.call("DeleteAppDBUser " + user)
+	return nil
+}
+
+func (f *fakeManifestApps) UpdateUserPrivileges(ctx context.Context, appID string, opts api.AppUserPrivilegesOptions) error {
+	f.call("UpdateUserPrivileges")
+	return nil
+}
+
+func (f *fakeManifestApps) ListAppDBSchema(ctx context.Context, app string) (api.DBSchemaResponse, error) {
+	return f.schemas, nil
+}
+
+func (f *fakeManifestApps) AddAppDBSchema(ctx context.Context, app string, config api.AppSchemaConfig) (api.TaskObjectResponse, error) {
+	f.call("AddAppDBSchema")
+	return api.TaskObjectResponse{}, nil
+}
+
+func (f *fakeManifestApps) DeleteAppDBSchema(ctx context.Context, app, schema string) error {
+	f.call("DeleteAppDBSchema")
+	return nil
+}
+
+type fakeManifestTasks struct {
+	lock    sync.Mutex
+	updated []model.Task
+}
+
+func (f *fakeManifestTasks) Insert(model.Task) (string, error) {
+	return "task-manifest", nil
+}
+
+func (f *fakeManifestTasks) Update(tk model.Task) error {
+	f.lock.Lock()
+	f.updated = append(f.updated, tk)
+	f.lock.Unlock()
+
+	return nil
+}
+
+func (f *fakeManifestTasks) Get(id string) (model.Task, error) {
+	return model.Task{ID: id, Status: model.TaskSuccess}, nil
+}
+
+type fakeManifestStrategies struct {
+	apps       *fakeManifestApps
+	strategies api.BackupStrategyResponse
+}
+
+func (f fakeManifestStrategies) AddBackupStrategy(ctx context.Context, config api.BackupStrategyConfig) (api.ObjectResponse, error) {
+	f.apps.call("AddBackupStrategy " + config.App)
+	return api.ObjectResponse{}, nil
+}
+
+func (f fakeManifestStrategies) SetBackupStrategy(ctx context.Context, id string, opts api.BackupStrategyOptions) error {
+	f.apps.call("SetBackupStrategy")
+	return nil
+}
+
+func (f fakeManifestStrategies) ListBackupStrategy(ctx context.Context, id, unit, app string) (api.BackupStrategyResponse, error) {
+	return f.strategies, nil
+}
+
+func (f fakeManifestStrategies) DeleteBackupStrategy(ctx context.Context, id, app string) error {
+	f.apps.call("DeleteBackupStrategy")
+	return nil
+}
+
+func manifestSpec(minor int, cpu int64, state api.State) api.AppSpec {
+	spec := api.AppSpec{Database: &api.GroupSpec{}}
+	spec.Database.Image = api.ImageVersion{Type: "mysql", Major: 5, Minor: minor, Arch: "amd64"}
+	spec.Database.Services.Arch = api.Arch{Replicas: 2, Mode: "replication_async"}
+	spec.Database.Services.Units.ReadinessState = state
+	spec.Database.Services.Units.Resources.Requests.CPU = cpu
+
+	return spec
+}
+
+func planActions(plan api.AppManifestPlan) []string {
+	out := make([]string, len(plan.Steps))
+	for i := range plan.Steps {
+		out[i] = plan.Steps[i].Action + " " + plan.Steps[i].Target
+	}
+
+	return out
+}
+
+func TestManifestPlanAndApply(t *testing.T) {
+	apps := &fakeManifestApps{
+		apps:    api.AppsResponse{{ID: "app001", Name: "db01", Spec: manifestSpec(7, 1, api.StatePassing)}},
+		config:  api.ConfigMapResponse{{Key: "max_connections", Value: "1000"}, {Key: "sql_mode", Value: "STRICT"}},
+		schemas: api.DBSchemaResponse{{Name: "db1"}},
+		users: api.AppUsersResponse{
+			{Name: "app", IP: "%", Privileges: []api.DatabasePrivilege{{DBName: "db1", Privileges: []string{"select"}}}},
+			{Name: "old", IP: "%"},
+		},
+	}
+	strategies := fakeManifestStrategies{
+		apps:       apps,
+		strategies: api.BackupStrategyResponse{{ID: "bs1", Name: "daily", Schedule: "0 1 * * *", Enabled: true, Type: "full"}},
+	}
+	tasks := &fakeManifestTasks{}
+
+	b := NewManifestBankend(apps, strategies, tasks, nil)
+	b.interval = time.Millisecond
+
+	manifest := api.AppManifest{
+		Name:   "db01",
+		Spec:   manifestSpec(8, 2, api.StatePassing),
+		Config: []api.ConfigMapOptions{{Key: "max_connections", Value: "2000"}, {Key: "sql_mode", Value: "STRICT"}},
+		Users: []api.AppUserConfig{
+			{DatabaseUser: api.DatabaseUser{Name: "app", IP: "%", Privileges: []api.DatabasePrivilege{{DBName: "db1", Privileges: []string{"SELECT", "INSERT"}}}}},
+			{DatabaseUser: api.DatabaseUser{Name: "ro", IP: "%", Password: "secret"}},
+		},
+		Schemas:          []api.AppSchemaConfig{{Name: "db1"}, {Name: "db2"}},
+		BackupStrategies: []api.BackupStrategyConfig{{Name: "daily", Schedule: "0 2 * * *", Enabled: true, BackupType: "full"}},
+		Prune:            true,
+	}
+
+	plan, err := b.Plan(context.Background(), manifest, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"app-image database",
+		"app-resources database",
+		"app-config max_connections",
+		"schema-create db2",
+		"user-privileges app@%",
+		"user-create ro@%",
+		"backup-strategy-update daily",
+		"user-delete old@%",
+	}
+	if got := planActions(plan); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected plan:\n%v\nwant:\n%v", got, want)
+	}
+
+	if u := plan.Steps[5].Body.(api.AppUserConfig); u.Password != redactedPassword {
+		t.Errorf("password is not redacted:%s", u.Password)
+	}
+
+	manifest.Spec.Database.Services.Units.ReadinessState = "terminated"
+
+	plan, err = b.Plan(context.Background(), manifest, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last := plan.Steps[len(plan.Steps)-1]; last.Action != api.ManifestAppState {
+		t.Errorf("app stopped before other steps:%v", planActions(plan))
+	}
+
+	manifest.Spec.Cmha = &api.GroupSpec{}
+
+	_, err = b.Plan(context.Background(), manifest, "")
+	if err == nil {
+		t.Error("expected error of adding cmha")
+	}
+
+	// 服务不存在时先创建，后续步骤使用新服务的id
+	apps.apps = nil
+	manifest.Spec.Cmha = nil
+
+	plan, err = b.Apply(context.Background(), manifest, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = []string{
+		"app-create db01",
+		"app-config max_connections",
+		"app-config sql_mode",
+		"schema-create db1",
+		"schema-create db2",
+		"user-create app@%",
+		"user-create ro@%",
+		"backup-strategy-create daily",
+	}
+	if got := planActions(plan); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected plan:\n%v\nwant:\n%v", got, want)
+	}
+
+	if plan.Task.ID != "task-manifest" {
+		t.Errorf("unexpected task %v", plan.Task)
+	}
+
+	for i := 0; i < 100; i++ {
+		tasks.lock.Lock()
+		done := len(tasks.updated) > 0
+		tasks.lock.Unlock()
+
+		if done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(tasks.updated) != 1 || tasks.updated[0].Status != model.TaskSuccess {
+		t.Fatalf("unexpected task update %v", tasks.updated)
+	}
+
+	want = []string{
+		"AddApp",
+		"UpdateConfig",
+		"UpdateConfig",
+		"AddAppDBSchema",
+		"AddAppDBSchema",
+		"AddAppDBUser app001",
+		"AddAppDBUser app001",
+		"AddBackupStrategy app001",
+	}
+	if !reflect.DeepEqual(apps.calls, want) {
+		t.Errorf("unexpected calls:\n%v\nwant:\n%v", apps.calls, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 	"github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
+	"github.com/upmio/dbscale-kube/pkg/structs"
 	"github.com/upmio/dbscale-kube/pkg/vars"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -31,6 +32,10 @@ func (beApp *bankendApp) UnregisterMonitor(ctx context.Context, appName string)
 		return err
 	}
 
+	if err := deleteAlertRules(iface, appName); err != nil {
+		return fmt.Errorf("delete k8s prometheus rule err:%s", err)
+	}
+
 	for _, imageType := range []string{"mysql", "proxysql"} {
 		serviceName := fmt.Sprintf("%s-%s-exporter-svc", appName, imageType)
 		serviceMonitorName := fmt.Sprintf("%s-%s-exporter-svcmon", appName, imageType)
@@ -67,6 +72,15 @@ func (beApp *bankendApp) serviceMonitorCreateWork(spec api.GroupSpec, appId, app
 		return nil
 	}
 
+	if mi.Type == structs.MysqlServiceType {
+		// 已存在时保留服务自定义的阈值
+		err := applyAlertRules(iface, appId, appName, api.DefaultAlertThresholds(), false)
+		if err != nil {
+			klog.Errorf("serviceMonitorCreateWork create k8s prometheus rule err:%s", err.Error())
+			return err
+		}
+	}
+
 	mysqlExporterPort := mi.ExporterPort
 	serviceName := fmt.Sprintf("%s-%s-exporter-svc", appName, mi.Type)
 	serviceMonitorName := fmt.Sprintf("%s-%s-exporter-svcmon", appName, mi.Type)
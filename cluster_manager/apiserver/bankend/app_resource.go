@@ -0,0 +1,151 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	appv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/app/v1alpha1"
+	appclientset "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/clientset/versioned"
+	appclient "github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1/clientset/versioned/typed/app/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/zone"
+)
+
+const defaultAppResourceNamespace = "default"
+
+// NewAppResourceBankend 把 manifest 写入站点的 App 对象，由站点的 operator 同步，
+// apiserver 只作为 App 对象的入口
+func NewAppResourceBankend(zone zone.ZoneInterface) *bankendAppResource {
+	return &bankendAppResource{
+		clients: func(site string) (appclientset.Interface, error) {
+			s, err := zone.GetSite(site)
+			if err != nil {
+				return nil, err
+			}
+
+			config, err := s.Config()
+			if err != nil {
+				return nil, err
+			}
+
+			return appclientset.NewForConfig(config)
+		},
+	}
+}
+
+type bankendAppResource struct {
+	clients func(site string) (appclientset.Interface, error)
+}
+
+func (b *bankendAppResource) apps(query api.AppResourceQuery) (appclient.AppInterface, string, error) {
+	client, err := b.clients(query.SiteID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ns := query.Namespace
+	if ns == "" {
+		ns = defaultAppResourceNamespace
+	}
+
+	return client.AppV1alpha1().Apps(ns), ns, nil
+}
+
+// ApplyResource 创建或更新与 manifest 同名的 App 对象
+func (b *bankendAppResource) ApplyResource(ctx context.Context, query api.AppResourceQuery, opts api.AppResourceOptions) (api.AppResource, error) {
+	raw, err := json.Marshal(opts.Manifest)
+	if err != nil {
+		return api.AppResource{}, err
+	}
+
+	apps, ns, err := b.apps(query)
+	if err != nil {
+		return api.AppResource{}, err
+	}
+
+	spec := appv1alpha1.AppSpec{
+		SubscriptionID: query.SubscriptionID,
+		Manifest:       runtime.RawExtension{Raw: raw},
+		DeletionPolicy: appv1alpha1.DeletionPolicy(opts.DeletionPolicy),
+		Suspend:        opts.Suspend,
+	}
+
+	app, err := apps.Get(ctx, opts.Manifest.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		app = &appv1alpha1.App{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      opts.Manifest.Name,
+				Namespace: ns,
+			},
+			Spec: spec,
+		}
+
+		app, err = apps.Create(ctx, app, metav1.CreateOptions{})
+		if err != nil {
+			return api.AppResource{}, err
+		}
+
+		return convertAppResource(query.SiteID, app), nil
+	}
+	if err != nil {
+		return api.AppResource{}, err
+	}
+
+	app = app.DeepCopy()
+	app.Spec = spec
+
+	app, err = apps.Update(ctx, app, metav1.UpdateOptions{})
+	if err != nil {
+		return api.AppResource{}, err
+	}
+
+	return convertAppResource(query.SiteID, app), nil
+}
+
+func (b *bankendAppResource) GetResource(ctx context.Context, query api.AppResourceQuery, name string) (api.AppResource, error) {
+	apps, _, err := b.apps(query)
+	if err != nil {
+		return api.AppResource{}, err
+	}
+
+	app, err := apps.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return api.AppResource{}, err
+	}
+
+	return convertAppResource(query.SiteID, app), nil
+}
+
+// DeleteResource 删除 App 对象，是否删除服务由对象的 deletionPolicy 决定
+func (b *bankendAppResource) DeleteResource(ctx context.Context, query api.AppResourceQuery, name string) error {
+	apps, _, err := b.apps(query)
+	if err != nil {
+		return err
+	}
+
+	err = apps.Delete(ctx, name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+func convertAppResource(site string, app *appv1alpha1.App) api.AppResource {
+	return api.AppResource{
+		SiteID:             site,
+		Namespace:          app.Namespace,
+		Name:               app.Name,
+		Generation:         app.Generation,
+		ObservedGeneration: app.Status.ObservedGeneration,
+		Phase:              string(app.Status.Phase),
+		AppID:              app.Status.AppID,
+		TaskID:             app.Status.TaskID,
+		Steps:              app.Status.Steps,
+		Message:            app.Status.Message,
+	}
+}
@@ -0,0 +1,84 @@
+package bankend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+// PinTemplate 固定服务使用镜像模板的版本，单元通过 MainImageVerison 使用该版本的配置模板及脚本，
+// revision 为0时恢复跟随镜像当前版本
+func (beApp *bankendApp) PinTemplate(ctx context.Context, id, serviceType string, im model.Image, revision int, user string) (api.TaskObjectResponse, error) {
+	app, _, spec, err := beApp.CheckAppModel(id)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	group := appImageGroup(spec, serviceType)
+	if group == nil || group.Image.ID != im.ID {
+		return api.TaskObjectResponse{}, fmt.Errorf("app %s does not use image %s", app.Name, im.ID)
+	}
+
+	units, err := beApp.syncAppUnitsByType(app.ID, app.Units, serviceType, true)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	// 模板版本属于单个架构的镜像
+	for i := range units {
+		if arch := units[i].Spec.Template.Spec.NodeSelector[corev1.LabelArchStable]; arch != "" && arch != im.Arch {
+			return api.TaskObjectResponse{}, fmt.Errorf("unit %s runs on %s,template of image %s can not be pinned", units[i].Name, arch, im.ID)
+		}
+	}
+
+	group.TemplateRevision = revision
+
+	data, err := encodeAppSpec(spec)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	task, err := beApp.m.UpdateSpec(app.ID, data, model.ActionAppTemplateEdit, user, nil, nil)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	version := templateRevisionVersion(im, revision)
+
+	wt := beApp.waits.NewWaitTask(app.ID, time.Second*10, func(err error) error {
+		tk := taskUpdate(task, err)
+
+		return beApp.m.UpdateAppTask(nil, tk)
+	})
+
+	go wt.WithTimeout(time.Minute*2, func() (bool, error) {
+		var errs []error
+
+		for i := range units {
+			if units[i].Spec.MainImageVerison == version {
+				continue
+			}
+
+			clone := units[i].DeepCopy()
+			clone.Spec.MainImageVerison = version
+
+			if err := beApp.zone.updateUnit(clone); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return true, utilerrors.NewAggregate(errs)
+	})
+
+	return api.TaskObjectResponse{
+		ObjectID:   app.ID,
+		ObjectName: app.Name,
+		TaskID:     task,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/audit"
+)
+
+type modelAudit interface {
+	InsertAudit(al model.AuditLog) (string, error)
+	ListAudits(selector model.AuditSelector) ([]model.AuditLog, error)
+}
+
+func NewAuditBankend(m modelAudit) *bankendAudit {
+	return &bankendAudit{m: m}
+}
+
+// bankendAudit stores the audit events into database,and implements audit.Sink.
+type bankendAudit struct {
+	m modelAudit
+}
+
+func (b *bankendAudit) Write(e audit.Event) error {
+	al := model.AuditLog{
+		User:       e.User,
+		Action:     e.Action,
+		Route:      e.Route,
+		Path:       e.Path,
+		App:        e.App,
+		RemoteAddr: e.RemoteAddr,
+		Code:       e.Code,
+		Error:      e.Error,
+		Request:    string(e.Request),
+		CreatedAt:  e.Time,
+	}
+
+	if len(e.Changes) > 0 {
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return err
+		}
+
+		al.Changes = string(changes)
+	}
+
+	_, err := b.m.InsertAudit(al)
+
+	return err
+}
+
+func (b *bankendAudit) ListAudits(ctx context.Context, query api.AuditQuery) (api.AuditLogsResponse, error) {
+	list, err := b.m.ListAudits(model.AuditSelector{
+		User:   query.User,
+		App:    query.App,
+		Action: query.Action,
+		Since:  query.Since,
+		Until:  query.Until,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(api.AuditLogsResponse, len(list))
+
+	for i, al := range list {
+		out[i] = api.AuditLog{
+			ID:         al.ID,
+			User:       al.User,
+			Action:     al.Action,
+			Route:      al.Route,
+			Path:       al.Path,
+			App:        al.App,
+			RemoteAddr: al.RemoteAddr,
+			Code:       al.Code,
+			Error:      al.Error,
+			Time:       api.Time(al.CreatedAt),
+		}
+
+		if al.Request != "" {
+			out[i].Request = json.RawMessage(al.Request)
+		}
+
+		if al.Changes != "" {
+			err := json.Unmarshal([]byte(al.Changes), &out[i].Changes)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return out, nil
+}
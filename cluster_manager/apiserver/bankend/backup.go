@@ -76,6 +76,7 @@ type modelBackupFile interface {
 	UpdateFile(model.BackupFile) error
 	BackupJobDone(model.BackupFile) error
 	DeleteFile(string) error
+	ListFilesPage(opts api.ListOptions) ([]model.BackupFile, api.ListMeta, error)
 }
 
 type endpointGetter interface {
@@ -90,6 +91,74 @@ type modelBackupEndpoint interface {
 	DeleteEndpoint(string) error
 }
 
+// CheckAppAndSubscription returns error if the app doesn't belong to the subscription,
+// no check if subscriptionId is empty.
+func (b bankendBackup) CheckAppAndSubscription(_ context.Context, app, subscriptionId string) error {
+	if strings.TrimSpace(subscriptionId) == "" {
+		return nil
+	}
+
+	obj, err := b.apps.Get(app)
+	if model.IsNotExist(err) || (err == nil && obj.SubscriptionId != subscriptionId) {
+		return stderror.New("app not found or permission denied")
+	}
+
+	return err
+}
+
+// CheckStrategySubscription returns error if the app of the backup strategy doesn't belong to the subscription.
+func (b bankendBackup) CheckStrategySubscription(ctx context.Context, id, subscriptionId string) error {
+	if strings.TrimSpace(subscriptionId) == "" {
+		return nil
+	}
+
+	strategy, err := b.mbs.GetStrategy(id)
+	if model.IsNotExist(err) {
+		return stderror.New("backup strategy not found or permission denied")
+	}
+	if err != nil {
+		return err
+	}
+
+	return b.CheckAppAndSubscription(ctx, strategy.App, subscriptionId)
+}
+
+// CheckBackupFileSubscription returns error if the app of the backup file doesn't belong to the subscription.
+func (b bankendBackup) CheckBackupFileSubscription(ctx context.Context, id, subscriptionId string) error {
+	if strings.TrimSpace(subscriptionId) == "" {
+		return nil
+	}
+
+	file, err := b.mbf.GetFile(id)
+	if model.IsNotExist(err) {
+		return stderror.New("backup file not found or permission denied")
+	}
+	if err != nil {
+		return err
+	}
+
+	return b.CheckAppAndSubscription(ctx, file.App, subscriptionId)
+}
+
+// SubscriptionApps returns the apps of the subscription,nil if subscriptionId is empty.
+func (b bankendBackup) SubscriptionApps(_ context.Context, subscriptionId string) (map[string]bool, error) {
+	if strings.TrimSpace(subscriptionId) == "" {
+		return nil, nil
+	}
+
+	list, err := b.apps.List(map[string]string{"subscription_id": subscriptionId})
+	if err != nil {
+		return nil, err
+	}
+
+	apps := make(map[string]bool, len(list))
+	for i := range list {
+		apps[list[i].ID] = true
+	}
+
+	return apps, nil
+}
+
 func (b bankendBackup) ListBackupFiles(ctx context.Context, id, unit, app, site, user string) (api.BackupFilesResponse, error) {
 	selector := make(map[string]string)
 	if id != "" {
@@ -116,36 +185,54 @@ func (b bankendBackup) ListBackupFiles(ctx context.Context, id, unit, app, site,
 	out := make([]api.BackupFile, len(list))
 
 	for i := range list {
-		var endpoint model.BackupEndpoint
-		endpointType := "unkown"
-		endpoint, err = b.mbe.GetEndpoint(list[i].EndpointId)
-		if err == nil {
-			endpointType = endpoint.Type
-		}
-
-		out[i] = api.BackupFile{
-			Valid:        list[i].Status == model.BackupFileComplete,
-			Size:         list[i].Size,
-			ID:           list[i].ID,
-			Name:         "",
-			Status:       list[i].Status,
-			Unit:         api.NewIDName(list[i].Unit, ""),
-			App:          api.NewIDName(list[i].App, ""),
-			Site:         api.NewIDName(list[i].Site, ""),
-			Endpoint:     api.NewIDName(list[i].EndpointId, ""),
-			EndpointType: endpointType,
-			Path:         list[i].File,
-			Type:         api.BackupType(list[i].Type),
-			ExpiredAt:    api.Time(list[i].ExpiredAt),
-			CreatedAt:    api.Time(list[i].CreatedAt),
-			FinishedAt:   api.Time(list[i].FinishedAt),
-			User:         list[i].CreatedUser,
-		}
+		out[i] = b.convertBackupFile(list[i])
 	}
 
 	return out, nil
 }
 
+func (b bankendBackup) ListBackupFilesPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error) {
+	list, meta, err := b.mbf.ListFilesPage(opts)
+	if err != nil {
+		return api.ListResponse{}, err
+	}
+
+	out := make([]api.BackupFile, len(list))
+
+	for i := range list {
+		out[i] = b.convertBackupFile(list[i])
+	}
+
+	return api.NewListResponse(out, meta, opts.Fields)
+}
+
+func (b bankendBackup) convertBackupFile(bf model.BackupFile) api.BackupFile {
+	endpointType := "unkown"
+	endpoint, err := b.mbe.GetEndpoint(bf.EndpointId)
+	if err == nil {
+		endpointType = endpoint.Type
+	}
+
+	return api.BackupFile{
+		Valid:        bf.Status == model.BackupFileComplete,
+		Size:         bf.Size,
+		ID:           bf.ID,
+		Name:         "",
+		Status:       bf.Status,
+		Unit:         api.NewIDName(bf.Unit, ""),
+		App:          api.NewIDName(bf.App, ""),
+		Site:         api.NewIDName(bf.Site, ""),
+		Endpoint:     api.NewIDName(bf.EndpointId, ""),
+		EndpointType: endpointType,
+		Path:         bf.File,
+		Type:         api.BackupType(bf.Type),
+		ExpiredAt:    api.Time(bf.ExpiredAt),
+		CreatedAt:    api.Time(bf.CreatedAt),
+		FinishedAt:   api.Time(bf.FinishedAt),
+		User:         bf.CreatedUser,
+	}
+}
+
 func (b bankendBackup) DeleteBackupFile(ctx context.Context, id, app string) error {
 	selector := make(map[string]string)
 
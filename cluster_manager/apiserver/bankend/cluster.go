@@ -34,6 +34,7 @@ type modelCluster interface {
 	Insert(model.Cluster) (string, error)
 	Update(model.Cluster) error
 	Delete(name string) error
+	ListPage(opts api.ListOptions) ([]model.Cluster, api.ListMeta, error)
 }
 
 type clusterGetter interface {
@@ -41,6 +42,25 @@ type clusterGetter interface {
 	List(selector map[string]string) ([]model.Cluster, error)
 }
 
+// siteClustersFilter returns the filter of cluster_id in the clusters of site.
+func siteClustersFilter(clusters clusterGetter, site string) (api.ListFilter, error) {
+	list, err := clusters.List(map[string]string{"site_id": site})
+	if err != nil && !model.IsNotExist(err) {
+		return api.ListFilter{}, err
+	}
+
+	ids := make([]string, len(list))
+	for i := range list {
+		ids[i] = list[i].ID
+	}
+
+	return api.ListFilter{
+		Field:    "cluster_id",
+		Operator: api.FilterIn,
+		Values:   ids,
+	}, nil
+}
+
 func (b *bankendCluster) Add(ctx context.Context, config api.ClusterConfig) (api.Cluster, error) {
 	site, err := b.sites.Get(config.Site)
 	if err != nil {
@@ -168,6 +188,26 @@ func (b *bankendCluster) List(ctx context.Context, id, name, site, enabled strin
 	return clusters, nil
 }
 
+func (b *bankendCluster) ListPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error) {
+	list, meta, err := b.m.ListPage(opts)
+	if err != nil {
+		return api.ListResponse{}, err
+	}
+
+	clusters := make([]api.Cluster, len(list))
+
+	for i := range list {
+		networks, err := b.networks.List(map[string]string{"cluster_id": list[i].ID})
+		if err != nil && !model.IsNotExist(err) {
+			return api.ListResponse{}, err
+		}
+
+		clusters[i] = convertToClusterAPI(list[i], networks)
+	}
+
+	return api.NewListResponse(clusters, meta, opts.Fields)
+}
+
 func (b *bankendCluster) Set(ctx context.Context, id string, opts api.ClusterOptions) (api.Cluster, error) {
 	mc, err := b.m.Get(id)
 	if err != nil {
@@ -0,0 +1,439 @@
+package bankend
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	podutil "github.com/upmio/dbscale-kube/pkg/utils/pod"
+	"github.com/upmio/dbscale-kube/pkg/zone"
+)
+
+const (
+	// 保留最近的事件，用于断线重连时按Last-Event-ID补发
+	eventBufferSize = 1000
+	// 订阅者缓冲满时断开，由客户端重连补发
+	subscriberBufferSize = 100
+)
+
+// eventHandler receives every event published,HandleEvent must not block for long.
+type eventHandler interface {
+	HandleEvent(ev api.Event)
+}
+
+func NewEventBankend(zone zone.ZoneInterface, tasks taskGetter, files backupFileGetter, handlers ...eventHandler) *bankendEvent {
+	return &bankendEvent{
+		zone:        zoneIface{zone: zone},
+		tasks:       tasks,
+		files:       files,
+		handlers:    handlers,
+		subscribers: make(map[*eventSubscriber]struct{}),
+	}
+}
+
+type bankendEvent struct {
+	zone  zoneIface
+	tasks taskGetter
+	files backupFileGetter
+
+	handlers []eventHandler
+
+	lock        sync.Mutex
+	seq         uint64
+	buffer      []api.Event
+	subscribers map[*eventSubscriber]struct{}
+
+	// 以下只由watch goroutine访问
+	synced      bool
+	lastTask    int
+	runningTask map[string]model.Task
+	units       map[string]unitEventState
+	apps        map[string]api.State
+	backups     map[string]model.BackupFile
+}
+
+type unitEventState struct {
+	App   string
+	Site  string
+	State api.State
+}
+
+type eventSubscriber struct {
+	types []string
+	app   string
+	ch    chan api.Event
+}
+
+func (s *eventSubscriber) match(ev api.Event) bool {
+	return api.MatchEventType(s.types, ev.Type) && (s.app == "" || s.app == ev.App)
+}
+
+// Subscribe returns the events after lastID kept in buffer and a channel of the new events,
+// the channel is closed if the subscriber is too slow,cancel must be called when done.
+func (b *bankendEvent) Subscribe(types []string, app string, lastID uint64) ([]api.Event, <-chan api.Event, func()) {
+	sub := &eventSubscriber{
+		types: types,
+		app:   app,
+		ch:    make(chan api.Event, subscriberBufferSize),
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var backlog []api.Event
+
+	if lastID > 0 {
+		for _, ev := range b.buffer {
+			if ev.ID > lastID && sub.match(ev) {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+
+	b.subscribers[sub] = struct{}{}
+
+	cancel := func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+
+	return backlog, sub.ch, cancel
+}
+
+func (b *bankendEvent) publish(ev api.Event) {
+	b.lock.Lock()
+
+	b.seq++
+	ev.ID = b.seq
+	if time.Time(ev.Time).IsZero() {
+		ev.Time = api.Time(time.Now())
+	}
+
+	b.buffer = append(b.buffer, ev)
+	if len(b.buffer) > eventBufferSize {
+		b.buffer = b.buffer[len(b.buffer)-eventBufferSize:]
+	}
+
+	for sub := range b.subscribers {
+		if !sub.match(ev) {
+			continue
+		}
+
+		select {
+		case sub.ch <- ev:
+		default:
+			klog.Warningf("event subscriber is too slow,disconnect")
+
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+
+	b.lock.Unlock()
+
+	for _, h := range b.handlers {
+		h.HandleEvent(ev)
+	}
+}
+
+// Run polls tasks,units and backup files every interval and publishes the changes, until stopCh is closed.
+// The first poll only records the current states.
+func (b *bankendEvent) Run(interval time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	go wait.Until(b.poll, interval, stopCh)
+}
+
+func (b *bankendEvent) poll() {
+	if err := b.pollTasks(); err != nil {
+		klog.Errorf("event watcher tasks:%s", err)
+	}
+
+	b.pollUnits()
+
+	if err := b.pollBackups(); err != nil {
+		klog.Errorf("event watcher backup files:%s", err)
+	}
+
+	b.synced = true
+}
+
+func taskEvent(typ string, tk model.Task) api.Event {
+	ev := api.Event{
+		Type:    typ,
+		Object:  tk.ID,
+		Name:    tk.Action,
+		State:   tk.Status.State(),
+		Message: tk.Error,
+		Time:    api.Time(tk.CreatedAt),
+	}
+
+	if tk.RelateTable == (model.Application{}).Table() {
+		ev.App = tk.RelateID
+	}
+
+	if typ == api.EventTaskFinished {
+		ev.PrevState = model.TaskRunning.State()
+		ev.Time = api.Time(tk.FinishedAt)
+	}
+
+	return ev
+}
+
+func (b *bankendEvent) pollTasks() error {
+	if b.runningTask == nil {
+		latest, err := b.tasks.List(map[string]string{"limit": "1"})
+		if err != nil {
+			return err
+		}
+
+		running, err := b.tasks.List(map[string]string{"status": model.TaskRunning.State()})
+		if err != nil {
+			return err
+		}
+
+		if len(latest) > 0 {
+			b.lastTask = latest[0].Auto
+		}
+
+		b.runningTask = make(map[string]model.Task, len(running))
+		for _, tk := range running {
+			b.runningTask[tk.ID] = tk
+		}
+
+		return nil
+	}
+
+	for id := range b.runningTask {
+		tk, err := b.tasks.Get(id)
+		if model.IsNotExist(err) {
+			delete(b.runningTask, id)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if tk.Status != model.TaskRunning {
+			delete(b.runningTask, id)
+			b.publish(taskEvent(api.EventTaskFinished, tk))
+		}
+	}
+
+	list, err := b.tasks.List(map[string]string{"after": strconv.Itoa(b.lastTask)})
+	if err != nil {
+		return err
+	}
+
+	// list order by ai desc
+	for i := len(list) - 1; i >= 0; i-- {
+		tk := list[i]
+
+		if tk.Auto > b.lastTask {
+			b.lastTask = tk.Auto
+		}
+
+		b.publish(taskEvent(api.EventTaskCreated, tk))
+
+		if tk.Status == model.TaskRunning {
+			b.runningTask[tk.ID] = tk
+		} else {
+			b.publish(taskEvent(api.EventTaskFinished, tk))
+		}
+	}
+
+	return nil
+}
+
+// mergeUnitStates merges the readiness of units as the state of app.
+func mergeUnitStates(states []api.State) api.State {
+	passing, critical := false, false
+
+	for _, s := range states {
+		switch s {
+		case api.StatePassing:
+			passing = true
+		case api.StateCritical:
+			critical = true
+		}
+	}
+
+	switch {
+	case passing && critical:
+		return api.StateWarning
+	case passing:
+		return api.StatePassing
+	case critical:
+		return api.StateCritical
+	}
+
+	return api.StateUnknown
+}
+
+func listDBScalePods(site zone.Site) ([]corev1.Pod, error) {
+	iface, err := site.SiteInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	return iface.Pods().List("", metav1.ListOptions{LabelSelector: labelAppID})
+}
+
+func (b *bankendEvent) pollUnits() {
+	units := make(map[string]unitEventState, len(b.units))
+	// 列取失败的site，保留其单元上次的状态
+	failed := make(map[string]bool)
+
+	for _, site := range b.zone.listSites() {
+		pods, err := listDBScalePods(site)
+		if err != nil {
+			klog.Errorf("event watcher list pods of site %s:%s", site.Name(), err)
+
+			failed[site.Name()] = true
+			continue
+		}
+
+		for i := range pods {
+			state := api.StateCritical
+			if podutil.IsRunningAndReady(&pods[i]) {
+				state = api.StatePassing
+			}
+
+			units[pods[i].Name] = unitEventState{
+				App:   pods[i].Labels[labelAppID],
+				Site:  site.Name(),
+				State: state,
+			}
+		}
+	}
+
+	for name, old := range b.units {
+		if _, ok := units[name]; ok {
+			continue
+		}
+
+		if failed[old.Site] {
+			units[name] = old
+			continue
+		}
+
+		b.publish(api.Event{
+			Type:      api.EventUnitDeleted,
+			Object:    name,
+			App:       old.App,
+			Site:      old.Site,
+			PrevState: string(old.State),
+		})
+	}
+
+	appStates := make(map[string][]api.State)
+
+	for name, us := range units {
+		appStates[us.App] = append(appStates[us.App], us.State)
+
+		old, ok := b.units[name]
+		if !b.synced || (ok && old.State == us.State) {
+			continue
+		}
+
+		b.publish(api.Event{
+			Type:      api.EventUnitState,
+			Object:    name,
+			App:       us.App,
+			Site:      us.Site,
+			State:     string(us.State),
+			PrevState: string(old.State),
+		})
+	}
+
+	apps := make(map[string]api.State, len(appStates))
+
+	for app, states := range appStates {
+		state := mergeUnitStates(states)
+		apps[app] = state
+
+		old, ok := b.apps[app]
+		if !b.synced || (ok && old == state) {
+			continue
+		}
+
+		b.publish(api.Event{
+			Type:      api.EventAppState,
+			Object:    app,
+			App:       app,
+			State:     string(state),
+			PrevState: string(old),
+		})
+	}
+
+	b.units = units
+	b.apps = apps
+}
+
+func (b *bankendEvent) pollBackups() error {
+	list, err := b.files.ListFiles(map[string]string{"status": model.BackupFileRunning})
+	if err != nil {
+		return err
+	}
+
+	running := make(map[string]model.BackupFile, len(list))
+	for _, bf := range list {
+		running[bf.ID] = bf
+	}
+
+	for id := range b.backups {
+		if _, ok := running[id]; ok {
+			continue
+		}
+
+		bf, err := b.files.GetFile(id)
+		if model.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			// 下次再检查
+			running[id] = b.backups[id]
+			klog.Errorf("event watcher get backup file %s:%s", id, err)
+			continue
+		}
+
+		typ := ""
+		switch bf.Status {
+		case model.BackupFileComplete:
+			typ = api.EventBackupCompleted
+		case model.BackupFileFailed:
+			typ = api.EventBackupFailed
+		default:
+			continue
+		}
+
+		b.publish(api.Event{
+			Type:      typ,
+			Object:    bf.ID,
+			Name:      bf.File,
+			App:       bf.App,
+			Site:      bf.Site,
+			State:     bf.Status,
+			PrevState: model.BackupFileRunning,
+			Time:      api.Time(bf.FinishedAt),
+		})
+	}
+
+	b.backups = running
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package bankend
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestEventSubscribe(t *testing.T) {
+	b := NewEventBankend(nil, nil, nil)
+
+	b.publish(api.Event{Type: api.EventTaskCreated, Object: "t1"})
+	b.publish(api.Event{Type: api.EventAppState, Object: "a1", App: "a1"})
+
+	backlog, ch, cancel := b.Subscribe([]string{"task"}, "", 0)
+	defer cancel()
+
+	if len(backlog) != 0 {
+		t.Errorf("expected no backlog without last id,got %d", len(backlog))
+	}
+
+	backlog, _, cancel2 := b.Subscribe(nil, "a1", 1)
+	cancel2()
+
+	if len(backlog) != 1 || backlog[0].ID != 2 {
+		t.Errorf("expected event 2 replayed,got %+v", backlog)
+	}
+
+	b.publish(api.Event{Type: api.EventAppState, Object: "a1", App: "a1"})
+	b.publish(api.Event{Type: api.EventTaskFinished, Object: "t1"})
+
+	select {
+	case ev := <-ch:
+		if ev.ID != 4 || ev.Type != api.EventTaskFinished {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	default:
+		t.Error("expected task event")
+	}
+}
+
+func TestEventPollTasks(t *testing.T) {
+	mt := model.NewFakeModels().ModelTask()
+
+	old := model.NewTask("old", "a1", model.Application{}.Table(), "")
+	old.ID, _ = mt.Insert(old)
+
+	b := NewEventBankend(nil, mt, nil)
+
+	if err := b.pollTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	tk := model.NewTask("new", "a1", model.Application{}.Table(), "")
+	tk.ID, _ = mt.Insert(tk)
+
+	old.Status = model.TaskSuccess
+	old.FinishedAt = time.Now()
+	mt.Update(old)
+
+	if err := b.pollTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.buffer) != 2 {
+		t.Fatalf("expected 2 events,got %+v", b.buffer)
+	}
+
+	if ev := b.buffer[0]; ev.Type != api.EventTaskFinished || ev.Object != old.ID || ev.State != old.Status.State() {
+		t.Errorf("unexpected event %+v", ev)
+	}
+
+	if ev := b.buffer[1]; ev.Type != api.EventTaskCreated || ev.Object != tk.ID || ev.App != "a1" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	var signature, event string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		event = r.Header.Get(api.WebhookHeaderEvent)
+		if r.Header.Get(api.WebhookHeaderSignature) == signPayload("secret", r.Header.Get(api.WebhookHeaderTimestamp), body) {
+			signature = "ok"
+		}
+	}))
+	defer srv.Close()
+
+	m := model.NewFakeModels().ModelWebhook()
+	b := NewWebhookBankend(m, "key")
+
+	wh, err := b.AddWebhook(context.Background(), api.WebhookConfig{
+		Name:       "test",
+		URL:        srv.URL,
+		Secret:     "secret",
+		EventTypes: []string{"backup"},
+		Enabled:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.HandleEvent(api.Event{ID: 1, Type: api.EventTaskCreated})
+	b.HandleEvent(api.Event{ID: 2, Type: api.EventBackupFailed})
+
+	if len(b.queue) != 1 {
+		t.Fatalf("expected 1 queued delivery,got %d", len(b.queue))
+	}
+
+	b.deliver(<-b.queue, nil)
+
+	if event != api.EventBackupFailed || signature != "ok" {
+		t.Errorf("unexpected request event=%s signature=%s", event, signature)
+	}
+
+	list, err := b.ListDeliveries(context.Background(), wh.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0].Status != model.DeliverySuccess || list[0].Attempts != 1 {
+		t.Errorf("unexpected deliveries %+v", list)
+	}
+}
+
+func TestWebhookRequeuePending(t *testing.T) {
+	received := make(chan string, 2)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(api.WebhookHeaderDelivery)
+	}))
+	defer srv.Close()
+
+	m := model.NewFakeModels().ModelWebhook()
+	b := NewWebhookBankend(m, "key")
+
+	wh, err := b.AddWebhook(context.Background(), api.WebhookConfig{
+		Name:    "test",
+		URL:     srv.URL,
+		Secret:  "secret",
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	pending, _ := m.InsertDelivery(model.WebhookDelivery{Webhook: wh.ID, EventID: 1, Payload: "{}", Status: model.DeliveryPending, Attempts: 2, CreatedAt: now})
+	orphan, _ := m.InsertDelivery(model.WebhookDelivery{Webhook: "deleted", EventID: 2, Payload: "{}", Status: model.DeliveryPending, CreatedAt: now})
+	m.InsertDelivery(model.WebhookDelivery{Webhook: wh.ID, EventID: 3, Payload: "{}", Status: model.DeliverySuccess, CreatedAt: now})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	b.Run(stopCh)
+
+	select {
+	case id := <-received:
+		if id != pending {
+			t.Fatalf("expect delivery %s,got %s", pending, id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending delivery is not requeued")
+	}
+
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		list, err := m.ListPendingDeliveries()
+		return len(list) == 0, err
+	})
+	if err != nil {
+		t.Fatalf("expect no pending delivery:%s", err)
+	}
+
+	list, _ := m.ListDeliveries(wh.ID, 0)
+	for _, d := range list {
+		if d.ID == pending && (d.Status != model.DeliverySuccess || d.Attempts != 3) {
+			t.Errorf("unexpected requeued delivery %+v", d)
+		}
+	}
+
+	list, _ = m.ListDeliveries("deleted", 0)
+	if len(list) != 1 || list[0].ID != orphan || list[0].Status != model.DeliveryFailed {
+		t.Errorf("expect the delivery of deleted webhook failed,got %+v", list)
+	}
+
+	if len(received) != 0 {
+		t.Errorf("unexpected deliveries %d", len(received))
+	}
+}
@@ -2,10 +2,18 @@ package bankend
 
 import (
 	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/utils"
+	"github.com/upmio/dbscale-kube/pkg/vars"
+	"github.com/upmio/dbscale-kube/pkg/zone/site"
 )
 
 func isInClusters(clusters []string, id string) bool {
@@ -240,3 +248,114 @@ func (s *scheduler) filterRemoteStorageCondition(requests api.StorageRequirement
 
 	return list, nil
 }
+
+// archHostFilter 多架构服务选择主机的条件，clusters 及 storages 取自 injectSchedulerInfo 过滤后的节点亲和，
+// cpu(毫核)及 memory(MiB)为单元的资源请求
+type archHostFilter struct {
+	clusters []string
+	storages []string
+	cpu      int64
+	memory   int64
+	// ha 每个单元独占主机
+	ha bool
+}
+
+// unitSlots returns how many units of the filter the host can hold
+func (f archHostFilter) unitSlots(host *hostv1.Host) int64 {
+	if !host.Status.NodeReady || host.Spec.Unschedulable {
+		return 0
+	}
+
+	if !utils.ContainsString(f.clusters, host.Labels[labelCluster]) {
+		return 0
+	}
+
+	if f.storages != nil && !utils.ContainsString(f.storages, host.Labels[labelRemoteStorage]) {
+		return 0
+	}
+
+	slots := host.Status.Allocatable.Pods.Value()
+	if !host.Status.Capacity.Units.IsZero() && host.Status.Allocatable.Units.Value() < slots {
+		slots = host.Status.Allocatable.Units.Value()
+	}
+
+	if f.cpu > 0 {
+		if n := host.Status.Allocatable.Cpu.MilliValue() / f.cpu; n < slots {
+			slots = n
+		}
+	}
+
+	if f.memory > 0 {
+		if n := (host.Status.Allocatable.Memery.Value() >> 20) / f.memory; n < slots {
+			slots = n
+		}
+	}
+
+	if f.ha && slots > 1 {
+		slots = 1
+	}
+
+	return slots
+}
+
+// filterHostArchs 统计满足条件的主机上各架构可以放置的单元数量，只统计有对应架构镜像的主机
+func filterHostArchs(iface site.Interface, filter archHostFilter, variants map[string]model.Image) (map[string]int, error) {
+	hosts, err := iface.Hosts().List(metav1.ListOptions{
+		LabelSelector: labels.Set{labelRole: vars.NodeRolenode}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]int)
+
+	for i := range hosts {
+		arch := hosts[i].Status.NodeInfo.Architecture
+
+		if _, ok := variants[arch]; !ok {
+			continue
+		}
+
+		if slots := filter.unitSlots(&hosts[i]); slots > 0 {
+			out[arch] += int(slots)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("non host matched on image archs and resources in clusters %v", filter.clusters)
+	}
+
+	return out, nil
+}
+
+// assignUnitArchs 依次为每个单元选择剩余可放置单元最多的架构，不足时返回错误
+func assignUnitArchs(slots map[string]int, replicas int) ([]string, error) {
+	archs := make([]string, 0, len(slots))
+	for arch := range slots {
+		archs = append(archs, arch)
+	}
+
+	sort.Strings(archs)
+
+	assigned := make(map[string]int, len(slots))
+	out := make([]string, 0, replicas)
+
+	for i := 0; i < replicas; i++ {
+		pick := ""
+
+		for _, arch := range archs {
+			if pick == "" || slots[arch]-assigned[arch] > slots[pick]-assigned[pick] {
+				pick = arch
+			}
+		}
+
+		if slots[pick]-assigned[pick] <= 0 {
+			return nil, fmt.Errorf("no enough hosts %v for %d units", slots, replicas)
+		}
+
+		assigned[pick]++
+		out = append(out, pick)
+	}
+
+	return out, nil
+}
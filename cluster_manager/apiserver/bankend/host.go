@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/upmio/dbscale-kube/pkg/audit"
 	"github.com/upmio/dbscale-kube/pkg/vars"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 
 	execAPI "github.com/upmio/dbscale-kube/cluster_engine/plugin/execservice/api"
 	cryptoutil "github.com/upmio/dbscale-kube/pkg/utils/crypto"
+	"github.com/upmio/dbscale-kube/pkg/utils/sshauth"
 	"github.com/upmio/dbscale-kube/pkg/zone"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -27,6 +29,8 @@ func NewHostBankend(zone zone.ZoneInterface,
 	clusters clusterGetter,
 	sites siteGetter,
 	storages storageGetter,
+	apps unitEvacuator,
+	mm modelHostMaintenance,
 	key string) *bankendHost {
 
 	return &bankendHost{
@@ -35,8 +39,14 @@ func NewHostBankend(zone zone.ZoneInterface,
 		clusters: clusters,
 		sites:    sites,
 		storages: storages,
+		apps:     apps,
 		zone:     zone,
 		waits:    NewWaitTasks(),
+
+		maintenances: &hostMaintenances{
+			m:     mm,
+			items: make(map[string]*hostMaintenance),
+		},
 	}
 }
 
@@ -47,10 +57,12 @@ type bankendHost struct {
 	clusters clusterGetter
 	sites    siteGetter
 	storages storageGetter
+	apps     unitEvacuator
 
 	zone zone.ZoneInterface
 
-	waits *waitTasks
+	waits        *waitTasks
+	maintenances *hostMaintenances
 }
 
 type modelHost interface {
@@ -62,6 +74,7 @@ type modelHost interface {
 	UpdateHostTask(h *model.Host, tk model.Task) error
 	Delete(name string) error
 	ListUnits() ([]model.Unit, error)
+	ListPage(opts api.ListOptions) ([]model.Host, api.ListMeta, error)
 }
 
 type hostGetter interface {
@@ -94,7 +107,11 @@ func (b *bankendHost) Add(ctx context.Context, config api.HostConfig) (api.Host,
 	storage := model.RemoteStorage{}
 	config.Cluster = cluster.ID
 	config.SSHConfig.User = cryptoutil.AesEncrypto(config.SSHConfig.User, b.key)
-	config.SSHConfig.Password = cryptoutil.AesEncrypto(config.SSHConfig.Password, b.key)
+	if config.SSHAuth == nil {
+		config.SSHConfig.Password = cryptoutil.AesEncrypto(config.SSHConfig.Password, b.key)
+	} else {
+		config.SSHConfig.Password = ""
+	}
 
 	if config.RemoteStorage != nil && *config.RemoteStorage != "" {
 		storage, err = b.storages.Get(*config.RemoteStorage)
@@ -296,9 +313,16 @@ func convertToHostV1alpha1(req api.HostConfig) (*v1alpha1.Host, error) {
 			MaxPod:        int64(req.MaxUnit),
 			NtpServer:     req.NTPServer,
 			Unschedulable: !req.Enabled,
+			SSH:           convertToSSHSpec(req.SSHAuth),
 		},
 	}
 
+	if host.Spec.SSH != nil {
+		if err := sshauth.Valid(*host.Spec.SSH); err != nil {
+			return nil, err
+		}
+	}
+
 	host.Spec.LocalVGs = make([]v1alpha1.VGSpec, len(req.HostStorages))
 
 	for i, ls := range req.HostStorages {
@@ -313,6 +337,31 @@ func convertToHostV1alpha1(req api.HostConfig) (*v1alpha1.Host, error) {
 	return host, nil
 }
 
+func convertToSSHSpec(auth *api.SSHAuth) *v1alpha1.SSHSpec {
+	if auth == nil {
+		return nil
+	}
+
+	spec := &v1alpha1.SSHSpec{
+		SecretName:      auth.Secret,
+		SecretNamespace: auth.SecretNamespace,
+		SudoMode:        auth.SudoMode,
+		HostKey:         auth.HostKey,
+	}
+
+	if auth.Bastion != nil {
+		spec.Bastion = &v1alpha1.BastionSpec{
+			Host:       auth.Bastion.IP.String(),
+			Port:       int64(auth.Bastion.Port),
+			User:       auth.Bastion.User,
+			SecretName: auth.Bastion.Secret,
+			HostKey:    auth.Bastion.HostKey,
+		}
+	}
+
+	return spec
+}
+
 func vgName(level string) string {
 
 	return strings.Join([]string{"local", level, "VG"}, "_")
@@ -545,6 +594,33 @@ func (b *bankendHost) List(ctx context.Context, id, name, cluster, site, enabled
 		return nil, err
 	}
 
+	return b.convertHosts(list), nil
+}
+
+// ListPage lists a page of hosts,site isn't a column of host,it's converted to the filter of clusters.
+func (b *bankendHost) ListPage(ctx context.Context, site string, opts api.ListOptions) (api.ListResponse, error) {
+	if site != "" {
+		filter, err := siteClustersFilter(b.clusters, site)
+		if err != nil {
+			return api.ListResponse{}, err
+		}
+
+		if len(filter.Values) == 0 {
+			return api.NewListResponse([]api.Host{}, api.ListMeta{}, opts.Fields)
+		}
+
+		opts.Filters = append(opts.Filters, filter)
+	}
+
+	list, meta, err := b.m.ListPage(opts)
+	if err != nil {
+		return api.ListResponse{}, err
+	}
+
+	return api.NewListResponse(b.convertHosts(list), meta, opts.Fields)
+}
+
+func (b *bankendHost) convertHosts(list []model.Host) []api.Host {
 	hosts := make([]api.Host, len(list))
 
 	for i := range list {
@@ -565,7 +641,7 @@ func (b *bankendHost) List(ctx context.Context, id, name, cluster, site, enabled
 
 	}
 
-	return hosts, nil
+	return hosts
 }
 
 func convertToHostAPI(h model.Host, hostv1 v1alpha1.Host) api.Host {
@@ -799,6 +875,10 @@ func (b *bankendHost) Delete(ctx context.Context, id, user, password string, por
 		return api.TaskObjectResponse{}, fmt.Errorf("since there are pods on this host, you cannot delete it")
 	}
 
+	if node.Spec.SSH == nil && password == "" {
+		return api.TaskObjectResponse{}, fmt.Errorf("ssh password is required to uninstall host %s", host.IP)
+	}
+
 	task, err := b.m.InsertHostTask(host, model.ActionHostDelete)
 	if err != nil {
 		return api.TaskObjectResponse{}, err
@@ -806,6 +886,8 @@ func (b *bankendHost) Delete(ctx context.Context, id, user, password string, por
 
 	b.waits.Delete(host.ID)
 
+	audit.Record(ctx, api.AuditHostDelete, "host", host.IP, convertToHostAPI(host, *node), nil)
+
 	wt := NewWaitTask(time.Minute, func(err error) error {
 		if err == nil {
 			err = b.m.Delete(host.ID)
@@ -822,9 +904,16 @@ func (b *bankendHost) Delete(ctx context.Context, id, user, password string, por
 
 			node = node.DeepCopy()
 			node.Spec.ActCode = v1alpha1.DeletCode
-			node.Spec.OsPassword = cryptoutil.AesEncrypto(password, b.key)
-			node.Spec.OsUser = cryptoutil.AesEncrypto(user, b.key)
-			node.Spec.HostPort = int64(port)
+			// 密钥认证时使用入库时的认证信息
+			if user != "" {
+				node.Spec.OsUser = cryptoutil.AesEncrypto(user, b.key)
+			}
+			if password != "" && node.Spec.SSH == nil {
+				node.Spec.OsPassword = cryptoutil.AesEncrypto(password, b.key)
+			}
+			if port > 0 {
+				node.Spec.HostPort = int64(port)
+			}
 
 			_, err = iface.Update(node)
 			if errors.IsNotFound(err) {
@@ -858,6 +947,7 @@ func (b *bankendHost) ValidateHost(ctx context.Context, hlc api.HostConfig, chec
 		HostName:   hlc.SSHConfig.IP.String(),
 		MaxUnit:    hlc.MaxUnit,
 		NtpServer:  hlc.NTPServer,
+		SSH:        convertToSSHSpec(hlc.SSHAuth),
 	}
 	opt.LocalVGs = []execAPI.LocalVGCfg{}
 	for _, ls := range hlc.HostStorages {
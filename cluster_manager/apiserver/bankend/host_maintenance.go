@@ -0,0 +1,693 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	sanv1 "github.com/upmio/dbscale-kube/pkg/apis/san/v1alpha1"
+	"github.com/upmio/dbscale-kube/pkg/structs"
+	podutil "github.com/upmio/dbscale-kube/pkg/utils/pod"
+	"github.com/upmio/dbscale-kube/pkg/zone/site"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	evacuateInterval = 10 * time.Second
+	evacuateTimeout  = 15 * time.Minute
+)
+
+// unitEvacuator 由bankendApp实现，维护模式迁出单元时使用
+type unitEvacuator interface {
+	RoleSwitch(ctx context.Context, app string, config api.UnitRoleSwitchConfig) error
+	UnitMigrate(ctx context.Context, app, unit string, opts api.UnitMigrateOptions) (api.TaskObjectResponse, error)
+	UnitRebuild(ctx context.Context, app, unit string, opts api.UnitRebuildOptions) (api.TaskObjectResponse, error)
+}
+
+type modelHostMaintenance interface {
+	SaveHostMaintenance(hm model.HostMaintenance) error
+	DeleteHostMaintenance(host string) error
+	GetHostMaintenance(host string) (model.HostMaintenance, error)
+	ListHostMaintenances(state string) ([]model.HostMaintenance, error)
+}
+
+// hostMaintenances 记录本进程中正在迁出的主机，key: host id，
+// 进度同时保存到数据库，重启后从数据库查询
+type hostMaintenances struct {
+	m modelHostMaintenance
+
+	lock  sync.Mutex
+	items map[string]*hostMaintenance
+}
+
+type hostMaintenance struct {
+	m modelHostMaintenance
+
+	lock   sync.Mutex
+	status api.HostMaintenance
+	cancel context.CancelFunc
+	// 已恢复服务，不再保存进度
+	removed bool
+}
+
+func saveHostMaintenance(m modelHostMaintenance, status api.HostMaintenance) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	return m.SaveHostMaintenance(model.HostMaintenance{
+		Host:      status.Host.ID,
+		State:     status.State,
+		Status:    string(data),
+		UpdatedAt: time.Now(),
+	})
+}
+
+func convertHostMaintenance(hm model.HostMaintenance) (api.HostMaintenance, error) {
+	status := api.HostMaintenance{}
+
+	err := json.Unmarshal([]byte(hm.Status), &status)
+	if err != nil {
+		return status, xerrors.Errorf("decode maintenance of host %s:%w", hm.Host, err)
+	}
+
+	status.State = hm.State
+	if status.Units == nil {
+		status.Units = []api.UnitEvacuation{}
+	}
+
+	return status, nil
+}
+
+// persist 保存进度，失败只记录日志，不影响迁移
+func (hm *hostMaintenance) persist() {
+	hm.lock.Lock()
+	defer hm.lock.Unlock()
+
+	if hm.removed {
+		return
+	}
+
+	status := hm.status
+	if err := saveHostMaintenance(hm.m, status); err != nil {
+		klog.Errorf("save maintenance of host %s:%s", status.Host.Name, err)
+	}
+}
+
+func (hm *hostMaintenance) get() api.HostMaintenance {
+	hm.lock.Lock()
+	defer hm.lock.Unlock()
+
+	out := hm.status
+	out.Units = append([]api.UnitEvacuation(nil), hm.status.Units...)
+
+	return out
+}
+
+func (hm *hostMaintenance) setState(state string) {
+	hm.lock.Lock()
+	hm.status.State = state
+	hm.lock.Unlock()
+
+	hm.persist()
+}
+
+func (hm *hostMaintenance) setUnit(i int, state string, err error) {
+	hm.lock.Lock()
+	hm.status.Units[i].State = state
+	if err != nil {
+		hm.status.Units[i].Error = err.Error()
+	}
+	hm.lock.Unlock()
+
+	hm.persist()
+}
+
+// RestoreMaintenances 进程退出时正在迁出的主机标记为失败，不自动继续，
+// 主机保持禁止调度，由用户确认后重新进入维护模式
+func (b *bankendHost) RestoreMaintenances() error {
+	list, err := b.maintenances.m.ListHostMaintenances(api.MaintenanceDraining)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range list {
+		status, err := convertHostMaintenance(row)
+		if err != nil {
+			klog.Errorf("restore maintenance:%s", err)
+			continue
+		}
+
+		for i := range status.Units {
+			if ev := &status.Units[i]; ev.State == api.EvacuationRunning {
+				ev.State = api.EvacuationFailed
+				ev.Error = "interrupted by apiserver restart"
+			}
+		}
+		status.State = api.MaintenanceFailed
+
+		if err := saveHostMaintenance(b.maintenances.m, status); err != nil {
+			return err
+		}
+
+		if status.Task.ID != "" {
+			err := b.m.UpdateHostTask(nil, taskUpdate(status.Task.ID, xerrors.New("interrupted by apiserver restart")))
+			if err != nil {
+				klog.Errorf("update host %s task %s:%s", status.Host.Name, status.Task.ID, err)
+			}
+		}
+
+		klog.Warningf("maintenance of host %s was interrupted, marked as %s", status.Host.Name, status.State)
+	}
+
+	return nil
+}
+
+func (b *bankendHost) GetMaintenance(ctx context.Context, id string) (api.HostMaintenance, error) {
+	host, err := b.m.Get(id)
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	b.maintenances.lock.Lock()
+	hm, ok := b.maintenances.items[host.ID]
+	b.maintenances.lock.Unlock()
+
+	if ok {
+		return hm.get(), nil
+	}
+
+	row, err := b.maintenances.m.GetHostMaintenance(host.ID)
+	if err == nil {
+		status, err := convertHostMaintenance(row)
+		status.Maintenance = !host.Enabled
+
+		return status, err
+	}
+
+	if !model.IsNotExist(err) {
+		return api.HostMaintenance{}, err
+	}
+
+	return api.HostMaintenance{
+		Host:        api.NewIDName(host.ID, host.IP),
+		Maintenance: !host.Enabled,
+		Units:       []api.UnitEvacuation{},
+	}, nil
+}
+
+func (b *bankendHost) SetMaintenance(ctx context.Context, id string, opts api.HostMaintenanceOptions) (api.HostMaintenance, error) {
+	host, err := b.m.Get(id)
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	if !opts.Maintenance {
+		return b.resumeHost(ctx, host, opts.User)
+	}
+
+	if opts.Concurrency == 0 {
+		opts.Concurrency = 1
+	}
+
+	units, err := b.planMaintenance(host)
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	status := api.HostMaintenance{
+		Host:        api.NewIDName(host.ID, host.IP),
+		Maintenance: true,
+		DryRun:      opts.DryRun,
+		State:       api.MaintenancePlanned,
+		Concurrency: opts.Concurrency,
+		Units:       units,
+		Created:     api.NewEditor(opts.User, time.Now()),
+	}
+
+	if opts.DryRun {
+		return status, nil
+	}
+
+	b.maintenances.lock.Lock()
+	defer b.maintenances.lock.Unlock()
+
+	if hm, ok := b.maintenances.items[host.ID]; ok && hm.get().State == api.MaintenanceDraining {
+		return api.HostMaintenance{}, fmt.Errorf("host %s is draining", host.IP)
+	}
+
+	if row, err := b.maintenances.m.GetHostMaintenance(host.ID); err == nil && row.State == api.MaintenanceDraining {
+		return api.HostMaintenance{}, fmt.Errorf("host %s is draining", host.IP)
+	} else if err != nil && !model.IsNotExist(err) {
+		return api.HostMaintenance{}, err
+	}
+
+	enabled := false
+	_, err = b.Set(ctx, host.ID, api.HostOptions{
+		Enabled: &enabled,
+		User:    opts.User,
+	})
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	task, err := b.m.InsertHostTask(host, model.ActionHostMaintenance)
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	status.State = api.MaintenanceDraining
+	status.Task = api.TaskBrief{
+		ID:     task,
+		Status: model.TaskRunning.State(),
+		Action: model.ActionHostMaintenance,
+		User:   opts.User,
+	}
+
+	err = saveHostMaintenance(b.maintenances.m, status)
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	drainCtx, cancel := context.WithCancel(context.Background())
+	hm := &hostMaintenance{
+		m:      b.maintenances.m,
+		status: status,
+		cancel: cancel,
+	}
+	b.maintenances.items[host.ID] = hm
+
+	go func() {
+		err := b.drainHost(drainCtx, host, hm)
+		if err != nil {
+			klog.Errorf("drain host %s:%s", host.IP, err)
+		}
+
+		if _err := b.m.UpdateHostTask(nil, taskUpdate(task, err)); _err != nil {
+			klog.Errorf("update host %s task %s:%s", host.IP, task, _err)
+		}
+	}()
+
+	return hm.get(), nil
+}
+
+// resumeHost 停止正在进行的迁移并恢复调度，已迁出的单元不迁回
+func (b *bankendHost) resumeHost(ctx context.Context, host model.Host, user string) (api.HostMaintenance, error) {
+	b.maintenances.lock.Lock()
+	hm, ok := b.maintenances.items[host.ID]
+	delete(b.maintenances.items, host.ID)
+	b.maintenances.lock.Unlock()
+
+	if ok {
+		hm.lock.Lock()
+		hm.removed = true
+		hm.lock.Unlock()
+
+		if hm.cancel != nil {
+			hm.cancel()
+		}
+	}
+
+	if err := b.maintenances.m.DeleteHostMaintenance(host.ID); err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	enabled := true
+	_, err := b.Set(ctx, host.ID, api.HostOptions{
+		Enabled: &enabled,
+		User:    user,
+	})
+	if err != nil {
+		return api.HostMaintenance{}, err
+	}
+
+	return api.HostMaintenance{
+		Host:        api.NewIDName(host.ID, host.IP),
+		Maintenance: false,
+		Units:       []api.UnitEvacuation{},
+		Created:     api.NewEditor(user, time.Now()),
+	}, nil
+}
+
+// planMaintenance 为主机上的每个单元选择目标主机：
+// 同集群、已启用、未运行同一应用的单元(反亲和)、未超过MaxUnit，
+// 共享存储要求相同的远程存储，本地存储要求相同性能等级的VG，优先选择单元最少的主机。
+func (b *bankendHost) planMaintenance(host model.Host) ([]api.UnitEvacuation, error) {
+	iface, err := b.zone.SiteInterface(host.Cluster.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	mus, err := b.m.ListUnits()
+	if err != nil {
+		return nil, fmt.Errorf("list unit in database err:%s", err)
+	}
+
+	unitsByID := make(map[string]model.Unit, len(mus))
+	for _, mu := range mus {
+		unitsByID[mu.ID] = mu
+	}
+
+	pods, err := iface.Pods().List("", metav1.ListOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// node -> app ids, node -> unit count
+	nodeApps := make(map[string]map[string]bool)
+	nodeUnits := make(map[string]int)
+	// app -> unit id -> node
+	appNodes := make(map[string]map[string]string)
+	local := []corev1.Pod{}
+
+	for _, pod := range pods {
+		mu, ok := unitsByID[pod.Name]
+		if !ok || pod.Spec.NodeName == "" {
+			continue
+		}
+
+		if nodeApps[pod.Spec.NodeName] == nil {
+			nodeApps[pod.Spec.NodeName] = make(map[string]bool)
+		}
+		nodeApps[pod.Spec.NodeName][mu.App] = true
+		nodeUnits[pod.Spec.NodeName]++
+
+		if appNodes[mu.App] == nil {
+			appNodes[mu.App] = make(map[string]string)
+		}
+		appNodes[mu.App][mu.ID] = pod.Spec.NodeName
+
+		if pod.Spec.NodeName == host.Hostname {
+			local = append(local, pod)
+		}
+	}
+
+	candidates, err := b.m.List(map[string]string{
+		"cluster_id": host.ClusterID,
+		"enabled":    "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.UnitEvacuation, 0, len(local))
+
+	for _, pod := range local {
+		mu := unitsByID[pod.Name]
+
+		ev := api.UnitEvacuation{
+			App:       mu.App,
+			Unit:      mu.ID,
+			Namespace: mu.Namespace,
+			Type:      mu.GetServiceType(),
+			Action:    api.EvacuationMigrate,
+			State:     api.EvacuationPending,
+		}
+
+		unit, err := iface.Units().Get(mu.Namespace, mu.ObjectName())
+		if err != nil {
+			return nil, err
+		}
+
+		level := ""
+		for _, claim := range unit.Spec.VolumeClaims {
+			if claim.Storage.Type == sanv1.LocalType {
+				ev.Action = api.EvacuationRebuild
+				level = claim.Storage.Level
+			}
+		}
+
+		if ev.Type == structs.MysqlServiceType && podutil.IsRunningAndReady(&pod) {
+			repl, err := getUnitReplication(iface.PodExec(), *unit)
+			if err != nil {
+				klog.Warningf("%s: get replication:%s", mu.ID, err)
+			} else if repl.Role == structs.MasterRole {
+				ev.Switchover = true
+				ev.NewMaster = pickNewMaster(mu, unitsByID, appNodes[mu.App], host.Hostname)
+				if ev.NewMaster == "" {
+					ev.Error = "no slave out of the host to switchover"
+				}
+			}
+		}
+
+		target := pickEvacuationTarget(host, candidates, nodeApps, nodeUnits, mu.App, ev.Action, level)
+		if target == nil {
+			if ev.Error == "" {
+				ev.Error = "no eligible host"
+			}
+		} else {
+			ev.Target = api.NewIDName(target.ID, target.IP)
+
+			if nodeApps[target.Hostname] == nil {
+				nodeApps[target.Hostname] = make(map[string]bool)
+			}
+			nodeApps[target.Hostname][mu.App] = true
+			nodeUnits[target.Hostname]++
+		}
+
+		out = append(out, ev)
+	}
+
+	// 同一应用的单元相邻，主库最后迁移
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].App != out[j].App {
+			return out[i].App < out[j].App
+		}
+
+		return !out[i].Switchover && out[j].Switchover
+	})
+
+	return out, nil
+}
+
+func pickNewMaster(master model.Unit, units map[string]model.Unit, nodes map[string]string, hostname string) string {
+	ids := make([]string, 0, len(nodes))
+	for id, node := range nodes {
+		if node == hostname || id == master.ID {
+			continue
+		}
+
+		if mu, ok := units[id]; ok && mu.IsServiceType(structs.MysqlServiceType) {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return ""
+	}
+
+	sort.Strings(ids)
+
+	return ids[0]
+}
+
+func pickEvacuationTarget(host model.Host, candidates []model.Host,
+	nodeApps map[string]map[string]bool, nodeUnits map[string]int,
+	app, action, level string) *model.Host {
+
+	var target *model.Host
+
+	for i := range candidates {
+		c := &candidates[i]
+
+		if c.ID == host.ID || !c.Enabled || nodeApps[c.Hostname][app] {
+			continue
+		}
+
+		if c.MaxUnit > 0 && nodeUnits[c.Hostname] >= c.MaxUnit {
+			continue
+		}
+
+		if action == api.EvacuationMigrate && host.RemoteStorageID != "" && c.RemoteStorageID != host.RemoteStorageID {
+			continue
+		}
+
+		if action == api.EvacuationRebuild && level != "" {
+			found := false
+			for _, hs := range c.HostStorages {
+				if hs.Performance == level {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				continue
+			}
+		}
+
+		if target == nil || nodeUnits[c.Hostname] < nodeUnits[target.Hostname] {
+			target = c
+		}
+	}
+
+	return target
+}
+
+// drainHost 按应用分组并发迁移，同一应用的单元依次迁移
+func (b *bankendHost) drainHost(ctx context.Context, host model.Host, hm *hostMaintenance) error {
+	status := hm.get()
+
+	groups := make(map[string][]int)
+	apps := []string{}
+	for i, ev := range status.Units {
+		if _, ok := groups[ev.App]; !ok {
+			apps = append(apps, ev.App)
+		}
+		groups[ev.App] = append(groups[ev.App], i)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, status.Concurrency)
+	)
+
+	for _, app := range apps {
+		index := groups[app]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			for _, i := range index {
+				if ctx.Err() != nil {
+					return
+				}
+
+				hm.setUnit(i, api.EvacuationRunning, nil)
+
+				err := b.evacuateUnit(ctx, host, status.Units[i])
+				if err != nil {
+					hm.setUnit(i, api.EvacuationFailed, err)
+
+					lock.Lock()
+					errs = append(errs, fmt.Errorf("unit %s:%s", status.Units[i].Unit, err))
+					lock.Unlock()
+
+					// 同一应用的后续单元不再迁移
+					return
+				}
+
+				hm.setUnit(i, api.EvacuationDone, nil)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	switch {
+	case ctx.Err() != nil:
+		hm.setState(api.MaintenanceCanceled)
+		return ctx.Err()
+	case len(errs) > 0:
+		hm.setState(api.MaintenanceFailed)
+		return fmt.Errorf("%d unit(s) evacuate failed:%v", len(errs), errs)
+	}
+
+	hm.setState(api.MaintenanceDrained)
+
+	return nil
+}
+
+func (b *bankendHost) evacuateUnit(ctx context.Context, host model.Host, ev api.UnitEvacuation) error {
+	if ev.Target.ID == "" {
+		return xerrors.New(ev.Error)
+	}
+
+	if b.apps == nil {
+		return xerrors.New("unit evacuator is not set")
+	}
+
+	if ev.Switchover {
+		if ev.NewMaster == "" {
+			return xerrors.New(ev.Error)
+		}
+
+		config := api.UnitRoleSwitchConfig{}
+		config.Units = append(config.Units, struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+		}{ID: ev.NewMaster, Role: structs.MasterRole})
+
+		if err := b.apps.RoleSwitch(ctx, ev.App, config); err != nil {
+			return fmt.Errorf("switchover to %s:%s", ev.NewMaster, err)
+		}
+	}
+
+	target := ev.Target.ID
+	var err error
+
+	switch ev.Action {
+	case api.EvacuationRebuild:
+		_, err = b.apps.UnitRebuild(ctx, ev.App, ev.Unit, api.UnitRebuildOptions{Node: &target})
+	default:
+		_, err = b.apps.UnitMigrate(ctx, ev.App, ev.Unit, api.UnitMigrateOptions{Node: &target})
+	}
+	if err != nil {
+		return err
+	}
+
+	iface, err := b.zone.SiteInterface(host.Cluster.SiteID)
+	if err != nil {
+		return err
+	}
+
+	return waitUnitEvacuated(ctx, iface, ev.Namespace, ev.Unit, host.Hostname)
+}
+
+// waitUnitEvacuated 等待 namespace 中的单元在其他主机上运行就绪
+func waitUnitEvacuated(ctx context.Context, iface site.Interface, namespace, name, hostname string) error {
+	ctx, cancel := context.WithTimeout(ctx, evacuateTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(evacuateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait unit %s evacuated:%s", name, ctx.Err())
+		case <-ticker.C:
+		}
+
+		unit, err := iface.Units().Get(namespace, name)
+		if err != nil {
+			klog.Warningf("wait unit %s evacuated:%s", name, err)
+			continue
+		}
+
+		if unit.Spec.Action.Rebuild != nil || unit.Spec.Action.Migrate != nil {
+			continue
+		}
+
+		pod, err := iface.Pods().Get(unit.Namespace, unit.PodName())
+		if err != nil {
+			continue
+		}
+
+		if pod.GetDeletionTimestamp() == nil &&
+			pod.Spec.NodeName != "" && pod.Spec.NodeName != hostname &&
+			podutil.IsRunningAndReady(pod) {
+			return nil
+		}
+	}
+}
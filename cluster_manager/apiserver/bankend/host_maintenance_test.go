@@ -0,0 +1,210 @@
+package bankend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+func TestPickEvacuationTarget(t *testing.T) {
+	host := model.Host{ID: "h0", Hostname: "node0", RemoteStorageID: "san1"}
+	host.MaxUnit = 10
+
+	newHost := func(id string, enabled bool, maxUnit int, san string, levels ...string) model.Host {
+		h := model.Host{ID: id, Hostname: "node" + id[1:], Enabled: enabled, RemoteStorageID: san}
+		h.MaxUnit = maxUnit
+
+		for _, level := range levels {
+			h.HostStorages = append(h.HostStorages, model.HostStorage{Host: id, Performance: level})
+		}
+
+		return h
+	}
+
+	candidates := []model.Host{
+		newHost("h0", true, 10, "san1", "high"),
+		newHost("h1", false, 10, "san1", "high"),
+		newHost("h2", true, 2, "san1", "medium"),
+		newHost("h3", true, 10, "san2", "high"),
+		newHost("h4", true, 10, "san1", "high"),
+	}
+
+	cases := []struct {
+		name      string
+		nodeApps  map[string]map[string]bool
+		nodeUnits map[string]int
+		action    string
+		level     string
+		want      string
+	}{
+		{
+			name:   "fewest units with the same remote storage",
+			action: api.EvacuationMigrate,
+			nodeUnits: map[string]int{
+				"node2": 1,
+				"node4": 0,
+			},
+			want: "h4",
+		},
+		{
+			name:     "anti affinity",
+			action:   api.EvacuationMigrate,
+			nodeApps: map[string]map[string]bool{"node4": {"app1": true}},
+			want:     "h2",
+		},
+		{
+			name:      "max unit",
+			action:    api.EvacuationMigrate,
+			nodeApps:  map[string]map[string]bool{"node4": {"app1": true}},
+			nodeUnits: map[string]int{"node2": 2},
+			want:      "",
+		},
+		{
+			name:   "rebuild requires the storage level",
+			action: api.EvacuationRebuild,
+			level:  "medium",
+			want:   "h2",
+		},
+		{
+			name:      "rebuild ignores the remote storage",
+			action:    api.EvacuationRebuild,
+			level:     "high",
+			nodeApps:  map[string]map[string]bool{"node4": {"app1": true}},
+			nodeUnits: map[string]int{},
+			want:      "h3",
+		},
+	}
+
+	for _, c := range cases {
+		if c.nodeApps == nil {
+			c.nodeApps = map[string]map[string]bool{}
+		}
+		if c.nodeUnits == nil {
+			c.nodeUnits = map[string]int{}
+		}
+
+		got := ""
+		if target := pickEvacuationTarget(host, candidates, c.nodeApps, c.nodeUnits, "app1", c.action, c.level); target != nil {
+			got = target.ID
+		}
+
+		if got != c.want {
+			t.Errorf("%s: expect target '%s',got '%s'", c.name, c.want, got)
+		}
+	}
+}
+
+func TestPickNewMaster(t *testing.T) {
+	units := map[string]model.Unit{
+		"app1-mysql-0":    {ID: "app1-mysql-0", App: "app1"},
+		"app1-mysql-1":    {ID: "app1-mysql-1", App: "app1"},
+		"app1-mysql-2":    {ID: "app1-mysql-2", App: "app1"},
+		"app1-proxysql-0": {ID: "app1-proxysql-0", App: "app1"},
+	}
+
+	cases := []struct {
+		name  string
+		nodes map[string]string
+		want  string
+	}{
+		{
+			name:  "first slave out of the host",
+			nodes: map[string]string{"app1-mysql-0": "node0", "app1-mysql-2": "node2", "app1-mysql-1": "node1", "app1-proxysql-0": "node3"},
+			want:  "app1-mysql-1",
+		},
+		{
+			name:  "slave on the same host",
+			nodes: map[string]string{"app1-mysql-0": "node0", "app1-mysql-1": "node0", "app1-mysql-2": "node2"},
+			want:  "app1-mysql-2",
+		},
+		{
+			name:  "no slave",
+			nodes: map[string]string{"app1-mysql-0": "node0", "app1-proxysql-0": "node3"},
+			want:  "",
+		},
+	}
+
+	for _, c := range cases {
+		if got := pickNewMaster(units["app1-mysql-0"], units, c.nodes, "node0"); got != c.want {
+			t.Errorf("%s: expect '%s',got '%s'", c.name, c.want, got)
+		}
+	}
+}
+
+func TestHostMaintenancePersist(t *testing.T) {
+	fm := model.NewFakeModels()
+	mm := fm.ModelHostMaintenance()
+
+	id, _, err := fm.ModelHost().Insert(model.Host{Hostname: "node0", IP: "192.168.1.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewHostBankend(nil, fm.ModelHost(), nil, nil, nil, nil, mm, "")
+
+	status, err := b.GetMaintenance(context.TODO(), id)
+	if err != nil || status.State != "" || len(status.Units) != 0 {
+		t.Fatalf("expect no maintenance,got %+v,%v", status, err)
+	}
+
+	hm := &hostMaintenance{
+		m: mm,
+		status: api.HostMaintenance{
+			Host:        api.NewIDName(id, "192.168.1.10"),
+			Maintenance: true,
+			State:       api.MaintenanceDraining,
+			Concurrency: 1,
+			Units: []api.UnitEvacuation{
+				{App: "app1", Unit: "app1-mysql-0", State: api.EvacuationPending},
+				{App: "app2", Unit: "app2-mysql-0", State: api.EvacuationPending},
+			},
+			Task: api.TaskBrief{ID: "task1"},
+		},
+	}
+
+	hm.setUnit(0, api.EvacuationDone, nil)
+	hm.setUnit(1, api.EvacuationRunning, nil)
+
+	// 进程重启，内存中的进度丢失
+	b = NewHostBankend(nil, fm.ModelHost(), nil, nil, nil, nil, mm, "")
+
+	status, err = b.GetMaintenance(context.TODO(), id)
+	if err != nil || status.State != api.MaintenanceDraining || status.Units[1].State != api.EvacuationRunning {
+		t.Fatalf("expect the progress persisted,got %+v,%v", status, err)
+	}
+
+	if err := b.RestoreMaintenances(); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = b.GetMaintenance(context.TODO(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.State != api.MaintenanceFailed ||
+		status.Units[0].State != api.EvacuationDone ||
+		status.Units[1].State != api.EvacuationFailed || status.Units[1].Error == "" {
+		t.Fatalf("expect the interrupted maintenance failed,got %+v", status)
+	}
+
+	if list, _ := mm.ListHostMaintenances(api.MaintenanceDraining); len(list) != 0 {
+		t.Fatalf("expect no draining maintenance,got %+v", list)
+	}
+
+	hm.lock.Lock()
+	hm.removed = true
+	hm.lock.Unlock()
+
+	if err := mm.DeleteHostMaintenance(id); err != nil {
+		t.Fatal(err)
+	}
+
+	hm.setState(api.MaintenanceCanceled)
+
+	if _, err := mm.GetHostMaintenance(id); !model.IsNotExist(err) {
+		t.Fatalf("expect removed maintenance not saved,got %v", err)
+	}
+}
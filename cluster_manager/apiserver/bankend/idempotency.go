@@ -0,0 +1,77 @@
+package bankend
+
+import (
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/idempotency"
+)
+
+type modelIdempotency interface {
+	InsertIdempotency(rec model.IdempotencyRecord) (bool, error)
+	UpdateIdempotency(rec model.IdempotencyRecord) error
+	DeleteIdempotency(key, method, path string) error
+	GetIdempotency(key, method, path string) (model.IdempotencyRecord, error)
+}
+
+func NewIdempotencyBankend(m modelIdempotency) *bankendIdempotency {
+	return &bankendIdempotency{m: m}
+}
+
+// bankendIdempotency stores the responses of requests with Idempotency-Key into database,
+// and implements idempotency.Store.
+type bankendIdempotency struct {
+	m modelIdempotency
+}
+
+func (b *bankendIdempotency) Reserve(rec idempotency.Record) (idempotency.Record, bool, error) {
+	ok, err := b.m.InsertIdempotency(convertToIdempotencyRecord(rec))
+	if err != nil || ok {
+		return rec, ok, err
+	}
+
+	old, err := b.m.GetIdempotency(rec.Key, rec.Method, rec.Path)
+	if model.IsNotExist(err) {
+		// released by another request
+		ok, err = b.m.InsertIdempotency(convertToIdempotencyRecord(rec))
+
+		return rec, ok, err
+	}
+	if err != nil {
+		return rec, false, err
+	}
+
+	return idempotency.Record{
+		Key:         old.Key,
+		Method:      old.Method,
+		Path:        old.Path,
+		RequestHash: old.RequestHash,
+		Done:        old.Done,
+		Code:        old.Code,
+		Response:    []byte(old.Response),
+		Error:       old.Error,
+		ETag:        old.ETag,
+		CreatedAt:   old.CreatedAt,
+	}, false, nil
+}
+
+func (b *bankendIdempotency) Complete(rec idempotency.Record) error {
+	return b.m.UpdateIdempotency(convertToIdempotencyRecord(rec))
+}
+
+func (b *bankendIdempotency) Release(rec idempotency.Record) error {
+	return b.m.DeleteIdempotency(rec.Key, rec.Method, rec.Path)
+}
+
+func convertToIdempotencyRecord(rec idempotency.Record) model.IdempotencyRecord {
+	return model.IdempotencyRecord{
+		Key:         rec.Key,
+		Method:      rec.Method,
+		Path:        rec.Path,
+		RequestHash: rec.RequestHash,
+		Done:        rec.Done,
+		Code:        rec.Code,
+		Response:    string(rec.Response),
+		Error:       rec.Error,
+		ETag:        rec.ETag,
+		CreatedAt:   rec.CreatedAt,
+	}
+}
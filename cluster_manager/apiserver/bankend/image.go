@@ -38,18 +38,20 @@ func init() {
 	flag.StringVar(&baseImageDir, "baseImageDir", baseDir, "base image dir")
 }
 
-func NewImageBankend(zone zone.ZoneInterface, sites siteGetter, m modelImage) *bankendImage {
+func NewImageBankend(zone zone.ZoneInterface, sites siteGetter, m modelImage, templates model.ModelImageTemplate) *bankendImage {
 	return &bankendImage{
-		sites: sites,
-		m:     m,
-		zone:  zone,
-		waits: NewWaitTasks(),
+		sites:     sites,
+		m:         m,
+		templates: templates,
+		zone:      zone,
+		waits:     NewWaitTasks(),
 	}
 }
 
 type bankendImage struct {
-	sites siteGetter
-	m     modelImage
+	sites     siteGetter
+	m         modelImage
+	templates model.ModelImageTemplate
 
 	zone zone.ZoneInterface
 
@@ -62,6 +64,9 @@ type modelImage interface {
 	Insert(model.Image) (string, string, error)
 	InsertImageTask(model.Image, string) (string, error)
 	Update(model.Image) error
+	UpdateDigest(model.Image) error
+	UpdateLifecycle(model.Image) error
+	UpdateTemplate(model.Image) error
 	UpdateImageTask(*model.Image, model.Task) error
 	Delete(name string) error
 }
@@ -114,6 +119,12 @@ func (b *bankendImage) Add(ctx context.Context, config api.ImageConfig) (api.Ima
 		return api.Image{}, err
 	}
 
+	err = b.inspectImage(site, &im)
+	if err != nil {
+		klog.Errorf("inspect image %s err: %s", im.ImageWithArch(), err)
+		return api.Image{}, err
+	}
+
 	{
 		templateConfig, err := generateTemplateConfig(im)
 		if err != nil {
@@ -208,7 +219,8 @@ func (b *bankendImage) AddAsync(ctx context.Context, config api.ImageConfig) (ap
 		tk := taskUpdate(task, err)
 
 		if err == nil {
-			im.Unschedulable = config.Unschedulable
+			// 签名未验证通过时 inspectImage 已设置为不可调度
+			im.Unschedulable = config.Unschedulable || im.Unschedulable
 
 			return b.m.UpdateImageTask(&im, tk)
 		}
@@ -294,6 +306,12 @@ func (b *bankendImage) AddAsync(ctx context.Context, config api.ImageConfig) (ap
 			klog.Infof("import image done")
 		}
 
+		err = b.inspectImage(site, &im)
+		if err != nil {
+			klog.Errorf("inspect image %s err: %s", im.ImageWithArch(), err)
+			return false, err
+		}
+
 		return true, nil
 	})
 
@@ -510,6 +528,14 @@ func convertToImageAPI(im model.Image) api.Image {
 		Created:       api.NewEditor("", im.CreatedAt),
 		Modified:      api.NewEditor("", im.ModifiedAt),
 		Site:          api.NewIDName(im.SiteID, im.Site.Name),
+
+		Digest:          im.Digest,
+		SignatureStatus: im.SignatureStatus,
+		DriftDigest:     im.DriftDigest,
+
+		Lifecycle:    im.Lifecycle(time.Now()),
+		DeprecatedAt: convertToTimePtr(im.DeprecatedAt),
+		EOLAt:        convertToTimePtr(im.EOLAt),
 	}
 }
 
@@ -522,6 +548,10 @@ func (b *bankendImage) Set(ctx context.Context, id string, opts api.ImageOptions
 
 	im, ok := mergeImage(im, opts)
 	if ok {
+		if err := checkSchedulable(im); err != nil {
+			return image, err
+		}
+
 		err = b.m.Update(im)
 	}
 
@@ -666,141 +696,6 @@ func (b *bankendImage) ListImageTemplates(ctx context.Context, imageID string) (
 	return tmpl, nil
 }
 
-func (b *bankendImage) SetImageTemplate(ctx context.Context, id string, kvs []api.KeySet) (api.TaskObjectResponse, error) {
-	/*im, err := b.m.Get(id)
-	if err != nil {
-		return api.TaskObjectResponse{}, err
-	}
-
-	zface := zoneIface{b.zone}
-
-	tmpl, err := zface.imageTemplate(im)
-	if err != nil {
-		return api.TaskObjectResponse{}, err
-	}
-
-	err = mergeKeysets(tmpl.Spec.Template.Keysets, kvs)
-	if err != nil {
-		return api.TaskObjectResponse{}, err
-	}
-
-	options := make(map[string]string, len(kvs))
-
-	for i := range kvs {
-		options[kvs[i].Key] = kvs[i].Value
-	}
-
-	err = parser.SetConfigTemplate(&tmpl.Spec.Template, options)
-	if err != nil {
-		return api.TaskObjectResponse{}, err
-	}
-
-	kvsData, err := json.Marshal(tmpl.Spec.Template.Keysets)
-	if err != nil {
-		return api.TaskObjectResponse{}, err
-	}
-	task, err := b.m.InsertImageTask(im, model.ActionImageTemplateEdit)
-	if err != nil {
-		return api.TaskObjectResponse{}, err
-	}
-
-	done := make(map[string]bool)
-	_, file := filepath.Split(tmpl.Spec.Template.ConfigFile)
-	kvsName := tmpl.Spec.Template.Image.ImageVersion() + ".json"
-
-	wt := b.waits.NewWaitTask(im.ID, time.Second*10, func(err error) error {
-		tk := taskUpdate(task, err)
-
-		return b.m.UpdateImageTask(nil, tk)
-	})
-
-	go wt.WithTimeout(time.Minute, func() (bool, error) {
-
-		sites := zface.listSites()
-		errs := make([]error, 0, len(sites))
-
-		for i := range sites {
-
-			if ok := done[sites[i].Name()]; ok {
-				continue
-			}
-
-			iface, err := sites[i].SiteInterface()
-			if err != nil {
-				errs = append(errs, err)
-				continue
-			}
-
-			clone, err := iface.ImageTemplates().Get(tmpl.Name)
-			if errors.IsNotFound(err) {
-
-				clone, err = iface.ImageTemplates().Create(&tmpl)
-			}
-			if err != nil {
-				errs = append(errs, err)
-				continue
-			}
-
-			if ref := clone.Spec.ConfigMapRef; ref != nil {
-
-				_, err = iface.ConfigMaps().Get(ref.Namespace, ref.Name)
-				if err != nil {
-					errs = append(errs, err)
-					continue
-				}
-			}
-
-			if ref := tmpl.Spec.Template.ConfigMapRef; ref != nil {
-
-				cm, err := iface.ConfigMaps().Get(ref.Namespace, ref.Name)
-				if errors.IsNotFound(err) {
-
-					cm = &corev1.ConfigMap{
-						ObjectMeta: metav1.ObjectMeta{Name: ref.Name,
-							Namespace: ref.Namespace,
-						},
-						Data: map[string]string{
-							file:    tmpl.Spec.Template.Content,
-							kvsName: string(kvsData),
-						},
-					}
-
-					cm, err = iface.ConfigMaps().Create(ref.Namespace, cm)
-
-				} else if err == nil {
-					cm.Data[file] = tmpl.Spec.Template.Content
-					cm.Data[kvsName] = string(kvsData)
-
-					cm, err = iface.ConfigMaps().Update(cm.Namespace, cm)
-				}
-				if err != nil {
-					errs = append(errs, err)
-					continue
-				}
-			}
-
-			if clone.Status.Phase == v1alpha3.ImageReady {
-				done[sites[i].Name()] = true
-			}
-		}
-
-		if len(done) == len(sites) {
-			klog.Infof("edit image template %s ready", tmpl.Name)
-			return true, nil
-		}
-
-		return false, utilerrors.NewAggregate(errs)
-	})
-
-	return api.TaskObjectResponse{
-		ObjectID:   im.ID,
-		ObjectName: im.Image(),
-		TaskID:     task,
-	}, nil*/
-
-	return api.TaskObjectResponse{}, nil
-}
-
 /*
 func mergeKeysets(kvs []v1alpha3.Keyset, news []api.Keyset) error {
 
@@ -860,38 +755,3 @@ func (b *bankendImage) ListImageScripts(ctx context.Context, imageID string) (ap
 
 	return is, nil
 }
-
-func (b *bankendImage) SyncImageScripts(ctx context.Context, imageID string, scriptType string) error {
-	im, err := b.m.Get(imageID)
-	if err != nil {
-		return err
-	}
-
-	iface, err := b.zone.SiteInterface(im.SiteID)
-	if err != nil {
-		return err
-	}
-
-	cmName := fmt.Sprintf("%s-%s", im.ImageTemplateFileNameWithArch(), scriptType)
-	old, err := iface.ConfigMaps().Get(metav1.NamespaceDefault, cmName)
-	if err != nil {
-		return fmt.Errorf("get configmap:[%s] by image id:[%s] ERR: %s", cmName, imageID, err)
-	}
-
-	if old == nil {
-		return fmt.Errorf("Image: %s doesn't have script configmap: %s", imageID, cmName)
-	}
-
-	fileName := im.ImageTemplateFileNameWithArch()
-	script, err := getScriptFromFile(baseImageDir, fileName, scriptType)
-	if err != nil {
-		return err
-	}
-
-	new := old.DeepCopy()
-	new.Data[unitv4.ScriptDataTab] = script
-
-	_, err = iface.ConfigMaps().Update(metav1.NamespaceDefault, new)
-
-	return err
-}
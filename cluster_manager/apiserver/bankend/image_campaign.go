@@ -0,0 +1,626 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"golang.org/x/xerrors"
+	"k8s.io/klog/v2"
+)
+
+const campaignInterval = 30 * time.Second
+
+// campaignApps 由bankendApp实现
+type campaignApps interface {
+	ListApps(ctx context.Context, id, name, subscriptionId string, detail bool) (api.AppsResponse, error)
+	UpdateImage(ctx context.Context, app string, opts api.AppImageOptions) (api.TaskObjectResponse, error)
+}
+
+type campaignTasks interface {
+	Get(id string) (model.Task, error)
+}
+
+func NewImageCampaignBankend(images imageGetter, apps campaignApps, tasks campaignTasks, m model.ModelImageCampaign) *bankendImageCampaign {
+	return &bankendImageCampaign{
+		images:    images,
+		apps:      apps,
+		tasks:     tasks,
+		m:         m,
+		interval:  campaignInterval,
+		campaigns: make(map[string]context.CancelFunc),
+	}
+}
+
+type bankendImageCampaign struct {
+	images imageGetter
+	apps   campaignApps
+	tasks  campaignTasks
+	m      model.ModelImageCampaign
+
+	interval time.Duration
+	stopCh   <-chan struct{}
+
+	lock      sync.Mutex
+	campaigns map[string]context.CancelFunc
+
+	// 串行修改升级活动，避免取消时被进行中的检查覆盖
+	updateLock sync.Mutex
+}
+
+// LifecycleReport 返回弃用或停止支持的镜像及仍在使用的服务，lifecycle 为空时返回两者
+func (b *bankendImageCampaign) LifecycleReport(ctx context.Context, lifecycle string) (api.ImageLifecycleReportResponse, error) {
+	images, err := b.images.List(map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reports := make(map[string]*api.ImageLifecycleReport)
+
+	for _, im := range images {
+		state := im.Lifecycle(now)
+		if state == api.ImageLifecycleActive || (lifecycle != "" && state != lifecycle) {
+			continue
+		}
+
+		reports[im.ID] = &api.ImageLifecycleReport{
+			Image:        api.ImageVersion(im.ImageVersion),
+			Lifecycle:    state,
+			DeprecatedAt: convertToTimePtr(im.DeprecatedAt),
+			EOLAt:        convertToTimePtr(im.EOLAt),
+			Apps:         []api.IDName{},
+		}
+	}
+
+	if len(reports) > 0 {
+		apps, err := b.apps.ListApps(ctx, "", "", "", false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, app := range apps {
+			for _, group := range appGroups(app.Spec) {
+				if r, ok := reports[group.Image.ID]; ok {
+					r.Apps = append(r.Apps, api.NewIDName(app.ID, app.Name))
+				}
+			}
+		}
+	}
+
+	out := make(api.ImageLifecycleReportResponse, 0, len(reports))
+	for _, r := range reports {
+		out = append(out, *r)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Image.ID < out[j].Image.ID
+	})
+
+	return out, nil
+}
+
+func appGroups(spec api.AppSpec) []*api.GroupSpec {
+	groups := make([]*api.GroupSpec, 0, 3)
+
+	for _, group := range []*api.GroupSpec{spec.Database, spec.Cmha, spec.Proxy} {
+		if group != nil {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// appImageGroup returns the group using the same type image as target
+func appImageGroup(spec api.AppSpec, typ string) *api.GroupSpec {
+	for _, group := range appGroups(spec) {
+		if group.Image.Type == typ {
+			return group
+		}
+	}
+
+	return nil
+}
+
+func compareImageVersion(a, b api.ImageVersion) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch, a.Dev - b.Dev} {
+		if d != 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// Add 创建升级活动，目标镜像必须是 active 状态，
+// 未指定服务时选择所有使用弃用或停止支持的同类型镜像的服务。
+func (b *bankendImageCampaign) Add(ctx context.Context, config api.ImageCampaignConfig) (api.ImageCampaign, error) {
+	target, err := b.images.Get(config.Image)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	if err := checkImageLifecycle(target); err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	apps, err := b.selectApps(ctx, config.Apps, target)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	if config.Concurrency == 0 {
+		config.Concurrency = 1
+	}
+
+	spec, err := json.Marshal(api.ImageCampaignSpec{
+		Window:      config.Window,
+		Concurrency: config.Concurrency,
+	})
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	items, err := json.Marshal(apps)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	now := time.Now()
+	c := model.ImageCampaign{
+		Name:  config.Name,
+		Image: target.ID,
+		State: api.CampaignRunning,
+		Spec:  string(spec),
+		Apps:  string(items),
+		Editor: model.Editor{
+			CreatedUser:  config.User,
+			CreatedAt:    now,
+			ModifiedUser: config.User,
+			ModifiedAt:   now,
+		},
+	}
+
+	c.ID, err = b.m.InsertCampaign(c)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	b.start(c)
+
+	return b.convert(c)
+}
+
+func (b *bankendImageCampaign) selectApps(ctx context.Context, ids []string, target model.Image) ([]api.CampaignApp, error) {
+	var apps api.AppsResponse
+
+	if len(ids) == 0 {
+		list, err := b.apps.ListApps(ctx, "", "", "", false)
+		if err != nil {
+			return nil, err
+		}
+
+		images, err := b.images.List(map[string]string{"type": target.Type})
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		retired := make(map[string]bool)
+		for _, im := range images {
+			if im.Lifecycle(now) != api.ImageLifecycleActive {
+				retired[im.ID] = true
+			}
+		}
+
+		for _, app := range list {
+			if group := appImageGroup(app.Spec, target.Type); group != nil && retired[group.Image.ID] {
+				apps = append(apps, app)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		list, err := b.apps.ListApps(ctx, id, "", "", false)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(list) == 0 {
+			return nil, xerrors.Errorf("app %s not found", id)
+		}
+
+		apps = append(apps, list[0])
+	}
+
+	out := make([]api.CampaignApp, 0, len(apps))
+	seen := make(map[string]bool, len(apps))
+
+	for _, app := range apps {
+		if seen[app.ID] {
+			continue
+		}
+		seen[app.ID] = true
+
+		item := api.CampaignApp{
+			App:   api.NewIDName(app.ID, app.Name),
+			State: api.CampaignAppPending,
+		}
+
+		if group := appImageGroup(app.Spec, target.Type); group != nil {
+			item.From = group.Image
+		}
+
+		if reason := skipReason(item.From, target); reason != "" {
+			item.State = api.CampaignAppSkipped
+			item.Error = reason
+		}
+
+		out = append(out, item)
+	}
+
+	return out, nil
+}
+
+// skipReason 服务无需或不能升级到目标镜像的原因
+func skipReason(from api.ImageVersion, target model.Image) string {
+	to := api.ImageVersion(target.ImageVersion)
+
+	switch {
+	case from.Type == "":
+		return fmt.Sprintf("no %s service", target.Type)
+	case from.Arch != "" && from.Arch != to.Arch:
+		return fmt.Sprintf("arch %s is different from %s", from.Arch, to.Arch)
+	case compareImageVersion(from, to) >= 0:
+		return fmt.Sprintf("%s is up to date", from.ID)
+	}
+
+	return ""
+}
+
+// Run 恢复未完成的升级活动
+func (b *bankendImageCampaign) Run(stopCh <-chan struct{}) {
+	b.stopCh = stopCh
+
+	list, err := b.m.ListCampaigns(map[string]string{"state": api.CampaignRunning})
+	if err != nil {
+		klog.Errorf("list running image campaigns:%s", err)
+		return
+	}
+
+	for _, c := range list {
+		b.start(c)
+	}
+}
+
+func (b *bankendImageCampaign) start(c model.ImageCampaign) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b.lock.Lock()
+	b.campaigns[c.ID] = cancel
+	b.lock.Unlock()
+
+	go func() {
+		defer func() {
+			b.lock.Lock()
+			delete(b.campaigns, c.ID)
+			b.lock.Unlock()
+
+			cancel()
+		}()
+
+		err := b.run(ctx, c.ID)
+		if err != nil {
+			klog.Errorf("image campaign %s:%s", c.ID, err)
+		}
+	}()
+}
+
+// run 每个周期检查升级中服务的任务，在维护窗口内按并发数开始升级等待中的服务，
+// 维护窗口结束时已开始的升级继续执行。
+func (b *bankendImageCampaign) run(ctx context.Context, id string) error {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		done, err := b.step(ctx, id)
+		if err != nil {
+			klog.Errorf("image campaign %s:%s", id, err)
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-b.stopCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *bankendImageCampaign) step(ctx context.Context, id string) (bool, error) {
+	b.updateLock.Lock()
+	defer b.updateLock.Unlock()
+
+	c, err := b.m.GetCampaign(id)
+	if err != nil {
+		return false, err
+	}
+
+	if c.State != api.CampaignRunning {
+		return true, nil
+	}
+
+	spec, apps, err := decodeCampaign(c)
+	if err != nil {
+		return true, err
+	}
+
+	target, err := b.images.Get(c.Image)
+	if err != nil {
+		return false, err
+	}
+
+	running, pending := 0, 0
+
+	for i := range apps {
+		if apps[i].State == api.CampaignAppRunning {
+			b.checkApp(&apps[i])
+		}
+
+		switch apps[i].State {
+		case api.CampaignAppRunning:
+			running++
+		case api.CampaignAppPending:
+			pending++
+		}
+	}
+
+	if pending > 0 && spec.Window.Contains(time.Now()) {
+		for i := range apps {
+			if running >= spec.Concurrency {
+				break
+			}
+
+			if apps[i].State != api.CampaignAppPending {
+				continue
+			}
+
+			b.upgradeApp(ctx, &apps[i], target)
+
+			if apps[i].State == api.CampaignAppRunning {
+				running++
+			}
+			pending--
+		}
+	}
+
+	if running == 0 && pending == 0 {
+		c.State = api.CampaignCompleted
+	}
+
+	err = b.save(c, apps)
+
+	return c.State != api.CampaignRunning, err
+}
+
+func (b *bankendImageCampaign) checkApp(item *api.CampaignApp) {
+	tk, err := b.tasks.Get(item.Task)
+	if err != nil {
+		klog.Errorf("get task %s of app %s:%s", item.Task, item.App.Name, err)
+		return
+	}
+
+	switch tk.Status {
+	case model.TaskRunning:
+		return
+	case model.TaskSuccess:
+		item.State = api.CampaignAppDone
+	default:
+		item.State = api.CampaignAppFailed
+		item.Error = fmt.Sprintf("task %s %s:%s", tk.ID, tk.Status.State(), tk.Error)
+	}
+
+	now := api.Now()
+	item.FinishedAt = &now
+}
+
+// upgradeApp 使用服务当前的 spec 检查后调用 UpdateImage
+func (b *bankendImageCampaign) upgradeApp(ctx context.Context, item *api.CampaignApp, target model.Image) {
+	now := api.Now()
+	item.StartedAt = &now
+
+	fail := func(state, reason string) {
+		item.State = state
+		item.Error = reason
+		item.FinishedAt = &now
+	}
+
+	apps, err := b.apps.ListApps(ctx, item.App.ID, "", "", false)
+	if err != nil {
+		fail(api.CampaignAppFailed, err.Error())
+		return
+	}
+	if len(apps) == 0 {
+		fail(api.CampaignAppSkipped, "app not found")
+		return
+	}
+
+	group := appImageGroup(apps[0].Spec, target.Type)
+	if group != nil {
+		item.From = group.Image
+	}
+
+	if reason := skipReason(item.From, target); reason != "" {
+		fail(api.CampaignAppSkipped, reason)
+		return
+	}
+
+	iv := api.ImageVersion(target.ImageVersion)
+	image := &struct {
+		Image *api.ImageVersion `json:"image,omitempty"`
+	}{Image: &iv}
+
+	opts := api.AppImageOptions{}
+	switch group {
+	case apps[0].Spec.Database:
+		opts.Spec.Database = image
+	case apps[0].Spec.Cmha:
+		opts.Spec.Cmha = image
+	case apps[0].Spec.Proxy:
+		opts.Spec.Proxy = image
+	}
+
+	resp, err := b.apps.UpdateImage(ctx, item.App.ID, opts)
+	if err != nil {
+		fail(api.CampaignAppFailed, err.Error())
+		return
+	}
+
+	item.State = api.CampaignAppRunning
+	item.Task = resp.TaskID
+}
+
+func (b *bankendImageCampaign) save(c model.ImageCampaign, apps []api.CampaignApp) error {
+	data, err := json.Marshal(apps)
+	if err != nil {
+		return err
+	}
+
+	c.Apps = string(data)
+	c.ModifiedAt = time.Now()
+
+	return b.m.UpdateCampaign(c)
+}
+
+// Cancel 停止升级活动，等待中的服务不再升级，已开始的升级继续执行
+func (b *bankendImageCampaign) Cancel(ctx context.Context, id string) (api.ImageCampaign, error) {
+	b.lock.Lock()
+	cancel, ok := b.campaigns[id]
+	b.lock.Unlock()
+
+	if ok {
+		cancel()
+	}
+
+	b.updateLock.Lock()
+	defer b.updateLock.Unlock()
+
+	c, err := b.m.GetCampaign(id)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	if c.State != api.CampaignRunning {
+		return api.ImageCampaign{}, xerrors.Errorf("image campaign %s is %s", c.Name, c.State)
+	}
+
+	_, apps, err := decodeCampaign(c)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	for i := range apps {
+		if apps[i].State == api.CampaignAppPending {
+			apps[i].State = api.CampaignAppCanceled
+		}
+	}
+
+	c.State = api.CampaignCanceled
+
+	err = b.save(c, apps)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	return b.Get(ctx, id)
+}
+
+func (b *bankendImageCampaign) Get(ctx context.Context, id string) (api.ImageCampaign, error) {
+	c, err := b.m.GetCampaign(id)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	return b.convert(c)
+}
+
+func (b *bankendImageCampaign) List(ctx context.Context, state string) (api.ImageCampaignsResponse, error) {
+	selector := make(map[string]string)
+	if state != "" {
+		selector["state"] = state
+	}
+
+	list, err := b.m.ListCampaigns(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(api.ImageCampaignsResponse, 0, len(list))
+
+	for _, c := range list {
+		v, err := b.convert(c)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+func decodeCampaign(c model.ImageCampaign) (api.ImageCampaignSpec, []api.CampaignApp, error) {
+	spec := api.ImageCampaignSpec{}
+	apps := []api.CampaignApp{}
+
+	err := json.Unmarshal([]byte(c.Spec), &spec)
+	if err != nil {
+		return spec, nil, xerrors.Errorf("decode image campaign %s spec:%s", c.ID, err)
+	}
+
+	err = json.Unmarshal([]byte(c.Apps), &apps)
+	if err != nil {
+		return spec, nil, xerrors.Errorf("decode image campaign %s apps:%s", c.ID, err)
+	}
+
+	return spec, apps, nil
+}
+
+func (b *bankendImageCampaign) convert(c model.ImageCampaign) (api.ImageCampaign, error) {
+	spec, apps, err := decodeCampaign(c)
+	if err != nil {
+		return api.ImageCampaign{}, err
+	}
+
+	image := api.ImageVersion{ID: c.Image}
+	if im, err := b.images.Get(c.Image); err == nil {
+		image = api.ImageVersion(im.ImageVersion)
+	}
+
+	summary := make(map[string]int)
+	for _, app := range apps {
+		summary[app.State]++
+	}
+
+	return api.ImageCampaign{
+		ID:                c.ID,
+		Name:              c.Name,
+		Image:             image,
+		State:             c.State,
+		ImageCampaignSpec: spec,
+		Summary:           summary,
+		Apps:              apps,
+		Created:           api.NewEditor(c.CreatedUser, c.CreatedAt),
+		Modified:          api.NewEditor(c.ModifiedUser, c.ModifiedAt),
+	}, nil
+}
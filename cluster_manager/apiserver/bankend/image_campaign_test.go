@@ -0,0 +1,176 @@
+package bankend
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+type fakeCampaignApps struct {
+	lock    sync.Mutex
+	apps    api.AppsResponse
+	tasks   model.ModelTask
+	upgrade map[string]api.ImageVersion
+}
+
+func (f *fakeCampaignApps) ListApps(ctx context.Context, id, name, subscriptionId string, detail bool) (api.AppsResponse, error) {
+	if id == "" {
+		return f.apps, nil
+	}
+
+	for _, app := range f.apps {
+		if app.ID == id {
+			return api.AppsResponse{app}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeCampaignApps) UpdateImage(ctx context.Context, app string, opts api.AppImageOptions) (api.TaskObjectResponse, error) {
+	f.lock.Lock()
+	f.upgrade[app] = *opts.Spec.Database.Image
+	f.lock.Unlock()
+
+	id, err := f.tasks.Insert(model.NewTask(model.ActionAppImageEdit, app, model.Application{}.Table(), ""))
+
+	return api.TaskObjectResponse{ObjectID: app, TaskID: id}, err
+}
+
+func TestImageCampaign(t *testing.T) {
+	fm := model.NewFakeModels()
+	mi := fm.ModelImage()
+	mt := fm.ModelTask()
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+
+	oldID, _, _ := mi.Insert(model.Image{ImageVersion: model.ImageVersion{Type: "mysql", Arch: "amd64", Major: 5, Minor: 7, Patch: 25, Dev: 1}})
+	newID, _, _ := mi.Insert(model.Image{ImageVersion: model.ImageVersion{Type: "mysql", Arch: "amd64", Major: 5, Minor: 7, Patch: 25, Dev: 2}})
+
+	old, _ := mi.Get(oldID)
+	old.DeprecatedAt = &yesterday
+	if err := mi.UpdateLifecycle(old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkImageLifecycle(old); err == nil {
+		t.Error("expected deprecated image is rejected")
+	}
+
+	app := func(id, image string) api.Application {
+		im, _ := mi.Get(image)
+		return api.Application{
+			ID:   id,
+			Name: id,
+			Spec: api.AppSpec{Database: &api.GroupSpec{Image: api.ImageVersion(im.ImageVersion)}},
+		}
+	}
+
+	apps := &fakeCampaignApps{
+		apps:    api.AppsResponse{app("app1", oldID), app("app2", newID)},
+		tasks:   mt,
+		upgrade: make(map[string]api.ImageVersion),
+	}
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+
+	b := NewImageCampaignBankend(mi, apps, mt, fm.ModelImageCampaign())
+	b.Run(stopCh)
+
+	ctx := context.Background()
+
+	report, err := b.LifecycleReport(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 || report[0].Image.ID != oldID || report[0].Lifecycle != api.ImageLifecycleDeprecated ||
+		len(report[0].Apps) != 1 || report[0].Apps[0].ID != "app1" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if _, err := b.Add(ctx, api.ImageCampaignConfig{Name: "bad", Image: oldID}); err == nil {
+		t.Error("expected deprecated target is rejected")
+	}
+
+	c, err := b.Add(ctx, api.ImageCampaignConfig{Name: "upgrade", Image: newID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Apps) != 1 || c.Apps[0].App.ID != "app1" || c.Summary[api.CampaignAppPending] != 1 {
+		t.Fatalf("unexpected campaign %+v", c)
+	}
+
+	// the first step starts upgrading app1
+	for i := 0; ; i++ {
+		c, err = b.Get(ctx, c.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Apps[0].State == api.CampaignAppRunning {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("app1 is not upgraded,%+v", c.Apps[0])
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	apps.lock.Lock()
+	got := apps.upgrade["app1"]
+	apps.lock.Unlock()
+
+	if got.ID != newID {
+		t.Errorf("app1 is upgraded to %s", got.ID)
+	}
+
+	if err := mt.Update(taskUpdate(c.Apps[0].Task, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := b.step(ctx, c.ID)
+	if err != nil || !done {
+		t.Fatalf("expected campaign done,%t %v", done, err)
+	}
+
+	c, _ = b.Get(ctx, c.ID)
+	if c.State != api.CampaignCompleted || c.Summary[api.CampaignAppDone] != 1 {
+		t.Errorf("unexpected campaign %+v", c)
+	}
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	// 2020-01-06 is Monday
+	at := func(clock string) time.Time {
+		tm, _ := time.ParseInLocation("2006-01-02 15:04", "2020-01-06 "+clock, time.Local)
+		return tm
+	}
+
+	cases := []struct {
+		window api.MaintenanceWindow
+		t      time.Time
+		want   bool
+	}{
+		{api.MaintenanceWindow{}, at("12:00"), true},
+		{api.MaintenanceWindow{Start: "01:00", End: "05:00"}, at("03:00"), true},
+		{api.MaintenanceWindow{Start: "01:00", End: "05:00"}, at("05:00"), false},
+		{api.MaintenanceWindow{Start: "23:00", End: "02:00"}, at("23:30"), true},
+		{api.MaintenanceWindow{Start: "23:00", End: "02:00"}, at("01:00"), true},
+		{api.MaintenanceWindow{Start: "23:00", End: "02:00"}, at("12:00"), false},
+		// the window starts on Sunday
+		{api.MaintenanceWindow{Start: "23:00", End: "02:00", Weekdays: []int{0}}, at("01:00"), true},
+		{api.MaintenanceWindow{Start: "23:00", End: "02:00", Weekdays: []int{1}}, at("01:00"), false},
+	}
+
+	for i, c := range cases {
+		if got := c.window.Contains(c.t); got != c.want {
+			t.Errorf("%d: %+v contains %s,expected %t but got %t", i, c.window, c.t, c.want, got)
+		}
+	}
+}
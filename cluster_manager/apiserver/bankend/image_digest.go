@@ -0,0 +1,168 @@
+package bankend
+
+import (
+	"crypto"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	execapi "github.com/upmio/dbscale-kube/cluster_engine/plugin/execservice/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/registry"
+)
+
+// imageVerifyKeys PEM 格式的公钥文件，配置后只有签名验证通过的镜像可以调度
+var imageVerifyKeys = ""
+
+func init() {
+	flag.StringVar(&imageVerifyKeys, "image-verify-keys", imageVerifyKeys, "PEM file of the public keys verifying the image signatures,unverified images are unschedulable")
+}
+
+func loadImageVerifyKeys() ([]crypto.PublicKey, error) {
+	if imageVerifyKeys == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(imageVerifyKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.ParsePublicKeys(data)
+}
+
+func imageDeployOption(site model.Site, im model.Image) execapi.DeployImageOption {
+	return execapi.DeployImageOption{
+		ImageRegistry: site.ImageRegistry,
+		ProjectName:   site.ProjectName,
+		Type:          im.Type,
+		Arch:          im.Arch,
+		Version:       im.Version(),
+	}
+}
+
+// inspectImage 记录镜像 tag 在站点仓库中对应的 digest，配置了公钥时验证签名，
+// 验证不通过的镜像设置为不可调度。
+// 未配置公钥时只尽力记录 digest，查询失败不影响导入，由 RunDigestCheck 补录。
+func (b *bankendImage) inspectImage(site model.Site, im *model.Image) error {
+	resp, err := b.inspectDigest(site, *im)
+	if err != nil && imageVerifyKeys == "" {
+		klog.Warningf("inspect image %s digest,skipped:%s", im.ImageWithArch(), err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	im.Digest = resp.Digest
+	im.DriftDigest = ""
+	im.SignatureStatus = ""
+
+	keys, err := loadImageVerifyKeys()
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	err = registry.Verify(resp.Digest, resp.Signatures, keys)
+	switch {
+	case err == nil:
+		im.SignatureStatus = api.ImageSignatureVerified
+		return nil
+	case err == registry.ErrUnsigned:
+		im.SignatureStatus = api.ImageSignatureUnsigned
+	default:
+		im.SignatureStatus = api.ImageSignatureInvalid
+	}
+
+	klog.Warningf("image %s@%s is unschedulable,%s", im.ImageWithArch(), im.Digest, err)
+	im.Unschedulable = true
+
+	return nil
+}
+
+func (b *bankendImage) inspectDigest(site model.Site, im model.Image) (execapi.InspectImageResponse, error) {
+	s, err := b.zone.GetSite(site.ID)
+	if err != nil {
+		return execapi.InspectImageResponse{}, err
+	}
+
+	iface, err := s.SiteInterface()
+	if err != nil {
+		return execapi.InspectImageResponse{}, err
+	}
+
+	return iface.ImageDeployExec().InspectImage(imageDeployOption(site, im))
+}
+
+// checkSchedulable 配置了公钥时，签名未验证通过的镜像不能设置为可调度
+func checkSchedulable(im model.Image) error {
+	if im.Unschedulable || imageVerifyKeys == "" || im.SignatureStatus == api.ImageSignatureVerified {
+		return nil
+	}
+
+	return fmt.Errorf("image %s signature is %q,cannot be schedulable", im.ID, im.SignatureStatus)
+}
+
+// RunDigestCheck 定期检查镜像 tag 在站点仓库中对应的 digest，
+// 与导入时不同时记录为 DriftDigest，单元使用导入时的 digest 不受影响
+func (b *bankendImage) RunDigestCheck(interval time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	go wait.Until(b.checkDigests, interval, stopCh)
+}
+
+func (b *bankendImage) checkDigests() {
+	images, err := b.m.List(map[string]string{})
+	if err != nil {
+		klog.Errorf("check image digests,list images:%s", err)
+		return
+	}
+
+	for _, im := range images {
+		site, err := b.sites.Get(im.SiteID)
+		if err != nil {
+			klog.Errorf("check image %s digest,get site %s:%s", im.ID, im.SiteID, err)
+			continue
+		}
+
+		resp, err := b.inspectDigest(site, im)
+		if err != nil {
+			klog.Errorf("check image %s digest:%s", im.ID, err)
+			continue
+		}
+
+		switch {
+		case im.Digest == "":
+			// 记录升级前导入的镜像的 digest
+			im.Digest = resp.Digest
+
+		case im.Digest == resp.Digest:
+			if im.DriftDigest == "" {
+				continue
+			}
+
+			im.DriftDigest = ""
+
+		default:
+			if im.DriftDigest == resp.Digest {
+				continue
+			}
+
+			klog.Warningf("image %s tag points to %s,but %s is imported", im.ID, resp.Digest, im.Digest)
+			im.DriftDigest = resp.Digest
+		}
+
+		im.ModifiedAt = time.Now()
+
+		if err := b.m.UpdateDigest(im); err != nil {
+			klog.Errorf("update image %s digest:%s", im.ID, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package bankend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+// SetLifecycle 设置镜像的弃用及停止支持时间，为空表示取消
+func (b *bankendImage) SetLifecycle(ctx context.Context, id string, opts api.ImageLifecycleOptions) (api.Image, error) {
+	im, err := b.m.Get(id)
+	if err != nil {
+		return api.Image{}, err
+	}
+
+	im.DeprecatedAt = convertFromTimePtr(opts.DeprecatedAt)
+	im.EOLAt = convertFromTimePtr(opts.EOLAt)
+	im.ModifiedUser = opts.User
+	im.ModifiedAt = time.Now()
+
+	err = b.m.UpdateLifecycle(im)
+
+	return convertToImageAPI(im), err
+}
+
+// checkImageLifecycle 弃用或停止支持的镜像不能用于新建服务和升级
+func checkImageLifecycle(im model.Image) error {
+	if state := im.Lifecycle(time.Now()); state != api.ImageLifecycleActive {
+		return fmt.Errorf("image %s is %s", im.ID, state)
+	}
+
+	return nil
+}
+
+func convertToTimePtr(t *time.Time) *api.Time {
+	if t == nil {
+		return nil
+	}
+
+	out := api.Time(*t)
+
+	return &out
+}
+
+func convertFromTimePtr(t *api.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	out := time.Time(*t)
+
+	return &out
+}
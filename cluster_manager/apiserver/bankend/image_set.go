@@ -0,0 +1,265 @@
+package bankend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	unitv4 "github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
+	"github.com/upmio/dbscale-kube/pkg/structs"
+)
+
+// ListSets 按类型和版本聚合各架构的镜像
+func (b *bankendImage) ListSets(ctx context.Context, opts api.ImageListOptions) (api.ImageSetsResponse, error) {
+	list, err := b.m.List(selectorFromOption(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make(map[string]*api.ImageSet)
+	keys := make([]string, 0, len(list))
+
+	for i := range list {
+		iv := api.ImageVersion(list[i].ImageVersion)
+		iv.Arch = ""
+		iv.ID = iv.String()
+
+		set, ok := sets[iv.ID]
+		if !ok {
+			set = &api.ImageSet{ImageVersion: iv}
+			sets[iv.ID] = set
+			keys = append(keys, iv.ID)
+		}
+
+		set.Variants = append(set.Variants, convertToImageAPI(list[i]))
+	}
+
+	sort.Strings(keys)
+
+	out := make(api.ImageSetsResponse, 0, len(keys))
+
+	for _, key := range keys {
+		set := sets[key]
+
+		sort.Slice(set.Variants, func(i, j int) bool {
+			return set.Variants[i].Arch < set.Variants[j].Arch
+		})
+
+		for _, v := range set.Variants {
+			set.Archs = append(set.Archs, v.Arch)
+		}
+
+		out = append(out, *set)
+	}
+
+	return out, nil
+}
+
+// imageVariants 返回与 im 同类型同版本、可调度且未弃用的各架构镜像，im 本身总是包含在内
+func imageVariants(images imageGetter, im model.Image) (map[string]model.Image, error) {
+	list, err := images.List(map[string]string{"type": im.Type})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	out := map[string]model.Image{im.Arch: im}
+
+	for _, v := range list {
+		if v.Major != im.Major || v.Minor != im.Minor || v.Patch != im.Patch || v.Dev != im.Dev {
+			continue
+		}
+
+		if v.Unschedulable || v.Lifecycle(now) != api.ImageLifecycleActive {
+			continue
+		}
+
+		if _, ok := out[v.Arch]; !ok {
+			out[v.Arch] = v
+		}
+	}
+
+	return out, nil
+}
+
+// resolveImageSet 解析多架构服务的镜像，id 可以是 latest、不含架构的镜像集合 ID 或某个架构的镜像 ID，
+// 返回架构名排序第一的镜像作为服务记录的镜像
+func resolveImageSet(images imageGetter, typ, id string) (model.Image, error) {
+	var (
+		im  model.Image
+		err error
+	)
+
+	switch {
+	case strings.ToLower(id) == structs.ImageLatestTag:
+		im, err = latestImageSet(images, typ)
+
+	case strings.Contains(id, "-"):
+		im, err = images.Get(id)
+
+	default:
+		im, err = getImageSet(images, id)
+	}
+	if err != nil {
+		return im, err
+	}
+
+	variants, err := imageVariants(images, im)
+	if err != nil {
+		return im, err
+	}
+
+	archs := make([]string, 0, len(variants))
+	for arch := range variants {
+		archs = append(archs, arch)
+	}
+
+	sort.Strings(archs)
+
+	return variants[archs[0]], nil
+}
+
+func latestImageSet(images imageGetter, typ string) (model.Image, error) {
+	list, err := images.List(map[string]string{"type": typ})
+	if err != nil {
+		return model.Image{}, err
+	}
+
+	now := time.Now()
+	found := false
+	latest := model.Image{}
+
+	for _, im := range list {
+		if im.Unschedulable || im.Lifecycle(now) != api.ImageLifecycleActive {
+			continue
+		}
+
+		if !found || compareImageVersion(api.ImageVersion(im.ImageVersion), api.ImageVersion(latest.ImageVersion)) > 0 {
+			latest = im
+			found = true
+		}
+	}
+
+	if !found {
+		return latest, fmt.Errorf("not found schedulable %s image", typ)
+	}
+
+	return latest, nil
+}
+
+func getImageSet(images imageGetter, id string) (model.Image, error) {
+	iv, err := api.ParseImageVersion(id)
+	if err != nil {
+		return model.Image{}, err
+	}
+
+	list, err := images.List(map[string]string{"type": iv.Type})
+	if err != nil {
+		return model.Image{}, err
+	}
+
+	for _, im := range list {
+		if im.Major == iv.Major && im.Minor == iv.Minor && im.Patch == iv.Patch && im.Dev == iv.Dev {
+			return im, nil
+		}
+	}
+
+	return model.Image{}, fmt.Errorf("not found image set %s", id)
+}
+
+// unitVariant 多架构服务中单元使用的架构及镜像，version 为空时沿用模板的 MainImageVerison
+type unitVariant struct {
+	arch    string
+	version string
+	image   string
+}
+
+func setUnitVariant(unit *unitv4.Unit, v unitVariant) {
+	if unit.Spec.Template.Spec.NodeSelector == nil {
+		unit.Spec.Template.Spec.NodeSelector = map[string]string{}
+	}
+
+	unit.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable] = v.arch
+	if v.version != "" {
+		unit.Spec.MainImageVerison = v.version
+	}
+
+	for i := range unit.Spec.Template.Spec.Containers {
+		if unit.Spec.Template.Spec.Containers[i].Name == unit.Spec.MainContainerName {
+			unit.Spec.Template.Spec.Containers[i].Image = v.image
+		}
+	}
+}
+
+// nodeAffinityValues returns the values of the required node affinity on key,nil if not found
+func nodeAffinityValues(spec corev1.PodSpec, key string) []string {
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil ||
+		spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return nil
+	}
+
+	for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == key && expr.Operator == corev1.NodeSelectorOpIn {
+				return expr.Values
+			}
+		}
+	}
+
+	return nil
+}
+
+// planUnitVariants 在 injectSchedulerInfo 过滤后的集群及存储中，按各架构主机可放置的单元数为每个单元分配架构。
+// tmpl 使用 image 的模板(可能固定了模板版本)，同架构的单元沿用，其他架构使用对应镜像的当前模板
+func (beApp *bankendApp) planUnitVariants(tmpl unitv4.Unit, image model.Image, replicas int, requests api.ResourceRequirements, ha bool, site model.Site) ([]unitVariant, error) {
+	variants, err := imageVariants(beApp.images, image)
+	if err != nil {
+		return nil, err
+	}
+
+	iface, err := beApp.zone.siteInterface(image.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := archHostFilter{
+		clusters: nodeAffinityValues(tmpl.Spec.Template.Spec, labelCluster),
+		storages: nodeAffinityValues(tmpl.Spec.Template.Spec, labelRemoteStorage),
+		cpu:      requests.CPU,
+		memory:   requests.Memory,
+		ha:       ha,
+	}
+
+	slots, err := filterHostArchs(iface, filter, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	archs, err := assignUnitArchs(slots, replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]unitVariant, len(archs))
+
+	for i, arch := range archs {
+		v := variants[arch]
+
+		out[i] = unitVariant{
+			arch:  arch,
+			image: v.Reference(site.ImageRegistry, site.ProjectName),
+		}
+
+		if arch != image.Arch {
+			out[i].version = v.VersionWithArch()
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,141 @@
+package bankend
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+	unitv4 "github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
+)
+
+func TestResolveImageSet(t *testing.T) {
+	mi := model.NewFakeModels().ModelImage()
+
+	ids := make(map[string]string)
+
+	for _, arch := range []string{"arm64", "amd64"} {
+		id, _, err := mi.Insert(model.Image{ImageVersion: model.ImageVersion{Type: "mysql", Arch: arch, Major: 5, Minor: 7, Patch: 25, Dev: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids[arch] = id
+	}
+
+	if _, _, err := mi.Insert(model.Image{ImageVersion: model.ImageVersion{Type: "mysql", Arch: "arm64", Major: 5, Minor: 7, Patch: 25, Dev: 2}, Unschedulable: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"latest", "mysql:5.7.25.1", ids["arm64"]} {
+		im, err := resolveImageSet(mi, "mysql", id)
+		if err != nil {
+			t.Fatalf("%s: %s", id, err)
+		}
+
+		if im.Arch != "amd64" || im.Dev != 1 {
+			t.Errorf("%s: unexpected image %s", id, im.ImageWithArch())
+		}
+
+		variants, err := imageVariants(mi, im)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(variants) != 2 || variants["arm64"].Dev != 1 {
+			t.Errorf("%s: unexpected variants %v", id, variants)
+		}
+	}
+}
+
+func TestAssignUnitArchs(t *testing.T) {
+	cases := []struct {
+		slots    map[string]int
+		replicas int
+		want     []string
+	}{
+		{map[string]int{"amd64": 2, "arm64": 1}, 3, []string{"amd64", "amd64", "arm64"}},
+		{map[string]int{"amd64": 1, "arm64": 1}, 2, []string{"amd64", "arm64"}},
+		{map[string]int{"arm64": 2}, 2, []string{"arm64", "arm64"}},
+		{map[string]int{"arm64": 1}, 2, nil},
+	}
+
+	for i, c := range cases {
+		got, err := assignUnitArchs(c.slots, c.replicas)
+		if c.want == nil {
+			if err == nil {
+				t.Errorf("%d: expected error but got %v", i, got)
+			}
+			continue
+		}
+
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%d: expected %v but got %v,%v", i, c.want, got, err)
+		}
+	}
+}
+
+func TestArchHostFilterUnitSlots(t *testing.T) {
+	newHost := func(cluster, storage, cpu, memory string, ready, unschedulable bool) *hostv1.Host {
+		h := &hostv1.Host{}
+		h.Labels = map[string]string{labelCluster: cluster, labelRemoteStorage: storage}
+		h.Spec.Unschedulable = unschedulable
+		h.Status.NodeReady = ready
+		h.Status.Allocatable.Cpu = resource.MustParse(cpu)
+		h.Status.Allocatable.Memery = resource.MustParse(memory)
+		h.Status.Allocatable.Pods = resource.MustParse("110")
+
+		return h
+	}
+
+	filter := archHostFilter{clusters: []string{"c1"}, cpu: 1000, memory: 2048}
+
+	cases := []struct {
+		name   string
+		filter archHostFilter
+		host   *hostv1.Host
+		want   int64
+	}{
+		{"fit", filter, newHost("c1", "", "4", "6Gi", true, false), 3},
+		{"ha", archHostFilter{clusters: []string{"c1"}, cpu: 1000, ha: true}, newHost("c1", "", "4", "6Gi", true, false), 1},
+		{"not enough memory", filter, newHost("c1", "", "4", "1Gi", true, false), 0},
+		{"other cluster", filter, newHost("c2", "", "4", "6Gi", true, false), 0},
+		{"not ready", filter, newHost("c1", "", "4", "6Gi", false, false), 0},
+		{"unschedulable", filter, newHost("c1", "", "4", "6Gi", true, true), 0},
+		{"remote storage", archHostFilter{clusters: []string{"c1"}, storages: []string{"san1"}}, newHost("c1", "san2", "4", "6Gi", true, false), 0},
+	}
+
+	for _, c := range cases {
+		if got := c.filter.unitSlots(c.host); got != c.want {
+			t.Errorf("%s: expected %d but got %d", c.name, c.want, got)
+		}
+	}
+}
+
+func TestSetUnitVariant(t *testing.T) {
+	unit := &unitv4.Unit{}
+	unit.Spec.MainContainerName = "mysql"
+	unit.Spec.MainImageVerison = "5.7.25.1-amd64-r2"
+	unit.Spec.Template.Spec.Containers = []corev1.Container{{Name: "mysql"}, {Name: "exporter", Image: "exporter"}}
+
+	pinned := unit.DeepCopy()
+	setUnitVariant(pinned, unitVariant{arch: "amd64", image: "reg/dbscale/mysql:5.7.25.1-amd64"})
+
+	if pinned.Spec.MainImageVerison != "5.7.25.1-amd64-r2" ||
+		pinned.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable] != "amd64" ||
+		pinned.Spec.Template.Spec.Containers[0].Image != "reg/dbscale/mysql:5.7.25.1-amd64" ||
+		pinned.Spec.Template.Spec.Containers[1].Image != "exporter" {
+		t.Errorf("unexpected unit of the pinned arch %+v", pinned.Spec)
+	}
+
+	other := unit.DeepCopy()
+	setUnitVariant(other, unitVariant{arch: "arm64", version: "5.7.25.1-arm64", image: "reg/dbscale/mysql:5.7.25.1-arm64"})
+
+	if other.Spec.MainImageVerison != "5.7.25.1-arm64" ||
+		other.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable] != "arm64" {
+		t.Errorf("unexpected unit of another arch %+v", other.Spec)
+	}
+}
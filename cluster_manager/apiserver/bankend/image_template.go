@@ -0,0 +1,714 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	unitv4 "github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
+	"github.com/upmio/dbscale-kube/pkg/zone"
+	"github.com/upmio/dbscale-kube/pkg/zone/site"
+)
+
+// templateRevisionLock 保证同一进程内版本号连续，数据库中 image_id+revision 唯一
+var templateRevisionLock sync.Mutex
+
+type templateSnapshot struct {
+	keySets string
+	config  string
+	scripts map[string]string
+}
+
+// templateRevisionVersion 单元的 MainImageVerison，固定版本的单元使用该版本的配置模板及脚本
+func templateRevisionVersion(im model.Image, revision int) string {
+	if revision <= 0 {
+		return im.VersionWithArch()
+	}
+
+	return fmt.Sprintf("%s-r%d", im.VersionWithArch(), revision)
+}
+
+func templateConfigMapName(im model.Image, revision int) string {
+	return fmt.Sprintf("%s-%s-config-template", im.Type, templateRevisionVersion(im, revision))
+}
+
+func templateScriptMapName(im model.Image, revision int, typ string) string {
+	return fmt.Sprintf("%s-%s-%s", im.Type, templateRevisionVersion(im, revision), typ)
+}
+
+// currentTemplate 读取站点中镜像当前的配置模板及指定类型的脚本
+func currentTemplate(iface site.Interface, im model.Image, scriptTypes ...string) (templateSnapshot, *corev1.ConfigMap, error) {
+	snap := templateSnapshot{
+		keySets: im.KeySets,
+		config:  im.ConfigTemplate,
+		scripts: make(map[string]string, len(scriptTypes)),
+	}
+
+	cm, err := iface.ConfigMaps().Get(metav1.NamespaceDefault, templateConfigMapName(im, 0))
+	if err != nil {
+		return snap, nil, err
+	}
+
+	if content, ok := cm.Data[unitv4.ConfigDataTab]; ok {
+		snap.config = content
+	}
+
+	for _, typ := range scriptTypes {
+		script, err := iface.ConfigMaps().Get(metav1.NamespaceDefault, templateScriptMapName(im, 0, typ))
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return snap, cm, err
+		}
+
+		snap.scripts[typ] = script.Data[unitv4.ScriptDataTab]
+	}
+
+	return snap, cm, nil
+}
+
+// recordTemplateRevision 记录修改后的模板为新版本，没有历史版本时先记录修改前的模板为版本1，
+// 内容与最新版本相同时不生成新版本
+func recordTemplateRevision(m model.ModelImageTemplate, im model.Image, before, after templateSnapshot, reason, user string) (int, error) {
+	templateRevisionLock.Lock()
+	defer templateRevisionLock.Unlock()
+
+	list, err := m.ListTemplateRevisions(im.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest model.ImageTemplateRevision
+
+	if len(list) > 0 {
+		latest = list[0]
+	} else {
+		latest, err = newTemplateRevision(im, 1, before, "initial", user)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := m.InsertTemplateRevision(latest); err != nil {
+			return 0, err
+		}
+	}
+
+	scripts, err := decodeTemplateScripts(latest.Scripts)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := after.keySets != latest.KeySets || after.config != latest.ConfigTemplate
+
+	for typ, content := range after.scripts {
+		if old, ok := scripts[typ]; !ok || old != content {
+			scripts[typ] = content
+			changed = true
+		}
+	}
+
+	if !changed {
+		return latest.Revision, nil
+	}
+
+	after.scripts = scripts
+
+	r, err := newTemplateRevision(im, latest.Revision+1, after, reason, user)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = m.InsertTemplateRevision(r)
+
+	return r.Revision, err
+}
+
+func newTemplateRevision(im model.Image, revision int, snap templateSnapshot, reason, user string) (model.ImageTemplateRevision, error) {
+	scripts, err := json.Marshal(snap.scripts)
+	if err != nil {
+		return model.ImageTemplateRevision{}, err
+	}
+
+	return model.ImageTemplateRevision{
+		Image:          im.ID,
+		Revision:       revision,
+		KeySets:        snap.keySets,
+		ConfigTemplate: snap.config,
+		Scripts:        string(scripts),
+		Reason:         reason,
+		Editor:         newCreateEditor(user),
+	}, nil
+}
+
+func decodeTemplateScripts(data string) (map[string]string, error) {
+	scripts := make(map[string]string)
+
+	if data == "" {
+		return scripts, nil
+	}
+
+	err := json.Unmarshal([]byte(data), &scripts)
+
+	return scripts, err
+}
+
+// SetImageTemplate 修改镜像配置模板中参数的值，每次修改记录为新的模板版本
+func (b *bankendImage) SetImageTemplate(ctx context.Context, id string, opts api.ConfigTemplateOptions) (api.TaskObjectResponse, error) {
+	im, err := b.m.Get(id)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	ks, err := im.ConvertToKeySets()
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+loop:
+	for _, kv := range opts.Keysets {
+		for i := range ks {
+			if ks[i].Key != kv.Key {
+				continue
+			}
+
+			if !ks[i].CanSet {
+				return api.TaskObjectResponse{}, fmt.Errorf("keyset %s can not be set", kv.Key)
+			}
+
+			continue loop
+		}
+
+		return api.TaskObjectResponse{}, fmt.Errorf("not found keyset %s", kv.Key)
+	}
+
+	iface, err := b.zone.SiteInterface(im.SiteID)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	before, cm, err := currentTemplate(iface, im)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	configer, err := config.NewConfigData("ini", []byte(before.config))
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	for _, kv := range opts.Keysets {
+		if err := configer.Set(kv.Key, kv.Value); err != nil {
+			return api.TaskObjectResponse{}, fmt.Errorf("update key: %s err: %s", kv.Key, err)
+		}
+	}
+
+	content, err := marshal(configer)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	task, err := b.m.InsertImageTask(im, model.ActionImageTemplateEdit)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	after := before
+	after.config = string(content)
+
+	err = applyTemplate(b.m, iface, cm, &im, after)
+	if err == nil {
+		_, err = recordTemplateRevision(b.templates, im, before, after, opts.Reason, opts.User)
+	}
+
+	if uerr := b.m.UpdateImageTask(nil, taskUpdate(task, err)); uerr != nil {
+		return api.TaskObjectResponse{}, uerr
+	}
+
+	return api.TaskObjectResponse{
+		ObjectID:   im.ID,
+		ObjectName: im.ImageWithArch(),
+		TaskID:     task,
+	}, err
+}
+
+// SyncImageScripts 从镜像目录同步脚本到站点，每次修改记录为新的模板版本
+func (b *bankendImage) SyncImageScripts(ctx context.Context, imageID string, opts api.ImageScriptsQuery) error {
+	im, err := b.m.Get(imageID)
+	if err != nil {
+		return err
+	}
+
+	iface, err := b.zone.SiteInterface(im.SiteID)
+	if err != nil {
+		return err
+	}
+
+	before, cm, err := currentTemplate(iface, im, opts.Type)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := before.scripts[opts.Type]; !ok {
+		return fmt.Errorf("Image: %s doesn't have script configmap: %s", imageID, templateScriptMapName(im, 0, opts.Type))
+	}
+
+	script, err := getScriptFromFile(baseImageDir, im.ImageTemplateFileNameWithArch(), opts.Type)
+	if err != nil {
+		return err
+	}
+
+	after := before
+	after.scripts = map[string]string{opts.Type: script}
+
+	err = applyTemplate(b.m, iface, cm, &im, after)
+	if err != nil {
+		return err
+	}
+
+	_, err = recordTemplateRevision(b.templates, im, before, after, opts.Reason, opts.User)
+
+	return err
+}
+
+// applyTemplate 更新站点中镜像当前的配置模板、脚本及数据库中的模板
+func applyTemplate(images modelImage, iface site.Interface, cm *corev1.ConfigMap, im *model.Image, snap templateSnapshot) error {
+	if cm.Data[unitv4.ConfigDataTab] != snap.config {
+		clone := cm.DeepCopy()
+		clone.Data[unitv4.ConfigDataTab] = snap.config
+
+		if _, err := iface.ConfigMaps().Update(clone.Namespace, clone); err != nil {
+			return err
+		}
+	}
+
+	for typ, content := range snap.scripts {
+		script, err := iface.ConfigMaps().Get(metav1.NamespaceDefault, templateScriptMapName(*im, 0, typ))
+		if err != nil {
+			return err
+		}
+
+		if script.Data[unitv4.ScriptDataTab] == content {
+			continue
+		}
+
+		clone := script.DeepCopy()
+		clone.Data[unitv4.ScriptDataTab] = content
+
+		if _, err := iface.ConfigMaps().Update(clone.Namespace, clone); err != nil {
+			return err
+		}
+	}
+
+	if im.KeySets == snap.keySets && im.ConfigTemplate == snap.config {
+		return nil
+	}
+
+	im.KeySets = snap.keySets
+	im.ConfigTemplate = snap.config
+	im.ModifiedAt = time.Now()
+
+	return images.UpdateTemplate(*im)
+}
+
+type templateApps interface {
+	ListApps(ctx context.Context, id, name, subscriptionId string, detail bool) (api.AppsResponse, error)
+	PinTemplate(ctx context.Context, id, serviceType string, im model.Image, revision int, user string) (api.TaskObjectResponse, error)
+}
+
+func NewImageTemplateBankend(zone zone.ZoneInterface, images modelImage, templates model.ModelImageTemplate, apps templateApps) *bankendImageTemplate {
+	return &bankendImageTemplate{
+		m:         images,
+		templates: templates,
+		zone:      zone,
+		apps:      apps,
+	}
+}
+
+// bankendImageTemplate 镜像模板版本的查询、对比、回滚及服务固定版本
+type bankendImageTemplate struct {
+	m         modelImage
+	templates model.ModelImageTemplate
+
+	zone zone.ZoneInterface
+	apps templateApps
+}
+
+func (b *bankendImageTemplate) ListRevisions(ctx context.Context, id string) (api.ImageTemplateRevisionsResponse, error) {
+	im, err := b.m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := b.templates.ListTemplateRevisions(im.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(api.ImageTemplateRevisionsResponse, len(list))
+
+	for i := range list {
+		out[i] = convertToTemplateRevisionAPI(im, list[i], i == 0)
+		out[i].KeySets, out[i].ConfigTemplate, out[i].Scripts = "", "", nil
+	}
+
+	return out, nil
+}
+
+func (b *bankendImageTemplate) GetRevision(ctx context.Context, id string, revision int) (api.ImageTemplateRevision, error) {
+	im, r, current, err := b.getRevision(id, revision)
+	if err != nil {
+		return api.ImageTemplateRevision{}, err
+	}
+
+	return convertToTemplateRevisionAPI(im, r, r.Revision == current), nil
+}
+
+// getRevision 查询镜像的模板版本，revision 为0时返回最新版本，同时返回最新版本号
+func (b *bankendImageTemplate) getRevision(id string, revision int) (model.Image, model.ImageTemplateRevision, int, error) {
+	im, err := b.m.Get(id)
+	if err != nil {
+		return im, model.ImageTemplateRevision{}, 0, err
+	}
+
+	list, err := b.templates.ListTemplateRevisions(im.ID)
+	if err != nil {
+		return im, model.ImageTemplateRevision{}, 0, err
+	}
+
+	if len(list) == 0 {
+		return im, model.ImageTemplateRevision{}, 0, fmt.Errorf("image %s has no template revision", im.ID)
+	}
+
+	if revision == 0 {
+		return im, list[0], list[0].Revision, nil
+	}
+
+	for i := range list {
+		if list[i].Revision == revision {
+			return im, list[i], list[0].Revision, nil
+		}
+	}
+
+	return im, model.ImageTemplateRevision{}, 0, model.NewNotFound("image template revision", fmt.Sprintf("%s:%d", im.ID, revision))
+}
+
+// Diff 并排对比两个模板版本，版本号为0表示最新版本
+func (b *bankendImageTemplate) Diff(ctx context.Context, id string, from, to int) (api.ImageTemplateDiff, error) {
+	im, left, _, err := b.getRevision(id, from)
+	if err != nil {
+		return api.ImageTemplateDiff{}, err
+	}
+
+	_, right, _, err := b.getRevision(id, to)
+	if err != nil {
+		return api.ImageTemplateDiff{}, err
+	}
+
+	out := api.ImageTemplateDiff{
+		Image:   api.ImageVersion(im.ImageVersion),
+		From:    left.Revision,
+		To:      right.Revision,
+		Config:  diffLines(left.ConfigTemplate, right.ConfigTemplate),
+		Scripts: make(map[string][]api.DiffLine),
+	}
+
+	out.KeySets, err = diffKeySets(right.KeySets, left.ConfigTemplate, right.ConfigTemplate)
+	if err != nil {
+		return out, err
+	}
+
+	ls, err := decodeTemplateScripts(left.Scripts)
+	if err != nil {
+		return out, err
+	}
+
+	rs, err := decodeTemplateScripts(right.Scripts)
+	if err != nil {
+		return out, err
+	}
+
+	for typ := range ls {
+		out.Scripts[typ] = diffLines(ls[typ], rs[typ])
+	}
+	for typ := range rs {
+		if _, ok := ls[typ]; !ok {
+			out.Scripts[typ] = diffLines("", rs[typ])
+		}
+	}
+
+	return out, nil
+}
+
+// Rollback 使用指定版本的内容作为镜像当前的模板，记录为新的版本
+func (b *bankendImageTemplate) Rollback(ctx context.Context, id string, opts api.ImageTemplateRollbackOptions) (api.ImageTemplateRevision, error) {
+	im, r, _, err := b.getRevision(id, opts.Revision)
+	if err != nil {
+		return api.ImageTemplateRevision{}, err
+	}
+
+	scripts, err := decodeTemplateScripts(r.Scripts)
+	if err != nil {
+		return api.ImageTemplateRevision{}, err
+	}
+
+	types := make([]string, 0, len(scripts))
+	for typ := range scripts {
+		types = append(types, typ)
+	}
+
+	iface, err := b.zone.SiteInterface(im.SiteID)
+	if err != nil {
+		return api.ImageTemplateRevision{}, err
+	}
+
+	before, cm, err := currentTemplate(iface, im, types...)
+	if err != nil {
+		return api.ImageTemplateRevision{}, err
+	}
+
+	after := templateSnapshot{
+		keySets: r.KeySets,
+		config:  r.ConfigTemplate,
+		scripts: scripts,
+	}
+
+	err = applyTemplate(b.m, iface, cm, &im, after)
+	if err != nil {
+		return api.ImageTemplateRevision{}, err
+	}
+
+	reason := fmt.Sprintf("rollback to revision %d", r.Revision)
+	if opts.Reason != "" {
+		reason = reason + ": " + opts.Reason
+	}
+
+	revision, err := recordTemplateRevision(b.templates, im, before, after, reason, opts.User)
+	if err != nil {
+		return api.ImageTemplateRevision{}, err
+	}
+
+	return b.GetRevision(ctx, im.ID, revision)
+}
+
+// Pin 固定服务使用的模板版本，之后镜像模板的修改不影响该服务，直到再次修改固定的版本
+func (b *bankendImageTemplate) Pin(ctx context.Context, app string, opts api.AppTemplatePinOptions) (api.TaskObjectResponse, error) {
+	apps, err := b.apps.ListApps(ctx, app, "", "", false)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	if len(apps) == 0 {
+		return api.TaskObjectResponse{}, model.NewNotFound("app", app)
+	}
+
+	group := appImageGroup(apps[0].Spec, opts.Type)
+	if group == nil {
+		return api.TaskObjectResponse{}, fmt.Errorf("app %s has no %s service", app, opts.Type)
+	}
+
+	im, err := b.m.Get(group.Image.ID)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
+	if opts.Revision > 0 {
+		_, r, _, err := b.getRevision(im.ID, opts.Revision)
+		if err != nil {
+			return api.TaskObjectResponse{}, err
+		}
+
+		iface, err := b.zone.SiteInterface(im.SiteID)
+		if err != nil {
+			return api.TaskObjectResponse{}, err
+		}
+
+		if err := ensureRevisionConfigMaps(iface, im, r); err != nil {
+			return api.TaskObjectResponse{}, err
+		}
+	}
+
+	return b.apps.PinTemplate(ctx, app, opts.Type, im, opts.Revision, opts.User)
+}
+
+// ensureRevisionConfigMaps 创建模板版本对应的配置模板及脚本，版本内容不变，已存在时不更新
+func ensureRevisionConfigMaps(iface site.Interface, im model.Image, r model.ImageTemplateRevision) error {
+	current, err := iface.ConfigMaps().Get(metav1.NamespaceDefault, templateConfigMapName(im, 0))
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]string, len(current.Data))
+	for k, v := range current.Data {
+		data[k] = v
+	}
+	data[unitv4.ConfigDataTab] = r.ConfigTemplate
+
+	configMaps := []*corev1.ConfigMap{newTemplateConfigMap(templateConfigMapName(im, r.Revision), data)}
+
+	scripts, err := decodeTemplateScripts(r.Scripts)
+	if err != nil {
+		return err
+	}
+
+	for typ, content := range scripts {
+		configMaps = append(configMaps, newTemplateConfigMap(templateScriptMapName(im, r.Revision, typ), map[string]string{
+			unitv4.ScriptDataTab: content,
+		}))
+	}
+
+	for _, cm := range configMaps {
+		_, err := iface.ConfigMaps().Get(cm.Namespace, cm.Name)
+		if errors.IsNotFound(err) {
+			_, err = iface.ConfigMaps().Create(cm.Namespace, cm)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newTemplateConfigMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Data: data,
+	}
+}
+
+func convertToTemplateRevisionAPI(im model.Image, r model.ImageTemplateRevision, current bool) api.ImageTemplateRevision {
+	scripts, _ := decodeTemplateScripts(r.Scripts)
+
+	return api.ImageTemplateRevision{
+		Image:          api.ImageVersion(im.ImageVersion),
+		Revision:       r.Revision,
+		Reason:         r.Reason,
+		Current:        current,
+		KeySets:        r.KeySets,
+		ConfigTemplate: r.ConfigTemplate,
+		Scripts:        scripts,
+		Created:        api.NewEditor(r.CreatedUser, r.CreatedAt),
+	}
+}
+
+// diffKeySets 对比两个配置模板中参数的值
+func diffKeySets(keySets, from, to string) ([]api.KeySetChange, error) {
+	ks, err := model.Image{KeySets: keySets}.ConvertToKeySets()
+	if err != nil {
+		return nil, err
+	}
+
+	left, err := config.NewConfigData("ini", []byte(from))
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := config.NewConfigData("ini", []byte(to))
+	if err != nil {
+		return nil, err
+	}
+
+	out := []api.KeySetChange{}
+
+	for _, k := range ks {
+		l, _ := beegoConfigString(left, k.Key)
+		r, _ := beegoConfigString(right, k.Key)
+
+		if l != r {
+			out = append(out, api.KeySetChange{Key: k.Key, From: l, To: r})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+
+	return out, nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines 按行计算最长公共子序列，相邻的删除和增加合并为修改行
+func diffLines(from, to string) []api.DiffLine {
+	a, b := splitLines(from), splitLines(to)
+	n, m := len(a), len(b)
+
+	// lcs[i*(m+1)+j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j]
+			default:
+				lcs[i*(m+1)+j] = lcs[i*(m+1)+j+1]
+			}
+		}
+	}
+
+	out := make([]api.DiffLine, 0, n+m)
+	var dels, adds []int
+
+	flush := func() {
+		k := 0
+		for ; k < len(dels) && k < len(adds); k++ {
+			out = append(out, api.DiffLine{Op: api.DiffChange, Left: a[dels[k]], LeftLine: dels[k] + 1, Right: b[adds[k]], RightLine: adds[k] + 1})
+		}
+		for _, i := range dels[k:] {
+			out = append(out, api.DiffLine{Op: api.DiffDelete, Left: a[i], LeftLine: i + 1})
+		}
+		for _, j := range adds[k:] {
+			out = append(out, api.DiffLine{Op: api.DiffAdd, Right: b[j], RightLine: j + 1})
+		}
+
+		dels, adds = dels[:0], adds[:0]
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			flush()
+			out = append(out, api.DiffLine{Op: api.DiffEqual, Left: a[i], LeftLine: i + 1, Right: b[j], RightLine: j + 1})
+			i++
+			j++
+		case j == m || (i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+			dels = append(dels, i)
+			i++
+		default:
+			adds = append(adds, j)
+			j++
+		}
+	}
+
+	flush()
+
+	return out
+}
@@ -0,0 +1,64 @@
+package bankend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+func TestDiffLines(t *testing.T) {
+	from := "[mysqld]\nport=3306\nmax_connections=100\nskip_name_resolve=1\n"
+	to := "[mysqld]\nport=3306\nmax_connections=200\nskip_name_resolve=1\nlog_bin=ON\n"
+
+	want := []api.DiffLine{
+		{Op: api.DiffEqual, Left: "[mysqld]", LeftLine: 1, Right: "[mysqld]", RightLine: 1},
+		{Op: api.DiffEqual, Left: "port=3306", LeftLine: 2, Right: "port=3306", RightLine: 2},
+		{Op: api.DiffChange, Left: "max_connections=100", LeftLine: 3, Right: "max_connections=200", RightLine: 3},
+		{Op: api.DiffEqual, Left: "skip_name_resolve=1", LeftLine: 4, Right: "skip_name_resolve=1", RightLine: 4},
+		{Op: api.DiffAdd, Right: "log_bin=ON", RightLine: 5},
+	}
+
+	if got := diffLines(from, to); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v but got %+v", want, got)
+	}
+
+	if got := diffLines("a\nb", ""); len(got) != 2 || got[0].Op != api.DiffDelete || got[1].LeftLine != 2 {
+		t.Errorf("unexpected %+v", got)
+	}
+}
+
+func TestRecordTemplateRevision(t *testing.T) {
+	m := model.NewFakeModels().ModelImageTemplate()
+	im := model.Image{ImageVersion: model.ImageVersion{ID: "mysql:5.7.25.1-amd64"}}
+
+	v1 := templateSnapshot{config: "a=1", scripts: map[string]string{"script": "echo 1"}}
+	v2 := templateSnapshot{config: "a=2", scripts: map[string]string{}}
+
+	revision, err := recordTemplateRevision(m, im, v1, v2, "tune", "admin")
+	if err != nil || revision != 2 {
+		t.Fatalf("expected revision 2,got %d %v", revision, err)
+	}
+
+	// no new revision when nothing changed
+	revision, err = recordTemplateRevision(m, im, v2, v2, "noop", "admin")
+	if err != nil || revision != 2 {
+		t.Fatalf("expected revision 2,got %d %v", revision, err)
+	}
+
+	r, err := m.GetTemplateRevision(im.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scripts, _ := decodeTemplateScripts(r.Scripts)
+	if r.ConfigTemplate != "a=2" || scripts["script"] != "echo 1" || r.Reason != "tune" || r.CreatedUser != "admin" {
+		t.Errorf("unexpected revision %+v", r)
+	}
+
+	first, err := m.GetTemplateRevision(im.ID, 1)
+	if err != nil || first.ConfigTemplate != "a=1" {
+		t.Errorf("unexpected initial revision %+v %v", first, err)
+	}
+}
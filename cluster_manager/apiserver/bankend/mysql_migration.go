@@ -0,0 +1,580 @@
+package bankend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	stderror "github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/structs"
+)
+
+const (
+	ActionSchemaMigrate       = "schema-migrate"
+	ActionSchemaMigrateDryRun = "schema-migrate-dry-run"
+)
+
+func NewMigrationBankend(apps migrationApps, m modelSchemaMigration, tasks manifestTasks, onlineTool string) *bankendMigration {
+	return &bankendMigration{
+		apps:       apps,
+		m:          m,
+		tasks:      tasks,
+		onlineTool: onlineTool,
+	}
+}
+
+// bankendMigration 同一个数据库同时只有一个迁移任务，由 tbl_schema_migration_lock 保证
+type bankendMigration struct {
+	apps       migrationApps
+	m          modelSchemaMigration
+	tasks      manifestTasks
+	onlineTool string
+}
+
+// migrationApps 由bankendApp实现
+type migrationApps interface {
+	CheckAppAndSubscription(ctx context.Context, appId, subscriptionId string) error
+	ListAppDBSchema(ctx context.Context, appID string) (api.DBSchemaResponse, error)
+	runAppDBCmd(appID, unitID string, cmd []string) error
+	dryRunUnit(appID, unitID string) (string, error)
+}
+
+type modelSchemaMigration interface {
+	SaveSchemaMigration(m model.SchemaMigration) error
+	DeleteSchemaMigration(app, schema string, version int64) error
+	ListSchemaMigrations(app, schema string) ([]model.SchemaMigration, error)
+	ListSchemaMigrationsByState(state string) ([]model.SchemaMigration, error)
+
+	LockSchemaMigrations(lock model.SchemaMigrationLock) (bool, error)
+	UnlockSchemaMigrations(app, schema string) error
+	ListSchemaMigrationLocks() ([]model.SchemaMigrationLock, error)
+}
+
+func migrationChecksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (b *bankendMigration) acquire(app, schema string) error {
+	ok, err := b.m.LockSchemaMigrations(model.SchemaMigrationLock{
+		App:       app,
+		Schema:    schema,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return stderror.Errorf("migrations of schema %s are running", schema)
+	}
+
+	return nil
+}
+
+func (b *bankendMigration) release(app, schema string) {
+	if err := b.m.UnlockSchemaMigrations(app, schema); err != nil {
+		klog.Errorf("unlock migrations of app %s schema %s:%s", app, schema, err)
+	}
+}
+
+// RestoreMigrations 进程退出时正在执行的迁移可能已部分执行，标记为中断，不自动重新执行，
+// 由用户确认后通过 resolve_interrupted 重新执行，并释放进程退出时未释放的锁
+func (b *bankendMigration) RestoreMigrations() error {
+	list, err := b.m.ListSchemaMigrationsByState(api.MigrationRunning)
+	if err != nil {
+		return err
+	}
+
+	for _, sm := range list {
+		sm.State = api.MigrationInterrupted
+		sm.Error = "interrupted by apiserver restart,check the schema before applying again"
+
+		if err := b.m.SaveSchemaMigration(sm); err != nil {
+			return err
+		}
+
+		klog.Warningf("migration %d of app %s schema %s was interrupted", sm.Version, sm.App, sm.Schema)
+	}
+
+	locks, err := b.m.ListSchemaMigrationLocks()
+	if err != nil {
+		return err
+	}
+
+	for _, lock := range locks {
+		if err := b.m.UnlockSchemaMigrations(lock.App, lock.Schema); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *bankendMigration) checkSchema(ctx context.Context, app, schema, subscriptionID string) error {
+	if err := b.apps.CheckAppAndSubscription(ctx, app, subscriptionID); err != nil {
+		return err
+	}
+
+	schemas, err := b.apps.ListAppDBSchema(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	for i := range schemas {
+		if schemas[i].Name == schema {
+			return nil
+		}
+	}
+
+	return stderror.Errorf("schema %s not found", schema)
+}
+
+func (b *bankendMigration) ListSchemaMigrations(ctx context.Context, app, schema, subscriptionID string) (api.SchemaMigrationsResponse, error) {
+	if err := b.apps.CheckAppAndSubscription(ctx, app, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	list, err := b.m.ListSchemaMigrations(app, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertSchemaMigrations(list), nil
+}
+
+// SetSchemaMigrations 已执行的版本必须保持不变，未执行的版本被替换，
+// 新增的版本不能小于已执行的最大版本
+func (b *bankendMigration) SetSchemaMigrations(ctx context.Context, app, schema, subscriptionID string, set api.SchemaMigrationSet) (api.SchemaMigrationsResponse, error) {
+	if err := b.checkSchema(ctx, app, schema, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	if err := b.acquire(app, schema); err != nil {
+		return nil, err
+	}
+	defer b.release(app, schema)
+
+	list, err := b.m.ListSchemaMigrations(app, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	exist := make(map[int64]model.SchemaMigration, len(list))
+	uploaded := make(map[int64]bool, len(set.Migrations))
+	applied := int64(0)
+
+	for _, sm := range list {
+		exist[sm.Version] = sm
+
+		if sm.State == api.MigrationApplied && sm.Version > applied {
+			applied = sm.Version
+		}
+	}
+
+	for _, m := range set.Migrations {
+		uploaded[m.Version] = true
+
+		old, ok := exist[m.Version]
+		checksum := migrationChecksum(m.SQL)
+
+		if ok && old.State == api.MigrationApplied {
+			if old.Checksum != checksum {
+				return nil, stderror.Errorf("version %d has been applied and can't be modified", m.Version)
+			}
+
+			continue
+		}
+
+		if m.Version < applied {
+			return nil, stderror.Errorf("version %d is lower than the applied version %d", m.Version, applied)
+		}
+	}
+
+	for _, sm := range list {
+		if sm.State == api.MigrationApplied && !uploaded[sm.Version] {
+			return nil, stderror.Errorf("version %d has been applied and can't be removed", sm.Version)
+		}
+
+		if sm.State == api.MigrationInterrupted && !uploaded[sm.Version] {
+			return nil, stderror.Errorf("version %d was interrupted and can't be removed", sm.Version)
+		}
+	}
+
+	now := time.Now()
+
+	for _, m := range set.Migrations {
+		old, ok := exist[m.Version]
+		if ok && old.State == api.MigrationApplied {
+			continue
+		}
+
+		sm := model.SchemaMigration{
+			App:         app,
+			Schema:      schema,
+			Version:     m.Version,
+			Description: m.Description,
+			SQL:         m.SQL,
+			Online:      m.Online,
+			Checksum:    migrationChecksum(m.SQL),
+			State:       api.MigrationPending,
+			CreatedAt:   now,
+		}
+
+		// 内容不变时保留验证及失败记录，中断的版本修改后仍需确认
+		if ok && old.Checksum == sm.Checksum && old.Online == sm.Online {
+			sm.State, sm.Error, sm.ValidatedAt = old.State, old.Error, old.ValidatedAt
+		} else if ok && old.State == api.MigrationInterrupted {
+			sm.State, sm.Error = old.State, old.Error
+		}
+
+		if err := b.m.SaveSchemaMigration(sm); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sm := range list {
+		if !uploaded[sm.Version] {
+			if err := b.m.DeleteSchemaMigration(app, schema, sm.Version); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	list, err = b.m.ListSchemaMigrations(app, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertSchemaMigrations(list), nil
+}
+
+// ApplySchemaMigrations 在一个任务中按版本依次执行未执行或失败的迁移，任一版本失败则停止，
+// 中断的迁移需 resolve_interrupted 确认后才执行，
+// dry_run 时在从库单元的草稿库中验证所有迁移，不修改 schema
+func (b *bankendMigration) ApplySchemaMigrations(ctx context.Context, app, schema, subscriptionID string, opts api.SchemaMigrationApplyOptions) (api.SchemaMigrationApplyResponse, error) {
+	if err := b.checkSchema(ctx, app, schema, subscriptionID); err != nil {
+		return api.SchemaMigrationApplyResponse{}, err
+	}
+
+	if opts.DryRun {
+		unit, err := b.apps.dryRunUnit(app, opts.Unit)
+		if err != nil {
+			return api.SchemaMigrationApplyResponse{}, err
+		}
+
+		opts.Unit = unit
+	}
+
+	if err := b.acquire(app, schema); err != nil {
+		return api.SchemaMigrationApplyResponse{}, err
+	}
+
+	list, err := b.m.ListSchemaMigrations(app, schema)
+	if err != nil {
+		b.release(app, schema)
+		return api.SchemaMigrationApplyResponse{}, err
+	}
+
+	pending := pendingMigrations(list, opts.TargetVersion)
+
+	if !opts.DryRun && !opts.ResolveInterrupted {
+		for _, sm := range pending {
+			if sm.State == api.MigrationInterrupted {
+				b.release(app, schema)
+				return api.SchemaMigrationApplyResponse{}, stderror.Errorf("migration %d was interrupted,check the schema and apply with resolve_interrupted", sm.Version)
+			}
+		}
+	}
+
+	out := api.SchemaMigrationApplyResponse{
+		Migrations: convertSchemaMigrations(pending),
+	}
+
+	if len(pending) == 0 {
+		b.release(app, schema)
+		return out, nil
+	}
+
+	action := ActionSchemaMigrate
+	if opts.DryRun {
+		action = ActionSchemaMigrateDryRun
+	}
+
+	tk := model.NewTask(action, app, model.Application{}.Table(), opts.User)
+	tk.ID, err = b.tasks.Insert(tk)
+	if err != nil {
+		b.release(app, schema)
+		return api.SchemaMigrationApplyResponse{}, err
+	}
+
+	out.Task = api.TaskBrief{
+		ID:     tk.ID,
+		Status: model.TaskRunning.State(),
+		Action: action,
+		User:   opts.User,
+	}
+
+	go func() {
+		var err error
+		if opts.DryRun {
+			err = b.dryRun(app, schema, opts.Unit, pending)
+		} else {
+			err = b.apply(app, schema, pending)
+		}
+		if err != nil {
+			klog.Errorf("%s of app %s schema %s:%s", action, app, schema, err)
+		}
+
+		b.release(app, schema)
+
+		if _err := b.tasks.Update(taskUpdate(tk.ID, err)); _err != nil {
+			klog.Errorf("update %s task %s:%s", action, tk.ID, _err)
+		}
+	}()
+
+	return out, nil
+}
+
+// pendingMigrations 未执行、失败或中断的迁移，target 大于0时不包括更大的版本
+func pendingMigrations(list []model.SchemaMigration, target int64) []model.SchemaMigration {
+	out := make([]model.SchemaMigration, 0, len(list))
+
+	for _, sm := range list {
+		if target > 0 && sm.Version > target {
+			break
+		}
+
+		if sm.State != api.MigrationApplied {
+			out = append(out, sm)
+		}
+	}
+
+	return out
+}
+
+func (b *bankendMigration) apply(app, schema string, pending []model.SchemaMigration) error {
+	for _, sm := range pending {
+		sm.State = api.MigrationRunning
+		sm.Error = ""
+		if err := b.m.SaveSchemaMigration(sm); err != nil {
+			return err
+		}
+
+		start := time.Now()
+
+		cmd, err := b.migrateCmd(schema, sm)
+		if err == nil {
+			err = b.apps.runAppDBCmd(app, "", cmd)
+		}
+
+		sm.Duration = int64(time.Since(start).Seconds())
+
+		if err != nil {
+			sm.State = api.MigrationFailed
+			sm.Error = err.Error()
+		} else {
+			sm.State = api.MigrationApplied
+			sm.AppliedAt = time.Now()
+		}
+
+		if _err := b.m.SaveSchemaMigration(sm); _err != nil {
+			klog.Errorf("save migration %d of app %s schema %s:%s", sm.Version, app, schema, _err)
+		}
+
+		if err != nil {
+			return stderror.Wrapf(err, "migration %d", sm.Version)
+		}
+	}
+
+	return nil
+}
+
+// migrateCmd 普通迁移执行 database migrate，在线迁移由 database online_alter 调用在线DDL工具
+func (b *bankendMigration) migrateCmd(schema string, sm model.SchemaMigration) ([]string, error) {
+	verb := "migrate"
+	args := map[string]interface{}{
+		"name":    schema,
+		"version": sm.Version,
+		"sql":     sm.SQL,
+	}
+
+	if sm.Online {
+		table, alter, err := api.ParseOnlineAlter(sm.SQL)
+		if err != nil {
+			return nil, err
+		}
+
+		verb = "online_alter"
+		args = map[string]interface{}{
+			"name":    schema,
+			"version": sm.Version,
+			"table":   table,
+			"alter":   alter,
+			"tool":    b.onlineTool,
+		}
+	}
+
+	data, err := encodeJson(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"sh", shell, "database", verb, string(data)}, nil
+}
+
+// dryRun 在 unit 上按 schema 的表结构创建草稿库，依次执行迁移后删除草稿库
+func (b *bankendMigration) dryRun(app, schema, unit string, pending []model.SchemaMigration) error {
+	type migration struct {
+		Version int64  `json:"version"`
+		SQL     string `json:"sql"`
+	}
+
+	migrations := make([]migration, len(pending))
+	for i := range pending {
+		migrations[i] = migration{Version: pending[i].Version, SQL: pending[i].SQL}
+	}
+
+	data, err := encodeJson(map[string]interface{}{
+		"name":       schema,
+		"scratch":    fmt.Sprintf("_dbscale_dryrun_%d", time.Now().Unix()),
+		"migrations": migrations,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = b.apps.runAppDBCmd(app, unit, []string{"sh", shell, "database", "migrate_dry_run", string(data)})
+
+	now := time.Now()
+
+	for _, sm := range pending {
+		if err != nil {
+			sm.Error = "dry run:" + err.Error()
+		} else {
+			sm.Error = ""
+			sm.ValidatedAt = now
+		}
+
+		if _err := b.m.SaveSchemaMigration(sm); _err != nil {
+			klog.Errorf("save migration %d of app %s schema %s:%s", sm.Version, app, schema, _err)
+		}
+	}
+
+	return err
+}
+
+// dryRunUnit 草稿库不能建在主库单元，避免验证影响业务，unit 为空时选择一个从库单元
+func (beApp *bankendApp) dryRunUnit(appID, unitID string) (string, error) {
+	app, err := beApp.m.Get(appID)
+	if err != nil {
+		return "", err
+	}
+
+	kUnit, ok, err := beApp.getMasterK8sMysqlUnit(appID)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", stderror.Errorf("Cannot find master pod")
+	}
+
+	if unitID == kUnit.Name {
+		return "", stderror.Errorf("unit %s is the master,dry run requires a slave or scratch unit", unitID)
+	}
+
+	if unitID != "" {
+		return unitID, nil
+	}
+
+	for _, unit := range app.Units {
+		if unit.IsServiceType(structs.MysqlServiceType) && unit.ID != kUnit.Name {
+			return unit.ID, nil
+		}
+	}
+
+	return "", stderror.Errorf("no slave unit in app %s,specify a scratch unit for dry run", appID)
+}
+
+// runAppDBCmd 在服务的数据库单元中执行命令，unit 为空时在主库单元执行，命令失败时返回输出
+func (beApp *bankendApp) runAppDBCmd(appID, unitID string, cmd []string) error {
+	app, err := beApp.m.Get(appID)
+	if err != nil {
+		return err
+	}
+
+	if unitID == "" {
+		kUnit, ok, err := beApp.getMasterK8sMysqlUnit(appID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return stderror.Errorf("Cannot find master pod")
+		}
+
+		unitID = kUnit.Name
+	}
+
+	for _, unit := range app.Units {
+		if unit.ID != unitID {
+			continue
+		}
+
+		if !unit.IsServiceType(structs.MysqlServiceType) {
+			return stderror.Errorf("unit %s is not a database unit", unitID)
+		}
+
+		ok, r, err := beApp.zone.runInContainer(unit.Site, unit.Namespace, unit.ObjectName(), cmd)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			out, _ := ioutil.ReadAll(r)
+			return stderror.Errorf("run %s %s in unit %s failed:%s", cmd[2], cmd[3], unitID, strings.TrimSpace(string(out)))
+		}
+
+		return nil
+	}
+
+	return stderror.Errorf("unit %s not found in app %s", unitID, appID)
+}
+
+func convertSchemaMigrations(list []model.SchemaMigration) api.SchemaMigrationsResponse {
+	out := make(api.SchemaMigrationsResponse, len(list))
+
+	for i, sm := range list {
+		out[i] = api.SchemaMigrationStatus{
+			SchemaMigration: api.SchemaMigration{
+				Version:     sm.Version,
+				Description: sm.Description,
+				SQL:         sm.SQL,
+				Online:      sm.Online,
+			},
+			Checksum: sm.Checksum,
+			State:    sm.State,
+			Error:    sm.Error,
+			Duration: sm.Duration,
+		}
+
+		if !sm.ValidatedAt.IsZero() {
+			t := api.Time(sm.ValidatedAt)
+			out[i].ValidatedAt = &t
+		}
+		if !sm.AppliedAt.IsZero() {
+			t := api.Time(sm.AppliedAt)
+			out[i].AppliedAt = &t
+		}
+	}
+
+	return out
+}
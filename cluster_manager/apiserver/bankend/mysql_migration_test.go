@@ -0,0 +1,285 @@
+package bankend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+type fakeMigrationApps struct {
+	lock sync.Mutex
+	cmds [][]string
+	// 执行该版本时失败
+	fail string
+}
+
+func (f *fakeMigrationApps) CheckAppAndSubscription(ctx context.Context, appId, subscriptionId string) error {
+	return nil
+}
+
+func (f *fakeMigrationApps) ListAppDBSchema(ctx context.Context, appID string) (api.DBSchemaResponse, error) {
+	return api.DBSchemaResponse{{Name: "db1"}}, nil
+}
+
+func (f *fakeMigrationApps) runAppDBCmd(appID, unitID string, cmd []string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.cmds = append(f.cmds, append([]string{unitID}, cmd[3:]...))
+
+	if f.fail != "" && strings.Contains(cmd[4], f.fail) {
+		return context.DeadlineExceeded
+	}
+
+	return nil
+}
+
+// dryRunUnit u1 为主库单元，u2 为从库单元
+func (f *fakeMigrationApps) dryRunUnit(appID, unitID string) (string, error) {
+	switch unitID {
+	case "u1":
+		return "", errors.New("unit u1 is the master")
+	case "":
+		return "u2", nil
+	}
+
+	return unitID, nil
+}
+
+func waitMigrationTask(t *testing.T, tasks *fakeManifestTasks, n int) model.Task {
+	for i := 0; i < 100; i++ {
+		tasks.lock.Lock()
+		if len(tasks.updated) >= n {
+			tk := tasks.updated[n-1]
+			tasks.lock.Unlock()
+			return tk
+		}
+		tasks.lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("task %d not finished", n)
+
+	return model.Task{}
+}
+
+func TestSchemaMigrations(t *testing.T) {
+	apps := &fakeMigrationApps{fail: "DROP"}
+	tasks := &fakeManifestTasks{}
+	b := NewMigrationBankend(apps, model.NewFakeModels().ModelSchemaMigration(), tasks, api.OnlineSchemaToolGhost)
+	ctx := context.Background()
+
+	set := api.SchemaMigrationSet{
+		Migrations: []api.SchemaMigration{
+			{Version: 1, SQL: "CREATE TABLE t1 (id int primary key)"},
+			{Version: 2, SQL: "ALTER TABLE t1 ADD COLUMN name varchar(64)", Online: true},
+			{Version: 3, SQL: "DROP TABLE t0"},
+		},
+	}
+	if err := set.Valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.SetSchemaMigrations(ctx, "app1", "db2", "", set); err == nil {
+		t.Error("expected error of schema not found")
+	}
+
+	list, err := b.SetSchemaMigrations(ctx, "app1", "db1", "", set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].State != api.MigrationPending || list[0].Checksum == "" {
+		t.Fatalf("unexpected migrations %+v", list)
+	}
+
+	resp, err := b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{DryRun: true, Unit: "u2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Migrations) != 3 || resp.Task.Action != ActionSchemaMigrateDryRun {
+		t.Fatalf("unexpected dry run %+v", resp)
+	}
+
+	// dry run 在一个命令中验证所有迁移，失败时所有迁移都记录错误
+	if tk := waitMigrationTask(t, tasks, 1); tk.Status != model.TaskFailed {
+		t.Errorf("expected dry run failed but got %s", tk.Status.State())
+	}
+	if cmd := apps.cmds[0]; cmd[0] != "u2" || cmd[1] != "migrate_dry_run" {
+		t.Errorf("unexpected dry run cmd %v", cmd)
+	}
+
+	list, _ = b.ListSchemaMigrations(ctx, "app1", "db1", "")
+	if list[0].ValidatedAt != nil || !strings.HasPrefix(list[0].Error, "dry run:") {
+		t.Errorf("expected dry run error but got %+v", list[0])
+	}
+
+	_, err = b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{TargetVersion: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk := waitMigrationTask(t, tasks, 2); tk.Status != model.TaskSuccess {
+		t.Fatalf("expected migration succeeded but got %s", tk.Error)
+	}
+	if cmd := apps.cmds[1]; cmd[0] != "" || cmd[1] != "migrate" {
+		t.Errorf("unexpected migrate cmd %v", cmd)
+	}
+	if cmd := apps.cmds[2]; cmd[1] != "online_alter" || !strings.Contains(cmd[2], `"table":"t1"`) || !strings.Contains(cmd[2], `"tool":"gh-ost"`) {
+		t.Errorf("unexpected online alter cmd %v", cmd)
+	}
+
+	list, _ = b.ListSchemaMigrations(ctx, "app1", "db1", "")
+	if list[1].State != api.MigrationApplied || list[1].AppliedAt == nil || list[2].State != api.MigrationPending {
+		t.Errorf("unexpected migrations %+v", list)
+	}
+
+	_, err = b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk := waitMigrationTask(t, tasks, 3); tk.Status != model.TaskFailed {
+		t.Errorf("expected migration failed but got %s", tk.Status.State())
+	}
+
+	list, _ = b.ListSchemaMigrations(ctx, "app1", "db1", "")
+	if list[2].State != api.MigrationFailed || list[2].Error == "" {
+		t.Errorf("expected version 3 failed but got %+v", list[2])
+	}
+
+	// 已执行的版本不能修改或删除，新版本不能小于已执行的版本
+	modified := set
+	modified.Migrations = append([]api.SchemaMigration{}, set.Migrations...)
+	modified.Migrations[0].SQL = "CREATE TABLE t1 (id bigint primary key)"
+
+	if _, err := b.SetSchemaMigrations(ctx, "app1", "db1", "", modified); err == nil {
+		t.Error("expected error of modifying applied version")
+	}
+	if _, err := b.SetSchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationSet{Migrations: set.Migrations[1:]}); err == nil {
+		t.Error("expected error of removing applied version")
+	}
+
+	fixed := api.SchemaMigrationSet{
+		Migrations: []api.SchemaMigration{
+			set.Migrations[0],
+			set.Migrations[1],
+			{Version: 3, SQL: "DROP TABLE IF EXISTS t0"},
+		},
+	}
+
+	list, err = b.SetSchemaMigrations(ctx, "app1", "db1", "", fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list[2].State != api.MigrationPending || list[2].Error != "" {
+		t.Errorf("expected the modified version pending but got %+v", list[2])
+	}
+}
+
+func TestParseOnlineAlter(t *testing.T) {
+	table, alter, err := api.ParseOnlineAlter("ALTER TABLE `orders` ADD INDEX idx_user (user_id);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table != "orders" || alter != "ADD INDEX idx_user (user_id)" {
+		t.Errorf("unexpected table %q alter %q", table, alter)
+	}
+
+	for _, sql := range []string{
+		"CREATE TABLE t1 (id int)",
+		"ALTER TABLE t1 ADD COLUMN a int; ALTER TABLE t2 ADD COLUMN b int",
+	} {
+		if _, _, err := api.ParseOnlineAlter(sql); err == nil {
+			t.Errorf("expected error of %s", sql)
+		}
+	}
+}
+
+func TestSchemaMigrationsInterrupted(t *testing.T) {
+	apps := &fakeMigrationApps{}
+	tasks := &fakeManifestTasks{}
+	mm := model.NewFakeModels().ModelSchemaMigration()
+	b := NewMigrationBankend(apps, mm, tasks, api.OnlineSchemaToolGhost)
+	ctx := context.Background()
+
+	set := api.SchemaMigrationSet{
+		Migrations: []api.SchemaMigration{
+			{Version: 1, SQL: "CREATE TABLE t1 (id int primary key)"},
+			{Version: 2, SQL: "ALTER TABLE t1 ADD COLUMN name varchar(64)"},
+		},
+	}
+	if _, err := b.SetSchemaMigrations(ctx, "app1", "db1", "", set); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{DryRun: true, Unit: "u1"}); err == nil {
+		t.Error("expected error of dry run on the master")
+	}
+
+	if _, err := b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if tk := waitMigrationTask(t, tasks, 1); tk.Status != model.TaskSuccess {
+		t.Fatalf("expected dry run succeeded but got %s", tk.Error)
+	}
+	if cmd := apps.cmds[0]; cmd[0] != "u2" {
+		t.Errorf("expected dry run on the slave but got %v", cmd)
+	}
+
+	// 模拟 apiserver 在执行版本1时退出：锁及 running 状态保留在数据库中
+	rows, _ := mm.ListSchemaMigrations("app1", "db1")
+	rows[0].State = api.MigrationRunning
+	if err := mm.SaveSchemaMigration(rows[0]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := mm.LockSchemaMigrations(model.SchemaMigrationLock{App: "app1", Schema: "db1"}); !ok {
+		t.Fatal("expected the schema unlocked")
+	}
+
+	b = NewMigrationBankend(apps, mm, tasks, api.OnlineSchemaToolGhost)
+
+	if _, err := b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{}); err == nil {
+		t.Error("expected error of the locked schema")
+	}
+
+	if err := b.RestoreMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, _ := b.ListSchemaMigrations(ctx, "app1", "db1", "")
+	if list[0].State != api.MigrationInterrupted || list[0].Error == "" || list[1].State != api.MigrationPending {
+		t.Fatalf("expected version 1 interrupted but got %+v", list)
+	}
+
+	if _, err := b.SetSchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationSet{Migrations: set.Migrations[1:]}); err == nil {
+		t.Error("expected error of removing interrupted version")
+	}
+
+	if _, err := b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{}); err == nil {
+		t.Error("expected error of applying interrupted version")
+	}
+	if len(apps.cmds) != 1 {
+		t.Errorf("expected the interrupted version not applied but got %v", apps.cmds)
+	}
+
+	if _, err := b.ApplySchemaMigrations(ctx, "app1", "db1", "", api.SchemaMigrationApplyOptions{ResolveInterrupted: true}); err != nil {
+		t.Fatal(err)
+	}
+	if tk := waitMigrationTask(t, tasks, 2); tk.Status != model.TaskSuccess {
+		t.Fatalf("expected migration succeeded but got %s", tk.Error)
+	}
+
+	list, _ = b.ListSchemaMigrations(ctx, "app1", "db1", "")
+	if list[0].State != api.MigrationApplied || list[1].State != api.MigrationApplied {
+		t.Errorf("unexpected migrations %+v", list)
+	}
+
+	if locks, _ := mm.ListSchemaMigrationLocks(); len(locks) != 0 {
+		t.Errorf("expected no lock but got %+v", locks)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
 	unitv4 "github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
 	podutil "github.com/upmio/dbscale-kube/pkg/utils/pod"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
@@ -262,12 +263,13 @@ func (beApp *bankendApp) UpdateImage(ctx context.Context, id string, opts api.Ap
 		return api.TaskObjectResponse{}, err
 	}
 
-	_, err = beApp.syncAppUnits(app.ID, app.Units)
+	current, err := beApp.syncAppUnits(app.ID, app.Units)
 	if err != nil {
 		return api.TaskObjectResponse{}, err
 	}
 
 	ims := make(map[string]model.Image)
+	variants := make(map[string]map[string]model.Image)
 	images := make(map[string]string)
 	unitImages := make(map[string]string)
 
@@ -289,10 +291,16 @@ func (beApp *bankendApp) UpdateImage(ctx context.Context, id string, opts api.Ap
 			return err
 		}
 
+		if err := checkImageLifecycle(im); err != nil {
+			return err
+		}
+
 		ims[serviceType] = im
 		images[serviceType] = im.ImageVersion.ImageWithArch()
-		unitImages[serviceType] = fmt.Sprintf("%s/%s/%s", site.ImageRegistry, site.ProjectName, im.ImageVersion.ImageWithArch())
-		return nil
+		unitImages[serviceType] = im.Reference(site.ImageRegistry, site.ProjectName)
+
+		variants[serviceType], err = imageVariants(beApp.images, im)
+		return err
 	}
 
 	err = fit2Groups(structs.MysqlServiceType, opts.Spec.Database)
@@ -310,6 +318,19 @@ func (beApp *bankendApp) UpdateImage(ctx context.Context, id string, opts api.Ap
 		return api.TaskObjectResponse{}, err
 	}
 
+	// 多架构服务的每个单元都需要有对应架构的镜像
+	for i := range current {
+		serviceType := current[i].Labels[labelServiceType]
+		if _, ok := images[serviceType]; !ok {
+			continue
+		}
+
+		arch := current[i].Spec.Template.Spec.NodeSelector[corev1.LabelArchStable]
+		if _, ok := variants[serviceType][arch]; arch != "" && !ok {
+			return api.TaskObjectResponse{}, fmt.Errorf("unit %s: not found %s image for arch %s", current[i].Name, ims[serviceType].Version(), arch)
+		}
+	}
+
 	//some sanity check
 	if spec.Proxy == nil && opts.Spec.Proxy != nil {
 		return api.TaskObjectResponse{}, stderror.New("proxySQL is not used in this app")
@@ -318,6 +339,13 @@ func (beApp *bankendApp) UpdateImage(ctx context.Context, id string, opts api.Ap
 		return api.TaskObjectResponse{}, stderror.New("cmha is not used in this app")
 	}
 
+	// 固定模板版本的服务需要先取消固定才能更换镜像
+	for _, group := range appGroups(spec) {
+		if im, ok := ims[group.Image.Type]; ok && group.TemplateRevision > 0 && im.ID != group.Image.ID {
+			return api.TaskObjectResponse{}, fmt.Errorf("%s is pinned to template revision %d of image %s,unpin it first", group.Image.Type, group.TemplateRevision, group.Image.ID)
+		}
+	}
+
 	if opts.Spec.Database != nil {
 		spec.Database.Image = api.ImageVersion(ims[structs.MysqlServiceType].ImageVersion)
 	}
@@ -363,16 +391,23 @@ func (beApp *bankendApp) UpdateImage(ctx context.Context, id string, opts api.Ap
 
 			for i := range units {
 
+				image, unitImage := images[serviceType], unitImages[serviceType]
+
+				arch := units[i].Spec.Template.Spec.NodeSelector[corev1.LabelArchStable]
+				if im, ok := variants[serviceType][arch]; ok && arch != ims[serviceType].Arch {
+					image, unitImage = im.ImageWithArch(), im.Reference(site.ImageRegistry, site.ProjectName)
+				}
+
 				for j, container := range units[i].Spec.Template.Spec.Containers {
 
 					if container.Name != units[i].Spec.MainContainerName {
 						continue
 					}
 
-					if !strings.HasSuffix(container.Image, images[serviceType]) {
+					if !strings.HasSuffix(container.Image, image) {
 
 						clone := units[i].DeepCopy()
-						clone.Spec.Template.Spec.Containers[j].Image = unitImages[serviceType]
+						clone.Spec.Template.Spec.Containers[j].Image = unitImage
 
 						err := beApp.zone.updateUnit(clone)
 						if err != nil {
@@ -443,6 +478,11 @@ func (beApp *bankendApp) UpdateAppResourceRequests(ctx context.Context, id strin
 		return api.TaskObjectResponse{}, utilerrors.NewAggregate(errs)
 	}
 
+	err = checkSubscriptionQuota(beApp.subscriptions, beApp.m, beApp.files, app.SubscriptionId, app.ID, spec)
+	if err != nil {
+		return api.TaskObjectResponse{}, err
+	}
+
 	// try to get pass
 	appUpdateRequestsChan <- appUpdateRequestsChanTake
 	passOrNot := <-appUpdateRequestsChan
@@ -613,7 +653,10 @@ func (beApp *bankendApp) UnitRebuild(ctx context.Context, appID, unitID string,
 	unitsMap[structs.CmhaServiceType] = []unitv4.Unit{}
 	unitsMap[structs.ProxysqlServiceType] = []unitv4.Unit{}
 
-	var mu *model.Unit
+	var (
+		mu   *model.Unit
+		arch string
+	)
 	for i := range app.Units {
 		theUnit, err := iface.Units().Get(metav1.NamespaceDefault, app.Units[i].ID)
 		if err != nil {
@@ -623,6 +666,7 @@ func (beApp *bankendApp) UnitRebuild(ctx context.Context, appID, unitID string,
 
 		if app.Units[i].ID == unitID {
 			mu = &app.Units[i]
+			arch = theUnit.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable]
 		}
 	}
 
@@ -655,6 +699,21 @@ func (beApp *bankendApp) UnitRebuild(ctx context.Context, appID, unitID string,
 		if !mu.IsServiceType(image.Type) {
 			return api.TaskObjectResponse{}, stderror.New("cannot rebuild unit with a different image type")
 		}
+
+		// 单元固定在其架构的主机上，使用同版本对应架构的镜像
+		if arch != "" && arch != image.Arch {
+			variants, err := imageVariants(beApp.images, *image)
+			if err != nil {
+				return api.TaskObjectResponse{}, err
+			}
+
+			v, ok := variants[arch]
+			if !ok {
+				return api.TaskObjectResponse{}, fmt.Errorf("unit %s: not found %s image for arch %s", unitID, image.Version(), arch)
+			}
+
+			image = &v
+		}
 	}
 
 	if opts.Resources != nil {
@@ -722,7 +781,7 @@ func (beApp *bankendApp) UnitRebuild(ctx context.Context, appID, unitID string,
 					}
 
 					clone.Spec.Template.Spec.Containers[i].Name = image.Type
-					clone.Spec.Template.Spec.Containers[i].Image = fmt.Sprintf("%s/%s/%s", site.ImageRegistry, site.ProjectName, image.ImageWithArch())
+					clone.Spec.Template.Spec.Containers[i].Image = image.Reference(site.ImageRegistry, site.ProjectName)
 				}
 
 				clone.Spec.MainContainerName = image.Type
@@ -9,13 +9,26 @@ import (
 
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/audit"
 	podutil "github.com/upmio/dbscale-kube/pkg/utils/pod"
 	"k8s.io/klog/v2"
 )
 
-func (beApp *bankendApp) AddAppDBUsers(_ context.Context, id string, config []api.AppUserConfig, units []model.Unit, masterUnitName string) (api.TaskObjectResponse, error) {
+// AddAppDBUsers 未指定密码的用户由服务端生成密码并保存到 Secret，指定的密码需要满足密码策略
+func (beApp *bankendApp) AddAppDBUsers(ctx context.Context, id string, config []api.AppUserConfig, units []model.Unit, masterUnitName string) (api.TaskObjectResponse, error) {
 
 	cmd := make([][]string, len(config))
+	config = append([]api.AppUserConfig(nil), config...)
+	generated := make([]bool, len(config))
+
+	for i := range config {
+		pwd, ok, err := beApp.preparePassword(id, config[i].Name, string(config[i].IP), config[i].Password)
+		if err != nil {
+			return api.TaskObjectResponse{}, stderror.Wrapf(err, "user %s@%s", config[i].Name, config[i].IP)
+		}
+
+		config[i].Password, generated[i] = pwd, ok
+	}
 
 	for i, configOne := range config {
 		dataOne, err := encodeJson(configOne)
@@ -31,13 +44,48 @@ func (beApp *bankendApp) AddAppDBUsers(_ context.Context, id string, config []ap
 		}
 	}
 
-	find, _, err := beApp.doAppDBCmd(id, units, masterUnitName, cmd)
+	if units == nil && beApp.credentials != nil {
+		app, err := beApp.m.Get(id)
+		if err != nil {
+			return api.TaskObjectResponse{}, err
+		}
+
+		units = app.Units
+	}
+
+	// 生成的密码先写入 Secret，修改数据库失败时恢复
+	secrets := make([]*passwordSecret, len(config))
+
+	for i := range config {
+		if !generated[i] {
+			continue
+		}
+
+		secret, err := beApp.deliverPassword(id, units, config[i].Name, string(config[i].IP), config[i].Password, false)
+		if err != nil {
+			beApp.restorePasswordSecrets(secrets)
+			return api.TaskObjectResponse{}, err
+		}
+
+		secrets[i] = secret
+	}
 
+	find, _, err := beApp.doAppDBCmd(id, units, masterUnitName, cmd)
+	if err == nil && !find {
+		err = stderror.Errorf("Cannot find master pod")
+	}
 	if err != nil {
+		beApp.restorePasswordSecrets(secrets)
 		return api.TaskObjectResponse{}, err
 	}
-	if !find {
-		return api.TaskObjectResponse{}, stderror.Errorf("Cannot find master pod")
+
+	for i := range config {
+		audit.Record(ctx, api.AuditDBUserCreate, "db_user", config[i].Name+"@"+string(config[i].IP), nil, config[i])
+
+		err := beApp.recordPassword(id, secrets[i], config[i].Name, string(config[i].IP), config[i].Password, generated[i], false)
+		if err != nil {
+			return api.TaskObjectResponse{}, err
+		}
 	}
 
 	return api.TaskObjectResponse{}, nil
@@ -110,7 +158,31 @@ func (beApp *bankendApp) ListAppDBUsers(_ context.Context, appID string) (api.Ap
 	}
 }
 
-func (beApp *bankendApp) ResetAppDBUser(_ context.Context, id string, config api.AppUserResetConfig) error {
+// ResetAppDBUser 未指定密码时由服务端生成密码并保存到 Secret，
+// RetainCurrent 时旧密码在丢弃前仍然有效
+func (beApp *bankendApp) ResetAppDBUser(ctx context.Context, id string, config api.AppUserResetConfig) error {
+	app, err := beApp.m.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if config.RetainCurrent {
+		spec, err := decodeAppSpec(app.Spec)
+		if err != nil {
+			return err
+		}
+
+		if !supportsDualPassword(spec) {
+			return stderror.Errorf("retaining current password requires MySQL 8.0.14 or later")
+		}
+	}
+
+	pwd, generated, err := beApp.preparePassword(id, config.Name, config.IP, config.Password)
+	if err != nil {
+		return err
+	}
+
+	config.Password = pwd
 
 	data, err := encodeJson(config)
 	if err != nil {
@@ -125,19 +197,34 @@ func (beApp *bankendApp) ResetAppDBUser(_ context.Context, id string, config api
 		string(data),
 	}
 
-	find, _, err := beApp.doAppDBCmd(id, nil, "", [][]string{cmd})
+	// 生成的密码先写入 Secret，修改数据库失败时恢复
+	var secret *passwordSecret
+	if generated {
+		secret, err = beApp.deliverPassword(id, app.Units, config.Name, config.IP, pwd, config.RetainCurrent)
+		if err != nil {
+			return err
+		}
+	}
 
+	find, _, err := beApp.doAppDBCmd(id, nil, "", [][]string{cmd})
+	if err == nil && !find {
+		err = stderror.Errorf("Cannot find master pod")
+	}
 	if err != nil {
+		beApp.restorePasswordSecrets([]*passwordSecret{secret})
 		return err
 	}
-	if !find {
-		return stderror.Errorf("Cannot find master pod")
-	}
 
-	return nil
+	audit.Record(ctx, api.AuditDBUserResetPassword, "db_user", config.Name+"@"+config.IP, nil, config)
+
+	return beApp.recordPassword(id, secret, config.Name, config.IP, pwd, generated, config.RetainCurrent)
 }
 
-func (beApp *bankendApp) DeleteAppDBUser(_ context.Context, id, user, ip string) error {
+func (beApp *bankendApp) DeleteAppDBUser(ctx context.Context, id, user, ip string) error {
+	before, err := beApp.GetAppDBUser(ctx, id, user, ip)
+	if err != nil {
+		klog.Warningf("get database user %s@%s of service %s before delete:%s", user, ip, id, err)
+	}
 
 	cmd := []string{
 		"sh",
@@ -156,7 +243,25 @@ func (beApp *bankendApp) DeleteAppDBUser(_ context.Context, id, user, ip string)
 		return stderror.Errorf("Cannot find master pod")
 	}
 
-	return nil
+	audit.Record(ctx, api.AuditDBUserDelete, "db_user", user+"@"+ip, before, nil)
+
+	if beApp.credentials == nil {
+		return nil
+	}
+
+	c, err := beApp.credentials.GetDBUserCredential(id, user, ip)
+	if model.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := beApp.deletePasswordSecret(c); err != nil {
+		klog.Warningf("delete password secret %s/%s of %s@%s:%s", c.SecretNamespace, c.SecretName, user, ip, err)
+	}
+
+	return beApp.credentials.DeleteDBUserCredential(id, user, ip)
 }
 
 func (beApp *bankendApp) RoleSwitch(_ context.Context, id string, config api.UnitRoleSwitchConfig) error {
@@ -500,6 +605,8 @@ func (beApp *bankendApp) UpdateUserPrivileges(ctx context.Context, appID string,
 		return err
 	}
 
+	audit.Record(ctx, api.AuditDBUserPrivileges, "db_user", opts.Name+"@"+string(opts.IP), user.Privileges, opts.Privileges)
+
 	return nil
 }
 
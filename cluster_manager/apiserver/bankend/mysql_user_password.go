@@ -0,0 +1,666 @@
+package bankend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	stderror "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/structs"
+	"github.com/upmio/dbscale-kube/pkg/utils"
+)
+
+const (
+	secretKeyUsername         = "username"
+	secretKeyHost             = "host"
+	secretKeyPassword         = "password"
+	secretKeyPreviousPassword = "previous-password"
+
+	annotationDBUser = "dbscale.db.user"
+
+	// 生成密码的最小长度
+	generatedPasswordLength = 20
+
+	passwordLower   = "abcdefghijklmnopqrstuvwxyz"
+	passwordUpper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordDigit   = "0123456789"
+	passwordSpecial = "#%+-.:=?@^_~"
+)
+
+type modelDBUserCredential interface {
+	SaveDBUserCredential(c model.DBUserCredential) error
+	DeleteDBUserCredential(app, name, ip string) error
+	GetDBUserCredential(app, name, ip string) (model.DBUserCredential, error)
+	ListDBUserCredentials(app string) ([]model.DBUserCredential, error)
+}
+
+// maskCmdSecrets masks the passwords in the json arguments of cmd for logging
+func maskCmdSecrets(cmd []string) []string {
+	out := make([]string, len(cmd))
+
+	for i, arg := range cmd {
+		out[i] = arg
+
+		if strings.HasPrefix(arg, "{") || strings.HasPrefix(arg, "[") {
+			if b, err := utils.MaskSecret(json.RawMessage(arg)); err == nil {
+				out[i] = string(b)
+			}
+		}
+	}
+
+	return out
+}
+
+// hashPassword returns salt$sha256(salt+password)
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	return hashPasswordWithSalt(hex.EncodeToString(salt), password), nil
+}
+
+func hashPasswordWithSalt(salt, password string) string {
+	sum := sha256.Sum256([]byte(salt + password))
+
+	return salt + "$" + hex.EncodeToString(sum[:])
+}
+
+func matchPassword(hash, password string) bool {
+	i := strings.Index(hash, "$")
+	if i < 0 {
+		return false
+	}
+
+	return hashPasswordWithSalt(hash[:i], password) == hash
+}
+
+func splitPasswordHistory(history string) []string {
+	if history == "" {
+		return nil
+	}
+
+	return strings.Split(history, ",")
+}
+
+// checkPasswordHistory returns error if password is one of the last policy.History passwords
+func checkPasswordHistory(policy api.PasswordPolicy, history, password string) error {
+	list := splitPasswordHistory(history)
+
+	for i := 0; i < len(list) && i < policy.History; i++ {
+		if matchPassword(list[i], password) {
+			return stderror.Errorf("password should not be one of the last %d passwords", policy.History)
+		}
+	}
+
+	return nil
+}
+
+// pushPasswordHistory 保留最近 keep 个密码哈希，至少保留当前密码
+func pushPasswordHistory(history, hash string, keep int) string {
+	if keep < 1 {
+		keep = 1
+	}
+
+	list := append([]string{hash}, splitPasswordHistory(history)...)
+	if len(list) > keep {
+		list = list[:keep]
+	}
+
+	return strings.Join(list, ",")
+}
+
+func randomChar(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, err
+	}
+
+	return chars[n.Int64()], nil
+}
+
+// generatePassword 生成满足策略的密码，包含每种要求的字符
+func generatePassword(policy api.PasswordPolicy) (string, error) {
+	length := policy.MinLength
+	if length < generatedPasswordLength {
+		length = generatedPasswordLength
+	}
+
+	required := []string{passwordLower, passwordUpper, passwordDigit}
+	if policy.RequireSpecial {
+		required = append(required, passwordSpecial)
+	}
+
+	all := strings.Join(required, "")
+	out := make([]byte, length)
+
+	for i := range out {
+		chars := all
+		if i < len(required) {
+			chars = required[i]
+		}
+
+		c, err := randomChar(chars)
+		if err != nil {
+			return "", err
+		}
+
+		out[i] = c
+	}
+
+	// 打乱必须字符的位置
+	for i := len(out) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+
+		j := n.Int64()
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return string(out), nil
+}
+
+// supportsDualPassword MySQL 8.0.14 开始支持 RETAIN CURRENT PASSWORD
+func supportsDualPassword(spec api.AppSpec) bool {
+	if spec.Database == nil {
+		return false
+	}
+
+	image := spec.Database.Image
+	if image.Type != "" && image.Type != structs.MysqlServiceType {
+		return false
+	}
+
+	return image.Major > 8 ||
+		(image.Major == 8 && (image.Minor > 0 || image.Patch >= 14))
+}
+
+func dbUserSecretName(app, name, ip string) string {
+	sum := sha256.Sum256([]byte(app + "/" + name + "@" + ip))
+
+	return "dbuser-" + hex.EncodeToString(sum[:])[:16]
+}
+
+func passwordExpiresAt(policy api.PasswordPolicy, changed time.Time) (time.Time, bool) {
+	if policy.MaxAgeDays <= 0 || changed.IsZero() {
+		return time.Time{}, false
+	}
+
+	return changed.AddDate(0, 0, policy.MaxAgeDays), true
+}
+
+func convertDBUserCredential(policy api.PasswordPolicy, c model.DBUserCredential) api.DBUserCredential {
+	out := api.DBUserCredential{
+		Name:                c.Name,
+		IP:                  api.IP(c.IP),
+		Generated:           c.Generated,
+		OldPasswordRetained: c.Retained,
+		ChangedAt:           api.Time(c.ChangedAt),
+	}
+
+	if c.SecretName != "" {
+		out.Secret = &api.DBUserSecret{
+			Site:      c.Site,
+			Namespace: c.SecretNamespace,
+			Name:      c.SecretName,
+		}
+	}
+
+	if expires, ok := passwordExpiresAt(policy, c.ChangedAt); ok {
+		t := api.Time(expires)
+		out.ExpiresAt = &t
+	}
+
+	return out
+}
+
+// preparePassword 检查用户指定的密码，密码为空时生成密码
+func (beApp *bankendApp) preparePassword(app, name, ip, password string) (string, bool, error) {
+	if password == "" {
+		pwd, err := generatePassword(beApp.passwordPolicy)
+
+		return pwd, true, err
+	}
+
+	if err := beApp.passwordPolicy.Check(password); err != nil {
+		return "", false, err
+	}
+
+	if beApp.credentials == nil {
+		return password, false, nil
+	}
+
+	c, err := beApp.credentials.GetDBUserCredential(app, name, ip)
+	if model.IsNotExist(err) {
+		return password, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return password, false, checkPasswordHistory(beApp.passwordPolicy, c.History, password)
+}
+
+// appSecretLocation 生成的密码保存在数据库单元所在的站点及 namespace
+func appSecretLocation(units []model.Unit) (string, string, error) {
+	for _, u := range units {
+		if u.GetServiceType() == structs.MysqlServiceType {
+			return u.Site, u.Namespace, nil
+		}
+	}
+
+	return "", "", stderror.New("no database unit to locate the secret")
+}
+
+// applyPasswordSecret 更新 Secret 中的密码，retain 时原密码保存为 previous-password，
+// 返回更新前的 Secret，Secret 不存在时为 nil
+func (beApp *bankendApp) applyPasswordSecret(app, site, namespace, name, ip, password string, retain bool) (string, *corev1.Secret, error) {
+	iface, err := beApp.zone.siteInterface(site)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secretName := dbUserSecretName(app, name, ip)
+
+	secret, err := iface.Secrets().Get(namespace, secretName)
+	if errors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      secretName,
+				Labels: map[string]string{
+					labelAppID: app,
+				},
+				Annotations: map[string]string{
+					annotationDBUser: name + "@" + ip,
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+
+		secret.Data = map[string][]byte{
+			secretKeyUsername: []byte(name),
+			secretKeyHost:     []byte(ip),
+			secretKeyPassword: []byte(password),
+		}
+
+		_, err = iface.Secrets().Create(namespace, secret)
+
+		return secretName, nil, err
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	previous := secret
+	secret = secret.DeepCopy()
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+
+	if retain && len(secret.Data[secretKeyPassword]) > 0 {
+		secret.Data[secretKeyPreviousPassword] = secret.Data[secretKeyPassword]
+	}
+	secret.Data[secretKeyPassword] = []byte(password)
+
+	_, err = iface.Secrets().Update(namespace, secret)
+
+	return secretName, previous, err
+}
+
+// passwordSecret 修改数据库用户前写入 Secret 的生成密码
+type passwordSecret struct {
+	site      string
+	namespace string
+	name      string
+	// previous 写入前的 Secret，为 nil 时恢复即删除 Secret
+	previous *corev1.Secret
+}
+
+// deliverPassword 修改数据库用户前把生成的密码写入 Secret，
+// 写入失败时不修改数据库，避免数据库中的密码无人知晓
+func (beApp *bankendApp) deliverPassword(app string, units []model.Unit, name, ip, password string, retain bool) (*passwordSecret, error) {
+	if beApp.credentials == nil {
+		return nil, nil
+	}
+
+	site, namespace, err := appSecretLocation(units)
+	if err != nil {
+		return nil, err
+	}
+
+	secretName, previous, err := beApp.applyPasswordSecret(app, site, namespace, name, ip, password, retain)
+	if err != nil {
+		return nil, stderror.Wrapf(err, "deliver password of %s@%s into secret", name, ip)
+	}
+
+	return &passwordSecret{
+		site:      site,
+		namespace: namespace,
+		name:      secretName,
+		previous:  previous,
+	}, nil
+}
+
+// restorePasswordSecrets 修改数据库用户失败时恢复写入的 Secret
+func (beApp *bankendApp) restorePasswordSecrets(secrets []*passwordSecret) {
+	for _, ps := range secrets {
+		if ps == nil {
+			continue
+		}
+
+		if err := beApp.restorePasswordSecret(ps); err != nil {
+			klog.Errorf("restore password secret %s/%s:%s", ps.namespace, ps.name, err)
+		}
+	}
+}
+
+func (beApp *bankendApp) restorePasswordSecret(ps *passwordSecret) error {
+	iface, err := beApp.zone.siteInterface(ps.site)
+	if err != nil {
+		return err
+	}
+
+	if ps.previous == nil {
+		err = iface.Secrets().Delete(ps.namespace, ps.name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	secret, err := iface.Secrets().Get(ps.namespace, ps.name)
+	if err != nil {
+		return err
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data = ps.previous.Data
+
+	_, err = iface.Secrets().Update(ps.namespace, secret)
+
+	return err
+}
+
+func (beApp *bankendApp) discardPreviousPasswordSecret(c model.DBUserCredential) error {
+	if c.SecretName == "" {
+		return nil
+	}
+
+	iface, err := beApp.zone.siteInterface(c.Site)
+	if err != nil {
+		return err
+	}
+
+	secret, err := iface.Secrets().Get(c.SecretNamespace, c.SecretName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secret.Data[secretKeyPreviousPassword]; !ok {
+		return nil
+	}
+
+	secret = secret.DeepCopy()
+	delete(secret.Data, secretKeyPreviousPassword)
+
+	_, err = iface.Secrets().Update(c.SecretNamespace, secret)
+
+	return err
+}
+
+func (beApp *bankendApp) deletePasswordSecret(c model.DBUserCredential) error {
+	if c.SecretName == "" {
+		return nil
+	}
+
+	iface, err := beApp.zone.siteInterface(c.Site)
+	if err != nil {
+		return err
+	}
+
+	err = iface.Secrets().Delete(c.SecretNamespace, c.SecretName, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// recordPassword 记录密码哈希及生成密码所在的 Secret，用户指定密码时删除之前生成的 Secret
+func (beApp *bankendApp) recordPassword(app string, secret *passwordSecret, name, ip, password string, generated, retain bool) error {
+	if beApp.credentials == nil {
+		return nil
+	}
+
+	c, err := beApp.credentials.GetDBUserCredential(app, name, ip)
+	if model.IsNotExist(err) {
+		c = model.DBUserCredential{App: app, Name: name, IP: ip}
+	} else if err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if generated {
+		if secret == nil {
+			return stderror.Errorf("password of %s@%s isn't delivered", name, ip)
+		}
+
+		c.Site, c.SecretNamespace, c.SecretName = secret.site, secret.namespace, secret.name
+	} else {
+		if err := beApp.deletePasswordSecret(c); err != nil {
+			klog.Warningf("delete password secret %s/%s of %s@%s:%s", c.SecretNamespace, c.SecretName, name, ip, err)
+		}
+
+		c.Site, c.SecretNamespace, c.SecretName = "", "", ""
+	}
+
+	c.History = pushPasswordHistory(c.History, hash, beApp.passwordPolicy.History)
+	c.Generated = generated
+	// 不指定 RETAIN CURRENT PASSWORD 修改密码时，已保留的旧密码不变
+	c.Retained = c.Retained || retain
+	c.Warned = false
+	c.ChangedAt = time.Now()
+
+	return beApp.credentials.SaveDBUserCredential(c)
+}
+
+func (beApp *bankendApp) DBUserPasswordPolicy(_ context.Context) api.PasswordPolicy {
+	return beApp.passwordPolicy
+}
+
+func (beApp *bankendApp) ListAppDBUserCredentials(_ context.Context, appID string) (api.DBUserCredentialsResponse, error) {
+	if beApp.credentials == nil {
+		return api.DBUserCredentialsResponse{}, nil
+	}
+
+	list, err := beApp.credentials.ListDBUserCredentials(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(api.DBUserCredentialsResponse, len(list))
+	for i := range list {
+		out[i] = convertDBUserCredential(beApp.passwordPolicy, list[i])
+	}
+
+	return out, nil
+}
+
+// DiscardAppDBUserOldPassword 丢弃双密码轮换保留的旧密码
+func (beApp *bankendApp) DiscardAppDBUserOldPassword(_ context.Context, appID string, config api.AppUserDiscardPasswordConfig) error {
+	cmd := []string{
+		"sh",
+		shell,
+		"user",
+		"discard_old_pwd",
+		fmt.Sprintf(`{"name":"%s","ip":"%s"}`, config.Name, config.IP),
+	}
+
+	find, _, err := beApp.doAppDBCmd(appID, nil, "", [][]string{cmd})
+	if err != nil {
+		return err
+	}
+	if !find {
+		return stderror.Errorf("Cannot find master pod")
+	}
+
+	if beApp.credentials == nil {
+		return nil
+	}
+
+	c, err := beApp.credentials.GetDBUserCredential(appID, config.Name, config.IP)
+	if model.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := beApp.discardPreviousPasswordSecret(c); err != nil {
+		return err
+	}
+
+	c.Retained = false
+
+	return beApp.credentials.SaveDBUserCredential(c)
+}
+
+// RunPasswordRotation 定期检查密码过期，发布即将过期事件，
+// 自动轮换过期的生成密码，并丢弃保留超过 RetainOldHours 的旧密码
+func (beApp *bankendApp) RunPasswordRotation(interval time.Duration, events eventPublisher, stopCh <-chan struct{}) {
+	if interval <= 0 || beApp.credentials == nil {
+		return
+	}
+
+	beApp.events = events
+
+	go wait.Until(beApp.checkPasswords, interval, stopCh)
+}
+
+func (beApp *bankendApp) checkPasswords() {
+	list, err := beApp.credentials.ListDBUserCredentials("")
+	if err != nil {
+		klog.Errorf("check db user passwords:%s", err)
+		return
+	}
+
+	policy := beApp.passwordPolicy
+	now := time.Now()
+
+	for _, c := range list {
+		user := c.Name + "@" + c.IP
+
+		if _, err := beApp.m.Get(c.App); model.IsNotExist(err) {
+			// 服务已删除
+			if err := beApp.credentials.DeleteDBUserCredential(c.App, c.Name, c.IP); err != nil {
+				klog.Errorf("delete credential of %s of deleted app %s:%s", user, c.App, err)
+			}
+			continue
+		}
+
+		if c.Retained && policy.RetainOldHours > 0 && now.Sub(c.ChangedAt) >= time.Duration(policy.RetainOldHours)*time.Hour {
+			err := beApp.DiscardAppDBUserOldPassword(context.Background(), c.App, api.AppUserDiscardPasswordConfig{Name: c.Name, IP: c.IP})
+			if err != nil {
+				klog.Errorf("discard old password of %s of app %s:%s", user, c.App, err)
+			}
+		}
+
+		expires, ok := passwordExpiresAt(policy, c.ChangedAt)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case !now.Before(expires) && c.Generated && policy.AutoRotate:
+			retained, err := beApp.rotatePassword(c)
+			if err != nil {
+				klog.Errorf("rotate password of %s of app %s:%s", user, c.App, err)
+				continue
+			}
+
+			msg := "password rotated"
+			if retained {
+				msg = fmt.Sprintf("password rotated,the old password is retained for %d hours", policy.RetainOldHours)
+			}
+
+			beApp.publishPasswordEvent(api.EventDBUserPasswordRotated, c, msg)
+
+		case !c.Warned && !now.Before(expires.AddDate(0, 0, -policy.WarnDays)):
+			beApp.publishPasswordEvent(api.EventDBUserPasswordExpiring, c, "password expires at "+expires.Format(api.TimeFormat))
+
+			c.Warned = true
+			if err := beApp.credentials.SaveDBUserCredential(c); err != nil {
+				klog.Errorf("save credential of %s of app %s:%s", user, c.App, err)
+			}
+		}
+	}
+}
+
+func (beApp *bankendApp) publishPasswordEvent(typ string, c model.DBUserCredential, msg string) {
+	klog.Infof("db user %s@%s of app %s:%s", c.Name, c.IP, c.App, msg)
+
+	if beApp.events == nil {
+		return
+	}
+
+	beApp.events.publish(api.Event{
+		Type:    typ,
+		Object:  c.Name + "@" + c.IP,
+		Name:    c.Name,
+		App:     c.App,
+		Message: msg,
+	})
+}
+
+// rotatePassword 生成新密码，支持双密码时保留旧密码
+func (beApp *bankendApp) rotatePassword(c model.DBUserCredential) (bool, error) {
+	app, err := beApp.m.Get(c.App)
+	if err != nil {
+		return false, err
+	}
+
+	spec, err := decodeAppSpec(app.Spec)
+	if err != nil {
+		return false, err
+	}
+
+	user, err := beApp.GetAppDBUser(context.Background(), c.App, c.Name, c.IP)
+	if err != nil {
+		return false, err
+	}
+
+	config := api.AppUserResetConfig{
+		Name:          c.Name,
+		IP:            c.IP,
+		AuthType:      user.AuthType,
+		RetainCurrent: supportsDualPassword(spec),
+	}
+
+	return config.RetainCurrent, beApp.ResetAppDBUser(context.Background(), c.App, config)
+}
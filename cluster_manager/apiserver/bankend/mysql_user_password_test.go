@@ -0,0 +1,195 @@
+package bankend
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	unitv4 "github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
+	"github.com/upmio/dbscale-kube/pkg/zone"
+	"github.com/upmio/dbscale-kube/pkg/zone/site"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	policy := api.PasswordPolicy{
+		MinLength:      24,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+	}
+
+	for i := 0; i < 50; i++ {
+		pwd, err := generatePassword(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(pwd) != 24 {
+			t.Errorf("expected 24 characters but got %d", len(pwd))
+		}
+		if err := policy.Check(pwd); err != nil {
+			t.Errorf("generated password %q:%s", pwd, err)
+		}
+	}
+}
+
+func TestPasswordHistory(t *testing.T) {
+	policy := api.PasswordPolicy{History: 2}
+	history := ""
+
+	for _, pwd := range []string{"first", "second", "third"} {
+		hash, err := hashPassword(pwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		history = pushPasswordHistory(history, hash, policy.History)
+	}
+
+	if n := len(splitPasswordHistory(history)); n != 2 {
+		t.Fatalf("expected 2 hashes in history but got %d", n)
+	}
+
+	for pwd, reused := range map[string]bool{"first": false, "second": true, "third": true} {
+		err := checkPasswordHistory(policy, history, pwd)
+		if (err != nil) != reused {
+			t.Errorf("password %s,expected reused=%t but got %v", pwd, reused, err)
+		}
+	}
+}
+
+func TestMaskCmdSecrets(t *testing.T) {
+	cmd := []string{"sh", "user", "add", `{"name":"u1","password":"Secret123"}`}
+
+	out := maskCmdSecrets(cmd)
+
+	if strings.Contains(strings.Join(out, " "), "Secret123") {
+		t.Errorf("password is not masked:%v", out)
+	}
+	if cmd[3] != `{"name":"u1","password":"Secret123"}` {
+		t.Error("the origin cmd should not be changed")
+	}
+}
+
+type fakeSecrets struct {
+	site.SecretInterface
+	secrets map[string]*corev1.Secret
+}
+
+func (f *fakeSecrets) Get(namespace, name string) (*corev1.Secret, error) {
+	if secret, ok := f.secrets[namespace+"/"+name]; ok {
+		return secret.DeepCopy(), nil
+	}
+
+	return nil, errors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+}
+
+func (f *fakeSecrets) Create(namespace string, secret *corev1.Secret) (*corev1.Secret, error) {
+	f.secrets[namespace+"/"+secret.Name] = secret.DeepCopy()
+	return secret, nil
+}
+
+func (f *fakeSecrets) Update(namespace string, secret *corev1.Secret) (*corev1.Secret, error) {
+	f.secrets[namespace+"/"+secret.Name] = secret.DeepCopy()
+	return secret, nil
+}
+
+func (f *fakeSecrets) Delete(namespace, name string, options metav1.DeleteOptions) error {
+	delete(f.secrets, namespace+"/"+name)
+	return nil
+}
+
+// fakeUnits 单元不可用，数据库命令执行失败
+type fakeUnits struct {
+	site.UnitInterface
+}
+
+func (fakeUnits) Get(namespace, name string) (*unitv4.Unit, error) {
+	return nil, errors.NewServiceUnavailable("unit " + name)
+}
+
+func (fakeUnits) List(namespace string, opts metav1.ListOptions) ([]unitv4.Unit, error) {
+	return nil, errors.NewServiceUnavailable("units")
+}
+
+type fakeSite struct {
+	site.Interface
+	secrets *fakeSecrets
+}
+
+func (f fakeSite) Secrets() site.SecretInterface { return f.secrets }
+func (f fakeSite) Units() site.UnitInterface     { return fakeUnits{} }
+
+type fakeZoneSite struct {
+	zone.Site
+}
+
+func (fakeZoneSite) Name() string { return "site1" }
+
+type fakeZone struct {
+	zone.ZoneInterface
+	site fakeSite
+}
+
+func (f fakeZone) SiteInterface(name string) (site.Interface, error) { return f.site, nil }
+func (f fakeZone) ListSites() []zone.Site                            { return []zone.Site{fakeZoneSite{}} }
+
+type fakeUserApp struct {
+	modelApp
+	app model.Application
+}
+
+func (f fakeUserApp) Get(id string) (model.Application, error) {
+	return f.app, nil
+}
+
+func TestDBUserPasswordRestoredOnFailure(t *testing.T) {
+	secrets := &fakeSecrets{secrets: map[string]*corev1.Secret{}}
+	credentials := model.NewFakeModels().ModelDBUserCredential()
+
+	app := model.Application{
+		ID:    "app1",
+		Units: []model.Unit{{ID: "app1-mysql-0", App: "app1", Site: "site1", Namespace: "default"}},
+	}
+
+	beApp := NewAppBankend(nil, fakeUserApp{app: app}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, credentials, api.PasswordPolicy{})
+	beApp.zone = zoneIface{zone: fakeZone{site: fakeSite{secrets: secrets}}}
+
+	ctx := context.Background()
+	config := api.AppUserConfig{DatabaseUser: api.DatabaseUser{Name: "u1", IP: "%"}}
+
+	_, err := beApp.AddAppDBUsers(ctx, "app1", []api.AppUserConfig{config}, app.Units, "app1-mysql-0")
+	if err == nil {
+		t.Fatal("expected the user add failed")
+	}
+
+	if len(secrets.secrets) != 0 {
+		t.Errorf("expected the secret deleted but got %v", secrets.secrets)
+	}
+
+	if _, err := credentials.GetDBUserCredential("app1", "u1", "%"); !model.IsNotExist(err) {
+		t.Errorf("expected no credential recorded but got %v", err)
+	}
+
+	key := "default/" + dbUserSecretName("app1", "u1", "%")
+	secrets.secrets[key] = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: dbUserSecretName("app1", "u1", "%")},
+		Data:       map[string][]byte{secretKeyPassword: []byte("current")},
+	}
+
+	err = beApp.ResetAppDBUser(ctx, "app1", api.AppUserResetConfig{Name: "u1", IP: "%"})
+	if err == nil {
+		t.Fatal("expected the password reset failed")
+	}
+
+	if secret := secrets.secrets[key]; secret == nil || string(secret.Data[secretKeyPassword]) != "current" {
+		t.Errorf("expected the secret restored but got %+v", secret)
+	}
+}
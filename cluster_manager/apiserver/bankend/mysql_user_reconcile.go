@@ -0,0 +1,391 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	stderror "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/audit"
+)
+
+func NewDBUserBankend(apps dbUserApps, ma appGetter, m modelDBUserSpec, events eventPublisher) *bankendDBUser {
+	return &bankendDBUser{
+		apps:   apps,
+		ma:     ma,
+		m:      m,
+		events: events,
+	}
+}
+
+type bankendDBUser struct {
+	apps   dbUserApps
+	ma     appGetter
+	m      modelDBUserSpec
+	events eventPublisher
+}
+
+type dbUserApps interface {
+	CheckAppAndSubscription(ctx context.Context, appId, subscriptionId string) error
+	ListAppDBUsers(ctx context.Context, appID string) (api.AppUsersResponse, error)
+	AddAppDBUser(ctx context.Context, id string, config api.AppUserConfig) (api.TaskObjectResponse, error)
+	UpdateUserPrivileges(ctx context.Context, appID string, opts api.AppUserPrivilegesOptions) error
+}
+
+type modelDBUserSpec interface {
+	SaveDBUserSpec(s model.DBUserSpec) error
+	UpdateDBUserSpecStatus(app, status string, drifted bool) error
+	DeleteDBUserSpec(app string) error
+	GetDBUserSpec(app string) (model.DBUserSpec, error)
+	ListDBUserSpecs() ([]model.DBUserSpec, error)
+}
+
+func (b *bankendDBUser) SetAppDBUserSpec(ctx context.Context, appID, subscriptionID string, spec api.AppDBUserSpec) (api.AppDBUserSpecResponse, error) {
+	if err := b.apps.CheckAppAndSubscription(ctx, appID, subscriptionID); err != nil {
+		return api.AppDBUserSpecResponse{}, err
+	}
+
+	data, err := json.Marshal(spec.Users)
+	if err != nil {
+		return api.AppDBUserSpecResponse{}, err
+	}
+
+	before, err := b.m.GetDBUserSpec(appID)
+	if err != nil && !model.IsNotExist(err) {
+		return api.AppDBUserSpecResponse{}, err
+	}
+
+	err = b.m.SaveDBUserSpec(model.DBUserSpec{
+		App:      appID,
+		Mode:     spec.Mode,
+		Users:    string(data),
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return api.AppDBUserSpecResponse{}, err
+	}
+
+	var old interface{}
+	if before.App != "" {
+		old, _ = convertDBUserSpec(before)
+	}
+
+	audit.Record(ctx, api.AuditDBUserSpecUpdate, "db_user_spec", appID, old, spec)
+
+	return b.getAppDBUserSpec(appID)
+}
+
+func (b *bankendDBUser) GetAppDBUserSpec(ctx context.Context, appID, subscriptionID string) (api.AppDBUserSpecResponse, error) {
+	if err := b.apps.CheckAppAndSubscription(ctx, appID, subscriptionID); err != nil {
+		return api.AppDBUserSpecResponse{}, err
+	}
+
+	return b.getAppDBUserSpec(appID)
+}
+
+func (b *bankendDBUser) getAppDBUserSpec(appID string) (api.AppDBUserSpecResponse, error) {
+	s, err := b.m.GetDBUserSpec(appID)
+	if err != nil {
+		return api.AppDBUserSpecResponse{}, err
+	}
+
+	return convertDBUserSpec(s)
+}
+
+func (b *bankendDBUser) DeleteAppDBUserSpec(ctx context.Context, appID, subscriptionID string) error {
+	if err := b.apps.CheckAppAndSubscription(ctx, appID, subscriptionID); err != nil {
+		return err
+	}
+
+	before, err := b.m.GetDBUserSpec(appID)
+	if model.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := b.m.DeleteDBUserSpec(appID); err != nil {
+		return err
+	}
+
+	old, _ := convertDBUserSpec(before)
+	audit.Record(ctx, api.AuditDBUserSpecDelete, "db_user_spec", appID, old, nil)
+
+	return nil
+}
+
+// ReconcileAppDBUser 立即比较一次，按期望的 mode 报告或修正差异
+func (b *bankendDBUser) ReconcileAppDBUser(ctx context.Context, appID, subscriptionID string) (api.DBUserReconcileStatus, error) {
+	if err := b.apps.CheckAppAndSubscription(ctx, appID, subscriptionID); err != nil {
+		return api.DBUserReconcileStatus{}, err
+	}
+
+	s, err := b.m.GetDBUserSpec(appID)
+	if err != nil {
+		return api.DBUserReconcileStatus{}, err
+	}
+
+	return b.reconcile(ctx, s)
+}
+
+// ListAppUnmanagedDBUsers 实例中不在期望中的用户，服务未设置期望时返回所有用户
+func (b *bankendDBUser) ListAppUnmanagedDBUsers(ctx context.Context, appID, subscriptionID string) (api.AppUsersResponse, error) {
+	if err := b.apps.CheckAppAndSubscription(ctx, appID, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	var want []api.DBUserSpec
+
+	s, err := b.m.GetDBUserSpec(appID)
+	if err == nil {
+		if err := json.Unmarshal([]byte(s.Users), &want); err != nil {
+			return nil, stderror.Wrapf(err, "decode db user spec of app %s", appID)
+		}
+	} else if !model.IsNotExist(err) {
+		return nil, err
+	}
+
+	live, err := b.apps.ListAppDBUsers(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, unmanaged := diffDBUsers(want, live)
+
+	return unmanaged, nil
+}
+
+// RunReconcile 定期比较设置了期望的服务，服务删除后删除期望
+func (b *bankendDBUser) RunReconcile(interval time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	go wait.Until(b.reconcileAll, interval, stopCh)
+}
+
+func (b *bankendDBUser) reconcileAll() {
+	specs, err := b.m.ListDBUserSpecs()
+	if err != nil {
+		klog.Errorf("list db user specs:%s", err)
+		return
+	}
+
+	for i := range specs {
+		_, err := b.ma.Get(specs[i].App)
+		if model.IsNotExist(err) {
+			if err := b.m.DeleteDBUserSpec(specs[i].App); err != nil {
+				klog.Errorf("delete db user spec of app %s:%s", specs[i].App, err)
+			}
+
+			continue
+		}
+		if err != nil {
+			klog.Errorf("get app %s:%s", specs[i].App, err)
+			continue
+		}
+
+		if _, err := b.reconcile(context.Background(), specs[i]); err != nil {
+			klog.Errorf("reconcile db users of app %s:%s", specs[i].App, err)
+		}
+	}
+}
+
+func (b *bankendDBUser) reconcile(ctx context.Context, s model.DBUserSpec) (api.DBUserReconcileStatus, error) {
+	var want []api.DBUserSpec
+
+	if err := json.Unmarshal([]byte(s.Users), &want); err != nil {
+		return api.DBUserReconcileStatus{}, stderror.Wrapf(err, "decode db user spec of app %s", s.App)
+	}
+
+	status := api.DBUserReconcileStatus{
+		CheckedAt: api.Time(time.Now()),
+	}
+
+	live, err := b.apps.ListAppDBUsers(ctx, s.App)
+	if err != nil {
+		// 实例不可用时保留上一次的状态
+		status.Error = err.Error()
+		status.State = api.DBUserStateInSync
+		if s.Drifted {
+			status.State = api.DBUserStateDrifted
+		}
+
+		return status, b.saveStatus(s, status)
+	}
+
+	status.Drifts, status.Unmanaged = diffDBUsers(want, live)
+
+	if s.Mode == api.DBUserReconcileEnforce {
+		for i := range status.Drifts {
+			err := b.correct(ctx, s.App, want, status.Drifts[i])
+			if err != nil {
+				status.Drifts[i].Error = err.Error()
+				continue
+			}
+
+			status.Drifts[i].Corrected = true
+		}
+	}
+
+	status.State = api.DBUserStateInSync
+	for _, d := range status.Drifts {
+		if !d.Corrected {
+			status.State = api.DBUserStateDrifted
+			break
+		}
+	}
+
+	return status, b.saveStatus(s, status)
+}
+
+func (b *bankendDBUser) correct(ctx context.Context, app string, want []api.DBUserSpec, drift api.DBUserDrift) error {
+	switch drift.Type {
+	case api.DBUserDriftMissing:
+		for _, u := range want {
+			if u.Name != drift.Name || u.IP != drift.IP {
+				continue
+			}
+
+			config := api.AppUserConfig{
+				DatabaseUser: api.DatabaseUser{
+					Name:       u.Name,
+					IP:         u.IP,
+					AuthType:   u.AuthType,
+					Privileges: u.Privileges,
+				},
+			}
+			if config.AuthType == "" {
+				config.AuthType = "mysql_native_password"
+			}
+
+			// 未指定密码，由服务端生成并保存到 Secret
+			_, err := b.apps.AddAppDBUser(ctx, app, config)
+
+			return err
+		}
+
+		return stderror.Errorf("user %s not found in spec", userKey(drift.Name, drift.IP))
+
+	case api.DBUserDriftPrivileges:
+		return b.apps.UpdateUserPrivileges(ctx, app, api.AppUserPrivilegesOptions{
+			Name:       drift.Name,
+			IP:         drift.IP,
+			Privileges: drift.Expected,
+		})
+	}
+
+	return stderror.Errorf("unsupported drift type %s", drift.Type)
+}
+
+// saveStatus 保存比较结果，状态变化时发布 db_user.drift 事件
+func (b *bankendDBUser) saveStatus(s model.DBUserSpec, status api.DBUserReconcileStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	drifted := status.State == api.DBUserStateDrifted
+
+	if drifted != s.Drifted && b.events != nil {
+		prev := api.DBUserStateInSync
+		if s.Drifted {
+			prev = api.DBUserStateDrifted
+		}
+
+		b.events.publish(api.Event{
+			Type:      api.EventDBUserDrift,
+			Object:    s.App,
+			App:       s.App,
+			State:     status.State,
+			PrevState: prev,
+		})
+	}
+
+	return b.m.UpdateDBUserSpecStatus(s.App, string(data), drifted)
+}
+
+// diffDBUsers 返回期望中不存在或权限不同的用户，以及实例中不在期望中的用户
+func diffDBUsers(want []api.DBUserSpec, live []api.DatabaseUser) ([]api.DBUserDrift, []api.DatabaseUser) {
+	exist := make(map[string]api.DatabaseUser, len(live))
+	for _, u := range live {
+		exist[userKey(u.Name, u.IP)] = u
+	}
+
+	wanted := make(map[string]bool, len(want))
+	drifts := []api.DBUserDrift{}
+
+	for _, u := range want {
+		key := userKey(u.Name, u.IP)
+		wanted[key] = true
+
+		cur, ok := exist[key]
+		if !ok {
+			drifts = append(drifts, api.DBUserDrift{
+				Name:     u.Name,
+				IP:       u.IP,
+				Type:     api.DBUserDriftMissing,
+				Expected: u.Privileges,
+			})
+
+			continue
+		}
+
+		if u.Privileges == nil || samePrivileges(cur.Privileges, u.Privileges) {
+			continue
+		}
+
+		drifts = append(drifts, api.DBUserDrift{
+			Name:     u.Name,
+			IP:       u.IP,
+			Type:     api.DBUserDriftPrivileges,
+			Expected: u.Privileges,
+			Actual:   cur.Privileges,
+		})
+	}
+
+	unmanaged := []api.DatabaseUser{}
+	for _, u := range live {
+		if !wanted[userKey(u.Name, u.IP)] {
+			u.Password = ""
+			unmanaged = append(unmanaged, u)
+		}
+	}
+
+	sort.Slice(unmanaged, func(i, j int) bool {
+		return userKey(unmanaged[i].Name, unmanaged[i].IP) < userKey(unmanaged[j].Name, unmanaged[j].IP)
+	})
+
+	return drifts, unmanaged
+}
+
+func convertDBUserSpec(s model.DBUserSpec) (api.AppDBUserSpecResponse, error) {
+	out := api.AppDBUserSpecResponse{
+		AppDBUserSpec: api.AppDBUserSpec{
+			Mode: s.Mode,
+		},
+		Modified: api.Time(s.Modified),
+	}
+
+	if err := json.Unmarshal([]byte(s.Users), &out.Users); err != nil {
+		return out, stderror.Wrapf(err, "decode db user spec of app %s", s.App)
+	}
+
+	if s.Status != "" {
+		status := &api.DBUserReconcileStatus{}
+		if err := json.Unmarshal([]byte(s.Status), status); err != nil {
+			return out, stderror.Wrapf(err, "decode db user status of app %s", s.App)
+		}
+
+		out.Status = status
+	}
+
+	return out, nil
+}
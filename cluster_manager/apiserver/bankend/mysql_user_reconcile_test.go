@@ -0,0 +1,126 @@
+package bankend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+type fakeDBUserApps struct {
+	users   api.AppUsersResponse
+	added   []api.AppUserConfig
+	updated []api.AppUserPrivilegesOptions
+}
+
+func (f *fakeDBUserApps) CheckAppAndSubscription(ctx context.Context, appId, subscriptionId string) error {
+	return nil
+}
+
+func (f *fakeDBUserApps) ListAppDBUsers(ctx context.Context, appID string) (api.AppUsersResponse, error) {
+	return f.users, nil
+}
+
+func (f *fakeDBUserApps) AddAppDBUser(ctx context.Context, id string, config api.AppUserConfig) (api.TaskObjectResponse, error) {
+	f.added = append(f.added, config)
+	return api.TaskObjectResponse{}, nil
+}
+
+func (f *fakeDBUserApps) UpdateUserPrivileges(ctx context.Context, appID string, opts api.AppUserPrivilegesOptions) error {
+	f.updated = append(f.updated, opts)
+	return nil
+}
+
+type fakeEventPublisher struct {
+	events []api.Event
+}
+
+func (f *fakeEventPublisher) publish(ev api.Event) {
+	f.events = append(f.events, ev)
+}
+
+func TestReconcileDBUsers(t *testing.T) {
+	read := []api.DatabasePrivilege{{DBName: "db1", Privileges: []string{"SELECT"}}}
+	write := []api.DatabasePrivilege{{DBName: "db1", Privileges: []string{"select", "INSERT"}}}
+
+	apps := &fakeDBUserApps{
+		users: api.AppUsersResponse{
+			{Name: "app", IP: "%", Privileges: read},
+			{Name: "report", IP: "%", Privileges: read},
+			{Name: "manual", IP: "10.0.0.1", Privileges: write},
+		},
+	}
+
+	events := &fakeEventPublisher{}
+	m := model.NewFakeModels().ModelDBUserSpec()
+	b := NewDBUserBankend(apps, nil, m, events)
+	ctx := context.Background()
+
+	spec := api.AppDBUserSpec{
+		Users: []api.DBUserSpec{
+			{Name: "app", IP: "%", Privileges: write},
+			{Name: "report", IP: "%"},
+			{Name: "etl", IP: "%", Privileges: read},
+		},
+	}
+	if err := spec.Valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.SetAppDBUserSpec(ctx, "app1", "", spec); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := b.ReconcileAppDBUser(ctx, "app1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.State != api.DBUserStateDrifted || len(status.Drifts) != 2 {
+		t.Fatalf("expected 2 drifts but got %s %+v", status.State, status.Drifts)
+	}
+	if status.Drifts[0].Type != api.DBUserDriftPrivileges || status.Drifts[1].Type != api.DBUserDriftMissing {
+		t.Errorf("unexpected drifts %+v", status.Drifts)
+	}
+	if len(status.Unmanaged) != 1 || status.Unmanaged[0].Name != "manual" {
+		t.Errorf("expected unmanaged user manual but got %+v", status.Unmanaged)
+	}
+	if len(apps.added) != 0 || len(apps.updated) != 0 {
+		t.Error("report mode should not change users")
+	}
+	if len(events.events) != 1 || events.events[0].State != api.DBUserStateDrifted {
+		t.Errorf("expected a drifted event but got %+v", events.events)
+	}
+
+	spec.Mode = api.DBUserReconcileEnforce
+	if _, err := b.SetAppDBUserSpec(ctx, "app1", "", spec); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = b.ReconcileAppDBUser(ctx, "app1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.State != api.DBUserStateInSync {
+		t.Errorf("expected in sync after enforce but got %+v", status)
+	}
+	if len(apps.added) != 1 || apps.added[0].Name != "etl" || apps.added[0].Password != "" {
+		t.Errorf("expected user etl created with generated password but got %+v", apps.added)
+	}
+	if len(apps.updated) != 1 || apps.updated[0].Name != "app" {
+		t.Errorf("expected privileges of app updated but got %+v", apps.updated)
+	}
+	if len(events.events) != 2 || events.events[1].State != api.DBUserStateInSync {
+		t.Errorf("expected an in sync event but got %+v", events.events)
+	}
+
+	resp, err := b.GetAppDBUserSpec(ctx, "app1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status == nil || resp.Status.State != api.DBUserStateInSync {
+		t.Errorf("expected saved status but got %+v", resp.Status)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
 )
 
 func NewNetworkBankend(zone zone.ZoneInterface, m modelNetwork, sites siteGetter, clusters clusterGetter) *bankendNetwork {
@@ -38,6 +39,7 @@ type modelNetwork interface {
 	Insert(model.Network) (string, error)
 	Update(model.Network) error
 	Delete(name string) error
+	ListPage(opts api.ListOptions) ([]model.Network, api.ListMeta, error)
 
 	networkGetter
 }
@@ -165,11 +167,27 @@ func convertToNetworkAPI(mn model.Network, net v1alpha1.Network) api.Network {
 				Gateway: api.IP(net.Spec.Route),
 			},
 		},
-		Created:  api.NewEditor(mn.CreatedUser, mn.CreatedAt),
-		Modified: api.NewEditor(mn.ModifiedUser, mn.ModifiedAt),
+		Conflicts: convertToIPConflictsAPI(net.Status.Quarantined),
+		Created:   api.NewEditor(mn.CreatedUser, mn.CreatedAt),
+		Modified:  api.NewEditor(mn.ModifiedUser, mn.ModifiedAt),
 	}
 }
 
+func convertToIPConflictsAPI(conflicts []v1alpha1.IPConflict) []api.IPConflict {
+	out := make([]api.IPConflict, len(conflicts))
+
+	for i, c := range conflicts {
+		out[i] = api.IPConflict{
+			IP:     api.IP(c.IP),
+			Claim:  c.Claim,
+			Reason: c.Reason,
+			Time:   api.Time(c.Time.Time),
+		}
+	}
+
+	return out
+}
+
 func (b *bankendNetwork) List(ctx context.Context, id, name, cluster, site, topology, enabled string) ([]api.Network, error) {
 	selector := make(map[string]string)
 	if topology != "" {
@@ -222,6 +240,33 @@ func (b *bankendNetwork) List(ctx context.Context, id, name, cluster, site, topo
 		list = out
 	}
 
+	return b.convertNetworks(list), nil
+}
+
+// ListPage lists a page of networks,site isn't a column of network,it's converted to the filter of clusters.
+func (b *bankendNetwork) ListPage(ctx context.Context, site string, opts api.ListOptions) (api.ListResponse, error) {
+	if site != "" {
+		filter, err := siteClustersFilter(b.clusters, site)
+		if err != nil {
+			return api.ListResponse{}, err
+		}
+
+		if len(filter.Values) == 0 {
+			return api.NewListResponse([]api.Network{}, api.ListMeta{}, opts.Fields)
+		}
+
+		opts.Filters = append(opts.Filters, filter)
+	}
+
+	list, meta, err := b.m.ListPage(opts)
+	if err != nil {
+		return api.ListResponse{}, err
+	}
+
+	return api.NewListResponse(b.convertNetworks(list), meta, opts.Fields)
+}
+
+func (b *bankendNetwork) convertNetworks(list []model.Network) []api.Network {
 	errs := make([]error, 0, len(list))
 	nm := make(map[string]v1alpha1.Network)
 	networks := make([]api.Network, len(list))
@@ -259,7 +304,7 @@ func (b *bankendNetwork) List(ctx context.Context, id, name, cluster, site, topo
 		klog.Errorln("network list:", err)
 	}
 
-	return networks, nil
+	return networks
 }
 
 func (b *bankendNetwork) Set(ctx context.Context, id string, opts api.NetworkOptions) (api.Network, error) {
@@ -328,3 +373,47 @@ func (b *bankendNetwork) Delete(ctx context.Context, id string) error {
 
 	return b.m.Delete(mn.ID)
 }
+
+// ReleaseConflict 清除隔离记录，由 network controller 解除隔离并释放 IP
+func (b *bankendNetwork) ReleaseConflict(ctx context.Context, id, ip string) error {
+	mn, err := b.m.Get(id)
+	if err != nil {
+		return err
+	}
+
+	iface, err := b.zone.NetworkInterface(mn.Cluster.SiteID)
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		networking, err := iface.Get(mn.ObjectName())
+		if err != nil {
+			return err
+		}
+
+		found := false
+		quarantined := make([]v1alpha1.IPConflict, 0, len(networking.Status.Quarantined))
+
+		for _, c := range networking.Status.Quarantined {
+			if c.IP == ip {
+				found = true
+				continue
+			}
+
+			quarantined = append(quarantined, c)
+		}
+
+		if !found {
+			return nil
+		}
+
+		networking = networking.DeepCopy()
+		networking.Status.Quarantined = quarantined
+		networking.Status.Conflicts = utils.RemoveString(networking.Status.Conflicts, ip)
+
+		_, err = iface.UpdateStatus(networking)
+
+		return err
+	})
+}
@@ -0,0 +1,107 @@
+package bankend
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/revision"
+)
+
+// 资源版本的类型，与 revision 路由的 Kind 一致
+const (
+	revisionKindApp   = "app"
+	revisionKindHost  = "host"
+	revisionKindImage = "image"
+)
+
+type modelRevision interface {
+	GetRevision(kind, id string) (int64, error)
+	BumpRevision(kind, id string, expect int64) (int64, bool, error)
+	RollbackRevision(kind, id string, rev int64) error
+	DeleteRevision(kind, id string) error
+}
+
+// revisionBumper increases the revision of the resource changed by the bankend
+type revisionBumper interface {
+	bump(kind, id string)
+}
+
+func NewRevisionBankend(m modelRevision, tasks taskGetter) *bankendRevision {
+	return &bankendRevision{m: m, tasks: tasks}
+}
+
+// bankendRevision stores the resource revisions into database,and implements revision.Store.
+type bankendRevision struct {
+	m     modelRevision
+	tasks taskGetter
+}
+
+func (b *bankendRevision) Revision(kind, id string) (int64, error) {
+	return b.m.GetRevision(kind, id)
+}
+
+func (b *bankendRevision) Bump(kind, id string, expect int64) (int64, error) {
+	rev, ok, err := b.m.BumpRevision(kind, id, expect)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return rev, &revision.ConflictError{
+			Kind:    kind,
+			ID:      id,
+			Expect:  expect,
+			Current: rev,
+		}
+	}
+
+	return rev, nil
+}
+
+func (b *bankendRevision) Rollback(kind, id string, rev int64) error {
+	return b.m.RollbackRevision(kind, id, rev)
+}
+
+func (b *bankendRevision) Delete(kind, id string) error {
+	return b.m.DeleteRevision(kind, id)
+}
+
+func (b *bankendRevision) bump(kind, id string) {
+	if id == "" {
+		return
+	}
+
+	if _, _, err := b.m.BumpRevision(kind, id, -1); err != nil {
+		klog.Errorf("bump revision of %s %s:%s", kind, id, err)
+	}
+}
+
+// HandleEvent bumps the revision of the resource when its task finished,
+// the tasks change apps,hosts and images in background after the request returned.
+func (b *bankendRevision) HandleEvent(ev api.Event) {
+	if ev.Type != api.EventTaskFinished {
+		return
+	}
+
+	if ev.App != "" {
+		b.bump(revisionKindApp, ev.App)
+		return
+	}
+
+	tk, err := b.tasks.Get(ev.Object)
+	if err != nil {
+		if !model.IsNotExist(err) {
+			klog.Errorf("get task %s for revision:%s", ev.Object, err)
+		}
+
+		return
+	}
+
+	switch tk.RelateTable {
+	case model.Host{}.Table():
+		b.bump(revisionKindHost, tk.RelateID)
+	case model.Image{}.Table():
+		b.bump(revisionKindImage, tk.RelateID)
+	}
+}
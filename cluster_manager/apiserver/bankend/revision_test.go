@@ -0,0 +1,36 @@
+package bankend
+
+import (
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+func TestRevisionHandleEvent(t *testing.T) {
+	fm := model.NewFakeModels()
+	mt := fm.ModelTask()
+	b := NewRevisionBankend(fm.ModelRevision(), mt)
+
+	hostTask, _ := mt.Insert(model.NewTask("host_add", "h1", model.Host{}.Table(), "admin"))
+	imageTask, _ := mt.Insert(model.NewTask("image_add", "i1", model.Image{}.Table(), "admin"))
+
+	b.HandleEvent(api.Event{Type: api.EventTaskCreated, Object: "t0", App: "a1"})
+	b.HandleEvent(api.Event{Type: api.EventTaskFinished, Object: "t1", App: "a1"})
+	b.HandleEvent(api.Event{Type: api.EventTaskFinished, Object: hostTask})
+	b.HandleEvent(api.Event{Type: api.EventTaskFinished, Object: imageTask})
+	b.HandleEvent(api.Event{Type: api.EventTaskFinished, Object: "unknown"})
+
+	for _, c := range []struct {
+		kind, id string
+		want     int64
+	}{
+		{revisionKindApp, "a1", 1},
+		{revisionKindHost, "h1", 1},
+		{revisionKindImage, "i1", 1},
+	} {
+		if rev, err := b.Revision(c.kind, c.id); err != nil || rev != c.want {
+			t.Errorf("%s %s: expect revision %d,got %d,%v", c.kind, c.id, c.want, rev, err)
+		}
+	}
+}
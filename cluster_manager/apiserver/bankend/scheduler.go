@@ -288,6 +288,8 @@ type planController struct {
 	zone zoneIface
 
 	units []unitv4.Unit
+	// variants 多架构服务每个单元使用的架构及镜像
+	variants []unitVariant
 }
 
 func NewPlanController(z zoneIface) *planController {
@@ -321,6 +323,9 @@ func (ctrl *planController) DeployService(appName, groupName, groupType string,
 
 	for count := 0; count < replicas; count += 1 {
 		unit := tmpl.DeepCopy()
+		if count < len(ctrl.variants) {
+			setUnitVariant(unit, ctrl.variants[count])
+		}
 
 		options := map[string]string{"count": strconv.Itoa(count + 1)}
 		setSpecificEnv(unit, options)
@@ -658,7 +663,10 @@ func (beApp *bankendApp) injectSchedulerInfo(unit *unitv4.Unit, arch string, rep
 	}
 
 	unit.Spec.Template.Spec.NodeSelector[labelRole] = vars.NodeRolenode
-	unit.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable] = arch
+	// 多架构服务的架构由 setUnitVariant 按单元设置
+	if arch != api.ArchMulti {
+		unit.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable] = arch
+	}
 
 	//TODO: use setpodAffinity
 	unit.Spec.Template.Spec.Affinity = &corev1.Affinity{}
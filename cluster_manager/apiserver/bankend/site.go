@@ -5,6 +5,7 @@ import (
 	"fmt"
 	stderror "github.com/pkg/errors"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/dashboard"
+	"github.com/upmio/dbscale-kube/pkg/audit"
 	"github.com/upmio/dbscale-kube/pkg/server"
 	"io/ioutil"
 	"net"
@@ -50,6 +51,7 @@ type modelSite interface {
 	Insert(model.Site) (string, error)
 	Update(model.Site) error
 	Delete(name string) error
+	ListPage(opts api.ListOptions) ([]model.Site, api.ListMeta, error)
 
 	siteGetter
 }
@@ -67,6 +69,9 @@ type bankendSite struct {
 	clusters clusterGetter
 	storages storageGetter
 	srv      *server.Server
+
+	events   eventPublisher
+	statuses siteStatuses
 }
 
 func siteVersion(site zone.Site) (string, string, error) {
@@ -139,11 +144,20 @@ func (b *bankendSite) Add(ctx context.Context, config api.SiteConfig) (api.Site,
 
 	if config.Type == api.KubernetesSite {
 
+		preflight, err := b.Preflight(ctx, config)
+		if err != nil {
+			return out, err
+		}
+
+		if !preflight.Passed && !config.SkipPreflight {
+			return out, stderror.Errorf("site %s preflight failed:%s", config.Name, failedSiteChecks(preflight.Checks))
+		}
+
 		addr := net.JoinHostPort(config.Domain, b.execPort)
 
 		site := zone.NewK8sSite(ms.ID, config.Domain, addr, config.Path, config.Port)
 
-		err := b.zone.AddSite(site)
+		err = b.zone.AddSite(site)
 		if err != nil {
 			return out, err
 		}
@@ -193,12 +207,31 @@ func (b *bankendSite) List(ctx context.Context, id, name string) ([]api.Site, er
 		return nil, err
 	}
 
+	return b.convertSites(list), nil
+}
+
+func (b *bankendSite) ListPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error) {
+	list, meta, err := b.ms.ListPage(opts)
+	if err != nil {
+		return api.ListResponse{}, err
+	}
+
+	return api.NewListResponse(b.convertSites(list), meta, opts.Fields)
+}
+
+func (b *bankendSite) convertSites(list []model.Site) []api.Site {
+	var err error
+
 	once := new(sync.Once)
 	sites := make([]api.Site, len(list))
 
 	for i := range list {
 		sites[i] = convertToSite(list[i])
 
+		if status, ok := b.statuses.get(sites[i].ID); ok {
+			sites[i].Status = &status
+		}
+
 		sites[i].State, sites[i].Version, err = b.siteVersion(sites[i].ID)
 		if err != nil {
 			once.Do(func() {
@@ -208,7 +241,7 @@ func (b *bankendSite) List(ctx context.Context, id, name string) ([]api.Site, er
 		}
 	}
 
-	return sites, nil
+	return sites
 }
 
 func (b *bankendSite) Set(ctx context.Context, id string, opts api.SiteOptions) error {
@@ -324,6 +357,10 @@ func (b *bankendSite) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
+	b.statuses.remove(site.ID)
+
+	audit.Record(ctx, api.AuditSiteDelete, "site", site.Name, convertToSite(site), nil)
+
 	return nil
 }
 
@@ -0,0 +1,384 @@
+package bankend
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	appv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/app/v1alpha1"
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+	networkv1 "github.com/upmio/dbscale-kube/pkg/apis/networking"
+	sanv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/san/v1alpha1"
+	unitv4 "github.com/upmio/dbscale-kube/pkg/apis/unit/v1alpha4"
+	lvmv1alpha1 "github.com/upmio/dbscale-kube/pkg/apis/volumepath/v1alpha1"
+)
+
+const (
+	siteCheckAPIServer   = "apiserver"
+	siteCheckVersion     = "version"
+	siteCheckCRDs        = "crds"
+	siteCheckExecService = "exec-service"
+	siteCheckMonitoring  = "prometheus-operator"
+	siteCheckOperator    = "app-operator"
+	siteCheckStorage     = "storage-classes"
+	siteCheckCache       = "cache"
+
+	siteCheckTimeout = 10 * time.Second
+
+	// 支持的 kubernetes 版本范围，client-go v0.18
+	minSupportedKubeMinor = 16
+	maxSupportedKubeMinor = 20
+)
+
+var (
+	// 与 cluster_engine/controller-manager/crds.go 创建的 CRD 保持一致
+	requiredSiteCRDs = []string{
+		"units." + unitv4.SchemeGroupVersion.Group,
+		"sansystems." + sanv1alpha1.SchemeGroupVersion.Group,
+		"hosts." + hostv1.SchemeGroupVersion.Group,
+		"lungroups." + sanv1alpha1.SchemeGroupVersion.Group,
+		"volumepaths." + lvmv1alpha1.SchemeGroupVersion.Group,
+		"networks." + networkv1.GroupName,
+		"networkclaims." + networkv1.GroupName,
+	}
+
+	// controller-manager 以 --manager-server 启动时才创建，App 对象及备份策略的 operator 依赖
+	operatorSiteCRDs = []string{
+		"apps." + appv1alpha1.SchemeGroupVersion.Group,
+		"backupstrategies." + appv1alpha1.SchemeGroupVersion.Group,
+	}
+
+	// 服务监控及告警规则依赖 prometheus operator
+	monitoringSiteCRDs = []string{
+		"servicemonitors.monitoring.coreos.com",
+		"prometheusrules.monitoring.coreos.com",
+	}
+)
+
+// eventPublisher is implemented by bankendEvent.
+type eventPublisher interface {
+	publish(ev api.Event)
+}
+
+// checkKubeVersion 低于支持范围为 critical，高于支持范围为 warning
+func checkKubeVersion(v *version.Info) api.SiteCheck {
+	check := api.SiteCheck{Name: siteCheckVersion, Status: api.SiteCheckPassing, Message: v.GitVersion}
+
+	major, err := strconv.Atoi(v.Major)
+	if err == nil {
+		var minor int
+		// 部分发行版的 minor 带有后缀，例如 "18+"
+		minor, err = strconv.Atoi(strings.TrimRight(v.Minor, "+"))
+
+		if err == nil && major == 1 {
+			switch {
+			case minor < minSupportedKubeMinor:
+				check.Status = api.SiteCheckCritical
+			case minor > maxSupportedKubeMinor:
+				check.Status = api.SiteCheckWarning
+			}
+		} else if err == nil {
+			check.Status = api.SiteCheckCritical
+		}
+	}
+
+	if err != nil {
+		check.Status = api.SiteCheckWarning
+		check.Message = fmt.Sprintf("unknown version %s.%s %s", v.Major, v.Minor, v.GitVersion)
+	} else if check.Status != api.SiteCheckPassing {
+		check.Message = fmt.Sprintf("%s is out of supported range 1.%d-1.%d", v.GitVersion, minSupportedKubeMinor, maxSupportedKubeMinor)
+	}
+
+	return check
+}
+
+func checkSiteCRDs(client apiextensions.Interface, name string, crds []string, missing string) api.SiteCheck {
+	var (
+		absent []string
+		errs   []string
+	)
+
+	for _, crd := range crds {
+		_, err := client.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), crd, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			absent = append(absent, crd)
+		} else if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	switch {
+	case len(errs) > 0:
+		return api.SiteCheck{Name: name, Status: api.SiteCheckCritical, Message: strings.Join(errs, ";")}
+	case len(absent) > 0:
+		return api.SiteCheck{Name: name, Status: missing, Message: "missing " + strings.Join(absent, ",")}
+	}
+
+	return api.SiteCheck{Name: name, Status: api.SiteCheckPassing}
+}
+
+func checkExecService(addr string) api.SiteCheck {
+	conn, err := net.DialTimeout("tcp", addr, siteCheckTimeout)
+	if err != nil {
+		return api.SiteCheck{Name: siteCheckExecService, Status: api.SiteCheckCritical, Message: err.Error()}
+	}
+	conn.Close()
+
+	return api.SiteCheck{Name: siteCheckExecService, Status: api.SiteCheckPassing, Message: addr}
+}
+
+func checkStorageClasses(client kubernetes.Interface) api.SiteCheck {
+	list, err := client.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return api.SiteCheck{Name: siteCheckStorage, Status: api.SiteCheckCritical, Message: err.Error()}
+	}
+
+	if len(list.Items) == 0 {
+		return api.SiteCheck{Name: siteCheckStorage, Status: api.SiteCheckWarning, Message: "no storage class found"}
+	}
+
+	names := make([]string, len(list.Items))
+	for i := range list.Items {
+		names[i] = list.Items[i].Name
+	}
+
+	return api.SiteCheck{Name: siteCheckStorage, Status: api.SiteCheckPassing, Message: strings.Join(names, ",")}
+}
+
+// sitePreflight 检查 API 连通性、版本范围、必需的 CRD、exec 服务、prometheus operator 及存储类，
+// API 不可达时不再执行依赖 API 的检查
+func sitePreflight(config *restclient.Config, execAddr string) api.SitePreflight {
+	config = restclient.CopyConfig(config)
+	config.Timeout = siteCheckTimeout
+
+	checks := make([]api.SiteCheck, 0, 6)
+
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err == nil {
+		var v *version.Info
+
+		v, err = kubeClient.Discovery().ServerVersion()
+		if err == nil {
+			checks = append(checks, api.SiteCheck{Name: siteCheckAPIServer, Status: api.SiteCheckPassing, Message: config.Host})
+			checks = append(checks, checkKubeVersion(v))
+		}
+	}
+
+	if err != nil {
+		checks = append(checks, api.SiteCheck{Name: siteCheckAPIServer, Status: api.SiteCheckCritical, Message: err.Error()})
+		checks = append(checks, checkExecService(execAddr))
+
+		return newSitePreflight(checks)
+	}
+
+	extClient, err := apiextensions.NewForConfig(config)
+	if err != nil {
+		checks = append(checks, api.SiteCheck{Name: siteCheckCRDs, Status: api.SiteCheckCritical, Message: err.Error()})
+	} else {
+		checks = append(checks, checkSiteCRDs(extClient, siteCheckCRDs, requiredSiteCRDs, api.SiteCheckCritical))
+		checks = append(checks, checkSiteCRDs(extClient, siteCheckOperator, operatorSiteCRDs, api.SiteCheckWarning))
+		checks = append(checks, checkSiteCRDs(extClient, siteCheckMonitoring, monitoringSiteCRDs, api.SiteCheckWarning))
+	}
+
+	checks = append(checks, checkExecService(execAddr))
+	checks = append(checks, checkStorageClasses(kubeClient))
+
+	return newSitePreflight(checks)
+}
+
+func newSitePreflight(checks []api.SiteCheck) api.SitePreflight {
+	return api.SitePreflight{
+		Passed: worstSiteCheck(checks) != api.SiteCheckCritical,
+		Checks: checks,
+	}
+}
+
+func worstSiteCheck(checks []api.SiteCheck) string {
+	state := api.SiteCheckPassing
+
+	for i := range checks {
+		switch checks[i].Status {
+		case api.SiteCheckCritical:
+			return api.SiteCheckCritical
+		case api.SiteCheckWarning:
+			state = api.SiteCheckWarning
+		}
+	}
+
+	return state
+}
+
+func failedSiteChecks(checks []api.SiteCheck) string {
+	var failed []string
+
+	for i := range checks {
+		if checks[i].Status == api.SiteCheckCritical {
+			failed = append(failed, fmt.Sprintf("%s:%s", checks[i].Name, checks[i].Message))
+		}
+	}
+
+	return strings.Join(failed, ";")
+}
+
+// Preflight 使用站点配置执行预检，不保存站点
+func (b *bankendSite) Preflight(ctx context.Context, config api.SiteConfig) (api.SitePreflight, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags(fmt.Sprintf("https://%s:%d", config.Domain, config.Port), config.Path)
+	if err != nil {
+		return api.SitePreflight{}, err
+	}
+
+	return sitePreflight(restConfig, net.JoinHostPort(config.Domain, b.execPort)), nil
+}
+
+// siteStatuses 保存后台检查的站点状态，key 为站点ID
+type siteStatuses struct {
+	lock  sync.RWMutex
+	items map[string]api.SiteStatus
+}
+
+func (s *siteStatuses) get(id string) (api.SiteStatus, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	status, ok := s.items[id]
+
+	return status, ok
+}
+
+// set stores the status and returns the previous one,
+// the last error is kept until a new error occurs.
+func (s *siteStatuses) set(id string, status api.SiteStatus) (api.SiteStatus, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.items == nil {
+		s.items = make(map[string]api.SiteStatus)
+	}
+
+	prev, ok := s.items[id]
+
+	if status.LastError == "" && ok {
+		status.LastError = prev.LastError
+		status.LastErrorAt = prev.LastErrorAt
+	}
+
+	s.items[id] = status
+
+	return prev, ok
+}
+
+func (s *siteStatuses) remove(id string) {
+	s.lock.Lock()
+	delete(s.items, id)
+	s.lock.Unlock()
+}
+
+// RunStatus checks the components of all sites every interval,
+// publishes site.state events when the state changes, until stopCh is closed.
+func (b *bankendSite) RunStatus(interval time.Duration, events eventPublisher, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	b.events = events
+
+	go wait.Until(b.checkSites, interval, stopCh)
+}
+
+func (b *bankendSite) checkSites() {
+	sites, err := b.ms.List(map[string]string{})
+	if err != nil {
+		klog.Errorf("check sites status:%s", err)
+		return
+	}
+
+	for i := range sites {
+		if sites[i].Type != api.KubernetesSite {
+			continue
+		}
+
+		status := b.checkSite(sites[i].ID, net.JoinHostPort(sites[i].Domain, b.execPort))
+
+		prev, ok := b.statuses.set(sites[i].ID, status)
+		if !ok || prev.State == status.State {
+			continue
+		}
+
+		klog.Warningf("site %s state changed from %s to %s:%s", sites[i].Name, prev.State, status.State, status.LastError)
+
+		if b.events != nil {
+			b.events.publish(api.Event{
+				Type:      api.EventSiteState,
+				Object:    sites[i].ID,
+				Name:      sites[i].Name,
+				Site:      sites[i].ID,
+				State:     status.State,
+				PrevState: prev.State,
+				Message:   failedSiteChecks(status.Components),
+			})
+		}
+	}
+}
+
+func (b *bankendSite) checkSite(id, execAddr string) api.SiteStatus {
+	now := time.Now()
+	status := api.SiteStatus{CheckedAt: api.Time(now)}
+
+	var checks []api.SiteCheck
+
+	site, err := b.zone.GetSite(id)
+	if err == nil {
+		var config *restclient.Config
+
+		config, err = site.Config()
+		if err == nil {
+			checks = sitePreflight(config, execAddr).Checks
+		}
+	}
+
+	if err != nil {
+		checks = []api.SiteCheck{{Name: siteCheckAPIServer, Status: api.SiteCheckCritical, Message: err.Error()}}
+	}
+
+	// 缓存同步会等待所有 informer，API 或 CRD 异常时不检查
+	if worstSiteCheck(checks) != api.SiteCheckCritical {
+		cache, err := site.CacheLister()
+		if err != nil {
+			checks = append(checks, api.SiteCheck{Name: siteCheckCache, Status: api.SiteCheckCritical, Message: err.Error()})
+		} else if status.CacheSynced = cache.HasSynced(); status.CacheSynced {
+			checks = append(checks, api.SiteCheck{Name: siteCheckCache, Status: api.SiteCheckPassing})
+		} else {
+			checks = append(checks, api.SiteCheck{Name: siteCheckCache, Status: api.SiteCheckWarning, Message: "informers have not synced"})
+		}
+	}
+
+	status.Components = checks
+	status.State = worstSiteCheck(checks)
+
+	for i := range checks {
+		if checks[i].Status != api.SiteCheckPassing {
+			at := api.Time(now)
+
+			status.LastError = fmt.Sprintf("%s:%s", checks[i].Name, checks[i].Message)
+			status.LastErrorAt = &at
+			break
+		}
+	}
+
+	return status
+}
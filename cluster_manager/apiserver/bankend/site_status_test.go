@@ -0,0 +1,80 @@
+package bankend
+
+import (
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+func TestCheckKubeVersion(t *testing.T) {
+	cases := []struct {
+		major, minor string
+		want         string
+	}{
+		{"1", "18", api.SiteCheckPassing},
+		{"1", "18+", api.SiteCheckPassing},
+		{"1", "14", api.SiteCheckCritical},
+		{"1", "24", api.SiteCheckWarning},
+		{"2", "0", api.SiteCheckCritical},
+		{"1", "", api.SiteCheckWarning},
+	}
+
+	for _, c := range cases {
+		got := checkKubeVersion(&version.Info{Major: c.major, Minor: c.minor, GitVersion: "v" + c.major + "." + c.minor})
+		if got.Status != c.want {
+			t.Errorf("%s.%s:expected %s but got %+v", c.major, c.minor, c.want, got)
+		}
+	}
+}
+
+func TestSiteStatusesKeepLastError(t *testing.T) {
+	s := siteStatuses{}
+	at := api.Time{}
+
+	s.set("s1", api.SiteStatus{State: api.SiteCheckCritical, LastError: "apiserver:timeout", LastErrorAt: &at})
+
+	prev, ok := s.set("s1", api.SiteStatus{State: api.SiteCheckPassing})
+	if !ok || prev.State != api.SiteCheckCritical {
+		t.Fatalf("unexpected previous status %+v %t", prev, ok)
+	}
+
+	got, _ := s.get("s1")
+	if got.State != api.SiteCheckPassing || got.LastError != "apiserver:timeout" {
+		t.Errorf("unexpected status %+v", got)
+	}
+
+	s.remove("s1")
+	if _, ok := s.get("s1"); ok {
+		t.Error("expected status removed")
+	}
+}
+
+func TestCheckSiteCRDs(t *testing.T) {
+	var objs []runtime.Object
+	for _, name := range requiredSiteCRDs {
+		objs = append(objs, &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	client := apiextensionsfake.NewSimpleClientset(objs...)
+
+	if check := checkSiteCRDs(client, siteCheckCRDs, requiredSiteCRDs, api.SiteCheckCritical); check.Status != api.SiteCheckPassing {
+		t.Errorf("expected required crds passing but got %+v", check)
+	}
+
+	// 未以 --manager-server 启动 controller-manager 的站点只是告警
+	check := checkSiteCRDs(client, siteCheckOperator, operatorSiteCRDs, api.SiteCheckWarning)
+	if check.Status != api.SiteCheckWarning || !strings.Contains(check.Message, operatorSiteCRDs[0]) {
+		t.Errorf("expected operator crds warning but got %+v", check)
+	}
+
+	if preflight := newSitePreflight([]api.SiteCheck{check}); !preflight.Passed {
+		t.Errorf("expected preflight passed without operator crds but got %+v", preflight)
+	}
+}
@@ -0,0 +1,492 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	stderror "github.com/pkg/errors"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/audit"
+	"github.com/upmio/dbscale-kube/pkg/tenant"
+)
+
+func NewSubscriptionBankend(m modelSubscription, apps appGetter, files backupFileGetter) *bankendSubscription {
+	return &bankendSubscription{
+		m:     m,
+		apps:  apps,
+		files: files,
+	}
+}
+
+type bankendSubscription struct {
+	m     modelSubscription
+	apps  appGetter
+	files backupFileGetter
+}
+
+type subscriptionGetter interface {
+	GetSubscription(id string) (model.Subscription, error)
+}
+
+type modelSubscription interface {
+	subscriptionGetter
+
+	InsertSubscription(s model.Subscription) (string, error)
+	UpdateSubscription(s model.Subscription) error
+	DeleteSubscription(id string) error
+	ListSubscriptions(selector map[string]string) ([]model.Subscription, error)
+}
+
+func decodeStorageQuota(s string) (map[api.Performance]int64, error) {
+	out := make(map[api.Performance]int64)
+	if s == "" {
+		return out, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &out)
+
+	return out, err
+}
+
+func encodeStorageQuota(quota map[api.Performance]int64) (string, error) {
+	if len(quota) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(quota)
+
+	return string(data), err
+}
+
+func subscriptionQuota(s model.Subscription) (api.SubscriptionResources, error) {
+	storage, err := decodeStorageQuota(s.QuotaStorage)
+	if err != nil {
+		return api.SubscriptionResources{}, stderror.Wrapf(err, "decode storage quota of subscription %s", s.ID)
+	}
+
+	return api.SubscriptionResources{
+		CPU:           s.QuotaCPU,
+		Memory:        s.QuotaMemory,
+		Storage:       storage,
+		Apps:          s.QuotaApps,
+		BackupStorage: s.QuotaBackupStorage,
+	}, nil
+}
+
+func setSubscriptionQuota(s *model.Subscription, quota api.SubscriptionResources) error {
+	storage, err := encodeStorageQuota(quota.Storage)
+	if err != nil {
+		return err
+	}
+
+	s.QuotaCPU = quota.CPU
+	s.QuotaMemory = quota.Memory
+	s.QuotaStorage = storage
+	s.QuotaApps = quota.Apps
+	s.QuotaBackupStorage = quota.BackupStorage
+
+	return nil
+}
+
+func convertSubscription(s model.Subscription) (api.Subscription, error) {
+	quota, err := subscriptionQuota(s)
+
+	return api.Subscription{
+		ID:       s.ID,
+		Name:     s.Name,
+		Desc:     s.Desc,
+		Enabled:  s.Enabled,
+		Quota:    quota,
+		Created:  api.NewEditor(s.CreatedUser, s.CreatedAt),
+		Modified: api.NewEditor(s.ModifiedUser, s.ModifiedAt),
+	}, err
+}
+
+// checkSubscriptionScope 限定订阅的请求只能访问自身订阅，管理订阅需要不限定订阅的请求
+func checkSubscriptionScope(ctx context.Context, id string, write bool) error {
+	scope := tenant.FromContext(ctx)
+
+	if scope == "" || (!write && scope == id) {
+		return nil
+	}
+
+	return stderror.Errorf("permission denied for subscription %s", id)
+}
+
+func (b *bankendSubscription) AddSubscription(ctx context.Context, config api.SubscriptionConfig) (api.ObjectResponse, error) {
+	if err := checkSubscriptionScope(ctx, config.ID, true); err != nil {
+		return api.ObjectResponse{}, err
+	}
+
+	now := time.Now()
+	s := model.Subscription{
+		ID:      strings.TrimSpace(config.ID),
+		Name:    strings.TrimSpace(config.Name),
+		Desc:    config.Desc,
+		Enabled: config.Enabled,
+		Editor: model.Editor{
+			CreatedUser:  config.User,
+			CreatedAt:    now,
+			ModifiedUser: config.User,
+			ModifiedAt:   now,
+		},
+	}
+
+	err := setSubscriptionQuota(&s, config.Quota)
+	if err != nil {
+		return api.ObjectResponse{}, err
+	}
+
+	id, err := b.m.InsertSubscription(s)
+	if err != nil {
+		return api.ObjectResponse{}, err
+	}
+
+	return api.ObjectResponse{
+		ID:   id,
+		Name: s.Name,
+	}, nil
+}
+
+func (b *bankendSubscription) SetSubscription(ctx context.Context, id string, opts api.SubscriptionOptions) (api.Subscription, error) {
+	if err := checkSubscriptionScope(ctx, id, true); err != nil {
+		return api.Subscription{}, err
+	}
+
+	s, err := b.m.GetSubscription(id)
+	if err != nil {
+		return api.Subscription{}, err
+	}
+
+	before, err := convertSubscription(s)
+	if err != nil {
+		return api.Subscription{}, err
+	}
+
+	if opts.Name != nil {
+		s.Name = strings.TrimSpace(*opts.Name)
+	}
+	if opts.Desc != nil {
+		s.Desc = *opts.Desc
+	}
+	if opts.Enabled != nil {
+		s.Enabled = *opts.Enabled
+	}
+	if opts.Quota != nil {
+		if err := setSubscriptionQuota(&s, *opts.Quota); err != nil {
+			return api.Subscription{}, err
+		}
+	}
+
+	s.ModifiedUser = opts.User
+	s.ModifiedAt = time.Now()
+
+	err = b.m.UpdateSubscription(s)
+	if err != nil {
+		return api.Subscription{}, err
+	}
+
+	after, err := convertSubscription(s)
+	if err == nil && opts.Quota != nil {
+		audit.Record(ctx, api.AuditSubscriptionQuota, "subscription", s.Name, before, after)
+	}
+
+	return after, err
+}
+
+func (b *bankendSubscription) GetSubscription(ctx context.Context, id string) (api.Subscription, error) {
+	if err := checkSubscriptionScope(ctx, id, false); err != nil {
+		return api.Subscription{}, err
+	}
+
+	s, err := b.m.GetSubscription(id)
+	if err != nil {
+		return api.Subscription{}, err
+	}
+
+	return convertSubscription(s)
+}
+
+// ListSubscriptions 限定订阅的请求只返回自身订阅
+func (b *bankendSubscription) ListSubscriptions(ctx context.Context, name string) (api.SubscriptionsResponse, error) {
+	selector := make(map[string]string)
+
+	if scope := tenant.FromContext(ctx); scope != "" {
+		selector["id"] = scope
+	}
+	if name != "" {
+		selector["name"] = name
+	}
+
+	list, err := b.m.ListSubscriptions(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(api.SubscriptionsResponse, len(list))
+
+	for i := range list {
+		out[i], err = convertSubscription(list[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func (b *bankendSubscription) DeleteSubscription(ctx context.Context, id string) error {
+	if err := checkSubscriptionScope(ctx, id, true); err != nil {
+		return err
+	}
+
+	apps, err := b.apps.List(map[string]string{"subscription_id": id})
+	if err != nil && !model.IsNotExist(err) {
+		return err
+	}
+
+	if len(apps) > 0 {
+		return stderror.Errorf("subscription %s still has %d apps", id, len(apps))
+	}
+
+	return b.m.DeleteSubscription(id)
+}
+
+// Enabled implements tenant.Store
+func (b *bankendSubscription) Enabled(id string) (bool, error) {
+	s, err := b.m.GetSubscription(id)
+	if model.IsNotExist(err) {
+		return false, nil
+	}
+
+	return s.Enabled, err
+}
+
+// Usage 统计订阅下各服务规格占用的资源及备份文件大小
+func (b *bankendSubscription) Usage(ctx context.Context, id string) (api.SubscriptionUsage, error) {
+	if err := checkSubscriptionScope(ctx, id, false); err != nil {
+		return api.SubscriptionUsage{}, err
+	}
+
+	s, err := b.m.GetSubscription(id)
+	if err != nil {
+		return api.SubscriptionUsage{}, err
+	}
+
+	quota, err := subscriptionQuota(s)
+	if err != nil {
+		return api.SubscriptionUsage{}, err
+	}
+
+	usage, apps, err := subscriptionUsage(b.apps, b.files, s.ID)
+	if err != nil {
+		return api.SubscriptionUsage{}, err
+	}
+
+	return api.SubscriptionUsage{
+		Subscription: api.IDName{ID: s.ID, Name: s.Name},
+		Quota:        quota,
+		Usage:        usage,
+		Apps:         apps,
+	}, nil
+}
+
+func addResources(total *api.SubscriptionResources, r api.SubscriptionResources) {
+	total.CPU += r.CPU
+	total.Memory += r.Memory
+	total.Apps += r.Apps
+	total.BackupStorage += r.BackupStorage
+
+	for level, v := range r.Storage {
+		if total.Storage == nil {
+			total.Storage = make(map[api.Performance]int64)
+		}
+
+		total.Storage[level] += v
+	}
+}
+
+func addGroupResources(total *api.SubscriptionResources, group *api.GroupSpec, units int) {
+	if group == nil || units <= 0 {
+		return
+	}
+
+	requests := group.Services.Units.Resources.Requests
+
+	total.CPU += requests.CPU * int64(units)
+	total.Memory += requests.Memory * int64(units)
+
+	if requests.Storage == nil || requests.Storage.Performance == api.PerformanceNone {
+		return
+	}
+
+	var capacity int64
+	for _, v := range requests.Storage.Volumes {
+		capacity += v.Capacity
+	}
+
+	if total.Storage == nil {
+		total.Storage = make(map[api.Performance]int64)
+	}
+
+	total.Storage[requests.Storage.Performance] += capacity * int64(units)
+}
+
+// appSpecResources 与 getResRequestsFromConfig 一致，proxy 及 cmha 按 replicas 统计
+func appSpecResources(spec api.AppSpec) api.SubscriptionResources {
+	out := api.SubscriptionResources{Apps: 1}
+
+	if spec.Database != nil {
+		addGroupResources(&out, spec.Database, spec.Database.Services.Num*spec.Database.Services.Arch.Replicas)
+	}
+	if spec.Proxy != nil {
+		addGroupResources(&out, spec.Proxy, spec.Proxy.Services.Arch.Replicas)
+	}
+	if spec.Cmha != nil {
+		addGroupResources(&out, spec.Cmha, spec.Cmha.Services.Arch.Replicas)
+	}
+
+	return out
+}
+
+// subscriptionUsage returns the total usage of the apps of subscription and the usage of each app.
+func subscriptionUsage(apps appGetter, files backupFileGetter, subscription string) (api.SubscriptionResources, []api.AppUsage, error) {
+	list, err := apps.List(map[string]string{"subscription_id": subscription})
+	if err != nil && !model.IsNotExist(err) {
+		return api.SubscriptionResources{}, nil, err
+	}
+
+	total := api.SubscriptionResources{}
+	out := make([]api.AppUsage, 0, len(list))
+
+	for i := range list {
+		if list[i].SubscriptionId != subscription {
+			continue
+		}
+
+		spec, err := decodeAppSpec(list[i].Spec)
+		if err != nil {
+			return total, nil, stderror.Wrapf(err, "decode spec of app %s", list[i].Name)
+		}
+
+		usage := appSpecResources(spec)
+
+		backups, err := files.ListFiles(map[string]string{"app_id": list[i].ID})
+		if err != nil && !model.IsNotExist(err) {
+			return total, nil, err
+		}
+
+		for _, bf := range backups {
+			usage.BackupStorage += bf.Size
+		}
+
+		addResources(&total, usage)
+
+		out = append(out, api.AppUsage{
+			App:   api.IDName{ID: list[i].ID, Name: list[i].Name},
+			Usage: usage,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].App.Name < out[j].App.Name
+	})
+
+	return total, out, nil
+}
+
+// exceedQuota returns the resources exceeding the quota,
+// a resource is allowed if it doesn't increase even if it has exceeded the quota.
+func exceedQuota(quota, before, after api.SubscriptionResources) []string {
+	var out []string
+
+	check := func(name string, limit, before, after int64) {
+		if limit > 0 && after > limit && after > before {
+			out = append(out, fmt.Sprintf("%s %d exceeds quota %d", name, after, limit))
+		}
+	}
+
+	check("cpu", quota.CPU, before.CPU, after.CPU)
+	check("memory", quota.Memory, before.Memory, after.Memory)
+	check("apps", int64(quota.Apps), int64(before.Apps), int64(after.Apps))
+
+	levels := make([]string, 0, len(quota.Storage))
+	for level := range quota.Storage {
+		levels = append(levels, string(level))
+	}
+	sort.Strings(levels)
+
+	for _, level := range levels {
+		l := api.Performance(level)
+		check(level+" storage", quota.Storage[l], before.Storage[l], after.Storage[l])
+	}
+
+	grow := after.CPU > before.CPU || after.Memory > before.Memory || after.Apps > before.Apps
+	for level, v := range after.Storage {
+		if v > before.Storage[level] {
+			grow = true
+		}
+	}
+
+	// 备份存储不随服务规格变化，已超出配额时不允许增加资源
+	if quota.BackupStorage > 0 && after.BackupStorage > quota.BackupStorage && grow {
+		out = append(out, fmt.Sprintf("backup storage %d exceeds quota %d", after.BackupStorage, quota.BackupStorage))
+	}
+
+	return out
+}
+
+// checkSubscriptionQuota checks the usage of subscription after the app uses spec,
+// app is empty for a new app. No quota if the subscription doesn't exist.
+func checkSubscriptionQuota(subscriptions subscriptionGetter, apps appGetter, files backupFileGetter,
+	subscription, app string, spec api.AppSpec) error {
+
+	if subscriptions == nil || strings.TrimSpace(subscription) == "" {
+		return nil
+	}
+
+	s, err := subscriptions.GetSubscription(subscription)
+	if model.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !s.Enabled {
+		return stderror.Errorf("subscription %s is disabled", s.Name)
+	}
+
+	quota, err := subscriptionQuota(s)
+	if err != nil {
+		return err
+	}
+
+	before, list, err := subscriptionUsage(apps, files, subscription)
+	if err != nil {
+		return err
+	}
+
+	after := api.SubscriptionResources{}
+
+	for i := range list {
+		if list[i].App.ID != app {
+			addResources(&after, list[i].Usage)
+		}
+	}
+
+	addResources(&after, appSpecResources(spec))
+	after.BackupStorage = before.BackupStorage
+
+	if exceeded := exceedQuota(quota, before, after); len(exceeded) > 0 {
+		return stderror.Errorf("subscription %s quota exceeded:%s", s.Name, strings.Join(exceeded, ","))
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package bankend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+func quotaTestSpec(cpu, storage int64) api.AppSpec {
+	spec := api.AppSpec{Database: &api.GroupSpec{}}
+
+	spec.Database.Services.Num = 1
+	spec.Database.Services.Arch.Replicas = 2
+	spec.Database.Services.Units.Resources.Requests = api.ResourceRequirements{
+		CPU:    cpu,
+		Memory: 1024,
+		Storage: &api.StorageRequirement{
+			Performance: "high",
+			Volumes:     []api.VolumeRequirement{{Type: "data", Capacity: storage}},
+		},
+	}
+
+	return spec
+}
+
+func TestCheckSubscriptionQuota(t *testing.T) {
+	fm := model.NewFakeModels()
+	subscriptions := fm.ModelSubscription()
+	apps := fm.ModelApp()
+	files := fm.ModelBackupFile()
+
+	_, err := subscriptions.InsertSubscription(model.Subscription{
+		ID:           "t1",
+		Name:         "tenant01",
+		Enabled:      true,
+		QuotaCPU:     4000,
+		QuotaStorage: `{"high":3000}`,
+		QuotaApps:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := encodeAppSpec(quotaTestSpec(1000, 1000))
+
+	app, _, err := apps.Insert(model.Application{Name: "app01", SubscriptionId: "t1", Spec: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他订阅的服务不计入
+	apps.Insert(model.Application{Name: "app02", SubscriptionId: "t2", Spec: data})
+
+	cases := []struct {
+		app     string
+		spec    api.AppSpec
+		exceeds []string
+	}{
+		{"", quotaTestSpec(500, 500), nil},
+		{"", quotaTestSpec(1500, 500), []string{"cpu 5000"}},
+		{"", quotaTestSpec(500, 1000), []string{"high storage 4000"}},
+		{app, quotaTestSpec(2000, 1500), nil},
+		{app, quotaTestSpec(2500, 1000), []string{"cpu 5000"}},
+	}
+
+	for i, c := range cases {
+		err := checkSubscriptionQuota(subscriptions, apps, files, "t1", c.app, c.spec)
+
+		if len(c.exceeds) == 0 && err != nil {
+			t.Errorf("%d:unexpected error %s", i, err)
+		}
+
+		for _, s := range c.exceeds {
+			if err == nil || !strings.Contains(err.Error(), s) {
+				t.Errorf("%d:expected error contains %q but got %v", i, s, err)
+			}
+		}
+	}
+
+	// 配额降低后不增加资源的修改仍然允许
+	subscriptions.UpdateSubscription(model.Subscription{ID: "t1", Name: "tenant01", Enabled: true, QuotaCPU: 1000, QuotaApps: 1})
+
+	if err := checkSubscriptionQuota(subscriptions, apps, files, "t1", app, quotaTestSpec(500, 1000)); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if err := checkSubscriptionQuota(subscriptions, apps, files, "t1", "", quotaTestSpec(100, 100)); err == nil || !strings.Contains(err.Error(), "apps 2") {
+		t.Errorf("expected apps quota exceeded but got %v", err)
+	}
+
+	// 没有订阅记录时不限制
+	if err := checkSubscriptionQuota(subscriptions, apps, files, "t3", "", quotaTestSpec(100000, 100000)); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
 )
 
-func NewTaskBankend(getter taskGetter) *bankendTask {
+func NewTaskBankend(getter modelTask) *bankendTask {
 	return &bankendTask{
 		getter: getter,
 	}
@@ -17,6 +17,7 @@ func NewTaskBankend(getter taskGetter) *bankendTask {
 
 type modelTask interface {
 	taskGetter
+	ListPage(opts api.ListOptions) ([]model.Task, api.ListMeta, error)
 }
 
 type taskGetter interface {
@@ -25,7 +26,7 @@ type taskGetter interface {
 }
 
 type bankendTask struct {
-	getter taskGetter
+	getter modelTask
 }
 
 func (b *bankendTask) List(ctx context.Context, id, relateID, action, state string) ([]api.Task, error) {
@@ -35,13 +36,13 @@ func (b *bankendTask) List(ctx context.Context, id, relateID, action, state stri
 		selector["id"] = id
 	}
 	if relateID != "" {
-		selector["related_id"] = relateID
+		selector["relate_id"] = relateID
 	}
 	if action != "" {
 		selector["action"] = action
 	}
 	if state != "" {
-		selector["state"] = state
+		selector["status"] = state
 	}
 
 	list, err := b.getter.List(selector)
@@ -63,6 +64,21 @@ func (b *bankendTask) List(ctx context.Context, id, relateID, action, state stri
 	return tasks, nil
 }
 
+func (b *bankendTask) ListPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error) {
+	list, meta, err := b.getter.ListPage(opts)
+	if err != nil {
+		return api.ListResponse{}, err
+	}
+
+	tasks := make([]api.Task, len(list))
+
+	for i := range list {
+		tasks[i] = convertToTask(list[i])
+	}
+
+	return api.NewListResponse(tasks, meta, opts.Fields)
+}
+
 func convertToTask(task model.Task) api.Task {
 	return api.Task{
 		ID:         task.ID,
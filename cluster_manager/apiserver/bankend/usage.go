@@ -0,0 +1,356 @@
+package bankend
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	stderror "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/pkg/structs"
+	"github.com/upmio/dbscale-kube/pkg/tenant"
+	"github.com/upmio/dbscale-kube/pkg/zone"
+)
+
+func NewUsageBankend(zone zone.ZoneInterface, m modelUsage, apps appGetter, files backupFileGetter) *bankendUsage {
+	return &bankendUsage{
+		zone:  zoneIface{zone: zone},
+		m:     m,
+		apps:  apps,
+		files: files,
+	}
+}
+
+type bankendUsage struct {
+	zone  zoneIface
+	m     modelUsage
+	apps  appGetter
+	files backupFileGetter
+}
+
+type modelUsage interface {
+	InsertUsageSamples(samples []model.UsageSample) error
+	ListUsageSamples(selector model.UsageSelector) ([]model.UsageSample, error)
+	DeleteUsageSamples(before time.Time) error
+}
+
+// RunMetering 定期采样各服务的用量，每小时只保留第一次采样，
+// retention 大于0时删除超过保留时间的采样
+func (b *bankendUsage) RunMetering(interval, retention time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	go wait.Until(func() {
+		b.sample(time.Now(), retention)
+	}, interval, stopCh)
+}
+
+func (b *bankendUsage) sample(now time.Time, retention time.Duration) {
+	apps, err := b.apps.List(map[string]string{})
+	if err != nil && !model.IsNotExist(err) {
+		klog.Errorf("sample usage,list apps:%s", err)
+		return
+	}
+
+	at := now.Truncate(time.Hour)
+
+	// 本小时已采样的服务不再查询单元容器
+	sampled, err := b.m.ListUsageSamples(model.UsageSelector{Since: at, Until: at.Add(time.Hour)})
+	if err != nil {
+		klog.Errorf("sample usage,list samples since %s:%s", at, err)
+		return
+	}
+
+	skip := make(map[string]bool, len(sampled))
+	for i := range sampled {
+		skip[sampled[i].App] = true
+	}
+
+	samples := make([]model.UsageSample, 0, len(apps))
+
+	for i := range apps {
+		if skip[apps[i].ID] {
+			continue
+		}
+
+		s, err := b.sampleApp(apps[i])
+		if err != nil {
+			klog.Errorf("sample usage of app %s:%s", apps[i].Name, err)
+			continue
+		}
+
+		s.SampledAt = at
+		samples = append(samples, s)
+	}
+
+	if len(samples) > 0 {
+		if err := b.m.InsertUsageSamples(samples); err != nil {
+			klog.Errorf("sample usage,insert %d samples:%s", len(samples), err)
+		}
+	}
+
+	if retention > 0 {
+		if err := b.m.DeleteUsageSamples(now.Add(-retention)); err != nil {
+			klog.Errorf("delete usage samples before %s:%s", now.Add(-retention), err)
+		}
+	}
+}
+
+// unitGroupSpec returns the group spec of unit
+func unitGroupSpec(spec api.AppSpec, unit model.Unit) *api.GroupSpec {
+	switch unit.GetServiceType() {
+	case structs.MysqlServiceType:
+		return spec.Database
+	case structs.ProxysqlServiceType:
+		return spec.Proxy
+	case structs.CmhaServiceType:
+		return spec.Cmha
+	}
+
+	return nil
+}
+
+// sampleApp 按单元统计分配的资源，已使用的存储通过单元容器查询，查询失败的单元不计入
+func (b *bankendUsage) sampleApp(app model.Application) (model.UsageSample, error) {
+	s := model.UsageSample{
+		App:            app.ID,
+		AppName:        app.Name,
+		SubscriptionId: app.SubscriptionId,
+	}
+
+	spec, err := decodeAppSpec(app.Spec)
+	if err != nil {
+		return s, stderror.Wrap(err, "decode spec")
+	}
+
+	storage := make(map[api.Performance]int64)
+	used := make(map[api.Performance]int64)
+
+	for _, unit := range app.Units {
+		group := unitGroupSpec(spec, unit)
+		if group == nil {
+			continue
+		}
+
+		requests := group.Services.Units.Resources.Requests
+
+		s.CPU += requests.CPU
+		s.Memory += requests.Memory
+		if requests.Bandwidth != nil {
+			s.Bandwidth += int64(*requests.Bandwidth)
+		}
+
+		if requests.Storage == nil || requests.Storage.Performance == api.PerformanceNone {
+			continue
+		}
+
+		level := requests.Storage.Performance
+		for _, v := range requests.Storage.Volumes {
+			storage[level] += v.Capacity
+		}
+
+		volumes, err := b.unitVolumesUsage(unit)
+		if err != nil {
+			klog.Warningf("sample usage of unit %s volumes:%s", unit.ID, err)
+			continue
+		}
+
+		for _, v := range volumes {
+			used[level] += int64(v.Used)
+		}
+	}
+
+	files, err := b.files.ListFiles(map[string]string{"app_id": app.ID})
+	if err != nil && !model.IsNotExist(err) {
+		return s, err
+	}
+
+	backups := make(map[string]int64)
+	for _, bf := range files {
+		if bf.Status == model.BackupFileComplete {
+			backups[bf.EndpointId] += bf.Size
+		}
+	}
+
+	if s.Storage, err = encodeUsageMap(storage); err != nil {
+		return s, err
+	}
+	if s.StorageUsed, err = encodeUsageMap(used); err != nil {
+		return s, err
+	}
+	s.BackupStorage, err = encodeUsageMap(backups)
+
+	return s, err
+}
+
+func (b *bankendUsage) unitVolumesUsage(unit model.Unit) ([]api.UnitVolumeUsage, error) {
+	u, err := b.zone.getUnit(unit.Site, unit.Namespace, unit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	iface, err := b.zone.siteInterface(unit.Site)
+	if err != nil {
+		return nil, err
+	}
+
+	return getUnitVolumesUsage(iface.PodExec(), *u)
+}
+
+func encodeUsageMap(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+
+	return string(data), err
+}
+
+func decodeUsageMap(s string, v interface{}) error {
+	if s == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(s), v)
+}
+
+func usagePeriod(t time.Time, period string) string {
+	if period == api.UsagePeriodMonth {
+		return t.In(time.Local).Format("2006-01")
+	}
+
+	return t.In(time.Local).Format("2006-01-02")
+}
+
+// defaultUsageSince 未指定开始时间时，按日统计最近30天，按月统计最近12个月
+func defaultUsageSince(until time.Time, period string) time.Time {
+	until = until.In(time.Local)
+
+	if period == api.UsagePeriodMonth {
+		return time.Date(until.Year(), until.Month()-11, 1, 0, 0, 0, 0, time.Local)
+	}
+
+	return time.Date(until.Year(), until.Month(), until.Day()-29, 0, 0, 0, 0, time.Local)
+}
+
+// ListUsage 按日或按月汇总服务或订阅的用量，限定订阅的请求只能查询自身订阅
+func (b *bankendUsage) ListUsage(ctx context.Context, query api.UsageQuery) (api.UsageResponse, error) {
+	if scope := tenant.FromContext(ctx); scope != "" {
+		if query.Subscription != "" && query.Subscription != scope {
+			return nil, stderror.Errorf("permission denied for subscription %s", query.Subscription)
+		}
+
+		query.Subscription = scope
+	}
+
+	if query.Until.IsZero() {
+		query.Until = time.Now()
+	}
+	if query.Since.IsZero() {
+		query.Since = defaultUsageSince(query.Until, query.Period)
+	}
+
+	samples, err := b.m.ListUsageSamples(model.UsageSelector{
+		Subscription: query.Subscription,
+		App:          query.App,
+		Since:        query.Since,
+		Until:        query.Until,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return aggregateUsage(samples, query.Period, query.GroupBy)
+}
+
+func aggregateUsage(samples []model.UsageSample, period, groupBy string) (api.UsageResponse, error) {
+	type usageKey struct {
+		period       string
+		subscription string
+		app          string
+	}
+
+	records := make(map[usageKey]*api.UsageRecord)
+	// 采样按时间排序，按订阅统计时同一小时的多个服务只计一小时
+	lastSampled := make(map[usageKey]time.Time)
+
+	for _, s := range samples {
+		key := usageKey{
+			period:       usagePeriod(s.SampledAt, period),
+			subscription: s.SubscriptionId,
+		}
+		if groupBy != api.UsageGroupBySubscription {
+			key.app = s.App
+		}
+
+		rec, ok := records[key]
+		if !ok {
+			rec = &api.UsageRecord{
+				Period:        key.period,
+				Subscription:  key.subscription,
+				Storage:       make(map[api.Performance]int64),
+				StorageUsed:   make(map[api.Performance]int64),
+				BackupStorage: make(map[string]int64),
+			}
+			if key.app != "" {
+				rec.App = api.IDName{ID: s.App, Name: s.AppName}
+			}
+
+			records[key] = rec
+		}
+
+		storage := make(map[api.Performance]int64)
+		used := make(map[api.Performance]int64)
+		backups := make(map[string]int64)
+
+		if err := decodeUsageMap(s.Storage, &storage); err != nil {
+			return nil, stderror.Wrapf(err, "decode storage usage of app %s", s.AppName)
+		}
+		if err := decodeUsageMap(s.StorageUsed, &used); err != nil {
+			return nil, stderror.Wrapf(err, "decode storage used of app %s", s.AppName)
+		}
+		if err := decodeUsageMap(s.BackupStorage, &backups); err != nil {
+			return nil, stderror.Wrapf(err, "decode backup usage of app %s", s.AppName)
+		}
+
+		if !s.SampledAt.Equal(lastSampled[key]) {
+			rec.Hours++
+		}
+		lastSampled[key] = s.SampledAt
+
+		rec.CPU += s.CPU
+		rec.Memory += s.Memory
+		rec.Bandwidth += s.Bandwidth
+
+		for level, v := range storage {
+			rec.Storage[level] += v
+		}
+		for level, v := range used {
+			rec.StorageUsed[level] += v
+		}
+		for endpoint, v := range backups {
+			rec.BackupStorage[endpoint] += v
+		}
+	}
+
+	out := make(api.UsageResponse, 0, len(records))
+	for _, rec := range records {
+		out = append(out, *rec)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Period != out[j].Period {
+			return out[i].Period < out[j].Period
+		}
+		if out[i].Subscription != out[j].Subscription {
+			return out[i].Subscription < out[j].Subscription
+		}
+
+		return out[i].App.Name < out[j].App.Name
+	})
+
+	return out, nil
+}
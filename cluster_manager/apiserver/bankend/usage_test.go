@@ -0,0 +1,125 @@
+package bankend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+func TestAggregateUsage(t *testing.T) {
+	day := time.Date(2021, 3, 31, 22, 0, 0, 0, time.Local)
+
+	sample := func(app, subscription string, at time.Time) model.UsageSample {
+		return model.UsageSample{
+			App:            app,
+			AppName:        app + "-name",
+			SubscriptionId: subscription,
+			CPU:            1000,
+			Memory:         2048,
+			Storage:        `{"high":100}`,
+			StorageUsed:    `{"high":40}`,
+			BackupStorage:  `{"ep1":10}`,
+			SampledAt:      at,
+		}
+	}
+
+	samples := []model.UsageSample{
+		sample("a1", "t1", day),
+		sample("a2", "t1", day),
+		sample("a1", "t1", day.Add(time.Hour)),
+		sample("a3", "t2", day.Add(time.Hour)),
+		sample("a1", "t1", day.Add(2*time.Hour)),
+	}
+
+	byApp, err := aggregateUsage(samples, api.UsagePeriodDay, api.UsageGroupByApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2021-03-31 a1,a2 2021-04-01 a1,a3
+	if len(byApp) != 4 {
+		t.Fatalf("expected 4 records but got %d:%+v", len(byApp), byApp)
+	}
+
+	if r := byApp[0]; r.Period != "2021-03-31" || r.App.ID != "a1" || r.Hours != 2 ||
+		r.CPU != 2000 || r.StorageUsed["high"] != 80 || r.BackupStorage["ep1"] != 20 {
+		t.Errorf("unexpected record %+v", r)
+	}
+
+	bySubscription, err := aggregateUsage(samples, api.UsagePeriodMonth, api.UsageGroupBySubscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bySubscription) != 3 {
+		t.Fatalf("expected 3 records but got %d:%+v", len(bySubscription), bySubscription)
+	}
+
+	// 同一小时的两个服务只计一小时
+	if r := bySubscription[0]; r.Period != "2021-03" || r.Subscription != "t1" || r.App.ID != "" ||
+		r.Hours != 2 || r.CPU != 3000 || r.Memory != 3*2048 {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
+
+type countFiles struct {
+	backupFileGetter
+	apps []string
+}
+
+func (f *countFiles) ListFiles(selector map[string]string) ([]model.BackupFile, error) {
+	f.apps = append(f.apps, selector["app_id"])
+	return nil, nil
+}
+
+func TestSampleUsageSkipsSampled(t *testing.T) {
+	fm := model.NewFakeModels()
+	apps := fm.ModelApp()
+	usages := fm.ModelUsage()
+
+	data, _ := encodeAppSpec(quotaTestSpec(1000, 1000))
+
+	app1, _, err := apps.Insert(model.Application{Name: "app01", SubscriptionId: "t1", Spec: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app2, _, err := apps.Insert(model.Application{Name: "app02", SubscriptionId: "t1", Spec: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 3, 31, 22, 30, 0, 0, time.Local)
+
+	err = usages.InsertUsageSamples([]model.UsageSample{{App: app1, SampledAt: now.Truncate(time.Hour)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := &countFiles{}
+	b := NewUsageBankend(nil, usages, apps, files)
+
+	b.sample(now, 0)
+
+	if len(files.apps) != 1 || files.apps[0] != app2 {
+		t.Fatalf("expected only %s sampled but got %v", app2, files.apps)
+	}
+
+	list, err := usages.ListUsageSamples(model.UsageSelector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 {
+		t.Fatalf("expected 2 samples but got %+v", list)
+	}
+
+	files.apps = nil
+	b.sample(now.Add(10*time.Minute), 0)
+
+	if len(files.apps) != 0 {
+		t.Fatalf("expected no app sampled twice in an hour but got %v", files.apps)
+	}
+}
@@ -0,0 +1,448 @@
+package bankend
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	cryptoutil "github.com/upmio/dbscale-kube/pkg/utils/crypto"
+)
+
+const (
+	webhookMaxAttempts = 5
+	webhookWorkers     = 4
+	webhookQueueSize   = 1000
+	// 重试间隔从webhookBackoff开始翻倍
+	webhookBackoff = 5 * time.Second
+	// 投递记录默认返回条数
+	defaultDeliveryLimit = 100
+)
+
+func NewWebhookBankend(m modelWebhook, key string) *bankendWebhook {
+	return &bankendWebhook{
+		m:      m,
+		key:    key,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan webhookJob, webhookQueueSize),
+	}
+}
+
+type bankendWebhook struct {
+	m   modelWebhook
+	key string
+
+	client *http.Client
+	queue  chan webhookJob
+}
+
+type modelWebhook interface {
+	InsertWebhook(wh model.Webhook) (string, error)
+	UpdateWebhook(wh model.Webhook) error
+	DeleteWebhook(id string) error
+	GetWebhook(id string) (model.Webhook, error)
+	ListWebhooks(selector map[string]string) ([]model.Webhook, error)
+
+	InsertDelivery(d model.WebhookDelivery) (string, error)
+	UpdateDelivery(d model.WebhookDelivery) error
+	ListDeliveries(webhook string, limit int) ([]model.WebhookDelivery, error)
+	ListPendingDeliveries() ([]model.WebhookDelivery, error)
+}
+
+type webhookJob struct {
+	url      string
+	secret   string
+	delivery model.WebhookDelivery
+}
+
+func splitEventTypes(types string) []string {
+	if types == "" {
+		return []string{}
+	}
+
+	return strings.Split(types, ",")
+}
+
+func convertWebhook(wh model.Webhook) api.Webhook {
+	return api.Webhook{
+		ID:         wh.ID,
+		Name:       wh.Name,
+		URL:        wh.URL,
+		EventTypes: splitEventTypes(wh.EventTypes),
+		App:        wh.App,
+		Enabled:    wh.Enabled,
+		Created:    api.NewEditor(wh.CreatedUser, wh.CreatedAt),
+		Modified:   api.NewEditor(wh.ModifiedUser, wh.ModifiedAt),
+	}
+}
+
+func convertWebhookDelivery(d model.WebhookDelivery) api.WebhookDelivery {
+	return api.WebhookDelivery{
+		ID:           d.ID,
+		Webhook:      d.Webhook,
+		EventID:      d.EventID,
+		EventType:    d.EventType,
+		Payload:      json.RawMessage(d.Payload),
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		Error:        d.Error,
+		CreatedAt:    api.Time(d.CreatedAt),
+		UpdatedAt:    api.Time(d.UpdatedAt),
+	}
+}
+
+func (b *bankendWebhook) AddWebhook(ctx context.Context, config api.WebhookConfig) (api.ObjectResponse, error) {
+	now := time.Now()
+	id, err := b.m.InsertWebhook(model.Webhook{
+		Name:       strings.TrimSpace(config.Name),
+		URL:        config.URL,
+		Secret:     cryptoutil.AesEncrypto(config.Secret, b.key),
+		EventTypes: strings.Join(config.EventTypes, ","),
+		App:        config.App,
+		Enabled:    config.Enabled,
+		Editor: model.Editor{
+			CreatedUser:  config.User,
+			CreatedAt:    now,
+			ModifiedUser: config.User,
+			ModifiedAt:   now,
+		},
+	})
+	if err != nil {
+		return api.ObjectResponse{}, err
+	}
+
+	return api.ObjectResponse{
+		ID:   id,
+		Name: config.Name,
+	}, nil
+}
+
+func (b *bankendWebhook) SetWebhook(ctx context.Context, id string, opts api.WebhookOptions) (api.Webhook, error) {
+	wh, err := b.m.GetWebhook(id)
+	if err != nil {
+		return api.Webhook{}, err
+	}
+
+	if opts.Name != nil {
+		wh.Name = strings.TrimSpace(*opts.Name)
+	}
+	if opts.URL != nil {
+		wh.URL = *opts.URL
+	}
+	if opts.Secret != nil {
+		wh.Secret = cryptoutil.AesEncrypto(*opts.Secret, b.key)
+	}
+	if opts.EventTypes != nil {
+		wh.EventTypes = strings.Join(*opts.EventTypes, ",")
+	}
+	if opts.App != nil {
+		wh.App = *opts.App
+	}
+	if opts.Enabled != nil {
+		wh.Enabled = *opts.Enabled
+	}
+
+	wh.ModifiedUser = opts.User
+	wh.ModifiedAt = time.Now()
+
+	err = b.m.UpdateWebhook(wh)
+
+	return convertWebhook(wh), err
+}
+
+func (b *bankendWebhook) GetWebhook(ctx context.Context, id string) (api.Webhook, error) {
+	wh, err := b.m.GetWebhook(id)
+	if err != nil {
+		return api.Webhook{}, err
+	}
+
+	return convertWebhook(wh), nil
+}
+
+func (b *bankendWebhook) ListWebhooks(ctx context.Context, app string) (api.WebhooksResponse, error) {
+	selector := make(map[string]string)
+	if app != "" {
+		selector["app_id"] = app
+	}
+
+	list, err := b.m.ListWebhooks(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(api.WebhooksResponse, len(list))
+
+	for i := range list {
+		out[i] = convertWebhook(list[i])
+	}
+
+	return out, nil
+}
+
+func (b *bankendWebhook) DeleteWebhook(ctx context.Context, id string) error {
+	wh, err := b.m.GetWebhook(id)
+	if model.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return b.m.DeleteWebhook(wh.ID)
+}
+
+func (b *bankendWebhook) ListDeliveries(ctx context.Context, id string, limit int) (api.WebhookDeliveriesResponse, error) {
+	wh, err := b.m.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+
+	list, err := b.m.ListDeliveries(wh.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(api.WebhookDeliveriesResponse, len(list))
+
+	for i := range list {
+		out[i] = convertWebhookDelivery(list[i])
+	}
+
+	return out, nil
+}
+
+// HandleEvent records a pending delivery for each enabled webhook subscribing the event
+// and queues it to the workers started by Run.
+func (b *bankendWebhook) HandleEvent(ev api.Event) {
+	list, err := b.m.ListWebhooks(map[string]string{labelEnabled: "1"})
+	if err != nil {
+		klog.Errorf("list webhooks for event %d:%s", ev.ID, err)
+		return
+	}
+
+	var payload []byte
+
+	for _, wh := range list {
+		if !wh.Enabled || (wh.App != "" && wh.App != ev.App) ||
+			!api.MatchEventType(splitEventTypes(wh.EventTypes), ev.Type) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(ev)
+			if err != nil {
+				klog.Errorf("encode event %d:%s", ev.ID, err)
+				return
+			}
+		}
+
+		secret, err := cryptoutil.AesDecrypto(wh.Secret, b.key)
+		if err != nil {
+			klog.Errorf("decrypt secret of webhook %s:%s", wh.Name, err)
+			continue
+		}
+
+		now := time.Now()
+		d := model.WebhookDelivery{
+			Webhook:   wh.ID,
+			EventID:   ev.ID,
+			EventType: ev.Type,
+			Payload:   string(payload),
+			Status:    model.DeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		d.ID, err = b.m.InsertDelivery(d)
+		if err != nil {
+			klog.Errorf("insert delivery of webhook %s:%s", wh.Name, err)
+			continue
+		}
+
+		select {
+		case b.queue <- webhookJob{url: wh.URL, secret: secret, delivery: d}:
+		default:
+			d.Status = model.DeliveryFailed
+			d.Error = "delivery queue is full"
+
+			if err := b.m.UpdateDelivery(d); err != nil {
+				klog.Errorf("update delivery %s:%s", d.ID, err)
+			}
+		}
+	}
+}
+
+// Run starts the workers delivering the queued events, until stopCh is closed.
+// The deliveries left pending by the last process are queued again.
+func (b *bankendWebhook) Run(stopCh <-chan struct{}) {
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case <-stopCh:
+					return
+				case job := <-b.queue:
+					b.deliver(job, stopCh)
+				}
+			}
+		}()
+	}
+
+	go b.requeuePending(stopCh)
+}
+
+// requeuePending 重新投递未完成的记录，已删除或停用的webhook的记录标记为失败
+func (b *bankendWebhook) requeuePending(stopCh <-chan struct{}) {
+	list, err := b.m.ListPendingDeliveries()
+	if err != nil {
+		klog.Errorf("list pending deliveries:%s", err)
+		return
+	}
+
+	webhooks := make(map[string]*webhookJob)
+
+	for _, d := range list {
+		job, ok := webhooks[d.Webhook]
+		if !ok {
+			job = b.pendingJob(d.Webhook)
+			webhooks[d.Webhook] = job
+		}
+
+		if job == nil {
+			d.Status = model.DeliveryFailed
+			d.Error = "webhook is deleted or disabled"
+			d.UpdatedAt = time.Now()
+
+			if err := b.m.UpdateDelivery(d); err != nil {
+				klog.Errorf("update delivery %s:%s", d.ID, err)
+			}
+			continue
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case b.queue <- webhookJob{url: job.url, secret: job.secret, delivery: d}:
+		}
+	}
+
+	if len(list) > 0 {
+		klog.Infof("requeue %d pending webhook deliveries", len(list))
+	}
+}
+
+func (b *bankendWebhook) pendingJob(id string) *webhookJob {
+	wh, err := b.m.GetWebhook(id)
+	if err != nil {
+		if !model.IsNotExist(err) {
+			klog.Errorf("get webhook %s:%s", id, err)
+		}
+		return nil
+	}
+
+	if !wh.Enabled {
+		return nil
+	}
+
+	secret, err := cryptoutil.AesDecrypto(wh.Secret, b.key)
+	if err != nil {
+		klog.Errorf("decrypt secret of webhook %s:%s", wh.Name, err)
+		return nil
+	}
+
+	return &webhookJob{url: wh.URL, secret: secret}
+}
+
+// signPayload returns the value of WebhookHeaderSignature,
+// the timestamp is signed with the body to prevent replay.
+func signPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (b *bankendWebhook) deliver(job webhookJob, stopCh <-chan struct{}) {
+	d := job.delivery
+	backoff := webhookBackoff
+
+	for d.Attempts < webhookMaxAttempts {
+		d.Attempts++
+		d.ResponseCode, d.Error = 0, ""
+
+		code, err := b.post(job, d)
+		d.ResponseCode = code
+		d.UpdatedAt = time.Now()
+
+		switch {
+		case err == nil:
+			d.Status = model.DeliverySuccess
+		case d.Attempts >= webhookMaxAttempts:
+			d.Status = model.DeliveryFailed
+			d.Error = err.Error()
+		default:
+			d.Error = err.Error()
+		}
+
+		if err := b.m.UpdateDelivery(d); err != nil {
+			klog.Errorf("update delivery %s:%s", d.ID, err)
+		}
+
+		if d.Status != model.DeliveryPending {
+			return
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+func (b *bankendWebhook) post(job webhookJob, d model.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, job.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.WebhookHeaderEvent, d.EventType)
+	req.Header.Set(api.WebhookHeaderDelivery, d.ID)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(api.WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(api.WebhookHeaderSignature, signPayload(job.secret, timestamp, body))
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("webhook %s response %s", job.url, resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
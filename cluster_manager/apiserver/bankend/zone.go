@@ -232,13 +232,13 @@ func (z zoneIface) ensureUnitResourceEqual(requests api.ResourceRequirements, si
 func mergeUnitResources(clone *unitv4.Unit, requests api.ResourceRequirements) (equal, restart bool, err error) {
 	equal, restart = true, false
 
-	//if requests.Bandwidth != nil &&
-	//	clone.Spec.Networking != nil &&
-	//	*requests.Bandwidth != clone.Spec.Networking.Bandwidth {
-	//
-	//	equal = false
-	//	clone.Spec.Networking.Bandwidth = *requests.Bandwidth
-	//}
+	// bandwidth is applied online by the node agent,no need to restart pod
+	if requests.Bandwidth != nil &&
+		*requests.Bandwidth != clone.Spec.Networking.Bandwidth {
+
+		equal = false
+		clone.Spec.Networking.Bandwidth = *requests.Bandwidth
+	}
 
 	if requests.Storage != nil {
 
@@ -309,7 +309,7 @@ func runInContainer(execer site.PodExecInterface, unit unitv4.Unit, cmd []string
 
 	ok, err := execer.RunInContainer(unit.Namespace, podname, unit.Spec.MainContainerName, cmd, stderr, stdout)
 
-	klog.Infof("Pod %s/%s Container %s exec %s done:%t,error:%v,Output:%s,%s", unit.Namespace, podname, unit.Spec.MainContainerName, cmd, ok, err, stderr.String(), stdout.String())
+	klog.Infof("Pod %s/%s Container %s exec %s done:%t,error:%v,Output:%s,%s", unit.Namespace, podname, unit.Spec.MainContainerName, maskCmdSecrets(cmd), ok, err, stderr.String(), stdout.String())
 
 	return ok, stdout, err
 }
@@ -738,7 +738,7 @@ func convertGroupSpecToUnit(appId, appName, groupname, grouptype, servicetype st
 		return unitv4.Unit{}, err
 	}
 
-	unitImage := fmt.Sprintf("%s/%s/%s", registry, projectName, image.ImageWithArch())
+	unitImage := image.Reference(registry, projectName)
 	defaultCmd := []string{"/bin/bash", "-c", "trap : TERM INT; sleep infinity & wait"}
 	unit := unitv4.Unit{
 		ObjectMeta: metav1.ObjectMeta{
@@ -27,6 +27,7 @@ var (
 
 func init() {
 	initDBConfig()
+	initAlertConfig()
 	flag.BoolVar(&versionFlag, "version", false, "show the version ")
 	flag.StringVar(&addr, "addr", addr, "apiserver addr of server")
 	flag.StringVar(&execServicePort, "exec-port", execServicePort, "exec server port")
@@ -60,7 +61,7 @@ func main() {
 	srv.AddMiddleware(middleware.DebugRequestMiddleware{})
 	srv.AddMiddleware(middleware.ErrorRequestMiddleware{})
 
-	err := initRouter(srv)
+	err := initRouter(srv, stopCh)
 	if err != nil {
 		klog.Fatal("Init routers:", err)
 		return
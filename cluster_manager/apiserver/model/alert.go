@@ -0,0 +1,239 @@
+package model
+
+import (
+	"errors"
+	"sync"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// AppAlertConfig 服务告警阈值，Thresholds为json
+type AppAlertConfig struct {
+	App        string `db:"app_id"`
+	Enabled    bool   `db:"enabled"`
+	Thresholds string `db:"thresholds"`
+	Editor
+}
+
+func (AppAlertConfig) Table() string {
+	return "tbl_app_alert"
+}
+
+// NotificationChannel 告警通知渠道，Config为json
+type NotificationChannel struct {
+	ID      string `db:"id"`
+	Name    string `db:"name"`
+	Type    string `db:"type"`
+	Config  string `db:"channel_config"`
+	Enabled bool   `db:"enabled"`
+	Editor
+}
+
+func (NotificationChannel) Table() string {
+	return "tbl_notification_channel"
+}
+
+type ModelAlert interface {
+	GetAppConfig(app string) (AppAlertConfig, error)
+	// SetAppConfig inserts or updates the config of app
+	SetAppConfig(c AppAlertConfig) error
+	DeleteAppConfig(app string) error
+
+	InsertChannel(ch NotificationChannel) (string, error)
+	UpdateChannel(ch NotificationChannel) error
+	DeleteChannel(id string) error
+	GetChannel(id string) (NotificationChannel, error)
+	ListChannels(selector map[string]string) ([]NotificationChannel, error)
+}
+
+type modelAlert struct {
+	*dbBase
+}
+
+func (m *modelAlert) GetAppConfig(app string) (AppAlertConfig, error) {
+	c := AppAlertConfig{}
+	query := "SELECT * FROM " + c.Table() + " WHERE app_id=?"
+
+	err := m.dbBase.Get(&c, query, app)
+
+	return c, err
+}
+
+func (m *modelAlert) SetAppConfig(c AppAlertConfig) error {
+	query := "INSERT INTO " + c.Table() +
+		" (app_id,enabled,thresholds,created_user,created_timestamp,modified_user,modified_timestamp) " +
+		"VALUES (:app_id,:enabled,:thresholds,:created_user,:created_timestamp,:modified_user,:modified_timestamp) " +
+		"ON DUPLICATE KEY UPDATE enabled=VALUES(enabled),thresholds=VALUES(thresholds)," +
+		"modified_user=VALUES(modified_user),modified_timestamp=VALUES(modified_timestamp)"
+
+	_, err := m.NamedExec(query, c)
+
+	return err
+}
+
+func (m *modelAlert) DeleteAppConfig(app string) error {
+	query := "DELETE FROM " + AppAlertConfig{}.Table() + " WHERE app_id=?"
+
+	_, err := m.Exec(query, app)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelAlert) InsertChannel(ch NotificationChannel) (string, error) {
+	if ch.ID == "" {
+		ch.ID = newUUID("")
+	}
+
+	query := "INSERT INTO " + ch.Table() +
+		" (id,name,type,channel_config,enabled,created_user,created_timestamp,modified_user,modified_timestamp) " +
+		"VALUES (:id,:name,:type,:channel_config,:enabled,:created_user,:created_timestamp,:modified_user,:modified_timestamp)"
+
+	_, err := m.NamedExec(query, ch)
+
+	return ch.ID, err
+}
+
+func (m *modelAlert) UpdateChannel(ch NotificationChannel) error {
+	query := "UPDATE " + ch.Table() + " SET name=:name,channel_config=:channel_config,enabled=:enabled," +
+		"modified_user=:modified_user,modified_timestamp=:modified_timestamp WHERE id=:id"
+
+	_, err := m.NamedExec(query, ch)
+
+	return err
+}
+
+func (m *modelAlert) DeleteChannel(id string) error {
+	query := "DELETE FROM " + NotificationChannel{}.Table() + " WHERE id=?"
+
+	_, err := m.Exec(query, id)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelAlert) GetChannel(id string) (NotificationChannel, error) {
+	ch := NotificationChannel{}
+	query := "SELECT * FROM " + ch.Table() + " WHERE id=? OR name=?"
+
+	err := m.dbBase.Get(&ch, query, id, id)
+
+	return ch, err
+}
+
+func (m *modelAlert) ListChannels(selector map[string]string) ([]NotificationChannel, error) {
+	if id, ok := selector["id"]; ok {
+
+		ch, err := m.GetChannel(id)
+		if IsNotExist(err) {
+			return nil, nil
+		}
+
+		return []NotificationChannel{ch}, err
+	}
+
+	query := sq.Select("*").From(NotificationChannel{}.Table())
+
+	if typ, ok := selector["type"]; ok {
+		query = query.Where(sq.Eq{"type": typ})
+	}
+	if enabled, ok := selector[labelEnabled]; ok {
+		query = query.Where(sq.Eq{"enabled": enabled})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []NotificationChannel{}
+	err = m.Select(&list, sql, args...)
+
+	return list, err
+}
+
+type fakeModelAlert struct {
+	configs  *sync.Map
+	channels *sync.Map
+}
+
+func (m *fakeModelAlert) GetAppConfig(app string) (AppAlertConfig, error) {
+	v, ok := m.configs.Load(app)
+	if !ok {
+		return AppAlertConfig{}, NewNotFound("app alert config", app)
+	}
+
+	return v.(AppAlertConfig), nil
+}
+
+func (m *fakeModelAlert) SetAppConfig(c AppAlertConfig) error {
+	m.configs.Store(c.App, c)
+
+	return nil
+}
+
+func (m *fakeModelAlert) DeleteAppConfig(app string) error {
+	m.configs.Delete(app)
+
+	return nil
+}
+
+func (m *fakeModelAlert) InsertChannel(ch NotificationChannel) (string, error) {
+	if ch.ID == "" {
+		ch.ID = newUUID("")
+	}
+
+	m.channels.Store(ch.ID, ch)
+
+	return ch.ID, nil
+}
+
+func (m *fakeModelAlert) UpdateChannel(ch NotificationChannel) error {
+	if ch.ID == "" {
+		return errors.New("id is required")
+	}
+
+	m.channels.Store(ch.ID, ch)
+
+	return nil
+}
+
+func (m *fakeModelAlert) DeleteChannel(id string) error {
+	m.channels.Delete(id)
+
+	return nil
+}
+
+func (m *fakeModelAlert) GetChannel(id string) (NotificationChannel, error) {
+	v, ok := m.channels.Load(id)
+	if !ok {
+		return NotificationChannel{}, NewNotFound("notification channel", id)
+	}
+
+	return v.(NotificationChannel), nil
+}
+
+func (m *fakeModelAlert) ListChannels(selector map[string]string) ([]NotificationChannel, error) {
+	list := []NotificationChannel{}
+
+	m.channels.Range(func(key, value interface{}) bool {
+		ch := value.(NotificationChannel)
+
+		if id, ok := selector["id"]; ok && ch.ID != id {
+			return true
+		}
+		if typ, ok := selector["type"]; ok && ch.Type != typ {
+			return true
+		}
+
+		list = append(list, ch)
+
+		return true
+	})
+
+	return list, nil
+}
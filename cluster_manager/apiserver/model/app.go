@@ -570,6 +570,20 @@ func (m modelApp) ListWithPagination(selector map[string]string, pagination api.
 		return apps, api.NewPaginationRespOk(pagination, 0, nil), nil
 	}
 
+	err = m.completeApps(apps)
+	if err != nil {
+		return apps, api.NewPaginationRespError("sql error"), err
+	}
+
+	return apps, api.NewPaginationRespOk(pagination, total, apps), err
+}
+
+// completeApps sets the units and the latest tasks of apps.
+func (m modelApp) completeApps(apps []Application) error {
+	if len(apps) == 0 {
+		return nil
+	}
+
 	var inClause []string
 	for _, app := range apps {
 		inClause = append(inClause, app.ID)
@@ -579,54 +593,72 @@ func (m modelApp) ListWithPagination(selector map[string]string, pagination api.
 
 	query, args, err := sqlx.In("SELECT * FROM "+Unit{}.Table()+" WHERE app_id IN (?)", inClause)
 	if err != nil {
-		return apps, api.NewPaginationRespError("sql error"), err
+		return err
 	}
 
 	query = m.Rebind(query)
 	err = m.Select(&units, query, args...)
 	if err != nil {
-		return apps, api.NewPaginationRespError("sql error"), err
+		return err
 	}
 
-	tasks, err := m.tasksByTable(Application{}.Table())
+	// 只查询本页服务及单元的最新任务
+	tasks, err := m.latestTasks(Application{}.Table(), inClause)
 	if err != nil {
-		return apps, api.NewPaginationRespError("sql error"), err
+		return err
 	}
 
-	utasks, err := m.tasksByTable(Unit{}.Table())
+	unitIDs := make([]string, len(units))
+	for k := range units {
+		unitIDs[k] = units[k].ID
+	}
+
+	utasks, err := m.latestTasks(Unit{}.Table(), unitIDs)
 	if err != nil {
-		return apps, api.NewPaginationRespError("sql error"), err
+		return err
 	}
 
-	for i := range apps {
+	for k := range units {
+		if units[k].Task.ID == "" {
+			units[k].Task = utasks[units[k].ID]
+		}
+	}
 
+	for i := range apps {
 		for k := range units {
-
-			if units[k].Task.ID == "" {
-
-				for l := range utasks {
-					if units[k].ID == utasks[l].RelateID &&
-						units[k].Task.Auto < utasks[l].Auto {
-
-						units[k].Task = utasks[l]
-					}
-				}
-			}
-
 			if apps[i].ID == units[k].App {
 				apps[i].Units = append(apps[i].Units, units[k])
 			}
 		}
 
-		for l := range tasks {
-			if apps[i].ID == tasks[l].RelateID &&
-				apps[i].Task.Auto < tasks[l].Auto {
-				apps[i].Task = tasks[l]
-			}
+		if tk, ok := tasks[apps[i].ID]; ok && apps[i].Task.Auto < tk.Auto {
+			apps[i].Task = tk
 		}
 	}
 
-	return apps, api.NewPaginationRespOk(pagination, total, apps), err
+	return nil
+}
+
+var appListColumns = listColumns{
+	"id":              {name: "id"},
+	"name":            {name: "name"},
+	"subscription_id": {name: "subscription_id"},
+	"created_user":    {name: "created_user"},
+	"created_at":      {name: "created_timestamp"},
+	"modified_at":     {name: "modified_timestamp"},
+}
+
+func (m modelApp) ListPage(opts api.ListOptions) ([]Application, api.ListMeta, error) {
+	apps := []Application{}
+
+	meta, err := m.selectPage(&apps, Application{}.Table(), "id", nil, nil, appListColumns, opts)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	err = m.completeApps(apps)
+
+	return apps, meta, err
 }
 
 type fakeModelApp struct {
@@ -795,10 +827,12 @@ func (m fakeModelApp) List(selector map[string]string) ([]Application, error) {
 		})
 
 	} else {
+		subscription, filter := selector["subscription_id"]
+
 		m.apps.Range(func(key, value interface{}) bool {
 
 			app, ok := value.(Application)
-			if ok {
+			if ok && (!filter || app.SubscriptionId == subscription) {
 				apps = append(apps, app)
 			}
 
@@ -828,6 +862,18 @@ func (m fakeModelApp) List(selector map[string]string) ([]Application, error) {
 	return apps, nil
 }
 
+func (m fakeModelApp) ListPage(opts api.ListOptions) ([]Application, api.ListMeta, error) {
+	list, err := m.List(nil)
+	if err != nil {
+		return nil, api.ListMeta{}, err
+	}
+
+	apps := []Application{}
+	meta, err := fakeSelectPage(&apps, list, "id", nil, appListColumns, opts)
+
+	return apps, meta, err
+}
+
 func (m fakeModelApp) ListWithPagination(selector map[string]string, pagination api.PaginationReq) ([]Application, api.PaginationResp, error) {
 	return nil, api.NewPaginationRespError("test"), nil
 }
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// AuditLog 审计记录，只插入不修改
+type AuditLog struct {
+	ID         string    `db:"id"`
+	User       string    `db:"user"`
+	Action     string    `db:"action"`
+	Route      string    `db:"route"`
+	Path       string    `db:"path"`
+	App        string    `db:"app_id"`
+	RemoteAddr string    `db:"remote_addr"`
+	Code       int       `db:"code"`
+	Error      string    `db:"error"`
+	Request    string    `db:"request"`
+	Changes    string    `db:"changes"`
+	CreatedAt  time.Time `db:"created_timestamp"`
+}
+
+func (AuditLog) Table() string {
+	return "tbl_audit_log"
+}
+
+// AuditSelector 审计记录的查询条件，Action为前缀匹配，空值表示不过滤
+type AuditSelector struct {
+	User   string
+	App    string
+	Action string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (s AuditSelector) match(al AuditLog) bool {
+	return (s.User == "" || al.User == s.User) &&
+		(s.App == "" || al.App == s.App) &&
+		(s.Action == "" || strings.HasPrefix(al.Action, s.Action)) &&
+		(s.Since.IsZero() || !al.CreatedAt.Before(s.Since)) &&
+		(s.Until.IsZero() || al.CreatedAt.Before(s.Until))
+}
+
+type ModelAudit interface {
+	InsertAudit(al AuditLog) (string, error)
+	// ListAudits returns the records order by time desc
+	ListAudits(selector AuditSelector) ([]AuditLog, error)
+}
+
+type modelAudit struct {
+	*dbBase
+}
+
+func (m *modelAudit) InsertAudit(al AuditLog) (string, error) {
+	if al.ID == "" {
+		al.ID = newUUID("")
+	}
+
+	query := "INSERT INTO " + al.Table() +
+		" (id,user,action,route,path,app_id,remote_addr,code,error,request,changes,created_timestamp) " +
+		"VALUES (:id,:user,:action,:route,:path,:app_id,:remote_addr,:code,:error,:request,:changes,:created_timestamp)"
+
+	_, err := m.NamedExec(query, al)
+
+	return al.ID, err
+}
+
+func (m *modelAudit) ListAudits(selector AuditSelector) ([]AuditLog, error) {
+	query := sq.Select("*").From(AuditLog{}.Table()).OrderBy("created_timestamp DESC")
+
+	if selector.User != "" {
+		query = query.Where(sq.Eq{"user": selector.User})
+	}
+	if selector.App != "" {
+		query = query.Where(sq.Eq{"app_id": selector.App})
+	}
+	if selector.Action != "" {
+		query = query.Where(sq.Like{"action": selector.Action + "%"})
+	}
+	if !selector.Since.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_timestamp": selector.Since})
+	}
+	if !selector.Until.IsZero() {
+		query = query.Where(sq.Lt{"created_timestamp": selector.Until})
+	}
+	if selector.Limit > 0 {
+		query = query.Limit(uint64(selector.Limit))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []AuditLog{}
+	err = m.Select(&list, sql, args...)
+
+	return list, err
+}
+
+type fakeModelAudit struct {
+	lock sync.Mutex
+	logs []AuditLog
+}
+
+func (m *fakeModelAudit) InsertAudit(al AuditLog) (string, error) {
+	if al.ID == "" {
+		al.ID = newUUID("")
+	}
+
+	m.lock.Lock()
+	m.logs = append(m.logs, al)
+	m.lock.Unlock()
+
+	return al.ID, nil
+}
+
+func (m *fakeModelAudit) ListAudits(selector AuditSelector) ([]AuditLog, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	list := []AuditLog{}
+
+	for i := range m.logs {
+		if selector.match(m.logs[i]) {
+			list = append(list, m.logs[i])
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	if selector.Limit > 0 && len(list) > selector.Limit {
+		list = list[:selector.Limit]
+	}
+
+	return list, nil
+}
@@ -4,6 +4,8 @@ import (
 	sq "github.com/Masterminds/squirrel"
 	"strings"
 	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 )
 
 const (
@@ -368,6 +370,32 @@ func (fakeModelBackupStrategy) ListStrategy(selector map[string]string) ([]Backu
 	return nil, nil
 }
 
+var backupFileListColumns = listColumns{
+	"id":           {name: "id"},
+	"type":         {name: "type"},
+	"status":       {name: "status"},
+	"app_id":       {name: "app_id"},
+	"unit_id":      {name: "unit_id"},
+	"site_id":      {name: "site_id"},
+	"strategy_id":  {name: "strategy_id"},
+	"created_user": {name: "created_user"},
+	"size":         {name: "size", convert: convertInt},
+	"created_at":   {name: "created_timestamp"},
+	"finished_at":  {name: "finished_timestamp"},
+	"expired_at":   {name: "expired_timestamp"},
+}
+
+// 默认按创建时间倒序
+var backupFileListDefaultSort = []api.ListSort{{Field: "created_at", Desc: true}}
+
+func (m modelBackupFile) ListFilesPage(opts api.ListOptions) ([]BackupFile, api.ListMeta, error) {
+	files := []BackupFile{}
+
+	meta, err := m.selectPage(&files, BackupFile{}.Table(), "id", backupFileListDefaultSort, nil, backupFileListColumns, opts)
+
+	return files, meta, err
+}
+
 type fakeModelBackupFile struct{}
 
 func (fakeModelBackupFile) InsertFile(bf BackupFile) (string, error) {
@@ -388,6 +416,17 @@ func (fakeModelBackupFile) GetFile(id string) (BackupFile, error) {
 func (fakeModelBackupFile) ListFiles(selector map[string]string) ([]BackupFile, error) {
 	return nil, nil
 }
+func (m fakeModelBackupFile) ListFilesPage(opts api.ListOptions) ([]BackupFile, api.ListMeta, error) {
+	list, err := m.ListFiles(nil)
+	if err != nil {
+		return nil, api.ListMeta{}, err
+	}
+
+	files := []BackupFile{}
+	meta, err := fakeSelectPage(&files, list, "id", backupFileListDefaultSort, backupFileListColumns, opts)
+
+	return files, meta, err
+}
 
 type BackupEndpoint struct {
 	ID      string `db:"id"`
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 )
 
 type ClusterBrief struct {
@@ -102,6 +104,32 @@ func (db *dbBase) getClusterBrief(id string) (ClusterBrief, error) {
 	return c, err
 }
 
+// clusterBriefs returns the briefs of clusters in ids,key: cluster id
+func (db *dbBase) clusterBriefs(ids []string) (map[string]ClusterBrief, error) {
+	out := make(map[string]ClusterBrief, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	query, args, err := sqlx.In("SELECT id,name,site_id FROM "+ClusterBrief{}.Table()+" WHERE id IN (?)", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []ClusterBrief{}
+
+	err = db.Select(&list, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		out[list[i].ID] = list[i]
+	}
+
+	return out, nil
+}
+
 func (m *modelCluster) Get(id string) (Cluster, error) {
 	c := Cluster{}
 	query := "SELECT * FROM " + c.Table() + " WHERE id=?"
@@ -247,6 +275,43 @@ func (m *modelCluster) List(selector map[string]string) ([]Cluster, error) {
 	return out, err
 }
 
+var clusterListColumns = listColumns{
+	"id":         {name: "id"},
+	"name":       {name: "name"},
+	"site_id":    {name: "site_id"},
+	"zone":       {name: "zone"},
+	"ha_tag":     {name: "ha_tag"},
+	"enabled":    {name: "enabled", convert: convertBool},
+	"created_at": {name: "created_timestamp"},
+}
+
+func (m *modelCluster) ListPage(opts api.ListOptions) ([]Cluster, api.ListMeta, error) {
+	clusters := []Cluster{}
+
+	meta, err := m.selectPage(&clusters, Cluster{}.Table(), "id", nil, nil, clusterListColumns, opts)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	sites := make(map[string]SiteBrief)
+
+	for i := range clusters {
+		bs, ok := sites[clusters[i].SiteID]
+		if !ok {
+			bs, err = m.getSiteBrief(clusters[i].SiteID)
+			if err != nil {
+				return nil, meta, err
+			}
+
+			sites[clusters[i].SiteID] = bs
+		}
+
+		clusters[i].Site = bs
+	}
+
+	return clusters, meta, nil
+}
+
 type fakeModelCluster struct {
 	sites    *sync.Map
 	clusters *sync.Map
@@ -352,3 +417,21 @@ func (m *fakeModelCluster) List(selector map[string]string) ([]Cluster, error) {
 
 	return nil, nil
 }
+
+func (m *fakeModelCluster) ListPage(opts api.ListOptions) ([]Cluster, api.ListMeta, error) {
+	list := make([]Cluster, 0, 1)
+
+	m.clusters.Range(func(key, value interface{}) bool {
+		v, ok := value.(Cluster)
+		if ok {
+			list = append(list, v)
+		}
+
+		return true
+	})
+
+	clusters := []Cluster{}
+	meta, err := fakeSelectPage(&clusters, list, "id", nil, clusterListColumns, opts)
+
+	return clusters, meta, err
+}
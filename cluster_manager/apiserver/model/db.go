@@ -93,6 +93,84 @@ func (db *dbBase) ModelBackupEndpoint() ModelBackupEndpoint {
 	}
 }
 
+func (db *dbBase) ModelAlert() ModelAlert {
+	return &modelAlert{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelAudit() ModelAudit {
+	return &modelAudit{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelWebhook() ModelWebhook {
+	return &modelWebhook{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelSubscription() ModelSubscription {
+	return &modelSubscription{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelUsage() ModelUsage {
+	return &modelUsage{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelDBUserCredential() ModelDBUserCredential {
+	return &modelDBUserCredential{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelDBUserSpec() ModelDBUserSpec {
+	return &modelDBUserSpec{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelSchemaMigration() ModelSchemaMigration {
+	return &modelSchemaMigration{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelHostMaintenance() ModelHostMaintenance {
+	return &modelHostMaintenance{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelIdempotency() ModelIdempotency {
+	return &modelIdempotency{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelRevision() ModelRevision {
+	return &modelRevision{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelImageCampaign() ModelImageCampaign {
+	return &modelImageCampaign{
+		dbBase: db,
+	}
+}
+
+func (db *dbBase) ModelImageTemplate() ModelImageTemplate {
+	return &modelImageTemplate{
+		dbBase: db,
+	}
+}
+
 // NewDB connect to a database and verify with Ping.
 func NewDB(config DBConfig) (*dbBase, error) {
 	if config.Auth != "" && config.User == "" {
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// DBUserCredential 数据库用户的密码记录，不保存密码，
+// History 为最近使用过的密码的加盐哈希，逗号分隔，最新的在前
+type DBUserCredential struct {
+	App             string    `db:"app_id"`
+	Name            string    `db:"name"`
+	IP              string    `db:"ip"`
+	History         string    `db:"password_history"`
+	Generated       bool      `db:"generated"`
+	Site            string    `db:"site_id"`
+	SecretNamespace string    `db:"secret_namespace"`
+	SecretName      string    `db:"secret_name"`
+	Retained        bool      `db:"old_password_retained"`
+	Warned          bool      `db:"expiry_warned"`
+	ChangedAt       time.Time `db:"changed_timestamp"`
+}
+
+func (DBUserCredential) Table() string {
+	return "tbl_db_user_credential"
+}
+
+func (c DBUserCredential) key() string {
+	return c.App + "/" + c.Name + "@" + c.IP
+}
+
+type ModelDBUserCredential interface {
+	// SaveDBUserCredential inserts or replaces the record of App,Name,IP
+	SaveDBUserCredential(c DBUserCredential) error
+	DeleteDBUserCredential(app, name, ip string) error
+	GetDBUserCredential(app, name, ip string) (DBUserCredential, error)
+	// ListDBUserCredentials returns the records of app,all records if app is empty
+	ListDBUserCredentials(app string) ([]DBUserCredential, error)
+}
+
+type modelDBUserCredential struct {
+	*dbBase
+}
+
+func (m *modelDBUserCredential) SaveDBUserCredential(c DBUserCredential) error {
+	query := "INSERT INTO " + c.Table() +
+		" (app_id,name,ip,password_history,generated,site_id,secret_namespace,secret_name,old_password_retained,expiry_warned,changed_timestamp) " +
+		"VALUES (:app_id,:name,:ip,:password_history,:generated,:site_id,:secret_namespace,:secret_name,:old_password_retained,:expiry_warned,:changed_timestamp) " +
+		"ON DUPLICATE KEY UPDATE password_history=VALUES(password_history),generated=VALUES(generated),site_id=VALUES(site_id)," +
+		"secret_namespace=VALUES(secret_namespace),secret_name=VALUES(secret_name),old_password_retained=VALUES(old_password_retained)," +
+		"expiry_warned=VALUES(expiry_warned),changed_timestamp=VALUES(changed_timestamp)"
+
+	_, err := m.NamedExec(query, c)
+
+	return err
+}
+
+func (m *modelDBUserCredential) DeleteDBUserCredential(app, name, ip string) error {
+	query := "DELETE FROM " + DBUserCredential{}.Table() + " WHERE app_id=? AND name=? AND ip=?"
+
+	_, err := m.Exec(query, app, name, ip)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelDBUserCredential) GetDBUserCredential(app, name, ip string) (DBUserCredential, error) {
+	c := DBUserCredential{}
+	query := "SELECT * FROM " + c.Table() + " WHERE app_id=? AND name=? AND ip=?"
+
+	err := m.dbBase.Get(&c, query, app, name, ip)
+
+	return c, err
+}
+
+func (m *modelDBUserCredential) ListDBUserCredentials(app string) ([]DBUserCredential, error) {
+	var (
+		err  error
+		list = []DBUserCredential{}
+	)
+
+	if app == "" {
+		err = m.Select(&list, "SELECT * FROM "+DBUserCredential{}.Table())
+	} else {
+		err = m.Select(&list, "SELECT * FROM "+DBUserCredential{}.Table()+" WHERE app_id=?", app)
+	}
+
+	return list, err
+}
+
+type fakeModelDBUserCredential struct {
+	credentials *sync.Map
+}
+
+func (m *fakeModelDBUserCredential) SaveDBUserCredential(c DBUserCredential) error {
+	m.credentials.Store(c.key(), c)
+
+	return nil
+}
+
+func (m *fakeModelDBUserCredential) DeleteDBUserCredential(app, name, ip string) error {
+	m.credentials.Delete(DBUserCredential{App: app, Name: name, IP: ip}.key())
+
+	return nil
+}
+
+func (m *fakeModelDBUserCredential) GetDBUserCredential(app, name, ip string) (DBUserCredential, error) {
+	key := DBUserCredential{App: app, Name: name, IP: ip}.key()
+
+	v, ok := m.credentials.Load(key)
+	if !ok {
+		return DBUserCredential{}, NewNotFound("db user credential", key)
+	}
+
+	return v.(DBUserCredential), nil
+}
+
+func (m *fakeModelDBUserCredential) ListDBUserCredentials(app string) ([]DBUserCredential, error) {
+	list := []DBUserCredential{}
+
+	m.credentials.Range(func(key, value interface{}) bool {
+		c := value.(DBUserCredential)
+
+		if app == "" || c.App == app {
+			list = append(list, c)
+		}
+
+		return true
+	})
+
+	return list, nil
+}
+
+// DBUserSpec 服务期望的数据库用户，Users 及 Status 为 json
+type DBUserSpec struct {
+	App      string    `db:"app_id"`
+	Mode     string    `db:"mode"`
+	Users    string    `db:"users"`
+	Status   string    `db:"status"`
+	Drifted  bool      `db:"drifted"`
+	Modified time.Time `db:"modified_timestamp"`
+}
+
+func (DBUserSpec) Table() string {
+	return "tbl_db_user_spec"
+}
+
+type ModelDBUserSpec interface {
+	// SaveDBUserSpec inserts or replaces the mode and users of App,the status is kept
+	SaveDBUserSpec(s DBUserSpec) error
+	UpdateDBUserSpecStatus(app, status string, drifted bool) error
+	DeleteDBUserSpec(app string) error
+	GetDBUserSpec(app string) (DBUserSpec, error)
+	ListDBUserSpecs() ([]DBUserSpec, error)
+}
+
+type modelDBUserSpec struct {
+	*dbBase
+}
+
+func (m *modelDBUserSpec) SaveDBUserSpec(s DBUserSpec) error {
+	query := "INSERT INTO " + s.Table() + " (app_id,mode,users,status,drifted,modified_timestamp) " +
+		"VALUES (:app_id,:mode,:users,:status,:drifted,:modified_timestamp) " +
+		"ON DUPLICATE KEY UPDATE mode=VALUES(mode),users=VALUES(users),modified_timestamp=VALUES(modified_timestamp)"
+
+	_, err := m.NamedExec(query, s)
+
+	return err
+}
+
+func (m *modelDBUserSpec) UpdateDBUserSpecStatus(app, status string, drifted bool) error {
+	query := "UPDATE " + DBUserSpec{}.Table() + " SET status=?,drifted=? WHERE app_id=?"
+
+	_, err := m.Exec(query, status, drifted, app)
+
+	return err
+}
+
+func (m *modelDBUserSpec) DeleteDBUserSpec(app string) error {
+	query := "DELETE FROM " + DBUserSpec{}.Table() + " WHERE app_id=?"
+
+	_, err := m.Exec(query, app)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelDBUserSpec) GetDBUserSpec(app string) (DBUserSpec, error) {
+	s := DBUserSpec{}
+	query := "SELECT * FROM " + s.Table() + " WHERE app_id=?"
+
+	err := m.dbBase.Get(&s, query, app)
+
+	return s, err
+}
+
+func (m *modelDBUserSpec) ListDBUserSpecs() ([]DBUserSpec, error) {
+	list := []DBUserSpec{}
+
+	err := m.Select(&list, "SELECT * FROM "+DBUserSpec{}.Table())
+
+	return list, err
+}
+
+type fakeModelDBUserSpec struct {
+	specs *sync.Map
+}
+
+func (m *fakeModelDBUserSpec) SaveDBUserSpec(s DBUserSpec) error {
+	if v, ok := m.specs.Load(s.App); ok {
+		old := v.(DBUserSpec)
+		s.Status, s.Drifted = old.Status, old.Drifted
+	}
+
+	m.specs.Store(s.App, s)
+
+	return nil
+}
+
+func (m *fakeModelDBUserSpec) UpdateDBUserSpecStatus(app, status string, drifted bool) error {
+	v, ok := m.specs.Load(app)
+	if !ok {
+		return NewNotFound("db user spec", app)
+	}
+
+	s := v.(DBUserSpec)
+	s.Status, s.Drifted = status, drifted
+	m.specs.Store(app, s)
+
+	return nil
+}
+
+func (m *fakeModelDBUserSpec) DeleteDBUserSpec(app string) error {
+	m.specs.Delete(app)
+
+	return nil
+}
+
+func (m *fakeModelDBUserSpec) GetDBUserSpec(app string) (DBUserSpec, error) {
+	v, ok := m.specs.Load(app)
+	if !ok {
+		return DBUserSpec{}, NewNotFound("db user spec", app)
+	}
+
+	return v.(DBUserSpec), nil
+}
+
+func (m *fakeModelDBUserSpec) ListDBUserSpecs() ([]DBUserSpec, error) {
+	list := []DBUserSpec{}
+
+	m.specs.Range(func(key, value interface{}) bool {
+		list = append(list, value.(DBUserSpec))
+		return true
+	})
+
+	return list, nil
+}
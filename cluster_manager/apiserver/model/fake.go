@@ -26,6 +26,28 @@ type fakeModels struct {
 	units *sync.Map
 
 	tasks *sync.Map
+
+	alertConfigs *sync.Map
+	channels     *sync.Map
+
+	audits *fakeModelAudit
+
+	webhooks   *sync.Map
+	deliveries *sync.Map
+
+	idempotency *sync.Map
+	revisions   *fakeModelRevision
+
+	campaigns *sync.Map
+	templates *sync.Map
+
+	subscriptions *sync.Map
+	usage         *fakeModelUsage
+	credentials   *sync.Map
+	userSpecs     *sync.Map
+	migrations    *sync.Map
+	migrationLock *sync.Map
+	maintenances  *sync.Map
 }
 
 func NewFakeModels() *fakeModels {
@@ -34,11 +56,36 @@ func NewFakeModels() *fakeModels {
 		clusters: new(sync.Map),
 		networks: new(sync.Map),
 		hosts:    new(sync.Map),
+
+		hostStorages: new(sync.Map),
+
 		images:   new(sync.Map),
 		storages: new(sync.Map),
 		apps:     new(sync.Map),
 		units:    new(sync.Map),
 		tasks:    new(sync.Map),
+
+		alertConfigs: new(sync.Map),
+		channels:     new(sync.Map),
+
+		audits: &fakeModelAudit{},
+
+		webhooks:   new(sync.Map),
+		deliveries: new(sync.Map),
+
+		idempotency: new(sync.Map),
+		revisions:   &fakeModelRevision{revisions: make(map[string]int64)},
+
+		campaigns: new(sync.Map),
+		templates: new(sync.Map),
+
+		subscriptions: new(sync.Map),
+		usage:         &fakeModelUsage{},
+		credentials:   new(sync.Map),
+		userSpecs:     new(sync.Map),
+		migrations:    new(sync.Map),
+		migrationLock: new(sync.Map),
+		maintenances:  new(sync.Map),
 	}
 }
 
@@ -123,3 +170,78 @@ func (fakeModels) ModelBackupFile() ModelBackupFile {
 func (fakeModels) ModelBackupEndpoint() ModelBackupEndpoint {
 	return &fakeModelBackupEndpoint{}
 }
+
+func (f *fakeModels) ModelAlert() ModelAlert {
+	return &fakeModelAlert{
+		configs:  f.alertConfigs,
+		channels: f.channels,
+	}
+}
+
+func (f *fakeModels) ModelAudit() ModelAudit {
+	return f.audits
+}
+
+func (f *fakeModels) ModelWebhook() ModelWebhook {
+	return &fakeModelWebhook{
+		webhooks:   f.webhooks,
+		deliveries: f.deliveries,
+	}
+}
+
+func (f *fakeModels) ModelIdempotency() ModelIdempotency {
+	return &fakeModelIdempotency{
+		records: f.idempotency,
+	}
+}
+
+func (f *fakeModels) ModelRevision() ModelRevision {
+	return f.revisions
+}
+
+func (f *fakeModels) ModelImageCampaign() ModelImageCampaign {
+	return &fakeModelImageCampaign{
+		campaigns: f.campaigns,
+	}
+}
+
+func (f *fakeModels) ModelSubscription() ModelSubscription {
+	return &fakeModelSubscription{
+		subscriptions: f.subscriptions,
+	}
+}
+
+func (f *fakeModels) ModelUsage() ModelUsage {
+	return f.usage
+}
+
+func (f *fakeModels) ModelDBUserCredential() ModelDBUserCredential {
+	return &fakeModelDBUserCredential{
+		credentials: f.credentials,
+	}
+}
+
+func (f *fakeModels) ModelDBUserSpec() ModelDBUserSpec {
+	return &fakeModelDBUserSpec{
+		specs: f.userSpecs,
+	}
+}
+
+func (f *fakeModels) ModelSchemaMigration() ModelSchemaMigration {
+	return &fakeModelSchemaMigration{
+		migrations: f.migrations,
+		locks:      f.migrationLock,
+	}
+}
+
+func (f *fakeModels) ModelHostMaintenance() ModelHostMaintenance {
+	return &fakeModelHostMaintenance{
+		maintenances: f.maintenances,
+	}
+}
+
+func (f *fakeModels) ModelImageTemplate() ModelImageTemplate {
+	return &fakeModelImageTemplate{
+		revisions: f.templates,
+	}
+}
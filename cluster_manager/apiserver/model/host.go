@@ -5,6 +5,8 @@ import (
 	"sync"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 )
 
 type Host struct {
@@ -349,14 +351,21 @@ func (m *modelHost) List(selector map[string]string) ([]Host, error) {
 		}
 	}
 
+	err := m.completeHosts(hosts)
+
+	return hosts, err
+}
+
+// completeHosts sets the host storages,remote storage name and latest task of hosts.
+func (m *modelHost) completeHosts(hosts []Host) error {
 	rss := make(map[string]string)
 
-	query = "SELECT * FROM " + HostStorage{}.Table() +
+	query := "SELECT * FROM " + HostStorage{}.Table() +
 		" WHERE host_id=?"
 	for i := range hosts {
 		err := m.Select(&hosts[i].HostStorages, query, hosts[i].ID)
 		if err != nil {
-			return hosts, err
+			return err
 		}
 
 		if hosts[i].RemoteStorageID != "" {
@@ -367,7 +376,7 @@ func (m *modelHost) List(selector map[string]string) ([]Host, error) {
 
 				rsb, err := m.getRemoteStorageBrief(hosts[i].RemoteStorageID)
 				if err != nil {
-					return hosts, err
+					return err
 				}
 
 				hosts[i].RemoteStorageName = rsb.Name
@@ -380,7 +389,47 @@ func (m *modelHost) List(selector map[string]string) ([]Host, error) {
 		hosts[i].Task, _ = m.latestByRelateID(hosts[i].ID)
 	}
 
-	return hosts, nil
+	return nil
+}
+
+var hostListColumns = listColumns{
+	"id":         {name: "id"},
+	"name":       {name: "host_name"},
+	"ip":         {name: "host_ip"},
+	"cluster_id": {name: "cluster_id"},
+	"room":       {name: "room"},
+	"seat":       {name: "seat"},
+	"enabled":    {name: "enabled", convert: convertBool},
+	"created_at": {name: "created_timestamp"},
+}
+
+func (m *modelHost) ListPage(opts api.ListOptions) ([]Host, api.ListMeta, error) {
+	hosts := []Host{}
+
+	meta, err := m.selectPage(&hosts, Host{}.Table(), "id", nil, nil, hostListColumns, opts)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	ids := make([]string, 0, len(hosts))
+	for i := range hosts {
+		if hosts[i].ClusterID != "" {
+			ids = append(ids, hosts[i].ClusterID)
+		}
+	}
+
+	clusters, err := m.clusterBriefs(ids)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	for i := range hosts {
+		hosts[i].Cluster = clusters[hosts[i].ClusterID]
+	}
+
+	err = m.completeHosts(hosts)
+
+	return hosts, meta, err
 }
 
 type fakeModelHost struct {
@@ -586,6 +635,18 @@ func (m *fakeModelHost) List(selector map[string]string) ([]Host, error) {
 	return hosts, nil
 }
 
+func (m *fakeModelHost) ListPage(opts api.ListOptions) ([]Host, api.ListMeta, error) {
+	list, err := m.List(nil)
+	if err != nil {
+		return nil, api.ListMeta{}, err
+	}
+
+	hosts := []Host{}
+	meta, err := fakeSelectPage(&hosts, list, "id", nil, hostListColumns, opts)
+
+	return hosts, meta, err
+}
+
 func (m *fakeModelHost) ListUnits() ([]Unit, error) {
 	return []Unit{}, nil
 }
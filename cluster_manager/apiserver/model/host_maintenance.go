@@ -0,0 +1,122 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// HostMaintenance 主机维护模式的迁移计划及进度，Status 为 api.HostMaintenance 的json
+type HostMaintenance struct {
+	Host      string    `db:"host_id"`
+	State     string    `db:"state"`
+	Status    string    `db:"status"`
+	UpdatedAt time.Time `db:"modified_timestamp"`
+}
+
+func (HostMaintenance) Table() string {
+	return "tbl_host_maintenance"
+}
+
+type ModelHostMaintenance interface {
+	// SaveHostMaintenance inserts or replaces the maintenance of Host
+	SaveHostMaintenance(hm HostMaintenance) error
+	DeleteHostMaintenance(host string) error
+	GetHostMaintenance(host string) (HostMaintenance, error)
+	// ListHostMaintenances returns the maintenances in state,all if state is empty
+	ListHostMaintenances(state string) ([]HostMaintenance, error)
+}
+
+type modelHostMaintenance struct {
+	*dbBase
+}
+
+func (m *modelHostMaintenance) SaveHostMaintenance(hm HostMaintenance) error {
+	query := "INSERT INTO " + hm.Table() + " (host_id,state,status,modified_timestamp) " +
+		"VALUES (:host_id,:state,:status,:modified_timestamp) " +
+		"ON DUPLICATE KEY UPDATE state=VALUES(state),status=VALUES(status),modified_timestamp=VALUES(modified_timestamp)"
+
+	_, err := m.NamedExec(query, hm)
+
+	return err
+}
+
+func (m *modelHostMaintenance) DeleteHostMaintenance(host string) error {
+	query := "DELETE FROM " + HostMaintenance{}.Table() + " WHERE host_id=?"
+
+	_, err := m.Exec(query, host)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelHostMaintenance) GetHostMaintenance(host string) (HostMaintenance, error) {
+	hm := HostMaintenance{}
+	query := "SELECT * FROM " + hm.Table() + " WHERE host_id=?"
+
+	err := m.dbBase.Get(&hm, query, host)
+
+	return hm, err
+}
+
+func (m *modelHostMaintenance) ListHostMaintenances(state string) ([]HostMaintenance, error) {
+	var (
+		err  error
+		list = []HostMaintenance{}
+	)
+
+	if state == "" {
+		err = m.Select(&list, "SELECT * FROM "+HostMaintenance{}.Table()+" ORDER BY host_id")
+	} else {
+		err = m.Select(&list, "SELECT * FROM "+HostMaintenance{}.Table()+" WHERE state=? ORDER BY host_id", state)
+	}
+
+	return list, err
+}
+
+type fakeModelHostMaintenance struct {
+	maintenances *sync.Map
+}
+
+func (m *fakeModelHostMaintenance) SaveHostMaintenance(hm HostMaintenance) error {
+	m.maintenances.Store(hm.Host, hm)
+
+	return nil
+}
+
+func (m *fakeModelHostMaintenance) DeleteHostMaintenance(host string) error {
+	m.maintenances.Delete(host)
+
+	return nil
+}
+
+func (m *fakeModelHostMaintenance) GetHostMaintenance(host string) (HostMaintenance, error) {
+	v, ok := m.maintenances.Load(host)
+	if !ok {
+		return HostMaintenance{}, NewNotFound("host maintenance", host)
+	}
+
+	return v.(HostMaintenance), nil
+}
+
+func (m *fakeModelHostMaintenance) ListHostMaintenances(state string) ([]HostMaintenance, error) {
+	list := []HostMaintenance{}
+
+	m.maintenances.Range(func(key, value interface{}) bool {
+		hm := value.(HostMaintenance)
+
+		if state == "" || hm.State == state {
+			list = append(list, hm)
+		}
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Host < list[j].Host
+	})
+
+	return list, nil
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord 携带 Idempotency-Key 的请求及其响应，Done 为 false 时请求仍在处理
+type IdempotencyRecord struct {
+	Key         string    `db:"idempotency_key"`
+	Method      string    `db:"method"`
+	Path        string    `db:"path"`
+	RequestHash string    `db:"request_hash"`
+	Done        bool      `db:"done"`
+	Code        int       `db:"code"`
+	Response    string    `db:"response"`
+	Error       string    `db:"error"`
+	ETag        string    `db:"etag"`
+	CreatedAt   time.Time `db:"created_timestamp"`
+}
+
+func (IdempotencyRecord) Table() string {
+	return "tbl_idempotency_key"
+}
+
+type ModelIdempotency interface {
+	// InsertIdempotency returns false if the record of Key,Method,Path already exists
+	InsertIdempotency(rec IdempotencyRecord) (bool, error)
+	UpdateIdempotency(rec IdempotencyRecord) error
+	DeleteIdempotency(key, method, path string) error
+	GetIdempotency(key, method, path string) (IdempotencyRecord, error)
+}
+
+type modelIdempotency struct {
+	*dbBase
+}
+
+func (m *modelIdempotency) InsertIdempotency(rec IdempotencyRecord) (bool, error) {
+	query := "INSERT IGNORE INTO " + rec.Table() +
+		" (idempotency_key,method,path,request_hash,done,code,response,error,etag,created_timestamp) " +
+		"VALUES (:idempotency_key,:method,:path,:request_hash,:done,:code,:response,:error,:etag,:created_timestamp)"
+
+	result, err := m.NamedExec(query, rec)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return n > 0, err
+}
+
+func (m *modelIdempotency) UpdateIdempotency(rec IdempotencyRecord) error {
+	query := "UPDATE " + rec.Table() + " SET request_hash=:request_hash,done=:done,code=:code,response=:response,error=:error,etag=:etag " +
+		"WHERE idempotency_key=:idempotency_key AND method=:method AND path=:path"
+
+	_, err := m.NamedExec(query, rec)
+
+	return err
+}
+
+func (m *modelIdempotency) DeleteIdempotency(key, method, path string) error {
+	query := "DELETE FROM " + IdempotencyRecord{}.Table() + " WHERE idempotency_key=? AND method=? AND path=?"
+
+	_, err := m.Exec(query, key, method, path)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelIdempotency) GetIdempotency(key, method, path string) (IdempotencyRecord, error) {
+	rec := IdempotencyRecord{}
+	query := "SELECT * FROM " + rec.Table() + " WHERE idempotency_key=? AND method=? AND path=?"
+
+	err := m.dbBase.Get(&rec, query, key, method, path)
+
+	return rec, err
+}
+
+type fakeModelIdempotency struct {
+	records *sync.Map
+}
+
+func idempotencyKey(key, method, path string) string {
+	return method + " " + path + " " + key
+}
+
+func (m *fakeModelIdempotency) InsertIdempotency(rec IdempotencyRecord) (bool, error) {
+	_, loaded := m.records.LoadOrStore(idempotencyKey(rec.Key, rec.Method, rec.Path), rec)
+
+	return !loaded, nil
+}
+
+func (m *fakeModelIdempotency) UpdateIdempotency(rec IdempotencyRecord) error {
+	key := idempotencyKey(rec.Key, rec.Method, rec.Path)
+
+	if _, ok := m.records.Load(key); !ok {
+		return NewNotFound("idempotency key", rec.Key)
+	}
+
+	m.records.Store(key, rec)
+
+	return nil
+}
+
+func (m *fakeModelIdempotency) DeleteIdempotency(key, method, path string) error {
+	m.records.Delete(idempotencyKey(key, method, path))
+
+	return nil
+}
+
+func (m *fakeModelIdempotency) GetIdempotency(key, method, path string) (IdempotencyRecord, error) {
+	v, ok := m.records.Load(idempotencyKey(key, method, path))
+	if !ok {
+		return IdempotencyRecord{}, NewNotFound("idempotency key", key)
+	}
+
+	return v.(IdempotencyRecord), nil
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -63,6 +64,15 @@ type Image struct {
 	ConfigTemplate string `db:"config_template"`
 	PodTemplate    string `db:"pod_template"`
 
+	// Digest 导入时 tag 对应的 digest，DriftDigest 为 tag 当前对应的不同的 digest
+	Digest          string `db:"digest"`
+	SignatureStatus string `db:"signature_status"`
+	DriftDigest     string `db:"drift_digest"`
+
+	// 弃用及停止支持时间，为空表示未设置
+	DeprecatedAt *time.Time `db:"deprecated_at"`
+	EOLAt        *time.Time `db:"eol_at"`
+
 	Site SiteBrief `db:"-"`
 
 	Editor
@@ -93,6 +103,28 @@ func (Image) Table() string {
 	return "tbl_image"
 }
 
+// Lifecycle returns the lifecycle state of the image at t
+func (im Image) Lifecycle(t time.Time) string {
+	switch {
+	case im.EOLAt != nil && !t.Before(*im.EOLAt):
+		return api.ImageLifecycleEOL
+	case im.DeprecatedAt != nil && !t.Before(*im.DeprecatedAt):
+		return api.ImageLifecycleDeprecated
+	}
+
+	return api.ImageLifecycleActive
+}
+
+// Reference returns the image reference in site registry,pinned by digest if known.
+func (im Image) Reference(registry, projectName string) string {
+	ref := fmt.Sprintf("%s/%s/%s", registry, projectName, im.ImageWithArch())
+	if im.Digest != "" {
+		ref += "@" + im.Digest
+	}
+
+	return ref
+}
+
 func (iv Image) ObjectName() string {
 	return fmt.Sprintf("%s-%d.%d.%d.%d", iv.Type, iv.Major, iv.Minor, iv.Patch, iv.Dev)
 }
@@ -115,8 +147,8 @@ func (m *modelImage) Insert(im Image) (string, string, error) {
 	task := NewTask(ActionImageAdd, im.ID, im.Table(), im.CreatedUser)
 
 	query := "INSERT INTO " + im.Table() +
-		" (id,type,site_id,arch,version_major,version_minor,version_patch,version_build,unschedulable,description,exporter_port,key_sets,config_template,pod_template,created_timestamp,modified_timestamp) " +
-		"VALUES (:id,:type,:site_id,:arch,:version_major,:version_minor,:version_patch,:version_build,:unschedulable,:description,:exporter_port,:key_sets,:config_template,:pod_template,:created_timestamp,:modified_timestamp)"
+		" (id,type,site_id,arch,version_major,version_minor,version_patch,version_build,unschedulable,description,exporter_port,key_sets,config_template,pod_template,digest,signature_status,drift_digest,created_timestamp,modified_timestamp) " +
+		"VALUES (:id,:type,:site_id,:arch,:version_major,:version_minor,:version_patch,:version_build,:unschedulable,:description,:exporter_port,:key_sets,:config_template,:pod_template,:digest,:signature_status,:drift_digest,:created_timestamp,:modified_timestamp)"
 
 	err := m.txFrame(func(tx Tx) error {
 
@@ -150,6 +182,39 @@ func (m *modelImage) Update(im Image) error {
 	return err
 }
 
+// UpdateDigest set digest,signature status and unschedulable of Image
+func (m *modelImage) UpdateDigest(im Image) error {
+	query := "UPDATE " + im.Table() +
+		" SET digest=:digest,signature_status=:signature_status,drift_digest=:drift_digest,unschedulable=:unschedulable,modified_timestamp=:modified_timestamp " +
+		"WHERE id=:id"
+
+	_, err := m.NamedExec(query, im)
+
+	return err
+}
+
+// UpdateTemplate set key_sets and config_template of Image
+func (m *modelImage) UpdateTemplate(im Image) error {
+	query := "UPDATE " + im.Table() +
+		" SET key_sets=:key_sets,config_template=:config_template,modified_timestamp=:modified_timestamp " +
+		"WHERE id=:id"
+
+	_, err := m.NamedExec(query, im)
+
+	return err
+}
+
+// UpdateLifecycle set deprecated_at and eol_at of Image
+func (m *modelImage) UpdateLifecycle(im Image) error {
+	query := "UPDATE " + im.Table() +
+		" SET deprecated_at=:deprecated_at,eol_at=:eol_at,modified_timestamp=:modified_timestamp " +
+		"WHERE id=:id"
+
+	_, err := m.NamedExec(query, im)
+
+	return err
+}
+
 func (m *modelImage) UpdateImageTask(im *Image, tk Task) error {
 	if im == nil {
 		return m.UpdateTask(tk)
@@ -158,7 +223,7 @@ func (m *modelImage) UpdateImageTask(im *Image, tk Task) error {
 	return m.txFrame(func(tx Tx) error {
 
 		query := "UPDATE " + im.Table() +
-			" SET unschedulable=:unschedulable,description=:description,modified_timestamp=:modified_timestamp " +
+			" SET unschedulable=:unschedulable,description=:description,digest=:digest,signature_status=:signature_status,modified_timestamp=:modified_timestamp " +
 			"WHERE id=:id"
 
 		_, err := tx.NamedExec(query, im)
@@ -207,9 +272,11 @@ func (m *modelImage) Get(id string) (Image, error) {
 func (m *modelImage) GetLatest(type_, arch string) (Image, error) {
 	im := Image{}
 
-	query := "SELECT * FROM " + im.Table() + " WHERE type=? AND arch=? order by id desc limit 1"
+	// 已弃用的镜像不能用于新建服务
+	query := "SELECT * FROM " + im.Table() + " WHERE type=? AND arch=? AND (deprecated_at IS NULL OR deprecated_at>?) AND (eol_at IS NULL OR eol_at>?) order by id desc limit 1"
 
-	err := m.dbBase.Get(&im, query, type_, arch)
+	now := time.Now()
+	err := m.dbBase.Get(&im, query, type_, arch, now, now)
 	if err != nil {
 		return im, errors.Errorf("get latest image: %s %s from db ERR: %s", type_, arch, err)
 	}
@@ -406,6 +473,56 @@ func (m *fakeModelImage) Update(im Image) error {
 	return nil
 }
 
+func (m *fakeModelImage) UpdateDigest(im Image) error {
+	v, ok := m.images.Load(im.ID)
+	if !ok {
+		return NewNotFound("image", im.ID)
+	}
+
+	old := v.(Image)
+	old.Digest = im.Digest
+	old.SignatureStatus = im.SignatureStatus
+	old.DriftDigest = im.DriftDigest
+	old.Unschedulable = im.Unschedulable
+	old.ModifiedAt = im.ModifiedAt
+
+	m.images.Store(im.ID, old)
+
+	return nil
+}
+
+func (m *fakeModelImage) UpdateLifecycle(im Image) error {
+	v, ok := m.images.Load(im.ID)
+	if !ok {
+		return NewNotFound("image", im.ID)
+	}
+
+	old := v.(Image)
+	old.DeprecatedAt = im.DeprecatedAt
+	old.EOLAt = im.EOLAt
+	old.ModifiedAt = im.ModifiedAt
+
+	m.images.Store(im.ID, old)
+
+	return nil
+}
+
+func (m *fakeModelImage) UpdateTemplate(im Image) error {
+	v, ok := m.images.Load(im.ID)
+	if !ok {
+		return NewNotFound("image", im.ID)
+	}
+
+	old := v.(Image)
+	old.KeySets = im.KeySets
+	old.ConfigTemplate = im.ConfigTemplate
+	old.ModifiedAt = im.ModifiedAt
+
+	m.images.Store(im.ID, old)
+
+	return nil
+}
+
 func (m *fakeModelImage) UpdateImageTask(_ *Image, _ Task) error {
 	return nil
 }
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ImageCampaign 镜像升级活动，Spec为维护窗口及并发数，Apps为各服务的升级进度，均为json
+type ImageCampaign struct {
+	ID    string `db:"id"`
+	Name  string `db:"name"`
+	Image string `db:"image_id"`
+	State string `db:"state"`
+	Spec  string `db:"spec"`
+	Apps  string `db:"apps"`
+	Editor
+}
+
+func (ImageCampaign) Table() string {
+	return "tbl_image_campaign"
+}
+
+type ModelImageCampaign interface {
+	InsertCampaign(c ImageCampaign) (string, error)
+	// UpdateCampaign set state and apps of ImageCampaign
+	UpdateCampaign(c ImageCampaign) error
+	GetCampaign(id string) (ImageCampaign, error)
+	ListCampaigns(selector map[string]string) ([]ImageCampaign, error)
+}
+
+type modelImageCampaign struct {
+	*dbBase
+}
+
+func (m *modelImageCampaign) InsertCampaign(c ImageCampaign) (string, error) {
+	if c.ID == "" {
+		c.ID = newUUID("")
+	}
+
+	query := "INSERT INTO " + c.Table() +
+		" (id,name,image_id,state,spec,apps,created_user,created_timestamp,modified_user,modified_timestamp) " +
+		"VALUES (:id,:name,:image_id,:state,:spec,:apps,:created_user,:created_timestamp,:modified_user,:modified_timestamp)"
+
+	_, err := m.NamedExec(query, c)
+
+	return c.ID, err
+}
+
+func (m *modelImageCampaign) UpdateCampaign(c ImageCampaign) error {
+	query := "UPDATE " + c.Table() + " SET state=:state,apps=:apps," +
+		"modified_user=:modified_user,modified_timestamp=:modified_timestamp WHERE id=:id"
+
+	_, err := m.NamedExec(query, c)
+
+	return err
+}
+
+func (m *modelImageCampaign) GetCampaign(id string) (ImageCampaign, error) {
+	c := ImageCampaign{}
+	query := "SELECT * FROM " + c.Table() + " WHERE id=?"
+
+	err := m.dbBase.Get(&c, query, id)
+
+	return c, err
+}
+
+func (m *modelImageCampaign) ListCampaigns(selector map[string]string) ([]ImageCampaign, error) {
+	query := sq.Select("*").From(ImageCampaign{}.Table()).OrderBy("created_timestamp DESC")
+
+	if state, ok := selector["state"]; ok {
+		query = query.Where(sq.Eq{"state": state})
+	}
+	if image, ok := selector["image_id"]; ok {
+		query = query.Where(sq.Eq{"image_id": image})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []ImageCampaign{}
+	err = m.Select(&list, sql, args...)
+
+	return list, err
+}
+
+type fakeModelImageCampaign struct {
+	campaigns *sync.Map
+}
+
+func (m *fakeModelImageCampaign) InsertCampaign(c ImageCampaign) (string, error) {
+	if c.ID == "" {
+		c.ID = newUUID("")
+	}
+
+	m.campaigns.Store(c.ID, c)
+
+	return c.ID, nil
+}
+
+func (m *fakeModelImageCampaign) UpdateCampaign(c ImageCampaign) error {
+	if c.ID == "" {
+		return errors.New("id is required")
+	}
+
+	v, ok := m.campaigns.Load(c.ID)
+	if !ok {
+		return NewNotFound("image campaign", c.ID)
+	}
+
+	old := v.(ImageCampaign)
+	old.State = c.State
+	old.Apps = c.Apps
+	old.ModifiedUser = c.ModifiedUser
+	old.ModifiedAt = c.ModifiedAt
+
+	m.campaigns.Store(c.ID, old)
+
+	return nil
+}
+
+func (m *fakeModelImageCampaign) GetCampaign(id string) (ImageCampaign, error) {
+	v, ok := m.campaigns.Load(id)
+	if !ok {
+		return ImageCampaign{}, NewNotFound("image campaign", id)
+	}
+
+	return v.(ImageCampaign), nil
+}
+
+func (m *fakeModelImageCampaign) ListCampaigns(selector map[string]string) ([]ImageCampaign, error) {
+	list := []ImageCampaign{}
+
+	m.campaigns.Range(func(key, value interface{}) bool {
+		c := value.(ImageCampaign)
+
+		if state, ok := selector["state"]; ok && c.State != state {
+			return true
+		}
+		if image, ok := selector["image_id"]; ok && c.Image != image {
+			return true
+		}
+
+		list = append(list, c)
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	return list, nil
+}
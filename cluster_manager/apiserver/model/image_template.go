@@ -0,0 +1,114 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ImageTemplateRevision 镜像模板及脚本的历史版本，Scripts 为脚本类型为 key 的 json
+type ImageTemplateRevision struct {
+	ID             string `db:"id"`
+	Image          string `db:"image_id"`
+	Revision       int    `db:"revision"`
+	KeySets        string `db:"key_sets"`
+	ConfigTemplate string `db:"config_template"`
+	Scripts        string `db:"scripts"`
+	Reason         string `db:"reason"`
+	Editor
+}
+
+func (ImageTemplateRevision) Table() string {
+	return "tbl_image_template_revision"
+}
+
+type ModelImageTemplate interface {
+	InsertTemplateRevision(r ImageTemplateRevision) (string, error)
+	GetTemplateRevision(image string, revision int) (ImageTemplateRevision, error)
+	// ListTemplateRevisions returns revisions of image order by revision DESC
+	ListTemplateRevisions(image string) ([]ImageTemplateRevision, error)
+}
+
+type modelImageTemplate struct {
+	*dbBase
+}
+
+func (m *modelImageTemplate) InsertTemplateRevision(r ImageTemplateRevision) (string, error) {
+	if r.ID == "" {
+		r.ID = newUUID("")
+	}
+
+	query := "INSERT INTO " + r.Table() +
+		" (id,image_id,revision,key_sets,config_template,scripts,reason,created_user,created_timestamp,modified_user,modified_timestamp) " +
+		"VALUES (:id,:image_id,:revision,:key_sets,:config_template,:scripts,:reason,:created_user,:created_timestamp,:modified_user,:modified_timestamp)"
+
+	_, err := m.NamedExec(query, r)
+
+	return r.ID, err
+}
+
+func (m *modelImageTemplate) GetTemplateRevision(image string, revision int) (ImageTemplateRevision, error) {
+	r := ImageTemplateRevision{}
+	query := "SELECT * FROM " + r.Table() + " WHERE image_id=? AND revision=?"
+
+	err := m.dbBase.Get(&r, query, image, revision)
+
+	return r, err
+}
+
+func (m *modelImageTemplate) ListTemplateRevisions(image string) ([]ImageTemplateRevision, error) {
+	list := []ImageTemplateRevision{}
+	query := "SELECT * FROM " + ImageTemplateRevision{}.Table() + " WHERE image_id=? ORDER BY revision DESC"
+
+	err := m.Select(&list, query, image)
+
+	return list, err
+}
+
+type fakeModelImageTemplate struct {
+	revisions *sync.Map
+}
+
+func (m *fakeModelImageTemplate) InsertTemplateRevision(r ImageTemplateRevision) (string, error) {
+	if r.ID == "" {
+		r.ID = newUUID("")
+	}
+
+	if _, err := m.GetTemplateRevision(r.Image, r.Revision); err == nil {
+		return "", fmt.Errorf("duplicate revision %d of image %s", r.Revision, r.Image)
+	}
+
+	m.revisions.Store(r.ID, r)
+
+	return r.ID, nil
+}
+
+func (m *fakeModelImageTemplate) GetTemplateRevision(image string, revision int) (ImageTemplateRevision, error) {
+	list, _ := m.ListTemplateRevisions(image)
+
+	for i := range list {
+		if list[i].Revision == revision {
+			return list[i], nil
+		}
+	}
+
+	return ImageTemplateRevision{}, NewNotFound("image template revision", image)
+}
+
+func (m *fakeModelImageTemplate) ListTemplateRevisions(image string) ([]ImageTemplateRevision, error) {
+	list := []ImageTemplateRevision{}
+
+	m.revisions.Range(func(key, value interface{}) bool {
+		if r := value.(ImageTemplateRevision); r.Image == image {
+			list = append(list, r)
+		}
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Revision > list[j].Revision
+	})
+
+	return list, nil
+}
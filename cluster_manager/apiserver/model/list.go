@@ -0,0 +1,506 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+// listColumn maps a field of list api to the column of table.
+type listColumn struct {
+	name string
+	// convert converts the filter value to the column value,nil means string
+	convert func(string) (interface{}, error)
+}
+
+type listColumns map[string]listColumn
+
+// convertBool accepts yes/no as the legacy enabled parameter
+func convertBool(v string) (interface{}, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+
+	return strconv.ParseBool(v)
+}
+
+func convertInt(v string) (interface{}, error) {
+	return strconv.ParseInt(v, 10, 64)
+}
+
+func convertTaskStatus(v string) (interface{}, error) {
+	return parseTaskState(v), nil
+}
+
+func (cols listColumns) column(field string) (listColumn, error) {
+	col, ok := cols[field]
+	if !ok {
+		return col, fmt.Errorf("unsupported list field '%s'", field)
+	}
+
+	return col, nil
+}
+
+func (col listColumn) values(values []string) ([]interface{}, error) {
+	out := make([]interface{}, len(values))
+
+	for i, v := range values {
+		if col.convert == nil {
+			out[i] = v
+			continue
+		}
+
+		cv, err := col.convert(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value '%s' of %s:%s", v, col.name, err)
+		}
+
+		out[i] = cv
+	}
+
+	return out, nil
+}
+
+// where converts the filters to sql conditions.
+func (cols listColumns) where(filters []api.ListFilter) (sq.And, error) {
+	and := sq.And{}
+
+	for _, f := range filters {
+		col, err := cols.column(f.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := col.values(f.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(values) == 0 {
+			return nil, fmt.Errorf("value of filter '%s' is required", f.Field)
+		}
+
+		switch f.Operator {
+		case api.FilterEquals:
+			and = append(and, sq.Eq{col.name: values[0]})
+		case api.FilterNotEquals:
+			and = append(and, sq.NotEq{col.name: values[0]})
+		case api.FilterIn:
+			and = append(and, sq.Eq{col.name: values})
+		case api.FilterNotIn:
+			and = append(and, sq.NotEq{col.name: values})
+		case api.FilterGreater:
+			and = append(and, sq.Gt{col.name: values[0]})
+		case api.FilterLess:
+			and = append(and, sq.Lt{col.name: values[0]})
+		default:
+			return nil, fmt.Errorf("unsupported filter operator '%s'", f.Operator)
+		}
+	}
+
+	return and, nil
+}
+
+type listOrder struct {
+	column string
+	desc   bool
+}
+
+// orderBy returns the order of sorts,the key is appended to make the pages stable.
+func (cols listColumns) orderBy(sorts, def []api.ListSort, key string) ([]listOrder, error) {
+	if len(sorts) == 0 {
+		sorts = def
+	}
+
+	out := make([]listOrder, 0, len(sorts)+1)
+
+	for _, s := range sorts {
+		col, err := cols.column(s.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, listOrder{column: col.name, desc: s.Desc})
+	}
+
+	return append(out, listOrder{column: key}), nil
+}
+
+func orderByClause(orders []listOrder) []string {
+	out := make([]string, len(orders))
+
+	for i, o := range orders {
+		out[i] = o.column
+		if o.desc {
+			out[i] += " DESC"
+		}
+	}
+
+	return out
+}
+
+// cursorString formats the field as the value of cursor.
+func cursorString(field reflect.Value) string {
+	if t, ok := field.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	case reflect.String:
+		return field.String()
+	}
+
+	return fmt.Sprint(field.Interface())
+}
+
+// cursorValue parses the value of cursor as the type of field.
+func cursorValue(t reflect.Type, v string) (interface{}, error) {
+	if t == reflect.TypeOf(time.Time{}) {
+		return time.Parse(time.RFC3339Nano, v)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(v, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(v, 10, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(v)
+	}
+
+	return v, nil
+}
+
+// afterValues converts opts.After to the values of order columns of elem.
+func afterValues(elem reflect.Type, orders []listOrder, after []string) ([]interface{}, error) {
+	if len(after) == 0 {
+		return nil, nil
+	}
+
+	if len(after) != len(orders) {
+		return nil, fmt.Errorf("invalid cursor,expected %d values but got %d", len(orders), len(after))
+	}
+
+	zero := reflect.New(elem).Elem()
+	out := make([]interface{}, len(orders))
+
+	for i, o := range orders {
+		field, ok := dbField(zero, o.column)
+		if !ok {
+			return nil, fmt.Errorf("unsupported list column '%s'", o.column)
+		}
+
+		v, err := cursorValue(field.Type(), after[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value '%s' of %s:%s", after[i], o.column, err)
+		}
+
+		out[i] = v
+	}
+
+	return out, nil
+}
+
+// lastValues returns the values of order columns of the last item of page.
+func lastValues(page reflect.Value, orders []listOrder) []string {
+	last := page.Index(page.Len() - 1)
+	out := make([]string, len(orders))
+
+	for i, o := range orders {
+		field, _ := dbField(last, o.column)
+		out[i] = cursorString(field)
+	}
+
+	return out
+}
+
+// keyset returns the condition of the rows after the values in the orders:
+// (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ...,'<' is used by the desc order.
+func keyset(orders []listOrder, values []interface{}) sq.Or {
+	or := make(sq.Or, 0, len(orders))
+
+	for i, o := range orders {
+		and := make(sq.And, 0, i+1)
+
+		for j := 0; j < i; j++ {
+			and = append(and, sq.Eq{orders[j].column: values[j]})
+		}
+
+		if o.desc {
+			and = append(and, sq.Lt{o.column: values[i]})
+		} else {
+			and = append(and, sq.Gt{o.column: values[i]})
+		}
+
+		or = append(or, and)
+	}
+
+	return or
+}
+
+// selectPage selects a page of table into dest which is a pointer of slice,
+// where are the conditions besides the filters of opts,def is the order if opts.Sort is empty.
+func (db *dbBase) selectPage(dest interface{}, table, key string, def []api.ListSort, where sq.And, cols listColumns, opts api.ListOptions) (api.ListMeta, error) {
+	filters, err := cols.where(opts.Filters)
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	where = append(where, filters...)
+
+	orders, err := cols.orderBy(opts.Sort, def, key)
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	query, args, err := sq.Select("COUNT(*)").From(table).Where(where).ToSql()
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	var total uint64
+
+	err = db.Get(&total, query, args...)
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	dv := reflect.ValueOf(dest).Elem()
+
+	after, err := afterValues(dv.Type().Elem(), orders, opts.After)
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	if after != nil {
+		where = append(where, keyset(orders, after))
+	}
+
+	page := sq.Select("*").From(table).Where(where).OrderBy(orderByClause(orders)...)
+	if opts.Limit > 0 {
+		// 多查一条判断是否有下一页
+		page = page.Limit(opts.Limit + 1)
+	}
+
+	query, args, err = page.ToSql()
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	err = db.Select(dest, query, args...)
+	if err != nil && !IsNotExist(err) {
+		return api.ListMeta{}, err
+	}
+
+	var next []string
+
+	if opts.Limit > 0 && uint64(dv.Len()) > opts.Limit {
+		dv.Set(dv.Slice(0, int(opts.Limit)))
+		next = lastValues(dv, orders)
+	}
+
+	return opts.NewListMeta(total, next), nil
+}
+
+// dbField returns the field of struct v tagged by db:"column",including the embedded structs.
+func dbField(v reflect.Value, column string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if fv, ok := dbField(v.Field(i), column); ok {
+				return fv, true
+			}
+			continue
+		}
+
+		if tag := strings.Split(f.Tag.Get("db"), ","); tag[0] == column {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// compareField compares the field with value,returns -1,0 or 1.
+func compareField(field reflect.Value, value interface{}) int {
+	if t, ok := field.Interface().(time.Time); ok {
+		if vt, ok := value.(time.Time); ok {
+			switch {
+			case t.Before(vt):
+				return -1
+			case t.After(vt):
+				return 1
+			}
+
+			return 0
+		}
+	}
+
+	a, b := fmt.Sprint(field.Interface()), fmt.Sprint(value)
+
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+
+		return 0
+	}
+
+	return strings.Compare(a, b)
+}
+
+func (cols listColumns) match(v reflect.Value, filters []api.ListFilter) (bool, error) {
+	for _, f := range filters {
+		col, err := cols.column(f.Field)
+		if err != nil {
+			return false, err
+		}
+
+		values, err := col.values(f.Values)
+		if err != nil {
+			return false, err
+		}
+
+		field, ok := dbField(v, col.name)
+		if !ok || len(values) == 0 {
+			return false, fmt.Errorf("unsupported list field '%s'", f.Field)
+		}
+
+		in := false
+		for _, value := range values {
+			if compareField(field, value) == 0 {
+				in = true
+				break
+			}
+		}
+
+		var matched bool
+
+		switch f.Operator {
+		case api.FilterEquals, api.FilterIn:
+			matched = in
+		case api.FilterNotEquals, api.FilterNotIn:
+			matched = !in
+		case api.FilterGreater:
+			matched = compareField(field, values[0]) > 0
+		case api.FilterLess:
+			matched = compareField(field, values[0]) < 0
+		default:
+			return false, fmt.Errorf("unsupported filter operator '%s'", f.Operator)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// fakeSelectPage selects a page of list into dest in memory,it's used by the fake models.
+func fakeSelectPage(dest, list interface{}, key string, def []api.ListSort, cols listColumns, opts api.ListOptions) (api.ListMeta, error) {
+	lv := reflect.ValueOf(list)
+	out := reflect.MakeSlice(lv.Type(), 0, lv.Len())
+
+	for i := 0; i < lv.Len(); i++ {
+		ok, err := cols.match(lv.Index(i), opts.Filters)
+		if err != nil {
+			return api.ListMeta{}, err
+		}
+
+		if ok {
+			out = reflect.Append(out, lv.Index(i))
+		}
+	}
+
+	orders, err := cols.orderBy(opts.Sort, def, key)
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	// compare returns the order of item with the values of order columns
+	compare := func(item reflect.Value, values func(i int) (interface{}, bool)) int {
+		for i, o := range orders {
+			a, _ := dbField(item, o.column)
+			b, ok := values(i)
+			if !a.IsValid() || !ok {
+				continue
+			}
+
+			c := compareField(a, b)
+			if o.desc {
+				c = -c
+			}
+
+			if c != 0 {
+				return c
+			}
+		}
+
+		return 0
+	}
+
+	sort.SliceStable(out.Interface(), func(i, j int) bool {
+		return compare(out.Index(i), func(k int) (interface{}, bool) {
+			b, ok := dbField(out.Index(j), orders[k].column)
+			if !ok {
+				return nil, false
+			}
+
+			return b.Interface(), true
+		}) < 0
+	})
+
+	total := uint64(out.Len())
+
+	after, err := afterValues(lv.Type().Elem(), orders, opts.After)
+	if err != nil {
+		return api.ListMeta{}, err
+	}
+
+	start := 0
+	if after != nil {
+		for start < out.Len() && compare(out.Index(start), func(k int) (interface{}, bool) { return after[k], true }) <= 0 {
+			start++
+		}
+	}
+
+	end := out.Len()
+	if opts.Limit > 0 && uint64(end-start) > opts.Limit {
+		end = start + int(opts.Limit)
+	}
+
+	page := out.Slice(start, end)
+	reflect.ValueOf(dest).Elem().Set(page)
+
+	var next []string
+	if end < out.Len() {
+		next = lastValues(page, orders)
+	}
+
+	return opts.NewListMeta(total, next), nil
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+func TestListColumns(t *testing.T) {
+	cases := []struct {
+		fields []string
+		cols   listColumns
+	}{
+		{api.TaskListFields, taskListColumns},
+		{api.BackupFileListFields, backupFileListColumns},
+		{api.HostListFields, hostListColumns},
+		{api.AppListFields, appListColumns},
+		{api.SiteListFields, siteListColumns},
+		{api.ClusterListFields, clusterListColumns},
+		{api.NetworkListFields, networkListColumns},
+	}
+
+	for i, c := range cases {
+		if len(c.fields) != len(c.cols) {
+			t.Errorf("%d:expected %d columns but got %d", i, len(c.fields), len(c.cols))
+		}
+
+		for _, f := range c.fields {
+			if _, err := c.cols.column(f); err != nil {
+				t.Errorf("%d:%s", i, err)
+			}
+		}
+	}
+}
+
+func TestFakeSelectPage(t *testing.T) {
+	now := time.Now()
+	list := make([]Task, 0, 5)
+
+	for i := 0; i < 5; i++ {
+		status := TaskSuccess
+		if i%2 == 0 {
+			status = TaskRunning
+		}
+
+		list = append(list, Task{
+			Auto:      i + 1,
+			ID:        newUUID(""),
+			Action:    ActionAppAdd,
+			Status:    status,
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	values := url.Values{
+		"limit":  []string{"2"},
+		"filter": []string{"status=running"},
+	}
+
+	opts, err := api.ParseListOptions(values, api.TaskListFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		page []Task
+		ids  []int
+	)
+
+	for {
+		meta, err := fakeSelectPage(&page, list, "id", taskListDefaultSort, taskListColumns, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 第一页之后插入了一条
+		total := uint64(3)
+		if len(ids) > 0 {
+			total = 4
+		}
+
+		if meta.Total != total {
+			t.Errorf("expected total %d but got %d", total, meta.Total)
+		}
+
+		for _, tk := range page {
+			ids = append(ids, tk.Auto)
+		}
+
+		if meta.NextCursor == "" {
+			break
+		}
+
+		opts.After, err = opts.DecodeCursor(meta.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 翻页期间插入的更新的记录不影响后续的页
+		if len(ids) == 2 {
+			list = append(list, Task{Auto: 7, ID: newUUID(""), Status: TaskRunning, CreatedAt: now.Add(time.Minute)})
+		}
+	}
+
+	if len(ids) != 3 || ids[0] != 5 || ids[1] != 3 || ids[2] != 1 {
+		t.Errorf("expected running tasks [5 3 1] but got %v", ids)
+	}
+
+	opts, err = api.ParseListOptions(url.Values{"sort": []string{"created_at"}}, api.TaskListFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fakeSelectPage(&page, list[:5], "id", taskListDefaultSort, taskListColumns, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page) != 5 || page[0].Auto != 1 || page[4].Auto != 5 {
+		t.Errorf("expected tasks sorted by created_at,%v", page)
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	orders := []listOrder{{column: "created_at", desc: true}, {column: "id"}}
+
+	query, args, err := keyset(orders, []interface{}{"t1", "id1"}).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if query != "((created_at < ?) OR (created_at = ? AND id > ?))" || len(args) != 3 {
+		t.Errorf("unexpected keyset %s %v", query, args)
+	}
+
+	opts, err := api.ParseListOptions(url.Values{"sort": []string{"-created_at"}}, api.TaskListFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cursor := opts.EncodeCursor([]string{"2006-01-02T15:04:05Z", "id1"})
+
+	if _, err := api.ParseListOptions(url.Values{"cursor": []string{cursor}}, api.TaskListFields); err == nil {
+		t.Error("expected error of the cursor with another sort")
+	}
+
+	opts, err = api.ParseListOptions(url.Values{"sort": []string{"-created_at"}, "cursor": []string{cursor}}, api.TaskListFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := afterValues(reflect.TypeOf(Task{}), []listOrder{{column: "created_at", desc: true}, {column: "id"}}, opts.After)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := after[0].(time.Time); !ok || after[1] != "id1" {
+		t.Errorf("unexpected cursor values %v", after)
+	}
+
+	if _, err := afterValues(reflect.TypeOf(Task{}), orders[:1], opts.After); err == nil {
+		t.Error("expected error of the cursor values mismatch the orders")
+	}
+}
+
+func TestParseListOptionsInvalid(t *testing.T) {
+	cases := []url.Values{
+		{"limit": []string{"0"}},
+		{"limit": []string{"1001"}},
+		{"cursor": []string{"xxx"}},
+		{"sort": []string{"-error"}},
+		{"filter": []string{"error=x"}},
+		{"filter": []string{"status=("}},
+	}
+
+	for i, values := range cases {
+		if _, err := api.ParseListOptions(values, api.TaskListFields); err == nil {
+			t.Errorf("%d:expected error of %v", i, values)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package model
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SchemaMigration 服务数据库的迁移版本及执行记录
+type SchemaMigration struct {
+	App         string    `db:"app_id"`
+	Schema      string    `db:"schema_name"`
+	Version     int64     `db:"version"`
+	Description string    `db:"description"`
+	SQL         string    `db:"sql_text"`
+	Online      bool      `db:"online"`
+	Checksum    string    `db:"checksum"`
+	State       string    `db:"state"`
+	Error       string    `db:"error"`
+	ValidatedAt time.Time `db:"validated_timestamp"`
+	AppliedAt   time.Time `db:"applied_timestamp"`
+	// 执行耗时，秒
+	Duration  int64     `db:"duration"`
+	CreatedAt time.Time `db:"created_timestamp"`
+}
+
+func (SchemaMigration) Table() string {
+	return "tbl_schema_migration"
+}
+
+func (m SchemaMigration) key() string {
+	return m.App + "/" + m.Schema + "/" + strconv.FormatInt(m.Version, 10)
+}
+
+// SchemaMigrationLock 正在执行迁移的数据库，同一个数据库同时只有一个迁移任务
+type SchemaMigrationLock struct {
+	App       string    `db:"app_id"`
+	Schema    string    `db:"schema_name"`
+	CreatedAt time.Time `db:"created_timestamp"`
+}
+
+func (SchemaMigrationLock) Table() string {
+	return "tbl_schema_migration_lock"
+}
+
+type ModelSchemaMigration interface {
+	// SaveSchemaMigration inserts or replaces the migration of App,Schema,Version
+	SaveSchemaMigration(m SchemaMigration) error
+	DeleteSchemaMigration(app, schema string, version int64) error
+	// ListSchemaMigrations returns the migrations of schema order by version
+	ListSchemaMigrations(app, schema string) ([]SchemaMigration, error)
+	// ListSchemaMigrationsByState returns the migrations of all schemas in state
+	ListSchemaMigrationsByState(state string) ([]SchemaMigration, error)
+
+	// LockSchemaMigrations returns false if the schema has been locked
+	LockSchemaMigrations(lock SchemaMigrationLock) (bool, error)
+	UnlockSchemaMigrations(app, schema string) error
+	// ListSchemaMigrationLocks returns all locks
+	ListSchemaMigrationLocks() ([]SchemaMigrationLock, error)
+}
+
+type modelSchemaMigration struct {
+	*dbBase
+}
+
+func (m *modelSchemaMigration) SaveSchemaMigration(sm SchemaMigration) error {
+	query := "INSERT INTO " + sm.Table() +
+		" (app_id,schema_name,version,description,sql_text,online,checksum,state,error,validated_timestamp,applied_timestamp,duration,created_timestamp) " +
+		"VALUES (:app_id,:schema_name,:version,:description,:sql_text,:online,:checksum,:state,:error,:validated_timestamp,:applied_timestamp,:duration,:created_timestamp) " +
+		"ON DUPLICATE KEY UPDATE description=VALUES(description),sql_text=VALUES(sql_text),online=VALUES(online),checksum=VALUES(checksum)," +
+		"state=VALUES(state),error=VALUES(error),validated_timestamp=VALUES(validated_timestamp)," +
+		"applied_timestamp=VALUES(applied_timestamp),duration=VALUES(duration)"
+
+	_, err := m.NamedExec(query, sm)
+
+	return err
+}
+
+func (m *modelSchemaMigration) DeleteSchemaMigration(app, schema string, version int64) error {
+	query := "DELETE FROM " + SchemaMigration{}.Table() + " WHERE app_id=? AND schema_name=? AND version=?"
+
+	_, err := m.Exec(query, app, schema, version)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelSchemaMigration) ListSchemaMigrations(app, schema string) ([]SchemaMigration, error) {
+	list := []SchemaMigration{}
+	query := "SELECT * FROM " + SchemaMigration{}.Table() + " WHERE app_id=? AND schema_name=? ORDER BY version"
+
+	err := m.Select(&list, query, app, schema)
+
+	return list, err
+}
+
+func (m *modelSchemaMigration) ListSchemaMigrationsByState(state string) ([]SchemaMigration, error) {
+	list := []SchemaMigration{}
+	query := "SELECT * FROM " + SchemaMigration{}.Table() + " WHERE state=? ORDER BY app_id,schema_name,version"
+
+	err := m.Select(&list, query, state)
+
+	return list, err
+}
+
+func (m *modelSchemaMigration) LockSchemaMigrations(lock SchemaMigrationLock) (bool, error) {
+	query := "INSERT IGNORE INTO " + lock.Table() + " (app_id,schema_name,created_timestamp) " +
+		"VALUES (:app_id,:schema_name,:created_timestamp)"
+
+	result, err := m.NamedExec(query, lock)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return n > 0, err
+}
+
+func (m *modelSchemaMigration) UnlockSchemaMigrations(app, schema string) error {
+	query := "DELETE FROM " + SchemaMigrationLock{}.Table() + " WHERE app_id=? AND schema_name=?"
+
+	_, err := m.Exec(query, app, schema)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelSchemaMigration) ListSchemaMigrationLocks() ([]SchemaMigrationLock, error) {
+	list := []SchemaMigrationLock{}
+	query := "SELECT * FROM " + SchemaMigrationLock{}.Table() + " ORDER BY app_id,schema_name"
+
+	err := m.Select(&list, query)
+
+	return list, err
+}
+
+type fakeModelSchemaMigration struct {
+	migrations *sync.Map
+	locks      *sync.Map
+}
+
+func (m *fakeModelSchemaMigration) SaveSchemaMigration(sm SchemaMigration) error {
+	if v, ok := m.migrations.Load(sm.key()); ok {
+		sm.CreatedAt = v.(SchemaMigration).CreatedAt
+	}
+
+	m.migrations.Store(sm.key(), sm)
+
+	return nil
+}
+
+func (m *fakeModelSchemaMigration) DeleteSchemaMigration(app, schema string, version int64) error {
+	m.migrations.Delete(SchemaMigration{App: app, Schema: schema, Version: version}.key())
+
+	return nil
+}
+
+func (m *fakeModelSchemaMigration) ListSchemaMigrations(app, schema string) ([]SchemaMigration, error) {
+	list := []SchemaMigration{}
+
+	m.migrations.Range(func(key, value interface{}) bool {
+		sm := value.(SchemaMigration)
+
+		if sm.App == app && sm.Schema == schema {
+			list = append(list, sm)
+		}
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list, nil
+}
+
+func (m *fakeModelSchemaMigration) ListSchemaMigrationsByState(state string) ([]SchemaMigration, error) {
+	list := []SchemaMigration{}
+
+	m.migrations.Range(func(key, value interface{}) bool {
+		if sm := value.(SchemaMigration); sm.State == state {
+			list = append(list, sm)
+		}
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].key() < list[j].key()
+	})
+
+	return list, nil
+}
+
+func (m *fakeModelSchemaMigration) LockSchemaMigrations(lock SchemaMigrationLock) (bool, error) {
+	_, loaded := m.locks.LoadOrStore(lock.App+"/"+lock.Schema, lock)
+
+	return !loaded, nil
+}
+
+func (m *fakeModelSchemaMigration) UnlockSchemaMigrations(app, schema string) error {
+	m.locks.Delete(app + "/" + schema)
+
+	return nil
+}
+
+func (m *fakeModelSchemaMigration) ListSchemaMigrationLocks() ([]SchemaMigrationLock, error) {
+	list := []SchemaMigrationLock{}
+
+	m.locks.Range(func(key, value interface{}) bool {
+		list = append(list, value.(SchemaMigrationLock))
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].App+"/"+list[i].Schema < list[j].App+"/"+list[j].Schema
+	})
+
+	return list, nil
+}
@@ -12,6 +12,7 @@ type ModelCluster interface {
 
 	Get(id string) (Cluster, error)
 	List(selector map[string]string) ([]Cluster, error)
+	ListPage(opts api.ListOptions) ([]Cluster, api.ListMeta, error)
 }
 
 type ModelSite interface {
@@ -21,6 +22,7 @@ type ModelSite interface {
 
 	Get(id string) (Site, error)
 	List(selector map[string]string) ([]Site, error)
+	ListPage(opts api.ListOptions) ([]Site, api.ListMeta, error)
 }
 
 type ModelNetwork interface {
@@ -29,6 +31,7 @@ type ModelNetwork interface {
 	Delete(id string) error
 	Get(id string) (Network, error)
 	List(selector map[string]string) ([]Network, error)
+	ListPage(opts api.ListOptions) ([]Network, api.ListMeta, error)
 }
 
 type ModelHost interface {
@@ -40,6 +43,7 @@ type ModelHost interface {
 	Get(id string) (Host, error)
 	GetHostBrief(id string) (HostBrief, error)
 	List(selector map[string]string) ([]Host, error)
+	ListPage(opts api.ListOptions) ([]Host, api.ListMeta, error)
 	ListUnits() ([]Unit, error)
 }
 
@@ -47,6 +51,9 @@ type ModelImage interface {
 	Insert(Image) (string, string, error)
 	InsertImageTask(Image, string) (string, error)
 	Update(Image) error
+	UpdateDigest(Image) error
+	UpdateLifecycle(Image) error
+	UpdateTemplate(Image) error
 	UpdateImageTask(im *Image, tk Task) error
 	Delete(id string) error
 	Get(id string) (Image, error)
@@ -62,6 +69,7 @@ type ModelTask interface {
 	Get(id string) (Task, error)
 	LatestByRelateID(id string) (Task, error)
 	List(selector map[string]string) ([]Task, error)
+	ListPage(opts api.ListOptions) ([]Task, api.ListMeta, error)
 }
 
 type ModelRemoteStorage interface {
@@ -96,6 +104,7 @@ type ModelApp interface {
 	Delete(id string) error
 	Get(id string) (Application, error)
 	List(selector map[string]string) ([]Application, error)
+	ListPage(opts api.ListOptions) ([]Application, api.ListMeta, error)
 	ListWithPagination(selector map[string]string, pagination api.PaginationReq) ([]Application, api.PaginationResp, error)
 }
 
@@ -115,6 +124,7 @@ type ModelBackupFile interface {
 	DeleteFile(id string) error
 	GetFile(id string) (BackupFile, error)
 	ListFiles(selector map[string]string) ([]BackupFile, error)
+	ListFilesPage(opts api.ListOptions) ([]BackupFile, api.ListMeta, error)
 }
 
 type ModelBackupEndpoint interface {
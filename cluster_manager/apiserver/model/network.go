@@ -6,6 +6,8 @@ import (
 	"sync"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 )
 
 type Network struct {
@@ -264,6 +266,30 @@ func (m *modelNetwork) List(selector map[string]string) ([]Network, error) {
 	return nets, nil
 }
 
+var networkListColumns = listColumns{
+	"id":         {name: "id"},
+	"name":       {name: "name"},
+	"cluster_id": {name: "cluster_id"},
+	"enabled":    {name: "enabled", convert: convertBool},
+	"created_at": {name: "created_timestamp"},
+}
+
+func (m *modelNetwork) ListPage(opts api.ListOptions) ([]Network, api.ListMeta, error) {
+	nets := []Network{}
+
+	meta, err := m.selectPage(&nets, Network{}.Table(), "id", nil, nil, networkListColumns, opts)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	for i := range nets {
+		nets[i].Cluster, _ = m.getClusterBrief(nets[i].ClusterID)
+		nets[i].Site, _ = m.getSiteBrief(nets[i].Cluster.SiteID)
+	}
+
+	return nets, meta, nil
+}
+
 type fakeModelNetwork struct {
 	networks *sync.Map
 	clusters *sync.Map
@@ -391,3 +417,15 @@ func (m *fakeModelNetwork) List(selector map[string]string) ([]Network, error) {
 
 	return networks, nil
 }
+
+func (m *fakeModelNetwork) ListPage(opts api.ListOptions) ([]Network, api.ListMeta, error) {
+	list, err := m.List(nil)
+	if err != nil {
+		return nil, api.ListMeta{}, err
+	}
+
+	nets := []Network{}
+	meta, err := fakeSelectPage(&nets, list, "id", nil, networkListColumns, opts)
+
+	return nets, meta, err
+}
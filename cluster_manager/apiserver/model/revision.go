@@ -0,0 +1,164 @@
+package model
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// ResourceRevision 资源版本，用于 ETag/If-Match，没有记录时版本为0
+type ResourceRevision struct {
+	Kind       string    `db:"kind"`
+	ResourceID string    `db:"resource_id"`
+	Revision   int64     `db:"revision"`
+	ModifiedAt time.Time `db:"modified_timestamp"`
+}
+
+func (ResourceRevision) Table() string {
+	return "tbl_resource_revision"
+}
+
+type ModelRevision interface {
+	GetRevision(kind, id string) (int64, error)
+	// BumpRevision increases the revision and returns the new one,
+	// if expect>=0 and the current revision is not expect,returns the current revision and false.
+	BumpRevision(kind, id string, expect int64) (int64, bool, error)
+	// RollbackRevision decreases the revision if the current revision is rev
+	RollbackRevision(kind, id string, rev int64) error
+	DeleteRevision(kind, id string) error
+}
+
+type modelRevision struct {
+	*dbBase
+}
+
+func (m *modelRevision) GetRevision(kind, id string) (int64, error) {
+	var rev int64
+	query := "SELECT revision FROM " + ResourceRevision{}.Table() + " WHERE kind=? AND resource_id=?"
+
+	err := m.dbBase.Get(&rev, query, kind, id)
+	if IsNotExist(err) {
+		return 0, nil
+	}
+
+	return rev, err
+}
+
+func (m *modelRevision) BumpRevision(kind, id string, expect int64) (int64, bool, error) {
+	var (
+		rev   int64
+		ok    bool
+		table = ResourceRevision{}.Table()
+		now   = time.Now()
+	)
+
+	err := m.txFrame(func(tx Tx) error {
+		var (
+			result sql.Result
+			err    error
+		)
+
+		switch {
+		case expect < 0:
+			query := "INSERT INTO " + table + " (kind,resource_id,revision,modified_timestamp) VALUES (?,?,1,?) " +
+				"ON DUPLICATE KEY UPDATE revision=revision+1,modified_timestamp=VALUES(modified_timestamp)"
+
+			result, err = tx.Exec(query, kind, id, now)
+
+		case expect == 0:
+			query := "INSERT IGNORE INTO " + table + " (kind,resource_id,revision,modified_timestamp) VALUES (?,?,1,?)"
+
+			result, err = tx.Exec(query, kind, id, now)
+
+		default:
+			query := "UPDATE " + table + " SET revision=revision+1,modified_timestamp=? WHERE kind=? AND resource_id=? AND revision=?"
+
+			result, err = tx.Exec(query, now, kind, id, expect)
+		}
+		if err != nil {
+			return err
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		ok = n > 0
+
+		err = tx.Get(&rev, "SELECT revision FROM "+table+" WHERE kind=? AND resource_id=?", kind, id)
+		if IsNotExist(err) {
+			rev, err = 0, nil
+		}
+
+		return err
+	})
+
+	return rev, ok, err
+}
+
+func (m *modelRevision) RollbackRevision(kind, id string, rev int64) error {
+	query := "UPDATE " + ResourceRevision{}.Table() + " SET revision=revision-1,modified_timestamp=? WHERE kind=? AND resource_id=? AND revision=?"
+
+	_, err := m.Exec(query, time.Now(), kind, id, rev)
+
+	return err
+}
+
+func (m *modelRevision) DeleteRevision(kind, id string) error {
+	query := "DELETE FROM " + ResourceRevision{}.Table() + " WHERE kind=? AND resource_id=?"
+
+	_, err := m.Exec(query, kind, id)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+type fakeModelRevision struct {
+	lock      sync.Mutex
+	revisions map[string]int64
+}
+
+func (m *fakeModelRevision) GetRevision(kind, id string) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.revisions[kind+"/"+id], nil
+}
+
+func (m *fakeModelRevision) BumpRevision(kind, id string, expect int64) (int64, bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := kind + "/" + id
+	rev := m.revisions[key]
+
+	if expect >= 0 && rev != expect {
+		return rev, false, nil
+	}
+
+	m.revisions[key] = rev + 1
+
+	return rev + 1, true, nil
+}
+
+func (m *fakeModelRevision) RollbackRevision(kind, id string, rev int64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if key := kind + "/" + id; rev > 0 && m.revisions[key] == rev {
+		m.revisions[key] = rev - 1
+	}
+
+	return nil
+}
+
+func (m *fakeModelRevision) DeleteRevision(kind, id string) error {
+	m.lock.Lock()
+	delete(m.revisions, kind+"/"+id)
+	m.lock.Unlock()
+
+	return nil
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"errors"
 	"sync"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 )
 
 type Site struct {
@@ -135,6 +137,22 @@ func (m *modelSite) List(selector map[string]string) ([]Site, error) {
 	return out, err
 }
 
+var siteListColumns = listColumns{
+	"id":         {name: "id"},
+	"name":       {name: "name"},
+	"type":       {name: "type"},
+	"region":     {name: "region"},
+	"created_at": {name: "created_timestamp"},
+}
+
+func (m *modelSite) ListPage(opts api.ListOptions) ([]Site, api.ListMeta, error) {
+	sites := []Site{}
+
+	meta, err := m.selectPage(&sites, Site{}.Table(), "id", nil, nil, siteListColumns, opts)
+
+	return sites, meta, err
+}
+
 type fakeModelSite struct {
 	sites *sync.Map
 }
@@ -208,3 +226,15 @@ func (m *fakeModelSite) List(selector map[string]string) ([]Site, error) {
 
 	return sites, nil
 }
+
+func (m *fakeModelSite) ListPage(opts api.ListOptions) ([]Site, api.ListMeta, error) {
+	list, err := m.List(nil)
+	if err != nil {
+		return nil, api.ListMeta{}, err
+	}
+
+	sites := []Site{}
+	meta, err := fakeSelectPage(&sites, list, "id", nil, siteListColumns, opts)
+
+	return sites, meta, err
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"errors"
+	"sync"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Subscription 租户订阅及其配额，配额为0表示不限制，
+// CPU单位为millicore，内存及存储单位为MiB，QuotaStorage为性能等级为key的Json
+type Subscription struct {
+	ID                 string `db:"id"`
+	Name               string `db:"name"`
+	Desc               string `db:"description"`
+	Enabled            bool   `db:"enabled"`
+	QuotaCPU           int64  `db:"quota_cpu"`
+	QuotaMemory        int64  `db:"quota_memory"`
+	QuotaStorage       string `db:"quota_storage"`
+	QuotaApps          int    `db:"quota_apps"`
+	QuotaBackupStorage int64  `db:"quota_backup_storage"`
+	Editor
+}
+
+func (Subscription) Table() string {
+	return "tbl_subscription"
+}
+
+type ModelSubscription interface {
+	InsertSubscription(s Subscription) (string, error)
+	UpdateSubscription(s Subscription) error
+	DeleteSubscription(id string) error
+	GetSubscription(id string) (Subscription, error)
+	ListSubscriptions(selector map[string]string) ([]Subscription, error)
+}
+
+type modelSubscription struct {
+	*dbBase
+}
+
+func (m *modelSubscription) InsertSubscription(s Subscription) (string, error) {
+	if s.ID == "" {
+		s.ID = newUUID("")
+	}
+
+	query := "INSERT INTO " + s.Table() +
+		" (id,name,description,enabled,quota_cpu,quota_memory,quota_storage,quota_apps,quota_backup_storage,created_user,created_timestamp,modified_user,modified_timestamp) " +
+		"VALUES (:id,:name,:description,:enabled,:quota_cpu,:quota_memory,:quota_storage,:quota_apps,:quota_backup_storage,:created_user,:created_timestamp,:modified_user,:modified_timestamp)"
+
+	_, err := m.NamedExec(query, s)
+
+	return s.ID, err
+}
+
+func (m *modelSubscription) UpdateSubscription(s Subscription) error {
+	query := "UPDATE " + s.Table() + " SET name=:name,description=:description,enabled=:enabled,quota_cpu=:quota_cpu,quota_memory=:quota_memory," +
+		"quota_storage=:quota_storage,quota_apps=:quota_apps,quota_backup_storage=:quota_backup_storage," +
+		"modified_user=:modified_user,modified_timestamp=:modified_timestamp WHERE id=:id"
+
+	_, err := m.NamedExec(query, s)
+
+	return err
+}
+
+func (m *modelSubscription) DeleteSubscription(id string) error {
+	query := "DELETE FROM " + Subscription{}.Table() + " WHERE id=?"
+
+	_, err := m.Exec(query, id)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *modelSubscription) GetSubscription(id string) (Subscription, error) {
+	s := Subscription{}
+	query := "SELECT * FROM " + s.Table() + " WHERE id=?"
+
+	err := m.dbBase.Get(&s, query, id)
+
+	return s, err
+}
+
+func (m *modelSubscription) ListSubscriptions(selector map[string]string) ([]Subscription, error) {
+	query := sq.Select("*").From(Subscription{}.Table())
+
+	if id, ok := selector["id"]; ok {
+		query = query.Where(sq.Eq{"id": id})
+	}
+	if name, ok := selector["name"]; ok {
+		query = query.Where(sq.Eq{"name": name})
+	}
+	if enabled, ok := selector[labelEnabled]; ok {
+		query = query.Where(sq.Eq{"enabled": enabled})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []Subscription{}
+	err = m.Select(&list, sql, args...)
+
+	return list, err
+}
+
+type fakeModelSubscription struct {
+	subscriptions *sync.Map
+}
+
+func (m *fakeModelSubscription) InsertSubscription(s Subscription) (string, error) {
+	if s.ID == "" {
+		s.ID = newUUID("")
+	}
+
+	if _, ok := m.subscriptions.LoadOrStore(s.ID, s); ok {
+		return "", errors.New("subscription " + s.ID + " already exists")
+	}
+
+	return s.ID, nil
+}
+
+func (m *fakeModelSubscription) UpdateSubscription(s Subscription) error {
+	if _, ok := m.subscriptions.Load(s.ID); !ok {
+		return NewNotFound("subscription", s.ID)
+	}
+
+	m.subscriptions.Store(s.ID, s)
+
+	return nil
+}
+
+func (m *fakeModelSubscription) DeleteSubscription(id string) error {
+	m.subscriptions.Delete(id)
+
+	return nil
+}
+
+func (m *fakeModelSubscription) GetSubscription(id string) (Subscription, error) {
+	v, ok := m.subscriptions.Load(id)
+	if !ok {
+		return Subscription{}, NewNotFound("subscription", id)
+	}
+
+	return v.(Subscription), nil
+}
+
+func (m *fakeModelSubscription) ListSubscriptions(selector map[string]string) ([]Subscription, error) {
+	list := []Subscription{}
+
+	m.subscriptions.Range(func(key, value interface{}) bool {
+		s := value.(Subscription)
+
+		if id, ok := selector["id"]; ok && s.ID != id {
+			return true
+		}
+		if name, ok := selector["name"]; ok && s.Name != name {
+			return true
+		}
+
+		list = append(list, s)
+
+		return true
+	})
+
+	return list, nil
+}
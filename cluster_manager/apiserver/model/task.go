@@ -3,8 +3,15 @@ package model
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 )
 
 type TaskStatus int
@@ -33,10 +40,13 @@ const (
 	ActionAppUnitStateEdit = "app-unit-state-edit"
 	ActionAppUnitRebuild   = "app-unit-rebuild"
 	ActionAppUnitRestore   = "app-unit-restore"
+	ActionAppTemplateEdit  = "app-template-edit"
 
 	ActionHostAdd    = "host-add"
 	ActionHostEdit   = "host-edit"
 	ActionHostDelete = "host-delete"
+	// 维护模式迁出单元
+	ActionHostMaintenance = "host-maintenance"
 
 	BackupEndpointAdd = "backup-endpoint-add"
 
@@ -210,6 +220,33 @@ func (db *dbBase) tasksByTable(table string) ([]Task, error) {
 	return tasks, err
 }
 
+// latestTasks returns the latest task of each id of table,key: relate id
+func (db *dbBase) latestTasks(table string, ids []string) (map[string]Task, error) {
+	out := make(map[string]Task, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM "+Task{}.Table()+" WHERE ai IN "+
+		"(SELECT MAX(ai) FROM "+Task{}.Table()+" WHERE relate_table=? AND relate_id IN (?) GROUP BY relate_id)", table, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []Task{}
+
+	err = db.Select(&tasks, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tk := range tasks {
+		out[tk.RelateID] = tk
+	}
+
+	return out, nil
+}
+
 func (db *dbBase) latestByRelateID(id string) (Task, error) {
 	tk := Task{}
 
@@ -251,15 +288,74 @@ func (m *modelTask) List(selector map[string]string) ([]Task, error) {
 		return tasks, err
 	}
 
-	tasks := []Task{}
-	query := "SELECT * FROM " + Task{}.Table() +
-		" ORDER BY ai DESC"
+	query := sq.Select("*").From(Task{}.Table()).OrderBy("ai DESC")
 
-	err := m.Select(&tasks, query)
+	// 自增列大于after的任务，用于增量获取新任务
+	if after, ok := selector["after"]; ok {
+		n, err := strconv.Atoi(after)
+		if err != nil {
+			return nil, err
+		}
+
+		query = query.Where(sq.Gt{"ai": n})
+	}
+	if status, ok := selector["status"]; ok {
+		query = query.Where(sq.Eq{"status": parseTaskState(status)})
+	}
+	if limit, ok := selector["limit"]; ok {
+		n, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		query = query.Limit(n)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []Task{}
+	err = m.Select(&tasks, sql, args...)
 
 	return tasks, err
 }
 
+var taskListColumns = listColumns{
+	"id":           {name: "id"},
+	"action":       {name: "action"},
+	"relate_id":    {name: "relate_id"},
+	"status":       {name: "status", convert: convertTaskStatus},
+	"created_user": {name: "created_user"},
+	"created_at":   {name: "created_at"},
+	"finished_at":  {name: "finished_at"},
+}
+
+// 默认按创建时间倒序
+var taskListDefaultSort = []api.ListSort{{Field: "created_at", Desc: true}}
+
+func (m *modelTask) ListPage(opts api.ListOptions) ([]Task, api.ListMeta, error) {
+	tasks := []Task{}
+
+	meta, err := m.selectPage(&tasks, Task{}.Table(), "id", taskListDefaultSort, nil, taskListColumns, opts)
+
+	return tasks, meta, err
+}
+
+func parseTaskState(state string) TaskStatus {
+	switch state {
+	case taskCanceled:
+		return TaskCanceled
+	case taskFailed:
+		return TaskFailed
+	case taskSuccess:
+		return TaskSuccess
+	}
+
+	return TaskRunning
+}
+
 func ReverseTasksByAutoNum(tasks []Task) {
 
 	sort.Slice(tasks,
@@ -268,6 +364,8 @@ func ReverseTasksByAutoNum(tasks []Task) {
 		})
 }
 
+var fakeTaskAuto int64
+
 type fakeModelTask struct {
 	tasks *sync.Map
 }
@@ -275,6 +373,7 @@ type fakeModelTask struct {
 func (m *fakeModelTask) Insert(tk Task) (string, error) {
 
 	tk.ID = taskUUID(tk.RelateID)
+	tk.Auto = int(atomic.AddInt64(&fakeTaskAuto, 1))
 
 	m.tasks.Store(tk.ID, tk)
 
@@ -349,5 +448,56 @@ func (m *fakeModelTask) List(selector map[string]string) ([]Task, error) {
 		return list, nil
 	}
 
-	return nil, nil
+	after, status, limit := -1, "", 0
+
+	if v, ok := selector["after"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		after = n
+	}
+	if v, ok := selector["status"]; ok {
+		status = v
+	}
+	if v, ok := selector["limit"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		limit = n
+	}
+
+	list := []Task{}
+
+	m.tasks.Range(func(key, value interface{}) bool {
+		tk, ok := value.(Task)
+		if !ok || tk.Auto <= after || (status != "" && tk.Status != parseTaskState(status)) {
+			return true
+		}
+
+		list = append(list, tk)
+
+		return true
+	})
+
+	ReverseTasksByAutoNum(list)
+
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+
+	return list, nil
+}
+
+func (m *fakeModelTask) ListPage(opts api.ListOptions) ([]Task, api.ListMeta, error) {
+	list, err := m.List(nil)
+	if err != nil {
+		return nil, api.ListMeta{}, err
+	}
+
+	tasks := []Task{}
+	meta, err := fakeSelectPage(&tasks, list, "id", taskListDefaultSort, taskListColumns, opts)
+
+	return tasks, meta, err
 }
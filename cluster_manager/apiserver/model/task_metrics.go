@@ -0,0 +1,46 @@
+package model
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var taskDesc = prometheus.NewDesc(
+	"dbscale_tasks",
+	"Number of tasks in tbl_task per action and status.",
+	[]string{"action", "status"}, nil,
+)
+
+type taskCollector struct {
+	db *dbBase
+}
+
+// TaskCollector returns a prometheus collector reports the number of tasks in tbl_task when scraped,
+// it is a gauge because running tasks finish and the tasks may be deleted.
+func (db *dbBase) TaskCollector() prometheus.Collector {
+	return taskCollector{db: db}
+}
+
+func (c taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- taskDesc
+}
+
+func (c taskCollector) Collect(ch chan<- prometheus.Metric) {
+	rows := []struct {
+		Action string     `db:"action"`
+		Status TaskStatus `db:"status"`
+		Count  int        `db:"count"`
+	}{}
+
+	query := "SELECT action,status,COUNT(*) AS count FROM " + Task{}.Table() + " GROUP BY action,status"
+
+	if err := c.db.Select(&rows, query); err != nil {
+		klog.Errorf("collect task metrics:%s", err)
+		ch <- prometheus.NewInvalidMetric(taskDesc, err)
+		return
+	}
+
+	for _, r := range rows {
+		ch <- prometheus.MustNewConstMetric(taskDesc, prometheus.GaugeValue, float64(r.Count), r.Action, r.Status.State())
+	}
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// UsageSample 服务每小时的资源用量采样，每个服务每小时只保留一条，
+// CPU单位为millicore，内存及存储单位为MiB，带宽单位为Mbps，
+// Storage、StorageUsed为性能等级为key的Json，BackupStorage为备份端点为key的Json
+type UsageSample struct {
+	App            string    `db:"app_id"`
+	AppName        string    `db:"app_name"`
+	SubscriptionId string    `db:"subscription_id"`
+	CPU            int64     `db:"cpu"`
+	Memory         int64     `db:"memory"`
+	Bandwidth      int64     `db:"net_bandwidth"`
+	Storage        string    `db:"storage"`
+	StorageUsed    string    `db:"storage_used"`
+	BackupStorage  string    `db:"backup_storage"`
+	SampledAt      time.Time `db:"sampled_timestamp"`
+}
+
+func (UsageSample) Table() string {
+	return "tbl_usage_sample"
+}
+
+// UsageSelector 用量采样的查询条件，空值表示不过滤
+type UsageSelector struct {
+	Subscription string
+	App          string
+	Since        time.Time
+	Until        time.Time
+}
+
+func (s UsageSelector) match(us UsageSample) bool {
+	return (s.Subscription == "" || us.SubscriptionId == s.Subscription) &&
+		(s.App == "" || us.App == s.App) &&
+		(s.Since.IsZero() || !us.SampledAt.Before(s.Since)) &&
+		(s.Until.IsZero() || us.SampledAt.Before(s.Until))
+}
+
+type ModelUsage interface {
+	// InsertUsageSamples ignores the samples already recorded in the same hour
+	InsertUsageSamples(samples []UsageSample) error
+	// ListUsageSamples returns the samples order by time
+	ListUsageSamples(selector UsageSelector) ([]UsageSample, error)
+	DeleteUsageSamples(before time.Time) error
+}
+
+type modelUsage struct {
+	*dbBase
+}
+
+func (m *modelUsage) InsertUsageSamples(samples []UsageSample) error {
+	query := "INSERT IGNORE INTO " + UsageSample{}.Table() +
+		" (app_id,app_name,subscription_id,cpu,memory,net_bandwidth,storage,storage_used,backup_storage,sampled_timestamp) " +
+		"VALUES (:app_id,:app_name,:subscription_id,:cpu,:memory,:net_bandwidth,:storage,:storage_used,:backup_storage,:sampled_timestamp)"
+
+	return m.txFrame(func(tx Tx) error {
+
+		for i := range samples {
+			_, err := tx.NamedExec(query, samples[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (m *modelUsage) ListUsageSamples(selector UsageSelector) ([]UsageSample, error) {
+	query := sq.Select("*").From(UsageSample{}.Table()).OrderBy("sampled_timestamp")
+
+	if selector.Subscription != "" {
+		query = query.Where(sq.Eq{"subscription_id": selector.Subscription})
+	}
+	if selector.App != "" {
+		query = query.Where(sq.Eq{"app_id": selector.App})
+	}
+	if !selector.Since.IsZero() {
+		query = query.Where(sq.GtOrEq{"sampled_timestamp": selector.Since})
+	}
+	if !selector.Until.IsZero() {
+		query = query.Where(sq.Lt{"sampled_timestamp": selector.Until})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []UsageSample{}
+	err = m.Select(&list, sql, args...)
+
+	return list, err
+}
+
+func (m *modelUsage) DeleteUsageSamples(before time.Time) error {
+	query := "DELETE FROM " + UsageSample{}.Table() + " WHERE sampled_timestamp<?"
+
+	_, err := m.Exec(query, before)
+	if IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+type fakeModelUsage struct {
+	lock    sync.Mutex
+	samples []UsageSample
+}
+
+func (m *fakeModelUsage) InsertUsageSamples(samples []UsageSample) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+loop:
+	for _, s := range samples {
+		for i := range m.samples {
+			if m.samples[i].App == s.App && m.samples[i].SampledAt.Equal(s.SampledAt) {
+				continue loop
+			}
+		}
+
+		m.samples = append(m.samples, s)
+	}
+
+	return nil
+}
+
+func (m *fakeModelUsage) ListUsageSamples(selector UsageSelector) ([]UsageSample, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	list := []UsageSample{}
+
+	for i := range m.samples {
+		if selector.match(m.samples[i]) {
+			list = append(list, m.samples[i])
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].SampledAt.Before(list[j].SampledAt)
+	})
+
+	return list, nil
+}
+
+func (m *fakeModelUsage) DeleteUsageSamples(before time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	list := m.samples[:0]
+
+	for i := range m.samples {
+		if !m.samples[i].SampledAt.Before(before) {
+			list = append(list, m.samples[i])
+		}
+	}
+
+	m.samples = list
+
+	return nil
+}
@@ -0,0 +1,334 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	DeliveryPending = "pending"
+	DeliverySuccess = "success"
+	DeliveryFailed  = "failed"
+)
+
+// Webhook 事件订阅，Secret为加密后的签名密钥，EventTypes以逗号分隔，空表示订阅全部
+type Webhook struct {
+	ID         string `db:"id"`
+	Name       string `db:"name"`
+	URL        string `db:"url"`
+	Secret     string `db:"secret"`
+	EventTypes string `db:"event_types"`
+	App        string `db:"app_id"`
+	Enabled    bool   `db:"enabled"`
+	Editor
+}
+
+func (Webhook) Table() string {
+	return "tbl_webhook"
+}
+
+// WebhookDelivery 事件投递记录
+type WebhookDelivery struct {
+	ID           string    `db:"id"`
+	Webhook      string    `db:"webhook_id"`
+	EventID      uint64    `db:"event_id"`
+	EventType    string    `db:"event_type"`
+	Payload      string    `db:"payload"`
+	Status       string    `db:"status"`
+	Attempts     int       `db:"attempts"`
+	ResponseCode int       `db:"response_code"`
+	Error        string    `db:"error"`
+	CreatedAt    time.Time `db:"created_timestamp"`
+	UpdatedAt    time.Time `db:"updated_timestamp"`
+}
+
+func (WebhookDelivery) Table() string {
+	return "tbl_webhook_delivery"
+}
+
+type ModelWebhook interface {
+	InsertWebhook(wh Webhook) (string, error)
+	UpdateWebhook(wh Webhook) error
+	DeleteWebhook(id string) error
+	GetWebhook(id string) (Webhook, error)
+	ListWebhooks(selector map[string]string) ([]Webhook, error)
+
+	InsertDelivery(d WebhookDelivery) (string, error)
+	UpdateDelivery(d WebhookDelivery) error
+	// ListDeliveries returns the deliveries of webhook order by time desc
+	ListDeliveries(webhook string, limit int) ([]WebhookDelivery, error)
+	// ListPendingDeliveries returns the pending deliveries of all webhooks order by time
+	ListPendingDeliveries() ([]WebhookDelivery, error)
+}
+
+type modelWebhook struct {
+	*dbBase
+}
+
+func (m *modelWebhook) InsertWebhook(wh Webhook) (string, error) {
+	if wh.ID == "" {
+		wh.ID = newUUID("")
+	}
+
+	query := "INSERT INTO " + wh.Table() +
+		" (id,name,url,secret,event_types,app_id,enabled,created_user,created_timestamp,modified_user,modified_timestamp) " +
+		"VALUES (:id,:name,:url,:secret,:event_types,:app_id,:enabled,:created_user,:created_timestamp,:modified_user,:modified_timestamp)"
+
+	_, err := m.NamedExec(query, wh)
+
+	return wh.ID, err
+}
+
+func (m *modelWebhook) UpdateWebhook(wh Webhook) error {
+	query := "UPDATE " + wh.Table() + " SET name=:name,url=:url,secret=:secret,event_types=:event_types,app_id=:app_id," +
+		"enabled=:enabled,modified_user=:modified_user,modified_timestamp=:modified_timestamp WHERE id=:id"
+
+	_, err := m.NamedExec(query, wh)
+
+	return err
+}
+
+func (m *modelWebhook) DeleteWebhook(id string) error {
+	return m.txFrame(func(tx Tx) error {
+		query := "DELETE FROM " + WebhookDelivery{}.Table() + " WHERE webhook_id=?"
+
+		_, err := tx.Exec(query, id)
+		if err != nil && !IsNotExist(err) {
+			return err
+		}
+
+		query = "DELETE FROM " + Webhook{}.Table() + " WHERE id=?"
+
+		_, err = tx.Exec(query, id)
+		if IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	})
+}
+
+func (m *modelWebhook) GetWebhook(id string) (Webhook, error) {
+	wh := Webhook{}
+	query := "SELECT * FROM " + wh.Table() + " WHERE id=? OR name=?"
+
+	err := m.dbBase.Get(&wh, query, id, id)
+
+	return wh, err
+}
+
+func (m *modelWebhook) ListWebhooks(selector map[string]string) ([]Webhook, error) {
+	if id, ok := selector["id"]; ok {
+
+		wh, err := m.GetWebhook(id)
+		if IsNotExist(err) {
+			return nil, nil
+		}
+
+		return []Webhook{wh}, err
+	}
+
+	query := sq.Select("*").From(Webhook{}.Table())
+
+	if app, ok := selector["app_id"]; ok {
+		query = query.Where(sq.Eq{"app_id": app})
+	}
+	if enabled, ok := selector[labelEnabled]; ok {
+		query = query.Where(sq.Eq{"enabled": enabled})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []Webhook{}
+	err = m.Select(&list, sql, args...)
+
+	return list, err
+}
+
+func (m *modelWebhook) InsertDelivery(d WebhookDelivery) (string, error) {
+	if d.ID == "" {
+		d.ID = newUUID("")
+	}
+
+	query := "INSERT INTO " + d.Table() +
+		" (id,webhook_id,event_id,event_type,payload,status,attempts,response_code,error,created_timestamp,updated_timestamp) " +
+		"VALUES (:id,:webhook_id,:event_id,:event_type,:payload,:status,:attempts,:response_code,:error,:created_timestamp,:updated_timestamp)"
+
+	_, err := m.NamedExec(query, d)
+
+	return d.ID, err
+}
+
+func (m *modelWebhook) UpdateDelivery(d WebhookDelivery) error {
+	query := "UPDATE " + d.Table() + " SET status=:status,attempts=:attempts,response_code=:response_code," +
+		"error=:error,updated_timestamp=:updated_timestamp WHERE id=:id"
+
+	_, err := m.NamedExec(query, d)
+
+	return err
+}
+
+func (m *modelWebhook) ListDeliveries(webhook string, limit int) ([]WebhookDelivery, error) {
+	query := sq.Select("*").From(WebhookDelivery{}.Table()).
+		Where(sq.Eq{"webhook_id": webhook}).
+		OrderBy("created_timestamp DESC")
+
+	if limit > 0 {
+		query = query.Limit(uint64(limit))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []WebhookDelivery{}
+	err = m.Select(&list, sql, args...)
+
+	return list, err
+}
+
+func (m *modelWebhook) ListPendingDeliveries() ([]WebhookDelivery, error) {
+	list := []WebhookDelivery{}
+	query := "SELECT * FROM " + WebhookDelivery{}.Table() + " WHERE status=? ORDER BY created_timestamp"
+
+	err := m.Select(&list, query, DeliveryPending)
+
+	return list, err
+}
+
+type fakeModelWebhook struct {
+	webhooks   *sync.Map
+	deliveries *sync.Map
+}
+
+func (m *fakeModelWebhook) InsertWebhook(wh Webhook) (string, error) {
+	if wh.ID == "" {
+		wh.ID = newUUID("")
+	}
+
+	m.webhooks.Store(wh.ID, wh)
+
+	return wh.ID, nil
+}
+
+func (m *fakeModelWebhook) UpdateWebhook(wh Webhook) error {
+	if wh.ID == "" {
+		return errors.New("id is required")
+	}
+
+	m.webhooks.Store(wh.ID, wh)
+
+	return nil
+}
+
+func (m *fakeModelWebhook) DeleteWebhook(id string) error {
+	m.webhooks.Delete(id)
+
+	m.deliveries.Range(func(key, value interface{}) bool {
+		if value.(WebhookDelivery).Webhook == id {
+			m.deliveries.Delete(key)
+		}
+
+		return true
+	})
+
+	return nil
+}
+
+func (m *fakeModelWebhook) GetWebhook(id string) (Webhook, error) {
+	v, ok := m.webhooks.Load(id)
+	if !ok {
+		return Webhook{}, NewNotFound("webhook", id)
+	}
+
+	return v.(Webhook), nil
+}
+
+func (m *fakeModelWebhook) ListWebhooks(selector map[string]string) ([]Webhook, error) {
+	list := []Webhook{}
+
+	m.webhooks.Range(func(key, value interface{}) bool {
+		wh := value.(Webhook)
+
+		if id, ok := selector["id"]; ok && wh.ID != id {
+			return true
+		}
+		if app, ok := selector["app_id"]; ok && wh.App != app {
+			return true
+		}
+
+		list = append(list, wh)
+
+		return true
+	})
+
+	return list, nil
+}
+
+func (m *fakeModelWebhook) InsertDelivery(d WebhookDelivery) (string, error) {
+	if d.ID == "" {
+		d.ID = newUUID("")
+	}
+
+	m.deliveries.Store(d.ID, d)
+
+	return d.ID, nil
+}
+
+func (m *fakeModelWebhook) UpdateDelivery(d WebhookDelivery) error {
+	if _, ok := m.deliveries.Load(d.ID); !ok {
+		return NewNotFound("webhook delivery", d.ID)
+	}
+
+	m.deliveries.Store(d.ID, d)
+
+	return nil
+}
+
+func (m *fakeModelWebhook) ListDeliveries(webhook string, limit int) ([]WebhookDelivery, error) {
+	list := []WebhookDelivery{}
+
+	m.deliveries.Range(func(key, value interface{}) bool {
+		if d := value.(WebhookDelivery); d.Webhook == webhook {
+			list = append(list, d)
+		}
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+
+	return list, nil
+}
+
+func (m *fakeModelWebhook) ListPendingDeliveries() ([]WebhookDelivery, error) {
+	list := []WebhookDelivery{}
+
+	m.deliveries.Range(func(key, value interface{}) bool {
+		if d := value.(WebhookDelivery); d.Status == DeliveryPending {
+			list = append(list, d)
+		}
+
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	return list, nil
+}
@@ -2,22 +2,37 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"time"
+
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/backup"
 	"github.com/upmio/dbscale-kube/pkg/server"
 	"k8s.io/klog/v2"
 
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/bankend"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/alert"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/app"
+	auditrouter "github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/audit"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/events"
 
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/host"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/image"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/network"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/openapi"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/site"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/storage"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/subscription"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/task"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/usage"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/upmio/dbscale-kube/pkg/audit"
+	"github.com/upmio/dbscale-kube/pkg/idempotency"
+	"github.com/upmio/dbscale-kube/pkg/metrics"
+	"github.com/upmio/dbscale-kube/pkg/revision"
+	"github.com/upmio/dbscale-kube/pkg/tenant"
 	"github.com/upmio/dbscale-kube/pkg/vars"
 	"github.com/upmio/dbscale-kube/pkg/zone"
 )
@@ -30,8 +45,80 @@ var (
 	}
 
 	execServicePort = "8800"
+
+	alertConfig = bankend.AlertConfig{
+		PrometheusNamespace: bankend.PrometheusNameSpace,
+		PrometheusService:   "prometheus-operated:web",
+		SMTPAddr:            "localhost:25",
+		SMTPFrom:            "dbscale@localhost",
+		NotifyInterval:      time.Minute,
+	}
+
+	passwordPolicy = api.PasswordPolicy{
+		MinLength:      12,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		History:        5,
+		WarnDays:       7,
+		RetainOldHours: 24,
+	}
+
+	// 检查数据库用户密码过期及轮换的间隔
+	passwordCheckInterval = time.Hour
+
+	// 比较数据库用户与期望的间隔
+	dbUserReconcileInterval = 10 * time.Minute
+
+	// 在线执行 ALTER TABLE 迁移的工具，gh-ost 或 pt-online-schema-change
+	onlineSchemaTool = api.OnlineSchemaToolPT
+
+	// 审计记录除写入数据库外，额外输出到syslog或文件(json lines)
+	auditOutput = ""
+
+	// 轮询任务、单元及备份状态并发布事件的间隔
+	eventPollInterval = 5 * time.Second
+
+	// 检查站点组件状态，状态变化时发布site.state事件的间隔
+	siteStatusInterval = time.Minute
+
+	// 采样服务资源用量的间隔，每个服务每小时只保留一次采样
+	usageSampleInterval = 10 * time.Minute
+
+	// 用量采样的保留时间
+	usageRetention = 400 * 24 * time.Hour
+
+	// Idempotency-Key 请求记录的保留时间
+	idempotencyTTL = 24 * time.Hour
+
+	// 检查镜像tag在站点仓库中对应的digest是否变化的间隔
+	imageDigestCheckInterval = time.Hour
+
+	// 管理员令牌，携带该令牌的请求可不携带订阅请求头，为空时不允许绕过
+	tenantAdminToken = ""
 )
 
+// 必须携带订阅请求头的路径，备份存储是站点级资源不在其中
+var tenantRoutes = []string{
+	"/manager/apps",
+	"/manager/backup/files",
+	"/manager/backup/strategy",
+}
+
+// 支持 ETag/If-Match 的资源，路径变量为ID的资源包括其子路由
+var revisionRoutes = map[string]revision.Resource{
+	"/manager/apps":                 {Kind: "app", Query: "id"},
+	"/manager/apps/{app}":           {Kind: "app", Var: "app"},
+	"/manager/hosts":                {Kind: "host", Query: "id"},
+	"/manager/hosts/{id}":           {Kind: "host", Var: "id"},
+	"/manager/images":               {Kind: "image", Query: "id"},
+	"/manager/images/{id}":          {Kind: "image", Var: "id"},
+	"/maintenance/images/{id}":      {Kind: "image", Var: "id"},
+	"/manager/backup/strategy":      {Kind: "backup_strategy", Query: "id"},
+	"/manager/backup/strategy/{id}": {Kind: "backup_strategy", Var: "id"},
+	"/manager/backup/endpoint/{id}": {Kind: "backup_endpoint", Var: "id"},
+}
+
 func initDBConfig() {
 	flag.BoolVar(&fakeDB, "fake", false, "test mode,fake database")
 
@@ -45,8 +132,51 @@ func initDBConfig() {
 	flag.IntVar(&dbConfig.MaxIdleConns, "dbMaxIdleConns", dbConfig.MaxIdleConns, "database max idle connects")
 }
 
+func initAlertConfig() {
+	flag.StringVar(&alertConfig.PrometheusNamespace, "prometheus-namespace", alertConfig.PrometheusNamespace, "namespace of prometheus service in sites")
+	flag.StringVar(&alertConfig.PrometheusService, "prometheus-service", alertConfig.PrometheusService, "prometheus service in sites,name:port")
+	flag.StringVar(&alertConfig.SMTPAddr, "smtp-addr", alertConfig.SMTPAddr, "smtp server addr for alert email")
+	flag.StringVar(&alertConfig.SMTPFrom, "smtp-from", alertConfig.SMTPFrom, "sender of alert email")
+	flag.DurationVar(&alertConfig.NotifyInterval, "alert-notify-interval", alertConfig.NotifyInterval, "interval of checking alerts and notifying channels,0 means disabled")
+
+	flag.StringVar(&auditOutput, "audit-output", auditOutput, "also write audit logs to 'syslog' or a file as json lines")
+
+	flag.DurationVar(&imageDigestCheckInterval, "image-digest-check-interval", imageDigestCheckInterval, "interval of checking the image tags point to the imported digests,0 means disabled")
+
+	flag.DurationVar(&idempotencyTTL, "idempotency-ttl", idempotencyTTL, "how long the responses of requests with Idempotency-Key are kept,0 means forever")
+
+	flag.DurationVar(&eventPollInterval, "event-poll-interval", eventPollInterval, "interval of polling tasks,units and backups for the event stream and webhooks,0 means disabled")
+	flag.DurationVar(&siteStatusInterval, "site-status-interval", siteStatusInterval, "interval of checking the components of sites,0 means disabled")
+	flag.DurationVar(&usageSampleInterval, "usage-sample-interval", usageSampleInterval, "interval of sampling the usage of apps for metering,one sample per app per hour is kept,0 means disabled")
+	flag.DurationVar(&usageRetention, "usage-retention", usageRetention, "how long the usage samples are kept,0 means forever")
+
+	flag.StringVar(&tenantAdminToken, "tenant-admin-token", tenantAdminToken, "requests of apps,backup files and strategies must carry the "+tenant.Header+" header unless they carry this token in the "+tenant.AdminHeader+" header,empty means no bypass")
+
+	flag.IntVar(&passwordPolicy.MinLength, "password-min-length", passwordPolicy.MinLength, "minimum length of db user passwords")
+	flag.BoolVar(&passwordPolicy.RequireUpper, "password-require-upper", passwordPolicy.RequireUpper, "db user passwords must contain upper case letters")
+	flag.BoolVar(&passwordPolicy.RequireLower, "password-require-lower", passwordPolicy.RequireLower, "db user passwords must contain lower case letters")
+	flag.BoolVar(&passwordPolicy.RequireDigit, "password-require-digit", passwordPolicy.RequireDigit, "db user passwords must contain digits")
+	flag.BoolVar(&passwordPolicy.RequireSpecial, "password-require-special", passwordPolicy.RequireSpecial, "db user passwords must contain special characters")
+	flag.IntVar(&passwordPolicy.History, "password-history", passwordPolicy.History, "number of previous db user passwords that can't be reused,0 means no check")
+	flag.IntVar(&passwordPolicy.MaxAgeDays, "password-max-age-days", passwordPolicy.MaxAgeDays, "days before db user passwords expire,0 means never")
+	flag.IntVar(&passwordPolicy.WarnDays, "password-warn-days", passwordPolicy.WarnDays, "days before expiry to publish db_user.password_expiring events")
+	flag.BoolVar(&passwordPolicy.AutoRotate, "password-auto-rotate", passwordPolicy.AutoRotate, "rotate the expired server generated passwords")
+	flag.IntVar(&passwordPolicy.RetainOldHours, "password-retain-old-hours", passwordPolicy.RetainOldHours, "hours the old password is kept after a dual password rotation,0 means until discarded manually")
+	flag.DurationVar(&passwordCheckInterval, "password-check-interval", passwordCheckInterval, "interval of checking db user password expiry and rotation,0 means disabled")
+	flag.DurationVar(&dbUserReconcileInterval, "db-user-reconcile-interval", dbUserReconcileInterval, "interval of comparing db users with the desired spec,0 means disabled")
+	flag.StringVar(&onlineSchemaTool, "online-schema-tool", onlineSchemaTool, "tool of online schema migrations,gh-ost or pt-online-schema-change")
+}
+
 //routers router.Adder, wsRouters handlerrouter.Adder
-func initRouter(srv *server.Server) error {
+func initRouter(srv *server.Server, stopCh <-chan struct{}) error {
+	if err := passwordPolicy.Valid(); err != nil {
+		return err
+	}
+
+	if onlineSchemaTool != api.OnlineSchemaToolGhost && onlineSchemaTool != api.OnlineSchemaToolPT {
+		return fmt.Errorf("unsupported online schema tool %s", onlineSchemaTool)
+	}
+
 	zone := zone.NewZone(8)
 
 	fm := model.NewFakeModels()
@@ -61,6 +191,19 @@ func initRouter(srv *server.Server) error {
 	mbs := fm.ModelBackupStrategy()
 	mbf := fm.ModelBackupFile()
 	mbe := fm.ModelBackupEndpoint()
+	malert := fm.ModelAlert()
+	maudit := fm.ModelAudit()
+	mwebhook := fm.ModelWebhook()
+	midempotency := fm.ModelIdempotency()
+	mrevision := fm.ModelRevision()
+	mcampaign := fm.ModelImageCampaign()
+	mtemplate := fm.ModelImageTemplate()
+	msubscription := fm.ModelSubscription()
+	musage := fm.ModelUsage()
+	mcredential := fm.ModelDBUserCredential()
+	muserspec := fm.ModelDBUserSpec()
+	mmigration := fm.ModelSchemaMigration()
+	mmaintenance := fm.ModelHostMaintenance()
 
 	if !fakeDB {
 		db, err := model.NewDB(dbConfig)
@@ -81,8 +224,52 @@ func initRouter(srv *server.Server) error {
 		mbs = db.ModelBackupStrategy()
 		mbf = db.ModelBackupFile()
 		mbe = db.ModelBackupEndpoint()
+		malert = db.ModelAlert()
+		maudit = db.ModelAudit()
+		mwebhook = db.ModelWebhook()
+		midempotency = db.ModelIdempotency()
+		mrevision = db.ModelRevision()
+		mcampaign = db.ModelImageCampaign()
+		mtemplate = db.ModelImageTemplate()
+		msubscription = db.ModelSubscription()
+		musage = db.ModelUsage()
+		mcredential = db.ModelDBUserCredential()
+		muserspec = db.ModelDBUserSpec()
+		mmigration = db.ModelSchemaMigration()
+		mmaintenance = db.ModelHostMaintenance()
+
+		metrics.MustRegister(db.TaskCollector())
 	}
 
+	auditBknd := bankend.NewAuditBankend(maudit)
+	sinks := audit.MultiSink{auditBknd}
+
+	if auditOutput != "" {
+		sink, err := audit.NewSink(auditOutput)
+		if err != nil {
+			return err
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	srv.AddMiddleware(audit.Middleware{Sink: sinks})
+	auditrouter.RegisterAuditRoute(auditBknd, srv)
+
+	// 后添加的中间件先执行，重放的响应不再审计和修改版本
+	revisionBknd := bankend.NewRevisionBankend(mrevision, mt)
+	srv.AddMiddleware(revision.Middleware{Store: revisionBknd, Routes: revisionRoutes})
+	srv.AddMiddleware(idempotency.Middleware{Store: bankend.NewIdempotencyBankend(midempotency), TTL: idempotencyTTL})
+
+	// 限定订阅的请求最先检查，改写的subscription_id参与审计及幂等
+	subscriptionBknd := bankend.NewSubscriptionBankend(msubscription, mas, mbf)
+	srv.AddMiddleware(tenant.Middleware{Store: subscriptionBknd, Required: tenantRoutes, AdminToken: tenantAdminToken})
+	subscription.RegisterSubscriptionRoute(subscriptionBknd, srv)
+
+	usageBknd := bankend.NewUsageBankend(zone, musage, mas, mbf)
+	usageBknd.RunMetering(usageSampleInterval, usageRetention, stopCh)
+	usage.RegisterUsageRoute(usageBknd, srv)
+
 	siteBknd := bankend.NewSiteBankend(execServicePort, zone, ms, mc, mrs, srv)
 	err := siteBknd.RestoreSites()
 	if err != nil {
@@ -98,15 +285,58 @@ func initRouter(srv *server.Server) error {
 	site.RegisterSiteRoute(siteBknd, srv)
 	task.RegisterTaskRoute(bankend.NewTaskBankend(mt), srv)
 	network.RegisterNetworkRoute(bankend.NewNetworkBankend(zone, mn, ms, mc), srv)
-	image.RegisterImageRoute(bankend.NewImageBankend(zone, ms, mi), srv)
-	host.RegisterHostRoute(bankend.NewHostBankend(zone, mh, mc, ms, mrs, vars.SeCretAESKey), srv)
+	imageBknd := bankend.NewImageBankend(zone, ms, mi, mtemplate)
+	imageBknd.RunDigestCheck(imageDigestCheckInterval, stopCh)
+	image.RegisterImageRoute(imageBknd, srv)
+	appBknd := bankend.NewAppBankend(zone, mas, mi, ms, mc, mn, mh, mbf, mbe, mrs, mrs, msubscription, mcredential, passwordPolicy)
+
+	hostBknd := bankend.NewHostBankend(zone, mh, mc, ms, mrs, appBknd, mmaintenance, vars.SeCretAESKey)
+	err = hostBknd.RestoreMaintenances()
+	if err != nil {
+		return err
+	}
+	host.RegisterHostRoute(hostBknd, srv)
 	host.RegisterClusterRoute(bankend.NewClusterBankend(ms, mn, mc, mh), srv)
 	storage.RegisterStorageRoute(bankend.NewStorageBankend(zone, mrs, ms, vars.SeCretAESKey), srv)
 
-	app.RegisterAppRoute(bankend.NewAppBankend(zone, mas, mi, ms, mc, mn, mh, mbf, mbe, mrs, mrs), srv)
+	app.RegisterAppRoute(appBknd, srv)
+	app.RegisterManifestRoute(bankend.NewManifestBankend(appBknd, bbknd, mt, revisionBknd), srv)
+	app.RegisterAppResourceRoute(bankend.NewAppResourceBankend(zone), srv)
+	migrationBknd := bankend.NewMigrationBankend(appBknd, mmigration, mt, onlineSchemaTool)
+	err = migrationBknd.RestoreMigrations()
+	if err != nil {
+		return err
+	}
+	app.RegisterMigrationRoute(migrationBknd, srv)
+
+	campaignBknd := bankend.NewImageCampaignBankend(mi, appBknd, mt, mcampaign)
+	campaignBknd.Run(stopCh)
+	image.RegisterCampaignRoute(campaignBknd, srv)
+	image.RegisterTemplateRoute(bankend.NewImageTemplateBankend(zone, mi, mtemplate, appBknd), srv)
 
 	backup.RegisterBackupRoute(bbknd, srv)
 
+	alertBknd := bankend.NewAlertBankend(zone, malert, mas, mbs, mbf, alertConfig)
+	alertBknd.RunNotifier(stopCh)
+	alert.RegisterAlertRoute(alertBknd, srv)
+
+	webhookBknd := bankend.NewWebhookBankend(mwebhook, vars.SeCretAESKey)
+	webhookBknd.Run(stopCh)
+	events.RegisterWebhookRoute(webhookBknd, srv)
+
+	eventBknd := bankend.NewEventBankend(zone, mt, mbf, webhookBknd, revisionBknd)
+	eventBknd.Run(eventPollInterval, stopCh)
+	siteBknd.RunStatus(siteStatusInterval, eventBknd, stopCh)
+	appBknd.RunPasswordRotation(passwordCheckInterval, eventBknd, stopCh)
+	events.RegisterEventRoute(eventBknd, srv)
+
+	dbUserBknd := bankend.NewDBUserBankend(appBknd, mas, muserspec, eventBknd)
+	dbUserBknd.RunReconcile(dbUserReconcileInterval, stopCh)
+	app.RegisterDBUserRoute(dbUserBknd, srv)
+
+	openapi.RegisterOpenAPIRoute(srv)
+	srv.SetAPIVersion(openapi.APIVersion)
+
 	err = siteBknd.InitDashboards()
 	if err != nil {
 		return err
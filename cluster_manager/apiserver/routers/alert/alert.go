@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterAlertRoute(bankend alertBankend, routers router.Adder) {
+	r := &alertRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/alerts", r.listAlerts, router.WithDoc(router.Doc{
+			ID:       "listAlerts",
+			Tags:     []string{"alerts"},
+			Summary:  "查询告警",
+			Query:    api.AlertListQuery{},
+			Response: api.AlertsResponse{},
+		})),
+
+		router.NewGetRoute("/manager/apps/{app}/alerts/config", r.getAppAlertConfig, router.WithDoc(router.Doc{
+			ID:       "getAppAlertConfig",
+			Tags:     []string{"alerts"},
+			Summary:  "查询服务告警规则",
+			Response: api.AppAlertConfig{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/alerts/config", r.setAppAlertConfig, router.WithDoc(router.Doc{
+			ID:       "setAppAlertConfig",
+			Tags:     []string{"alerts"},
+			Summary:  "设置服务告警规则",
+			Body:     api.AppAlertConfigOptions{},
+			Response: api.AppAlertConfig{},
+		})),
+
+		router.NewGetRoute("/manager/alerts/channels", r.listChannels, router.WithDoc(router.Doc{
+			ID:       "listNotificationChannels",
+			Tags:     []string{"alerts"},
+			Summary:  "查询通知渠道",
+			Query:    api.TypeQuery{},
+			Response: []api.NotificationChannel{},
+		})),
+		router.NewPostRoute("/manager/alerts/channels", r.postChannel, router.WithDoc(router.Doc{
+			ID:       "postNotificationChannel",
+			Tags:     []string{"alerts"},
+			Summary:  "增加通知渠道",
+			Body:     api.NotificationChannelConfig{},
+			Code:     http.StatusCreated,
+			Response: api.ObjectResponse{},
+		})),
+		router.NewGetRoute("/manager/alerts/channels/{id}", r.getChannel, router.WithDoc(router.Doc{
+			ID:       "getNotificationChannel",
+			Tags:     []string{"alerts"},
+			Summary:  "查询通知渠道详情",
+			Response: api.NotificationChannel{},
+		})),
+		router.NewPutRoute("/manager/alerts/channels/{id}", r.setChannel, router.WithDoc(router.Doc{
+			ID:       "setNotificationChannel",
+			Tags:     []string{"alerts"},
+			Summary:  "更新通知渠道",
+			Body:     api.NotificationChannelOptions{},
+			Response: api.NotificationChannel{},
+		})),
+		router.NewDeleteRoute("/manager/alerts/channels/{id}", r.deleteChannel, router.WithDoc(router.Doc{
+			ID:      "deleteNotificationChannel",
+			Tags:    []string{"alerts"},
+			Summary: "删除通知渠道",
+			Code:    http.StatusNoContent,
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type alertRoute struct {
+	bankend alertBankend
+
+	routes []router.Route
+}
+
+func (ar alertRoute) Routes() []router.Route {
+	return ar.routes
+}
+
+type alertBankend interface {
+	ListAlerts(ctx context.Context, app, severity string) (api.AlertsResponse, error)
+
+	GetAppAlertConfig(ctx context.Context, app string) (api.AppAlertConfig, error)
+	SetAppAlertConfig(ctx context.Context, app string, opts api.AppAlertConfigOptions) (api.AppAlertConfig, error)
+
+	AddChannel(ctx context.Context, config api.NotificationChannelConfig) (api.ObjectResponse, error)
+	SetChannel(ctx context.Context, id string, opts api.NotificationChannelOptions) (api.NotificationChannel, error)
+	GetChannel(ctx context.Context, id string) (api.NotificationChannel, error)
+	ListChannels(ctx context.Context, typ string) ([]api.NotificationChannel, error)
+	DeleteChannel(ctx context.Context, id string) error
+}
@@ -0,0 +1,133 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+// list alerts options
+//
+// swagger:parameters listAlerts
+type listAlertsRequest struct {
+	// in: query
+	// required: false
+	App string `json:"app_id"`
+
+	// critical or warning
+	//
+	// in: query
+	// required: false
+	Severity string `json:"severity"`
+}
+
+// active alerts of apps
+//
+// swagger:response listAlertsResponseWrapper
+type listAlertsResponseWrapper struct {
+	// in: body
+	Body api.AlertsResponse
+}
+
+func (ar alertRoute) listAlerts(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/alerts alert listAlerts
+	//
+	// 查询服务当前的告警
+	//
+	// List alerts
+	// This will returns the firing and pending alerts of apps
+	//
+	//     Responses:
+	//       200: listAlertsResponseWrapper
+	//       500: ErrorResponse
+
+	app := r.FormValue("app_id")
+	severity := r.FormValue("severity")
+
+	list, err := ar.bankend.ListAlerts(ctx, app, severity)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+// swagger:parameters getAppAlertConfig
+type getAppAlertConfigRequest struct {
+	// in: path
+	// required: true
+	App string `json:"app"`
+}
+
+// app alert config
+//
+// swagger:response appAlertConfigResponseWrapper
+type appAlertConfigResponseWrapper struct {
+	// in: body
+	Body api.AppAlertConfig
+}
+
+func (ar alertRoute) getAppAlertConfig(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/apps/{app}/alerts/config alert getAppAlertConfig
+	//
+	// 查询服务告警配置
+	//
+	// Get app alert config
+	// This will returns the alert thresholds of the app
+	//
+	//     Responses:
+	//       200: appAlertConfigResponseWrapper
+	//       500: ErrorResponse
+
+	config, err := ar.bankend.GetAppAlertConfig(ctx, vars["app"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, config, nil
+}
+
+// swagger:parameters setAppAlertConfig
+type setAppAlertConfigRequest struct {
+	// in: path
+	// required: true
+	App string `json:"app"`
+
+	// in: body
+	// required: true
+	Body api.AppAlertConfigOptions
+}
+
+func (ar alertRoute) setAppAlertConfig(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route PUT /manager/apps/{app}/alerts/config alert setAppAlertConfig
+	//
+	// 更改服务告警配置
+	//
+	// Set app alert config
+	// This will update the alert thresholds and prometheus rules of the app
+	//
+	//     Responses:
+	//       200: appAlertConfigResponseWrapper
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	req := api.AppAlertConfigOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Apply(api.DefaultAlertThresholds()).Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	config, err := ar.bankend.SetAppAlertConfig(ctx, vars["app"], req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, config, nil
+}
@@ -0,0 +1,179 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+// swagger:parameters postNotificationChannel
+type postChannelRequest struct {
+	// in: body
+	// required: true
+	Body api.NotificationChannelConfig
+}
+
+func (ar alertRoute) postChannel(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route POST /manager/alerts/channels alert postNotificationChannel
+	//
+	// 增加告警通知渠道
+	//
+	// Add a notification channel
+	// This will create a webhook or email notification channel
+	//
+	//     Responses:
+	//       201: ObjectResponse
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	req := api.NotificationChannelConfig{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	obj, err := ar.bankend.AddChannel(ctx, req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusCreated, obj, nil
+}
+
+// swagger:parameters setNotificationChannel
+type setChannelRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	// required: true
+	Body api.NotificationChannelOptions
+}
+
+// notification channel info
+//
+// swagger:response notificationChannelResponseWrapper
+type notificationChannelResponseWrapper struct {
+	// in: body
+	Body api.NotificationChannel
+}
+
+func (ar alertRoute) setChannel(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route PUT /manager/alerts/channels/{id} alert setNotificationChannel
+	//
+	// 更改告警通知渠道
+	//
+	// Update a notification channel
+	// This will update the notification channel
+	//
+	//     Responses:
+	//       200: notificationChannelResponseWrapper
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	req := api.NotificationChannelOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	ch, err := ar.bankend.SetChannel(ctx, vars["id"], req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, ch, nil
+}
+
+// swagger:parameters getNotificationChannel deleteNotificationChannel
+type channelIDRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+func (ar alertRoute) getChannel(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/alerts/channels/{id} alert getNotificationChannel
+	//
+	// 查询一个告警通知渠道
+	//
+	// Get a notification channel
+	// This will returns a notification channel
+	//
+	//     Responses:
+	//       200: notificationChannelResponseWrapper
+	//       500: ErrorResponse
+
+	ch, err := ar.bankend.GetChannel(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, ch, nil
+}
+
+// swagger:parameters listNotificationChannels
+type listChannelsRequest struct {
+	// webhook or email
+	//
+	// in: query
+	// required: false
+	Type string `json:"type"`
+}
+
+// notification channels
+//
+// swagger:response listNotificationChannelsResponseWrapper
+type listNotificationChannelsResponseWrapper struct {
+	// in: body
+	Body []api.NotificationChannel
+}
+
+func (ar alertRoute) listChannels(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/alerts/channels alert listNotificationChannels
+	//
+	// 查询告警通知渠道
+	//
+	// List notification channels
+	// This will returns a list of notification channels
+	//
+	//     Responses:
+	//       200: listNotificationChannelsResponseWrapper
+	//       500: ErrorResponse
+
+	list, err := ar.bankend.ListChannels(ctx, r.FormValue("type"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (ar alertRoute) deleteChannel(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route DELETE /manager/alerts/channels/{id} alert deleteNotificationChannel
+	//
+	// 删除告警通知渠道
+	//
+	// Delete a notification channel
+	// This will delete the notification channel
+	//
+	//     Responses:
+	//       204: description: Deleted
+	//       500: ErrorResponse
+
+	err := ar.bankend.DeleteChannel(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
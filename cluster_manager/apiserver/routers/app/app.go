@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 	"github.com/upmio/dbscale-kube/pkg/server/router"
@@ -13,53 +14,287 @@ func RegisterAppRoute(bankend appBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewPostRoute("/manager/apps", r.postApp),
-		router.NewGetRoute("/manager/apps", r.listApps),
-		router.NewGetRoute("/pagination/apps", r.listAppsWithPagination),
-		router.NewGetRoute("/manager/apps/detail", r.listAppsDetail),
-		router.NewDeleteRoute("/manager/apps/{app}", r.deleteApp),
+		router.NewPostRoute("/manager/apps", r.postApp, router.WithDoc(router.Doc{
+			ID:         "postApp",
+			Tags:       []string{"apps"},
+			Summary:    "增加新服务",
+			Query:      api.SubscriptionQuery{},
+			Body:       api.AppConfig{},
+			BodySchema: "app.json",
+			Code:       http.StatusCreated,
+			Response:   api.TaskObjectResponse{},
+		})),
+		router.NewGetRoute("/manager/apps", r.listApps, router.WithDoc(router.Doc{
+			ID:       "listApps",
+			Tags:     []string{"apps"},
+			Summary:  "查询服务",
+			Query:    api.AppListQuery{},
+			Response: api.AppsResponse{},
+			Paged:    true,
+		})),
+		router.NewGetRoute("/pagination/apps", r.listAppsWithPagination, router.WithDoc(router.Doc{
+			ID:       "listAppsWithPagination",
+			Tags:     []string{"apps"},
+			Summary:  "分页查询服务",
+			Query:    api.AppPaginationQuery{},
+			Response: api.PaginationResp{},
+		})),
+		router.NewGetRoute("/manager/apps/detail", r.listAppsDetail, router.WithDoc(router.Doc{
+			ID:       "listAppsDetail",
+			Tags:     []string{"apps"},
+			Summary:  "查询服务详情",
+			Query:    api.AppListQuery{},
+			Response: api.AppsResponse{},
+			Paged:    true,
+		})),
+		router.NewDeleteRoute("/manager/apps/{app}", r.deleteApp, router.WithDoc(router.Doc{
+			ID:      "deleteApp",
+			Tags:    []string{"apps"},
+			Summary: "删除服务",
+			Query:   api.SubscriptionQuery{},
+			Code:    http.StatusNoContent,
+		})),
 
-		router.NewPutRoute("/manager/apps/{app}/arch", r.updateAppArch),
-		router.NewPutRoute("/manager/apps/{app}/image", r.updateAppImage),
-		router.NewPutRoute("/manager/apps/{app}/state", r.updateAppState),
-		router.NewPutRoute("/manager/apps/{app}/resource/requests", r.updateAppResources),
+		router.NewPutRoute("/manager/apps/{app}/arch", r.updateAppArch, router.WithDoc(router.Doc{
+			ID:       "updateAppArch",
+			Tags:     []string{"apps"},
+			Summary:  "更新服务架构",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.AppArchOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/image", r.updateAppImage, router.WithDoc(router.Doc{
+			ID:       "updateAppImage",
+			Tags:     []string{"apps"},
+			Summary:  "更新服务镜像",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.AppImageOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/state", r.updateAppState, router.WithDoc(router.Doc{
+			ID:         "updateAppState",
+			Tags:       []string{"apps"},
+			Summary:    "更新服务状态",
+			Query:      api.SubscriptionQuery{},
+			Body:       api.AppStateOptions{},
+			BodySchema: "appStateOptions.json",
+			Response:   api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/resource/requests", r.updateAppResources, router.WithDoc(router.Doc{
+			ID:         "updateAppResources",
+			Tags:       []string{"apps"},
+			Summary:    "更新服务资源",
+			Query:      api.SubscriptionQuery{},
+			Body:       api.AppResourcesOptions{},
+			BodySchema: "appResourcesOptions.json",
+			Response:   api.TaskObjectResponse{},
+		})),
 
 		//set unit role
-		router.NewPutRoute("/manager/apps/{app}/units/{unit}/role", r.setUnitRole),
+		router.NewPutRoute("/manager/apps/{app}/units/{unit}/role", r.setUnitRole, router.WithDoc(router.Doc{
+			ID:      "setUnitRole",
+			Tags:    []string{"units"},
+			Summary: "设置单元角色",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.RoleSetOptions{},
+		})),
 
-		router.NewPutRoute("/manager/apps/{app}/units/{unit}/state", r.updateUnitState),
-		router.NewPutRoute("/manager/apps/{app}/units/{unit}/rebuild", r.rebuildUnit),
-		router.NewPutRoute("/manager/apps/{app}/units/{unit}/migrate", r.migrateUnit),
-		router.NewPutRoute("/manager/apps/{app}/units/{unit}/restore", r.restoreUnit),
-		router.NewPutRoute("/manager/apps/{app}/units/{unit}/resource/requests", r.updateUnitResources),
-		router.NewPutRoute("/manager/apps/{app}/role", r.roleSwitch),
+		router.NewPutRoute("/manager/apps/{app}/units/{unit}/state", r.updateUnitState, router.WithDoc(router.Doc{
+			ID:         "updateUnitState",
+			Tags:       []string{"units"},
+			Summary:    "更新单元状态",
+			Query:      api.SubscriptionQuery{},
+			Body:       api.AppStateOptions{},
+			BodySchema: "appStateOptions.json",
+			Response:   api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/units/{unit}/rebuild", r.rebuildUnit, router.WithDoc(router.Doc{
+			ID:       "rebuildUnit",
+			Tags:     []string{"units"},
+			Summary:  "重建单元",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.UnitRebuildOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/units/{unit}/migrate", r.migrateUnit, router.WithDoc(router.Doc{
+			ID:       "migrateUnit",
+			Tags:     []string{"units"},
+			Summary:  "迁移单元",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.UnitMigrateOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/units/{unit}/restore", r.restoreUnit, router.WithDoc(router.Doc{
+			ID:       "restoreUnit",
+			Tags:     []string{"units"},
+			Summary:  "单元备份恢复",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.UnitRestoreOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/units/{unit}/resource/requests", r.updateUnitResources, router.WithDoc(router.Doc{
+			ID:         "updateUnitResources",
+			Tags:       []string{"units"},
+			Summary:    "更新单元资源",
+			Query:      api.SubscriptionQuery{},
+			Body:       api.AppResourcesOptions{},
+			BodySchema: "appResourcesOptions.json",
+			Response:   api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/role", r.roleSwitch, router.WithDoc(router.Doc{
+			ID:      "roleSwitch",
+			Tags:    []string{"units"},
+			Summary: "单元角色切换",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.UnitRoleSwitchConfig{},
+		})),
 
 		//config
-		router.NewGetRoute("/manager/apps/{app}/config", r.listConfig),
-		router.NewPutRoute("/manager/apps/{app}/config", r.updateConfig),
+		router.NewGetRoute("/manager/apps/{app}/config", r.listConfig, router.WithDoc(router.Doc{
+			ID:       "listAppConfig",
+			Tags:     []string{"apps"},
+			Summary:  "查询服务配置",
+			Query:    api.SubscriptionQuery{},
+			Response: api.ConfigMapResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/config", r.updateConfig, router.WithDoc(router.Doc{
+			ID:      "updateAppConfig",
+			Tags:    []string{"apps"},
+			Summary: "更新服务配置",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.ConfigMapOptions{},
+		})),
 
-		router.NewGetRoute("/manager/apps/{app}/database/users", r.listAppDBUser),
-		router.NewGetRoute("/manager/apps/{app}/database/users/{user}", r.listAppDBSingleUser),
-		router.NewPostRoute("/manager/apps/{app}/database/users", r.postAppUser),
-		router.NewPutRoute("/manager/apps/{app}/database/users/pwd", r.resetAppUserPassword),
-		router.NewDeleteRoute("/manager/apps/{app}/database/users/{user}", r.deleteAppDBUser),
+		router.NewGetRoute("/manager/apps/{app}/database/users", r.listAppDBUser, router.WithDoc(router.Doc{
+			ID:       "listAppDBUsers",
+			Tags:     []string{"users"},
+			Summary:  "查询数据库用户",
+			Query:    api.SubscriptionQuery{},
+			Response: api.AppUsersResponse{},
+		})),
+		router.NewGetRoute("/manager/apps/{app}/database/users/{user}", r.listAppDBSingleUser, router.WithDoc(router.Doc{
+			ID:       "getAppDBUser",
+			Tags:     []string{"users"},
+			Summary:  "查询单个数据库用户",
+			Query:    api.DBUserQuery{},
+			Response: api.DatabaseUser{},
+		})),
+		router.NewPostRoute("/manager/apps/{app}/database/users", r.postAppUser, router.WithDoc(router.Doc{
+			ID:       "postAppDBUser",
+			Tags:     []string{"users"},
+			Summary:  "增加数据库用户，未指定密码时由服务端生成并保存到服务namespace的Secret",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.AppUserConfig{},
+			Code:     http.StatusCreated,
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/database/users/pwd", r.resetAppUserPassword, router.WithDoc(router.Doc{
+			ID:      "resetAppDBUserPassword",
+			Tags:    []string{"users"},
+			Summary: "重置数据库用户密码，未指定密码时由服务端生成并保存到Secret",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.AppUserResetConfig{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/database/users/pwd/discard", r.discardAppUserOldPassword, router.WithDoc(router.Doc{
+			ID:      "discardAppDBUserOldPassword",
+			Tags:    []string{"users"},
+			Summary: "丢弃双密码轮换保留的旧密码",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.AppUserDiscardPasswordConfig{},
+		})),
+		router.NewGetRoute("/manager/apps/{app}/database/credentials", r.listAppDBUserCredentials, router.WithDoc(router.Doc{
+			ID:       "listAppDBUserCredentials",
+			Tags:     []string{"users"},
+			Summary:  "查询数据库用户的密码状态，包括Secret及过期时间，不包含密码",
+			Query:    api.SubscriptionQuery{},
+			Response: api.DBUserCredentialsResponse{},
+		})),
+		router.NewGetRoute("/manager/password_policy", r.getPasswordPolicy, router.WithDoc(router.Doc{
+			ID:       "getPasswordPolicy",
+			Tags:     []string{"users"},
+			Summary:  "查询数据库用户的密码策略",
+			Response: api.PasswordPolicy{},
+		})),
+		router.NewDeleteRoute("/manager/apps/{app}/database/users/{user}", r.deleteAppDBUser, router.WithDoc(router.Doc{
+			ID:      "deleteAppDBUser",
+			Tags:    []string{"users"},
+			Summary: "删除数据库用户",
+			Query:   api.DBUserQuery{},
+			Code:    http.StatusNoContent,
+		})),
 
 		//privileges
-		router.NewPutRoute("/manager/apps/{app}/database/users/privileges", r.updateAppUserPrivileges),
+		router.NewPutRoute("/manager/apps/{app}/database/users/privileges", r.updateAppUserPrivileges, router.WithDoc(router.Doc{
+			ID:      "updateAppDBUserPrivileges",
+			Tags:    []string{"users"},
+			Summary: "更新数据库用户权限",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.AppUserPrivilegesOptions{},
+		})),
 
-		router.NewGetRoute("/manager/apps/{app}/database/schemas", r.listAppDBSchema),
-		router.NewGetRoute("/manager/apps/{app}/database/schemas/{schema}", r.listAppDBSchemaDetail),
-		router.NewPostRoute("/manager/apps/{app}/database/schemas", r.postAppDBSchema),
-		router.NewDeleteRoute("/manager/apps/{app}/database/schemas/{schema}", r.deleteAppDBSchema),
+		router.NewGetRoute("/manager/apps/{app}/database/schemas", r.listAppDBSchema, router.WithDoc(router.Doc{
+			ID:       "listAppDBSchemas",
+			Tags:     []string{"schemas"},
+			Summary:  "查询数据库",
+			Query:    api.SubscriptionQuery{},
+			Response: api.DBSchemaResponse{},
+		})),
+		router.NewGetRoute("/manager/apps/{app}/database/schemas/{schema}", r.listAppDBSchemaDetail, router.WithDoc(router.Doc{
+			ID:       "getAppDBSchema",
+			Tags:     []string{"schemas"},
+			Summary:  "查询数据库详情",
+			Query:    api.SubscriptionQuery{},
+			Response: api.DBSchemaDetailResponse{},
+		})),
+		router.NewPostRoute("/manager/apps/{app}/database/schemas", r.postAppDBSchema, router.WithDoc(router.Doc{
+			ID:       "postAppDBSchema",
+			Tags:     []string{"schemas"},
+			Summary:  "增加数据库",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.AppSchemaConfig{},
+			Code:     http.StatusCreated,
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewDeleteRoute("/manager/apps/{app}/database/schemas/{schema}", r.deleteAppDBSchema, router.WithDoc(router.Doc{
+			ID:      "deleteAppDBSchema",
+			Tags:    []string{"schemas"},
+			Summary: "删除数据库",
+			Query:   api.SubscriptionQuery{},
+			Code:    http.StatusNoContent,
+		})),
 
 		//cmha topology_show
-		router.NewGetRoute("/manager/apps/{app}/topology", r.getCmhaTopology),
+		router.NewGetRoute("/manager/apps/{app}/topology", r.getCmhaTopology, router.WithDoc(router.Doc{
+			ID:       "getCmhaTopology",
+			Tags:     []string{"cmha"},
+			Summary:  "查询cmha拓扑",
+			Query:    api.SubscriptionQuery{},
+			Response: api.CmhaTopology{},
+		})),
 		//cmha set replication_mode
-		router.NewPutRoute("/manager/apps/{app}/replication/semi_sync", r.setCmhaReplMode),
+		router.NewPutRoute("/manager/apps/{app}/replication/semi_sync", r.setCmhaReplMode, router.WithDoc(router.Doc{
+			ID:      "setCmhaReplMode",
+			Tags:    []string{"cmha"},
+			Summary: "设置复制模式",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.CmhaReplModeOptions{},
+		})),
 		//cmha replication: set source
-		router.NewPutRoute("/manager/apps/{app}/replication/set_source", r.setReplSource),
+		router.NewPutRoute("/manager/apps/{app}/replication/set_source", r.setReplSource, router.WithDoc(router.Doc{
+			ID:      "setCmhaReplSource",
+			Tags:    []string{"cmha"},
+			Summary: "设置复制源",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.CmhaReplModeOptions{},
+		})),
 		//cmha set maintenance
-		router.NewPutRoute("/manager/apps/{app}/maintenance", r.setCmhaMaintenance),
+		router.NewPutRoute("/manager/apps/{app}/maintenance", r.setCmhaMaintenance, router.WithDoc(router.Doc{
+			ID:      "setCmhaMaintenance",
+			Tags:    []string{"cmha"},
+			Summary: "设置cmha维护模式",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.CmhaMaintenanceOptions{},
+		})),
 
 		//get json schema
 		//router.NewGetRoute("/manager/jsonschema/{schema}", r.getJsonSchema),
@@ -71,6 +306,7 @@ func RegisterAppRoute(bankend appBankend, routers router.Adder) {
 type appBankend interface {
 	AddApp(ctx context.Context, config api.AppConfig, subscriptionId string) (api.Application, error)
 	ListApps(ctx context.Context, app, name, subscriptionId string, detail bool) (api.AppsResponse, error)
+	ListAppsPage(ctx context.Context, detail bool, opts api.ListOptions) (api.ListResponse, error)
 	ListAppsWithPagination(ctx context.Context, app, name, subscriptionId string, detail bool, pagination api.PaginationReq) (api.PaginationResp, error)
 	DeleteApp(ctx context.Context, app string) (api.TaskObjectResponse, error)
 
@@ -98,6 +334,9 @@ type appBankend interface {
 	GetAppDBUser(ctx context.Context, appID, user, ip string) (api.DatabaseUser, error)
 	ListAppDBUsers(ctx context.Context, app string) (api.AppUsersResponse, error)
 	DeleteAppDBUser(ctx context.Context, app, user, ip string) error
+	DiscardAppDBUserOldPassword(ctx context.Context, app string, config api.AppUserDiscardPasswordConfig) error
+	ListAppDBUserCredentials(ctx context.Context, app string) (api.DBUserCredentialsResponse, error)
+	DBUserPasswordPolicy(ctx context.Context) api.PasswordPolicy
 	//privileges
 	UpdateUserPrivileges(ctx context.Context, appID string, opts api.AppUserPrivilegesOptions) error
 
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+// RegisterDBUserRoute 服务期望的数据库用户及权限
+func RegisterDBUserRoute(bankend dbUserBankend, routers router.Adder) {
+	r := &dbUserRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/apps/{app}/database/user_spec", r.getSpec, router.WithDoc(router.Doc{
+			ID:       "getAppDBUserSpec",
+			Tags:     []string{"users"},
+			Summary:  "查询服务期望的数据库用户及最近一次比较的结果",
+			Query:    api.SubscriptionQuery{},
+			Response: api.AppDBUserSpecResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/database/user_spec", r.setSpec, router.WithDoc(router.Doc{
+			ID:       "setAppDBUserSpec",
+			Tags:     []string{"users"},
+			Summary:  "设置服务期望的数据库用户及权限，report 只报告差异，enforce 创建缺少的用户并修正权限",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.AppDBUserSpec{},
+			Response: api.AppDBUserSpecResponse{},
+		})),
+		router.NewDeleteRoute("/manager/apps/{app}/database/user_spec", r.deleteSpec, router.WithDoc(router.Doc{
+			ID:      "deleteAppDBUserSpec",
+			Tags:    []string{"users"},
+			Summary: "删除服务期望的数据库用户，不影响实例中的用户",
+			Query:   api.SubscriptionQuery{},
+			Code:    http.StatusNoContent,
+		})),
+		router.NewPostRoute("/manager/apps/{app}/database/user_spec/reconcile", r.reconcile, router.WithDoc(router.Doc{
+			ID:       "reconcileAppDBUser",
+			Tags:     []string{"users"},
+			Summary:  "立即比较服务的数据库用户与期望",
+			Query:    api.SubscriptionQuery{},
+			Response: api.DBUserReconcileStatus{},
+		})),
+		router.NewGetRoute("/manager/apps/{app}/database/unmanaged_users", r.listUnmanaged, router.WithDoc(router.Doc{
+			ID:       "listAppUnmanagedDBUsers",
+			Tags:     []string{"users"},
+			Summary:  "查询实例中不在期望中的数据库用户",
+			Query:    api.SubscriptionQuery{},
+			Response: api.AppUsersResponse{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type dbUserBankend interface {
+	SetAppDBUserSpec(ctx context.Context, appID, subscriptionID string, spec api.AppDBUserSpec) (api.AppDBUserSpecResponse, error)
+	GetAppDBUserSpec(ctx context.Context, appID, subscriptionID string) (api.AppDBUserSpecResponse, error)
+	DeleteAppDBUserSpec(ctx context.Context, appID, subscriptionID string) error
+	ReconcileAppDBUser(ctx context.Context, appID, subscriptionID string) (api.DBUserReconcileStatus, error)
+	ListAppUnmanagedDBUsers(ctx context.Context, appID, subscriptionID string) (api.AppUsersResponse, error)
+}
+
+type dbUserRoute struct {
+	bankend dbUserBankend
+
+	routes []router.Route
+}
+
+func (dr dbUserRoute) Routes() []router.Route {
+	return dr.routes
+}
+
+func (dr dbUserRoute) getSpec(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	spec, err := dr.bankend.GetAppDBUserSpec(ctx, vars["app"], r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, spec, nil
+}
+
+func (dr dbUserRoute) setSpec(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	spec := api.AppDBUserSpec{}
+
+	err := json.NewDecoder(r.Body).Decode(&spec)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := spec.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	resp, err := dr.bankend.SetAppDBUserSpec(ctx, vars["app"], r.FormValue("subscription_id"), spec)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, resp, nil
+}
+
+func (dr dbUserRoute) deleteSpec(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	err := dr.bankend.DeleteAppDBUserSpec(ctx, vars["app"], r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+func (dr dbUserRoute) reconcile(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	status, err := dr.bankend.ReconcileAppDBUser(ctx, vars["app"], r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, status, nil
+}
+
+func (dr dbUserRoute) listUnmanaged(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	users, err := dr.bankend.ListAppUnmanagedDBUsers(ctx, vars["app"], r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, users, nil
+}
@@ -18,6 +18,8 @@ type listAppsRequest struct {
 	// in: query
 	// required: false
 	Name string `json:"name"`
+
+	api.ListRequest
 }
 
 // list Apps info
@@ -38,12 +40,17 @@ func (ar appRoute) listApps(ctx context.Context, w http.ResponseWriter, r *http.
 	//
 	//     Responses:
 	//       200: listAppsResponseWrapper
+	//       400: ErrorResponse
 	//       500: ErrorResponse
 
 	subscriptionId := r.FormValue("subscription_id")
 	id := r.FormValue("id")
 	name := r.FormValue("name")
 
+	if api.IsListRequest(r.URL.Query()) {
+		return ar.listAppsPage(ctx, r, id, name, subscriptionId, false)
+	}
+
 	list, err := ar.bankend.ListApps(ctx, id, name, subscriptionId, false)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -66,12 +73,17 @@ func (ar appRoute) listAppsDetail(ctx context.Context, w http.ResponseWriter, r
 	//
 	//     Responses:
 	//       200: listAppsResponseWrapper
+	//       400: ErrorResponse
 	//       500: ErrorResponse
 
 	subscriptionId := r.FormValue("subscription_id")
 	id := r.FormValue("id")
 	name := r.FormValue("name")
 
+	if api.IsListRequest(r.URL.Query()) {
+		return ar.listAppsPage(ctx, r, id, name, subscriptionId, true)
+	}
+
 	list, err := ar.bankend.ListApps(ctx, id, name, subscriptionId, true)
 	if len(list) > 0 {
 		return http.StatusOK, list, nil
@@ -87,6 +99,24 @@ func (ar appRoute) listAppsDetail(ctx context.Context, w http.ResponseWriter, r
 	return http.StatusOK, list, nil
 }
 
+func (ar appRoute) listAppsPage(ctx context.Context, r *http.Request, id, name, subscriptionId string, detail bool) (int, interface{}, error) {
+	opts, err := api.ParseListOptions(r.URL.Query(), api.AppListFields)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	opts.AddFilter("id", id)
+	opts.AddFilter("name", name)
+	opts.AddFilter("subscription_id", subscriptionId)
+
+	resp, err := ar.bankend.ListAppsPage(ctx, detail, opts)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, resp, nil
+}
+
 // list object options
 //
 // swagger:parameters listAppDBSchema
@@ -405,3 +435,23 @@ func (ar appRoute) getCmhaTopology(ctx context.Context, w http.ResponseWriter, r
 //
 //	return http.StatusOK, string(jsonSchema), nil
 //}
+
+func (ar appRoute) listAppDBUserCredentials(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	app := vars["app"]
+
+	err := ar.bankend.CheckAppAndSubscription(ctx, app, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	list, err := ar.bankend.ListAppDBUserCredentials(ctx, app)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (ar appRoute) getPasswordPolicy(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	return http.StatusOK, ar.bankend.DBUserPasswordPolicy(ctx), nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterManifestRoute(bankend manifestBankend, routers router.Adder) {
+	r := &manifestRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewPostRoute("/manager/apps/manifests/plan", r.planManifest, router.WithDoc(router.Doc{
+			ID:       "planAppManifest",
+			Tags:     []string{"apps"},
+			Summary:  "生成服务manifest的执行计划",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.AppManifest{},
+			Response: api.AppManifestPlan{},
+		})),
+		router.NewPostRoute("/manager/apps/manifests/apply", r.applyManifest, router.WithDoc(router.Doc{
+			ID:       "applyAppManifest",
+			Tags:     []string{"apps"},
+			Summary:  "在一个任务中执行服务manifest",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.AppManifest{},
+			Code:     http.StatusCreated,
+			Response: api.AppManifestPlan{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type manifestBankend interface {
+	Plan(ctx context.Context, manifest api.AppManifest, subscriptionId string) (api.AppManifestPlan, error)
+	Apply(ctx context.Context, manifest api.AppManifest, subscriptionId string) (api.AppManifestPlan, error)
+}
+
+type manifestRoute struct {
+	bankend manifestBankend
+
+	routes []router.Route
+}
+
+func (mr manifestRoute) Routes() []router.Route {
+	return mr.routes
+}
+
+func decodeManifest(r *http.Request) (api.AppManifest, error) {
+	manifest := api.AppManifest{}
+
+	err := json.NewDecoder(r.Body).Decode(&manifest)
+	if err != nil {
+		return manifest, err
+	}
+
+	return manifest, manifest.Valid()
+}
+
+func (mr manifestRoute) planManifest(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	manifest, err := decodeManifest(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	plan, err := mr.bankend.Plan(ctx, manifest, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, plan, nil
+}
+
+func (mr manifestRoute) applyManifest(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	manifest, err := decodeManifest(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	plan, err := mr.bankend.Apply(ctx, manifest, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusCreated, plan, nil
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+// RegisterMigrationRoute 服务数据库的版本化DDL迁移
+func RegisterMigrationRoute(bankend migrationBankend, routers router.Adder) {
+	r := &migrationRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/apps/{app}/database/schemas/{schema}/migrations", r.listMigrations, router.WithDoc(router.Doc{
+			ID:       "listSchemaMigrations",
+			Tags:     []string{"schemas"},
+			Summary:  "查询数据库的迁移版本及执行记录",
+			Query:    api.SubscriptionQuery{},
+			Response: api.SchemaMigrationsResponse{},
+		})),
+		router.NewPutRoute("/manager/apps/{app}/database/schemas/{schema}/migrations", r.setMigrations, router.WithDoc(router.Doc{
+			ID:       "setSchemaMigrations",
+			Tags:     []string{"schemas"},
+			Summary:  "上传数据库的迁移集合，已执行的版本不能修改，未执行的版本被替换",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.SchemaMigrationSet{},
+			Response: api.SchemaMigrationsResponse{},
+		})),
+		router.NewPostRoute("/manager/apps/{app}/database/schemas/{schema}/migrations/apply", r.applyMigrations, router.WithDoc(router.Doc{
+			ID:       "applySchemaMigrations",
+			Tags:     []string{"schemas"},
+			Summary:  "在一个任务中按版本执行未执行的迁移，dry_run 时只在草稿库中验证",
+			Query:    api.SubscriptionQuery{},
+			Body:     api.SchemaMigrationApplyOptions{},
+			Code:     http.StatusCreated,
+			Response: api.SchemaMigrationApplyResponse{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type migrationBankend interface {
+	ListSchemaMigrations(ctx context.Context, app, schema, subscriptionID string) (api.SchemaMigrationsResponse, error)
+	SetSchemaMigrations(ctx context.Context, app, schema, subscriptionID string, set api.SchemaMigrationSet) (api.SchemaMigrationsResponse, error)
+	ApplySchemaMigrations(ctx context.Context, app, schema, subscriptionID string, opts api.SchemaMigrationApplyOptions) (api.SchemaMigrationApplyResponse, error)
+}
+
+type migrationRoute struct {
+	bankend migrationBankend
+
+	routes []router.Route
+}
+
+func (mr migrationRoute) Routes() []router.Route {
+	return mr.routes
+}
+
+func (mr migrationRoute) listMigrations(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	list, err := mr.bankend.ListSchemaMigrations(ctx, vars["app"], vars["schema"], r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (mr migrationRoute) setMigrations(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	set := api.SchemaMigrationSet{}
+
+	err := json.NewDecoder(r.Body).Decode(&set)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := set.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	list, err := mr.bankend.SetSchemaMigrations(ctx, vars["app"], vars["schema"], r.FormValue("subscription_id"), set)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (mr migrationRoute) applyMigrations(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	opts := api.SchemaMigrationApplyOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&opts)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := opts.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	resp, err := mr.bankend.ApplySchemaMigrations(ctx, vars["app"], vars["schema"], r.FormValue("subscription_id"), opts)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusCreated, resp, nil
+}
@@ -35,10 +35,16 @@ func (ar appRoute) updateAppImage(ctx context.Context, w http.ResponseWriter, r
 	//       500: ErrorResponse
 
 	app := vars["app"]
+	subscriptionId := r.FormValue("subscription_id")
+
+	err := ar.bankend.CheckAppAndSubscription(ctx, app, subscriptionId)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
 
 	req := api.AppImageOptions{}
 
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		return http.StatusBadRequest, nil, err
 	}
@@ -82,10 +88,16 @@ func (ar appRoute) updateAppResources(ctx context.Context, w http.ResponseWriter
 	//       500: ErrorResponse
 
 	app := vars["app"]
+	subscriptionId := r.FormValue("subscription_id")
+
+	err := ar.bankend.CheckAppAndSubscription(ctx, app, subscriptionId)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
 
 	req := api.AppResourcesOptions{}
 
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		return http.StatusBadRequest, nil, err
 	}
@@ -531,6 +543,33 @@ func (ar appRoute) resetAppUserPassword(ctx context.Context, w http.ResponseWrit
 	return http.StatusOK, nil, nil
 }
 
+func (ar appRoute) discardAppUserOldPassword(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	app := vars["app"]
+
+	err := ar.bankend.CheckAppAndSubscription(ctx, app, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	req := api.AppUserDiscardPasswordConfig{}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	err = ar.bankend.DiscardAppDBUserOldPassword(ctx, app, req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, nil, nil
+}
+
 func (ar appRoute) roleSwitch(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
 	// swagger:route PUT /manager/apps/{app}/role  apps roleSwitch
 	//
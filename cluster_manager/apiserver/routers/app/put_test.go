@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+type fakeAppBankend struct {
+	appBankend
+
+	// subscriptions 服务所属的订阅
+	subscriptions map[string]string
+	calls         []string
+}
+
+func (f *fakeAppBankend) CheckAppAndSubscription(ctx context.Context, app, subscriptionId string) error {
+	if subscriptionId != "" && f.subscriptions[app] != subscriptionId {
+		return errors.New("app not found or permission denied")
+	}
+
+	return nil
+}
+
+func (f *fakeAppBankend) UpdateImage(ctx context.Context, app string, opts api.AppImageOptions) (api.TaskObjectResponse, error) {
+	f.calls = append(f.calls, "UpdateImage "+app)
+	return api.TaskObjectResponse{}, nil
+}
+
+func (f *fakeAppBankend) UpdateAppResourceRequests(ctx context.Context, app string, opts api.AppResourcesOptions) (api.TaskObjectResponse, error) {
+	f.calls = append(f.calls, "UpdateAppResourceRequests "+app)
+	return api.TaskObjectResponse{}, nil
+}
+
+func TestUpdateAppSubscription(t *testing.T) {
+	bankend := &fakeAppBankend{subscriptions: map[string]string{"app1": "t1", "app2": "t2"}}
+	ar := appRoute{bankend: bankend}
+
+	cases := []struct {
+		name    string
+		handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error)
+		url     string
+		app     string
+		code    int
+		calls   string
+	}{
+		{"image of other subscription", ar.updateAppImage, "/manager/apps/app2/image?subscription_id=t1", "app2", http.StatusBadRequest, ""},
+		{"image", ar.updateAppImage, "/manager/apps/app1/image?subscription_id=t1", "app1", http.StatusOK, "UpdateImage app1"},
+		{"image without subscription", ar.updateAppImage, "/manager/apps/app2/image", "app2", http.StatusOK, "UpdateImage app2"},
+		{"resources of other subscription", ar.updateAppResources, "/manager/apps/app2/resource/requests?subscription_id=t1", "app2", http.StatusBadRequest, ""},
+	}
+
+	for _, c := range cases {
+		bankend.calls = nil
+
+		r := httptest.NewRequest(http.MethodPut, c.url, strings.NewReader(`{}`))
+
+		code, _, err := c.handler(context.Background(), httptest.NewRecorder(), r, map[string]string{"app": c.app})
+		if code != c.code {
+			t.Errorf("%s:expected %d but got %d,%v", c.name, c.code, code, err)
+		}
+
+		if calls := strings.Join(bankend.calls, ";"); calls != c.calls {
+			t.Errorf("%s:expected calls %q but got %q", c.name, c.calls, calls)
+		}
+	}
+}
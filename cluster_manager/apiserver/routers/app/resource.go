@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// RegisterAppResourceRoute 站点中 App 对象的入口，对象由站点的 operator 同步
+func RegisterAppResourceRoute(bankend appResourceBankend, routers router.Adder) {
+	r := &appResourceRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewPutRoute("/manager/apps/resources", r.applyResource, router.WithDoc(router.Doc{
+			ID:       "applyAppResource",
+			Tags:     []string{"apps"},
+			Summary:  "创建或更新站点中的App对象",
+			Query:    api.AppResourceQuery{},
+			Body:     api.AppResourceOptions{},
+			Response: api.AppResource{},
+		})),
+		router.NewGetRoute("/manager/apps/resources/{name}", r.getResource, router.WithDoc(router.Doc{
+			ID:       "getAppResource",
+			Tags:     []string{"apps"},
+			Summary:  "查询站点中App对象的同步状态",
+			Query:    api.AppResourceQuery{},
+			Response: api.AppResource{},
+		})),
+		router.NewDeleteRoute("/manager/apps/resources/{name}", r.deleteResource, router.WithDoc(router.Doc{
+			ID:      "deleteAppResource",
+			Tags:    []string{"apps"},
+			Summary: "删除站点中的App对象",
+			Query:   api.AppResourceQuery{},
+			Code:    http.StatusNoContent,
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type appResourceBankend interface {
+	ApplyResource(ctx context.Context, query api.AppResourceQuery, opts api.AppResourceOptions) (api.AppResource, error)
+	GetResource(ctx context.Context, query api.AppResourceQuery, name string) (api.AppResource, error)
+	DeleteResource(ctx context.Context, query api.AppResourceQuery, name string) error
+}
+
+type appResourceRoute struct {
+	bankend appResourceBankend
+
+	routes []router.Route
+}
+
+func (ar appResourceRoute) Routes() []router.Route {
+	return ar.routes
+}
+
+func decodeAppResourceQuery(r *http.Request) (api.AppResourceQuery, error) {
+	query := api.AppResourceQuery{
+		SiteID:    r.FormValue("site_id"),
+		Namespace: r.FormValue("namespace"),
+	}
+	query.SubscriptionID = r.FormValue("subscription_id")
+
+	if query.SiteID == "" {
+		return query, fmt.Errorf("site_id is required")
+	}
+
+	return query, nil
+}
+
+func (ar appResourceRoute) applyResource(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	query, err := decodeAppResourceQuery(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	opts := api.AppResourceOptions{}
+
+	err = json.NewDecoder(r.Body).Decode(&opts)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	switch opts.DeletionPolicy {
+	case "", "Retain", "Delete":
+	default:
+		return http.StatusBadRequest, nil, fmt.Errorf("unsupported deletion_policy %s", opts.DeletionPolicy)
+	}
+
+	err = opts.Manifest.Valid()
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	resource, err := ar.bankend.ApplyResource(ctx, query, opts)
+	if errors.IsConflict(err) {
+		return http.StatusConflict, nil, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, resource, nil
+}
+
+func (ar appResourceRoute) getResource(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	query, err := decodeAppResourceQuery(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	resource, err := ar.bankend.GetResource(ctx, query, vars["name"])
+	if errors.IsNotFound(err) {
+		return http.StatusNotFound, nil, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, resource, nil
+}
+
+func (ar appResourceRoute) deleteResource(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	query, err := decodeAppResourceQuery(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	err = ar.bankend.DeleteResource(ctx, query, vars["name"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
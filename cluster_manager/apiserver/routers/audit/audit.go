@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterAuditRoute(bankend auditBankend, routers router.Adder) {
+	r := &auditRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/audit", r.listAudits, router.WithDoc(router.Doc{
+			ID:       "listAudits",
+			Tags:     []string{"audit"},
+			Summary:  "查询审计记录",
+			Query:    api.AuditListQuery{},
+			Response: api.AuditLogsResponse{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type auditRoute struct {
+	bankend auditBankend
+
+	routes []router.Route
+}
+
+func (ar auditRoute) Routes() []router.Route {
+	return ar.routes
+}
+
+type auditBankend interface {
+	ListAudits(ctx context.Context, query api.AuditQuery) (api.AuditLogsResponse, error)
+}
+
+// list audit logs options
+//
+// swagger:parameters listAudits
+type listAuditsRequest struct {
+	// in: query
+	// required: false
+	User string `json:"user"`
+
+	// in: query
+	// required: false
+	App string `json:"app_id"`
+
+	// 前缀匹配，如 db_user.
+	//
+	// in: query
+	// required: false
+	Action string `json:"action"`
+
+	// 开始时间，格式 2006-01-02 15:04:05 或 RFC3339
+	//
+	// in: query
+	// required: false
+	Since string `json:"since"`
+
+	// 结束时间，格式 2006-01-02 15:04:05 或 RFC3339
+	//
+	// in: query
+	// required: false
+	Until string `json:"until"`
+
+	// 默认500
+	//
+	// in: query
+	// required: false
+	Limit int `json:"limit"`
+}
+
+// audit logs
+//
+// swagger:response listAuditsResponseWrapper
+type listAuditsResponseWrapper struct {
+	// in: body
+	Body api.AuditLogsResponse
+}
+
+func (ar auditRoute) listAudits(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/audit audit listAudits
+	//
+	// 查询审计记录
+	//
+	// List audit logs
+	// This will returns the audit logs of mutating api calls,newest first
+	//
+	//     Responses:
+	//       200: listAuditsResponseWrapper
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	var (
+		err   error
+		query = api.AuditQuery{
+			User:   r.FormValue("user"),
+			App:    r.FormValue("app_id"),
+			Action: r.FormValue("action"),
+			Limit:  api.DefaultAuditLimit,
+		}
+	)
+
+	if query.Since, err = api.ParseAuditTime(r.FormValue("since")); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if query.Until, err = api.ParseAuditTime(r.FormValue("until")); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+	}
+
+	if err := query.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	list, err := ar.bankend.ListAudits(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
@@ -2,6 +2,7 @@ package backup
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 	"github.com/upmio/dbscale-kube/pkg/server/router"
@@ -13,19 +14,90 @@ func RegisterBackupRoute(bankend backupBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewGetRoute("/manager/backup/files", r.listBackupFiles),
-		router.NewDeleteRoute("/manager/backup/files", r.deleteBackupFile),
-
-		router.NewPostRoute("/manager/backup/strategy", r.postStrategy),
-		router.NewPutRoute("/manager/backup/strategy/{id}", r.updateStrategy),
-		router.NewGetRoute("/manager/backup/strategy", r.listBackupStrategy),
-		router.NewDeleteRoute("/manager/backup/strategy", r.deleteBackupStrategy),
-
-		router.NewGetRoute("/manager/backup/endpoint", r.listBackupEndpoints),
-		router.NewPostRoute("/manager/backup/endpoint", r.createBackupEndpoint),
-		router.NewPutRoute("/manager/backup/endpoint/{id}", r.updateBackupEndpoint),
-		router.NewGetRoute("/manager/backup/endpoint/{id}", r.getBackupEndpoint),
-		router.NewDeleteRoute("/manager/backup/endpoint/{id}", r.deleteBackupEndpoint),
+		router.NewGetRoute("/manager/backup/files", r.listBackupFiles, router.WithDoc(router.Doc{
+			ID:       "listBackupFiles",
+			Tags:     []string{"backup"},
+			Summary:  "查询备份文件",
+			Query:    api.BackupFileListQuery{},
+			Response: api.BackupFilesResponse{},
+			Paged:    true,
+		})),
+		router.NewDeleteRoute("/manager/backup/files", r.deleteBackupFile, router.WithDoc(router.Doc{
+			ID:      "deleteBackupFile",
+			Tags:    []string{"backup"},
+			Summary: "删除备份文件",
+			Query:   api.IDAppQuery{},
+			Code:    http.StatusNoContent,
+		})),
+
+		router.NewPostRoute("/manager/backup/strategy", r.postStrategy, router.WithDoc(router.Doc{
+			ID:         "postBackupStrategy",
+			Tags:       []string{"backup"},
+			Summary:    "增加备份策略",
+			Query:      api.SubscriptionQuery{},
+			Body:       api.BackupStrategyConfig{},
+			BodySchema: "backupStrategy.json",
+			Code:       http.StatusCreated,
+			Response:   api.ObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/backup/strategy/{id}", r.updateStrategy, router.WithDoc(router.Doc{
+			ID:      "updateBackupStrategy",
+			Tags:    []string{"backup"},
+			Summary: "更新备份策略",
+			Query:   api.SubscriptionQuery{},
+			Body:    api.BackupStrategyOptions{},
+		})),
+		router.NewGetRoute("/manager/backup/strategy", r.listBackupStrategy, router.WithDoc(router.Doc{
+			ID:       "listBackupStrategies",
+			Tags:     []string{"backup"},
+			Summary:  "查询备份策略",
+			Query:    api.BackupStrategyListQuery{},
+			Response: api.BackupStrategyResponse{},
+		})),
+		router.NewDeleteRoute("/manager/backup/strategy", r.deleteBackupStrategy, router.WithDoc(router.Doc{
+			ID:      "deleteBackupStrategy",
+			Tags:    []string{"backup"},
+			Summary: "删除备份策略",
+			Query:   api.IDAppQuery{},
+			Code:    http.StatusNoContent,
+		})),
+
+		router.NewGetRoute("/manager/backup/endpoint", r.listBackupEndpoints, router.WithDoc(router.Doc{
+			ID:       "listBackupEndpoints",
+			Tags:     []string{"backup"},
+			Summary:  "查询备份存储",
+			Query:    api.BackupEndpointListQuery{},
+			Response: []api.BackupEndpoint{},
+		})),
+		router.NewPostRoute("/manager/backup/endpoint", r.createBackupEndpoint, router.WithDoc(router.Doc{
+			ID:         "postBackupEndpoint",
+			Tags:       []string{"backup"},
+			Summary:    "增加备份存储",
+			Body:       api.BackupEndpoint{},
+			BodySchema: "backupEndpoint.json",
+			Code:       http.StatusCreated,
+			Response:   api.BackupEndpoint{},
+		})),
+		router.NewPutRoute("/manager/backup/endpoint/{id}", r.updateBackupEndpoint, router.WithDoc(router.Doc{
+			ID:         "updateBackupEndpoint",
+			Tags:       []string{"backup"},
+			Summary:    "更新备份存储",
+			Body:       api.BackupEndpoint{},
+			BodySchema: "backupEndpoint.json",
+			Response:   api.BackupEndpoint{},
+		})),
+		router.NewGetRoute("/manager/backup/endpoint/{id}", r.getBackupEndpoint, router.WithDoc(router.Doc{
+			ID:       "getBackupEndpoint",
+			Tags:     []string{"backup"},
+			Summary:  "查询备份存储详情",
+			Response: api.BackupEndpoint{},
+		})),
+		router.NewDeleteRoute("/manager/backup/endpoint/{id}", r.deleteBackupEndpoint, router.WithDoc(router.Doc{
+			ID:      "deleteBackupEndpoint",
+			Tags:    []string{"backup"},
+			Summary: "删除备份存储",
+			Code:    http.StatusNoContent,
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -41,8 +113,29 @@ func (br backupRoute) Routes() []router.Route {
 	return br.routes
 }
 
+// checkIDAppSubscription checks the object of id and the app belong to the subscription
+func (br backupRoute) checkIDAppSubscription(ctx context.Context, check func(ctx context.Context, id, subscriptionId string) error, id, app, subscriptionId string) error {
+	if id != "" {
+		if err := check(ctx, id, subscriptionId); err != nil {
+			return err
+		}
+	}
+
+	if app != "" {
+		return br.bankend.CheckAppAndSubscription(ctx, app, subscriptionId)
+	}
+
+	return nil
+}
+
 type backupBankend interface {
+	CheckAppAndSubscription(ctx context.Context, app, subscriptionId string) error
+	CheckStrategySubscription(ctx context.Context, id, subscriptionId string) error
+	CheckBackupFileSubscription(ctx context.Context, id, subscriptionId string) error
+	SubscriptionApps(ctx context.Context, subscriptionId string) (map[string]bool, error)
+
 	ListBackupFiles(ctx context.Context, id, unit, app, site, user string) (api.BackupFilesResponse, error)
+	ListBackupFilesPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error)
 	DeleteBackupFile(ctx context.Context, id, app string) error
 
 	AddBackupStrategy(ctx context.Context, config api.BackupStrategyConfig) (api.ObjectResponse, error)
@@ -27,6 +27,8 @@ type listBackupFilesRequest struct {
 	// in: query
 	// required: false
 	Site string `json:"site_id"`
+
+	api.ListRequest
 }
 
 // list Apps info
@@ -47,6 +49,7 @@ func (br backupRoute) listBackupFiles(ctx context.Context, w http.ResponseWriter
 	//
 	//     Responses:
 	//       200: listBackupFilesResponseWrapper
+	//       400: ErrorResponse
 	//       500: ErrorResponse
 
 	id := r.FormValue("id")
@@ -55,6 +58,44 @@ func (br backupRoute) listBackupFiles(ctx context.Context, w http.ResponseWriter
 	site := r.FormValue("site_id")
 	createdUser := r.FormValue("created_user")
 
+	apps, err := br.bankend.SubscriptionApps(ctx, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	if api.IsListRequest(r.URL.Query()) {
+		opts, err := api.ParseListOptions(r.URL.Query(), api.BackupFileListFields)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+
+		opts.AddFilter("id", id)
+		opts.AddFilter("unit_id", unit)
+		opts.AddFilter("app_id", app)
+		opts.AddFilter("site_id", site)
+		opts.AddFilter("created_user", createdUser)
+
+		if apps != nil {
+			if len(apps) == 0 {
+				return http.StatusOK, api.ListResponse{Items: []api.BackupFile{}}, nil
+			}
+
+			ids := make([]string, 0, len(apps))
+			for id := range apps {
+				ids = append(ids, id)
+			}
+
+			opts.Filters = append(opts.Filters, api.ListFilter{Field: "app_id", Operator: api.FilterIn, Values: ids})
+		}
+
+		resp, err := br.bankend.ListBackupFilesPage(ctx, opts)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		return http.StatusOK, resp, nil
+	}
+
 	list, err := br.bankend.ListBackupFiles(ctx, id, unit, app, site, createdUser)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -64,6 +105,17 @@ func (br backupRoute) listBackupFiles(ctx context.Context, w http.ResponseWriter
 		return http.StatusOK, api.BackupFilesResponse{}, nil
 	}
 
+	if apps != nil {
+		out := make(api.BackupFilesResponse, 0, len(list))
+		for i := range list {
+			if apps[list[i].App.ID] {
+				out = append(out, list[i])
+			}
+		}
+
+		list = out
+	}
+
 	return http.StatusOK, list, nil
 }
 
@@ -101,7 +153,12 @@ func (br backupRoute) deleteBackupFile(ctx context.Context, w http.ResponseWrite
 		return http.StatusBadRequest, nil, errors.New("id or app_id is required in delete backup files")
 	}
 
-	err := br.bankend.DeleteBackupFile(ctx, id, app)
+	err := br.checkIDAppSubscription(ctx, br.bankend.CheckBackupFileSubscription, id, app, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	err = br.bankend.DeleteBackupFile(ctx, id, app)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
 	}
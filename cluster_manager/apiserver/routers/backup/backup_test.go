@@ -0,0 +1,261 @@
+package backup
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/bankend"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/model"
+)
+
+type fakeStrategies struct {
+	list    []model.BackupStrategy
+	changed []string
+}
+
+func (f *fakeStrategies) Lock(id string) (model.BackupStrategy, error) {
+	return f.GetStrategy(id)
+}
+
+func (f *fakeStrategies) GetStrategy(id string) (model.BackupStrategy, error) {
+	for i := range f.list {
+		if f.list[i].ID == id {
+			return f.list[i], nil
+		}
+	}
+
+	return model.BackupStrategy{}, model.NewNotFound("backup strategy", id)
+}
+
+func (f *fakeStrategies) ListStrategy(selector map[string]string) ([]model.BackupStrategy, error) {
+	return f.list, nil
+}
+
+func (f *fakeStrategies) InsertStrategy(bs model.BackupStrategy) (string, error) {
+	f.changed = append(f.changed, "insert "+bs.App)
+	return "", nil
+}
+
+func (f *fakeStrategies) UpdateStrategy(bs model.BackupStrategy) error {
+	f.changed = append(f.changed, "update "+bs.ID)
+	return nil
+}
+
+func (f *fakeStrategies) DeleteStrategy(id, app string) error {
+	f.changed = append(f.changed, "delete "+id+app)
+	return nil
+}
+
+type fakeFiles struct {
+	list    []model.BackupFile
+	changed []string
+}
+
+func (f *fakeFiles) GetFile(id string) (model.BackupFile, error) {
+	for i := range f.list {
+		if f.list[i].ID == id {
+			return f.list[i], nil
+		}
+	}
+
+	return model.BackupFile{}, model.NewNotFound("backup file", id)
+}
+
+func (f *fakeFiles) ListFiles(selector map[string]string) ([]model.BackupFile, error) {
+	f.changed = append(f.changed, "list "+selector["id"]+selector["app_id"])
+	return f.list, nil
+}
+
+func (f *fakeFiles) ListFilesPage(opts api.ListOptions) ([]model.BackupFile, api.ListMeta, error) {
+	out := []model.BackupFile{}
+
+	for _, file := range f.list {
+		ok := true
+		for _, filter := range opts.Filters {
+			if filter.Field == "app_id" && !strings.Contains(strings.Join(filter.Values, ","), file.App) {
+				ok = false
+			}
+		}
+
+		if ok {
+			out = append(out, file)
+		}
+	}
+
+	return out, api.ListMeta{Total: uint64(len(out))}, nil
+}
+
+func (f *fakeFiles) InsertFile(bf model.BackupFile) (string, error) { return "", nil }
+func (f *fakeFiles) UpdateFile(bf model.BackupFile) error           { return nil }
+func (f *fakeFiles) BackupJobDone(bf model.BackupFile) error        { return nil }
+func (f *fakeFiles) DeleteFile(id string) error                     { return nil }
+
+func TestBackupRouteSubscription(t *testing.T) {
+	fm := model.NewFakeModels()
+	apps := fm.ModelApp()
+
+	app1, _, err := apps.Insert(model.Application{Name: "app1", SubscriptionId: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app2, _, err := apps.Insert(model.Application{Name: "app2", SubscriptionId: "t2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strategies := &fakeStrategies{list: []model.BackupStrategy{{ID: "s1", App: app1, Schedule: "0 1 * * *"}, {ID: "s2", App: app2, Schedule: "0 1 * * *"}}}
+	files := &fakeFiles{list: []model.BackupFile{{ID: "f1", App: app1}, {ID: "f2", App: app2}}}
+
+	br := backupRoute{bankend: bankend.NewBackupBankend(nil, nil, strategies, files, fm.ModelBackupEndpoint(), apps)}
+
+	type handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error)
+
+	cases := []struct {
+		name    string
+		handler handler
+		method  string
+		url     string
+		vars    map[string]string
+		body    string
+		code    int
+		changed string
+	}{
+		{
+			name:    "add strategy of other subscription",
+			handler: br.postStrategy,
+			method:  http.MethodPost,
+			url:     "/manager/backup/strategy?subscription_id=t1",
+			body:    `{"app_id":"` + app2 + `","schedule":"0 1 * * *","type":"full","retention":7}`,
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "update strategy of other subscription",
+			handler: br.updateStrategy,
+			method:  http.MethodPut,
+			url:     "/manager/backup/strategy/s2?subscription_id=t1",
+			vars:    map[string]string{"id": "s2"},
+			body:    `{"enabled":false}`,
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "move strategy to other subscription",
+			handler: br.updateStrategy,
+			method:  http.MethodPut,
+			url:     "/manager/backup/strategy/s1?subscription_id=t1",
+			vars:    map[string]string{"id": "s1"},
+			body:    `{"app_id":"` + app2 + `"}`,
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "update strategy",
+			handler: br.updateStrategy,
+			method:  http.MethodPut,
+			url:     "/manager/backup/strategy/s1?subscription_id=t1",
+			vars:    map[string]string{"id": "s1"},
+			body:    `{"enabled":false}`,
+			code:    http.StatusOK,
+			changed: "update s1",
+		},
+		{
+			name:    "delete strategy of other subscription",
+			handler: br.deleteBackupStrategy,
+			method:  http.MethodDelete,
+			url:     "/manager/backup/strategy?id=s2&subscription_id=t1",
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "delete strategies of other app",
+			handler: br.deleteBackupStrategy,
+			method:  http.MethodDelete,
+			url:     "/manager/backup/strategy?app_id=" + app2 + "&subscription_id=t1",
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "delete strategy",
+			handler: br.deleteBackupStrategy,
+			method:  http.MethodDelete,
+			url:     "/manager/backup/strategy?id=s1&subscription_id=t1",
+			code:    http.StatusNoContent,
+			changed: "delete s1",
+		},
+		{
+			name:    "delete file of other subscription",
+			handler: br.deleteBackupFile,
+			method:  http.MethodDelete,
+			url:     "/manager/backup/files?id=f2&subscription_id=t1",
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "delete files of other app",
+			handler: br.deleteBackupFile,
+			method:  http.MethodDelete,
+			url:     "/manager/backup/files?app_id=" + app2 + "&subscription_id=t1",
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "create endpoint",
+			handler: br.createBackupEndpoint,
+			method:  http.MethodPost,
+			url:     "/manager/backup/endpoint?subscription_id=t1",
+			body:    `{}`,
+			code:    http.StatusForbidden,
+		},
+		{
+			name:    "delete endpoint",
+			handler: br.deleteBackupEndpoint,
+			method:  http.MethodDelete,
+			url:     "/manager/backup/endpoint/e1?subscription_id=t1",
+			vars:    map[string]string{"id": "e1"},
+			code:    http.StatusForbidden,
+		},
+	}
+
+	for _, c := range cases {
+		strategies.changed, files.changed = nil, nil
+
+		r := httptest.NewRequest(c.method, c.url, strings.NewReader(c.body))
+
+		code, _, err := c.handler(context.Background(), httptest.NewRecorder(), r, c.vars)
+		if code != c.code {
+			t.Errorf("%s:expected %d but got %d,%v", c.name, c.code, code, err)
+		}
+
+		changed := strings.Join(append(strategies.changed, files.changed...), ";")
+		if changed != c.changed {
+			t.Errorf("%s:expected changes %q but got %q", c.name, c.changed, changed)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/manager/backup/strategy?subscription_id=t1", nil)
+	if _, obj, err := br.listBackupStrategy(context.Background(), httptest.NewRecorder(), r, nil); err != nil {
+		t.Fatal(err)
+	} else if list := obj.(api.BackupStrategyResponse); len(list) != 1 || list[0].ID != "s1" {
+		t.Errorf("expected strategies of t1 but got %+v", list)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/manager/backup/files?subscription_id=t2", nil)
+	if _, obj, err := br.listBackupFiles(context.Background(), httptest.NewRecorder(), r, nil); err != nil {
+		t.Fatal(err)
+	} else if list := obj.(api.BackupFilesResponse); len(list) != 1 || list[0].ID != "f2" {
+		t.Errorf("expected files of t2 but got %+v", list)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/manager/backup/files?limit=10&subscription_id=t1", nil)
+	if _, obj, err := br.listBackupFiles(context.Background(), httptest.NewRecorder(), r, nil); err != nil {
+		t.Fatal(err)
+	} else if resp := obj.(api.ListResponse); resp.Total != 1 {
+		t.Errorf("expected a page of files of t1 but got %+v", resp)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/manager/backup/files?limit=10&subscription_id=t3", nil)
+	if _, obj, err := br.listBackupFiles(context.Background(), httptest.NewRecorder(), r, nil); err != nil {
+		t.Fatal(err)
+	} else if resp := obj.(api.ListResponse); resp.Total != 0 {
+		t.Errorf("expected no files of t3 but got %+v", resp)
+	}
+}
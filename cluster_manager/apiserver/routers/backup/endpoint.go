@@ -3,10 +3,20 @@ package backup
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
 	"net/http"
 )
 
+// checkNotSubscription 备份存储是站点级资源，限定订阅的请求不能修改
+func checkNotSubscription(r *http.Request) error {
+	if id := r.FormValue("subscription_id"); id != "" {
+		return fmt.Errorf("backup endpoints can't be changed by subscription %s", id)
+	}
+
+	return nil
+}
+
 // swagger:parameters postBackupEndpoint
 type postBackupEndpoint struct {
 	// in: body
@@ -27,6 +37,10 @@ func (br backupRoute) createBackupEndpoint(ctx context.Context, w http.ResponseW
 	//       400: ErrorResponse
 	//       500: ErrorResponse
 
+	if err := checkNotSubscription(r); err != nil {
+		return http.StatusForbidden, nil, err
+	}
+
 	req := api.BackupEndpoint{}
 
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -65,6 +79,10 @@ func (br backupRoute) deleteBackupEndpoint(ctx context.Context, w http.ResponseW
 	//       204: description: Deleted
 	//       500: ErrorResponse
 
+	if err := checkNotSubscription(r); err != nil {
+		return http.StatusForbidden, nil, err
+	}
+
 	id := vars["id"]
 
 	err := br.bankend.DeleteEndpoint(ctx, id)
@@ -95,6 +113,10 @@ func (br backupRoute) updateBackupEndpoint(ctx context.Context, w http.ResponseW
 	//       400: ErrorResponse
 	//       500: ErrorResponse
 
+	if err := checkNotSubscription(r); err != nil {
+		return http.StatusForbidden, nil, err
+	}
+
 	id := vars["id"]
 	req := api.BackupEndpoint{}
 
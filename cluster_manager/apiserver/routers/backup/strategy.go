@@ -40,6 +40,11 @@ func (br backupRoute) postStrategy(ctx context.Context, w http.ResponseWriter, r
 		return http.StatusBadRequest, nil, err
 	}
 
+	err = br.bankend.CheckAppAndSubscription(ctx, req.App, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
 	obj, err := br.bankend.AddBackupStrategy(ctx, req)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -91,6 +96,16 @@ func (br backupRoute) updateStrategy(ctx context.Context, w http.ResponseWriter,
 		return http.StatusBadRequest, nil, err
 	}
 
+	app := ""
+	if req.App != nil {
+		app = *req.App
+	}
+
+	err = br.checkIDAppSubscription(ctx, br.bankend.CheckStrategySubscription, id, app, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
 	err = br.bankend.SetBackupStrategy(ctx, id, req)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -140,6 +155,11 @@ func (br backupRoute) listBackupStrategy(ctx context.Context, w http.ResponseWri
 	unit := r.FormValue("unit_id")
 	app := r.FormValue("app_id")
 
+	apps, err := br.bankend.SubscriptionApps(ctx, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
 	list, err := br.bankend.ListBackupStrategy(ctx, id, unit, app)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -149,6 +169,17 @@ func (br backupRoute) listBackupStrategy(ctx context.Context, w http.ResponseWri
 		return http.StatusOK, api.BackupStrategyResponse{}, nil
 	}
 
+	if apps != nil {
+		out := make(api.BackupStrategyResponse, 0, len(list))
+		for i := range list {
+			if apps[list[i].App] {
+				out = append(out, list[i])
+			}
+		}
+
+		list = out
+	}
+
 	return http.StatusOK, list, nil
 }
 
@@ -186,7 +217,12 @@ func (br backupRoute) deleteBackupStrategy(ctx context.Context, w http.ResponseW
 		return http.StatusBadRequest, nil, errors.New("id or app_id is required in delete backup strategy")
 	}
 
-	err := br.bankend.DeleteBackupStrategy(ctx, id, app)
+	err := br.checkIDAppSubscription(ctx, br.bankend.CheckStrategySubscription, id, app, r.FormValue("subscription_id"))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	err = br.bankend.DeleteBackupStrategy(ctx, id, app)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
 	}
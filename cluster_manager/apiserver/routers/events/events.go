@@ -0,0 +1,157 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server"
+	"github.com/upmio/dbscale-kube/pkg/server/handlerrouter"
+)
+
+// 无事件时定期发送注释行，避免连接被代理断开
+const heartbeatInterval = 15 * time.Second
+
+// RegisterEventRoute registers the server-sent events stream,
+// it's a raw route because the response is streaming rather than a json object.
+func RegisterEventRoute(bankend eventBankend, srv *server.Server) {
+	r := &eventRoute{
+		bankend: bankend,
+	}
+
+	h := http.HandlerFunc(r.streamEvents)
+
+	r.handlerRoutes = []handlerrouter.HandlerRoute{
+		handlerrouter.NewHandlerRoute("/v{version:[0-9.]+}/manager/events", h),
+		handlerrouter.NewHandlerRoute("/manager/events", h),
+	}
+
+	srv.AddRawRouter(r)
+}
+
+type eventRoute struct {
+	bankend eventBankend
+
+	handlerRoutes []handlerrouter.HandlerRoute
+}
+
+func (er eventRoute) HandlerRoutes() []handlerrouter.HandlerRoute {
+	return er.handlerRoutes
+}
+
+type eventBankend interface {
+	Subscribe(types []string, app string, lastID uint64) ([]api.Event, <-chan api.Event, func())
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(server.ErrorResponse{
+		Code:  code,
+		Error: err.Error(),
+	})
+}
+
+func writeEvent(w http.ResponseWriter, ev api.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+
+	return err
+}
+
+// streamEvents GET /manager/events
+//
+// 订阅任务、服务、单元状态变化及备份完成事件(text/event-stream)
+//
+// 查询参数types为事件类型，逗号分隔，如 task,app.state，为空表示全部；
+// app_id只订阅该服务的事件；重连时通过Last-Event-ID请求头补发该ID之后的事件。
+func (er eventRoute) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+
+	var types []string
+	if v := r.FormValue("types"); v != "" {
+		types = strings.Split(v, ",")
+	}
+
+	if err := api.ValidEventTypes(types); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.FormValue("last_event_id")
+	}
+
+	var last uint64
+	if lastID != "" {
+		n, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID '%s'", lastID))
+			return
+		}
+
+		last = n
+	}
+
+	backlog, events, cancel := er.bankend.Subscribe(types, r.FormValue("app_id"), last)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range backlog {
+		if err := writeEvent(w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+
+		case ev, ok := <-events:
+			if !ok {
+				// 订阅者过慢被断开，客户端按Last-Event-ID重连
+				return
+			}
+
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,310 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterWebhookRoute(bankend webhookBankend, routers router.Adder) {
+	r := &webhookRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/webhooks", r.listWebhooks, router.WithDoc(router.Doc{
+			ID:       "listWebhooks",
+			Tags:     []string{"webhooks"},
+			Summary:  "查询webhook",
+			Query:    api.AppIDQuery{},
+			Response: api.WebhooksResponse{},
+		})),
+		router.NewPostRoute("/manager/webhooks", r.postWebhook, router.WithDoc(router.Doc{
+			ID:       "postWebhook",
+			Tags:     []string{"webhooks"},
+			Summary:  "增加webhook",
+			Body:     api.WebhookConfig{},
+			Code:     http.StatusCreated,
+			Response: api.ObjectResponse{},
+		})),
+		router.NewGetRoute("/manager/webhooks/{id}", r.getWebhook, router.WithDoc(router.Doc{
+			ID:       "getWebhook",
+			Tags:     []string{"webhooks"},
+			Summary:  "查询webhook详情",
+			Response: api.Webhook{},
+		})),
+		router.NewPutRoute("/manager/webhooks/{id}", r.setWebhook, router.WithDoc(router.Doc{
+			ID:       "setWebhook",
+			Tags:     []string{"webhooks"},
+			Summary:  "更新webhook",
+			Body:     api.WebhookOptions{},
+			Response: api.Webhook{},
+		})),
+		router.NewDeleteRoute("/manager/webhooks/{id}", r.deleteWebhook, router.WithDoc(router.Doc{
+			ID:      "deleteWebhook",
+			Tags:    []string{"webhooks"},
+			Summary: "删除webhook",
+			Code:    http.StatusNoContent,
+		})),
+
+		router.NewGetRoute("/manager/webhooks/{id}/deliveries", r.listDeliveries, router.WithDoc(router.Doc{
+			ID:       "listWebhookDeliveries",
+			Tags:     []string{"webhooks"},
+			Summary:  "查询webhook投递记录",
+			Query:    api.LimitQuery{},
+			Response: api.WebhookDeliveriesResponse{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type webhookRoute struct {
+	bankend webhookBankend
+
+	routes []router.Route
+}
+
+func (wr webhookRoute) Routes() []router.Route {
+	return wr.routes
+}
+
+type webhookBankend interface {
+	AddWebhook(ctx context.Context, config api.WebhookConfig) (api.ObjectResponse, error)
+	SetWebhook(ctx context.Context, id string, opts api.WebhookOptions) (api.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (api.Webhook, error)
+	ListWebhooks(ctx context.Context, app string) (api.WebhooksResponse, error)
+	DeleteWebhook(ctx context.Context, id string) error
+
+	ListDeliveries(ctx context.Context, id string, limit int) (api.WebhookDeliveriesResponse, error)
+}
+
+// swagger:parameters postWebhook
+type postWebhookRequest struct {
+	// in: body
+	// required: true
+	Body api.WebhookConfig
+}
+
+func (wr webhookRoute) postWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route POST /manager/webhooks webhook postWebhook
+	//
+	// 增加事件订阅webhook
+	//
+	// Add a webhook
+	// This will create a webhook receiving the events,the requests are signed by the secret
+	//
+	//     Responses:
+	//       201: ObjectResponse
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	req := api.WebhookConfig{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	obj, err := wr.bankend.AddWebhook(ctx, req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusCreated, obj, nil
+}
+
+// swagger:parameters setWebhook
+type setWebhookRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	// required: true
+	Body api.WebhookOptions
+}
+
+// webhook info
+//
+// swagger:response webhookResponseWrapper
+type webhookResponseWrapper struct {
+	// in: body
+	Body api.Webhook
+}
+
+func (wr webhookRoute) setWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route PUT /manager/webhooks/{id} webhook setWebhook
+	//
+	// 更改事件订阅webhook
+	//
+	// Update a webhook
+	// This will update the webhook
+	//
+	//     Responses:
+	//       200: webhookResponseWrapper
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	req := api.WebhookOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	wh, err := wr.bankend.SetWebhook(ctx, vars["id"], req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, wh, nil
+}
+
+// swagger:parameters getWebhook deleteWebhook
+type webhookIDRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+func (wr webhookRoute) getWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/webhooks/{id} webhook getWebhook
+	//
+	// 查询一个事件订阅webhook
+	//
+	// Get a webhook
+	// This will returns a webhook,the secret is not returned
+	//
+	//     Responses:
+	//       200: webhookResponseWrapper
+	//       500: ErrorResponse
+
+	wh, err := wr.bankend.GetWebhook(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, wh, nil
+}
+
+// swagger:parameters listWebhooks
+type listWebhooksRequest struct {
+	// in: query
+	// required: false
+	App string `json:"app_id"`
+}
+
+// webhooks
+//
+// swagger:response listWebhooksResponseWrapper
+type listWebhooksResponseWrapper struct {
+	// in: body
+	Body api.WebhooksResponse
+}
+
+func (wr webhookRoute) listWebhooks(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/webhooks webhook listWebhooks
+	//
+	// 查询事件订阅webhook
+	//
+	// List webhooks
+	// This will returns a list of webhooks
+	//
+	//     Responses:
+	//       200: listWebhooksResponseWrapper
+	//       500: ErrorResponse
+
+	list, err := wr.bankend.ListWebhooks(ctx, r.FormValue("app_id"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (wr webhookRoute) deleteWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route DELETE /manager/webhooks/{id} webhook deleteWebhook
+	//
+	// 删除事件订阅webhook
+	//
+	// Delete a webhook
+	// This will delete the webhook and its deliveries
+	//
+	//     Responses:
+	//       204: description: Deleted
+	//       500: ErrorResponse
+
+	err := wr.bankend.DeleteWebhook(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// swagger:parameters listWebhookDeliveries
+type listDeliveriesRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// 默认100
+	//
+	// in: query
+	// required: false
+	Limit int `json:"limit"`
+}
+
+// webhook deliveries
+//
+// swagger:response listWebhookDeliveriesResponseWrapper
+type listWebhookDeliveriesResponseWrapper struct {
+	// in: body
+	Body api.WebhookDeliveriesResponse
+}
+
+func (wr webhookRoute) listDeliveries(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/webhooks/{id}/deliveries webhook listWebhookDeliveries
+	//
+	// 查询webhook投递记录
+	//
+	// List deliveries of webhook
+	// This will returns the latest deliveries of the webhook
+	//
+	//     Responses:
+	//       200: listWebhookDeliveriesResponseWrapper
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	limit := 0
+
+	if v := r.FormValue("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return http.StatusBadRequest, nil, fmt.Errorf("invalid limit '%s'", v)
+		}
+
+		limit = n
+	}
+
+	list, err := wr.bankend.ListDeliveries(ctx, vars["id"], limit)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
@@ -15,10 +15,35 @@ func RegisterClusterRoute(bankend clusterBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewGetRoute("/manager/clusters", r.listClusters),
-		router.NewPostRoute("/manager/clusters", r.postCluster),
-		router.NewPutRoute("/manager/clusters/{id}", r.updateCluster),
-		router.NewDeleteRoute("/manager/clusters/{id}", r.deleteCluster),
+		router.NewGetRoute("/manager/clusters", r.listClusters, router.WithDoc(router.Doc{
+			ID:       "listClusters",
+			Tags:     []string{"clusters"},
+			Summary:  "查询集群",
+			Query:    api.ClusterListQuery{},
+			Response: api.ClustersResponse{},
+			Paged:    true,
+		})),
+		router.NewPostRoute("/manager/clusters", r.postCluster, router.WithDoc(router.Doc{
+			ID:       "postCluster",
+			Tags:     []string{"clusters"},
+			Summary:  "增加集群",
+			Body:     api.ClusterConfig{},
+			Code:     http.StatusCreated,
+			Response: api.ObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/clusters/{id}", r.updateCluster, router.WithDoc(router.Doc{
+			ID:       "updateCluster",
+			Tags:     []string{"clusters"},
+			Summary:  "更新集群",
+			Body:     api.ClusterOptions{},
+			Response: api.ObjectResponse{},
+		})),
+		router.NewDeleteRoute("/manager/clusters/{id}", r.deleteCluster, router.WithDoc(router.Doc{
+			ID:      "deleteCluster",
+			Tags:    []string{"clusters"},
+			Summary: "删除集群",
+			Code:    http.StatusNoContent,
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -27,6 +52,7 @@ func RegisterClusterRoute(bankend clusterBankend, routers router.Adder) {
 type clusterBankend interface {
 	Add(ctx context.Context, config api.ClusterConfig) (api.Cluster, error)
 	List(ctx context.Context, id, name, site, enabled string) ([]api.Cluster, error)
+	ListPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error)
 	Set(ctx context.Context, id string, opts api.ClusterOptions) (api.Cluster, error)
 	Delete(ctx context.Context, id string) error
 }
@@ -103,6 +129,8 @@ type listClusterRequest struct {
 	// in: query
 	// required: false
 	Enable string `json:"enabled"`
+
+	api.ListRequest
 }
 
 // list clusters info
@@ -131,6 +159,25 @@ func (cr clusterRoute) listClusters(ctx context.Context, w http.ResponseWriter,
 	site := r.FormValue("site_id")
 	enabled := r.FormValue("enabled")
 
+	if api.IsListRequest(r.URL.Query()) {
+		opts, err := api.ParseListOptions(r.URL.Query(), api.ClusterListFields)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+
+		opts.AddFilter("id", id)
+		opts.AddFilter("name", name)
+		opts.AddFilter("site_id", site)
+		opts.AddFilter("enabled", enabled)
+
+		resp, err := cr.bankend.ListPage(ctx, opts)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		return http.StatusOK, resp, nil
+	}
+
 	clusters, err := cr.bankend.List(ctx, id, name, site, enabled)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
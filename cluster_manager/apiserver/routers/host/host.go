@@ -17,15 +17,68 @@ func RegisterHostRoute(bankend hostBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewGetRoute("/manager/hosts", r.listHosts),
-		router.NewPostRoute("/manager/hosts", r.postHost),
-		router.NewPutRoute("/manager/hosts/{id}", r.updateHost),
-		router.NewDeleteRoute("/manager/hosts/{id}", r.deleteHost),
-
-		router.NewGetRoute("/manager/hosts/{id}/detail", r.getHostDetail),
+		router.NewGetRoute("/manager/hosts", r.listHosts, router.WithDoc(router.Doc{
+			ID:       "listHosts",
+			Tags:     []string{"hosts"},
+			Summary:  "查询主机",
+			Query:    api.HostListQuery{},
+			Response: api.HostsResponse{},
+			Paged:    true,
+		})),
+		router.NewPostRoute("/manager/hosts", r.postHost, router.WithDoc(router.Doc{
+			ID:       "postHost",
+			Tags:     []string{"hosts"},
+			Summary:  "增加主机",
+			Body:     api.HostConfig{},
+			Code:     http.StatusCreated,
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/hosts/{id}", r.updateHost, router.WithDoc(router.Doc{
+			ID:       "updateHost",
+			Tags:     []string{"hosts"},
+			Summary:  "更新主机",
+			Body:     api.HostOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewDeleteRoute("/manager/hosts/{id}", r.deleteHost, router.WithDoc(router.Doc{
+			ID:      "deleteHost",
+			Tags:    []string{"hosts"},
+			Summary: "删除主机",
+			Query:   api.HostDeleteQuery{},
+			Code:    http.StatusNoContent,
+		})),
+
+		router.NewGetRoute("/manager/hosts/{id}/detail", r.getHostDetail, router.WithDoc(router.Doc{
+			ID:       "getHostDetail",
+			Tags:     []string{"hosts"},
+			Summary:  "查询主机详情",
+			Response: api.HostDetail{},
+		})),
+
+		//维护模式: 迁出单元及恢复服务
+		router.NewPutRoute("/manager/hosts/{id}/maintenance", r.setHostMaintenance, router.WithDoc(router.Doc{
+			ID:       "setHostMaintenance",
+			Tags:     []string{"hosts"},
+			Summary:  "设置主机维护模式",
+			Body:     api.HostMaintenanceOptions{},
+			Response: api.HostMaintenance{},
+		})),
+		router.NewGetRoute("/manager/hosts/{id}/maintenance", r.getHostMaintenance, router.WithDoc(router.Doc{
+			ID:       "getHostMaintenance",
+			Tags:     []string{"hosts"},
+			Summary:  "查询主机维护模式",
+			Response: api.HostMaintenance{},
+		})),
 
 		//验证username,password,ssh_port
-		router.NewPostRoute("/manager/hosts/validation", r.validateHost),
+		router.NewPostRoute("/manager/hosts/validation", r.validateHost, router.WithDoc(router.Doc{
+			ID:      "validateHost",
+			Tags:    []string{"hosts"},
+			Summary: "主机合规检查",
+			Query:   api.TypeQuery{},
+			Body:    api.HostConfig{},
+			Code:    http.StatusCreated,
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -34,12 +87,16 @@ func RegisterHostRoute(bankend hostBankend, routers router.Adder) {
 type hostBankend interface {
 	Add(ctx context.Context, config api.HostConfig) (api.Host, error)
 	List(ctx context.Context, id, name, cluster, site, enabled string) ([]api.Host, error)
+	ListPage(ctx context.Context, site string, opts api.ListOptions) (api.ListResponse, error)
 	Set(ctx context.Context, id string, opts api.HostOptions) (api.Host, error)
 
 	GetDetail(ctx context.Context, id string) (api.HostDetail, error)
 	Delete(ctx context.Context, id, user, password string, port int) (api.TaskObjectResponse, error)
 
 	ValidateHost(ctx context.Context, config api.HostConfig, checkType string) error
+
+	SetMaintenance(ctx context.Context, id string, opts api.HostMaintenanceOptions) (api.HostMaintenance, error)
+	GetMaintenance(ctx context.Context, id string) (api.HostMaintenance, error)
 }
 
 type nodeRoute struct {
@@ -119,6 +176,8 @@ type listHostRequest struct {
 	// in: query
 	// required: false
 	Enable string `json:"enabled"`
+
+	api.ListRequest
 }
 
 // list hosts info
@@ -169,6 +228,25 @@ func (nr nodeRoute) listHosts(ctx context.Context, w http.ResponseWriter, r *htt
 	site := r.FormValue("site_id")
 	enabled := r.FormValue("enabled")
 
+	if api.IsListRequest(r.URL.Query()) {
+		opts, err := api.ParseListOptions(r.URL.Query(), api.HostListFields)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+
+		opts.AddFilter("id", id)
+		opts.AddFilter("name", name)
+		opts.AddFilter("cluster_id", cluster)
+		opts.AddFilter("enabled", enabled)
+
+		resp, err := nr.bankend.ListPage(ctx, site, opts)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		return http.StatusOK, resp, nil
+	}
+
 	list, err := nr.bankend.List(ctx, id, name, cluster, site, enabled)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -251,12 +329,13 @@ func (nr nodeRoute) deleteHost(ctx context.Context, w http.ResponseWriter, r *ht
 	//       204: description: Deleted
 	//       500: ErrorResponse
 
+	// 密钥认证入库的主机不需要username,pwd,ssh_port，为空时使用入库时的值
 	id := vars["id"]
 	user := r.FormValue("username")
 	password := r.FormValue("pwd")
 	portStr := r.FormValue("ssh_port")
 
-	port := 22
+	port := 0
 	if portStr != "" {
 		portInt, err := strconv.Atoi(portStr)
 		if err != nil {
@@ -273,6 +352,80 @@ func (nr nodeRoute) deleteHost(ctx context.Context, w http.ResponseWriter, r *ht
 	return http.StatusNoContent, out, nil
 }
 
+// swagger:parameters setHostMaintenance
+type setHostMaintenanceRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	// required: true
+	Body api.HostMaintenanceOptions
+}
+
+func (nr nodeRoute) setHostMaintenance(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route PUT /manager/hosts/{id}/maintenance hosts setHostMaintenance
+	//
+	// 计算节点维护模式
+	//
+	// Set Host maintenance
+	// This will cordon the host and evacuate units on it,
+	// or return the host to service when maintenance is false.
+	// dry_run only returns the evacuation plan.
+	//
+	//     Responses:
+	//       200: HostMaintenance
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	id := vars["id"]
+
+	req := api.HostMaintenanceOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	out, err := nr.bankend.SetMaintenance(ctx, id, req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+// swagger:parameters getHostMaintenance
+type getHostMaintenanceRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+func (nr nodeRoute) getHostMaintenance(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route GET /manager/hosts/{id}/maintenance hosts getHostMaintenance
+	//
+	// 查询计算节点维护进度
+	//
+	// Get Host maintenance
+	// This will return the evacuation progress of the host
+	//
+	//     Responses:
+	//       200: HostMaintenance
+	//       500: ErrorResponse
+
+	out, err := nr.bankend.GetMaintenance(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
 func (nr nodeRoute) validateHost(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
 	// swagger:route POST /manager/hosts/validation hosts legalizeHost
 	//
@@ -0,0 +1,140 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterCampaignRoute(bankend campaignBankend, routers router.Adder) {
+	r := &campaignRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/images/lifecycle/report", r.lifecycleReport, router.WithDoc(router.Doc{
+			ID:       "imageLifecycleReport",
+			Tags:     []string{"images"},
+			Summary:  "查询仍在使用弃用或停止支持镜像的服务",
+			Query:    api.ImageLifecycleQuery{},
+			Response: api.ImageLifecycleReportResponse{},
+		})),
+
+		router.NewPostRoute("/manager/images/campaigns", r.postCampaign, router.WithDoc(router.Doc{
+			ID:       "postImageCampaign",
+			Tags:     []string{"images"},
+			Summary:  "创建镜像升级活动，在维护窗口内按并发数升级服务镜像",
+			Body:     api.ImageCampaignConfig{},
+			Code:     http.StatusCreated,
+			Response: api.ImageCampaign{},
+		})),
+		router.NewGetRoute("/manager/images/campaigns", r.listCampaigns, router.WithDoc(router.Doc{
+			ID:       "listImageCampaigns",
+			Tags:     []string{"images"},
+			Summary:  "查询镜像升级活动",
+			Query:    api.ImageCampaignListQuery{},
+			Response: api.ImageCampaignsResponse{},
+		})),
+		router.NewGetRoute("/manager/images/campaigns/{id}", r.getCampaign, router.WithDoc(router.Doc{
+			ID:       "getImageCampaign",
+			Tags:     []string{"images"},
+			Summary:  "查询镜像升级活动的进度",
+			Response: api.ImageCampaign{},
+		})),
+		router.NewPostRoute("/manager/images/campaigns/{id}/cancel", r.cancelCampaign, router.WithDoc(router.Doc{
+			ID:       "cancelImageCampaign",
+			Tags:     []string{"images"},
+			Summary:  "取消镜像升级活动，已开始的升级继续执行",
+			Response: api.ImageCampaign{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type campaignBankend interface {
+	LifecycleReport(ctx context.Context, lifecycle string) (api.ImageLifecycleReportResponse, error)
+
+	Add(ctx context.Context, config api.ImageCampaignConfig) (api.ImageCampaign, error)
+	List(ctx context.Context, state string) (api.ImageCampaignsResponse, error)
+	Get(ctx context.Context, id string) (api.ImageCampaign, error)
+	Cancel(ctx context.Context, id string) (api.ImageCampaign, error)
+}
+
+type campaignRoute struct {
+	bankend campaignBankend
+
+	routes []router.Route
+}
+
+func (cr campaignRoute) Routes() []router.Route {
+	return cr.routes
+}
+
+func (cr campaignRoute) lifecycleReport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	lifecycle := r.FormValue("lifecycle")
+
+	switch lifecycle {
+	case "", api.ImageLifecycleDeprecated, api.ImageLifecycleEOL:
+	default:
+		return http.StatusBadRequest, nil, fmt.Errorf("unknown lifecycle %q", lifecycle)
+	}
+
+	out, err := cr.bankend.LifecycleReport(ctx, lifecycle)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (cr campaignRoute) postCampaign(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	req := api.ImageCampaignConfig{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	out, err := cr.bankend.Add(ctx, req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusCreated, out, nil
+}
+
+func (cr campaignRoute) listCampaigns(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	out, err := cr.bankend.List(ctx, r.FormValue("state"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (cr campaignRoute) getCampaign(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	out, err := cr.bankend.Get(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (cr campaignRoute) cancelCampaign(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	out, err := cr.bankend.Cancel(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
@@ -16,18 +16,82 @@ func RegisterImageRoute(bankend imageBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewGetRoute("/manager/images", r.listImages),
-		router.NewPostRoute("/manager/images", r.postImage),
-		router.NewPutRoute("/manager/images/{id}", r.updateImage),
-		router.NewDeleteRoute("/manager/images/{id}", r.deleteImage),
-
-		router.NewGetRoute("/manager/images/{id}/templates", r.listImageTemplates),
-		router.NewPutRoute("/manager/images/{id}/templates", r.updateImageTemplate),
-
-		router.NewGetRoute("/manager/images/{id}/scripts", r.listImageScripts),
-		router.NewPutRoute("/manager/images/{id}/scripts", r.syncImageScripts),
+		router.NewGetRoute("/manager/images", r.listImages, router.WithDoc(router.Doc{
+			ID:       "listImages",
+			Tags:     []string{"images"},
+			Summary:  "查询镜像",
+			Query:    api.ImageListOptions{},
+			Response: api.ImagesResponse{},
+		})),
+		router.NewGetRoute("/manager/images/sets", r.listImageSets, router.WithDoc(router.Doc{
+			ID:       "listImageSets",
+			Tags:     []string{"images"},
+			Summary:  "查询多架构镜像集合，同一类型和版本的各架构镜像",
+			Query:    api.ImageListOptions{},
+			Response: api.ImageSetsResponse{},
+		})),
+		router.NewPostRoute("/manager/images", r.postImage, router.WithDoc(router.Doc{
+			ID:       "postImage",
+			Tags:     []string{"images"},
+			Summary:  "增加镜像",
+			Body:     api.ImageConfig{},
+			Code:     http.StatusCreated,
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/images/{id}", r.updateImage, router.WithDoc(router.Doc{
+			ID:       "updateImage",
+			Tags:     []string{"images"},
+			Summary:  "更新镜像",
+			Body:     api.ImageOptions{},
+			Response: api.ObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/images/{id}/lifecycle", r.updateImageLifecycle, router.WithDoc(router.Doc{
+			ID:       "updateImageLifecycle",
+			Tags:     []string{"images"},
+			Summary:  "设置镜像的弃用及停止支持时间",
+			Body:     api.ImageLifecycleOptions{},
+			Response: api.Image{},
+		})),
+		router.NewDeleteRoute("/manager/images/{id}", r.deleteImage, router.WithDoc(router.Doc{
+			ID:      "deleteImage",
+			Tags:    []string{"images"},
+			Summary: "删除镜像",
+			Code:    http.StatusNoContent,
+		})),
+
+		router.NewGetRoute("/manager/images/{id}/templates", r.listImageTemplates, router.WithDoc(router.Doc{
+			ID:       "listImageTemplates",
+			Tags:     []string{"images"},
+			Summary:  "查询镜像模板",
+			Response: api.ImageTemplate{},
+		})),
+		router.NewPutRoute("/manager/images/{id}/templates", r.updateImageTemplate, router.WithDoc(router.Doc{
+			ID:       "updateImageTemplate",
+			Tags:     []string{"images"},
+			Summary:  "更新镜像模板",
+			Body:     api.ConfigTemplateOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+
+		router.NewGetRoute("/manager/images/{id}/scripts", r.listImageScripts, router.WithDoc(router.Doc{
+			ID:       "listImageScripts",
+			Tags:     []string{"images"},
+			Summary:  "查询镜像脚本",
+			Response: api.ImageScripts{},
+		})),
+		router.NewPutRoute("/manager/images/{id}/scripts", r.syncImageScripts, router.WithDoc(router.Doc{
+			ID:      "syncImageScripts",
+			Tags:    []string{"images"},
+			Summary: "同步镜像脚本",
+			Query:   api.ImageScriptsQuery{},
+		})),
 		// DBCH-TOREMOVE remove maintenance
-		router.NewPutRoute("/maintenance/images/{id}/scripts", r.syncImageScripts),
+		router.NewPutRoute("/maintenance/images/{id}/scripts", r.syncImageScripts, router.WithDoc(router.Doc{
+			ID:      "syncMaintenanceImageScripts",
+			Tags:    []string{"images"},
+			Summary: "同步镜像脚本(维护)",
+			Query:   api.ImageScriptsQuery{},
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -36,14 +100,16 @@ func RegisterImageRoute(bankend imageBankend, routers router.Adder) {
 type imageBankend interface {
 	Add(ctx context.Context, config api.ImageConfig) (api.Image, error)
 	List(ctx context.Context, opts api.ImageListOptions) ([]api.Image, error)
+	ListSets(ctx context.Context, opts api.ImageListOptions) (api.ImageSetsResponse, error)
 	Set(ctx context.Context, id string, opts api.ImageOptions) (api.Image, error)
+	SetLifecycle(ctx context.Context, id string, opts api.ImageLifecycleOptions) (api.Image, error)
 	Delete(ctx context.Context, id string) (api.TaskObjectResponse, error)
 
 	ListImageTemplates(ctx context.Context, id string) (api.ImageTemplate, error)
-	SetImageTemplate(ctx context.Context, id string, kvs []api.KeySet) (api.TaskObjectResponse, error)
+	SetImageTemplate(ctx context.Context, id string, opts api.ConfigTemplateOptions) (api.TaskObjectResponse, error)
 
 	ListImageScripts(ctx context.Context, id string) (api.ImageScripts, error)
-	SyncImageScripts(ctx context.Context, id string, scriptType string) error
+	SyncImageScripts(ctx context.Context, id string, opts api.ImageScriptsQuery) error
 }
 
 type imageRoute struct {
@@ -148,6 +214,28 @@ func (ir imageRoute) listImages(ctx context.Context, w http.ResponseWriter, r *h
 	//       200: listImagesResponseWrapper
 	//       500: ErrorResponse
 
+	list, err := ir.bankend.List(ctx, imageListOptions(r))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	if list == nil {
+		return http.StatusOK, api.RemoteStoragesResponse{}, nil
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (ir imageRoute) listImageSets(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	list, err := ir.bankend.ListSets(ctx, imageListOptions(r))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func imageListOptions(r *http.Request) api.ImageListOptions {
 	opts := api.ImageListOptions{}
 
 	if v := r.FormValue("unschedulable"); v != "" {
@@ -186,16 +274,7 @@ func (ir imageRoute) listImages(ctx context.Context, w http.ResponseWriter, r *h
 		opts.Dev = &val
 	}
 
-	list, err := ir.bankend.List(ctx, opts)
-	if err != nil {
-		return http.StatusInternalServerError, nil, err
-	}
-
-	if list == nil {
-		return http.StatusOK, api.RemoteStoragesResponse{}, nil
-	}
-
-	return http.StatusOK, list, nil
+	return opts
 }
 
 // update object
@@ -243,6 +322,26 @@ func (ir imageRoute) updateImage(ctx context.Context, w http.ResponseWriter, r *
 	}, nil
 }
 
+func (ir imageRoute) updateImageLifecycle(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	req := api.ImageLifecycleOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	im, err := ir.bankend.SetLifecycle(ctx, vars["id"], req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, im, nil
+}
+
 // object by id
 //
 // swagger:parameters deleteImage
@@ -372,7 +471,7 @@ func (ir imageRoute) updateImageTemplate(ctx context.Context, w http.ResponseWri
 		return http.StatusBadRequest, nil, err
 	}
 
-	out, err := ir.bankend.SetImageTemplate(ctx, id, opts.Keysets)
+	out, err := ir.bankend.SetImageTemplate(ctx, id, opts)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
 	}
@@ -416,13 +515,17 @@ func (ir imageRoute) syncImageScripts(ctx context.Context, w http.ResponseWriter
 	//       400: ErrorResponse
 	//       500: ErrorResponse
 	id := vars["id"]
-	scriptType := r.FormValue("type")
+	opts := api.ImageScriptsQuery{
+		Type:   r.FormValue("type"),
+		Reason: r.FormValue("reason"),
+		User:   r.FormValue("user"),
+	}
 
-	if scriptType == "" {
+	if opts.Type == "" {
 		return http.StatusBadRequest, nil, fmt.Errorf("type is required")
 	}
 
-	err := ir.bankend.SyncImageScripts(ctx, id, scriptType)
+	err := ir.bankend.SyncImageScripts(ctx, id, opts)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
 	}
@@ -0,0 +1,164 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterTemplateRoute(bankend templateBankend, routers router.Adder) {
+	r := &templateRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/images/{id}/templates/revisions", r.listRevisions, router.WithDoc(router.Doc{
+			ID:       "listImageTemplateRevisions",
+			Tags:     []string{"images"},
+			Summary:  "查询镜像模板及脚本的历史版本",
+			Response: api.ImageTemplateRevisionsResponse{},
+		})),
+		router.NewGetRoute("/manager/images/{id}/templates/revisions/{revision}", r.getRevision, router.WithDoc(router.Doc{
+			ID:       "getImageTemplateRevision",
+			Tags:     []string{"images"},
+			Summary:  "查询镜像模板版本的内容",
+			Response: api.ImageTemplateRevision{},
+		})),
+		router.NewGetRoute("/manager/images/{id}/templates/diff", r.diff, router.WithDoc(router.Doc{
+			ID:       "diffImageTemplateRevisions",
+			Tags:     []string{"images"},
+			Summary:  "并排对比镜像模板的两个版本，版本号为空表示最新版本",
+			Query:    api.ImageTemplateDiffQuery{},
+			Response: api.ImageTemplateDiff{},
+		})),
+		router.NewPostRoute("/manager/images/{id}/templates/rollback", r.rollback, router.WithDoc(router.Doc{
+			ID:       "rollbackImageTemplate",
+			Tags:     []string{"images"},
+			Summary:  "回滚镜像模板到指定版本，回滚记录为新的版本",
+			Body:     api.ImageTemplateRollbackOptions{},
+			Response: api.ImageTemplateRevision{},
+		})),
+
+		router.NewPutRoute("/manager/apps/{id}/templates/pin", r.pin, router.WithDoc(router.Doc{
+			ID:       "pinAppImageTemplate",
+			Tags:     []string{"apps"},
+			Summary:  "固定服务使用的镜像模板版本，版本号为0表示跟随镜像当前版本",
+			Body:     api.AppTemplatePinOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type templateBankend interface {
+	ListRevisions(ctx context.Context, id string) (api.ImageTemplateRevisionsResponse, error)
+	GetRevision(ctx context.Context, id string, revision int) (api.ImageTemplateRevision, error)
+	Diff(ctx context.Context, id string, from, to int) (api.ImageTemplateDiff, error)
+	Rollback(ctx context.Context, id string, opts api.ImageTemplateRollbackOptions) (api.ImageTemplateRevision, error)
+
+	Pin(ctx context.Context, app string, opts api.AppTemplatePinOptions) (api.TaskObjectResponse, error)
+}
+
+type templateRoute struct {
+	bankend templateBankend
+
+	routes []router.Route
+}
+
+func (tr templateRoute) Routes() []router.Route {
+	return tr.routes
+}
+
+func (tr templateRoute) listRevisions(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	out, err := tr.bankend.ListRevisions(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (tr templateRoute) getRevision(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil || revision <= 0 {
+		return http.StatusBadRequest, nil, fmt.Errorf("invalid revision %q", vars["revision"])
+	}
+
+	out, err := tr.bankend.GetRevision(ctx, vars["id"], revision)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (tr templateRoute) diff(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	revisions := [2]int{}
+
+	for i, key := range []string{"from", "to"} {
+		v := r.FormValue(key)
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return http.StatusBadRequest, nil, fmt.Errorf("invalid %s %q", key, v)
+		}
+
+		revisions[i] = n
+	}
+
+	out, err := tr.bankend.Diff(ctx, vars["id"], revisions[0], revisions[1])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (tr templateRoute) rollback(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	opts := api.ImageTemplateRollbackOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&opts)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := opts.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	out, err := tr.bankend.Rollback(ctx, vars["id"], opts)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (tr templateRoute) pin(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	opts := api.AppTemplatePinOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&opts)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := opts.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	out, err := tr.bankend.Pin(ctx, vars["id"], opts)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
@@ -3,6 +3,7 @@ package network
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
@@ -15,10 +16,41 @@ func RegisterNetworkRoute(bankend networkBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewPostRoute("/manager/networks", r.postNetwork),
-		router.NewGetRoute("/manager/networks", r.listNetworks),
-		router.NewPutRoute("/manager/networks/{id}", r.updateNetwork),
-		router.NewDeleteRoute("/manager/networks/{id}", r.deleteNetwork),
+		router.NewPostRoute("/manager/networks", r.postNetwork, router.WithDoc(router.Doc{
+			ID:       "postNetwork",
+			Tags:     []string{"networks"},
+			Summary:  "增加网络",
+			Body:     api.NetworkConfig{},
+			Code:     http.StatusCreated,
+			Response: api.ObjectResponse{},
+		})),
+		router.NewGetRoute("/manager/networks", r.listNetworks, router.WithDoc(router.Doc{
+			ID:       "listNetworks",
+			Tags:     []string{"networks"},
+			Summary:  "查询网络",
+			Query:    api.NetworkListQuery{},
+			Response: api.NetworksResponse{},
+			Paged:    true,
+		})),
+		router.NewPutRoute("/manager/networks/{id}", r.updateNetwork, router.WithDoc(router.Doc{
+			ID:       "updateNetwork",
+			Tags:     []string{"networks"},
+			Summary:  "更新网络",
+			Body:     api.NetworkOptions{},
+			Response: api.ObjectResponse{},
+		})),
+		router.NewDeleteRoute("/manager/networks/{id}", r.deleteNetwork, router.WithDoc(router.Doc{
+			ID:      "deleteNetwork",
+			Tags:    []string{"networks"},
+			Summary: "删除网络",
+			Code:    http.StatusNoContent,
+		})),
+		router.NewDeleteRoute("/manager/networks/{id}/conflicts/{ip}", r.releaseConflict, router.WithDoc(router.Doc{
+			ID:      "releaseConflict",
+			Tags:    []string{"networks"},
+			Summary: "释放冲突IP",
+			Code:    http.StatusNoContent,
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -27,8 +59,10 @@ func RegisterNetworkRoute(bankend networkBankend, routers router.Adder) {
 type networkBankend interface {
 	Add(ctx context.Context, config api.NetworkConfig) (api.Network, error)
 	List(ctx context.Context, id, name, cluster, site, topology, enabled string) ([]api.Network, error)
+	ListPage(ctx context.Context, site string, opts api.ListOptions) (api.ListResponse, error)
 	Set(ctx context.Context, id string, opts api.NetworkOptions) (api.Network, error)
 	Delete(ctx context.Context, id string) error
+	ReleaseConflict(ctx context.Context, id, ip string) error
 }
 
 type networkRoute struct {
@@ -107,6 +141,8 @@ type listNetworskRequest struct {
 	// in: query
 	// required: false
 	Enable string `json:"enabled"`
+
+	api.ListRequest
 }
 
 // list object info
@@ -127,6 +163,7 @@ func (nr networkRoute) listNetworks(ctx context.Context, w http.ResponseWriter,
 	//
 	//     Responses:
 	//       200: listNetworksResponseWrapper
+	//       400: ErrorResponse
 	//       500: ErrorResponse
 
 	id := r.FormValue("id")
@@ -136,6 +173,30 @@ func (nr networkRoute) listNetworks(ctx context.Context, w http.ResponseWriter,
 	topology := r.FormValue("topology")
 	enabled := r.FormValue("enabled")
 
+	if api.IsListRequest(r.URL.Query()) {
+		// topology 保存为列表，不支持分页查询
+		if topology != "" {
+			return http.StatusBadRequest, nil, errors.New("topology isn't supported by paged list")
+		}
+
+		opts, err := api.ParseListOptions(r.URL.Query(), api.NetworkListFields)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+
+		opts.AddFilter("id", id)
+		opts.AddFilter("name", name)
+		opts.AddFilter("cluster_id", cluster)
+		opts.AddFilter("enabled", enabled)
+
+		resp, err := nr.bankend.ListPage(ctx, site, opts)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		return http.StatusOK, resp, nil
+	}
+
 	list, err := nr.bankend.List(ctx, id, name, cluster, site, topology, enabled)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -225,3 +286,47 @@ func (nr networkRoute) deleteNetwork(ctx context.Context, w http.ResponseWriter,
 
 	return http.StatusNoContent, nil, nil
 }
+
+// swagger:parameters releaseConflict
+type releaseConflictRequest struct {
+	// 对象 ID 或者 Name
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// 隔离的 IP
+	//
+	// required: true
+	// in: path
+	IP string `json:"ip"`
+}
+
+func (nr networkRoute) releaseConflict(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	// swagger:route DELETE /manager/networks/{id}/conflicts/{ip} networks releaseConflict
+	//
+	// 解除隔离的冲突 IP
+	//
+	// Release a quarantined IP
+	// This will release the IP which responded to the probe before allocation,
+	// make sure it is no longer in use before release it.
+	//
+	//     Responses:
+	//       204: description: Released
+	//       400: ErrorResponse
+	//       500: ErrorResponse
+
+	id := vars["id"]
+	ip := api.IP(vars["ip"])
+
+	if err := ip.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	err := nr.bankend.ReleaseConflict(ctx, id, ip.String())
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
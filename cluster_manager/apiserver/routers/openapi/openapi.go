@@ -0,0 +1,125 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/alert"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/app"
+	auditrouter "github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/audit"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/backup"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/events"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/host"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/image"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/network"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/site"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/storage"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/subscription"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/task"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/routers/usage"
+	"github.com/upmio/dbscale-kube/pkg/server"
+	oas "github.com/upmio/dbscale-kube/pkg/server/openapi"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+const (
+	// APIVersion 接口大版本，不兼容的修改需要升级大版本(/v2)并生成新的客户端，
+	// 服务端只匹配该大版本的请求
+	APIVersion = "1"
+
+	BasePath = "/v" + APIVersion
+)
+
+// RegisterOpenAPIRoute 注册 GET /openapi.json，文档由srv注册的路由生成
+func RegisterOpenAPIRoute(srv *server.Server) {
+	r := &openapiRoute{
+		srv: srv,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/openapi.json", r.getDocument, router.WithDoc(router.Doc{
+			ID:       "getOpenAPIDocument",
+			Tags:     []string{"openapi"},
+			Summary:  "查询OpenAPI文档",
+			Response: map[string]interface{}{},
+		})),
+	}
+
+	srv.AddRouter(r)
+}
+
+type openapiRoute struct {
+	srv *server.Server
+
+	once sync.Once
+	doc  *oas.Document
+	err  error
+
+	routes []router.Route
+}
+
+func (or *openapiRoute) Routes() []router.Route {
+	return or.routes
+}
+
+func (or *openapiRoute) getDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	or.once.Do(func() {
+		or.doc, or.err = NewDocument(or.srv.Routes())
+	})
+
+	if or.err != nil {
+		return http.StatusInternalServerError, nil, or.err
+	}
+
+	return http.StatusOK, or.doc, nil
+}
+
+// NewDocument builds the OpenAPI document of the routes.
+func NewDocument(routes []router.Route) (*oas.Document, error) {
+	b := oas.NewBuilder(oas.Info{
+		Title:   "dbscale-kube cluster_manager apiserver",
+		Version: APIVersion,
+	}, oas.Server{URL: BasePath})
+
+	b.SetType(api.Time{}, oas.Schema{
+		Type:        "string",
+		Description: api.TimeFormat,
+	})
+	b.SetSchemaLoader(api.GetJsonSchema)
+
+	err := b.Add(routes...)
+
+	return b.Document(), err
+}
+
+// Routes returns all routes of the apiserver,bankends are nil,
+// only used to build the document and clients.
+func Routes() []router.Route {
+	srv := server.NewServer(nil, nil)
+
+	auditrouter.RegisterAuditRoute(nil, srv)
+	site.RegisterSiteRoute(nil, srv)
+	task.RegisterTaskRoute(nil, srv)
+	network.RegisterNetworkRoute(nil, srv)
+	image.RegisterImageRoute(nil, srv)
+	image.RegisterCampaignRoute(nil, srv)
+	image.RegisterTemplateRoute(nil, srv)
+	host.RegisterHostRoute(nil, srv)
+	host.RegisterClusterRoute(nil, srv)
+	storage.RegisterStorageRoute(nil, srv)
+	subscription.RegisterSubscriptionRoute(nil, srv)
+	usage.RegisterUsageRoute(nil, srv)
+	app.RegisterAppRoute(nil, srv)
+	app.RegisterManifestRoute(nil, srv)
+	app.RegisterDBUserRoute(nil, srv)
+	app.RegisterMigrationRoute(nil, srv)
+	app.RegisterAppResourceRoute(nil, srv)
+	backup.RegisterBackupRoute(nil, srv)
+	alert.RegisterAlertRoute(nil, srv)
+	events.RegisterWebhookRoute(nil, srv)
+	RegisterOpenAPIRoute(srv)
+
+	return srv.Routes()
+}
@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func TestDocument(t *testing.T) {
+	routes := Routes()
+
+	for _, r := range routes {
+		dr, ok := r.(router.DocRoute)
+		if !ok || dr.Doc().ID == "" {
+			t.Errorf("%s %s has no doc", r.Method(), r.Path())
+		}
+	}
+
+	doc, err := NewDocument(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ioutil.ReadFile("../../openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(append(data, '\n'), file) {
+		t.Error("openapi.json is out of date,run go generate ./api/client/v1")
+	}
+}
@@ -30,10 +30,41 @@ func RegisterSiteRoute(bankend siteBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewPostRoute("/manager/sites", r.postSite),
-		router.NewGetRoute("/manager/sites", r.listSites),
-		router.NewPutRoute("/manager/sites/{id}", r.updateSite),
-		router.NewDeleteRoute("/manager/sites/{id}", r.deleteSite),
+		router.NewPostRoute("/manager/sites", r.postSite, router.WithDoc(router.Doc{
+			ID:       "postSite",
+			Tags:     []string{"sites"},
+			Summary:  "增加站点",
+			Body:     api.SiteConfig{},
+			Code:     http.StatusCreated,
+			Response: api.ObjectResponse{},
+		})),
+		router.NewPostRoute("/manager/sites/preflight", r.preflightSite, router.WithDoc(router.Doc{
+			ID:       "preflightSite",
+			Tags:     []string{"sites"},
+			Summary:  "站点预检，检查API连通性、版本、CRD、exec服务、prometheus operator及存储类，不保存站点",
+			Body:     api.SiteConfig{},
+			Response: api.SitePreflight{},
+		})),
+		router.NewGetRoute("/manager/sites", r.listSites, router.WithDoc(router.Doc{
+			ID:       "listSites",
+			Tags:     []string{"sites"},
+			Summary:  "查询站点",
+			Query:    api.SiteListQuery{},
+			Response: api.SitesResponse{},
+			Paged:    true,
+		})),
+		router.NewPutRoute("/manager/sites/{id}", r.updateSite, router.WithDoc(router.Doc{
+			ID:      "updateSite",
+			Tags:    []string{"sites"},
+			Summary: "更新站点",
+			Body:    api.SiteOptions{},
+		})),
+		router.NewDeleteRoute("/manager/sites/{id}", r.deleteSite, router.WithDoc(router.Doc{
+			ID:      "deleteSite",
+			Tags:    []string{"sites"},
+			Summary: "删除站点",
+			Code:    http.StatusNoContent,
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -41,8 +72,10 @@ func RegisterSiteRoute(bankend siteBankend, routers router.Adder) {
 
 type siteBankend interface {
 	Add(ctx context.Context, config api.SiteConfig) (api.Site, error)
+	Preflight(ctx context.Context, config api.SiteConfig) (api.SitePreflight, error)
 	Set(ctx context.Context, id string, opts api.SiteOptions) error
 	List(ctx context.Context, id, name string) ([]api.Site, error)
+	ListPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error)
 	Delete(ctx context.Context, id string) error
 }
 
@@ -101,6 +134,30 @@ func (sr siteRoute) postSite(ctx context.Context, w http.ResponseWriter, r *http
 	}, nil
 }
 
+func (sr siteRoute) preflightSite(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	req := api.SiteConfig{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if req.Path == "" {
+		req.Path = ConfigPath(req.Domain, req.Port)
+	}
+
+	out, err := sr.bankend.Preflight(ctx, req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
 func ConfigPath(domain string, port int) string {
 	// lijj32: windows does not allow file name contains character ":", so change it to under-bar("_")
 	return filepath.Join(siteConfigPath, fmt.Sprintf("%s_%d", domain, port))
@@ -117,6 +174,8 @@ type listSiteRequest struct {
 	// in: query
 	// required: false
 	Name string `json:"name"`
+
+	api.ListRequest
 }
 
 // list sites info
@@ -137,11 +196,29 @@ func (sr siteRoute) listSites(ctx context.Context, w http.ResponseWriter, r *htt
 	//
 	//     Responses:
 	//       200: listSitesResponseWrapper
+	//       400: ErrorResponse
 	//       500: ErrorResponse
 
 	id := r.FormValue("id")
 	name := r.FormValue("name")
 
+	if api.IsListRequest(r.URL.Query()) {
+		opts, err := api.ParseListOptions(r.URL.Query(), api.SiteListFields)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+
+		opts.AddFilter("id", id)
+		opts.AddFilter("name", name)
+
+		resp, err := sr.bankend.ListPage(ctx, opts)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		return http.StatusOK, resp, nil
+	}
+
 	list, err := sr.bankend.List(ctx, id, name)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
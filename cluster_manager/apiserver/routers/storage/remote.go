@@ -15,15 +15,63 @@ func RegisterStorageRoute(bankend storageBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewPostRoute("/manager/storages/remote", r.postRemoteStorage),
-		router.NewGetRoute("/manager/storages/remote", r.listRemoteStorages),
-		router.NewPutRoute("/manager/storages/remote/{id}", r.updateRemoteStorage),
-		router.NewDeleteRoute("/manager/storages/remote/{id}", r.deleteRemoteStorage),
-
-		router.NewGetRoute("/manager/storages/remote/{storage}/pools", r.listRemoteStoragePools),
-		router.NewPostRoute("/manager/storages/remote/{storage}/pools", r.postRemoteStoragePools),
-		router.NewPutRoute("/manager/storages/remote/{storage}/pools/{pool}", r.updateRemoteStoragePool),
-		router.NewDeleteRoute("/manager/storages/remote/{storage}/pools/{pool}", r.deleteRemoteStoragePool),
+		router.NewPostRoute("/manager/storages/remote", r.postRemoteStorage, router.WithDoc(router.Doc{
+			ID:       "postRemoteStorage",
+			Tags:     []string{"storages"},
+			Summary:  "增加外置存储",
+			Body:     api.RemoteStorageConfig{},
+			Code:     http.StatusCreated,
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewGetRoute("/manager/storages/remote", r.listRemoteStorages, router.WithDoc(router.Doc{
+			ID:       "listRemoteStorages",
+			Tags:     []string{"storages"},
+			Summary:  "查询外置存储",
+			Query:    api.RemoteStorageListQuery{},
+			Response: api.RemoteStoragesResponse{},
+		})),
+		router.NewPutRoute("/manager/storages/remote/{id}", r.updateRemoteStorage, router.WithDoc(router.Doc{
+			ID:       "updateRemoteStorage",
+			Tags:     []string{"storages"},
+			Summary:  "更新外置存储",
+			Body:     api.RemoteStorageOptions{},
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewDeleteRoute("/manager/storages/remote/{id}", r.deleteRemoteStorage, router.WithDoc(router.Doc{
+			ID:      "deleteRemoteStorage",
+			Tags:    []string{"storages"},
+			Summary: "删除外置存储",
+			Code:    http.StatusNoContent,
+		})),
+
+		router.NewGetRoute("/manager/storages/remote/{storage}/pools", r.listRemoteStoragePools, router.WithDoc(router.Doc{
+			ID:       "listRemoteStoragePools",
+			Tags:     []string{"storages"},
+			Summary:  "查询外置存储池",
+			Query:    api.IDNameQuery{},
+			Response: api.RemoteStoragePoolsResponse{},
+		})),
+		router.NewPostRoute("/manager/storages/remote/{storage}/pools", r.postRemoteStoragePools, router.WithDoc(router.Doc{
+			ID:       "postRemoteStoragePool",
+			Tags:     []string{"storages"},
+			Summary:  "增加外置存储池",
+			Body:     api.RemoteStoragePoolConfig{},
+			Code:     http.StatusCreated,
+			Response: api.TaskObjectResponse{},
+		})),
+		router.NewPutRoute("/manager/storages/remote/{storage}/pools/{pool}", r.updateRemoteStoragePool, router.WithDoc(router.Doc{
+			ID:       "updateRemoteStoragePool",
+			Tags:     []string{"storages"},
+			Summary:  "更新外置存储池",
+			Body:     api.RemoteStoragePoolOptions{},
+			Response: api.ObjectResponse{},
+		})),
+		router.NewDeleteRoute("/manager/storages/remote/{storage}/pools/{pool}", r.deleteRemoteStoragePool, router.WithDoc(router.Doc{
+			ID:      "deleteRemoteStoragePool",
+			Tags:    []string{"storages"},
+			Summary: "删除外置存储池",
+			Code:    http.StatusNoContent,
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -0,0 +1,157 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterSubscriptionRoute(bankend subscriptionBankend, routers router.Adder) {
+	r := &subscriptionRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/subscriptions", r.listSubscriptions, router.WithDoc(router.Doc{
+			ID:       "listSubscriptions",
+			Tags:     []string{"subscriptions"},
+			Summary:  "查询订阅，限定订阅的请求只返回自身订阅",
+			Query:    api.SubscriptionListQuery{},
+			Response: api.SubscriptionsResponse{},
+		})),
+		router.NewPostRoute("/manager/subscriptions", r.postSubscription, router.WithDoc(router.Doc{
+			ID:       "postSubscription",
+			Tags:     []string{"subscriptions"},
+			Summary:  "增加订阅及配额",
+			Body:     api.SubscriptionConfig{},
+			Code:     http.StatusCreated,
+			Response: api.ObjectResponse{},
+		})),
+		router.NewGetRoute("/manager/subscriptions/{id}", r.getSubscription, router.WithDoc(router.Doc{
+			ID:       "getSubscription",
+			Tags:     []string{"subscriptions"},
+			Summary:  "查询订阅详情",
+			Response: api.Subscription{},
+		})),
+		router.NewPutRoute("/manager/subscriptions/{id}", r.setSubscription, router.WithDoc(router.Doc{
+			ID:       "setSubscription",
+			Tags:     []string{"subscriptions"},
+			Summary:  "更新订阅及配额",
+			Body:     api.SubscriptionOptions{},
+			Response: api.Subscription{},
+		})),
+		router.NewDeleteRoute("/manager/subscriptions/{id}", r.deleteSubscription, router.WithDoc(router.Doc{
+			ID:      "deleteSubscription",
+			Tags:    []string{"subscriptions"},
+			Summary: "删除订阅，订阅下存在服务时不能删除",
+			Code:    http.StatusNoContent,
+		})),
+		router.NewGetRoute("/manager/subscriptions/{id}/usage", r.getUsage, router.WithDoc(router.Doc{
+			ID:       "getSubscriptionUsage",
+			Tags:     []string{"subscriptions"},
+			Summary:  "查询订阅的配额及各服务的资源用量",
+			Response: api.SubscriptionUsage{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type subscriptionBankend interface {
+	AddSubscription(ctx context.Context, config api.SubscriptionConfig) (api.ObjectResponse, error)
+	SetSubscription(ctx context.Context, id string, opts api.SubscriptionOptions) (api.Subscription, error)
+	GetSubscription(ctx context.Context, id string) (api.Subscription, error)
+	ListSubscriptions(ctx context.Context, name string) (api.SubscriptionsResponse, error)
+	DeleteSubscription(ctx context.Context, id string) error
+
+	Usage(ctx context.Context, id string) (api.SubscriptionUsage, error)
+}
+
+type subscriptionRoute struct {
+	bankend subscriptionBankend
+
+	routes []router.Route
+}
+
+func (sr subscriptionRoute) Routes() []router.Route {
+	return sr.routes
+}
+
+func (sr subscriptionRoute) listSubscriptions(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	list, err := sr.bankend.ListSubscriptions(ctx, r.FormValue("name"))
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (sr subscriptionRoute) postSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	req := api.SubscriptionConfig{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	obj, err := sr.bankend.AddSubscription(ctx, req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusCreated, obj, nil
+}
+
+func (sr subscriptionRoute) getSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	out, err := sr.bankend.GetSubscription(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (sr subscriptionRoute) setSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	req := api.SubscriptionOptions{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	out, err := sr.bankend.SetSubscription(ctx, vars["id"], req)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
+
+func (sr subscriptionRoute) deleteSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	err := sr.bankend.DeleteSubscription(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+func (sr subscriptionRoute) getUsage(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	out, err := sr.bankend.Usage(ctx, vars["id"])
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, out, nil
+}
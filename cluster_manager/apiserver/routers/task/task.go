@@ -14,7 +14,14 @@ func RegisterTaskRoute(bankend taskBankend, routers router.Adder) {
 	}
 
 	r.routes = []router.Route{
-		router.NewGetRoute("/manager/tasks", r.listTasks),
+		router.NewGetRoute("/manager/tasks", r.listTasks, router.WithDoc(router.Doc{
+			ID:       "listTasks",
+			Tags:     []string{"tasks"},
+			Summary:  "查询任务列表",
+			Query:    api.TaskListQuery{},
+			Response: api.TasksResponse{},
+			Paged:    true,
+		})),
 	}
 
 	routers.AddRouter(r)
@@ -22,6 +29,7 @@ func RegisterTaskRoute(bankend taskBankend, routers router.Adder) {
 
 type taskBankend interface {
 	List(ctx context.Context, id, relateID, action, state string) ([]api.Task, error)
+	ListPage(ctx context.Context, opts api.ListOptions) (api.ListResponse, error)
 }
 
 type taskRoute struct {
@@ -52,6 +60,8 @@ type listTaskRequest struct {
 	// in: query
 	// required: false
 	Status string `json:"status"`
+
+	api.ListRequest
 }
 
 // list tasks info
@@ -72,6 +82,7 @@ func (sr taskRoute) listTasks(ctx context.Context, w http.ResponseWriter, r *htt
 	//
 	//     Responses:
 	//       200: listTasksResponseWrapper
+	//       400: ErrorResponse
 	//       500: ErrorResponse
 
 	id := r.FormValue("id")
@@ -79,6 +90,25 @@ func (sr taskRoute) listTasks(ctx context.Context, w http.ResponseWriter, r *htt
 	action := r.FormValue("action")
 	status := r.FormValue("status")
 
+	if api.IsListRequest(r.URL.Query()) {
+		opts, err := api.ParseListOptions(r.URL.Query(), api.TaskListFields)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+
+		opts.AddFilter("id", id)
+		opts.AddFilter("relate_id", relateID)
+		opts.AddFilter("action", action)
+		opts.AddFilter("status", status)
+
+		resp, err := sr.bankend.ListPage(ctx, opts)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		return http.StatusOK, resp, nil
+	}
+
 	tasks, err := sr.bankend.List(ctx, id, relateID, action, status)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -0,0 +1,202 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"github.com/upmio/dbscale-kube/pkg/server"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+func RegisterUsageRoute(bankend usageBankend, routers router.Adder) {
+	r := &usageRoute{
+		bankend: bankend,
+	}
+
+	r.routes = []router.Route{
+		router.NewGetRoute("/manager/usage", r.listUsage, router.WithDoc(router.Doc{
+			ID:       "listUsage",
+			Tags:     []string{"usage"},
+			Summary:  "按日或按月汇总服务或订阅的资源用量，限定订阅的请求只返回自身订阅",
+			Query:    api.UsageListQuery{},
+			Response: api.UsageResponse{},
+		})),
+		router.NewGetRoute("/manager/usage/export", r.exportUsage, router.WithDoc(router.Doc{
+			ID:      "exportUsage",
+			Tags:    []string{"usage"},
+			Summary: "导出资源用量，返回text/csv，查询参数同listUsage",
+			Query:   api.UsageListQuery{},
+		})),
+	}
+
+	routers.AddRouter(r)
+}
+
+type usageRoute struct {
+	bankend usageBankend
+
+	routes []router.Route
+}
+
+func (ur usageRoute) Routes() []router.Route {
+	return ur.routes
+}
+
+type usageBankend interface {
+	ListUsage(ctx context.Context, query api.UsageQuery) (api.UsageResponse, error)
+}
+
+func parseUsageQuery(r *http.Request) (api.UsageQuery, error) {
+	var (
+		err   error
+		query = api.UsageQuery{
+			Subscription: r.FormValue("subscription_id"),
+			App:          r.FormValue("app_id"),
+			Period:       r.FormValue("period"),
+			GroupBy:      r.FormValue("group_by"),
+		}
+	)
+
+	if query.Period == "" {
+		query.Period = api.UsagePeriodDay
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = api.UsageGroupByApp
+	}
+
+	if query.Since, err = api.ParseAuditTime(r.FormValue("since")); err != nil {
+		return query, err
+	}
+
+	if query.Until, err = api.ParseAuditTime(r.FormValue("until")); err != nil {
+		return query, err
+	}
+
+	return query, query.Valid()
+}
+
+func (ur usageRoute) listUsage(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	query, err := parseUsageQuery(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	list, err := ur.bankend.ListUsage(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, list, nil
+}
+
+func (ur usageRoute) exportUsage(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	query, err := parseUsageQuery(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	list, err := ur.bankend.ListUsage(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	data, err := encodeCSV(list)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, server.RawResponse{
+		ContentType: "text/csv",
+		Filename:    "usage-" + query.Period + ".csv",
+		Body:        data,
+	}, nil
+}
+
+// encodeCSV 每行一条记录，存储及备份按性能等级、备份端点展开为列
+func encodeCSV(list api.UsageResponse) ([]byte, error) {
+	levels := make(map[string]struct{})
+	endpoints := make(map[string]struct{})
+
+	for _, rec := range list {
+		for level := range rec.Storage {
+			levels[string(level)] = struct{}{}
+		}
+		for level := range rec.StorageUsed {
+			levels[string(level)] = struct{}{}
+		}
+		for endpoint := range rec.BackupStorage {
+			endpoints[endpoint] = struct{}{}
+		}
+	}
+
+	levelColumns := sortedKeys(levels)
+	endpointColumns := sortedKeys(endpoints)
+
+	header := []string{"period", "subscription_id", "app_id", "app_name", "hours",
+		"cpu_millicore_hours", "memory_mib_hours", "net_bandwidth_mbps_hours"}
+	for _, level := range levelColumns {
+		header = append(header, "storage_"+level+"_mib_hours", "storage_used_"+level+"_mib_hours")
+	}
+	for _, endpoint := range endpointColumns {
+		header = append(header, "backup_"+endpoint+"_mib_hours")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	cw := csv.NewWriter(buf)
+
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, rec := range list {
+		row := []string{rec.Period, csvCell(rec.Subscription), csvCell(rec.App.ID), csvCell(rec.App.Name),
+			strconv.Itoa(rec.Hours),
+			strconv.FormatInt(rec.CPU, 10),
+			strconv.FormatInt(rec.Memory, 10),
+			strconv.FormatInt(rec.Bandwidth, 10),
+		}
+
+		for _, level := range levelColumns {
+			row = append(row,
+				strconv.FormatInt(rec.Storage[api.Performance(level)], 10),
+				strconv.FormatInt(rec.StorageUsed[api.Performance(level)], 10))
+		}
+		for _, endpoint := range endpointColumns {
+			row = append(row, strconv.FormatInt(rec.BackupStorage[endpoint], 10))
+		}
+
+		if err := cw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	cw.Flush()
+
+	return buf.Bytes(), cw.Error()
+}
+
+// csvCell 以 = + - @ 及制表符、回车开头的单元格在表格软件中作为公式执行，加单引号前缀作为文本
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+
+	return v
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
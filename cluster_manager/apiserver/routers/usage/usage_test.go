@@ -0,0 +1,33 @@
+package usage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+func TestEncodeCSVFormula(t *testing.T) {
+	list := api.UsageResponse{
+		{Period: "2021-03", Subscription: "@t1", App: api.NewIDName("-a1", "=HYPERLINK(\"x\")")},
+		{Period: "2021-03", Subscription: "t2", App: api.NewIDName("a2", "app2")},
+	}
+
+	data, err := encodeCSV(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines but got %q", data)
+	}
+
+	if !strings.HasPrefix(lines[1], `2021-03,'@t1,'-a1,"'=HYPERLINK(""x"")",`) {
+		t.Errorf("expected the formulas neutralised but got %q", lines[1])
+	}
+
+	if !strings.HasPrefix(lines[2], "2021-03,t2,a2,app2,") {
+		t.Errorf("unexpected line %q", lines[2])
+	}
+}
@@ -37,7 +37,9 @@ CREATE TABLE `tbl_app` (
   `created_timestamp` timestamp NULL DEFAULT NULL COMMENT '创建时间，用于展示。',
   `modified_user` varchar(64) DEFAULT NULL COMMENT '修改用户，用于展示。',
   `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
-  PRIMARY KEY (`id`)
+  PRIMARY KEY (`id`),
+  KEY `idx_subscription_id` (`subscription_id`),
+  KEY `idx_created_timestamp` (`created_timestamp`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -66,7 +68,11 @@ CREATE TABLE `tbl_backup_file` (
   `created_user` varchar(64) NOT NULL COMMENT '创建用户，用于展示。',
   `created_timestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间，用于展示。',
   `finished_timestamp` timestamp NULL DEFAULT NULL COMMENT '完成时间，用于展示。',
-  PRIMARY KEY (`id`)
+  PRIMARY KEY (`id`),
+  KEY `idx_app_id` (`app_id`),
+  KEY `idx_site_id` (`site_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_created_timestamp` (`created_timestamp`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -151,7 +157,8 @@ CREATE TABLE `tbl_host` (
   `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
   PRIMARY KEY (`id`),
   UNIQUE KEY `host_ip_UNIQUE` (`host_ip`),
-  KEY `idx_cluster_id` (`cluster_id`)
+  KEY `idx_cluster_id` (`cluster_id`),
+  KEY `idx_created_timestamp` (`created_timestamp`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -178,10 +185,15 @@ CREATE TABLE `tbl_image`
     `config_template`    text                 DEFAULT NULL COMMENT 'config_template。',
     `pod_template`       text                 DEFAULT NULL COMMENT 'pod_template。',
     `description`        varchar(512)         DEFAULT NULL COMMENT '描述信息。',
+    `digest`             varchar(128) NOT NULL DEFAULT '' COMMENT '导入时镜像tag对应的digest，单元使用digest固定镜像',
+    `signature_status`   varchar(32)  NOT NULL DEFAULT '' COMMENT '签名验证结果，verified/unsigned/invalid，未配置公钥时为空',
+    `drift_digest`       varchar(128) NOT NULL DEFAULT '' COMMENT '站点仓库中tag当前对应的digest，与digest不同时非空',
+    `deprecated_at`      timestamp   NULL     DEFAULT NULL COMMENT '弃用时间，之后不能用于新建服务',
+    `eol_at`             timestamp   NULL     DEFAULT NULL COMMENT '停止支持(EOL)时间',
     `created_timestamp`  timestamp   NULL     DEFAULT NULL COMMENT '创建时间，用于展示。',
     `modified_timestamp` timestamp   NULL     DEFAULT NULL COMMENT '修改时间，用于展示。',
     PRIMARY KEY (`id`),
-    UNIQUE KEY `idx_version` (`type`, `version_major`, `version_minor`, `version_build`, `version_patch`)
+    UNIQUE KEY `idx_version` (`type`, `arch`, `version_major`, `version_minor`, `version_build`, `version_patch`)
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
@@ -341,7 +353,10 @@ CREATE TABLE `tbl_task` (
   `created_at` timestamp(6) NULL DEFAULT NULL COMMENT '创建时间',
   `finished_at` timestamp(6) NULL DEFAULT NULL COMMENT '完成时间',
   PRIMARY KEY (`ai`),
-  UNIQUE KEY `id_UNIQUE` (`id`)
+  UNIQUE KEY `id_UNIQUE` (`id`),
+  KEY `idx_relate_id` (`relate_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_created_at` (`created_at`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1160 DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -383,6 +398,301 @@ CREATE TABLE `tbl_backup_endpoint` (
 ) ENGINE=InnoDB AUTO_INCREMENT=1160 DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+DROP TABLE IF EXISTS `tbl_app_alert`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `tbl_app_alert` (
+    `app_id`            varchar(64) NOT NULL COMMENT '关联的服务唯一标识符。',
+    `enabled`           tinyint(4) NOT NULL COMMENT '是否启用告警。值范围: true = 1, false = 0',
+    `thresholds`        varchar(1024) NOT NULL COMMENT '告警阈值Json',
+    `created_user`      varchar(64) NOT NULL COMMENT '创建用户，用于展示。',
+    `created_timestamp` timestamp NULL DEFAULT NULL COMMENT '创建时间，用于展示。',
+    `modified_user`     varchar(64) DEFAULT NULL COMMENT '修改用户，用于展示。',
+    `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
+    PRIMARY KEY (`app_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_notification_channel`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `tbl_notification_channel` (
+    `id`                varchar(64) NOT NULL COMMENT '唯一标识符。',
+    `name`              varchar(64) NOT NULL COMMENT '渠道名称',
+    `type`              varchar(32) NOT NULL COMMENT '渠道类型，webhook或email',
+    `channel_config`    varchar(2048) NOT NULL COMMENT '配置Json',
+    `enabled`           tinyint(4) NOT NULL COMMENT '是否启用。值范围: true = 1, false = 0',
+    `created_user`      varchar(64) NOT NULL COMMENT '创建用户，用于展示。',
+    `created_timestamp` timestamp NULL DEFAULT NULL COMMENT '创建时间，用于展示。',
+    `modified_user`     varchar(64) DEFAULT NULL COMMENT '修改用户，用于展示。',
+    `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `name_UNIQUE` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_audit_log`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 审计记录只插入不修改
+CREATE TABLE `tbl_audit_log` (
+    `id`                varchar(64) NOT NULL COMMENT '唯一标识符。',
+    `user`              varchar(64) NOT NULL COMMENT '操作用户',
+    `action`            varchar(128) NOT NULL COMMENT '操作',
+    `route`             varchar(256) NOT NULL COMMENT '请求路由，METHOD 路径模板',
+    `path`              varchar(512) NOT NULL COMMENT '请求路径',
+    `app_id`            varchar(64) NOT NULL COMMENT '服务',
+    `remote_addr`       varchar(64) NOT NULL COMMENT '请求来源地址',
+    `code`              int(11) NOT NULL COMMENT '响应状态码',
+    `error`             varchar(2048) NOT NULL COMMENT '错误信息',
+    `request`           mediumtext COMMENT '请求内容Json，敏感字段已屏蔽',
+    `changes`           mediumtext COMMENT '变更前后对象Json，敏感字段已屏蔽',
+    `created_timestamp` timestamp(3) NOT NULL COMMENT '操作时间',
+    PRIMARY KEY (`id`),
+    KEY `created_timestamp_INDEX` (`created_timestamp`),
+    KEY `user_INDEX` (`user`),
+    KEY `app_id_INDEX` (`app_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_webhook`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `tbl_webhook` (
+    `id`                varchar(64) NOT NULL COMMENT '唯一标识符。',
+    `name`              varchar(64) NOT NULL COMMENT '名称',
+    `url`               varchar(1024) NOT NULL COMMENT '投递地址',
+    `secret`            varchar(512) NOT NULL COMMENT '签名密钥，加密存储',
+    `event_types`       varchar(256) NOT NULL COMMENT '订阅的事件类型，逗号分隔，空表示全部',
+    `app_id`            varchar(64) NOT NULL COMMENT '只订阅该服务的事件，空表示全部',
+    `enabled`           tinyint(4) NOT NULL COMMENT '是否启用。值范围: true = 1, false = 0',
+    `created_user`      varchar(64) NOT NULL COMMENT '创建用户，用于展示。',
+    `created_timestamp` timestamp NULL DEFAULT NULL COMMENT '创建时间，用于展示。',
+    `modified_user`     varchar(64) DEFAULT NULL COMMENT '修改用户，用于展示。',
+    `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `name_UNIQUE` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_webhook_delivery`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `tbl_webhook_delivery` (
+    `id`                varchar(64) NOT NULL COMMENT '唯一标识符。',
+    `webhook_id`        varchar(64) NOT NULL COMMENT '所属webhook',
+    `event_id`          bigint(20) unsigned NOT NULL COMMENT '事件序号',
+    `event_type`        varchar(64) NOT NULL COMMENT '事件类型',
+    `payload`           mediumtext COMMENT '投递内容Json',
+    `status`            varchar(32) NOT NULL COMMENT '状态，pending/success/failed',
+    `attempts`          int(11) NOT NULL COMMENT '已尝试次数',
+    `response_code`     int(11) NOT NULL COMMENT '最后一次响应状态码',
+    `error`             varchar(2048) NOT NULL COMMENT '最后一次错误信息',
+    `created_timestamp` timestamp(3) NOT NULL COMMENT '创建时间',
+    `updated_timestamp` timestamp(3) NULL DEFAULT NULL COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `webhook_id_INDEX` (`webhook_id`,`created_timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_idempotency_key`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 携带 Idempotency-Key 的 POST/PUT 请求及其响应，重试时返回保存的响应
+CREATE TABLE `tbl_idempotency_key` (
+    `idempotency_key`   varchar(128) NOT NULL COMMENT '请求头 Idempotency-Key',
+    `method`            varchar(16) NOT NULL COMMENT '请求方法',
+    `path`              varchar(256) NOT NULL COMMENT '请求路径',
+    `request_hash`      varchar(64) NOT NULL COMMENT '请求 query 及 body 的 sha256',
+    `done`              tinyint(4) NOT NULL COMMENT '请求是否已完成。值范围: true = 1, false = 0',
+    `code`              int(11) NOT NULL COMMENT '响应状态码',
+    `response`          mediumtext COMMENT '响应内容Json',
+    `error`             varchar(2048) NOT NULL COMMENT '错误信息',
+    `etag`              varchar(64) NOT NULL COMMENT '响应头 ETag',
+    `created_timestamp` timestamp(3) NOT NULL COMMENT '创建时间',
+    PRIMARY KEY (`idempotency_key`,`method`,`path`),
+    KEY `created_timestamp_INDEX` (`created_timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_resource_revision`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 资源版本，用于 ETag/If-Match，没有记录时版本为0
+CREATE TABLE `tbl_resource_revision` (
+    `kind`               varchar(32) NOT NULL COMMENT '资源类型，app/host/image/backup_strategy/backup_endpoint',
+    `resource_id`        varchar(64) NOT NULL COMMENT '资源ID',
+    `revision`           bigint(20) NOT NULL COMMENT '版本，每次修改加1',
+    `modified_timestamp` timestamp(3) NULL DEFAULT NULL COMMENT '修改时间',
+    PRIMARY KEY (`kind`,`resource_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_image_campaign`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `tbl_image_campaign` (
+    `id`                varchar(64) NOT NULL COMMENT '唯一标识符。',
+    `name`              varchar(64) NOT NULL COMMENT '名称',
+    `image_id`          varchar(64) NOT NULL COMMENT '升级的目标镜像',
+    `state`             varchar(32) NOT NULL COMMENT 'running/completed/canceled',
+    `spec`              text        NOT NULL COMMENT '维护窗口及并发数，json',
+    `apps`              mediumtext  NOT NULL COMMENT '各服务的升级进度，json',
+    `created_user`      varchar(64) NOT NULL COMMENT '创建用户，用于展示。',
+    `created_timestamp` timestamp NULL DEFAULT NULL COMMENT '创建时间，用于展示。',
+    `modified_user`     varchar(64) DEFAULT NULL COMMENT '修改用户，用于展示。',
+    `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
+    PRIMARY KEY (`id`),
+    KEY `state_INDEX` (`state`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_image_template_revision`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 镜像模板及脚本的历史版本，只插入不修改，回滚生成新的版本
+CREATE TABLE `tbl_image_template_revision` (
+    `id`                varchar(64) NOT NULL COMMENT '唯一标识符。',
+    `image_id`          varchar(64) NOT NULL COMMENT '所属镜像',
+    `revision`          int(11) NOT NULL COMMENT '版本号，从1开始',
+    `key_sets`          text COMMENT 'key_set',
+    `config_template`   text COMMENT '配置模板内容',
+    `scripts`           mediumtext COMMENT '脚本内容，脚本类型为key的Json',
+    `reason`            varchar(512) NOT NULL COMMENT '修改原因',
+    `created_user`      varchar(64) NOT NULL COMMENT '创建用户，用于展示。',
+    `created_timestamp` timestamp NULL DEFAULT NULL COMMENT '创建时间，用于展示。',
+    `modified_user`     varchar(64) DEFAULT NULL COMMENT '修改用户，用于展示。',
+    `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `image_revision_UNIQUE` (`image_id`,`revision`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_subscription`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 租户订阅及配额，服务通过subscription_id属于订阅
+CREATE TABLE `tbl_subscription` (
+    `id`                   varchar(128) NOT NULL COMMENT '订阅号，与服务的subscription_id对应',
+    `name`                 varchar(64) NOT NULL COMMENT '名称',
+    `description`          varchar(512) DEFAULT NULL COMMENT '描述',
+    `enabled`              tinyint(4) NOT NULL COMMENT '是否启用。值范围: true = 1, false = 0',
+    `quota_cpu`            bigint(20) NOT NULL DEFAULT '0' COMMENT 'CPU配额，单位millicore，0表示不限制',
+    `quota_memory`         bigint(20) NOT NULL DEFAULT '0' COMMENT '内存配额，单位MiB，0表示不限制',
+    `quota_storage`        varchar(512) DEFAULT NULL COMMENT '各性能等级的存储配额，单位MiB，性能等级为key的Json',
+    `quota_apps`           int(11) NOT NULL DEFAULT '0' COMMENT '服务数量配额，0表示不限制',
+    `quota_backup_storage` bigint(20) NOT NULL DEFAULT '0' COMMENT '备份存储配额，单位MiB，0表示不限制',
+    `created_user`         varchar(64) NOT NULL COMMENT '创建用户，用于展示。',
+    `created_timestamp`    timestamp NULL DEFAULT NULL COMMENT '创建时间，用于展示。',
+    `modified_user`        varchar(64) DEFAULT NULL COMMENT '修改用户，用于展示。',
+    `modified_timestamp`   timestamp NULL DEFAULT NULL COMMENT '修改时间，用于展示。',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `name_UNIQUE` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_usage_sample`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 服务每小时的资源用量采样，用于计量及分摊费用
+CREATE TABLE `tbl_usage_sample` (
+    `app_id`            varchar(64) NOT NULL COMMENT '服务',
+    `app_name`          varchar(64) NOT NULL COMMENT '服务名称，服务删除后用于展示',
+    `subscription_id`   varchar(128) DEFAULT NULL COMMENT '所属订阅',
+    `cpu`               bigint(20) NOT NULL DEFAULT '0' COMMENT '分配的CPU，单位millicore',
+    `memory`            bigint(20) NOT NULL DEFAULT '0' COMMENT '分配的内存，单位MiB',
+    `net_bandwidth`     bigint(20) NOT NULL DEFAULT '0' COMMENT '分配的网络带宽，单位Mbps',
+    `storage`           varchar(512) DEFAULT NULL COMMENT '分配的存储，单位MiB，性能等级为key的Json',
+    `storage_used`      varchar(512) DEFAULT NULL COMMENT '已使用的存储，单位MiB，性能等级为key的Json',
+    `backup_storage`    varchar(1024) DEFAULT NULL COMMENT '备份文件大小，单位MiB，备份端点为key的Json',
+    `sampled_timestamp` timestamp NOT NULL COMMENT '采样时间，按小时取整',
+    PRIMARY KEY (`app_id`,`sampled_timestamp`),
+    KEY `subscription_sampled` (`subscription_id`,`sampled_timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_db_user_credential`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 数据库用户的密码记录，只保存密码哈希，用于密码历史检查、过期提醒及轮换
+CREATE TABLE `tbl_db_user_credential` (
+    `app_id`                varchar(64) NOT NULL COMMENT '服务',
+    `name`                  varchar(64) NOT NULL COMMENT '用户名',
+    `ip`                    varchar(64) NOT NULL COMMENT '用户的host',
+    `password_history`      varchar(2048) DEFAULT NULL COMMENT '最近使用过的密码的加盐哈希，逗号分隔，最新的在前',
+    `generated`             tinyint(4) NOT NULL COMMENT '密码是否由服务端生成。值范围: true = 1, false = 0',
+    `site_id`               varchar(64) DEFAULT NULL COMMENT 'Secret所在站点',
+    `secret_namespace`      varchar(64) DEFAULT NULL COMMENT 'Secret所在namespace',
+    `secret_name`           varchar(64) DEFAULT NULL COMMENT '保存生成的密码的Secret',
+    `old_password_retained` tinyint(4) NOT NULL DEFAULT '0' COMMENT '双密码轮换后旧密码是否仍然有效',
+    `expiry_warned`         tinyint(4) NOT NULL DEFAULT '0' COMMENT '是否已发布即将过期事件',
+    `changed_timestamp`     timestamp NULL DEFAULT NULL COMMENT '密码修改时间',
+    PRIMARY KEY (`app_id`,`name`,`ip`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_db_user_spec`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 服务期望的数据库用户及权限，后台定期与实例中的用户比较
+CREATE TABLE `tbl_db_user_spec` (
+    `app_id`             varchar(64) NOT NULL COMMENT '服务',
+    `mode`               varchar(16) NOT NULL COMMENT 'report 只报告差异，enforce 修正差异',
+    `users`              text NOT NULL COMMENT '期望的用户及权限，json',
+    `status`             text DEFAULT NULL COMMENT '最近一次比较的结果，json',
+    `drifted`            tinyint(4) NOT NULL DEFAULT '0' COMMENT '最近一次比较是否存在差异。值范围: true = 1, false = 0',
+    `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '期望修改时间',
+    PRIMARY KEY (`app_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_schema_migration`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 服务数据库的迁移版本及执行记录
+CREATE TABLE `tbl_schema_migration` (
+    `app_id`              varchar(64) NOT NULL COMMENT '服务',
+    `schema_name`         varchar(64) NOT NULL COMMENT '数据库',
+    `version`             bigint(20) NOT NULL COMMENT '迁移版本，按从小到大执行',
+    `description`         varchar(256) DEFAULT NULL COMMENT '描述',
+    `sql_text`            mediumtext NOT NULL COMMENT '迁移的SQL',
+    `online`              tinyint(4) NOT NULL DEFAULT '0' COMMENT '是否使用在线DDL工具执行。值范围: true = 1, false = 0',
+    `checksum`            varchar(64) NOT NULL COMMENT 'SQL的sha256',
+    `state`               varchar(16) NOT NULL COMMENT 'pending, running, applied, failed, interrupted',
+    `error`               text DEFAULT NULL COMMENT '最近一次执行或验证的错误',
+    `validated_timestamp` timestamp NULL DEFAULT NULL COMMENT '最近一次验证通过的时间',
+    `applied_timestamp`   timestamp NULL DEFAULT NULL COMMENT '执行完成时间',
+    `duration`            bigint(20) NOT NULL DEFAULT '0' COMMENT '执行耗时，秒',
+    `created_timestamp`   timestamp NULL DEFAULT NULL COMMENT '上传时间',
+    PRIMARY KEY (`app_id`,`schema_name`,`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_schema_migration_lock`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 正在执行迁移的数据库，同一个数据库同时只有一个迁移任务
+CREATE TABLE `tbl_schema_migration_lock` (
+    `app_id`            varchar(64) NOT NULL COMMENT '服务',
+    `schema_name`       varchar(64) NOT NULL COMMENT '数据库',
+    `created_timestamp` timestamp NULL DEFAULT NULL COMMENT '加锁时间',
+    PRIMARY KEY (`app_id`,`schema_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+DROP TABLE IF EXISTS `tbl_host_maintenance`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+-- 主机维护模式的迁移计划及进度
+CREATE TABLE `tbl_host_maintenance` (
+    `host_id`            varchar(64) NOT NULL COMMENT '主机',
+    `state`              varchar(16) NOT NULL COMMENT 'draining, drained, failed, canceled',
+    `status`             mediumtext NOT NULL COMMENT '迁移计划及各单元进度(json)',
+    `modified_timestamp` timestamp NULL DEFAULT NULL COMMENT '更新时间',
+    PRIMARY KEY (`host_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 
 
 /*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
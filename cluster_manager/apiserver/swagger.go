@@ -5,7 +5,7 @@
 //
 // 		Host: localhost
 //     	Version: 1.0
-//      BasePath: /v1.0
+//      BasePath: /v1
 //      Schemes: http, https
 //      Contact: Hubery<fuguangrong@bsgchina.com>
 //      Consumes:
@@ -0,0 +1,774 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+var (
+	appColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"ARCH", "arch"},
+		{"STATE", "state"},
+		{"IMAGE", "spec.database.image.type"},
+		{"TASK", "task.status"},
+		{"CREATED", "created.timestamp"},
+	}
+
+	userColumns = []column{
+		{"NAME", "name"},
+		{"IP", "ip"},
+		{"AUTH_TYPE", "auth_type"},
+		{"PRIVILEGES", "db_privileges"},
+	}
+
+	credentialColumns = []column{
+		{"NAME", "name"},
+		{"IP", "ip"},
+		{"GENERATED", "generated"},
+		{"SECRET", "secret.name"},
+		{"OLD_RETAINED", "old_password_retained"},
+		{"EXPIRES_AT", "expires_at"},
+	}
+
+	driftColumns = []column{
+		{"NAME", "name"},
+		{"IP", "ip"},
+		{"TYPE", "type"},
+		{"CORRECTED", "corrected"},
+		{"ERROR", "error"},
+	}
+
+	manifestStepColumns = []column{
+		{"ACTION", "action"},
+		{"TARGET", "target"},
+		{"REASON", "reason"},
+	}
+
+	schemaColumns = []column{
+		{"NAME", "name"},
+		{"CHARACTER_SET", "character_set"},
+		{"SIZE", "size"},
+	}
+
+	migrationColumns = []column{
+		{"VERSION", "version"},
+		{"DESCRIPTION", "description"},
+		{"ONLINE", "online"},
+		{"STATE", "state"},
+		{"VALIDATED_AT", "validated_at"},
+		{"APPLIED_AT", "applied_at"},
+		{"ERROR", "error"},
+	}
+)
+
+func (c *cli) subscription() api.SubscriptionQuery {
+	return api.SubscriptionQuery{SubscriptionID: c.subscriptionID}
+}
+
+func appCommand() *command {
+	var (
+		q      api.AppListQuery
+		detail bool
+		file   string
+		state  string
+	)
+
+	return &command{
+		Use:   "apps",
+		Short: "管理服务",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询服务列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "app id")
+					fs.StringVar(&q.Name, "name", "", "app name")
+					fs.BoolVar(&detail, "detail", false, "list apps with the status of units")
+					listFlags(fs, &q.ListRequest)
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					q.SubscriptionID = c.subscriptionID
+
+					if isPaged(q.ListRequest) {
+						list := c.client.ListAppsPage
+						if detail {
+							list = c.client.ListAppsDetailPage
+						}
+
+						items, meta, err := list(ctx, q)
+						if err != nil {
+							return err
+						}
+
+						return c.printPage(items, meta, appColumns)
+					}
+
+					list := c.client.ListApps
+					if detail {
+						list = c.client.ListAppsDetail
+					}
+
+					apps, err := list(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(apps, appColumns)
+				},
+			},
+			{
+				Use:   "get",
+				Short: "查询服务详情",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					apps, err := c.client.ListAppsDetail(ctx, api.AppListQuery{
+						ID:             args[0],
+						SubscriptionID: c.subscriptionID,
+					})
+					if err != nil {
+						return err
+					}
+
+					if len(apps) == 0 {
+						return errors.Errorf("not found app %s", args[0])
+					}
+
+					return c.print(apps[0], nil)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "创建服务",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.AppConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostApp(ctx, c.subscription(), config)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "plan",
+				Short: "查看服务manifest的执行计划",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var manifest api.AppManifest
+
+					err := c.readInput(file, &manifest)
+					if err != nil {
+						return err
+					}
+
+					plan, err := c.client.PlanAppManifest(ctx, c.subscription(), manifest)
+					if err != nil {
+						return err
+					}
+
+					return c.print(plan.Steps, manifestStepColumns)
+				},
+			},
+			{
+				Use:   "apply",
+				Short: "执行服务manifest",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var manifest api.AppManifest
+
+					err := c.readInput(file, &manifest)
+					if err != nil {
+						return err
+					}
+
+					plan, err := c.client.ApplyAppManifest(ctx, c.subscription(), manifest)
+					if err != nil {
+						return err
+					}
+
+					return c.printTaskObject(ctx, api.TaskObjectResponse{
+						ObjectID:   plan.App.ID,
+						ObjectName: plan.App.Name,
+						TaskID:     plan.Task.ID,
+					}, nil)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除服务",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteApp(ctx, args[0], c.subscription())
+				},
+			},
+			{
+				Use:   "state",
+				Short: "启动或停止服务",
+				Args:  []string{"APP"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&state, "state", "", "passing or terminated")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					obj, err := c.client.UpdateAppState(ctx, args[0], c.subscription(), api.AppStateOptions{State: api.State(state)})
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "image",
+				Short: "升级服务镜像",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.AppImageOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateAppImage(ctx, args[0], c.subscription(), opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "resources",
+				Short: "更新服务资源",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.AppResourcesOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateAppResources(ctx, args[0], c.subscription(), opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "arch",
+				Short: "更新服务架构",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.AppArchOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateAppArch(ctx, args[0], c.subscription(), opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "config",
+				Short: "查询服务配置",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					config, err := c.client.ListAppConfig(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(config, nil)
+				},
+			},
+			{
+				Use:   "set-config",
+				Short: "更新服务配置",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.ConfigMapOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					return c.client.UpdateAppConfig(ctx, args[0], c.subscription(), opts)
+				},
+			},
+			{
+				Use:   "topology",
+				Short: "查询cmha拓扑",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					topology, err := c.client.GetCmhaTopology(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(topology, nil)
+				},
+			},
+		},
+	}
+}
+
+func unitCommand() *command {
+	var (
+		file   string
+		state  string
+		role   api.RoleSetOptions
+		backup string
+	)
+
+	return &command{
+		Use:   "units",
+		Short: "管理服务单元",
+		Subs: []*command{
+			{
+				Use:   "state",
+				Short: "启动或停止单元",
+				Args:  []string{"APP", "UNIT"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&state, "state", "", "passing or terminated")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					obj, err := c.client.UpdateUnitState(ctx, args[0], args[1], c.subscription(), api.AppStateOptions{State: api.State(state)})
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "rebuild",
+				Short: "重建单元",
+				Args:  []string{"APP", "UNIT"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.UnitRebuildOptions
+
+					if file != "" {
+						err := c.readInput(file, &opts)
+						if err != nil {
+							return err
+						}
+					}
+
+					obj, err := c.client.RebuildUnit(ctx, args[0], args[1], c.subscription(), opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "migrate",
+				Short: "迁移单元",
+				Args:  []string{"APP", "UNIT"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.UnitMigrateOptions
+
+					if file != "" {
+						err := c.readInput(file, &opts)
+						if err != nil {
+							return err
+						}
+					}
+
+					obj, err := c.client.MigrateUnit(ctx, args[0], args[1], c.subscription(), opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "restore",
+				Short: "用备份文件恢复单元",
+				Args:  []string{"APP", "UNIT"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&backup, "backup-file", "", "backup file id")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					obj, err := c.client.RestoreUnit(ctx, args[0], args[1], c.subscription(), api.UnitRestoreOptions{File: backup})
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "resources",
+				Short: "更新单元资源",
+				Args:  []string{"APP", "UNIT"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.AppResourcesOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateUnitResources(ctx, args[0], args[1], c.subscription(), opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "role",
+				Short: "设置单元角色",
+				Args:  []string{"APP", "UNIT"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&role.Role, "role", "", "role of the unit")
+					fs.StringVar(&role.MasterID, "master", "", "master unit id")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.SetUnitRole(ctx, args[0], args[1], c.subscription(), role)
+				},
+			},
+			{
+				Use:   "switch",
+				Short: "单元主从切换",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.UnitRoleSwitchConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					return c.client.RoleSwitch(ctx, args[0], c.subscription(), config)
+				},
+			},
+		},
+	}
+}
+
+func userCommand() *command {
+	var (
+		file string
+		ip   string
+	)
+
+	return &command{
+		Use:   "users",
+		Short: "管理服务的数据库用户",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询数据库用户列表",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					users, err := c.client.ListAppDBUsers(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(users, userColumns)
+				},
+			},
+			{
+				Use:   "get",
+				Short: "查询数据库用户",
+				Args:  []string{"APP", "USER"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&ip, "ip", "", "host of the user")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					user, err := c.client.GetAppDBUser(ctx, args[0], args[1], api.DBUserQuery{IP: ip, SubscriptionID: c.subscriptionID})
+					if err != nil {
+						return err
+					}
+
+					return c.print(user, userColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "创建数据库用户",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.AppUserConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostAppDBUser(ctx, args[0], c.subscription(), config)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除数据库用户",
+				Args:  []string{"APP", "USER"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&ip, "ip", "", "host of the user")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteAppDBUser(ctx, args[0], args[1], api.DBUserQuery{IP: ip, SubscriptionID: c.subscriptionID})
+				},
+			},
+			{
+				Use:   "reset-password",
+				Short: "重置数据库用户密码",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.AppUserResetConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					return c.client.ResetAppDBUserPassword(ctx, args[0], c.subscription(), config)
+				},
+			},
+			{
+				Use:   "discard-old-password",
+				Short: "丢弃轮换后保留的旧密码",
+				Args:  []string{"APP", "USER"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&ip, "ip", "%", "host of the user")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DiscardAppDBUserOldPassword(ctx, args[0], c.subscription(), api.AppUserDiscardPasswordConfig{Name: args[1], IP: ip})
+				},
+			},
+			{
+				Use:   "credentials",
+				Short: "查询数据库用户的密码状态",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					list, err := c.client.ListAppDBUserCredentials(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, credentialColumns)
+				},
+			},
+			{
+				Use:   "spec",
+				Short: "查询服务期望的数据库用户及最近一次比较的结果",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					spec, err := c.client.GetAppDBUserSpec(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(spec, nil)
+				},
+			},
+			{
+				Use:   "set-spec",
+				Short: "设置服务期望的数据库用户及权限",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var spec api.AppDBUserSpec
+
+					err := c.readInput(file, &spec)
+					if err != nil {
+						return err
+					}
+
+					resp, err := c.client.SetAppDBUserSpec(ctx, args[0], c.subscription(), spec)
+					if err != nil {
+						return err
+					}
+
+					return c.print(resp, nil)
+				},
+			},
+			{
+				Use:   "delete-spec",
+				Short: "删除服务期望的数据库用户",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteAppDBUserSpec(ctx, args[0], c.subscription())
+				},
+			},
+			{
+				Use:   "reconcile",
+				Short: "立即比较数据库用户与期望",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					status, err := c.client.ReconcileAppDBUser(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(status.Drifts, driftColumns)
+				},
+			},
+			{
+				Use:   "unmanaged",
+				Short: "查询实例中不在期望中的数据库用户",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					users, err := c.client.ListAppUnmanagedDBUsers(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(users, userColumns)
+				},
+			},
+			{
+				Use:   "privileges",
+				Short: "更新数据库用户权限",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.AppUserPrivilegesOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					return c.client.UpdateAppDBUserPrivileges(ctx, args[0], c.subscription(), opts)
+				},
+			},
+		},
+	}
+}
+
+func schemaCommand() *command {
+	var (
+		file    string
+		migrate api.SchemaMigrationApplyOptions
+	)
+
+	return &command{
+		Use:   "schemas",
+		Short: "管理服务的数据库",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询数据库列表",
+				Args:  []string{"APP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					schemas, err := c.client.ListAppDBSchemas(ctx, args[0], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(schemas, schemaColumns)
+				},
+			},
+			{
+				Use:   "get",
+				Short: "查询数据库详情",
+				Args:  []string{"APP", "SCHEMA"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					schema, err := c.client.GetAppDBSchema(ctx, args[0], args[1], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(schema, nil)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "创建数据库",
+				Args:  []string{"APP"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.AppSchemaConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostAppDBSchema(ctx, args[0], c.subscription(), config)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除数据库",
+				Args:  []string{"APP", "SCHEMA"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteAppDBSchema(ctx, args[0], args[1], c.subscription())
+				},
+			},
+			{
+				Use:   "migrations",
+				Short: "查询数据库的迁移版本及执行记录",
+				Args:  []string{"APP", "SCHEMA"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					list, err := c.client.ListSchemaMigrations(ctx, args[0], args[1], c.subscription())
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, migrationColumns)
+				},
+			},
+			{
+				Use:   "set-migrations",
+				Short: "上传数据库的迁移集合",
+				Args:  []string{"APP", "SCHEMA"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var set api.SchemaMigrationSet
+
+					err := c.readInput(file, &set)
+					if err != nil {
+						return err
+					}
+
+					list, err := c.client.SetSchemaMigrations(ctx, args[0], args[1], c.subscription(), set)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, migrationColumns)
+				},
+			},
+			{
+				Use:   "migrate",
+				Short: "执行或验证数据库未执行的迁移",
+				Args:  []string{"APP", "SCHEMA"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.Int64Var(&migrate.TargetVersion, "target-version", 0, "apply migrations up to the version,0 means all")
+					fs.BoolVar(&migrate.DryRun, "dry-run", false, "validate migrations in a scratch schema without changing the schema")
+					fs.StringVar(&migrate.Unit, "unit", "", "unit of the dry run,default is the master unit")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					resp, err := c.client.ApplySchemaMigrations(ctx, args[0], args[1], c.subscription(), migrate)
+					if err != nil {
+						return err
+					}
+
+					if resp.Task.ID == "" {
+						return c.print(resp.Migrations, migrationColumns)
+					}
+
+					return c.printTaskObject(ctx, api.TaskObjectResponse{
+						ObjectID:   args[0],
+						ObjectName: args[1],
+						TaskID:     resp.Task.ID,
+					}, nil)
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/pflag"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+var (
+	backupFileColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"APP", "app.name"},
+		{"UNIT", "unit.name"},
+		{"TYPE", "type"},
+		{"SIZE", "size"},
+		{"STATUS", "status"},
+		{"VALID", "valid"},
+		{"CREATED", "create_at"},
+		{"EXPIRED", "expire_at"},
+	}
+
+	backupStrategyColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"APP", "app_id"},
+		{"UNIT", "unit_id"},
+		{"TYPE", "type"},
+		{"SCHEDULE", "schedule"},
+		{"RETENTION", "retention"},
+		{"ENABLED", "enabled"},
+	}
+
+	backupEndpointColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"SITE", "site_id"},
+		{"TYPE", "type"},
+		{"STATUS", "status"},
+		{"ENABLED", "enabled"},
+	}
+)
+
+func backupCommand() *command {
+	return &command{
+		Use:   "backups",
+		Short: "管理备份",
+		Subs: []*command{
+			backupFileCommand(),
+			backupStrategyCommand(),
+			backupEndpointCommand(),
+		},
+	}
+}
+
+func backupFileCommand() *command {
+	var (
+		q  api.BackupFileListQuery
+		dq api.IDAppQuery
+	)
+
+	return &command{
+		Use:   "files",
+		Short: "管理备份文件",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询备份文件列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "backup file id")
+					fs.StringVar(&q.Unit, "unit", "", "unit id")
+					fs.StringVar(&q.App, "app", "", "app id")
+					fs.StringVar(&q.Site, "site", "", "site id")
+					fs.StringVar(&q.CreatedUser, "user", "", "created user")
+					listFlags(fs, &q.ListRequest)
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					q.SubscriptionID = c.subscriptionID
+
+					if isPaged(q.ListRequest) {
+						items, meta, err := c.client.ListBackupFilesPage(ctx, q)
+						if err != nil {
+							return err
+						}
+
+						return c.printPage(items, meta, backupFileColumns)
+					}
+
+					list, err := c.client.ListBackupFiles(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, backupFileColumns)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除备份文件",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&dq.ID, "id", "", "backup file id")
+					fs.StringVar(&dq.App, "app", "", "delete all backup files of the app")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					dq.SubscriptionID = c.subscriptionID
+
+					return c.client.DeleteBackupFile(ctx, dq)
+				},
+			},
+		},
+	}
+}
+
+func backupStrategyCommand() *command {
+	var (
+		q    api.BackupStrategyListQuery
+		dq   api.IDAppQuery
+		file string
+	)
+
+	return &command{
+		Use:   "strategies",
+		Short: "管理备份策略",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询备份策略列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "strategy id")
+					fs.StringVar(&q.Unit, "unit", "", "unit id")
+					fs.StringVar(&q.App, "app", "", "app id")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					q.SubscriptionID = c.subscriptionID
+
+					list, err := c.client.ListBackupStrategies(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, backupStrategyColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "创建备份策略",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.BackupStrategyConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostBackupStrategy(ctx, c.subscription(), config)
+					if err != nil {
+						return err
+					}
+
+					return c.print(obj, objectColumns)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新备份策略",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.BackupStrategyOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					return c.client.UpdateBackupStrategy(ctx, args[0], c.subscription(), opts)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除备份策略",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&dq.ID, "id", "", "strategy id")
+					fs.StringVar(&dq.App, "app", "", "delete all strategies of the app")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					dq.SubscriptionID = c.subscriptionID
+
+					return c.client.DeleteBackupStrategy(ctx, dq)
+				},
+			},
+		},
+	}
+}
+
+func backupEndpointCommand() *command {
+	var (
+		q    api.BackupEndpointListQuery
+		file string
+	)
+
+	return &command{
+		Use:   "endpoints",
+		Short: "管理备份存储端",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询备份存储端列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.Site, "site", "", "site id")
+					fs.StringVar(&q.Type, "type", "", "endpoint type,such as nfs or s3")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					list, err := c.client.ListBackupEndpoints(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, backupEndpointColumns)
+				},
+			},
+			{
+				Use:   "get",
+				Short: "查询备份存储端",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					endpoint, err := c.client.GetBackupEndpoint(ctx, args[0])
+					if err != nil {
+						return err
+					}
+
+					return c.print(endpoint, backupEndpointColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "添加备份存储端",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var endpoint api.BackupEndpoint
+
+					err := c.readInput(file, &endpoint)
+					if err != nil {
+						return err
+					}
+
+					endpoint, err = c.client.PostBackupEndpoint(ctx, endpoint)
+					if err != nil {
+						return err
+					}
+
+					return c.print(endpoint, backupEndpointColumns)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新备份存储端",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var endpoint api.BackupEndpoint
+
+					err := c.readInput(file, &endpoint)
+					if err != nil {
+						return err
+					}
+
+					endpoint, err = c.client.UpdateBackupEndpoint(ctx, args[0], endpoint)
+					if err != nil {
+						return err
+					}
+
+					return c.print(endpoint, backupEndpointColumns)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除备份存储端",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteBackupEndpoint(ctx, args[0])
+				},
+			},
+		},
+	}
+}
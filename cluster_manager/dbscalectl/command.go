@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+type command struct {
+	Use   string
+	Short string
+	// Args are the names of positional arguments,such as APP
+	Args  []string
+	Flags func(fs *pflag.FlagSet)
+	Run   func(ctx context.Context, c *cli, args []string) error
+	Subs  []*command
+
+	// Hidden command isn't listed in usage
+	Hidden bool
+	// Raw command gets the rest args without parsing flags
+	Raw bool
+}
+
+func (cmd *command) sub(name string) *command {
+	for _, s := range cmd.Subs {
+		if s.Use == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// find walks the sub commands by args before the first positional argument,
+// returns the command found,its path and the rest args.
+func (cmd *command) find(args []string, global *pflag.FlagSet) (*command, []string, []string) {
+	var (
+		path = []string{cmd.Use}
+		rest []string
+	)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+
+			if takesValue(global, arg) && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+
+		sub := cmd.sub(arg)
+		if sub == nil {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		cmd = sub
+		path = append(path, sub.Use)
+	}
+
+	return cmd, path, rest
+}
+
+// takesValue returns true if arg is a global flag followed by a separate value.
+func takesValue(fs *pflag.FlagSet, arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+
+	var f *pflag.Flag
+
+	if strings.HasPrefix(arg, "--") {
+		f = fs.Lookup(strings.TrimPrefix(arg, "--"))
+	} else if len(arg) == 2 {
+		f = fs.ShorthandLookup(arg[1:])
+	}
+
+	return f != nil && f.NoOptDefVal == ""
+}
+
+func (cmd *command) flagSet(name string, global *pflag.FlagSet) *pflag.FlagSet {
+	fs := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	fs.AddFlagSet(global)
+
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+
+	return fs
+}
+
+func (cmd *command) execute(ctx context.Context, c *cli, args []string, global *pflag.FlagSet) error {
+	found, path, rest := cmd.find(args, global)
+	name := strings.Join(path, " ")
+
+	if found.Raw {
+		return found.Run(ctx, c, rest)
+	}
+
+	fs := found.flagSet(name, global)
+	fs.SetOutput(c.errOut)
+	fs.Usage = func() {
+		found.usage(c.errOut, name, fs)
+	}
+
+	err := fs.Parse(rest)
+	if err == pflag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if found.Run == nil {
+		found.usage(c.errOut, name, fs)
+
+		if fs.NArg() > 0 {
+			return errors.Errorf("unknown command %q for %q", fs.Arg(0), name)
+		}
+		return nil
+	}
+
+	if fs.NArg() != len(found.Args) {
+		found.usage(c.errOut, name, fs)
+		return errors.Errorf("%q requires %d argument(s) but got %d", name, len(found.Args), fs.NArg())
+	}
+
+	err = c.init()
+	if err != nil {
+		return err
+	}
+
+	return found.Run(ctx, c, fs.Args())
+}
+
+func (cmd *command) usage(w io.Writer, name string, fs *pflag.FlagSet) {
+	if cmd.Short != "" {
+		fmt.Fprintf(w, "%s\n\n", cmd.Short)
+	}
+
+	if len(cmd.Subs) > 0 {
+		fmt.Fprintf(w, "Usage:\n  %s <command> [flags]\n\nCommands:\n", name)
+
+		for _, s := range cmd.Subs {
+			if s.Hidden {
+				continue
+			}
+			fmt.Fprintf(w, "  %-16s %s\n", s.Use, s.Short)
+		}
+	} else {
+		usage := name
+		for _, a := range cmd.Args {
+			usage += " " + a
+		}
+
+		fmt.Fprintf(w, "Usage:\n  %s [flags]\n", usage)
+	}
+
+	fmt.Fprintf(w, "\nFlags:\n%s", fs.FlagUsages())
+}
+
+// completions returns the candidates of the next word after words.
+func (cmd *command) completions(words []string, global *pflag.FlagSet) []string {
+	found, path, _ := cmd.find(words, global)
+
+	var out []string
+
+	for _, s := range found.Subs {
+		if s.Hidden {
+			continue
+		}
+		out = append(out, s.Use)
+	}
+
+	found.flagSet(strings.Join(path, " "), global).VisitAll(func(f *pflag.Flag) {
+		out = append(out, "--"+f.Name)
+	})
+
+	sort.Strings(out)
+
+	return out
+}
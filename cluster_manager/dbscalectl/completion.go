@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const bashCompletion = `# bash completion of dbscalectl
+_dbscalectl() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local words=("${COMP_WORDS[@]:1:$((COMP_CWORD-1))}")
+
+	COMPREPLY=( $(compgen -W "$(dbscalectl __complete "${words[@]}" 2>/dev/null)" -- "${cur}") )
+}
+complete -o default -F _dbscalectl dbscalectl
+`
+
+const zshCompletion = `# zsh completion of dbscalectl
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+func completionCommand(root *command) *command {
+	return &command{
+		Use:   "completion",
+		Short: "输出shell补全脚本，如 source <(dbscalectl completion bash)",
+		Args:  []string{"SHELL"},
+		Run: func(ctx context.Context, c *cli, args []string) error {
+			switch args[0] {
+			case "bash":
+				fmt.Fprint(c.out, bashCompletion)
+			case "zsh":
+				fmt.Fprint(c.out, zshCompletion)
+			default:
+				return errors.Errorf("unsupported shell %q,bash or zsh", args[0])
+			}
+
+			return nil
+		},
+	}
+}
+
+// completeCommand is called by the completion scripts,prints the candidates of the next word.
+func completeCommand(root *command) *command {
+	return &command{
+		Use:    "__complete",
+		Hidden: true,
+		Raw:    true,
+		Run: func(ctx context.Context, c *cli, args []string) error {
+			_, err := fmt.Fprintln(c.out, strings.Join(root.completions(args, c.globalFlags()), "\n"))
+			return err
+		},
+	}
+}
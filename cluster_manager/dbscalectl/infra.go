@@ -0,0 +1,849 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/pflag"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+var (
+	siteColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"TYPE", "type"},
+		{"DOMAIN", "domain"},
+		{"PORT", "port"},
+		{"REGION", "region"},
+		{"VERSION", "version"},
+		{"STATE", "state"},
+		{"STATUS", "status.state"},
+		{"CREATED", "created.timestamp"},
+	}
+
+	siteCheckColumns = []column{
+		{"NAME", "name"},
+		{"STATUS", "status"},
+		{"MESSAGE", "message"},
+	}
+
+	clusterColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"SITE", "site.name"},
+		{"ZONE", "zone"},
+		{"IMAGES", "image_type"},
+		{"HA_TAG", "ha_tag"},
+		{"ENABLED", "enabled"},
+		{"CREATED", "created.timestamp"},
+	}
+
+	hostColumns = []column{
+		{"ID", "id"},
+		{"NAME", "node.name"},
+		{"IP", "node.ip"},
+		{"CLUSTER", "cluster.name"},
+		{"ROLE", "role"},
+		{"ARCH", "arch"},
+		{"ENABLED", "enabled"},
+		{"TASK", "task.status"},
+	}
+
+	networkColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"SITE", "site.name"},
+		{"CLUSTER", "cluster.name"},
+		{"TOPOLOGY", "topology"},
+		{"IP_TOTAL", "ip_summary.total"},
+		{"IP_USED", "ip_summary.used"},
+		{"ENABLED", "enabled"},
+	}
+
+	storageColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"SITE", "site.name"},
+		{"TYPE", "type"},
+		{"VENDOR", "vendor"},
+		{"MODEL", "model"},
+		{"STATUS", "status"},
+		{"ENABLED", "enabled"},
+	}
+
+	poolColumns = []column{
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"STORAGE", "storage.name"},
+		{"NATIVE", "native_id"},
+		{"PERFORMANCE", "performance"},
+		{"ENABLED", "enabled"},
+	}
+
+	imageColumns = []column{
+		{"ID", "id"},
+		{"TYPE", "type"},
+		{"ARCH", "arch"},
+		{"MAJOR", "major"},
+		{"MINOR", "minor"},
+		{"PATCH", "patch"},
+		{"BUILD", "build"},
+		{"SITE", "site.name"},
+		{"UNSCHEDULABLE", "unschedulable"},
+		{"TASK", "task.status"},
+	}
+)
+
+func fileFlag(file *string) func(fs *pflag.FlagSet) {
+	return func(fs *pflag.FlagSet) {
+		fs.StringVarP(file, "file", "f", "", "yaml or json file of the request body,- for stdin")
+	}
+}
+
+func siteCommand() *command {
+	var (
+		q    api.SiteListQuery
+		file string
+	)
+
+	return &command{
+		Use:   "sites",
+		Short: "管理站点",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询站点列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "site id")
+					fs.StringVar(&q.Name, "name", "", "site name")
+					listFlags(fs, &q.ListRequest)
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					if isPaged(q.ListRequest) {
+						items, meta, err := c.client.ListSitesPage(ctx, q)
+						if err != nil {
+							return err
+						}
+
+						return c.printPage(items, meta, siteColumns)
+					}
+
+					list, err := c.client.ListSites(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, siteColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "注册站点",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.SiteConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostSite(ctx, config)
+					if err != nil {
+						return err
+					}
+
+					return c.print(obj, objectColumns)
+				},
+			},
+			{
+				Use:   "preflight",
+				Short: "站点预检，不注册站点",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.SiteConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					out, err := c.client.PreflightSite(ctx, config)
+					if err != nil {
+						return err
+					}
+
+					return c.print(out.Checks, siteCheckColumns)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新站点",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.SiteOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					return c.client.UpdateSite(ctx, args[0], opts)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除站点",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteSite(ctx, args[0])
+				},
+			},
+		},
+	}
+}
+
+func clusterCommand() *command {
+	var (
+		q    api.ClusterListQuery
+		file string
+	)
+
+	return &command{
+		Use:   "clusters",
+		Short: "管理集群",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询集群列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "cluster id")
+					fs.StringVar(&q.Name, "name", "", "cluster name")
+					fs.StringVar(&q.Site, "site", "", "site id")
+					fs.StringVar(&q.Enabled, "enabled", "", "true or false")
+					listFlags(fs, &q.ListRequest)
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					if isPaged(q.ListRequest) {
+						items, meta, err := c.client.ListClustersPage(ctx, q)
+						if err != nil {
+							return err
+						}
+
+						return c.printPage(items, meta, clusterColumns)
+					}
+
+					list, err := c.client.ListClusters(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, clusterColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "创建集群",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.ClusterConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostCluster(ctx, config)
+					if err != nil {
+						return err
+					}
+
+					return c.print(obj, objectColumns)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新集群",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.ClusterOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateCluster(ctx, args[0], opts)
+					if err != nil {
+						return err
+					}
+
+					return c.print(obj, objectColumns)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除集群",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteCluster(ctx, args[0])
+				},
+			},
+		},
+	}
+}
+
+func hostCommand() *command {
+	var (
+		q     api.HostListQuery
+		dq    api.HostDeleteQuery
+		opts  api.HostMaintenanceOptions
+		file  string
+		vtype string
+	)
+
+	return &command{
+		Use:   "hosts",
+		Short: "管理主机",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询主机列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "host id")
+					fs.StringVar(&q.Name, "name", "", "host name")
+					fs.StringVar(&q.Cluster, "cluster", "", "cluster id")
+					fs.StringVar(&q.Site, "site", "", "site id")
+					fs.StringVar(&q.Enabled, "enabled", "", "true or false")
+					listFlags(fs, &q.ListRequest)
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					if isPaged(q.ListRequest) {
+						items, meta, err := c.client.ListHostsPage(ctx, q)
+						if err != nil {
+							return err
+						}
+
+						return c.printPage(items, meta, hostColumns)
+					}
+
+					list, err := c.client.ListHosts(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, hostColumns)
+				},
+			},
+			{
+				Use:   "get",
+				Short: "查询主机详情",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					detail, err := c.client.GetHostDetail(ctx, args[0])
+					if err != nil {
+						return err
+					}
+
+					return c.print(detail, nil)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "注册主机",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.HostConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostHost(ctx, config)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "validate",
+				Short: "注册前检查主机",
+				Flags: func(fs *pflag.FlagSet) {
+					fileFlag(&file)(fs)
+					fs.StringVar(&vtype, "type", "", "check type")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.HostConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					return c.client.ValidateHost(ctx, api.TypeQuery{Type: vtype}, config)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新主机",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.HostOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateHost(ctx, args[0], opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "注销主机",
+				Args:  []string{"ID"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&dq.Username, "ssh-user", "", "ssh user to clean the host")
+					fs.StringVar(&dq.Password, "ssh-password", "", "ssh password to clean the host")
+					fs.IntVar(&dq.Port, "ssh-port", 0, "ssh port to clean the host")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteHost(ctx, args[0], dq)
+				},
+			},
+			{
+				Use:   "maintenance",
+				Short: "主机进入或退出维护模式",
+				Args:  []string{"ID"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.BoolVar(&opts.Maintenance, "enable", true, "true: enter maintenance,false: resume")
+					fs.BoolVar(&opts.DryRun, "dry-run", false, "only print the migration plan")
+					fs.IntVar(&opts.Concurrency, "concurrency", 0, "apps migrated at the same time")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					m, err := c.client.SetHostMaintenance(ctx, args[0], opts)
+					if err != nil {
+						return err
+					}
+
+					return c.print(m, nil)
+				},
+			},
+			{
+				Use:   "maintenance-status",
+				Short: "查询主机维护进度",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					m, err := c.client.GetHostMaintenance(ctx, args[0])
+					if err != nil {
+						return err
+					}
+
+					return c.print(m, nil)
+				},
+			},
+		},
+	}
+}
+
+func networkCommand() *command {
+	var (
+		q    api.NetworkListQuery
+		file string
+	)
+
+	return &command{
+		Use:   "networks",
+		Short: "管理网络",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询网络列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "network id")
+					fs.StringVar(&q.Name, "name", "", "network name")
+					fs.StringVar(&q.Cluster, "cluster", "", "cluster id")
+					fs.StringVar(&q.Site, "site", "", "site id")
+					fs.StringVar(&q.Topology, "topology", "", "topology")
+					fs.StringVar(&q.Enabled, "enabled", "", "true or false")
+					listFlags(fs, &q.ListRequest)
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					if isPaged(q.ListRequest) {
+						items, meta, err := c.client.ListNetworksPage(ctx, q)
+						if err != nil {
+							return err
+						}
+
+						return c.printPage(items, meta, networkColumns)
+					}
+
+					list, err := c.client.ListNetworks(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, networkColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "创建网络",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.NetworkConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostNetwork(ctx, config)
+					if err != nil {
+						return err
+					}
+
+					return c.print(obj, objectColumns)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新网络",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.NetworkOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateNetwork(ctx, args[0], opts)
+					if err != nil {
+						return err
+					}
+
+					return c.print(obj, objectColumns)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除网络",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteNetwork(ctx, args[0])
+				},
+			},
+			{
+				Use:   "release-conflict",
+				Short: "释放冲突的IP",
+				Args:  []string{"ID", "IP"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.ReleaseConflict(ctx, args[0], args[1])
+				},
+			},
+		},
+	}
+}
+
+func storageCommand() *command {
+	var (
+		q    api.RemoteStorageListQuery
+		pq   api.IDNameQuery
+		file string
+	)
+
+	return &command{
+		Use:   "storages",
+		Short: "管理外置存储",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询外置存储列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "storage id")
+					fs.StringVar(&q.Name, "name", "", "storage name")
+					fs.StringVar(&q.Site, "site", "", "site id")
+					fs.StringVar(&q.Enabled, "enabled", "", "true or false")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					list, err := c.client.ListRemoteStorages(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, storageColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "注册外置存储",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.RemoteStorageConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostRemoteStorage(ctx, config)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新外置存储",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.RemoteStorageOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateRemoteStorage(ctx, args[0], opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "注销外置存储",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteRemoteStorage(ctx, args[0])
+				},
+			},
+			{
+				Use:   "pools",
+				Short: "管理外置存储池",
+				Subs: []*command{
+					{
+						Use:   "list",
+						Short: "查询存储池列表",
+						Args:  []string{"STORAGE"},
+						Flags: func(fs *pflag.FlagSet) {
+							fs.StringVar(&pq.ID, "id", "", "pool id")
+							fs.StringVar(&pq.Name, "name", "", "pool name")
+						},
+						Run: func(ctx context.Context, c *cli, args []string) error {
+							list, err := c.client.ListRemoteStoragePools(ctx, args[0], pq)
+							if err != nil {
+								return err
+							}
+
+							return c.print(list, poolColumns)
+						},
+					},
+					{
+						Use:   "create",
+						Short: "添加存储池",
+						Args:  []string{"STORAGE"},
+						Flags: fileFlag(&file),
+						Run: func(ctx context.Context, c *cli, args []string) error {
+							var config api.RemoteStoragePoolConfig
+
+							err := c.readInput(file, &config)
+							if err != nil {
+								return err
+							}
+
+							obj, err := c.client.PostRemoteStoragePool(ctx, args[0], config)
+
+							return c.printTaskObject(ctx, obj, err)
+						},
+					},
+					{
+						Use:   "update",
+						Short: "更新存储池",
+						Args:  []string{"STORAGE", "POOL"},
+						Flags: fileFlag(&file),
+						Run: func(ctx context.Context, c *cli, args []string) error {
+							var opts api.RemoteStoragePoolOptions
+
+							err := c.readInput(file, &opts)
+							if err != nil {
+								return err
+							}
+
+							obj, err := c.client.UpdateRemoteStoragePool(ctx, args[0], args[1], opts)
+							if err != nil {
+								return err
+							}
+
+							return c.print(obj, objectColumns)
+						},
+					},
+					{
+						Use:   "delete",
+						Short: "删除存储池",
+						Args:  []string{"STORAGE", "POOL"},
+						Run: func(ctx context.Context, c *cli, args []string) error {
+							return c.client.DeleteRemoteStoragePool(ctx, args[0], args[1])
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func imageCommand() *command {
+	var (
+		id, site, typ, unschedulable string
+		file                         string
+		scriptType, reason           string
+	)
+
+	return &command{
+		Use:   "images",
+		Short: "管理镜像",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询镜像列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&id, "id", "", "image id")
+					fs.StringVar(&site, "site", "", "site id")
+					fs.StringVar(&typ, "type", "", "image type,such as mysql")
+					fs.StringVar(&unschedulable, "unschedulable", "", "true or false")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					opts := api.ImageListOptions{
+						ID:            optionalString(id),
+						SiteId:        optionalString(site),
+						Type:          optionalString(typ),
+						Unschedulable: optionalString(unschedulable),
+					}
+
+					list, err := c.client.ListImages(ctx, opts)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, imageColumns)
+				},
+			},
+			{
+				Use:   "create",
+				Short: "注册镜像",
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var config api.ImageConfig
+
+					err := c.readInput(file, &config)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.PostImage(ctx, config)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "update",
+				Short: "更新镜像",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.ImageOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateImage(ctx, args[0], opts)
+					if err != nil {
+						return err
+					}
+
+					return c.print(obj, objectColumns)
+				},
+			},
+			{
+				Use:   "delete",
+				Short: "删除镜像",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.DeleteImage(ctx, args[0])
+				},
+			},
+			{
+				Use:   "template",
+				Short: "查询镜像配置模板",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					tmpl, err := c.client.ListImageTemplates(ctx, args[0])
+					if err != nil {
+						return err
+					}
+
+					return c.print(tmpl, nil)
+				},
+			},
+			{
+				Use:   "set-template",
+				Short: "更新镜像配置模板",
+				Args:  []string{"ID"},
+				Flags: fileFlag(&file),
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					var opts api.ConfigTemplateOptions
+
+					err := c.readInput(file, &opts)
+					if err != nil {
+						return err
+					}
+
+					obj, err := c.client.UpdateImageTemplate(ctx, args[0], opts)
+
+					return c.printTaskObject(ctx, obj, err)
+				},
+			},
+			{
+				Use:   "scripts",
+				Short: "查询镜像脚本",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					scripts, err := c.client.ListImageScripts(ctx, args[0])
+					if err != nil {
+						return err
+					}
+
+					return c.print(scripts, nil)
+				},
+			},
+			{
+				Use:   "sync-scripts",
+				Short: "同步镜像脚本到单元",
+				Args:  []string{"ID"},
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&scriptType, "type", "", "script type")
+					fs.StringVar(&reason, "reason", "", "reason of the change,recorded in template revision")
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					return c.client.SyncImageScripts(ctx, args[0], api.ImageScriptsQuery{Type: scriptType, Reason: reason})
+				},
+			},
+		},
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
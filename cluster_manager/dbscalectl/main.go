@@ -0,0 +1,144 @@
+// dbscalectl is the command-line client of the cluster_manager apiserver.
+//
+//	dbscalectl sites list
+//	dbscalectl apps create -f app.yaml --wait
+//	dbscalectl -o yaml backups files list --app app001
+//	source <(dbscalectl completion bash)
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	client "github.com/upmio/dbscale-kube/cluster_manager/apiserver/api/client/v1"
+	"github.com/upmio/dbscale-kube/pkg/tenant"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+
+	defaultServer = "http://127.0.0.1:8080"
+	serverEnv     = "DBSCALE_SERVER"
+	adminTokenEnv = "DBSCALE_ADMIN_TOKEN"
+)
+
+type cli struct {
+	server  string
+	output  string
+	timeout time.Duration
+
+	// subscriptionID is the subscription_id of app requests,
+	// also sent as the subscription header
+	subscriptionID string
+	// adminToken is sent as the admin token header,
+	// requests without subscription are allowed with it
+	adminToken string
+
+	wait         bool
+	waitTimeout  time.Duration
+	waitInterval time.Duration
+
+	client *client.Client
+	in     io.Reader
+	out    io.Writer
+	errOut io.Writer
+}
+
+func (c *cli) globalFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("global", pflag.ContinueOnError)
+
+	server := os.Getenv(serverEnv)
+	if server == "" {
+		server = defaultServer
+	}
+
+	fs.StringVarP(&c.server, "server", "s", server, "address of apiserver,default $"+serverEnv)
+	fs.StringVarP(&c.output, "output", "o", outputTable, "output format: table,json or yaml")
+	fs.DurationVar(&c.timeout, "timeout", 30*time.Second, "timeout of each request")
+	fs.StringVar(&c.subscriptionID, "subscription", "", "subscription id of apps")
+	fs.StringVar(&c.adminToken, "admin-token", os.Getenv(adminTokenEnv), "admin token of apiserver,required to access apps and backups without --subscription,default $"+adminTokenEnv)
+	fs.BoolVarP(&c.wait, "wait", "w", false, "wait for the task to complete")
+	fs.DurationVar(&c.waitTimeout, "wait-timeout", 30*time.Minute, "timeout of waiting for the task")
+	fs.DurationVar(&c.waitInterval, "wait-interval", 3*time.Second, "interval of polling the task")
+
+	return fs
+}
+
+func (c *cli) init() error {
+	switch c.output {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return errors.Errorf("unsupported output format %q", c.output)
+	}
+
+	c.client = client.NewClient(c.server, &http.Client{Timeout: c.timeout})
+
+	if c.subscriptionID != "" {
+		c.client.SetHeader(tenant.Header, c.subscriptionID)
+	}
+	if c.adminToken != "" {
+		c.client.SetHeader(tenant.AdminHeader, c.adminToken)
+	}
+
+	return nil
+}
+
+func newRootCommand() *command {
+	root := &command{
+		Use:   "dbscalectl",
+		Short: "dbscalectl controls the dbscale cluster_manager apiserver",
+		Subs: []*command{
+			siteCommand(),
+			clusterCommand(),
+			hostCommand(),
+			networkCommand(),
+			storageCommand(),
+			imageCommand(),
+			appCommand(),
+			unitCommand(),
+			userCommand(),
+			schemaCommand(),
+			backupCommand(),
+			taskCommand(),
+		},
+	}
+
+	root.Subs = append(root.Subs, completionCommand(root), completeCommand(root))
+
+	return root
+}
+
+func main() {
+	c := &cli{
+		in:     os.Stdin,
+		out:    os.Stdout,
+		errOut: os.Stderr,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	err := newRootCommand().execute(ctx, c, os.Args[1:], c.globalFlags())
+	if err != nil {
+		fmt.Fprintln(c.errOut, "Error:", err)
+		os.Exit(1)
+	}
+}
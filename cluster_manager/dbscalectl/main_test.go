@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+func runCommand(t *testing.T, url string, args ...string) (string, error) {
+	var out bytes.Buffer
+
+	c := &cli{
+		in:     strings.NewReader(""),
+		out:    &out,
+		errOut: ioutil.Discard,
+	}
+
+	err := newRootCommand().execute(context.Background(), c, append([]string{"-s", url}, args...), c.globalFlags())
+
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	polled := 0
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/manager/sites":
+			json.NewEncoder(w).Encode(api.SitesResponse{{ID: "s1", Name: "site01", Type: "kubernetes"}})
+
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/manager/clusters":
+			var config api.ClusterConfig
+			if err := json.NewDecoder(r.Body).Decode(&config); err != nil || config.Name != "c1" {
+				t.Errorf("unexpected body %v %v", config, err)
+			}
+
+			json.NewEncoder(w).Encode(api.ObjectResponse{ID: "c001", Name: config.Name})
+
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/manager/hosts":
+			json.NewEncoder(w).Encode(api.TaskObjectResponse{ObjectID: "h001", TaskID: "t001"})
+
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/manager/tasks":
+			status := "running"
+			if polled++; polled > 1 {
+				status = "success"
+			}
+
+			json.NewEncoder(w).Encode(api.TasksResponse{{ID: r.URL.Query().Get("id"), Status: status}})
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer s.Close()
+
+	out, err := runCommand(t, s.URL, "sites", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "site01") || !strings.HasPrefix(out, "ID") {
+		t.Errorf("unexpected table output:\n%s", out)
+	}
+
+	dir, err := ioutil.TempDir("", "dbscalectl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "cluster.yaml")
+	if err := ioutil.WriteFile(file, []byte("name: c1\nsite_id: s1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = runCommand(t, s.URL, "-o", "json", "clusters", "create", "-f", file)
+	if err != nil || !strings.Contains(out, `"c001"`) {
+		t.Errorf("create cluster,%s %v", out, err)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("name: c1\nunknown: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = runCommand(t, s.URL, "clusters", "create", "-f", file)
+	if err == nil {
+		t.Error("expected error of unknown field")
+	}
+
+	file = filepath.Join(dir, "host.yaml")
+	if err := ioutil.WriteFile(file, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = runCommand(t, s.URL, "hosts", "create", "-f", file, "--wait", "--wait-interval", "1ms")
+	if err != nil || !strings.Contains(out, "success") || polled != 2 {
+		t.Errorf("wait task,%s %v %d", out, err, polled)
+	}
+
+	_, err = runCommand(t, s.URL, "sites", "delete")
+	if err == nil {
+		t.Error("expected error of missing argument")
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	c := &cli{}
+	root := newRootCommand()
+
+	out := strings.Join(root.completions([]string{"-o", "json", "backups"}, c.globalFlags()), " ")
+	if !strings.HasSuffix(out, "endpoints files strategies") || !strings.Contains(out, "--output") {
+		t.Errorf("unexpected completions %s", out)
+	}
+
+	found := false
+	for _, w := range root.completions(nil, c.globalFlags()) {
+		if w == "__complete" {
+			t.Error("hidden command completed")
+		}
+		if w == "apps" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Error("apps not completed")
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+	"sigs.k8s.io/yaml"
+)
+
+// column of table output,Path is the dotted json path of the value,such as site.name
+type column struct {
+	Header string
+	Path   string
+}
+
+var (
+	objectColumns = []column{{"ID", "id"}, {"NAME", "name"}}
+
+	taskObjectColumns = []column{{"ID", "id"}, {"NAME", "name"}, {"TASK", "task_id"}}
+)
+
+// print prints v in the output format,v is printed as yaml in table format if cols is nil.
+func (c *cli) print(v interface{}, cols []column) error {
+	switch {
+	case c.output == outputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(c.out, string(data))
+		return err
+
+	case c.output == outputYAML || cols == nil:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.out.Write(data)
+		return err
+	}
+
+	return c.printTable(v, cols)
+}
+
+// printPage prints the items and the cursor of next page.
+func (c *cli) printPage(items interface{}, meta api.ListMeta, cols []column) error {
+	if c.output != outputTable {
+		return c.print(api.ListResponse{
+			Items:      items,
+			Total:      meta.Total,
+			NextCursor: meta.NextCursor,
+		}, cols)
+	}
+
+	err := c.printTable(items, cols)
+	if err == nil && meta.NextCursor != "" {
+		fmt.Fprintf(c.errOut, "total %d,next page: --cursor %s\n", meta.Total, meta.NextCursor)
+	}
+
+	return err
+}
+
+func (c *cli) printTable(v interface{}, cols []column) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var obj interface{}
+
+	err = json.Unmarshal(data, &obj)
+	if err != nil {
+		return err
+	}
+
+	rows, ok := obj.([]interface{})
+	if !ok {
+		rows = []interface{}{obj}
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+
+	headers := make([]string, len(cols))
+	for i := range cols {
+		headers[i] = cols[i].Header
+	}
+
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		values := make([]string, len(cols))
+
+		for i := range cols {
+			values[i] = formatValue(lookup(row, cols[i].Path))
+		}
+
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+
+	return w.Flush()
+}
+
+func lookup(v interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		v = m[key]
+	}
+
+	return v
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+
+	case string:
+		if v == "" {
+			return "-"
+		}
+		return v
+
+	case float64, bool:
+		return fmt.Sprint(v)
+
+	case []interface{}:
+		out := make([]string, len(v))
+		for i := range v {
+			out[i] = formatValue(v[i])
+		}
+		return strings.Join(out, ",")
+	}
+
+	data, _ := json.Marshal(v)
+
+	return string(data)
+}
+
+// readInput decodes the yaml or json file into v,"-" means stdin,
+// unknown fields are rejected.
+func (c *cli) readInput(file string, v interface{}) error {
+	if file == "" {
+		return errors.New("input file is required,use -f")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	if file == "-" {
+		data, err = ioutil.ReadAll(c.in)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return errors.WithMessagef(err, "decode %s", file)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(v)
+	if err != nil {
+		return errors.WithMessagef(err, "decode %s", file)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/upmio/dbscale-kube/cluster_manager/apiserver/api"
+)
+
+const (
+	taskRunning = "running"
+	taskSuccess = "success"
+)
+
+var taskColumns = []column{
+	{"ID", "id"},
+	{"ACTION", "action"},
+	{"RELATE", "relate_id"},
+	{"STATUS", "status"},
+	{"USER", "created_user"},
+	{"CREATED", "created_at"},
+	{"FINISHED", "finished_at"},
+	{"ERROR", "error"},
+}
+
+func taskCommand() *command {
+	var q api.TaskListQuery
+
+	return &command{
+		Use:   "tasks",
+		Short: "查询任务",
+		Subs: []*command{
+			{
+				Use:   "list",
+				Short: "查询任务列表",
+				Flags: func(fs *pflag.FlagSet) {
+					fs.StringVar(&q.ID, "id", "", "task id")
+					fs.StringVar(&q.RelateID, "relate", "", "id of the object related")
+					fs.StringVar(&q.Action, "action", "", "task action")
+					fs.StringVar(&q.Status, "status", "", "running,failed,canceled or success")
+					listFlags(fs, &q.ListRequest)
+				},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					if isPaged(q.ListRequest) {
+						items, meta, err := c.client.ListTasksPage(ctx, q)
+						if err != nil {
+							return err
+						}
+
+						return c.printPage(items, meta, taskColumns)
+					}
+
+					list, err := c.client.ListTasks(ctx, q)
+					if err != nil {
+						return err
+					}
+
+					return c.print(list, taskColumns)
+				},
+			},
+			{
+				Use:   "get",
+				Short: "查询任务",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					task, err := c.getTask(ctx, args[0])
+					if err != nil {
+						return err
+					}
+
+					return c.print(task, taskColumns)
+				},
+			},
+			{
+				Use:   "wait",
+				Short: "等待任务结束",
+				Args:  []string{"ID"},
+				Run: func(ctx context.Context, c *cli, args []string) error {
+					task, err := c.waitTask(ctx, args[0])
+					if perr := c.print(task, taskColumns); err == nil {
+						err = perr
+					}
+
+					return err
+				},
+			},
+		},
+	}
+}
+
+func (c *cli) getTask(ctx context.Context, id string) (api.Task, error) {
+	list, err := c.client.ListTasks(ctx, api.TaskListQuery{ID: id})
+	if err != nil {
+		return api.Task{}, err
+	}
+
+	if len(list) == 0 {
+		return api.Task{}, errors.Errorf("not found task %s", id)
+	}
+
+	return list[0], nil
+}
+
+// waitTask polls the task until it isn't running,returns error if the task isn't success.
+func (c *cli) waitTask(ctx context.Context, id string) (api.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.waitTimeout)
+	defer cancel()
+
+	fmt.Fprintf(c.errOut, "waiting for task %s ...\n", id)
+
+	for {
+		task, err := c.getTask(ctx, id)
+		if err != nil {
+			return task, err
+		}
+
+		if task.Status != taskRunning {
+			if task.Status != taskSuccess {
+				return task, errors.Errorf("task %s %s:%s", id, task.Status, task.Error)
+			}
+
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return task, errors.Errorf("wait for task %s,%v", id, ctx.Err())
+		case <-time.After(c.waitInterval):
+		}
+	}
+}
+
+// printTaskObject prints the object created or updated,waits for the task if --wait.
+func (c *cli) printTaskObject(ctx context.Context, obj api.TaskObjectResponse, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if !c.wait || obj.TaskID == "" {
+		return c.print(obj, taskObjectColumns)
+	}
+
+	task, err := c.waitTask(ctx, obj.TaskID)
+	if perr := c.print(task, taskColumns); err == nil {
+		err = perr
+	}
+
+	return err
+}
+
+func listFlags(fs *pflag.FlagSet, req *api.ListRequest) {
+	fs.Uint64Var(&req.Limit, "limit", 0, "max items of the page,enable paging")
+	fs.StringVar(&req.Cursor, "cursor", "", "cursor of the next page")
+	fs.StringVar(&req.Sort, "sort", "", "sort fields,such as -created_at,name")
+	fs.StringVar(&req.Filter, "filter", "", "filter as label selector,such as status=running,app_id in (a,b)")
+	fs.StringVar(&req.Fields, "fields", "", "fields returned,only for json or yaml output")
+}
+
+func isPaged(req api.ListRequest) bool {
+	return req != api.ListRequest{}
+}
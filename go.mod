@@ -27,6 +27,7 @@ require (
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/rogpeppe/go-internal v1.7.0 // indirect
 	github.com/sirupsen/logrus v1.8.0 // indirect
+	github.com/spf13/pflag v1.0.5
 	github.com/xeipuuv/gojsonschema v1.2.0
 	golang.org/x/crypto v0.0.0-20210220033148-5ea612d1eb83
 	golang.org/x/net v0.0.0-20201021035429-f5854403a974
@@ -47,4 +48,5 @@ require (
 	k8s.io/component-base v0.18.16
 	k8s.io/heapster v1.5.4
 	k8s.io/klog/v2 v2.6.0
+	sigs.k8s.io/yaml v1.2.0
 )
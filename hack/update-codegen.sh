@@ -27,5 +27,8 @@ ${CODEGEN_PKG}/generate-groups.sh all github.com/upmio/dbscale-kube/pkg/client/u
 # host
 ${CODEGEN_PKG}/generate-groups.sh all github.com/upmio/dbscale-kube/pkg/client/host/v1alpha1  github.com/upmio/dbscale-kube/pkg/apis host:v1alpha1 --go-header-file boilerplate.go.txt
 
+# app
+${CODEGEN_PKG}/generate-groups.sh all github.com/upmio/dbscale-kube/pkg/client/app/v1alpha1  github.com/upmio/dbscale-kube/pkg/apis app:v1alpha1 --go-header-file boilerplate.go.txt
+
 
 echo "please copy pkg/client from go directory to pkg"
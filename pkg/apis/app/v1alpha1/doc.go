@@ -0,0 +1,5 @@
+// +k8s:deepcopy-gen=package,register
+
+// Package v1alpha1 is the v1alpha1 version of the API.
+// +groupName=app.upm.io
+package v1alpha1
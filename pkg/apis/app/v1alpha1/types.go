@@ -0,0 +1,121 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// AppFinalizer 删除策略为 Delete 时添加，删除 cluster_manager 中的服务后移除
+	AppFinalizer = "app.upm.io/delete-app"
+)
+
+type DeletionPolicy string
+
+const (
+	// DeletionRetain 删除对象时保留 cluster_manager 中的服务
+	DeletionRetain DeletionPolicy = "Retain"
+	// DeletionDelete 删除对象时同时删除服务
+	DeletionDelete DeletionPolicy = "Delete"
+)
+
+type Phase string
+
+const (
+	PhasePending  Phase = "Pending"
+	PhaseApplying Phase = "Applying"
+	PhaseReady    Phase = "Ready"
+	PhaseFailed   Phase = "Failed"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// App is a specification for a App resource
+type App struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AppSpec   `json:"spec"`
+	Status AppStatus `json:"status"`
+}
+
+// AppSpec is the spec for a App resource
+type AppSpec struct {
+	SubscriptionID string `json:"subscriptionID,omitempty"`
+
+	// 与 cluster_manager POST /manager/apps/manifests/apply 的请求体相同，
+	// name 为空时使用对象名称，backup_strategies 合并引用该对象的 BackupStrategy
+	Manifest runtime.RawExtension `json:"manifest"`
+
+	// 默认 Retain
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// 暂停同步，已开始的任务不受影响
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// AppStatus is the status for a App resource
+type AppStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	Phase Phase  `json:"phase,omitempty"`
+	AppID string `json:"appID,omitempty"`
+	// 执行 manifest 的任务，结束后保留最后一次的任务id
+	TaskID string `json:"taskID,omitempty"`
+	// 最后一次执行计划的步骤，格式为 action target
+	Steps []string `json:"steps,omitempty"`
+
+	Message       string      `json:"message,omitempty"`
+	LastApplyTime metav1.Time `json:"lastApplyTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AppList is a list of App resources
+type AppList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []App `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupStrategy is a specification for a BackupStrategy resource
+type BackupStrategy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackupStrategySpec   `json:"spec"`
+	Status BackupStrategyStatus `json:"status"`
+}
+
+// BackupStrategySpec is the spec for a BackupStrategy resource
+type BackupStrategySpec struct {
+	// 同一命名空间中 App 的名称
+	AppRef string `json:"appRef"`
+
+	// 与 POST /manager/backup/strategies 的请求体相同，
+	// app_id 由 operator 填写，name 为空时使用对象名称
+	Strategy runtime.RawExtension `json:"strategy"`
+}
+
+// BackupStrategyStatus is the status for a BackupStrategy resource
+type BackupStrategyStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// 与所属 App 的状态相同
+	Phase   Phase  `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupStrategyList is a list of BackupStrategy resources
+type BackupStrategyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []BackupStrategy `json:"items"`
+}
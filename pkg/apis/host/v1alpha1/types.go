@@ -44,6 +44,11 @@ const (
 	HostMaxUnitAnnotation   = "upm.host.maxunit"
 	HostNetworkMode         = "upm.network.mode"
 
+	//sriov网络模式，由agent-manager发布的VF资源清单(SriovInventory)
+	SriovInventoryAnnotation = "upm.host.sriov.inventory"
+	//期望的VF配置([]SriovVFConfig)，由agent-manager声明式同步
+	SriovVFConfigAnnotation = "upm.host.sriov.vfconfig"
+
 	HostReady        HostPhase = "ready"
 	HostDeployFailed HostPhase = "failed"
 
@@ -110,6 +115,8 @@ type HostSpec struct {
 	OsUser string `json:"os_user"`
 	//加密后的值
 	OsPassword string `json:"os_password"`
+	//密钥认证，设置后不再使用OsPassword
+	SSH *SSHSpec `json:"ssh,omitempty"`
 	//none: 不加密
 	//默认:AES
 	// SSHCryptoMode string `json:"cryptoMode,omitempty"`
@@ -118,6 +125,29 @@ type HostSpec struct {
 	LocalVGs []VGSpec `json:"local_vgs"`
 }
 
+// SSHSpec 主机密钥认证，私钥及sudo密码保存在Secret中
+type SSHSpec struct {
+	//Secret需包含ssh-privatekey，sudo_mode为password时还需包含sudo-password
+	SecretName string `json:"secret_name"`
+	//默认kube-system
+	SecretNamespace string `json:"secret_namespace,omitempty"`
+	//nopasswd(默认) or password
+	SudoMode string `json:"sudo_mode,omitempty"`
+	//主机公钥(authorized_keys格式)，设置后严格校验主机指纹
+	HostKey string       `json:"host_key,omitempty"`
+	Bastion *BastionSpec `json:"bastion,omitempty"`
+}
+
+// BastionSpec 跳板机，只支持密钥认证
+type BastionSpec struct {
+	Host string `json:"host"`
+	Port int64  `json:"port"`
+	User string `json:"user"`
+	//为空时使用主机的Secret
+	SecretName string `json:"secret_name,omitempty"`
+	HostKey    string `json:"host_key,omitempty"`
+}
+
 type SanSpec struct {
 	Os        string    `json:"os_type,omitempty"`
 	Desc      string    `json:"description,omitempty"`
@@ -147,6 +177,54 @@ type Initiator struct {
 	ID   []string `json:"ids"`
 }
 
+// SriovInventory is the SR-IOV inventory of a node
+type SriovInventory struct {
+	TotalVFs int `json:"total_vfs"`
+	UsedVFs  int `json:"used_vfs"`
+	FreeVFs  int `json:"free_vfs"`
+	//分配给pod的网卡(VF组成的bond)
+	Devices     int `json:"devices"`
+	UsedDevices int `json:"used_devices"`
+	FreeDevices int `json:"free_devices"`
+	//link不是up的空闲网卡，不计入free_devices
+	DownDevices int `json:"down_devices"`
+
+	PFs []SriovPF `json:"pfs"`
+}
+
+type SriovPF struct {
+	Name     string    `json:"name"`
+	Link     string    `json:"link"`
+	TotalVFs int       `json:"total_vfs"`
+	NumVFs   int       `json:"num_vfs"`
+	VFs      []SriovVF `json:"vfs"`
+}
+
+type SriovVF struct {
+	Index    int    `json:"index"`
+	MAC      string `json:"mac"`
+	Vlan     int    `json:"vlan"`
+	SpoofChk bool   `json:"spoofchk"`
+	//Mbps,0 不限速
+	Rate int `json:"rate"`
+	//VF在宿主机网络命名空间的网卡名，为空表示已在pod内
+	Netdev string `json:"netdev"`
+	Master string `json:"master"`
+	Used   bool   `json:"used"`
+}
+
+// SriovVFConfig is the desired config of VFs,
+// nil fields are not reconciled.
+type SriovVFConfig struct {
+	PF string `json:"pf"`
+	//为空表示PF下所有VF
+	VF       *int  `json:"vf,omitempty"`
+	Vlan     *int  `json:"vlan,omitempty"`
+	SpoofChk *bool `json:"spoofchk,omitempty"`
+	//已使用的VF速率由networkclaim带宽控制，只对空闲VF生效
+	Rate *int `json:"rate,omitempty"`
+}
+
 type HostStatus struct {
 	Phase       HostPhase             `json:"phase"`
 	NodeReady   bool                  `json:"node_ready"`
@@ -24,6 +24,22 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BastionSpec) DeepCopyInto(out *BastionSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BastionSpec.
+func (in *BastionSpec) DeepCopy() *BastionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BastionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Host) DeepCopyInto(out *Host) {
 	*out = *in
@@ -88,6 +104,11 @@ func (in *HostList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostSpec) DeepCopyInto(out *HostSpec) {
 	*out = *in
+	if in.SSH != nil {
+		in, out := &in.SSH, &out.SSH
+		*out = new(SSHSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.San != nil {
 		in, out := &in.San, &out.San
 		*out = new(SanSpec)
@@ -196,6 +217,27 @@ func (in *ResouceStatus) DeepCopy() *ResouceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHSpec) DeepCopyInto(out *SSHSpec) {
+	*out = *in
+	if in.Bastion != nil {
+		in, out := &in.Bastion, &out.Bastion
+		*out = new(BastionSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHSpec.
+func (in *SSHSpec) DeepCopy() *SSHSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SanSpec) DeepCopyInto(out *SanSpec) {
 	*out = *in
@@ -213,6 +255,102 @@ func (in *SanSpec) DeepCopy() *SanSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SriovInventory) DeepCopyInto(out *SriovInventory) {
+	*out = *in
+	if in.PFs != nil {
+		in, out := &in.PFs, &out.PFs
+		*out = make([]SriovPF, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SriovInventory.
+func (in *SriovInventory) DeepCopy() *SriovInventory {
+	if in == nil {
+		return nil
+	}
+	out := new(SriovInventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SriovPF) DeepCopyInto(out *SriovPF) {
+	*out = *in
+	if in.VFs != nil {
+		in, out := &in.VFs, &out.VFs
+		*out = make([]SriovVF, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SriovPF.
+func (in *SriovPF) DeepCopy() *SriovPF {
+	if in == nil {
+		return nil
+	}
+	out := new(SriovPF)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SriovVF) DeepCopyInto(out *SriovVF) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SriovVF.
+func (in *SriovVF) DeepCopy() *SriovVF {
+	if in == nil {
+		return nil
+	}
+	out := new(SriovVF)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SriovVFConfig) DeepCopyInto(out *SriovVFConfig) {
+	*out = *in
+	if in.VF != nil {
+		in, out := &in.VF, &out.VF
+		*out = new(int)
+		**out = **in
+	}
+	if in.Vlan != nil {
+		in, out := &in.Vlan, &out.Vlan
+		*out = new(int)
+		**out = **in
+	}
+	if in.SpoofChk != nil {
+		in, out := &in.SpoofChk, &out.SpoofChk
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Rate != nil {
+		in, out := &in.Rate, &out.Rate
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SriovVFConfig.
+func (in *SriovVFConfig) DeepCopy() *SriovVFConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SriovVFConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UsageLimit) DeepCopyInto(out *UsageLimit) {
 	*out = *in
@@ -62,10 +62,21 @@ type NetworkStatus struct {
 	AllIPCounts int32 `json:"allIPCounts"`
 	//冲突IP地址
 	Conflicts []string `json:"conflicts"`
+	//分配前探测(ARP/ICMP)有应答而被隔离的IP地址，不再参与分配，需人工确认后清除
+	Quarantined []IPConflict `json:"quarantined,omitempty"`
 
 	Status string `json:"status"`
 }
 
+// IPConflict is a quarantined IP address found in use on the wire
+type IPConflict struct {
+	IP string `json:"ip"`
+	//探测时准备绑定的networkclaim
+	Claim  string      `json:"claim,omitempty"`
+	Reason string      `json:"reason,omitempty"`
+	Time   metav1.Time `json:"time"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // NetworkList is a list of Network resources
 type NetworkList struct {
@@ -106,6 +117,10 @@ type NetworkClaimStatus struct {
 	HostDevice   string `json:"hostDevice,omitempty"`
 	Host         string `json:"host,omitempty"`
 	CurBandwidth int32  `json:"curBandwidth,omitempty"`
+
+	//pod网络命名空间及容器网卡名，用于在线调整带宽
+	Netns  string `json:"netns,omitempty"`
+	IfName string `json:"ifName,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
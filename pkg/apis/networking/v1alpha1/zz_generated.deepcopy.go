@@ -24,6 +24,23 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPConflict) DeepCopyInto(out *IPConflict) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPConflict.
+func (in *IPConflict) DeepCopy() *IPConflict {
+	if in == nil {
+		return nil
+	}
+	out := new(IPConflict)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Network) DeepCopyInto(out *Network) {
 	*out = *in
@@ -207,6 +224,13 @@ func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Quarantined != nil {
+		in, out := &in.Quarantined, &out.Quarantined
+		*out = make([]IPConflict, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
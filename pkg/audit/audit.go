@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/upmio/dbscale-kube/pkg/utils"
+)
+
+// Event is the audit record of a mutating api call,
+// Changes are recorded by the handlers with the objects before and after the call.
+type Event struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	RemoteAddr string    `json:"remote_addr"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	// route is "METHOD path template"
+	Route string `json:"route"`
+	// Action is the first action of Changes,or Route if no change recorded
+	Action  string          `json:"action"`
+	App     string          `json:"app,omitempty"`
+	Request json.RawMessage `json:"request,omitempty"`
+	Code    int             `json:"code"`
+	Error   string          `json:"error,omitempty"`
+	Changes []Change        `json:"changes,omitempty"`
+}
+
+// Change is an object changed by the call,Before and After are json with secrets masked.
+type Change struct {
+	Action string          `json:"action"`
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+type eventKey struct{}
+
+// recorder holds the event of a request,
+// the changes recorded after the request finished(by background tasks) are dropped.
+type recorder struct {
+	lock  sync.Mutex
+	done  bool
+	event *Event
+}
+
+func withRecorder(ctx context.Context, rec *recorder) context.Context {
+	return context.WithValue(ctx, eventKey{}, rec)
+}
+
+// Record appends a change to the audit event of ctx,
+// does nothing if ctx is not a request context wrapped by Middleware.
+func Record(ctx context.Context, action, kind, name string, before, after interface{}) {
+	if ctx == nil {
+		return
+	}
+
+	rec, ok := ctx.Value(eventKey{}).(*recorder)
+	if !ok || rec == nil {
+		return
+	}
+
+	c := Change{
+		Action: action,
+		Kind:   kind,
+		Name:   name,
+		Before: encode(before),
+		After:  encode(after),
+	}
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	if rec.done {
+		return
+	}
+
+	rec.event.Changes = append(rec.event.Changes, c)
+}
+
+// SetApp sets the app of the audit event of ctx,used when the app is not in the request path.
+func SetApp(ctx context.Context, app string) {
+	rec, ok := ctx.Value(eventKey{}).(*recorder)
+	if !ok || rec == nil {
+		return
+	}
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	if !rec.done {
+		rec.event.App = app
+	}
+}
+
+func encode(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	b, err := utils.MaskSecret(v)
+	if err != nil {
+		return nil
+	}
+
+	return b
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/klog/v2"
+
+	"github.com/upmio/dbscale-kube/pkg/utils"
+)
+
+// maxRequestSize the request body larger than it is not recorded
+const maxRequestSize = 64 << 10
+
+// Middleware records the mutating api calls(POST,PUT,PATCH,DELETE) to Sink.
+type Middleware struct {
+	Sink Sink
+}
+
+func (m Middleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error)) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			return handler(ctx, w, r, vars)
+		}
+
+		event := &Event{
+			Time:       time.Now(),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Route:      r.Method + " " + routeTemplate(r),
+			App:        vars["app"],
+		}
+
+		body := readBody(r)
+		if len(body) > 0 {
+			event.User = userOfBody(body)
+
+			if b, err := utils.MaskSecret(json.RawMessage(body)); err == nil {
+				event.Request = b
+			}
+		}
+
+		if event.User == "" {
+			event.User = r.URL.Query().Get("modified_user")
+		}
+		if event.App == "" {
+			event.App = r.URL.Query().Get("app_id")
+		}
+
+		rec := &recorder{event: event}
+
+		code, out, err := handler(withRecorder(ctx, rec), w, r, vars)
+
+		rec.lock.Lock()
+		rec.done = true
+		rec.lock.Unlock()
+
+		event.Code = code
+		if err != nil {
+			event.Error = err.Error()
+		}
+
+		event.Action = event.Route
+		if len(event.Changes) > 0 {
+			event.Action = event.Changes[0].Action
+		}
+
+		if werr := m.Sink.Write(*event); werr != nil {
+			klog.Errorf("write audit event %s %s:%s", r.Method, r.RequestURI, werr)
+		}
+
+		return code, out, err
+	}
+}
+
+// routeTemplate returns the path template without the version prefix.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return r.URL.Path
+	}
+
+	if strings.HasPrefix(tpl, "/v{") {
+		if i := strings.Index(tpl, "}"); i > 0 {
+			tpl = tpl[i+1:]
+		}
+	}
+
+	return tpl
+}
+
+// readBody reads the json body and restores r.Body for the handler.
+func readBody(r *http.Request) []byte {
+	if r.Body == nil || r.ContentLength > maxRequestSize {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+
+	r.Body = newReadCloser(io.MultiReader(bytes.NewReader(b), r.Body), r.Body)
+
+	if err != nil || len(b) > maxRequestSize || !json.Valid(b) {
+		return nil
+	}
+
+	return b
+}
+
+func userOfBody(body []byte) string {
+	var form struct {
+		Modified string `json:"modified_user"`
+		Created  string `json:"created_user"`
+	}
+
+	if err := json.Unmarshal(body, &form); err != nil {
+		return ""
+	}
+
+	if form.Modified != "" {
+		return form.Modified
+	}
+
+	return form.Created
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func newReadCloser(r io.Reader, c io.Closer) io.ReadCloser {
+	return readCloser{Reader: r, Closer: c}
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type memorySink []Event
+
+func (s *memorySink) Write(e Event) error {
+	*s = append(*s, e)
+
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	sink := &memorySink{}
+	mw := Middleware{Sink: sink}
+
+	var reqCtx context.Context
+
+	handler := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		reqCtx = ctx
+
+		Record(ctx, "db_user.reset_password", "db_user", "u1@%",
+			map[string]string{"name": "u1"},
+			map[string]string{"name": "u1", "pwd": "123456"})
+
+		return http.StatusInternalServerError, nil, errors.New("failed")
+	})
+
+	body := `{"name":"u1","pwd":"123456","modified_user":"admin"}`
+	r := httptest.NewRequest(http.MethodPut, "/manager/apps/app1/users/u1", strings.NewReader(body))
+
+	handler(context.Background(), httptest.NewRecorder(), r, map[string]string{"app": "app1"})
+
+	// recorded after the request finished
+	Record(reqCtx, "ignored", "", "", nil, nil)
+
+	if len(*sink) != 1 {
+		t.Fatalf("expected 1 event but got %d", len(*sink))
+	}
+
+	e := (*sink)[0]
+
+	if e.User != "admin" || e.App != "app1" || e.Code != http.StatusInternalServerError || e.Error != "failed" {
+		t.Errorf("unexpected event:%+v", e)
+	}
+
+	if e.Action != "db_user.reset_password" || len(e.Changes) != 1 {
+		t.Errorf("unexpected changes:%+v", e.Changes)
+	}
+
+	if strings.Contains(string(e.Request), "123456") || strings.Contains(string(e.Changes[0].After), "123456") {
+		t.Errorf("secret not masked:%s %s", e.Request, e.Changes[0].After)
+	}
+
+	get := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		return http.StatusOK, nil, nil
+	})
+
+	get(context.Background(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/manager/apps", nil), map[string]string{})
+
+	if len(*sink) != 1 {
+		t.Errorf("GET should not be audited")
+	}
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Sink writes the audit events.
+type Sink interface {
+	Write(Event) error
+}
+
+// MultiSink writes events to all sinks.
+type MultiSink []Sink
+
+func (ms MultiSink) Write(e Event) error {
+	var errs []error
+
+	for _, s := range ms {
+		if err := s.Write(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// jsonLinesSink writes an event as a json line.
+type jsonLinesSink struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func (s *jsonLinesSink) Write(e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	b = append(b, '\n')
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, err = s.w.Write(b)
+
+	return err
+}
+
+// NewFileSink appends the events as json lines to file.
+func NewFileSink(file string) (Sink, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonLinesSink{w: f}, nil
+}
+
+// NewSink returns the sink of output,"syslog" or a file path.
+func NewSink(output string) (Sink, error) {
+	if output == "syslog" {
+		return NewSyslogSink()
+	}
+
+	return NewFileSink(output)
+}
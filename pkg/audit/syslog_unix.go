@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package audit
+
+import (
+	"log/syslog"
+)
+
+// NewSyslogSink writes the events as json to the local syslog.
+func NewSyslogSink() (Sink, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_AUTH, "dbscale-audit")
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonLinesSink{w: w}, nil
+}
@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package audit
+
+import (
+	"fmt"
+)
+
+func NewSyslogSink() (Sink, error) {
+	return nil, fmt.Errorf("Windows platform does not support syslog")
+}
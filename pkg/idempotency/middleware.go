@@ -0,0 +1,182 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HeaderKey 请求头，相同 Key 的 POST/PUT 请求只执行一次
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed 响应头，表示响应来自保存的记录
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 128
+	// maxRequestSize the request body larger than it is rejected
+	maxRequestSize = 4 << 20
+)
+
+// Record 请求及其响应，Done 为 false 时请求仍在处理
+type Record struct {
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+
+	Done     bool
+	Code     int
+	Response []byte
+	Error    string
+	ETag     string
+
+	CreatedAt time.Time
+}
+
+// Store saves the records,a record is identified by Key,Method and Path.
+type Store interface {
+	// Reserve saves the record if it doesn't exist,
+	// otherwise returns the existing record and false.
+	Reserve(rec Record) (Record, bool, error)
+	Complete(rec Record) error
+	Release(rec Record) error
+}
+
+// Middleware 保存携带 Idempotency-Key 的 POST/PUT 请求的响应，
+// 客户端重试时返回保存的响应，不重复执行。
+// 服务端错误(5xx)不保存，可以使用相同的 Key 重试，
+// 超过 TTL 的记录视为不存在，TTL 为0时不过期。
+type Middleware struct {
+	Store Store
+	TTL   time.Duration
+}
+
+func (m Middleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error)) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		key := r.Header.Get(HeaderKey)
+
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPut) {
+			return handler(ctx, w, r, vars)
+		}
+
+		if len(key) > maxKeyLength {
+			return http.StatusBadRequest, nil, fmt.Errorf("%s is longer than %d", HeaderKey, maxKeyLength)
+		}
+
+		hash, err := requestHash(r)
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+
+		rec := Record{
+			Key:         key,
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			RequestHash: hash,
+			CreatedAt:   time.Now(),
+		}
+
+		old, ok, err := m.Store.Reserve(rec)
+		if err == nil && !ok && m.expired(old) {
+			if err = m.Store.Release(old); err == nil {
+				old, ok, err = m.Store.Reserve(rec)
+			}
+		}
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+
+		if !ok {
+			return replay(w, rec, old)
+		}
+
+		code, out, err := handler(ctx, w, r, vars)
+
+		if code >= http.StatusInternalServerError {
+			if rerr := m.Store.Release(rec); rerr != nil {
+				klog.Errorf("release %s %s %s:%s", r.Method, r.URL.Path, key, rerr)
+			}
+
+			return code, out, err
+		}
+
+		rec.Done = true
+		rec.Code = code
+		rec.ETag = w.Header().Get("ETag")
+
+		if err != nil {
+			rec.Error = err.Error()
+		} else if out != nil {
+			rec.Response, _ = json.Marshal(out)
+		}
+
+		if cerr := m.Store.Complete(rec); cerr != nil {
+			klog.Errorf("save response of %s %s %s:%s", r.Method, r.URL.Path, key, cerr)
+		}
+
+		return code, out, err
+	}
+}
+
+func (m Middleware) expired(rec Record) bool {
+	return m.TTL > 0 && time.Since(rec.CreatedAt) > m.TTL
+}
+
+func replay(w http.ResponseWriter, rec, old Record) (int, interface{}, error) {
+	if old.RequestHash != rec.RequestHash {
+		return http.StatusUnprocessableEntity, nil, fmt.Errorf("%s %s is used by another request", HeaderKey, rec.Key)
+	}
+
+	if !old.Done {
+		return http.StatusConflict, nil, fmt.Errorf("the request of %s %s is in progress", HeaderKey, rec.Key)
+	}
+
+	w.Header().Set(HeaderReplayed, "true")
+	if old.ETag != "" {
+		w.Header().Set("ETag", old.ETag)
+	}
+
+	if old.Error != "" {
+		return old.Code, nil, errors.New(old.Error)
+	}
+
+	if len(old.Response) == 0 {
+		return old.Code, nil, nil
+	}
+
+	return old.Code, json.RawMessage(old.Response), nil
+}
+
+// requestHash returns sha256 of query and body,and restores r.Body for the handler.
+func requestHash(r *http.Request) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, r.URL.RawQuery)
+	h.Write([]byte{'\n'})
+
+	if r.Body != nil {
+		b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+		if err != nil {
+			return "", err
+		}
+		if len(b) > maxRequestSize {
+			return "", fmt.Errorf("request body with %s is larger than %d", HeaderKey, maxRequestSize)
+		}
+
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+		h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,105 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryStore struct {
+	lock    sync.Mutex
+	records map[string]Record
+}
+
+func (s *memoryStore) Reserve(rec Record) (Record, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := rec.Method + rec.Path + rec.Key
+
+	if old, ok := s.records[key]; ok {
+		return old, false, nil
+	}
+
+	s.records[key] = rec
+
+	return rec, true, nil
+}
+
+func (s *memoryStore) Complete(rec Record) error {
+	s.lock.Lock()
+	s.records[rec.Method+rec.Path+rec.Key] = rec
+	s.lock.Unlock()
+
+	return nil
+}
+
+func (s *memoryStore) Release(rec Record) error {
+	s.lock.Lock()
+	delete(s.records, rec.Method+rec.Path+rec.Key)
+	s.lock.Unlock()
+
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	mw := Middleware{Store: &memoryStore{records: map[string]Record{}}}
+
+	calls := 0
+	failed := true
+
+	handler := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		calls++
+
+		if failed {
+			return http.StatusInternalServerError, nil, errors.New("timeout")
+		}
+
+		return http.StatusCreated, map[string]string{"id": "app001"}, nil
+	})
+
+	do := func(key, body string) (*httptest.ResponseRecorder, int, interface{}, error) {
+		r := httptest.NewRequest(http.MethodPost, "/manager/apps", strings.NewReader(body))
+		r.Header.Set(HeaderKey, key)
+
+		w := httptest.NewRecorder()
+		code, out, err := handler(context.Background(), w, r, map[string]string{})
+
+		return w, code, out, err
+	}
+
+	// server error is not saved,retry with the same key
+	if _, code, _, _ := do("k1", `{"name":"db01"}`); code != http.StatusInternalServerError {
+		t.Fatalf("unexpected code %d", code)
+	}
+
+	failed = false
+
+	_, code, out, err := do("k1", `{"name":"db01"}`)
+	if code != http.StatusCreated || err != nil || calls != 2 {
+		t.Fatalf("unexpected response %d %v %v,calls %d", code, out, err, calls)
+	}
+
+	w, code, out, err := do("k1", `{"name":"db01"}`)
+	if code != http.StatusCreated || err != nil || calls != 2 || w.Header().Get(HeaderReplayed) != "true" {
+		t.Fatalf("unexpected replay %d %v %v,calls %d", code, out, err, calls)
+	}
+
+	b, _ := json.Marshal(out)
+	if string(b) != `{"id":"app001"}` {
+		t.Errorf("unexpected replay body %s", b)
+	}
+
+	if _, code, _, _ = do("k1", `{"name":"db02"}`); code != http.StatusUnprocessableEntity || calls != 2 {
+		t.Errorf("expected %d but got %d", http.StatusUnprocessableEntity, code)
+	}
+
+	if _, code, _, _ = do("", `{"name":"db01"}`); code != http.StatusCreated || calls != 3 {
+		t.Errorf("request without key should be handled,calls %d", calls)
+	}
+}
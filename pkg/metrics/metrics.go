@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+)
+
+const namespace = "dbscale"
+
+var (
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "reconcile_errors_total",
+		Help:      "Total number of reconcile errors per controller and reason.",
+	}, []string{"controller", "reason"})
+
+	scriptDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "script",
+		Name:      "duration_seconds",
+		Help:      "How long in seconds a script(StorMGR,VPMGR) call takes.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"script", "action"})
+
+	scriptCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "script",
+		Name:      "calls_total",
+		Help:      "Total number of script calls per action and exit code.",
+	}, []string{"script", "action", "code"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "How long in seconds a http request takes per route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+)
+
+func init() {
+	prometheus.MustRegister(reconcileErrors, scriptDuration, scriptCalls, httpDuration)
+}
+
+// MustRegister registers the collectors of the component into the default registry.
+func MustRegister(cs ...prometheus.Collector) {
+	prometheus.MustRegister(cs...)
+}
+
+// Handler returns the handler of /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve starts a http server serving /metrics on addr,
+// used by the components that have no api server, addr is empty means disabled.
+func Serve(addr string) {
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	go func() {
+		klog.Infof("metrics server listen on %s", addr)
+
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			klog.Errorf("metrics server %s:%s", addr, err)
+		}
+	}()
+}
+
+// ReconcileError counts a failed reconcile of controller by the reason of err.
+func ReconcileError(controller string, err error) {
+	if err == nil {
+		return
+	}
+
+	reconcileErrors.WithLabelValues(controller, reason(err)).Inc()
+}
+
+func reason(err error) string {
+	if r := apierrors.ReasonForError(err); r != "" {
+		return string(r)
+	}
+
+	var exitErr *exec.ExitError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Timeout"
+	case errors.As(err, &exitErr):
+		return "ScriptFailed"
+	}
+
+	return "Unknown"
+}
+
+// ObserveScript records the duration and exit code of a script call started at start.
+// code is 0 if err is nil, -1 if the script not exited normally(killed or not started).
+func ObserveScript(script, action string, start time.Time, err error) {
+	code := 0
+
+	if err != nil {
+		code = -1
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+	}
+
+	scriptDuration.WithLabelValues(script, action).Observe(time.Since(start).Seconds())
+	scriptCalls.WithLabelValues(script, action, strconv.Itoa(code)).Inc()
+}
+
+// ObserveHTTP records the latency of a http request,route is the path template of the router.
+func ObserveHTTP(method, route string, code int, start time.Time) {
+	httpDuration.WithLabelValues(method, route, strconv.Itoa(code)).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func scriptExitError(t *testing.T, code int) error {
+	err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	if err == nil {
+		t.Fatalf("expect exit code %d", code)
+	}
+
+	return err
+}
+
+func TestReason(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{apierrors.NewNotFound(schema.GroupResource{Resource: "units"}, "u1"), "NotFound"},
+		{apierrors.NewConflict(schema.GroupResource{Resource: "units"}, "u1", errors.New("modified")), "Conflict"},
+		{context.DeadlineExceeded, "Timeout"},
+		{fmt.Errorf("wait:%w", context.DeadlineExceeded), "Timeout"},
+		{fmt.Errorf("VPMGR:%w", scriptExitError(t, 2)), "ScriptFailed"},
+		{errors.New("failed"), "Unknown"},
+	}
+
+	for _, c := range cases {
+		if got := reason(c.err); got != c.want {
+			t.Errorf("%v: expect reason %s,got %s", c.err, c.want, got)
+		}
+	}
+}
+
+func TestObserveScript(t *testing.T) {
+	cases := []struct {
+		action string
+		err    error
+		code   string
+	}{
+		{"ok", nil, "0"},
+		{"exit", fmt.Errorf("StorMGR:%w", scriptExitError(t, 3)), "3"},
+		{"killed", context.DeadlineExceeded, "-1"},
+		{"not started", &exec.Error{Name: "StorMGR", Err: exec.ErrNotFound}, "-1"},
+	}
+
+	for _, c := range cases {
+		ObserveScript("test", c.action, time.Now(), c.err)
+
+		if n := testutil.ToFloat64(scriptCalls.WithLabelValues("test", c.action, c.code)); n != 1 {
+			t.Errorf("%s: expect 1 call with code %s,got %v", c.action, c.code, n)
+		}
+	}
+}
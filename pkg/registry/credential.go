@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credential 镜像仓库的账号，用于获取 token 或 Basic 认证
+type Credential struct {
+	Username string
+	Password string
+}
+
+// DockerConfigFile returns the docker config.json,
+// $DOCKER_CONFIG/config.json or ~/.docker/config.json
+func DockerConfigFile() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// LoadDockerConfig reads the registry credentials from the auths of docker config.json,
+// the key is the registry host,returns nil if the file doesn't exist.
+func LoadDockerConfig(file string) (map[string]Credential, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s:%s", file, err)
+	}
+
+	creds := make(map[string]Credential, len(config.Auths))
+
+	for registry, auth := range config.Auths {
+		cred := Credential{Username: auth.Username, Password: auth.Password}
+
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("decode auth of %s in %s:%s", registry, file, err)
+			}
+
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid auth of %s in %s", registry, file)
+			}
+
+			cred = Credential{Username: parts[0], Password: parts[1]}
+		}
+
+		creds[registryHost(registry)] = cred
+	}
+
+	return creds, nil
+}
+
+// registryHost returns the host of the registry without scheme and path
+func registryHost(registry string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+
+	return host
+}
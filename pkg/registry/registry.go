@@ -0,0 +1,371 @@
+// Package registry reads the image manifests and cosign signatures
+// from a docker registry by the distribution api v2.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mediaTypeManifestV2   = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+
+	// SignatureAnnotation the layer annotation of cosign signature
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	maxManifestSize = 4 << 20
+
+	defaultTimeout = 30 * time.Second
+)
+
+var acceptManifests = strings.Join([]string{
+	mediaTypeManifestV2, mediaTypeManifestList, mediaTypeOCIManifest, mediaTypeOCIIndex,
+}, ",")
+
+// NotFoundError the manifest or blob doesn't exist
+type NotFoundError struct {
+	URL string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.URL)
+}
+
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// Client 访问镜像仓库，creds 中有账号的仓库使用账号认证，否则匿名访问，
+// 仓库地址不含 scheme 时先尝试 https，失败后使用 http
+type Client struct {
+	client *http.Client
+	creds  map[string]Credential
+
+	lock    sync.Mutex
+	schemes map[string]string
+	// key: registry host/repository,value: Authorization header
+	tokens map[string]string
+}
+
+// NewClient returns a registry client,the key of creds is the registry host,
+// a client with 30s timeout is used if cli is nil.
+func NewClient(cli *http.Client, creds map[string]Credential) *Client {
+	if cli == nil {
+		cli = &http.Client{Timeout: defaultTimeout}
+	}
+
+	return &Client{
+		client:  cli,
+		creds:   creds,
+		schemes: make(map[string]string),
+		tokens:  make(map[string]string),
+	}
+}
+
+// Digest returns the digest of the manifest which the tag points to.
+func (c *Client) Digest(ctx context.Context, registry, repository, tag string) (string, error) {
+	resp, err := c.do(ctx, http.MethodHead, registry, repository, "/manifests/"+tag)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// some registries don't return the digest for HEAD
+	_, digest, err := c.manifest(ctx, registry, repository, tag)
+
+	return digest, err
+}
+
+// Signatures returns the cosign signatures of the digest,
+// which are stored as the layers of tag sha256-<hex>.sig
+func (c *Client) Signatures(ctx context.Context, registry, repository, digest string) ([]Signature, error) {
+	tag := strings.Replace(digest, ":", "-", 1) + ".sig"
+
+	data, _, err := c.manifest(ctx, registry, repository, tag)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Layers []struct {
+			Digest      string            `json:"digest"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"layers"`
+	}
+
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("decode manifest %s/%s:%s,%s", registry, repository, tag, err)
+	}
+
+	sigs := make([]Signature, 0, len(manifest.Layers))
+
+	for _, layer := range manifest.Layers {
+		sig, ok := layer.Annotations[SignatureAnnotation]
+		if !ok {
+			continue
+		}
+
+		payload, err := c.blob(ctx, registry, repository, layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		sigs = append(sigs, Signature{
+			Payload:   payload,
+			Signature: sig,
+		})
+	}
+
+	return sigs, nil
+}
+
+func (c *Client) manifest(ctx context.Context, registry, repository, reference string) ([]byte, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, registry, repository, "/manifests/"+reference)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		sum := sha256.Sum256(data)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return data, digest, nil
+}
+
+func (c *Client) blob(ctx context.Context, registry, repository, digest string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, registry, repository, "/blobs/"+digest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if got := "sha256:" + hex.EncodeToString(sum[:]); got != digest {
+		return nil, fmt.Errorf("blob %s/%s@%s,unexpected digest %s", registry, repository, digest, got)
+	}
+
+	return data, nil
+}
+
+// do sends the request to /v2/<repository><path>,
+// gets an anonymous bearer token and retries if the registry requires.
+func (c *Client) do(ctx context.Context, method, registry, repository, path string) (*http.Response, error) {
+	base, err := c.baseURL(ctx, registry)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := base + "/v2/" + repository + path
+	key := registryHost(registry) + "/" + repository
+
+	for retry := 0; ; retry++ {
+		req, err := http.NewRequest(method, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.WithContext(ctx)
+		req.Header.Set("Accept", acceptManifests)
+
+		if auth := c.token(key); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && retry == 0:
+			resp.Body.Close()
+
+			err = c.authorize(ctx, registry, repository, resp.Header.Get("WWW-Authenticate"))
+			if err != nil {
+				return nil, fmt.Errorf("%s %s,%s", method, uri, err)
+			}
+
+			continue
+
+		case resp.StatusCode == http.StatusNotFound:
+			resp.Body.Close()
+			return nil, &NotFoundError{URL: uri}
+
+		case resp.StatusCode >= http.StatusMultipleChoices:
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s,%s", method, uri, resp.Status)
+		}
+
+		return resp, nil
+	}
+}
+
+func (c *Client) baseURL(ctx context.Context, registry string) (string, error) {
+	if strings.HasPrefix(registry, "http://") || strings.HasPrefix(registry, "https://") {
+		return strings.TrimSuffix(registry, "/"), nil
+	}
+
+	c.lock.Lock()
+	scheme, ok := c.schemes[registry]
+	c.lock.Unlock()
+
+	if ok {
+		return scheme + "://" + registry, nil
+	}
+
+	var last error
+
+	for _, scheme := range []string{"https", "http"} {
+		req, err := http.NewRequest(http.MethodGet, scheme+"://"+registry+"/v2/", nil)
+		if err != nil {
+			return "", err
+		}
+
+		resp, err := c.client.Do(req.WithContext(ctx))
+		if err != nil {
+			last = err
+			continue
+		}
+		resp.Body.Close()
+
+		c.lock.Lock()
+		c.schemes[registry] = scheme
+		c.lock.Unlock()
+
+		return scheme + "://" + registry, nil
+	}
+
+	return "", last
+}
+
+func (c *Client) token(key string) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.tokens[key]
+}
+
+func (c *Client) setToken(key, auth string) {
+	c.lock.Lock()
+	c.tokens[key] = auth
+	c.lock.Unlock()
+}
+
+// authorize gets a token by the challenge,with the credential of the registry if configured:
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:foo:pull"
+// Basic challenge requires the credential.
+func (c *Client) authorize(ctx context.Context, registry, repository, challenge string) error {
+	key := registryHost(registry) + "/" + repository
+	cred, ok := c.creds[registryHost(registry)]
+
+	if strings.HasPrefix(challenge, "Basic ") {
+		if !ok {
+			return fmt.Errorf("credential of registry %s is required", registry)
+		}
+
+		c.setToken(key, "Basic "+base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password)))
+
+		return nil
+	}
+
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return fmt.Errorf("unsupported authenticate challenge %q", challenge)
+	}
+
+	params := make(map[string]string)
+
+	for _, kv := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		parts := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(parts) == 2 {
+			params[parts[0]] = strings.Trim(parts[1], `"`)
+		}
+	}
+
+	if params["realm"] == "" {
+		return fmt.Errorf("realm is required,%q", challenge)
+	}
+
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + repository + ":pull"
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequest(http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get token from %s,%s", params["realm"], resp.Status)
+	}
+
+	var out struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	if err != nil {
+		return err
+	}
+
+	if out.Token == "" {
+		out.Token = out.AccessToken
+	}
+
+	c.setToken(key, "Bearer "+out.Token)
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDigestAndVerify(t *testing.T) {
+	const (
+		repository = "dbscale/mysql"
+		digest     = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"%s"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"}}`, repository, digest))
+	sum := sha256.Sum256(payload)
+	payloadDigest := "sha256:" + hex.EncodeToString(sum[:])
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+
+	sigManifest, _ := json.Marshal(map[string]interface{}{
+		"layers": []map[string]interface{}{{
+			"digest":      payloadDigest,
+			"annotations": map[string]string{SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+		}},
+	})
+
+	token := "anonymous"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			fmt.Fprintf(w, `{"token":%q}`, token)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="registry"`, r.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/" + repository + "/manifests/5.7.25.1-amd64":
+			w.Header().Set("Docker-Content-Digest", digest)
+		case "/v2/" + repository + "/manifests/sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.sig":
+			w.Write(sigManifest)
+		case "/v2/" + repository + "/blobs/" + payloadDigest:
+			w.Write(payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil, nil)
+	ctx := context.Background()
+
+	got, err := c.Digest(ctx, srv.URL, repository, "5.7.25.1-amd64")
+	if err != nil || got != digest {
+		t.Fatalf("unexpected digest %s,%v", got, err)
+	}
+
+	if _, err := c.Digest(ctx, srv.URL, repository, "5.7.25.2-amd64"); !IsNotFound(err) {
+		t.Errorf("expected not found but got %v", err)
+	}
+
+	sigs, err := c.Signatures(ctx, srv.URL, repository, digest)
+	if err != nil || len(sigs) != 1 {
+		t.Fatalf("unexpected signatures %v,%v", sigs, err)
+	}
+
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	keys, err := ParsePublicKeys(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(digest, sigs, keys); err != nil {
+		t.Errorf("verify:%s", err)
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ = x509.MarshalPKIXPublicKey(&other.PublicKey)
+	keys, _ = ParsePublicKeys(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	if err := Verify(digest, sigs, keys); err == nil {
+		t.Error("expected verify failed with another key")
+	}
+
+	if err := Verify(digest, nil, keys); err != ErrUnsigned {
+		t.Errorf("expected ErrUnsigned but got %v", err)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	newServer := func(challenge func(host string) string, authorized func(r *http.Request) bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/token" {
+				user, pass, ok := r.BasicAuth()
+				if !ok || user != "admin" || pass != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				fmt.Fprintf(w, `{"token":%q}`, "t-"+r.URL.Query().Get("scope"))
+				return
+			}
+
+			if !authorized(r) {
+				w.Header().Set("WWW-Authenticate", challenge(r.Host))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			w.Header().Set("Docker-Content-Digest", digest)
+		}))
+	}
+
+	basic := newServer(func(string) string { return `Basic realm="registry"` }, func(r *http.Request) bool {
+		user, pass, ok := r.BasicAuth()
+		return ok && user == "admin" && pass == "secret"
+	})
+	defer basic.Close()
+
+	bearer := newServer(func(host string) string {
+		return fmt.Sprintf(`Bearer realm="http://%s/token",service="registry"`, host)
+	}, func(r *http.Request) bool {
+		// token is valid for the repository only
+		return r.Header.Get("Authorization") == "Bearer t-repository:dbscale/mysql:pull" &&
+			strings.HasPrefix(r.URL.Path, "/v2/dbscale/mysql/")
+	})
+	defer bearer.Close()
+
+	ctx := context.Background()
+
+	anonymous := NewClient(nil, nil)
+	if _, err := anonymous.Digest(ctx, basic.URL, "dbscale/mysql", "latest"); err == nil {
+		t.Error("expected credential required")
+	}
+
+	creds := map[string]Credential{
+		registryHost(basic.URL):  {Username: "admin", Password: "secret"},
+		registryHost(bearer.URL): {Username: "admin", Password: "secret"},
+	}
+	c := NewClient(nil, creds)
+
+	for _, registry := range []string{basic.URL, bearer.URL} {
+		if got, err := c.Digest(ctx, registry, "dbscale/mysql", "latest"); err != nil || got != digest {
+			t.Errorf("%s: unexpected digest %s,%v", registry, got, err)
+		}
+	}
+
+	// the token of another repository is not reused
+	if _, err := c.Digest(ctx, bearer.URL, "dbscale/proxysql", "latest"); err == nil {
+		t.Error("expected unauthorized for another repository")
+	}
+
+	if c.token(registryHost(bearer.URL)+"/dbscale/mysql") != "Bearer t-repository:dbscale/mysql:pull" {
+		t.Errorf("unexpected tokens %v", c.tokens)
+	}
+}
+
+func TestLoadDockerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.json")
+	data := fmt.Sprintf(`{"auths":{"https://harbor.example.com/v2/":{"auth":%q},"10.0.0.1:5000":{"username":"u","password":"p"}}}`,
+		base64.StdEncoding.EncodeToString([]byte("admin:se:cret")))
+
+	if err := ioutil.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := LoadDockerConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Credential{
+		"harbor.example.com": {Username: "admin", Password: "se:cret"},
+		"10.0.0.1:5000":      {Username: "u", Password: "p"},
+	}
+
+	if !reflect.DeepEqual(creds, want) {
+		t.Errorf("expected %v but got %v", want, creds)
+	}
+
+	if creds, err := LoadDockerConfig(filepath.Join(dir, "none.json")); err != nil || creds != nil {
+		t.Errorf("expected no credentials but got %v,%v", creds, err)
+	}
+}
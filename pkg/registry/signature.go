@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Signature cosign 签名，Payload 为 simple signing 格式的 json，Signature 为 base64 编码
+type Signature struct {
+	Payload   []byte `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// ErrUnsigned the image has no signature
+var ErrUnsigned = errors.New("image is not signed")
+
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// ParsePublicKeys parses the PEM encoded public keys,ECDSA and RSA keys are supported.
+func ParsePublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey:
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("unsupported public key %T", key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no public key found")
+	}
+
+	return keys, nil
+}
+
+// Verify returns nil if any signature of digest is verified by any key,
+// returns ErrUnsigned if sigs is empty.
+func Verify(digest string, sigs []Signature, keys []crypto.PublicKey) error {
+	if len(sigs) == 0 {
+		return ErrUnsigned
+	}
+
+	var last error
+
+	for _, sig := range sigs {
+		payload := simpleSigning{}
+
+		if err := json.Unmarshal(sig.Payload, &payload); err != nil {
+			last = fmt.Errorf("decode signature payload,%s", err)
+			continue
+		}
+
+		if got := payload.Critical.Image.DockerManifestDigest; got != digest {
+			last = fmt.Errorf("signature is for %s,not %s", got, digest)
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+		if err != nil {
+			last = fmt.Errorf("decode signature,%s", err)
+			continue
+		}
+
+		sum := sha256.Sum256(sig.Payload)
+
+		for _, key := range keys {
+			if verifySignature(key, sum[:], raw) {
+				return nil
+			}
+		}
+
+		last = fmt.Errorf("signature of %s is not verified by the public keys", digest)
+	}
+
+	return last
+}
+
+func verifySignature(key crypto.PublicKey, hashed, sig []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		var es struct {
+			R, S *big.Int
+		}
+
+		rest, err := asn1.Unmarshal(sig, &es)
+		if err != nil || len(rest) > 0 {
+			return false
+		}
+
+		return ecdsa.Verify(k, hashed, es.R, es.S)
+
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed, sig) == nil
+	}
+
+	return false
+}
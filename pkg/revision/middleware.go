@@ -0,0 +1,221 @@
+package revision
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/klog/v2"
+)
+
+// ConflictError 资源版本与 If-Match 不一致
+type ConflictError struct {
+	Kind    string
+	ID      string
+	Expect  int64
+	Current int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s has been modified,resource version is %s,not %s",
+		e.Kind, e.ID, ETag(e.Current), ETag(e.Expect))
+}
+
+// IsConflict returns true if err is a *ConflictError
+func IsConflict(err error) bool {
+	_, ok := err.(*ConflictError)
+	return ok
+}
+
+// Store saves the revisions of resources,the revision of unknown resource is 0.
+type Store interface {
+	Revision(kind, id string) (int64, error)
+	// Bump increases the revision and returns the new one,
+	// if expect>=0 and the current revision is not expect,returns *ConflictError.
+	Bump(kind, id string, expect int64) (int64, error)
+	// Rollback decreases the revision if it is still rev,
+	// undoes the revision reserved by a failed request.
+	Rollback(kind, id string, rev int64) error
+	Delete(kind, id string) error
+}
+
+// Resource 路由对应的资源，ID 取自路径变量 Var 或 query 参数 Query
+type Resource struct {
+	Kind  string
+	Var   string
+	Query string
+}
+
+type handleFunc func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error)
+
+// Middleware 为资源的请求返回 ETag，修改资源时检查 If-Match。
+// Routes 的 key 为路由模板(不含版本前缀)，ID 来自路径变量的资源，其子路由也属于该资源。
+// 携带 If-Match 时在处理请求前以比较并交换的方式将版本加1，不一致返回409，
+// 同一版本的并发修改只有一个被处理，请求失败(状态码不小于300或有错误)时回退版本；
+// 没有 If-Match 的修改请求成功后版本才加1。
+type Middleware struct {
+	Store  Store
+	Routes map[string]Resource
+}
+
+func (m Middleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error)) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		res, exact, ok := m.resource(r)
+		if !ok {
+			return handler(ctx, w, r, vars)
+		}
+
+		id := vars[res.Var]
+		if res.Var == "" {
+			id = r.FormValue(res.Query)
+		}
+		if id == "" {
+			return handler(ctx, w, r, vars)
+		}
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code, out, err := handler(ctx, w, r, vars)
+			if err != nil || code >= http.StatusMultipleChoices {
+				return code, out, err
+			}
+
+			if rev, rerr := m.Store.Revision(res.Kind, id); rerr != nil {
+				klog.Errorf("get revision of %s %s:%s", res.Kind, id, rerr)
+			} else {
+				w.Header().Set("ETag", ETag(rev))
+			}
+
+			return code, out, err
+		}
+
+		if match := r.Header.Get("If-Match"); match != "" && match != "*" {
+			expect, err := ParseETag(match)
+			if err != nil {
+				return http.StatusBadRequest, nil, err
+			}
+
+			return m.compareAndHandle(ctx, w, r, vars, handler, res, exact, id, expect)
+		}
+
+		code, out, err := handler(ctx, w, r, vars)
+		if err != nil || code >= http.StatusMultipleChoices {
+			return code, out, err
+		}
+
+		if exact && r.Method == http.MethodDelete {
+			m.delete(res.Kind, id)
+
+			return code, out, err
+		}
+
+		rev, err := m.Store.Bump(res.Kind, id, -1)
+		if err != nil {
+			klog.Errorf("bump revision of %s %s:%s", res.Kind, id, err)
+
+			return code, out, nil
+		}
+
+		w.Header().Set("ETag", ETag(rev))
+
+		return code, out, nil
+	}
+}
+
+// compareAndHandle 处理请求前将版本从 expect 加1，并发的相同 If-Match 请求只有一个成功，
+// 其余返回409且不调用 handler，handler 失败时回退版本
+func (m Middleware) compareAndHandle(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string,
+	handler handleFunc, res Resource, exact bool, id string, expect int64) (int, interface{}, error) {
+	rev, err := m.Store.Bump(res.Kind, id, expect)
+	if IsConflict(err) {
+		return http.StatusConflict, nil, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	code, out, err := handler(ctx, w, r, vars)
+	if err != nil || code >= http.StatusMultipleChoices {
+		if rerr := m.Store.Rollback(res.Kind, id, rev); rerr != nil {
+			klog.Errorf("rollback revision of %s %s:%s", res.Kind, id, rerr)
+		}
+
+		return code, out, err
+	}
+
+	if exact && r.Method == http.MethodDelete {
+		m.delete(res.Kind, id)
+
+		return code, out, err
+	}
+
+	w.Header().Set("ETag", ETag(rev))
+
+	return code, out, nil
+}
+
+func (m Middleware) delete(kind, id string) {
+	if err := m.Store.Delete(kind, id); err != nil {
+		klog.Errorf("delete revision of %s %s:%s", kind, id, err)
+	}
+}
+
+// resource returns the resource of the route,
+// exact is false if the route is a sub route of the resource.
+func (m Middleware) resource(r *http.Request) (res Resource, exact bool, ok bool) {
+	tpl := routeTemplate(r)
+
+	if res, ok = m.Routes[tpl]; ok {
+		return res, true, true
+	}
+
+	prefix := ""
+
+	for key, v := range m.Routes {
+		if v.Var != "" && len(key) > len(prefix) && strings.HasPrefix(tpl, key+"/") {
+			prefix, res, ok = key, v, true
+		}
+	}
+
+	return res, false, ok
+}
+
+// routeTemplate returns the path template without the version prefix.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return r.URL.Path
+	}
+
+	if strings.HasPrefix(tpl, "/v{") {
+		if i := strings.Index(tpl, "}"); i > 0 {
+			tpl = tpl[i+1:]
+		}
+	}
+
+	return tpl
+}
+
+// ETag formats the revision as a strong entity tag
+func ETag(rev int64) string {
+	return strconv.Quote(strconv.FormatInt(rev, 10))
+}
+
+// ParseETag parses the revision from If-Match,weak tag is accepted.
+func ParseETag(tag string) (int64, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+
+	rev, err := strconv.ParseInt(strings.Trim(s, `"`), 10, 64)
+	if err != nil || rev < 0 {
+		return 0, fmt.Errorf("invalid If-Match %s", tag)
+	}
+
+	return rev, nil
+}
@@ -0,0 +1,204 @@
+package revision
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type memoryStore struct {
+	lock      sync.Mutex
+	revisions map[string]int64
+}
+
+func (s *memoryStore) get(key string) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.revisions[key]
+}
+
+func (s *memoryStore) Revision(kind, id string) (int64, error) {
+	return s.get(kind + "/" + id), nil
+}
+
+func (s *memoryStore) Bump(kind, id string, expect int64) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rev := s.revisions[kind+"/"+id]
+
+	if expect >= 0 && expect != rev {
+		return rev, &ConflictError{Kind: kind, ID: id, Expect: expect, Current: rev}
+	}
+
+	s.revisions[kind+"/"+id] = rev + 1
+
+	return rev + 1, nil
+}
+
+func (s *memoryStore) Rollback(kind, id string, rev int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.revisions[kind+"/"+id] == rev {
+		s.revisions[kind+"/"+id] = rev - 1
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Delete(kind, id string) error {
+	s.lock.Lock()
+	delete(s.revisions, kind+"/"+id)
+	s.lock.Unlock()
+
+	return nil
+}
+
+func doRequest(handler handleFunc, method, path, match string) (string, int) {
+	r := httptest.NewRequest(method, path, nil)
+	if match != "" {
+		r.Header.Set("If-Match", match)
+	}
+
+	w := httptest.NewRecorder()
+	code, _, _ := handler(context.Background(), w, r, map[string]string{"id": "h1"})
+
+	return w.Header().Get("ETag"), code
+}
+
+func TestMiddleware(t *testing.T) {
+	store := &memoryStore{revisions: map[string]int64{}}
+	mw := Middleware{
+		Store: store,
+		Routes: map[string]Resource{
+			"/manager/hosts/h1": {Kind: "host", Var: "id"},
+		},
+	}
+
+	calls := 0
+	fail := false
+
+	handler := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		calls++
+
+		if fail {
+			return http.StatusInternalServerError, nil, errors.New("failed")
+		}
+
+		return http.StatusOK, nil, nil
+	})
+
+	do := func(method, path, match string) (string, int) {
+		return doRequest(handler, method, path, match)
+	}
+
+	if etag, _ := do(http.MethodGet, "/manager/hosts/h1", ""); etag != `"0"` {
+		t.Errorf("unexpected etag %s", etag)
+	}
+
+	if etag, _ := do(http.MethodPut, "/manager/hosts/h1", ""); etag != `"1"` {
+		t.Errorf("unexpected etag %s", etag)
+	}
+
+	// sub route of the resource
+	if etag, code := do(http.MethodPut, "/manager/hosts/h1/maintenance", `"1"`); etag != `"2"` || code != http.StatusOK {
+		t.Errorf("unexpected etag %s %d", etag, code)
+	}
+
+	if _, code := do(http.MethodPut, "/manager/hosts/h1", `"1"`); code != http.StatusConflict || calls != 3 {
+		t.Errorf("expected conflict but got %d,calls %d", code, calls)
+	}
+
+	// failed request does not change the revision
+	fail = true
+	if _, code := do(http.MethodPut, "/manager/hosts/h1", `"2"`); code != http.StatusInternalServerError || store.get("host/h1") != 2 {
+		t.Errorf("unexpected failed request %d,revision %d", code, store.get("host/h1"))
+	}
+	fail = false
+
+	if etag, code := do(http.MethodPut, "/manager/hosts/h1", `"2"`); etag != `"3"` || code != http.StatusOK {
+		t.Errorf("unexpected etag %s %d", etag, code)
+	}
+
+	// modified by a background task while handling,the request has reserved revision 4
+	handler = mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		store.Bump("host", "h1", -1)
+
+		return http.StatusOK, nil, nil
+	})
+
+	if etag, code := do(http.MethodPut, "/manager/hosts/h1", `"3"`); etag != `"4"` || code != http.StatusOK {
+		t.Errorf("unexpected etag %s %d", etag, code)
+	}
+
+	// the client holding revision 4 has missed the background change
+	if _, code := do(http.MethodPut, "/manager/hosts/h1", `"4"`); code != http.StatusConflict || store.get("host/h1") != 5 {
+		t.Errorf("expected conflict but got %d,revision %d", code, store.get("host/h1"))
+	}
+
+	if _, code := do(http.MethodDelete, "/manager/hosts/h1", `W/"5"`); code != http.StatusOK || len(store.revisions) != 0 {
+		t.Errorf("unexpected delete %d %v", code, store.revisions)
+	}
+}
+
+func TestMiddlewareConcurrentIfMatch(t *testing.T) {
+	store := &memoryStore{revisions: map[string]int64{"host/h1": 1}}
+	mw := Middleware{
+		Store: store,
+		Routes: map[string]Resource{
+			"/manager/hosts/h1": {Kind: "host", Var: "id"},
+		},
+	}
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	var (
+		lock  sync.Mutex
+		calls int
+	)
+
+	handler := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		lock.Lock()
+		calls++
+		lock.Unlock()
+
+		entered <- struct{}{}
+		<-release
+
+		return http.StatusOK, nil, nil
+	})
+
+	type result struct {
+		etag string
+		code int
+	}
+
+	first := make(chan result)
+	go func() {
+		etag, code := doRequest(handler, http.MethodPut, "/manager/hosts/h1", `"1"`)
+		first <- result{etag, code}
+	}()
+
+	<-entered
+
+	// the same If-Match while the first request is being handled
+	if _, code := doRequest(handler, http.MethodPut, "/manager/hosts/h1", `"1"`); code != http.StatusConflict {
+		t.Errorf("expected conflict but got %d", code)
+	}
+
+	close(release)
+
+	if res := <-first; res.code != http.StatusOK || res.etag != `"2"` {
+		t.Errorf("unexpected first request %+v", res)
+	}
+
+	if calls != 1 || store.get("host/h1") != 2 {
+		t.Errorf("expected one write but got calls %d,revision %d", calls, store.get("host/h1"))
+	}
+}
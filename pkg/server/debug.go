@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 
+	"github.com/upmio/dbscale-kube/pkg/metrics"
 	"github.com/upmio/dbscale-kube/pkg/server/router"
 )
 
@@ -29,6 +30,7 @@ type debugRouter struct {
 
 func (r *debugRouter) initRoutes() {
 	r.routes = []router.Route{
+		router.NewGetRoute("/metrics", frameworkAdaptHandler(metrics.Handler())),
 		router.NewGetRoute("/debug/vars", frameworkAdaptHandler(expvar.Handler())),
 		router.NewGetRoute("/debug/pprof/", frameworkAdaptHandlerFunc(pprof.Index)),
 		router.NewGetRoute("/debug/pprof/cmdline", frameworkAdaptHandlerFunc(pprof.Cmdline)),
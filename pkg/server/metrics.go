@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/upmio/dbscale-kube/pkg/metrics"
+)
+
+// statusRecorder records the status code written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// instrumentHandler observes the latency of the route,
+// labeled by the path template rather than the request path to limit the cardinality.
+func instrumentHandler(method, route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+
+		handler(rec, r)
+
+		metrics.ObserveHTTP(method, route, rec.code, start)
+	}
+}
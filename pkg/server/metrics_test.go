@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// observedCount returns the sample count of the http request duration of route labeled by code.
+func observedCount(t *testing.T, route, code string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != "dbscale_http_request_duration_seconds" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			if labels["route"] == route && labels["code"] == code {
+				return m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	cases := []struct {
+		route   string
+		handler http.HandlerFunc
+		code    string
+	}{
+		{"/test/implicit", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }, "200"},
+		{"/test/created", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) }, "201"},
+		{"/test/missing", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) }, "404"},
+		{"/test/failed", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) }, "500"},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		instrumentHandler(http.MethodGet, c.route, c.handler)(w, httptest.NewRequest(http.MethodGet, c.route, nil))
+
+		if n := observedCount(t, c.route, c.code); n != 1 {
+			t.Errorf("%s: expect 1 request with code %s,got %d", c.route, c.code, n)
+		}
+
+		if code := strconv.Itoa(w.Code); code != c.code {
+			t.Errorf("%s: expect response %s,got %s", c.route, c.code, code)
+		}
+	}
+}
@@ -0,0 +1,559 @@
+// Package openapi builds the OpenAPI 3 document from the routes with router.Doc.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/upmio/dbscale-kube/pkg/server"
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+const (
+	Version = "3.0.3"
+
+	mimeJSON = "application/json"
+
+	schemasRef        = "#/components/schemas/"
+	errorResponseName = "Error"
+	jsonschemaPrefix  = "jsonschema."
+)
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem key is the lower case http method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	// value is *Schema or the schema loaded from jsonschema files
+	Schemas   map[string]interface{} `json:"schemas"`
+	Responses map[string]*Response   `json:"responses,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+
+	// GoType is the go type of the component
+	GoType string `json:"x-go-type,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+	pathVarRegexp = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+)
+
+// Builder builds the Document,not safe for concurrent use.
+type Builder struct {
+	doc    *Document
+	types  map[reflect.Type]Schema
+	names  map[reflect.Type]string
+	ops    map[string]string
+	loader func(name string) ([]byte, error)
+}
+
+func NewBuilder(info Info, servers ...Server) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Servers: servers,
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas:   make(map[string]interface{}),
+				Responses: make(map[string]*Response),
+			},
+		},
+		types: make(map[reflect.Type]Schema),
+		names: make(map[reflect.Type]string),
+		ops:   make(map[string]string),
+	}
+
+	b.doc.Components.Responses[errorResponseName] = &Response{
+		Description: "Error",
+		Content: map[string]MediaType{
+			mimeJSON: {Schema: b.schemaOf(reflect.TypeOf(server.ErrorResponse{}))},
+		},
+	}
+
+	return b
+}
+
+// SetType sets the schema of the type of v,such as types implement json.Marshaler.
+func (b *Builder) SetType(v interface{}, s Schema) {
+	b.types[reflect.TypeOf(v)] = s
+}
+
+// SetSchemaLoader sets the loader of jsonschema files,see router.Doc.BodySchema.
+func (b *Builder) SetSchemaLoader(loader func(name string) ([]byte, error)) {
+	b.loader = loader
+}
+
+// Document returns the document built.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// Add adds the routes to the document,routes without doc have no operationId.
+func (b *Builder) Add(routes ...router.Route) error {
+	for _, r := range routes {
+		var doc router.Doc
+		if dr, ok := r.(router.DocRoute); ok {
+			doc = dr.Doc()
+		}
+
+		p, vars := PathTemplate(r.Path())
+		method := strings.ToLower(r.Method())
+
+		if doc.ID != "" {
+			if other, ok := b.ops[doc.ID]; ok {
+				return errors.Errorf("duplicate operation id %s of %s %s and %s", doc.ID, r.Method(), r.Path(), other)
+			}
+			b.ops[doc.ID] = r.Method() + " " + r.Path()
+		}
+
+		op, err := b.operation(doc, vars)
+		if err != nil {
+			return errors.WithMessagef(err, "%s %s", r.Method(), r.Path())
+		}
+
+		item := b.doc.Paths[p]
+		if item == nil {
+			item = make(PathItem)
+			b.doc.Paths[p] = item
+		}
+		item[method] = op
+	}
+
+	return nil
+}
+
+// PathTemplate converts the mux path to OpenAPI path template,returns the path vars.
+func PathTemplate(p string) (string, []string) {
+	var vars []string
+
+	out := pathVarRegexp.ReplaceAllStringFunc(p, func(s string) string {
+		name := pathVarRegexp.FindStringSubmatch(s)[1]
+		vars = append(vars, name)
+
+		return "{" + name + "}"
+	})
+
+	return out, vars
+}
+
+// QueryFields returns the json names and types of query parameters of the Query struct.
+func QueryFields(v interface{}) ([]string, []reflect.Type) {
+	var (
+		names []string
+		types []reflect.Type
+	)
+
+	if v == nil {
+		return nil, nil
+	}
+
+	walkFields(reflect.TypeOf(v), func(name string, f reflect.StructField) {
+		names = append(names, name)
+		types = append(types, f.Type)
+	})
+
+	return names, types
+}
+
+func (b *Builder) operation(doc router.Doc, vars []string) (*Operation, error) {
+	op := &Operation{
+		OperationID: doc.ID,
+		Tags:        doc.Tags,
+		Summary:     doc.Summary,
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, v := range vars {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     v,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	names, types := QueryFields(doc.Query)
+	for i := range names {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:   names[i],
+			In:     "query",
+			Schema: b.schemaOf(types[i]),
+		})
+	}
+
+	if doc.Body != nil {
+		s := b.schemaOf(reflect.TypeOf(doc.Body))
+
+		if doc.BodySchema != "" {
+			name, err := b.addJSONSchema(doc.BodySchema)
+			if err != nil {
+				return nil, err
+			}
+
+			s = &Schema{AllOf: []*Schema{s, {Ref: schemasRef + name}}}
+		}
+
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				mimeJSON: {Schema: s},
+			},
+		}
+	}
+
+	code := doc.Code
+	if code == 0 {
+		code = http.StatusOK
+	}
+
+	resp := &Response{
+		Description: http.StatusText(code),
+	}
+
+	if doc.Response != nil && code != http.StatusNoContent {
+		s := b.schemaOf(reflect.TypeOf(doc.Response))
+
+		if doc.Paged && s.Items != nil {
+			s = &Schema{OneOf: []*Schema{s, pageSchema(s.Items)}}
+		}
+
+		resp.Content = map[string]MediaType{
+			mimeJSON: {Schema: s},
+		}
+	}
+
+	op.Responses[strconv.Itoa(code)] = resp
+	op.Responses["default"] = &Response{Ref: "#/components/responses/" + errorResponseName}
+
+	return op, nil
+}
+
+func pageSchema(items *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"items":       {Type: "array", Items: items},
+			"total":       {Type: "integer", Format: "int64"},
+			"next_cursor": {Type: "string"},
+		},
+	}
+}
+
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	if s, ok := b.types[t]; ok {
+		return &s
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := b.schemaOf(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &Schema{Ref: schemasRef + b.component(t)}
+	}
+
+	return &Schema{}
+}
+
+func (b *Builder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, ok := b.doc.Components.Schemas[name]; ok {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+
+	// register before building for recursive types
+	b.names[t] = name
+	b.doc.Components.Schemas[name] = nil
+
+	s := b.structSchema(t)
+	s.GoType = t.String()
+	b.doc.Components.Schemas[name] = s
+
+	return name
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	walkFields(t, func(name string, f reflect.StructField) {
+		fs := b.schemaOf(f.Type)
+
+		if tagOptions(f.Tag.Get("json")).contains("string") {
+			fs = &Schema{Type: "string"}
+		}
+
+		s.Properties[name] = fs
+	})
+
+	return s
+}
+
+// walkFields walks the exported fields as encoding/json,embedded structs are flattened.
+func walkFields(t reflect.Type, fn func(name string, f reflect.StructField)) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				walkFields(ft, fn)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fn(name, f)
+	}
+}
+
+type tagOptions string
+
+func (o tagOptions) contains(opt string) bool {
+	parts := strings.Split(string(o), ",")
+
+	for _, p := range parts[1:] {
+		if p == opt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addJSONSchema adds the jsonschema file to components,definitions of the file
+// are added as "jsonschema.<file>.<definition>".
+func (b *Builder) addJSONSchema(file string) (string, error) {
+	name := jsonschemaPrefix + strings.TrimSuffix(file, path.Ext(file))
+
+	if _, ok := b.doc.Components.Schemas[name]; ok {
+		return name, nil
+	}
+
+	if b.loader == nil {
+		return "", errors.Errorf("no loader for jsonschema %s", file)
+	}
+
+	data, err := b.loader(file)
+	if err != nil {
+		return "", errors.WithMessagef(err, "load jsonschema %s", file)
+	}
+
+	var m map[string]interface{}
+
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return "", errors.WithMessagef(err, "decode jsonschema %s", file)
+	}
+
+	delete(m, "$schema")
+
+	defs, _ := m["definitions"].(map[string]interface{})
+	delete(m, "definitions")
+
+	ref := schemasRef + name + "."
+
+	for k, v := range defs {
+		rewriteRefs(v, ref)
+		b.doc.Components.Schemas[name+"."+k] = v
+	}
+
+	rewriteRefs(m, ref)
+	b.doc.Components.Schemas[name] = m
+
+	return name, nil
+}
+
+// unsupported keywords of the OpenAPI 3.0 schema object,kept as extensions.
+var unsupportedKeywords = map[string]bool{
+	"$id":           true,
+	"const":         true,
+	"contains":      true,
+	"dependencies":  true,
+	"examples":      true,
+	"if":            true,
+	"then":          true,
+	"else":          true,
+	"propertyNames": true,
+}
+
+// rewriteRefs rewrites refs of definitions and renames unsupported keywords to "x-<keyword>".
+func rewriteRefs(v interface{}, prefix string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			switch k {
+			case "$ref":
+				if s, ok := val.(string); ok && strings.HasPrefix(s, "#/definitions/") {
+					v[k] = prefix + strings.TrimPrefix(s, "#/definitions/")
+				}
+				continue
+
+			case "properties", "patternProperties":
+				// keys are property names
+				if props, ok := val.(map[string]interface{}); ok {
+					for _, p := range props {
+						rewriteRefs(p, prefix)
+					}
+					continue
+				}
+			}
+
+			rewriteRefs(val, prefix)
+
+			if unsupportedKeywords[k] {
+				delete(v, k)
+				v["x-"+k] = val
+			}
+		}
+
+	case []interface{}:
+		for i := range v {
+			rewriteRefs(v[i], prefix)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package router
+
+// Doc describes a route for the generated OpenAPI document and clients.
+type Doc struct {
+	// ID is the unique operation id,also used as the name of the client method.
+	ID      string
+	Tags    []string
+	Summary string
+
+	// Query is a struct,every field with json tag is a query parameter,
+	// path parameters are parsed from the path.
+	Query interface{}
+
+	// Body is the request body,BodySchema is the jsonschema file validating Body.
+	Body       interface{}
+	BodySchema string
+
+	// Code is the success status code,default 200.
+	Code     int
+	Response interface{}
+
+	// Paged means the route returns a page of Response's elements
+	// if any list parameter is given.
+	Paged bool
+}
+
+// DocRoute is a route with document.
+type DocRoute interface {
+	Route
+	Doc() Doc
+}
+
+type docRoute struct {
+	Route
+	doc Doc
+}
+
+func (r docRoute) Doc() Doc {
+	return r.doc
+}
+
+// WithDoc attaches the document to the route.
+func WithDoc(doc Doc) RouteWrapper {
+	return func(r Route) Route {
+		return docRoute{
+			Route: r,
+			doc:   doc,
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/upmio/dbscale-kube/pkg/server/handlerrouter"
 	"net"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
@@ -44,6 +45,8 @@ func (e jsonEncoder) Encode(w http.ResponseWriter, code int, v interface{}) erro
 }
 
 type Server struct {
+	// apiVersion 大版本，不为空时只匹配该版本的请求
+	apiVersion  string
 	hosts       []Addr
 	servers     []*_HTTPServer
 	routers     []router.Router
@@ -67,6 +70,20 @@ func (srv *Server) SetEncoder(er encoder) {
 	srv.encoder = er
 }
 
+// SetAPIVersion limits the versioned routes to the major version,
+// such as /v1 and /v1.0 of major 1,requests of other versions such as /v2 are not found.
+func (srv *Server) SetAPIVersion(major string) {
+	srv.apiVersion = major
+}
+
+func (srv *Server) versionMatcher() string {
+	if srv.apiVersion == "" {
+		return versionMatcher
+	}
+
+	return "/v{version:" + regexp.QuoteMeta(srv.apiVersion) + `(?:\.[0-9]+)*}`
+}
+
 func (srv *Server) AddMiddleware(mw middleware.Middleware) {
 	srv.middlewares = append(srv.middlewares, mw)
 }
@@ -75,6 +92,17 @@ func (srv *Server) AddRouter(r router.Router) {
 	srv.routers = append(srv.routers, r)
 }
 
+// Routes returns all routes added by AddRouter.
+func (srv *Server) Routes() []router.Route {
+	var routes []router.Route
+
+	for _, r := range srv.routers {
+		routes = append(routes, r.Routes()...)
+	}
+
+	return routes
+}
+
 func (srv *Server) AddRawRouter(r handlerrouter.HandlerRouter) {
 	srv.rawRouters = append(srv.rawRouters, r)
 }
@@ -95,14 +123,16 @@ func (srv *Server) createMux() {
 	lmt := tollbooth.NewLimiter(20, nil)
 	lmt.SetTokenBucketExpirationTTL(time.Minute * 30)
 
+	prefix := srv.versionMatcher()
+
 	for _, apiRouter := range srv.routers {
 		for _, r := range apiRouter.Routes() {
-			f := srv.makeHTTPHandler(r.Handler())
+			f := instrumentHandler(r.Method(), r.Path(), srv.makeHTTPHandler(r.Handler()))
 
-			m.Path(versionMatcher + r.Path()).Methods(r.Method()).Handler(tollbooth.LimitFuncHandler(lmt, f))
+			m.Path(prefix + r.Path()).Methods(r.Method()).Handler(tollbooth.LimitFuncHandler(lmt, f))
 			m.Path(r.Path()).Methods(r.Method()).Handler(tollbooth.LimitFuncHandler(lmt, f))
 
-			klog.Infof("Router %s %s", r.Method(), versionMatcher+r.Path())
+			klog.Infof("Router %s %s", r.Method(), prefix+r.Path())
 		}
 	}
 
@@ -140,6 +170,11 @@ func (srv *Server) makeHTTPHandler(handler router.HandlerFunc) http.HandlerFunc
 			return
 		}
 
+		if raw, ok := out.(RawResponse); ok {
+			raw.write(w, code)
+			return
+		}
+
 		if out != nil {
 			srv.encoder.Encode(w, code, out)
 			return
@@ -149,6 +184,24 @@ func (srv *Server) makeHTTPHandler(handler router.HandlerFunc) http.HandlerFunc
 	}
 }
 
+// RawResponse 非json的响应，如导出的CSV文件
+type RawResponse struct {
+	ContentType string
+	// 不为空时作为附件下载
+	Filename string
+	Body     []byte
+}
+
+func (raw RawResponse) write(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", raw.ContentType)
+	if raw.Filename != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", raw.Filename))
+	}
+
+	w.WriteHeader(code)
+	w.Write(raw.Body)
+}
+
 type ErrorResponse struct {
 	Code  int    `json:"code"`
 	Error string `json:"msg"`
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/upmio/dbscale-kube/pkg/server/router"
+)
+
+type testRouter []router.Route
+
+func (r testRouter) Routes() []router.Route {
+	return r
+}
+
+func TestAPIVersion(t *testing.T) {
+	ok := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		return http.StatusOK, nil, nil
+	}
+
+	cases := []struct {
+		version string
+		path    string
+		code    int
+	}{
+		{"", "/v1/manager/sites", http.StatusOK},
+		{"", "/v2.1/manager/sites", http.StatusOK},
+		{"1", "/manager/sites", http.StatusOK},
+		{"1", "/v1/manager/sites", http.StatusOK},
+		{"1", "/v1.0/manager/sites", http.StatusOK},
+		{"1", "/v2/manager/sites", http.StatusNotFound},
+		{"1", "/v9.9/manager/sites", http.StatusNotFound},
+		{"1", "/v11/manager/sites", http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		srv := NewServer(nil, nil)
+		srv.SetAPIVersion(c.version)
+		srv.AddRouter(testRouter{router.NewGetRoute("/manager/sites", ok)})
+		srv.createMux()
+
+		w := httptest.NewRecorder()
+		srv.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+		if w.Code != c.code {
+			t.Errorf("version %q %s: expect %d,got %d", c.version, c.path, c.code, w.Code)
+		}
+	}
+}
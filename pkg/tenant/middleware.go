@@ -0,0 +1,107 @@
+package tenant
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Header 请求头，携带时请求只能访问该订阅的资源
+	Header = "X-DBScale-Subscription"
+	// QueryKey 各接口按订阅过滤的 query 参数
+	QueryKey = "subscription_id"
+	// AdminHeader 管理员令牌请求头，令牌正确时可不携带订阅请求头访问所有订阅的资源
+	AdminHeader = "X-DBScale-Admin-Token"
+)
+
+// Store checks the subscriptions.
+type Store interface {
+	// Enabled returns false if the subscription doesn't exist or is disabled.
+	Enabled(id string) (bool, error)
+}
+
+type subscriptionKey struct{}
+
+// WithSubscription returns a copy of ctx carrying the subscription of caller.
+func WithSubscription(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, subscriptionKey{}, id)
+}
+
+// FromContext returns the subscription of caller,
+// empty if the request is not scoped to a subscription.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, _ := ctx.Value(subscriptionKey{}).(string)
+
+	return id
+}
+
+// Middleware 携带订阅请求头的请求限定在该订阅内，
+// 覆盖 query 参数 subscription_id，使列表及服务的订阅检查自动按订阅过滤，
+// query 参数与请求头不一致或订阅不可用时返回403。
+// 路径属于 Required 的请求必须携带订阅请求头，否则返回403，
+// 只有携带正确管理员令牌的请求(如站点 operator)可以不携带，未配置 AdminToken 时不能绕过。
+type Middleware struct {
+	Store Store
+	// Required 必须携带请求头的路径前缀
+	Required []string
+	// AdminToken 管理员令牌，为空表示不允许绕过
+	AdminToken string
+}
+
+func (m Middleware) required(path string) bool {
+	for _, prefix := range m.Required {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m Middleware) admin(r *http.Request) bool {
+	token := r.Header.Get(AdminHeader)
+
+	return m.AdminToken != "" && token != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(m.AdminToken)) == 1
+}
+
+func (m Middleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error)) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		id := r.Header.Get(Header)
+		if id == "" {
+			if m.required(r.URL.Path) && !m.admin(r) {
+				return http.StatusForbidden, nil, fmt.Errorf("%s is required", Header)
+			}
+
+			return handler(ctx, w, r, vars)
+		}
+
+		query := r.URL.Query()
+
+		if v := query.Get(QueryKey); v != "" && v != id {
+			return http.StatusForbidden, nil, fmt.Errorf("%s %s is not the subscription of %s", QueryKey, v, Header)
+		}
+
+		ok, err := m.Store.Enabled(id)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		if !ok {
+			return http.StatusForbidden, nil, fmt.Errorf("subscription %s is not available", id)
+		}
+
+		query.Set(QueryKey, id)
+		r.URL.RawQuery = query.Encode()
+		// FormValue 重新解析 query
+		r.Form = nil
+
+		return handler(WithSubscription(ctx, id), w, r, vars)
+	}
+}
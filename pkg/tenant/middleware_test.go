@@ -0,0 +1,130 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeStore map[string]bool
+
+func (s fakeStore) Enabled(id string) (bool, error) {
+	return s[id], nil
+}
+
+func TestMiddleware(t *testing.T) {
+	mw := Middleware{Store: fakeStore{"t1": true, "t2": false}}
+
+	var (
+		query  string
+		tenant string
+	)
+
+	handler := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		query = r.FormValue(QueryKey)
+		tenant = FromContext(ctx)
+
+		return http.StatusOK, nil, nil
+	})
+
+	cases := []struct {
+		header string
+		url    string
+		code   int
+		query  string
+	}{
+		{"", "/manager/apps?subscription_id=t2", http.StatusOK, "t2"},
+		{"t1", "/manager/apps", http.StatusOK, "t1"},
+		{"t1", "/manager/apps?subscription_id=t1&name=a", http.StatusOK, "t1"},
+		{"t1", "/manager/apps?subscription_id=t2", http.StatusForbidden, ""},
+		{"t2", "/manager/apps", http.StatusForbidden, ""},
+		{"t3", "/manager/apps", http.StatusForbidden, ""},
+	}
+
+	for _, c := range cases {
+		query, tenant = "", ""
+
+		r := httptest.NewRequest(http.MethodGet, c.url, nil)
+		if c.header != "" {
+			r.Header.Set(Header, c.header)
+		}
+
+		// 模拟已被前面的中间件解析过的请求
+		r.ParseForm()
+
+		code, _, _ := handler(context.Background(), httptest.NewRecorder(), r, nil)
+		if code != c.code || query != c.query {
+			t.Errorf("%s %s:expected %d %q but got %d %q", c.header, c.url, c.code, c.query, code, query)
+		}
+
+		if code == http.StatusOK && tenant != c.header {
+			t.Errorf("%s %s:unexpected subscription in context %q", c.header, c.url, tenant)
+		}
+	}
+}
+
+func TestMiddlewareRequired(t *testing.T) {
+	mw := Middleware{
+		Store:      fakeStore{"t1": true},
+		Required:   []string{"/manager/apps", "/manager/backup/files"},
+		AdminToken: "secret",
+	}
+
+	handler := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		return http.StatusOK, nil, nil
+	})
+
+	cases := []struct {
+		header string
+		token  string
+		url    string
+		code   int
+	}{
+		{"", "", "/manager/apps", http.StatusForbidden},
+		{"", "", "/manager/apps/app1/image?subscription_id=t1", http.StatusForbidden},
+		{"", "", "/manager/backup/files?app_id=app1", http.StatusForbidden},
+		{"", "wrong", "/manager/apps", http.StatusForbidden},
+		{"", "secret", "/manager/apps", http.StatusOK},
+		{"", "", "/manager/appsx", http.StatusOK},
+		{"", "", "/manager/backup/endpoint", http.StatusOK},
+		{"t1", "", "/manager/apps/app1/image", http.StatusOK},
+		{"t2", "secret", "/manager/apps", http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPut, c.url, nil)
+		if c.header != "" {
+			r.Header.Set(Header, c.header)
+		}
+		if c.token != "" {
+			r.Header.Set(AdminHeader, c.token)
+		}
+
+		code, _, _ := handler(context.Background(), httptest.NewRecorder(), r, nil)
+		if code != c.code {
+			t.Errorf("%s %s %s:expected %d but got %d", c.header, c.token, c.url, c.code, code)
+		}
+	}
+}
+
+func TestMiddlewareNoAdminToken(t *testing.T) {
+	mw := Middleware{
+		Store:    fakeStore{"t1": true},
+		Required: []string{"/manager/apps"},
+	}
+
+	handler := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) (int, interface{}, error) {
+		return http.StatusOK, nil, nil
+	})
+
+	for _, token := range []string{"", "secret"} {
+		r := httptest.NewRequest(http.MethodGet, "/manager/apps", nil)
+		r.Header.Set(AdminHeader, token)
+
+		code, _, _ := handler(context.Background(), httptest.NewRecorder(), r, nil)
+		if code != http.StatusForbidden {
+			t.Errorf("%q:expected %d but got %d", token, http.StatusForbidden, code)
+		}
+	}
+}
@@ -15,7 +15,7 @@ func maskSecretKeys(inp interface{}) {
 	if form, ok := inp.(map[string]interface{}); ok {
 	loop0:
 		for k, v := range form {
-			for _, m := range []string{"password", "secret", "jointoken", "unlockkey", "signingcakey"} {
+			for _, m := range []string{"password", "pwd", "secret", "jointoken", "unlockkey", "signingcakey"} {
 				if strings.EqualFold(m, k) {
 					form[k] = "*****"
 					continue loop0
@@ -37,3 +37,21 @@ func MaskJsonSecret(in []byte) ([]byte, error) {
 
 	return json.Marshal(form)
 }
+
+// MaskSecret encodes v to json with the secret fields masked,v could be a struct,map or slice.
+func MaskSecret(v interface{}) ([]byte, error) {
+	in, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var form interface{}
+
+	if err := json.Unmarshal(in, &form); err != nil {
+		return nil, err
+	}
+
+	maskSecretKeys(form)
+
+	return json.Marshal(form)
+}
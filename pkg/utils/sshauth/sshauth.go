@@ -0,0 +1,331 @@
+package sshauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Secret中的私钥，与kubernetes.io/ssh-auth类型的Secret一致
+	PrivateKeyKey = corev1.SSHAuthPrivateKey
+	// Secret中的sudo密码，sudo_mode为password时使用
+	SudoPasswordKey = "sudo-password"
+
+	SudoNoPasswd = "nopasswd"
+	SudoPassword = "password"
+
+	defaultBastionPort = 22
+)
+
+// Credential 主机ssh认证信息
+type Credential struct {
+	User string
+	// 兼容旧的密码认证，PrivateKey为空时使用
+	Password     string
+	PrivateKey   []byte
+	SudoPassword string
+	// authorized_keys格式，为空时不校验主机公钥
+	HostKey string
+
+	Bastion *Bastion
+}
+
+// Bastion 跳板机
+type Bastion struct {
+	Addr       string
+	User       string
+	PrivateKey []byte
+	HostKey    string
+}
+
+// Valid checks the spec without reading the secrets.
+func Valid(spec hostv1.SSHSpec) error {
+	if spec.SecretName == "" {
+		return xerrors.New("ssh secret is required")
+	}
+
+	switch spec.SudoMode {
+	case "", SudoNoPasswd, SudoPassword:
+	default:
+		return xerrors.Errorf("unsupported sudo mode '%s'", spec.SudoMode)
+	}
+
+	if _, err := parseHostKey(spec.HostKey); err != nil {
+		return err
+	}
+
+	if b := spec.Bastion; b != nil {
+		if b.Host == "" || b.User == "" {
+			return xerrors.New("bastion host and user are required")
+		}
+
+		if _, err := parseHostKey(b.HostKey); err != nil {
+			return xerrors.Errorf("bastion %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Load resolves the private keys and sudo password referenced by spec from secrets.
+func Load(ctx context.Context, client kubernetes.Interface, user string, spec hostv1.SSHSpec) (Credential, error) {
+	if err := Valid(spec); err != nil {
+		return Credential{}, err
+	}
+
+	namespace := spec.SecretNamespace
+	if namespace == "" {
+		namespace = metav1.NamespaceSystem
+	}
+
+	data, err := secretData(ctx, client, namespace, spec.SecretName)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	cred := Credential{
+		User:       user,
+		PrivateKey: data[PrivateKeyKey],
+		HostKey:    spec.HostKey,
+	}
+
+	if len(cred.PrivateKey) == 0 {
+		return Credential{}, xerrors.Errorf("secret %s/%s: %s is required", namespace, spec.SecretName, PrivateKeyKey)
+	}
+
+	if spec.SudoMode == SudoPassword {
+		cred.SudoPassword = string(data[SudoPasswordKey])
+		if cred.SudoPassword == "" {
+			return Credential{}, xerrors.Errorf("secret %s/%s: %s is required by sudo mode %s", namespace, spec.SecretName, SudoPasswordKey, SudoPassword)
+		}
+	}
+
+	if b := spec.Bastion; b != nil {
+		port := b.Port
+		if port <= 0 {
+			port = defaultBastionPort
+		}
+
+		cred.Bastion = &Bastion{
+			Addr:       net.JoinHostPort(b.Host, strconv.Itoa(int(port))),
+			User:       b.User,
+			PrivateKey: cred.PrivateKey,
+			HostKey:    b.HostKey,
+		}
+
+		if b.SecretName != "" && b.SecretName != spec.SecretName {
+			bdata, err := secretData(ctx, client, namespace, b.SecretName)
+			if err != nil {
+				return Credential{}, err
+			}
+
+			cred.Bastion.PrivateKey = bdata[PrivateKeyKey]
+			if len(cred.Bastion.PrivateKey) == 0 {
+				return Credential{}, xerrors.Errorf("secret %s/%s: %s is required", namespace, b.SecretName, PrivateKeyKey)
+			}
+		}
+	}
+
+	return cred, nil
+}
+
+func secretData(ctx context.Context, client kubernetes.Interface, namespace, name string) (map[string][]byte, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, xerrors.Errorf("get ssh secret %s/%s:%w", namespace, name, err)
+	}
+
+	return secret.Data, nil
+}
+
+func parseHostKey(key string) (ssh.PublicKey, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return nil, xerrors.Errorf("parse host key:%w", err)
+	}
+
+	return pk, nil
+}
+
+func clientConfig(user, password string, privateKey []byte, hostKey string, timeout time.Duration) (*ssh.ClientConfig, error) {
+	config := &ssh.ClientConfig{
+		User:            user,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         timeout,
+	}
+
+	if len(privateKey) > 0 {
+		signer, err := ssh.ParsePrivateKey(privateKey)
+		if err != nil {
+			return nil, xerrors.Errorf("parse private key:%w", err)
+		}
+
+		config.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	} else {
+		config.Auth = []ssh.AuthMethod{ssh.Password(password)}
+	}
+
+	pk, err := parseHostKey(hostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if pk != nil {
+		config.HostKeyCallback = ssh.FixedHostKey(pk)
+	}
+
+	return config, nil
+}
+
+// Verify connects to addr(through the bastion if set) and checks the authentication.
+func (c Credential) Verify(addr string, timeout time.Duration) error {
+	config, err := clientConfig(c.User, c.Password, c.PrivateKey, c.HostKey, timeout)
+	if err != nil {
+		return err
+	}
+
+	if c.Bastion == nil {
+		client, err := ssh.Dial("tcp", addr, config)
+		if err != nil {
+			return err
+		}
+
+		return client.Close()
+	}
+
+	bconfig, err := clientConfig(c.Bastion.User, "", c.Bastion.PrivateKey, c.Bastion.HostKey, timeout)
+	if err != nil {
+		return err
+	}
+
+	bastion, err := ssh.Dial("tcp", c.Bastion.Addr, bconfig)
+	if err != nil {
+		return xerrors.Errorf("bastion %s:%w", c.Bastion.Addr, err)
+	}
+	defer bastion.Close()
+
+	conn, err := bastion.Dial("tcp", addr)
+	if err != nil {
+		return xerrors.Errorf("bastion %s dial %s:%w", c.Bastion.Addr, addr, err)
+	}
+	defer conn.Close()
+
+	cc, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		return err
+	}
+
+	return ssh.NewClient(cc, chans, reqs).Close()
+}
+
+// AnsibleVars 传给host-init(ansible)的ssh参数，不包含私钥及sudo密码内容
+type AnsibleVars struct {
+	PrivateKeyFile  string `json:"ssh_private_key_file,omitempty"`
+	CommonArgs      string `json:"ssh_common_args,omitempty"`
+	HostKeyChecking bool   `json:"host_key_checking"`
+	// sudo密码文件 {"become_password": "..."}，由host-init以 --extra-vars @file 传给ansible
+	BecomePasswordFile string `json:"become_password_file,omitempty"`
+}
+
+// WriteAnsibleFiles writes the private keys, sudo password and known_hosts into dir for ansible,
+// dir should be removed by the caller when ansible finished.
+func (c Credential) WriteAnsibleFiles(dir, addr string) (AnsibleVars, error) {
+	vars := AnsibleVars{}
+
+	if c.SudoPassword != "" {
+		data, err := json.Marshal(map[string]string{"become_password": c.SudoPassword})
+		if err != nil {
+			return vars, err
+		}
+
+		vars.BecomePasswordFile = filepath.Join(dir, "become.json")
+		if err := ioutil.WriteFile(vars.BecomePasswordFile, data, 0600); err != nil {
+			return vars, err
+		}
+	}
+
+	if len(c.PrivateKey) > 0 {
+		vars.PrivateKeyFile = filepath.Join(dir, "id_host")
+		if err := ioutil.WriteFile(vars.PrivateKeyFile, c.PrivateKey, 0600); err != nil {
+			return vars, err
+		}
+	}
+
+	knownHosts := []string{}
+	args := []string{}
+
+	pk, err := parseHostKey(c.HostKey)
+	if err != nil {
+		return vars, err
+	}
+
+	if pk != nil {
+		knownHosts = append(knownHosts, knownhosts.Line([]string{knownhosts.Normalize(addr)}, pk))
+		vars.HostKeyChecking = true
+	}
+
+	if c.Bastion != nil {
+		bpk, err := parseHostKey(c.Bastion.HostKey)
+		if err != nil {
+			return vars, err
+		}
+
+		if bpk != nil {
+			knownHosts = append(knownHosts, knownhosts.Line([]string{knownhosts.Normalize(c.Bastion.Addr)}, bpk))
+		}
+	}
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	if len(knownHosts) > 0 {
+		if err := ioutil.WriteFile(knownHostsFile, []byte(strings.Join(knownHosts, "\n")+"\n"), 0600); err != nil {
+			return vars, err
+		}
+	}
+
+	if vars.HostKeyChecking {
+		args = append(args, "-o StrictHostKeyChecking=yes", "-o UserKnownHostsFile="+knownHostsFile)
+	}
+
+	if b := c.Bastion; b != nil {
+		keyFile := filepath.Join(dir, "id_bastion")
+		if err := ioutil.WriteFile(keyFile, b.PrivateKey, 0600); err != nil {
+			return vars, err
+		}
+
+		host, port, err := net.SplitHostPort(b.Addr)
+		if err != nil {
+			return vars, err
+		}
+
+		check := "-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+		if b.HostKey != "" {
+			check = "-o StrictHostKeyChecking=yes -o UserKnownHostsFile=" + knownHostsFile
+		}
+
+		args = append(args, fmt.Sprintf(`-o ProxyCommand="ssh -W %%h:%%p -q -p %s -i %s -o IdentitiesOnly=yes %s %s@%s"`,
+			port, keyFile, check, b.User, host))
+	}
+
+	vars.CommonArgs = strings.Join(args, " ")
+
+	return vars, nil
+}
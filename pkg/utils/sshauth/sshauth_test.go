@@ -0,0 +1,359 @@
+package sshauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	hostv1 "github.com/upmio/dbscale-kube/pkg/apis/host/v1alpha1"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestKey(t *testing.T) ([]byte, ssh.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data, signer
+}
+
+func authorizedKey(signer ssh.Signer) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+}
+
+// newTestServer starts a ssh server accepts the client key only,
+// it forwards direct-tcpip channels so it works as a bastion too.
+func newTestServer(t *testing.T, client ssh.PublicKey) (string, ssh.Signer) {
+	_, host := newTestKey(t)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(client.Marshal()) {
+				return nil, nil
+			}
+
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(host)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveTestConn(conn, config)
+		}
+	}()
+
+	return l.Addr().String(), host
+}
+
+func serveTestConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "direct-tcpip" {
+			nc.Reject(ssh.UnknownChannelType, nc.ChannelType())
+			continue
+		}
+
+		payload := struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}{}
+
+		if err := ssh.Unmarshal(nc.ExtraData(), &payload); err != nil {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		ch, creqs, err := nc.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+
+		go ssh.DiscardRequests(creqs)
+		go func() {
+			io.Copy(ch, target)
+			ch.Close()
+		}()
+		go func() {
+			io.Copy(target, ch)
+			target.Close()
+		}()
+	}
+}
+
+func TestVerifyPrivateKey(t *testing.T) {
+	key, signer := newTestKey(t)
+	other, _ := newTestKey(t)
+
+	addr, _ := newTestServer(t, signer.PublicKey())
+
+	if err := (Credential{User: "root", PrivateKey: key}).Verify(addr, 2*time.Second); err != nil {
+		t.Fatalf("expect key accepted,got %s", err)
+	}
+
+	if err := (Credential{User: "root", PrivateKey: other}).Verify(addr, 2*time.Second); err == nil {
+		t.Fatal("expect unknown key rejected")
+	}
+
+	if err := (Credential{User: "root", PrivateKey: []byte("invalid")}).Verify(addr, 2*time.Second); err == nil {
+		t.Fatal("expect invalid key error")
+	}
+}
+
+func TestVerifyHostKey(t *testing.T) {
+	key, signer := newTestKey(t)
+	_, wrong := newTestKey(t)
+
+	addr, host := newTestServer(t, signer.PublicKey())
+
+	cases := []struct {
+		name    string
+		hostKey string
+		ok      bool
+	}{
+		{name: "not pinned", hostKey: "", ok: true},
+		{name: "pinned", hostKey: authorizedKey(host), ok: true},
+		{name: "mismatch", hostKey: authorizedKey(wrong), ok: false},
+	}
+
+	for _, c := range cases {
+		err := Credential{User: "root", PrivateKey: key, HostKey: c.hostKey}.Verify(addr, 2*time.Second)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: expect ok %t,got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestVerifyBastion(t *testing.T) {
+	key, signer := newTestKey(t)
+	bkey, bsigner := newTestKey(t)
+	_, wrong := newTestKey(t)
+
+	addr, host := newTestServer(t, signer.PublicKey())
+	baddr, bhost := newTestServer(t, bsigner.PublicKey())
+
+	cases := []struct {
+		name    string
+		bastion Bastion
+		hostKey string
+		ok      bool
+	}{
+		{
+			name:    "pinned",
+			bastion: Bastion{Addr: baddr, User: "jump", PrivateKey: bkey, HostKey: authorizedKey(bhost)},
+			hostKey: authorizedKey(host),
+			ok:      true,
+		},
+		{
+			name:    "bastion mismatch",
+			bastion: Bastion{Addr: baddr, User: "jump", PrivateKey: bkey, HostKey: authorizedKey(wrong)},
+			ok:      false,
+		},
+		{
+			name:    "host mismatch",
+			bastion: Bastion{Addr: baddr, User: "jump", PrivateKey: bkey},
+			hostKey: authorizedKey(wrong),
+			ok:      false,
+		},
+		{
+			name:    "bastion key rejected",
+			bastion: Bastion{Addr: baddr, User: "jump", PrivateKey: key},
+			ok:      false,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		cred := Credential{User: "root", PrivateKey: key, HostKey: c.hostKey, Bastion: &c.bastion}
+
+		err := cred.Verify(addr, 2*time.Second)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: expect ok %t,got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: "host"},
+			Data: map[string][]byte{
+				PrivateKeyKey:   []byte("host-key"),
+				SudoPasswordKey: []byte("sudo"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: "jump"},
+			Data:       map[string][]byte{PrivateKeyKey: []byte("jump-key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: "empty"},
+		},
+	)
+
+	spec := hostv1.SSHSpec{
+		SecretName: "host",
+		SudoMode:   SudoPassword,
+		Bastion:    &hostv1.BastionSpec{Host: "10.0.0.1", User: "jump", SecretName: "jump"},
+	}
+
+	cred, err := Load(context.TODO(), client, "root", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(cred.PrivateKey) != "host-key" || cred.SudoPassword != "sudo" {
+		t.Fatalf("unexpected credential %+v", cred)
+	}
+
+	if b := cred.Bastion; b == nil || b.Addr != "10.0.0.1:22" || string(b.PrivateKey) != "jump-key" {
+		t.Fatalf("unexpected bastion %+v", cred.Bastion)
+	}
+
+	spec.Bastion.SecretName = ""
+	cred, err = Load(context.TODO(), client, "root", spec)
+	if err != nil || string(cred.Bastion.PrivateKey) != "host-key" {
+		t.Fatalf("expect bastion uses the host key,got %+v,%v", cred.Bastion, err)
+	}
+
+	if _, err := Load(context.TODO(), client, "root", hostv1.SSHSpec{SecretName: "empty"}); err == nil {
+		t.Fatal("expect private key required")
+	}
+
+	if _, err := Load(context.TODO(), client, "root", hostv1.SSHSpec{SecretName: "host", HostKey: "invalid"}); err == nil {
+		t.Fatal("expect invalid host key error")
+	}
+}
+
+func TestWriteAnsibleFiles(t *testing.T) {
+	_, host := newTestKey(t)
+	_, bhost := newTestKey(t)
+
+	dir, err := ioutil.TempDir("", "sshauth-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cred := Credential{
+		User:         "root",
+		PrivateKey:   []byte("host-key"),
+		SudoPassword: "s3cret",
+		HostKey:      authorizedKey(host),
+		Bastion:      &Bastion{Addr: "10.0.0.1:2222", User: "jump", PrivateKey: []byte("jump-key"), HostKey: authorizedKey(bhost)},
+	}
+
+	vars, err := cred.WriteAnsibleFiles(dir, "10.0.0.2:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatalf("sudo password should not be in the vars:%s", data)
+	}
+
+	become := map[string]string{}
+	if data, err := ioutil.ReadFile(vars.BecomePasswordFile); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(data, &become); err != nil || become["become_password"] != "s3cret" {
+		t.Fatalf("unexpected become password file:%s,%v", data, err)
+	}
+
+	for _, file := range []string{vars.PrivateKeyFile, vars.BecomePasswordFile, filepath.Join(dir, "id_bastion"), filepath.Join(dir, "known_hosts")} {
+		fi, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fi.Mode().Perm() != 0600 {
+			t.Errorf("%s: expect mode 0600,got %s", file, fi.Mode())
+		}
+	}
+
+	knownHosts, err := ioutil.ReadFile(filepath.Join(dir, "known_hosts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(string(knownHosts)), "\n"); len(lines) != 2 ||
+		!strings.HasPrefix(lines[0], "10.0.0.2 ") || !strings.HasPrefix(lines[1], "[10.0.0.1]:2222 ") {
+		t.Fatalf("unexpected known_hosts:%s", knownHosts)
+	}
+
+	if !vars.HostKeyChecking ||
+		!strings.Contains(vars.CommonArgs, "-o StrictHostKeyChecking=yes") ||
+		!strings.Contains(vars.CommonArgs, "-p 2222 -i "+filepath.Join(dir, "id_bastion")) ||
+		!strings.Contains(vars.CommonArgs, "jump@10.0.0.1") {
+		t.Fatalf("unexpected ssh args:%s", vars.CommonArgs)
+	}
+
+	vars, err = Credential{User: "root", PrivateKey: []byte("host-key")}.WriteAnsibleFiles(dir, "10.0.0.2:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vars.HostKeyChecking || vars.CommonArgs != "" || vars.BecomePasswordFile != "" {
+		t.Fatalf("unexpected vars without host key:%+v", vars)
+	}
+}
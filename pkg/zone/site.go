@@ -86,6 +86,17 @@ type CacheLister struct {
 	//hostSynced cache.InformerSynced
 }
 
+// HasSynced returns true if all the informers of cachelister have synced.
+func (c *CacheLister) HasSynced() bool {
+	for _, synced := range []cache.InformerSynced{c.networkClaimSynced, c.unitSynced, c.podSynced, c.volumePathSynced, c.nodeSynced} {
+		if synced == nil || !synced() {
+			return false
+		}
+	}
+
+	return true
+}
+
 func NewK8sSite(name, domain, execAddr, path string, port int) *k8sSite {
 	master := fmt.Sprintf("https://%s:%d", domain, port)
 
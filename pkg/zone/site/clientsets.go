@@ -3,10 +3,12 @@ package site
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"k8s.io/apimachinery/pkg/runtime"
 	restclient "k8s.io/client-go/rest"
+	"strings"
 	"sync"
 	"time"
 
@@ -99,6 +101,8 @@ type clientset struct {
 	roleBinding        *roleBindingClientset
 
 	serviceMonitor *serviceMonitorClientset
+	prometheusRule *prometheusRuleClientset
+	alert          *prometheusAlertClientset
 	storageClass   *storageClassClientset
 }
 
@@ -155,6 +159,8 @@ func NewClientset(
 		set.roleBinding = NewRoleBindingClientset(kubeClient)
 
 		set.storageClass = NewStorageClassClientset(kubeClient)
+
+		set.alert = NewPrometheusAlertClientset(kubeClient)
 	}
 
 	if execAddr != "" {
@@ -193,6 +199,7 @@ func NewClientset(
 
 	if monitorV1Client != nil {
 		set.serviceMonitor = NewServiceMonitorClientset(monitorV1Client)
+		set.prometheusRule = NewPrometheusRuleClientset(monitorV1Client)
 	}
 
 	return set
@@ -286,6 +293,14 @@ func (set *clientset) ServiceMonitor() ServiceMonitorInterface {
 	return set.serviceMonitor
 }
 
+func (set *clientset) PrometheusRules() PrometheusRuleInterface {
+	return set.prometheusRule
+}
+
+func (set *clientset) PrometheusAlerts() PrometheusAlertInterface {
+	return set.alert
+}
+
 func (set *clientset) ServiceAccounts() ServiceAccountInterface {
 	return set.serviceAccount
 }
@@ -495,6 +510,19 @@ func (client *imageDeployExecClient) DeployImage(req execapi.DeployImageOption)
 	return nil
 }
 
+func (client *imageDeployExecClient) InspectImage(req execapi.DeployImageOption) (execapi.InspectImageResponse, error) {
+	resp, err := client.client.InspectImage(context.Background(), req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.Errors != "" {
+		return resp, fmt.Errorf("InspectImage %s/%s/%s:%s-%s err: %s", req.ImageRegistry, req.ProjectName, req.Type, req.Version, req.Arch, resp.Errors)
+	}
+
+	return resp, nil
+}
+
 type hostLegalizeExecClient struct {
 	client execapi.ExecClient
 }
@@ -809,6 +837,77 @@ func (client *serviceMonitorClientset) Delete(namespace string, name string) err
 	return client.client.ServiceMonitors(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 }
 
+var _ PrometheusRuleInterface = &prometheusRuleClientset{}
+
+type prometheusRuleClientset struct {
+	client serviceMonitor.MonitoringV1Interface
+}
+
+func NewPrometheusRuleClientset(client serviceMonitor.MonitoringV1Interface) *prometheusRuleClientset {
+	return &prometheusRuleClientset{
+		client: client,
+	}
+}
+
+func (client *prometheusRuleClientset) Get(namespace string, name string) (*serviceMonitorv1.PrometheusRule, error) {
+	return client.client.PrometheusRules(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (client *prometheusRuleClientset) Create(namespace string, rule *serviceMonitorv1.PrometheusRule) (*serviceMonitorv1.PrometheusRule, error) {
+	return client.client.PrometheusRules(namespace).Create(context.TODO(), rule, metav1.CreateOptions{})
+}
+
+func (client *prometheusRuleClientset) Update(namespace string, rule *serviceMonitorv1.PrometheusRule) (*serviceMonitorv1.PrometheusRule, error) {
+	return client.client.PrometheusRules(namespace).Update(context.TODO(), rule, metav1.UpdateOptions{})
+}
+
+func (client *prometheusRuleClientset) Delete(namespace string, name string) error {
+	return client.client.PrometheusRules(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+var _ PrometheusAlertInterface = &prometheusAlertClientset{}
+
+type prometheusAlertClientset struct {
+	client kubernetes.Interface
+}
+
+func NewPrometheusAlertClientset(client kubernetes.Interface) *prometheusAlertClientset {
+	return &prometheusAlertClientset{
+		client: client,
+	}
+}
+
+func (client *prometheusAlertClientset) List(namespace, service string) ([]PrometheusAlert, error) {
+	name, port := service, ""
+	if i := strings.LastIndex(service, ":"); i > 0 {
+		name, port = service[:i], service[i+1:]
+	}
+
+	data, err := client.client.CoreV1().Services(namespace).
+		ProxyGet("http", name, port, "api/v1/alerts", nil).DoRaw(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			Alerts []PrometheusAlert `json:"alerts"`
+		} `json:"data"`
+	}{}
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("prometheus %s/%s alerts:%s", namespace, service, resp.Error)
+	}
+
+	return resp.Data.Alerts, nil
+}
+
 var _ ServiceAccountInterface = &serviceAccountClientset{}
 
 type serviceAccountClientset struct {
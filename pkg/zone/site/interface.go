@@ -2,6 +2,7 @@ package site
 
 import (
 	"io"
+	"time"
 
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -64,6 +65,8 @@ type Interface interface {
 	StorageClass() StorageClassInterface
 
 	ServiceMonitor() ServiceMonitorInterface
+	PrometheusRules() PrometheusRuleInterface
+	PrometheusAlerts() PrometheusAlertInterface
 }
 
 type EventInterface interface {
@@ -91,6 +94,8 @@ type PodExecInterface interface {
 
 type ImageDeployExecInterface interface {
 	DeployImage(execapi.DeployImageOption) error
+	// InspectImage returns the digest and signatures of the image in site registry
+	InspectImage(execapi.DeployImageOption) (execapi.InspectImageResponse, error)
 }
 
 type HostLegalizeInterface interface {
@@ -185,6 +190,30 @@ type ServiceMonitorInterface interface {
 	Delete(namespace string, name string) error
 }
 
+// PrometheusRuleInterface has methods to work with PrometheusRule resources.
+type PrometheusRuleInterface interface {
+	Get(namespace string, name string) (*serviceMonitorv1.PrometheusRule, error)
+	Create(namespace string, rule *serviceMonitorv1.PrometheusRule) (*serviceMonitorv1.PrometheusRule, error)
+	Update(namespace string, rule *serviceMonitorv1.PrometheusRule) (*serviceMonitorv1.PrometheusRule, error)
+	Delete(namespace string, name string) error
+}
+
+// PrometheusAlertInterface reads the active alerts from prometheus through the service proxy of kube-apiserver.
+type PrometheusAlertInterface interface {
+	// service is "name:port" of the prometheus service
+	List(namespace, service string) ([]PrometheusAlert, error)
+}
+
+// PrometheusAlert is the alert returned by prometheus /api/v1/alerts.
+type PrometheusAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	// pending or firing
+	State    string    `json:"state"`
+	ActiveAt time.Time `json:"activeAt"`
+	Value    string    `json:"value"`
+}
+
 // ServiceAccountInterface has methods to work with ServiceAccount resources.
 type ServiceAccountInterface interface {
 	Create(namespace string, serviceAccount *v1.ServiceAccount) (*v1.ServiceAccount, error)